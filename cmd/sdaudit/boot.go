@@ -0,0 +1,450 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+var bootCmd = &cobra.Command{
+	Use:   "boot",
+	Short: "Analyze boot time",
+	Long: `Analyze systemd boot time using systemd-analyze and critical-chain.
+
+Unit start times are the median over the last --journal-boots boots, measured
+from the journal. When the journal has no start times, the single-boot
+numbers from systemd-analyze blame are used instead.
+
+With --timing timestamps, start times are the units' activation intervals in
+this boot, read with systemctl show, which are precise and include the units
+blame leaves out. Units activating at the same time share the wall-clock time
+they take, and slow units are judged by their share, so a unit that started
+alongside many others is not blamed for the whole of its start time.
+
+The critical path to the boot target is also computed from the unit files,
+with each unit counted at its observed start time, and set against the chain
+systemd reported. Its worst case, with each unit at its TimeoutStartSec=, is
+shown beside it.`,
+	RunE: runBoot,
+}
+
+func init() {
+	bootCmd.Flags().String("timing", "auto", "Unit start time source: auto, journal, blame, timestamps")
+	bootCmd.Flags().Int("journal-boots", 5, "Number of boots to measure start times over")
+	bootCmd.Flags().Int("history", 0, "Compare against the last N saved boots")
+	bootCmd.Flags().Bool("save", false, "Save this boot's timing to the history directory")
+	bootCmd.Flags().String("history-dir", audit.DefaultBootHistoryDir, "Directory holding saved boot timings")
+	bootCmd.Flags().Float64("regression-percent", 20, "Flag units whose start time grew by at least this percentage")
+	bootCmd.Flags().Duration("regression-min", 2*time.Second, "Flag units whose start time grew by at least this much")
+	rootCmd.AddCommand(bootCmd)
+}
+
+func runBoot(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+
+	timing, _ := cmd.Flags().GetString("timing")
+	boots, _ := cmd.Flags().GetInt("journal-boots")
+
+	var opts audit.BootOptions
+	switch timing {
+	case "auto":
+		opts = audit.BootOptions{Journal: true, Boots: boots, Fallback: true}
+	case "journal":
+		opts = audit.BootOptions{Journal: true, Boots: boots}
+	case "blame":
+	case "timestamps":
+		opts = audit.BootOptions{Timestamps: true}
+	default:
+		return fmt.Errorf("unknown timing source %q (use auto, journal, blame or timestamps)", timing)
+	}
+	f, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if f != nil {
+		opts.SlowUnit, opts.SlowUserspace = f.BootThresholds()
+	}
+
+	analysis, err := audit.AnalyzeBoot(cmd.Context(), opts)
+	if err != nil {
+		return fmt.Errorf("boot analysis failed: %w", err)
+	}
+
+	history, _ := cmd.Flags().GetInt("history")
+	save, _ := cmd.Flags().GetBool("save")
+	if history > 0 || save {
+		return runBootHistory(cmd, analysis, history, save, format)
+	}
+
+	if err := correlateBootPath(cmd, analysis); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; leaving out the predicted critical path\n", err)
+	}
+	issues, err := bootFindings(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; reporting units without findings\n", err)
+	}
+	analysis.Correlate(issues, opts)
+
+	switch format {
+	case "json":
+		return outputBootJSON(analysis)
+	default:
+		return outputBootText(analysis, outputStyle(cmd))
+	}
+}
+
+// correlateBootPath computes the critical path the unit files of this system
+// predict for the boot analyzed
+func correlateBootPath(cmd *cobra.Command, analysis *audit.BootAnalysis) error {
+	units, err := audit.LoadSystemUnits(cmd.Context(), "")
+	if err != nil {
+		return err
+	}
+	t, err := audit.NewTiming(units, "")
+	if err != nil {
+		return err
+	}
+	t.PredictBootPath(analysis)
+	return nil
+}
+
+// bootFindings scans the units of this system for the boot report
+func bootFindings(cmd *cobra.Command) ([]types.Issue, error) {
+	opts := audit.Options{IncludeRuntime: true}
+	if err := applyConfig(cmd, &opts); err != nil {
+		return nil, err
+	}
+	sdVersion, err := systemdVersion(cmd)
+	if err != nil {
+		return nil, err
+	}
+	opts.SystemdVersion = sdVersion
+	result, err := audit.Scan(cmd.Context(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+	return result.Issues, nil
+}
+
+func runBootHistory(cmd *cobra.Command, analysis *audit.BootAnalysis, limit int, save bool, format string) error {
+	dir, _ := cmd.Flags().GetString("history-dir")
+	percent, _ := cmd.Flags().GetFloat64("regression-percent")
+	minDelta, _ := cmd.Flags().GetDuration("regression-min")
+
+	bootID, err := audit.CurrentBootID()
+	if err != nil {
+		return fmt.Errorf("failed to read boot ID: %w", err)
+	}
+	current := analysis.Snapshot(bootID, time.Now())
+
+	if save {
+		if err := audit.SaveBootSnapshot(dir, current); err != nil {
+			return fmt.Errorf("failed to save boot timing: %w", err)
+		}
+		if limit == 0 {
+			fmt.Fprintf(os.Stderr, "Saved boot %s to %s\n", bootID, dir)
+			return nil
+		}
+	}
+
+	previous, err := audit.LoadBootSnapshots(dir)
+	if err != nil {
+		return fmt.Errorf("failed to load boot history: %w", err)
+	}
+
+	history := audit.CompareBoots(current, previous, limit, audit.RegressionThresholds{Percent: percent, Absolute: minDelta})
+
+	switch format {
+	case "json":
+		return outputBootHistoryJSON(history)
+	default:
+		return outputBootHistoryText(history, dir, outputStyle(cmd))
+	}
+}
+
+func outputBootHistoryJSON(history *audit.BootHistory) error {
+	type jsonBoot struct {
+		BootID        string    `json:"boot_id"`
+		Time          time.Time `json:"time"`
+		TotalTime     string    `json:"total_time"`
+		UserspaceTime string    `json:"userspace_time"`
+	}
+	type jsonRegression struct {
+		Unit     string  `json:"unit"`
+		Baseline float64 `json:"baseline_seconds"`
+		Current  float64 `json:"current_seconds"`
+		Delta    float64 `json:"delta_seconds"`
+		Percent  float64 `json:"percent"`
+	}
+
+	output := struct {
+		Boots       []jsonBoot            `json:"boots"`
+		Series      map[string][]*float64 `json:"series"`
+		Regressions []jsonRegression      `json:"regressions"`
+	}{
+		Series:      make(map[string][]*float64),
+		Regressions: []jsonRegression{},
+	}
+
+	for _, b := range history.Boots {
+		output.Boots = append(output.Boots, jsonBoot{BootID: b.BootID, Time: b.Time, TotalTime: b.TotalTime.String(), UserspaceTime: b.UserspaceTime.String()})
+	}
+	// Series are in seconds, null where the unit did not start in that boot
+	for unit, series := range history.Series {
+		values := make([]*float64, len(series))
+		for i, d := range series {
+			if d != nil {
+				secs := d.Seconds()
+				values[i] = &secs
+			}
+		}
+		output.Series[unit] = values
+	}
+	for _, r := range history.Regressions {
+		output.Regressions = append(output.Regressions, jsonRegression{Unit: r.Unit, Baseline: r.Baseline.Seconds(), Current: r.Current.Seconds(), Delta: r.Delta.Seconds(), Percent: r.Percent})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+func outputBootHistoryText(history *audit.BootHistory, dir string, p style.Provider) error {
+	printSection(p, 1, "Boot History")
+
+	if len(history.Boots) < 2 {
+		fmt.Printf("\nNo earlier boots saved in %s; run 'sdaudit boot --save' after each boot.\n\n", dir)
+		return nil
+	}
+
+	fmt.Println()
+	for i, b := range history.Boots {
+		label := b.Time.Format("2006-01-02 15:04")
+		if i == len(history.Boots)-1 {
+			label = "current"
+		}
+		fmt.Printf("  %-16s  total %-10s userspace %s\n", label, b.TotalTime, b.UserspaceTime)
+	}
+
+	if len(history.Regressions) == 0 {
+		fmt.Println("\nNo units regressed compared to earlier boots.")
+		fmt.Println()
+		return nil
+	}
+
+	printSection(p, 2, "Regressions (current vs median of earlier boots)")
+	for _, r := range history.Regressions {
+		fmt.Printf("  +%-9s %9s -> %-9s (%+.0f%%)  %s\n", r.Delta.Round(time.Millisecond), r.Baseline.Round(time.Millisecond), r.Current.Round(time.Millisecond), r.Percent, r.Unit)
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func outputBootJSON(analysis *audit.BootAnalysis) error {
+	type JSONBootOutput struct {
+		Version       int                    `json:"version"`
+		TotalTime     string                 `json:"total_time"`
+		KernelTime    string                 `json:"kernel_time"`
+		InitrdTime    string                 `json:"initrd_time"`
+		UserspaceTime string                 `json:"userspace_time"`
+		ReachedTarget string                 `json:"reached_target,omitempty"`
+		TargetReached string                 `json:"target_reached_time,omitempty"`
+		TimingSource  string                 `json:"timing_source"`
+		TimingBoots   int                    `json:"timing_boots,omitempty"`
+		Parallelism   float64                `json:"parallelism,omitempty"`
+		TopUnits      []audit.UnitTiming     `json:"top_units"`
+		CriticalChain []audit.ChainLink      `json:"critical_chain"`
+		PredictedPath *audit.BootPath        `json:"predicted_path,omitempty"`
+		Units         []audit.BootUnitReport `json:"units"`
+		Issues        []audit.BootIssue      `json:"issues"`
+	}
+
+	// Get top 10 slowest units
+	topUnits := analysis.Units
+	if len(topUnits) > 10 {
+		topUnits = topUnits[:10]
+	}
+
+	output := JSONBootOutput{
+		Version:       audit.BootJSONVersion,
+		TotalTime:     analysis.TotalTime.String(),
+		KernelTime:    analysis.KernelTime.String(),
+		InitrdTime:    analysis.InitrdTime.String(),
+		UserspaceTime: analysis.UserspaceTime.String(),
+		ReachedTarget: analysis.ReachedTarget,
+		TimingSource:  analysis.TimingSource,
+		TimingBoots:   analysis.TimingBoots,
+		Parallelism:   analysis.Parallelism,
+		TopUnits:      topUnits,
+		CriticalChain: analysis.CriticalChain,
+		PredictedPath: analysis.PredictedPath,
+		Units:         []audit.BootUnitReport{},
+		Issues:        analysis.Issues,
+	}
+	if analysis.Report != nil {
+		output.Units = analysis.Report.Units
+	}
+
+	if analysis.ReachedTarget != "" {
+		output.TargetReached = analysis.TargetReachedTime.String()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+func outputBootText(analysis *audit.BootAnalysis, p style.Provider) error {
+	printSection(p, 1, "Boot Time Analysis")
+
+	fmt.Printf("\nTotal:     %s\n", analysis.TotalTime)
+	fmt.Printf("Kernel:    %s\n", analysis.KernelTime)
+	if analysis.InitrdTime > 0 {
+		fmt.Printf("Initrd:    %s\n", analysis.InitrdTime)
+	}
+	fmt.Printf("Userspace: %s\n", analysis.UserspaceTime)
+	if analysis.ReachedTarget != "" {
+		fmt.Printf("%s reached after %s in userspace\n", analysis.ReachedTarget, analysis.TargetReachedTime)
+	}
+
+	if analysis.Parallelism > 0 {
+		fmt.Printf("Parallelism: %.1f units starting at a time on average\n", analysis.Parallelism)
+	}
+
+	switch analysis.TimingSource {
+	case "journal":
+		printSection(p, 2, fmt.Sprintf("Slowest Units (median of %d boots from the journal)", analysis.TimingBoots))
+	case "timestamps":
+		printSection(p, 2, "Units Holding Up Boot (activation timestamps, this boot)")
+		fmt.Printf("  %10s  %10s  %8s  %s\n", "wall-clock", "took", "parallel", "unit")
+	default:
+		printSection(p, 2, "Slowest Units (blame, this boot only)")
+	}
+	count := 10
+	if len(analysis.Units) < count {
+		count = len(analysis.Units)
+	}
+	for i := 0; i < count; i++ {
+		unit := analysis.Units[i]
+		switch analysis.TimingSource {
+		case "journal":
+			fmt.Printf("  %10s  p95 %-10s  %s\n", unit.Time.Round(time.Millisecond), unit.P95.Round(time.Millisecond), unit.Name)
+		case "timestamps":
+			fmt.Printf("  %10s  %10s  %8.1f  %s\n", unit.WallClock.Round(time.Millisecond), unit.Time.Round(time.Millisecond), unit.Parallelism, unit.Name)
+		default:
+			fmt.Printf("  %10s  %s\n", unit.Time, unit.Name)
+		}
+	}
+
+	if len(analysis.CriticalChain) > 0 {
+		printSection(p, 2, "Critical Chain")
+		for _, link := range analysis.CriticalChain {
+			if p.ASCII() {
+				// Spell out criticality instead of relying on a symbol
+				critical := ""
+				if link.IsCritical {
+					critical = ", critical"
+				}
+				fmt.Printf("  %s%s: active at %s, took %s%s\n", strings.Repeat("  ", link.Depth), link.Name, link.ActiveAt, link.Time, critical)
+				continue
+			}
+			marker := " "
+			if link.IsCritical {
+				marker = "!"
+			}
+			fmt.Printf("  %s %10s %10s  %s%s\n", marker, "@"+link.ActiveAt.String(), "+"+link.Time.String(), strings.Repeat("  ", link.Depth), link.Name)
+		}
+	}
+
+	if predicted := analysis.PredictedPath; predicted != nil && len(predicted.Path.Path) > 1 {
+		printSection(p, 2, "Predicted Critical Path (unit files, observed start times)")
+		fmt.Printf("  %s: %s observed, %s worst case\n", predicted.Target,
+			audit.FormatDuration(predicted.Path.Observed), audit.FormatDuration(predicted.Path.WorstCase))
+		inChain := make(map[string]bool, len(predicted.InChain))
+		for _, unit := range predicted.InChain {
+			inChain[unit] = true
+		}
+		for _, node := range predicted.Path.Path {
+			took := "+" + node.Observed.String()
+			if node.Unobserved {
+				took = "no start time"
+			}
+			note := ""
+			if !inChain[node.Unit] {
+				note = "  (not in critical chain)"
+			}
+			fmt.Printf("  %14s  %s%s\n", took, node.Unit, note)
+		}
+		if len(predicted.Unobserved) > 0 {
+			fmt.Printf("  %d unit(s) without a start time this boot count as starting at once.\n", len(predicted.Unobserved))
+		}
+	}
+
+	reported := make(map[string]bool)
+	if analysis.Report != nil && len(analysis.Report.Units) > 0 {
+		printSection(p, 2, "Units Slowing Boot")
+		for _, u := range analysis.Report.Units {
+			reported[u.Unit] = true
+			printBootUnit(u)
+		}
+	}
+
+	// The report covers the issues of the units it lists
+	var issues []audit.BootIssue
+	for _, issue := range analysis.Issues {
+		if !reported[issue.Unit] {
+			issues = append(issues, issue)
+		}
+	}
+	if len(issues) > 0 {
+		printSection(p, 2, "Issues Detected")
+		for _, issue := range issues {
+			fmt.Printf("  [%s] %s: %s\n", strings.ToUpper(issue.Severity), issue.Unit, issue.Description)
+			fmt.Printf("          Suggestion: %s\n", issue.Suggestion)
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
+
+// printBootUnit prints what the boot report knows of one unit
+func printBootUnit(u audit.BootUnitReport) {
+	fmt.Printf("\n  %s\n", u.Unit)
+	took := u.Time.String()
+	if u.WallClock > 0 {
+		took += fmt.Sprintf(", %s of boot wall-clock time", u.WallClock.Round(time.Millisecond))
+	}
+	if u.Slow {
+		took += " (slow)"
+	}
+	fmt.Printf("    Start time:      %s\n", took)
+	chain := "no"
+	if u.InChain {
+		chain = "yes, active at " + u.ActiveAt.String()
+		if u.Critical {
+			chain += " (critical)"
+		}
+	}
+	fmt.Printf("    Critical chain:  %s\n", chain)
+	if u.OnPredictedPath {
+		fmt.Printf("    Predicted path:  yes\n")
+	}
+	for i, finding := range u.Findings {
+		label := ""
+		if i == 0 {
+			label = "Findings:"
+		}
+		fmt.Printf("    %-16s [%s] %s %s\n", label, strings.ToUpper(finding.Severity.String()), finding.RuleID, finding.RuleName)
+	}
+	fmt.Printf("    Recommendation:  %s\n", u.Recommendation)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/cache"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the scan cache",
+	Long: `scan and check with --cache (or SDAUDIT_CACHE set) keep the issues of
+rules that only read a unit's own directives, keyed by each unit file's
+contents, and reuse them while the file is unchanged. Cache entries are
+dropped when sdaudit, the rule set or the rule options change.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear [dir]",
+	Short: "Remove cached scan results",
+	Long: `Remove cached scan results from dir, or from $SDAUDIT_CACHE, or from
+sdaudit in the user's cache directory.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCacheClear,
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	var dir string
+	if len(args) > 0 {
+		dir = args[0]
+	} else {
+		var err error
+		if dir, err = cache.DefaultDir(); err != nil {
+			return fmt.Errorf("cannot find the cache directory: %w", err)
+		}
+	}
+
+	removed, err := cache.Clear(dir)
+	if err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	fmt.Printf("Removed %d cache files from %s\n", removed, dir)
+	return nil
+}
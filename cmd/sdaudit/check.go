@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/tui"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [unit-files...]",
+	Short: "Check specific unit file(s)",
+	Long: `Validate one or more systemd unit files for issues.
+
+Arguments may be unit files, directories, searched recursively, or glob
+patterns such as 'deploy/*.service', which are expanded when the shell has
+not. Units get the drop-ins in .d directories next to them, and rules that
+look at other units see every unit given.
+
+The file - reads one unit from stdin, named with --stdin-name:
+
+  generate-unit | sdaudit check --stdin-name app.service -`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runCheck,
+}
+
+func init() {
+	checkCmd.Flags().Bool("tui", false, "Launch interactive TUI after check")
+	checkCmd.Flags().String("theme", "auto", "TUI colors: auto, dark, light, mono")
+	checkCmd.Flags().Bool("quick", false, "Only run rules that inspect a unit's own directives")
+	checkCmd.Flags().Bool("no-graph", false, "Skip the rules that analyze dependencies between units")
+	checkCmd.Flags().Bool("no-progress", false, "Do not show a progress bar on stderr")
+	checkCmd.Flags().Bool("summary-only", false, "Print only the summary and the units with the worst issues (text format)")
+	checkCmd.Flags().Bool("show-source", false, "Print the lines of the unit file around each issue (text format)")
+	checkCmd.Flags().BoolP("quiet", "q", false, "Print nothing but errors; the exit status tells the result with --fail-on")
+	checkCmd.MarkFlagsMutuallyExclusive("quiet", "tui")
+	checkCmd.Flags().String("cache", "", "Reuse per-unit rule results from this directory for unchanged files (also SDAUDIT_CACHE)")
+	checkCmd.Flags().Bool("no-cache", false, "Check every unit again, ignoring --cache and SDAUDIT_CACHE")
+	checkCmd.Flags().String("stdin-name", "", "Unit name, such as app.service, of the unit read from stdin for the file -")
+	checkCmd.Flags().String("baseline", "", "Leave out the issues acknowledged in this file; with --tui, acknowledge issues into it")
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	severity, _ := cmd.Flags().GetString("severity")
+	category, _ := cmd.Flags().GetString("category")
+	tagsStr, _ := cmd.Flags().GetString("tags")
+	useTUI, _ := cmd.Flags().GetBool("tui")
+
+	opts := buildOptions(severity, category, tagsStr)
+	opts.Profile, _ = cmd.Flags().GetString("profile")
+	if err := applyConfig(cmd, &opts); err != nil {
+		return err
+	}
+
+	sdVersion, err := systemdVersion(cmd)
+	if err != nil {
+		return err
+	}
+	opts.SystemdVersion = sdVersion
+	opts.Root, _ = cmd.Flags().GetString("root")
+	opts.Quick, _ = cmd.Flags().GetBool("quick")
+	opts.NoGraph, _ = cmd.Flags().GetBool("no-graph")
+	opts.CacheDir = cacheDir(cmd)
+	if slices.Contains(args, "-") {
+		opts.Stdin = os.Stdin
+		opts.StdinName, _ = cmd.Flags().GetString("stdin-name")
+		if opts.StdinName == "" {
+			return fmt.Errorf("reading a unit from stdin needs its name, such as --stdin-name app.service")
+		}
+	}
+
+	baselinePath, _ := cmd.Flags().GetString("baseline")
+	acked, err := loadBaseline(baselinePath)
+	if err != nil {
+		return err
+	}
+
+	stopProgress := startProgress(cmd, &opts)
+	result, err := audit.Check(cmd.Context(), args, opts)
+	stopProgress()
+	if err != nil {
+		return err
+	}
+
+	if useTUI {
+		in := tuiInput(cmd.Context(), result, opts, nil)
+		in.Baseline, in.BaselinePath = acked, baselinePath
+		opts, err := tuiOptions(cmd)
+		if err != nil {
+			return err
+		}
+		return tui.Run(in, opts)
+	}
+	if acked != nil {
+		acked.Apply(result)
+	}
+
+	if err := outputResult(cmd, result, format); err != nil {
+		return err
+	}
+	if err := checkParseErrors(cmd, args, result.Issues); err != nil {
+		return err
+	}
+	return checkFailOn(cmd, result.Issues)
+}
+
+// checkParseErrors returns an error when a unit file named on the command
+// line does not parse. The user asked for that file to be audited, so it
+// fails the check whatever --fail-on says; files found in a directory only
+// count through --fail-on.
+func checkParseErrors(cmd *cobra.Command, args []string, issues []types.Issue) error {
+	named := make(map[string]bool)
+	for _, arg := range args {
+		if arg == "-" {
+			named[types.StdinPath] = true
+			continue
+		}
+		// Files matched by a quoted pattern are named too
+		paths, _ := filepath.Glob(arg)
+		for _, path := range paths {
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+			named[filepath.Base(path)] = true
+			if real, err := filepath.EvalSymlinks(path); err == nil {
+				named[real] = true
+			}
+		}
+	}
+
+	files := make(map[string]bool)
+	for _, issue := range issues {
+		if issue.RuleID == types.ParseRuleID && (named[issue.File] || named[issue.Unit]) {
+			files[issue.File] = true
+		}
+	}
+	if len(files) > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d unit file(s) named on the command line do not parse", len(files))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <old.json> <new.json>",
+	Short: "Compare two JSON scan reports",
+	Long: `Compare two reports written by scan or check with -f json, matching issues
+by fingerprint as baselines do: issues new in the second report, resolved
+since the first, and persisting in both, with their counts.
+
+With --fail-on-new, exit non-zero when a new issue is at or above the
+severity given, to gate changes on the issues they introduce.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCompare,
+}
+
+func init() {
+	compareCmd.Flags().String("fail-on-new", "", "Exit non-zero when a new issue is at or above this severity: critical, high, medium, low, info")
+	rootCmd.AddCommand(compareCmd)
+}
+
+func runCompare(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q for compare (use text or json)", format)
+	}
+	failOnNew, _ := cmd.Flags().GetString("fail-on-new")
+	threshold := types.ParseSeverity(failOnNew)
+	if failOnNew != "" && threshold.String() != failOnNew {
+		return fmt.Errorf("unknown --fail-on-new severity %q", failOnNew)
+	}
+
+	var reports [2]*types.ScanResult
+	for i, path := range args {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		reports[i], err = audit.DecodeJSON(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	diff := audit.CompareIssues(reports[0].Issues, reports[1].Issues)
+
+	if format == "json" {
+		if err := outputCompareJSON(args[0], args[1], diff); err != nil {
+			return err
+		}
+	} else {
+		outputCompareText(args[0], args[1], diff, outputStyle(cmd))
+	}
+
+	if failOnNew == "" {
+		return nil
+	}
+	count := 0
+	for _, issue := range diff.New {
+		if issue.Severity >= threshold {
+			count++
+		}
+	}
+	if count > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d new issue(s) at or above %s severity", count, failOnNew)
+	}
+	return nil
+}
+
+func outputCompareJSON(oldPath, newPath string, diff *audit.IssueDiff) error {
+	convert := func(issues []types.Issue) []audit.JSONIssue {
+		out := make([]audit.JSONIssue, len(issues))
+		for i, issue := range issues {
+			out[i] = audit.NewJSONIssue(issue)
+		}
+		return out
+	}
+	type counts struct {
+		New        int `json:"new"`
+		Resolved   int `json:"resolved"`
+		Persisting int `json:"persisting"`
+	}
+	output := struct {
+		Old        string            `json:"old"`
+		New        string            `json:"new"`
+		Counts     counts            `json:"counts"`
+		NewIssues  []audit.JSONIssue `json:"new_issues"`
+		Resolved   []audit.JSONIssue `json:"resolved_issues"`
+		Persisting []audit.JSONIssue `json:"persisting_issues"`
+	}{
+		Old:        oldPath,
+		New:        newPath,
+		Counts:     counts{len(diff.New), len(diff.Resolved), len(diff.Persisting)},
+		NewIssues:  convert(diff.New),
+		Resolved:   convert(diff.Resolved),
+		Persisting: convert(diff.Persisting),
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+func outputCompareText(oldPath, newPath string, diff *audit.IssueDiff, p style.Provider) {
+	printSection(p, 1, "Issue Comparison")
+	fmt.Printf("\nOld: %s\nNew: %s\n", oldPath, newPath)
+	fmt.Printf("\n%s new, %s resolved, %d persisting\n",
+		p.Red(fmt.Sprint(len(diff.New))), p.Green(fmt.Sprint(len(diff.Resolved))), len(diff.Persisting))
+
+	printIssueList(p, "New Issues", diff.New, p.Red("+"))
+	printIssueList(p, "Resolved Issues", diff.Resolved, p.Green("-"))
+	printIssueList(p, "Persisting Issues", diff.Persisting, "=")
+	fmt.Println()
+}
+
+// printIssueList prints a section of issues, each after mark, unless there
+// are none
+func printIssueList(p style.Provider, title string, issues []types.Issue, mark string) {
+	if len(issues) == 0 {
+		return
+	}
+	printSection(p, 2, fmt.Sprintf("%s (%d)", title, len(issues)))
+	for _, issue := range issues {
+		fmt.Printf("  %s [%s] %s %s: %s\n", mark, p.Severity(issue.Severity), issue.RuleID, issue.Unit, issue.Description)
+	}
+}
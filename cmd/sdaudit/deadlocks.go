@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+var deadlocksCmd = &cobra.Command{
+	Use:   "deadlocks",
+	Short: "Detect restart deadlocks and restart storms",
+	Long: `Detect dependency patterns that keep units from restarting or make them
+restart in a loop: BindsTo= and After= combined with a dependency back on the
+unit, BindsTo= conflicting with a requirement, JobTimeoutSec= spent waiting on
+long dependency chains, Requisite= on units that may never be active, and
+BindsTo= between units with Restart=.
+
+Findings are reported like scan issues, so --format, --severity and --fail-on
+work as they do for scan.`,
+	Args: cobra.NoArgs,
+	RunE: runDeadlocks,
+}
+
+func init() {
+	rootCmd.AddCommand(deadlocksCmd)
+}
+
+func runDeadlocks(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	severity, _ := cmd.Flags().GetString("severity")
+	root, _ := cmd.Flags().GetString("root")
+
+	units, err := audit.LoadSystemUnits(cmd.Context(), root)
+	if err != nil {
+		return err
+	}
+	result, err := audit.CheckDeadlocks(cmd.Context(), units, types.ParseSeverity(severity))
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json", "sarif":
+		err = outputResult(cmd, result, format)
+	default:
+		err = outputDeadlocksText(result, outputStyle(cmd))
+	}
+	if err != nil {
+		return err
+	}
+	return checkFailOn(cmd, result.Issues)
+}
+
+// outputDeadlocksText prints deadlock findings grouped by pattern, most
+// severe pattern first
+func outputDeadlocksText(result *types.ScanResult, p style.Provider) error {
+	printSection(p, 1, "Deadlock Analysis")
+	fmt.Printf("\nTotal units: %d\n", result.Summary.TotalUnits)
+	fmt.Printf("Findings: %d\n", result.Summary.TotalIssues)
+
+	if len(result.Issues) == 0 {
+		fmt.Println("\nNo restart deadlocks or restart storms found.")
+		fmt.Println()
+		return nil
+	}
+
+	var order []string
+	groups := make(map[string][]types.Issue)
+	for _, issue := range result.Issues {
+		if _, ok := groups[issue.RuleID]; !ok {
+			order = append(order, issue.RuleID)
+		}
+		groups[issue.RuleID] = append(groups[issue.RuleID], issue)
+	}
+
+	for _, id := range order {
+		issues := groups[id]
+		printSection(p, 2, fmt.Sprintf("%s %s (%d)", id, issues[0].RuleName, len(issues)))
+		for _, issue := range issues {
+			fmt.Printf("  [%s] %s\n", strings.ToUpper(issue.Severity.String()), issue.Unit)
+			if issue.File != "" {
+				location := issue.File
+				if issue.Line != nil {
+					location += fmt.Sprintf(":%d", *issue.Line)
+				}
+				fmt.Printf("          File: %s\n", location)
+			}
+			fmt.Printf("          %s\n", issue.Description)
+			if issue.Suggestion != "" {
+				fmt.Printf("          Resolution: %s\n", issue.Suggestion)
+			}
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
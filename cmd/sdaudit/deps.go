@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+var depsCmd = &cobra.Command{
+	Use:   "deps [unit]",
+	Short: "Analyze dependencies",
+	Long: `Analyze systemd unit dependencies and detect issues like circular dependencies.
+
+The dependency graph is built from unit files, keeping the type of each
+dependency. It reports how many units each unit pulls in and detects cycles,
+references to missing units, ordering issues, BindsTo= without After= and
+contradictory dependencies. With --runtime, dependencies that systemd adds at
+runtime are included as well.
+
+With --reverse, the units that depend on the given unit are listed instead,
+as a tree annotated with the type of each dependency and whether the unit
+will stop, will fail to start or is only ordered after it when the given unit
+fails. --depth limits how many levels of the tree are shown.`,
+	RunE: runDeps,
+}
+
+func init() {
+	depsCmd.Flags().String("save", "", "Save dependency graph to file")
+	depsCmd.Flags().String("diff", "", "Compare against baseline file")
+	depsCmd.Flags().Bool("runtime", false, "Add dependencies systemd has loaded at runtime, such as default dependencies")
+	depsCmd.Flags().Bool("fail-on-change", false, "With --diff, exit non-zero when the graph changed")
+	depsCmd.Flags().String("reverse", "", "List the units that depend on this unit")
+	depsCmd.Flags().Int("depth", 0, "With --reverse, how many levels of dependents to show (0 for all)")
+	rootCmd.AddCommand(depsCmd)
+}
+
+func runDeps(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	severity, _ := cmd.Flags().GetString("severity")
+	root, _ := cmd.Flags().GetString("root")
+	runtime, _ := cmd.Flags().GetBool("runtime")
+	save, _ := cmd.Flags().GetString("save")
+	baselinePath, _ := cmd.Flags().GetString("diff")
+	failOnChange, _ := cmd.Flags().GetBool("fail-on-change")
+	reverse, _ := cmd.Flags().GetString("reverse")
+	depth, _ := cmd.Flags().GetInt("depth")
+
+	if runtime && root != "" {
+		return fmt.Errorf("--runtime reads the running system and cannot be combined with --root")
+	}
+	if reverse != "" && len(args) > 0 {
+		return fmt.Errorf("--reverse takes the unit to query; do not pass another unit")
+	}
+	if depth < 0 {
+		return fmt.Errorf("--depth must not be negative")
+	}
+
+	var opts audit.Options
+	if err := applyConfig(cmd, &opts); err != nil {
+		return err
+	}
+
+	var unitName string
+	if len(args) > 0 {
+		unitName = args[0]
+	}
+
+	units, err := audit.LoadSystemUnits(cmd.Context(), root)
+	if err != nil {
+		return err
+	}
+	g := audit.BuildGraph(units)
+	g.AddSynchronizationUnits(opts.SynchronizationUnits...)
+	if runtime {
+		if err := g.AddRuntimeDependencies(cmd.Context()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; using unit files only\n", err)
+		}
+	}
+
+	if reverse != "" {
+		result, err := audit.FindReverseDependencies(g, reverse, depth)
+		if err != nil {
+			return fmt.Errorf("dependency analysis failed: %w", err)
+		}
+		switch format {
+		case "json":
+			return outputReverseDepsJSON(result)
+		default:
+			return outputReverseDepsText(result, outputStyle(cmd))
+		}
+	}
+
+	report, err := audit.AnalyzeDependencies(g, unitName)
+	if err != nil {
+		return fmt.Errorf("dependency analysis failed: %w", err)
+	}
+
+	minSeverity := types.ParseSeverity(severity)
+	var issues []audit.DependencyIssue
+	for _, issue := range report.Issues {
+		if types.ParseSeverity(issue.Severity) >= minSeverity {
+			issues = append(issues, issue)
+		}
+	}
+	report.Issues = issues
+
+	if save != "" {
+		if err := audit.SaveDependencyGraph(save, report.Graph); err != nil {
+			return fmt.Errorf("failed to save dependency graph: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Saved dependency graph to %s\n", save)
+	}
+
+	if baselinePath != "" {
+		baseline, err := audit.LoadDependencyGraph(baselinePath)
+		if err != nil {
+			return fmt.Errorf("failed to load baseline: %w", err)
+		}
+		diff := audit.DiffDependencyGraphs(baseline, report.Graph)
+
+		switch format {
+		case "json":
+			err = outputDepsDiffJSON(diff)
+		default:
+			err = outputDepsDiffText(diff, baselinePath, outputStyle(cmd))
+		}
+		if err != nil {
+			return err
+		}
+
+		if failOnChange && !diff.Empty() {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("dependency graph changed since %s", baselinePath)
+		}
+		return nil
+	}
+
+	switch format {
+	case "json":
+		return outputDepsJSON(report)
+	default:
+		return outputDepsText(report, outputStyle(cmd))
+	}
+}
+
+func outputDepsDiffJSON(diff *audit.DependencyDiff) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diff)
+}
+
+func outputDepsDiffText(diff *audit.DependencyDiff, baselinePath string, p style.Provider) error {
+	printSection(p, 1, "Dependency Changes")
+	fmt.Printf("\nBaseline: %s\n", baselinePath)
+
+	if diff.Empty() {
+		fmt.Println("\nNo changes since the baseline.")
+		fmt.Println()
+		return nil
+	}
+
+	printUnits := func(title string, units []string, mark string) {
+		if len(units) == 0 {
+			return
+		}
+		printSection(p, 2, fmt.Sprintf("%s (%d)", title, len(units)))
+		for _, u := range units {
+			fmt.Printf("  %s %s\n", mark, u)
+		}
+	}
+	printEdges := func(title string, edges []audit.DependencyEdge, mark string) {
+		if len(edges) == 0 {
+			return
+		}
+		printSection(p, 2, fmt.Sprintf("%s (%d)", title, len(edges)))
+		for _, e := range edges {
+			fmt.Printf("  %s %s %s %s (%s)\n", mark, e.From, p.Text("→"), e.To, e.Type)
+		}
+	}
+
+	printUnits("Added Units", diff.AddedUnits, "+")
+	printUnits("Removed Units", diff.RemovedUnits, "-")
+	printEdges("Added Dependencies", diff.AddedEdges, "+")
+	printEdges("Removed Dependencies", diff.RemovedEdges, "-")
+
+	if len(diff.ChangedEdges) > 0 {
+		printSection(p, 2, fmt.Sprintf("Changed Dependencies (%d)", len(diff.ChangedEdges)))
+		for _, c := range diff.ChangedEdges {
+			fmt.Printf("  ~ %s %s %s: %s %s %s\n", c.From, p.Text("→"), c.To,
+				strings.Join(c.OldTypes, ","), p.Text("→"), strings.Join(c.NewTypes, ","))
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func outputReverseDepsJSON(result *audit.ReverseDependencies) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+func outputReverseDepsText(result *audit.ReverseDependencies, p style.Provider) error {
+	printSection(p, 1, "Reverse Dependencies")
+
+	fmt.Printf("\nUnit: %s\n", result.Unit)
+	fmt.Printf("Direct dependents: %d\n", result.Direct)
+	fmt.Printf("Transitive dependents: %d\n", result.Transitive)
+
+	if len(result.Dependents) == 0 {
+		fmt.Println("\nNo units depend on this unit.")
+		fmt.Println()
+		return nil
+	}
+
+	edgeTypes := make([]string, 0, len(result.ByType))
+	for t := range result.ByType {
+		edgeTypes = append(edgeTypes, t)
+	}
+	sort.Strings(edgeTypes)
+
+	printSection(p, 2, "Direct Dependents by Type")
+	for _, t := range edgeTypes {
+		fmt.Printf("  %-22s %s\n", t, strings.Join(result.ByType[t], ", "))
+	}
+
+	title := "Dependency Tree"
+	if result.Depth > 0 {
+		title += fmt.Sprintf(" (depth %d)", result.Depth)
+	}
+	printSection(p, 2, title)
+	fmt.Printf("  %s\n", result.Unit)
+	printReverseTree(result.Dependents, "  ", p)
+
+	fmt.Println()
+	return nil
+}
+
+// printReverseTree prints dependents as an indented tree, each annotated with
+// its dependency types and what happens to it when the queried unit fails
+func printReverseTree(deps []*audit.ReverseDependency, indent string, p style.Provider) {
+	for i, dep := range deps {
+		branch, next := "├─", "│  "
+		if i == len(deps)-1 {
+			branch, next = "└─", "   "
+		}
+		fmt.Printf("%s%s %s (%s) %s\n", indent, p.Text(branch), dep.Unit, strings.Join(dep.EdgeTypes, ", "), reverseEffectText(dep))
+		printReverseTree(dep.Dependents, indent+p.Text(next), p)
+	}
+}
+
+func reverseEffectText(dep *audit.ReverseDependency) string {
+	var effects []string
+	for _, a := range dep.Affected {
+		for _, impact := range a.Impacts() {
+			effects = append(effects, impactText(impact))
+		}
+	}
+	if len(effects) > 0 {
+		return strings.Join(effects, ", ")
+	}
+	if dep.Effect == audit.EffectOrdering {
+		return "ordering only"
+	}
+	return "not affected"
+}
+
+// depsTopUnits is how many units the text output lists by dependency count
+const depsTopUnits = 10
+
+func outputDepsJSON(report *audit.DependencyReport) error {
+	output := struct {
+		Unit        string                  `json:"unit,omitempty"`
+		UnitCount   int                     `json:"unit_count"`
+		Units       []string                `json:"units"`
+		EdgesByType map[string]int          `json:"edges_by_type"`
+		Counts      []audit.DependencyCount `json:"dependency_counts"`
+		Issues      []audit.DependencyIssue `json:"issues"`
+	}{
+		Unit:        report.Unit,
+		UnitCount:   report.UnitCount,
+		Units:       []string{},
+		EdgesByType: report.EdgesByType,
+		Counts:      report.Counts,
+		Issues:      report.Issues,
+	}
+
+	for name := range report.Graph.Units {
+		output.Units = append(output.Units, name)
+	}
+	sort.Strings(output.Units)
+	if output.Issues == nil {
+		output.Issues = []audit.DependencyIssue{}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+func outputDepsText(report *audit.DependencyReport, p style.Provider) error {
+	printSection(p, 1, "Dependency Analysis")
+
+	if report.Unit != "" {
+		fmt.Printf("\nAnalyzing: %s\n", report.Unit)
+		fmt.Printf("\nUnits pulled in: %d\n", report.UnitCount-1)
+	} else {
+		fmt.Printf("\nTotal units: %d\n", report.UnitCount)
+	}
+
+	if len(report.EdgesByType) > 0 {
+		edgeTypes := make([]string, 0, len(report.EdgesByType))
+		for t := range report.EdgesByType {
+			edgeTypes = append(edgeTypes, t)
+		}
+		sort.Strings(edgeTypes)
+
+		printSection(p, 2, "Dependencies by Type")
+		for _, t := range edgeTypes {
+			fmt.Printf("  %-22s %d\n", t, report.EdgesByType[t])
+		}
+	}
+
+	if report.Unit != "" {
+		c := report.Counts[0]
+		fmt.Printf("\nDirect dependencies: %d\nTransitive dependencies: %d\n", c.Direct, c.Transitive)
+	} else if len(report.Counts) > 0 {
+		printSection(p, 2, "Most Dependencies")
+		for i, c := range report.Counts {
+			if i == depsTopUnits || c.Transitive == 0 {
+				break
+			}
+			fmt.Printf("  %-40s %3d direct, %3d transitive\n", c.Unit, c.Direct, c.Transitive)
+		}
+	}
+
+	if len(report.Issues) > 0 {
+		printSection(p, 2, fmt.Sprintf("Issues Detected (%d)", len(report.Issues)))
+		for _, issue := range report.Issues {
+			kind := issue.Kind
+			if issue.Rule != "" {
+				kind += " (" + issue.Rule + ")"
+			}
+			fmt.Printf("  [%s] %s: %s\n", strings.ToUpper(issue.Severity), kind, issue.Description)
+			if issue.File != "" {
+				location := issue.File
+				if issue.Line > 0 {
+					location += fmt.Sprintf(":%d", issue.Line)
+				}
+				fmt.Printf("          File: %s\n", location)
+			}
+			if len(issue.Cycle) > 0 {
+				fmt.Println("          Cycle:")
+				for _, e := range issue.Cycle {
+					line := fmt.Sprintf("            %s %s=%s", e.From, e.Directive(), e.To)
+					if e.File != "" {
+						location := e.File
+						if e.Line > 0 {
+							location += fmt.Sprintf(":%d", e.Line)
+						}
+						line += "  (" + location + ")"
+					}
+					if e.Break {
+						line += "  <- break here"
+					}
+					fmt.Println(line)
+				}
+			}
+			if issue.Suggestion != "" {
+				fmt.Printf("          Suggestion: %s\n", issue.Suggestion)
+			}
+		}
+	} else {
+		fmt.Println("\nNo dependency issues detected.")
+	}
+
+	fmt.Println()
+	return nil
+}
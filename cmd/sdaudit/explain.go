@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <rule-id> [unit | unit-file]",
+	Short: "Explain a rule and how to fix it",
+	Long: `Explain what a rule checks, why it matters, and the directives that fix
+it, with a sample unit snippet and the rule's references.
+
+Given a unit name or a unit file as well, the rule is run on it and the
+directives it reports there are shown with their lines.
+
+With -f markdown, the explanation is written as Markdown for pasting into
+tickets and pull requests.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "markdown" {
+		return fmt.Errorf("unknown format %q for explain (use text or markdown)", format)
+	}
+	rule, ok := audit.LookupRule(strings.ToUpper(args[0]))
+	if !ok {
+		return fmt.Errorf("unknown rule %q", args[0])
+	}
+
+	var found *unitFindings
+	if len(args) == 2 {
+		root, _ := cmd.Flags().GetString("root")
+		var err error
+		found, err = findRuleIssues(cmd.Context(), rule.ID, args[1], root)
+		if err != nil {
+			return err
+		}
+	}
+
+	if format == "markdown" {
+		writeExplainMarkdown(os.Stdout, rule, found)
+		return nil
+	}
+	writeExplainText(os.Stdout, outputStyle(cmd), rule, found)
+	return nil
+}
+
+// unitFindings are the issues one rule reports for a unit, with the unit's
+// file to quote the offending lines from
+type unitFindings struct {
+	unit   *types.UnitFile
+	issues []types.Issue
+}
+
+// line returns the text of the unit file at an issue's line, "" if the
+// issue has no line
+func (f *unitFindings) line(issue types.Issue) string {
+	if issue.Line == nil {
+		return ""
+	}
+	lines := strings.Split(f.unit.Raw, "\n")
+	if *issue.Line < 1 || *issue.Line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[*issue.Line-1])
+}
+
+// findRuleIssues runs every rule on the unit file at arg, or on the unit
+// named arg among the system's units, and keeps the issues of rule id for
+// that unit. Version gating is off, as the rule was asked for by name.
+func findRuleIssues(ctx context.Context, id, arg, root string) (*unitFindings, error) {
+	var units map[string]*types.UnitFile
+	var err error
+	name := arg
+	if isPath(arg) {
+		units, err = audit.LoadUnits(ctx, arg)
+		name = filepath.Base(arg)
+	} else {
+		units, err = audit.LoadSystemUnits(ctx, root)
+	}
+	if err != nil {
+		return nil, err
+	}
+	unit, ok := units[name]
+	if !ok {
+		return nil, fmt.Errorf("unit %q not found", arg)
+	}
+
+	issues, _, err := audit.RunRules(ctx, units, audit.Options{Root: root})
+	if err != nil {
+		return nil, err
+	}
+	found := &unitFindings{unit: unit}
+	for _, issue := range issues {
+		if issue.RuleID == id && issue.Unit == unit.Name {
+			found.issues = append(found.issues, issue)
+		}
+	}
+	return found, nil
+}
+
+// fixDirective is a line a rule's fixed example adds to its reported one
+type fixDirective struct {
+	section string
+	line    string
+}
+
+// key returns the directive's name
+func (d fixDirective) key() string {
+	key, _, _ := strings.Cut(d.line, "=")
+	return key
+}
+
+// addedDirectives returns the lines of a rule's fixed example that its
+// reported example lacks: the directives that fix it
+func addedDirectives(rule audit.RuleInfo) []fixDirective {
+	have := make(map[string]bool)
+	for _, line := range strings.Split(rule.ExampleBad, "\n") {
+		have[strings.TrimSpace(line)] = true
+	}
+	var added []fixDirective
+	section := ""
+	for _, line := range strings.Split(rule.ExampleGood, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = strings.Trim(line, "[]")
+		case line != "" && !have[line]:
+			added = append(added, fixDirective{section: section, line: line})
+		}
+	}
+	return added
+}
+
+// currentValues says what the unit sets for each directive the rule's fix
+// adds, for issues that do not point at a line
+func currentValues(rule audit.RuleInfo, unit *types.UnitFile) []string {
+	var out []string
+	for _, d := range addedDirectives(rule) {
+		directives := unit.GetDirectives(d.section, d.key())
+		if len(directives) == 0 {
+			out = append(out, fmt.Sprintf("%s= is not set in [%s]", d.key(), d.section))
+			continue
+		}
+		for _, directive := range directives {
+			out = append(out, fmt.Sprintf("%s:%d: %s=%s", unit.Path, directive.Line, directive.Key, directive.Value))
+		}
+	}
+	return out
+}
+
+func writeExplainText(w io.Writer, p style.Provider, rule audit.RuleInfo, found *unitFindings) {
+	section := func(level int, title string) {
+		switch {
+		case p.ASCII():
+			fmt.Fprintf(w, "\n%s\n", p.Heading(level, title))
+		case level == 1:
+			fmt.Fprintf(w, "\n%s\n%s\n", title, strings.Repeat("=", 50))
+		default:
+			fmt.Fprintf(w, "\n%s:\n%s\n", title, strings.Repeat("-", 50))
+		}
+	}
+
+	section(1, fmt.Sprintf("%s: %s", rule.ID, rule.Name))
+	fmt.Fprintf(w, "Category: %s\n", rule.Category)
+	fmt.Fprintf(w, "Severity: %s\n", p.Severity(rule.Severity))
+	if rule.MinSystemdVersion > 0 {
+		fmt.Fprintf(w, "Requires: systemd %d or later\n", rule.MinSystemdVersion)
+	}
+
+	section(2, "Why it matters")
+	fmt.Fprintln(w, rule.Description)
+
+	section(2, "How to fix it")
+	fmt.Fprintln(w, rule.Suggestion)
+	if added := addedDirectives(rule); len(added) > 0 {
+		fmt.Fprintln(w, "\nAdd:")
+		for _, d := range added {
+			fmt.Fprintf(w, "  %s\n", d.line)
+		}
+	}
+	if rule.ExampleGood != "" {
+		fmt.Fprintln(w, "\nFor example:")
+		fmt.Fprint(w, indentLines(rule.ExampleGood, "  "))
+	}
+
+	if len(rule.References) > 0 {
+		section(2, "References")
+		for _, ref := range rule.References {
+			if ref.URL != "" {
+				fmt.Fprintf(w, "  %s: %s\n", ref.Title, ref.URL)
+			} else {
+				fmt.Fprintf(w, "  %s\n", ref.Title)
+			}
+		}
+	}
+
+	if found != nil {
+		section(2, "In "+found.unit.Name)
+		if len(found.issues) == 0 {
+			fmt.Fprintf(w, "%s does not break %s.\n", found.unit.Name, rule.ID)
+		}
+		for _, issue := range found.issues {
+			fmt.Fprintf(w, "  %s\n", issue.Description)
+			if issue.Line != nil {
+				fmt.Fprintf(w, "    %s:%d: %s\n", issue.File, *issue.Line, found.line(issue))
+				continue
+			}
+			for _, value := range currentValues(rule, found.unit) {
+				fmt.Fprintf(w, "    %s\n", value)
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func writeExplainMarkdown(w io.Writer, rule audit.RuleInfo, found *unitFindings) {
+	fmt.Fprintf(w, "## %s: %s\n\n", rule.ID, rule.Name)
+	fmt.Fprintf(w, "**Category:** %s | **Severity:** %s", rule.Category, rule.Severity)
+	if rule.MinSystemdVersion > 0 {
+		fmt.Fprintf(w, " | **Requires:** systemd %d or later", rule.MinSystemdVersion)
+	}
+	fmt.Fprint(w, "\n\n")
+
+	fmt.Fprintf(w, "### Why it matters\n\n%s\n\n", rule.Description)
+
+	fmt.Fprintf(w, "### How to fix it\n\n%s\n\n", rule.Suggestion)
+	if added := addedDirectives(rule); len(added) > 0 {
+		fmt.Fprintln(w, "Add:")
+		fmt.Fprintln(w)
+		for _, d := range added {
+			fmt.Fprintf(w, "- `%s` in `[%s]`\n", d.line, d.section)
+		}
+		fmt.Fprintln(w)
+	}
+	if rule.ExampleGood != "" {
+		fmt.Fprintf(w, "```ini\n%s```\n\n", rule.ExampleGood)
+	}
+
+	if len(rule.References) > 0 {
+		fmt.Fprint(w, "### References\n\n")
+		for _, ref := range rule.References {
+			if ref.URL != "" {
+				fmt.Fprintf(w, "- [%s](%s)\n", ref.Title, ref.URL)
+			} else {
+				fmt.Fprintf(w, "- %s\n", ref.Title)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	if found != nil {
+		fmt.Fprintf(w, "### In `%s`\n\n", found.unit.Name)
+		if len(found.issues) == 0 {
+			fmt.Fprintf(w, "`%s` does not break %s.\n", found.unit.Name, rule.ID)
+		}
+		for _, issue := range found.issues {
+			fmt.Fprintf(w, "- %s\n", issue.Description)
+			if issue.Line != nil {
+				fmt.Fprintf(w, "  - `%s:%d`: `%s`\n", issue.File, *issue.Line, found.line(issue))
+				continue
+			}
+			for _, value := range currentValues(rule, found.unit) {
+				fmt.Fprintf(w, "  - `%s`\n", value)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/pkg/audit"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [unit]",
+	Short: "Export the dependency graph",
+	Long: `Export the unit dependency graph built from unit files as Graphviz DOT,
+JSON, GraphML or a Mermaid flowchart.
+
+Given a unit, only that unit and its direct dependencies and dependents are
+exported. References to missing units are drawn dashed. With
+--highlight-cycle, units in dependency cycles are highlighted and the
+shortest path around each cycle is drawn in red.
+
+With --unreachable, the units that are never started from the default target
+are listed instead, as text or with -f json as JSON.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGraph,
+}
+
+func init() {
+	graphCmd.Flags().StringP("format", "f", "dot", "Output format: dot, json, graphml, mermaid")
+	graphCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+	graphCmd.Flags().Bool("cluster", false, "Group units by type")
+	graphCmd.Flags().Bool("no-missing", false, "Leave out references to missing units")
+	graphCmd.Flags().Bool("no-implicit", false, "Leave out the dependencies systemd adds implicitly, such as the default dependencies")
+	graphCmd.Flags().StringSlice("highlight", nil, "Units to highlight (comma-separated)")
+	graphCmd.Flags().Bool("highlight-cycle", false, "Highlight units in dependency cycles and draw the shortest path around each in red")
+	graphCmd.Flags().StringSlice("edges", nil, "Only include these dependency types, e.g. Requires,After")
+	graphCmd.Flags().Bool("unreachable", false, "List the units never started from the default target instead of the graph")
+	rootCmd.AddCommand(graphCmd)
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	outputPath, _ := cmd.Flags().GetString("output")
+	root, _ := cmd.Flags().GetString("root")
+
+	var opts audit.ExportOptions
+	opts.Edges, _ = cmd.Flags().GetStringSlice("edges")
+	opts.Clustered, _ = cmd.Flags().GetBool("cluster")
+	opts.HideMissing, _ = cmd.Flags().GetBool("no-missing")
+	opts.HideImplicit, _ = cmd.Flags().GetBool("no-implicit")
+	opts.Highlight, _ = cmd.Flags().GetStringSlice("highlight")
+	opts.HighlightCycle, _ = cmd.Flags().GetBool("highlight-cycle")
+
+	unreachable, _ := cmd.Flags().GetBool("unreachable")
+	if unreachable && len(args) > 0 {
+		return fmt.Errorf("--unreachable lists units of the whole system and takes no unit")
+	}
+
+	units, err := audit.LoadSystemUnits(cmd.Context(), root)
+	if err != nil {
+		return err
+	}
+	g := audit.BuildGraph(units, audit.UnitPaths(root)...)
+
+	if len(args) > 0 {
+		if !g.HasUnit(args[0]) {
+			return fmt.Errorf("unit %s not found", args[0])
+		}
+		g = g.Neighborhood(args...)
+		opts.Title = "Dependencies of " + args[0]
+		opts.Highlight = append(opts.Highlight, args[0])
+	}
+
+	var buf strings.Builder
+	if unreachable {
+		err = writeUnreachable(&buf, g, format)
+	} else {
+		err = g.Encode(&buf, format, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		_, err = os.Stdout.WriteString(buf.String())
+		return err
+	}
+	if err := os.WriteFile(outputPath, []byte(buf.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write graph: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote dependency graph to %s\n", outputPath)
+	return nil
+}
+
+// writeUnreachable lists the units never started at boot, as JSON with
+// format json and as text otherwise
+func writeUnreachable(w io.Writer, g *audit.Graph, format string) error {
+	root := g.DefaultTarget()
+	if root == "" {
+		return fmt.Errorf("no default.target or graphical.target to start from")
+	}
+	dead := g.Unreachable()
+
+	if format == "json" {
+		output := struct {
+			DefaultTarget string                  `json:"default_target"`
+			Units         []audit.UnreachableUnit `json:"units"`
+		}{root, dead}
+		if output.Units == nil {
+			output.Units = []audit.UnreachableUnit{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(output)
+	}
+
+	fmt.Fprintf(w, "Units never started from %s: %d\n", root, len(dead))
+	for _, d := range dead {
+		reason := "not referenced by any unit"
+		if d.Kind == "unreachable_target" {
+			reason = "only wanted by " + d.Target
+		}
+		fmt.Fprintf(w, "  %-40s %s\n", d.Unit, reason)
+		if d.File != "" {
+			location := d.File
+			if d.Line > 0 {
+				location += fmt.Sprintf(":%d", d.Line)
+			}
+			fmt.Fprintf(w, "  %-40s %s\n", "", location)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+)
+
+var impactCmd = &cobra.Command{
+	Use:   "impact <unit>",
+	Short: "Simulate the failure of a unit",
+	Long: `Simulate what happens to the units that depend on a unit when it fails to
+start or stops, following each dependency type's propagation semantics.
+
+Affected units are listed once, with their strongest impact and the severity
+and path of it, along with the longest chain the failure takes through
+Requires=, Requisite= and BindsTo= alone. Dependents that
+only use Wants= on the unit, and so never notice it failing, and dependents
+bound with BindsTo= but not ordered After= it are reported as well.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImpact,
+}
+
+func init() {
+	impactCmd.Flags().String("scenario", "all", "Scenario to simulate: fail, stop, all")
+	rootCmd.AddCommand(impactCmd)
+}
+
+func runImpact(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	root, _ := cmd.Flags().GetString("root")
+	scenario, _ := cmd.Flags().GetString("scenario")
+
+	var scenarios []string
+	switch scenario {
+	case "all":
+		scenarios = []string{audit.ScenarioFail, audit.ScenarioStop}
+	case audit.ScenarioFail, audit.ScenarioStop:
+		scenarios = []string{scenario}
+	default:
+		return fmt.Errorf("unknown scenario %q: use fail, stop or all", scenario)
+	}
+
+	units, err := audit.LoadSystemUnits(cmd.Context(), root)
+	if err != nil {
+		return err
+	}
+	impact, err := audit.AnalyzeImpact(audit.BuildGraph(units), args[0], scenarios...)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(impact)
+	default:
+		return outputImpactText(impact, outputStyle(cmd))
+	}
+}
+
+func outputImpactText(impact audit.UnitImpact, p style.Provider) error {
+	printSection(p, 1, "Failure Impact")
+
+	fmt.Printf("\nUnit: %s\n", impact.FailedUnit)
+	fmt.Printf("Scenarios: %s\n", strings.Join(impact.Scenarios, ", "))
+	fmt.Printf("Affected units: %d\n", impact.TotalAffected)
+
+	if len(impact.AffectedUnits) > 0 {
+		printSection(p, 2, "Affected Units")
+		for _, a := range impact.AffectedUnits {
+			var impacts []string
+			for _, i := range a.Impacts() {
+				impacts = append(impacts, impactText(i))
+			}
+			fmt.Printf("  [%s] %s %s (%s=)\n", strings.ToUpper(a.Severity), a.Name, strings.Join(impacts, ", "), a.EdgeType)
+			fmt.Printf("          Path: %s\n", strings.Join(a.PropagationPath, " "+p.Text("→")+" "))
+		}
+	}
+
+	if len(impact.CriticalChain) > 0 {
+		printSection(p, 2, "Critical Chain")
+		fmt.Printf("  %s\n", strings.Join(impact.CriticalChain, " "+p.Text("→")+" "))
+	}
+
+	if len(impact.SilentFailures) > 0 {
+		printSection(p, 2, fmt.Sprintf("Silent Failures (%d)", len(impact.SilentFailures)))
+		for _, f := range impact.SilentFailures {
+			fmt.Printf("  [%s] %s\n", strings.ToUpper(f.Risk), f.Description)
+			if f.File != "" {
+				fmt.Printf("          File: %s:%d\n", f.File, f.Line)
+			}
+		}
+	}
+
+	if len(impact.StopOrderInversions) > 0 {
+		printSection(p, 2, fmt.Sprintf("Stop Order Inversions (%d)", len(impact.StopOrderInversions)))
+		for _, inv := range impact.StopOrderInversions {
+			fmt.Printf("  [%s] %s\n", strings.ToUpper(inv.Severity), inv.Description)
+		}
+	}
+
+	if impact.TotalAffected == 0 && len(impact.SilentFailures) == 0 && len(impact.StopOrderInversions) == 0 {
+		fmt.Println("\nNo other units are affected.")
+	}
+
+	fmt.Println()
+	return nil
+}
+
+// impactText describes an AffectedUnit impact
+func impactText(impact string) string {
+	switch impact {
+	case "stop":
+		return "will stop"
+	case "fail_to_start":
+		return "will fail to start"
+	default:
+		return impact
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/baseline"
+	"github.com/supabase/sdaudit/internal/cache"
+	"github.com/supabase/sdaudit/internal/config"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// isPath reports whether a security argument names a unit file or directory
+// rather than a loaded unit
+func isPath(arg string) bool {
+	if strings.ContainsRune(arg, os.PathSeparator) {
+		return true
+	}
+	_, err := os.Stat(arg)
+	return err == nil
+}
+
+func buildOptions(severity, category, tagsStr string) audit.Options {
+	opts := audit.Options{Version: version}
+
+	if severity != "" && severity != "info" {
+		sev := types.ParseSeverity(severity)
+		opts.MinSeverity = &sev
+	}
+
+	if category != "" {
+		cat := types.ParseCategory(category)
+		opts.Category = &cat
+	}
+
+	if tagsStr != "" {
+		opts.Tags = strings.Split(tagsStr, ",")
+		for i := range opts.Tags {
+			opts.Tags[i] = strings.TrimSpace(opts.Tags[i])
+		}
+	}
+
+	return opts
+}
+
+// checkFailOn returns an error when any issue is at or above the --fail-on
+// severity, so the command exits non-zero after printing its output
+func checkFailOn(cmd *cobra.Command, issues []types.Issue) error {
+	failOn, _ := cmd.Flags().GetString("fail-on")
+	if failOn == "" {
+		return nil
+	}
+	threshold := types.ParseSeverity(failOn)
+	if threshold.String() != failOn {
+		return fmt.Errorf("unknown --fail-on severity %q", failOn)
+	}
+
+	count := 0
+	for _, issue := range issues {
+		if issue.Severity >= threshold {
+			count++
+		}
+	}
+	if count > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d issue(s) at or above %s severity", count, failOn)
+	}
+	return nil
+}
+
+// cacheDir returns the directory to cache per-unit rule results in: --cache,
+// else $SDAUDIT_CACHE, or "" when caching is off or --no-cache is set
+func cacheDir(cmd *cobra.Command) string {
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache {
+		return ""
+	}
+	if dir, _ := cmd.Flags().GetString("cache"); dir != "" {
+		return dir
+	}
+	return os.Getenv(cache.EnvDir)
+}
+
+// loadBaseline reads the --baseline file, or returns nil when there is none
+func loadBaseline(path string) (*baseline.Baseline, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return baseline.Load(path)
+}
+
+// systemdVersion returns the --systemd-version flag, falling back to detection
+// on the live system
+func systemdVersion(cmd *cobra.Command) (int, error) {
+	value, _ := cmd.Flags().GetString("systemd-version")
+	root, _ := cmd.Flags().GetString("root")
+	quick, _ := cmd.Flags().GetBool("quick")
+	if value == "" {
+		// The running systemd says nothing about an offline image, and quick
+		// scans avoid external tool calls
+		if root != "" || quick {
+			return 0, nil
+		}
+		return audit.DetectSystemdVersion(), nil
+	}
+	v := audit.ParseSystemdVersion(value)
+	if v == 0 {
+		return 0, fmt.Errorf("invalid --systemd-version %q", value)
+	}
+	return v, nil
+}
+
+// applyConfig applies the settings of the --config file to opts
+func applyConfig(cmd *cobra.Command, opts *audit.Options) error {
+	f, err := loadConfig(cmd)
+	if f == nil {
+		return err
+	}
+	opts.ScoreWeights = f.ScoreWeights()
+	opts.SynchronizationUnits = f.Ordering.SynchronizationUnits
+	opts.RuleParams = f.Rules
+	return nil
+}
+
+// loadConfig reads the file given with --config, nil without one
+func loadConfig(cmd *cobra.Command) (*config.File, error) {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		return nil, nil
+	}
+	f, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --config: %w", err)
+	}
+	return f, nil
+}
+
+// reportHostname returns the name of the host a report is about: the host
+// sdaudit runs on, or the image's /etc/hostname with --root, empty with
+// --no-hostname or when it is unknown
+func reportHostname(cmd *cobra.Command) string {
+	if noHostname, _ := cmd.Flags().GetBool("no-hostname"); noHostname {
+		return ""
+	}
+	if root, _ := cmd.Flags().GetString("root"); root != "" {
+		data, err := os.ReadFile(filepath.Join(root, "etc/hostname"))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+	name, _ := os.Hostname()
+	return name
+}
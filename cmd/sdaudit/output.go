@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/progress"
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/internal/tui"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// outputStyle returns how text output to stdout is decorated
+func outputStyle(cmd *cobra.Command) style.Provider {
+	return styleFor(cmd, os.Stdout)
+}
+
+// styleFor returns how text written to f is decorated: colored as --color
+// says, which --no-color sets to never, and plain ASCII with --ascii or
+// SDAUDIT_ASCII
+func styleFor(cmd *cobra.Command, f *os.File) style.Provider {
+	ascii, _ := cmd.Flags().GetBool("ascii")
+	return style.New(style.UseColor(colorMode(cmd), f), style.ASCIIRequested(ascii))
+}
+
+// colorMode returns the --color mode, never with --no-color. The root
+// command has checked the mode is valid.
+func colorMode(cmd *cobra.Command) style.ColorMode {
+	if noColor, _ := cmd.Flags().GetBool("no-color"); noColor {
+		return style.ColorNever
+	}
+	name, _ := cmd.Flags().GetString("color")
+	mode, _ := style.ParseColorMode(name)
+	return mode
+}
+
+// tuiOptions returns how the TUI draws, from --ascii, --theme, and --color,
+// --no-color or NO_COLOR
+func tuiOptions(cmd *cobra.Command) (tui.Options, error) {
+	name, _ := cmd.Flags().GetString("theme")
+	theme, err := tui.ParseTheme(name)
+	if err != nil {
+		return tui.Options{}, err
+	}
+	p := outputStyle(cmd)
+	return tui.Options{ASCII: p.ASCII(), Theme: theme, NoColor: !p.Color()}, nil
+}
+
+// tuiInput builds the dependency graph of the scanned units for the TUI,
+// unless the scan ran with --no-graph, the loaders of its boot and security
+// tabs, and the check of edited unit files. unitPaths add the .wants/
+// symlinks and aliases of a system scan; without them the graph has the
+// units' directives only, and the security tab estimates the scores of the
+// checked files. ctx bounds the commands the tabs run.
+func tuiInput(ctx context.Context, result *types.ScanResult, opts audit.Options, unitPaths []string) tui.Input {
+	in := tui.Input{Result: result}
+	units := make(map[string]*types.UnitFile, len(result.Units))
+	for _, u := range result.Units {
+		units[u.Name] = u
+	}
+
+	// The tabs read the running system like sdaudit boot and sdaudit
+	// security do, unless the scan was of another root
+	if opts.Root == "" {
+		in.Boot = func() (*audit.BootAnalysis, error) {
+			return audit.AnalyzeBoot(ctx, audit.BootOptions{Journal: true, Boots: 5, Fallback: true})
+		}
+	} else {
+		in.Boot = func() (*audit.BootAnalysis, error) {
+			return nil, fmt.Errorf("boot times are read from the running system, not from --root")
+		}
+	}
+	if opts.Root == "" && unitPaths != nil {
+		in.Security = func() ([]audit.SecurityScore, error) { return audit.AnalyzeSecurity(ctx, "") }
+	} else {
+		in.Security = func() ([]audit.SecurityScore, error) { return audit.EstimateSecurity(units, "") }
+	}
+
+	// The scan's progress bar is gone by the time units are edited
+	recheckOpts := opts
+	recheckOpts.Progress = nil
+	in.Recheck = func(path string, units map[string]*types.UnitFile) (*audit.Recheck, error) {
+		return audit.RecheckFile(ctx, path, units, recheckOpts)
+	}
+
+	if opts.NoGraph {
+		return in
+	}
+	in.Graph = tui.ScanGraph(units, unitPaths)
+	return in
+}
+
+// startProgress shows a progress bar on stderr while the scan in opts runs,
+// unless stderr is not a terminal or --no-progress is set. It returns the
+// function that removes the bar.
+func startProgress(cmd *cobra.Command, opts *audit.Options) func() {
+	noProgress, _ := cmd.Flags().GetBool("no-progress")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	if noProgress || quiet || !style.IsTerminal(os.Stderr) {
+		return func() {}
+	}
+	bar := progress.New(os.Stderr, styleFor(cmd, os.Stderr))
+	opts.Progress = bar.Update
+	return bar.Done
+}
+
+// printSection prints a section heading: a '#' heading in ASCII mode, or the
+// title underlined with '=' (level 1) or '-' (deeper levels)
+func printSection(p style.Provider, level int, title string) {
+	if p.ASCII() {
+		fmt.Printf("\n%s\n", p.Heading(level, title))
+		return
+	}
+	if level == 1 {
+		fmt.Printf("\n%s\n", title)
+		fmt.Println(strings.Repeat("=", 50))
+		return
+	}
+	fmt.Printf("\n%s:\n", title)
+	fmt.Println(strings.Repeat("-", 50))
+}
+
+func outputResult(cmd *cobra.Command, result *types.ScanResult, format string) error {
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		return nil
+	}
+	// Formats other than json, sarif, markdown, github, codeclimate and
+	// prometheus fall back to text
+	switch format {
+	case audit.FormatJSON, audit.FormatSARIF, audit.FormatMarkdown, audit.FormatGitHub, audit.FormatCodeClimate, audit.FormatPrometheus:
+	default:
+		format = audit.FormatText
+	}
+	p := outputStyle(cmd)
+	strip, _ := cmd.Flags().GetString("path-prefix-strip")
+	summaryOnly, _ := cmd.Flags().GetBool("summary-only")
+	showSource, _ := cmd.Flags().GetBool("show-source")
+	encoder, err := audit.NewEncoder(os.Stdout, format, audit.TextOptions{
+		Color:           p.Color(),
+		ASCII:           p.ASCII(),
+		SummaryOnly:     summaryOnly,
+		ShowSource:      showSource,
+		PathPrefixStrip: strip,
+		Version:         version,
+		Hostname:        reportHostname(cmd),
+	})
+	if err != nil {
+		return err
+	}
+	return encoder.Encode(result)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+)
+
+var diffOverrideCmd = &cobra.Command{
+	Use:   "diff-override <unit>",
+	Short: "Show how a unit's override differs from the vendor unit file",
+	Long: `Compare the unit file in effect, such as a copy in /etc/systemd/system,
+with the vendor file it shadows in /usr/lib/systemd/system: the directives
+the override adds, removes and changes, grouped by section. The unit's
+drop-ins are listed separately.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDiffOverride,
+}
+
+func init() {
+	rootCmd.AddCommand(diffOverrideCmd)
+}
+
+func runDiffOverride(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q for diff-override (use text or json)", format)
+	}
+	root, _ := cmd.Flags().GetString("root")
+	diff, err := audit.DiffOverride(cmd.Context(), root, args[0])
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+	writeOverrideDiff(os.Stdout, outputStyle(cmd), diff)
+	return nil
+}
+
+// writeOverrideDiff writes a diff as -/+ lines grouped by section, removed
+// values in red and added ones in green
+func writeOverrideDiff(w io.Writer, p style.Provider, diff *audit.OverrideDiff) {
+	fmt.Fprintf(w, "%s\n", p.Bold(diff.Unit))
+	fmt.Fprintf(w, "Override: %s\n", diff.Override)
+	if diff.Runtime != "" {
+		fmt.Fprintf(w, "Runtime:  %s (shadowed runtime copy)\n", diff.Runtime)
+	}
+	base := "vendor file"
+	switch {
+	case diff.Vendor != "":
+		fmt.Fprintf(w, "Vendor:   %s\n", diff.Vendor)
+	case diff.Runtime != "":
+		fmt.Fprintln(w, "Vendor:   none, the changes are against the runtime copy")
+		base = "file in /run"
+	default:
+		fmt.Fprintln(w, "Vendor:   none, the unit does not override another unit file")
+	}
+	switch {
+	case diff.Masked:
+		fmt.Fprintln(w, "\nThe unit is masked.")
+	case (diff.Vendor != "" || diff.Runtime != "") && len(diff.Changes) == 0:
+		fmt.Fprintf(w, "\nNo directive differs: the override is a copy of the %s.\n", base)
+	}
+
+	section := ""
+	for _, c := range diff.Changes {
+		if c.Section != section {
+			section = c.Section
+			fmt.Fprintf(w, "\n[%s]\n", section)
+		}
+		for _, v := range c.Old {
+			fmt.Fprintln(w, p.Red(fmt.Sprintf("- %s=%s", c.Key, v)))
+		}
+		for _, v := range c.New {
+			fmt.Fprintln(w, p.Green(fmt.Sprintf("+ %s=%s", c.Key, v)))
+		}
+	}
+
+	if len(diff.DropIns) > 0 {
+		fmt.Fprintln(w, "\nDrop-ins:")
+		for _, path := range diff.DropIns {
+			fmt.Fprintf(w, "  %s\n", path)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+var listRulesCmd = &cobra.Command{
+	Use:   "list-rules [rule-id]",
+	Short: "List all available rules",
+	Long: `List the registered rules, grouped by category. --category, --tags and
+--severity select rules as they do for scan.
+
+Given a rule ID, the rule's full description, suggestion, references and
+the systemd version it needs are shown, with an example of a unit it
+reports and the same unit fixed.
+
+With -f json, every rule's metadata is written as a JSON array, or a single
+object for one rule, for scripts and documentation generators.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runListRules,
+}
+
+func init() {
+	rootCmd.AddCommand(listRulesCmd)
+}
+
+func runListRules(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q for list-rules (use text or json)", format)
+	}
+	severity, _ := cmd.Flags().GetString("severity")
+	category, _ := cmd.Flags().GetString("category")
+	tagsStr, _ := cmd.Flags().GetString("tags")
+	opts := buildOptions(severity, category, tagsStr)
+	opts.Profile, _ = cmd.Flags().GetString("profile")
+	if err := applyConfig(cmd, &opts); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		rule, ok := audit.LookupRule(strings.ToUpper(args[0]))
+		if !ok {
+			return fmt.Errorf("unknown rule %q", args[0])
+		}
+		rule = rule.WithParams(opts.RuleParams)
+		if format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(rule.JSON())
+		}
+		printRuleDetail(outputStyle(cmd), rule)
+		return nil
+	}
+
+	allRules, err := audit.RulesFor(opts)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return audit.EncodeRulesJSON(os.Stdout, allRules)
+	}
+
+	// Rules come sorted by ID; group them under one heading per category
+	sort.SliceStable(allRules, func(i, j int) bool {
+		return allRules[i].Category < allRules[j].Category
+	})
+	p := outputStyle(cmd)
+
+	if p.ASCII() {
+		fmt.Printf("\n%s\n", p.Heading(1, fmt.Sprintf("Registered Rules: %d", len(allRules))))
+	} else {
+		fmt.Printf("\nRegistered Rules: %d\n", len(allRules))
+		fmt.Println(strings.Repeat("=", 60))
+	}
+
+	currentCategory := types.Category(-1)
+	for _, rule := range allRules {
+		if rule.Category != currentCategory {
+			currentCategory = rule.Category
+			if p.ASCII() {
+				fmt.Printf("\n%s\n", p.Heading(2, currentCategory.String()))
+			} else {
+				fmt.Printf("\n[%s]\n", strings.ToUpper(currentCategory.String()))
+			}
+		}
+		fmt.Printf("  %-8s %-10s %-55s %s\n", rule.ID, "["+rule.Severity.String()+"]", rule.Name, strings.Join(rule.Profiles, ","))
+		if len(rule.Params) > 0 {
+			var params []string
+			for _, param := range rule.Params {
+				params = append(params, param.Name+"="+param.Value)
+			}
+			fmt.Printf("  %-8s %-10s %s\n", "", "", strings.Join(params, " "))
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// printRuleDetail prints everything known about one rule, for
+// 'list-rules <rule-id>'
+func printRuleDetail(p style.Provider, rule audit.RuleInfo) {
+	printSection(p, 1, fmt.Sprintf("%s: %s", rule.ID, rule.Name))
+	fmt.Printf("Category: %s\n", rule.Category)
+	fmt.Printf("Severity: %s\n", p.Severity(rule.Severity))
+	if len(rule.Tags) > 0 {
+		fmt.Printf("Tags:     %s\n", strings.Join(rule.Tags, ", "))
+	}
+	if rule.MinSystemdVersion > 0 {
+		fmt.Printf("Requires: systemd %d or later\n", rule.MinSystemdVersion)
+	}
+	if len(rule.UnitTypes) > 0 {
+		fmt.Printf("Units:    %s\n", strings.Join(rule.UnitTypes, ", "))
+	}
+	if len(rule.Profiles) > 0 {
+		fmt.Printf("Profiles: %s\n", strings.Join(rule.Profiles, ", "))
+	}
+	if rule.Origin != "" {
+		fmt.Printf("From:     %s\n", rule.Origin)
+	}
+	fmt.Printf("\n%s\n", rule.Description)
+
+	if rule.Suggestion != "" {
+		printSection(p, 2, "Suggestion")
+		fmt.Println(rule.Suggestion)
+	}
+	if len(rule.References) > 0 {
+		printSection(p, 2, "References")
+		for _, ref := range rule.References {
+			if ref.URL != "" {
+				fmt.Printf("  %s: %s\n", ref.Title, ref.URL)
+			} else {
+				fmt.Printf("  %s\n", ref.Title)
+			}
+		}
+	}
+	if len(rule.Params) > 0 {
+		printSection(p, 2, "Parameters")
+		for _, param := range rule.Params {
+			value := param.Value
+			if value != param.Default {
+				value += " (default " + param.Default + ")"
+			}
+			fmt.Printf("  %s = %s: %s\n", param.Name, value, param.Description)
+		}
+	}
+	if rule.ExampleBad != "" {
+		printSection(p, 2, "Reported")
+		fmt.Print(indentLines(rule.ExampleBad, "  "))
+		printSection(p, 2, "Fixed")
+		fmt.Print(indentLines(rule.ExampleGood, "  "))
+	}
+	fmt.Println()
+}
+
+// indentLines prefixes each non-empty line of s with indent
+func indentLines(s, indent string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "")
+}
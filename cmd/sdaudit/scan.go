@@ -0,0 +1,98 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/tui"
+	"github.com/supabase/sdaudit/pkg/audit"
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Perform a full system audit",
+	Long:  `Scan all systemd units and system configuration for issues.`,
+	RunE:  runScan,
+}
+
+func init() {
+	scanCmd.Flags().Bool("tui", false, "Launch interactive TUI after scan")
+	scanCmd.Flags().String("theme", "auto", "TUI colors: auto, dark, light, mono")
+	scanCmd.Flags().Bool("quick", false, "Only run rules that inspect a unit's own directives")
+	scanCmd.Flags().Bool("with-journal", false, "Read this boot's restart history from the journal")
+	scanCmd.Flags().Bool("no-graph", false, "Skip the rules that analyze dependencies between units")
+	scanCmd.Flags().Bool("include-runtime", false, "Add the generated and transient units under /run/systemd to the dependency graph (default: on without --root)")
+	scanCmd.Flags().Bool("no-progress", false, "Do not show a progress bar on stderr")
+	scanCmd.Flags().Bool("summary-only", false, "Print only the summary and the units with the worst issues (text format)")
+	scanCmd.Flags().Bool("show-source", false, "Print the lines of the unit file around each issue (text format)")
+	scanCmd.Flags().BoolP("quiet", "q", false, "Print nothing but errors; the exit status tells the result with --fail-on")
+	scanCmd.MarkFlagsMutuallyExclusive("quiet", "tui")
+	scanCmd.Flags().String("cache", "", "Reuse per-unit rule results from this directory for unchanged files (also SDAUDIT_CACHE)")
+	scanCmd.Flags().Bool("no-cache", false, "Check every unit again, ignoring --cache and SDAUDIT_CACHE")
+	scanCmd.Flags().String("baseline", "", "Leave out the issues acknowledged in this file; with --tui, acknowledge issues into it")
+	rootCmd.AddCommand(scanCmd)
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	severity, _ := cmd.Flags().GetString("severity")
+	category, _ := cmd.Flags().GetString("category")
+	tagsStr, _ := cmd.Flags().GetString("tags")
+	useTUI, _ := cmd.Flags().GetBool("tui")
+
+	opts := buildOptions(severity, category, tagsStr)
+	opts.Profile, _ = cmd.Flags().GetString("profile")
+	if err := applyConfig(cmd, &opts); err != nil {
+		return err
+	}
+
+	sdVersion, err := systemdVersion(cmd)
+	if err != nil {
+		return err
+	}
+	opts.SystemdVersion = sdVersion
+	opts.Root, _ = cmd.Flags().GetString("root")
+	opts.Quick, _ = cmd.Flags().GetBool("quick")
+	opts.Journal, _ = cmd.Flags().GetBool("with-journal")
+	opts.NoGraph, _ = cmd.Flags().GetBool("no-graph")
+	// /run of an offline image holds nothing of the system it would boot
+	opts.IncludeRuntime = opts.Root == ""
+	if cmd.Flags().Changed("include-runtime") {
+		opts.IncludeRuntime, _ = cmd.Flags().GetBool("include-runtime")
+	}
+	opts.CacheDir = cacheDir(cmd)
+
+	baselinePath, _ := cmd.Flags().GetString("baseline")
+	acked, err := loadBaseline(baselinePath)
+	if err != nil {
+		return err
+	}
+
+	stopProgress := startProgress(cmd, &opts)
+	result, err := audit.Scan(cmd.Context(), opts)
+	stopProgress()
+	if err != nil {
+		return err
+	}
+
+	if useTUI {
+		unitPaths := audit.UnitPaths(opts.Root)
+		if opts.IncludeRuntime {
+			unitPaths = audit.RuntimeUnitPaths(opts.Root)
+		}
+		in := tuiInput(cmd.Context(), result, opts, unitPaths)
+		in.Baseline, in.BaselinePath = acked, baselinePath
+		opts, err := tuiOptions(cmd)
+		if err != nil {
+			return err
+		}
+		return tui.Run(in, opts)
+	}
+	if acked != nil {
+		acked.Apply(result)
+	}
+
+	if err := outputResult(cmd, result, format); err != nil {
+		return err
+	}
+	return checkFailOn(cmd, result.Issues)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/pkg/audit"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema json",
+	Short: "Print the JSON Schema of a report format",
+	Long: `Print the JSON Schema document of the report scan and check write with
+-f json, for validating reports and generating code that reads them. The
+report's version field names the schema version it follows.`,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"json"},
+	RunE:      runSchema,
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	_, err := os.Stdout.Write(audit.JSONSchema())
+	return err
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+var securityCmd = &cobra.Command{
+	Use:   "security [unit | files...]",
+	Short: "Security scoring",
+	Long: `Run security analysis on systemd units using systemd-analyze security.
+
+When systemd-analyze is unavailable, when --root is set, or when given unit
+files or directories, scores are estimated from the unit files themselves and
+marked as estimated.
+
+With --max-score, the command exits non-zero when any service scores above
+the threshold, so unit files can be gated in CI before they reach a host.`,
+	RunE: runSecurity,
+}
+
+func init() {
+	securityCmd.Flags().Float64("max-score", 0, "Fail when any service's exposure score exceeds this (0 disables)")
+	rootCmd.AddCommand(securityCmd)
+}
+
+func runSecurity(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	root, _ := cmd.Flags().GetString("root")
+	maxScore, _ := cmd.Flags().GetFloat64("max-score")
+
+	var unitName string
+	if len(args) > 0 {
+		unitName = args[0]
+	}
+
+	var scores []audit.SecurityScore
+	var err error
+	switch {
+	case len(args) > 0 && isPath(args[0]):
+		var units map[string]*types.UnitFile
+		units, err = audit.LoadUnits(cmd.Context(), args...)
+		if err == nil {
+			scores, err = audit.EstimateSecurity(units, "")
+		}
+	case root != "":
+		var units map[string]*types.UnitFile
+		units, err = audit.LoadSystemUnits(cmd.Context(), root)
+		if err == nil {
+			scores, err = audit.EstimateSecurity(units, unitName)
+		}
+	default:
+		scores, err = audit.AnalyzeSecurity(cmd.Context(), unitName)
+	}
+	if err != nil {
+		return fmt.Errorf("security analysis failed: %w", err)
+	}
+
+	switch format {
+	case "json":
+		err = outputSecurityJSON(scores)
+	default:
+		err = outputSecurityText(scores, outputStyle(cmd))
+	}
+	if err != nil {
+		return err
+	}
+
+	if maxScore > 0 {
+		var over []string
+		for _, score := range scores {
+			if score.Score > maxScore {
+				over = append(over, fmt.Sprintf("%s (%.1f)", score.Unit, score.Score))
+			}
+		}
+		if len(over) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d service(s) exceed the maximum exposure score of %.1f: %s", len(over), maxScore, strings.Join(over, ", "))
+		}
+	}
+	return nil
+}
+
+func outputSecurityJSON(scores []audit.SecurityScore) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(scores)
+}
+
+func outputSecurityText(scores []audit.SecurityScore, p style.Provider) error {
+	printSection(p, 1, "Security Analysis")
+
+	if len(scores) == 0 {
+		fmt.Println("\nNo services analyzed.")
+		fmt.Println()
+		return nil
+	}
+
+	// Count by exposure level
+	counts := make(map[string]int)
+	var highRisk []audit.SecurityScore
+
+	for _, score := range scores {
+		counts[score.Exposure]++
+		if score.Score > 5.0 {
+			highRisk = append(highRisk, score)
+		}
+	}
+
+	fmt.Printf("\nTotal services analyzed: %d\n", len(scores))
+	if estimated(scores) {
+		fmt.Println("Scores marked (estimated) were computed from unit files, not by systemd-analyze.")
+	}
+	if p.ASCII() {
+		fmt.Printf("\n%s\n", p.Heading(2, "Exposure Summary"))
+	} else {
+		fmt.Println("\nExposure Summary:")
+	}
+	for _, level := range []string{"UNSAFE", "EXPOSED", "MEDIUM", "OK", "SAFE"} {
+		if counts[level] > 0 {
+			fmt.Printf("  %-8s  %d\n", level, counts[level])
+		}
+	}
+
+	if len(highRisk) > 0 {
+		printSection(p, 2, "High Risk Services (score > 5.0)")
+		for _, score := range highRisk {
+			line := fmt.Sprintf("  %.1f %-8s  %s", score.Score, score.Exposure, score.Unit)
+			if score.Estimated {
+				line += " (estimated)"
+			}
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func estimated(scores []audit.SecurityScore) bool {
+	for _, score := range scores {
+		if score.Estimated {
+			return true
+		}
+	}
+	return false
+}
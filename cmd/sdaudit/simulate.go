@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate --unit <unit> [--set Section.Key=value]... [--patch file.conf]",
+	Short: "Preview what a change to a unit would change in the findings",
+	Long: `Scan the units as scan does, then again with a change made to one of them,
+and report the difference: the issues the change introduces and resolves,
+matched by fingerprint as compare does, the units whose critical path gets
+longer or shorter, and the timeout cascade risks it adds or removes. The unit
+files are not modified.
+
+--set Section.Key=value assigns a directive after the unit's own
+assignments, as a drop-in would: it overrides a directive that takes one
+value, such as Restart=, and adds to a list, such as After=. An empty value
+resets a list. --set Section.Key=- removes every assignment of the directive.
+--patch applies a drop-in file to the unit before the --set changes.
+
+With --fail-on-new, exit non-zero when the change introduces an issue at or
+above the severity given, to gate a change on review.`,
+	Example: `  sdaudit simulate --unit app.service --set Service.Restart=always --set Unit.After=db.service
+  sdaudit simulate --unit app.service --patch override.conf --fail-on-new high`,
+	Args: cobra.NoArgs,
+	RunE: runSimulate,
+}
+
+func init() {
+	simulateCmd.Flags().String("unit", "", "Unit to change, such as app.service")
+	simulateCmd.Flags().StringArray("set", nil, "Assign a directive, as Section.Key=value, or remove it with Section.Key=- (repeatable)")
+	simulateCmd.Flags().String("patch", "", "Apply this drop-in file to the unit")
+	simulateCmd.Flags().String("fail-on-new", "", "Exit non-zero when the change introduces an issue at or above this severity: critical, high, medium, low, info")
+	rootCmd.AddCommand(simulateCmd)
+}
+
+func runSimulate(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q for simulate (use text or json)", format)
+	}
+	failOnNew, _ := cmd.Flags().GetString("fail-on-new")
+	threshold := types.ParseSeverity(failOnNew)
+	if failOnNew != "" && threshold.String() != failOnNew {
+		return fmt.Errorf("unknown --fail-on-new severity %q", failOnNew)
+	}
+
+	change := audit.UnitChange{Edits: []audit.DirectiveEdit{}}
+	change.Unit, _ = cmd.Flags().GetString("unit")
+	change.Patch, _ = cmd.Flags().GetString("patch")
+	sets, _ := cmd.Flags().GetStringArray("set")
+	if change.Unit == "" {
+		return fmt.Errorf("simulate needs the unit to change, such as --unit app.service")
+	}
+	if len(sets) == 0 && change.Patch == "" {
+		return fmt.Errorf("simulate needs a change, with --set or --patch")
+	}
+	for _, set := range sets {
+		edit, err := audit.ParseDirectiveEdit(set)
+		if err != nil {
+			return err
+		}
+		change.Edits = append(change.Edits, edit)
+	}
+
+	severity, _ := cmd.Flags().GetString("severity")
+	category, _ := cmd.Flags().GetString("category")
+	tagsStr, _ := cmd.Flags().GetString("tags")
+	opts := buildOptions(severity, category, tagsStr)
+	opts.Profile, _ = cmd.Flags().GetString("profile")
+	if err := applyConfig(cmd, &opts); err != nil {
+		return err
+	}
+	sdVersion, err := systemdVersion(cmd)
+	if err != nil {
+		return err
+	}
+	opts.SystemdVersion = sdVersion
+	opts.Root, _ = cmd.Flags().GetString("root")
+	opts.IncludeRuntime = opts.Root == ""
+
+	sim, err := audit.Simulate(cmd.Context(), change, opts)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		if err := outputSimulateJSON(sim); err != nil {
+			return err
+		}
+	} else {
+		outputSimulateText(sim, outputStyle(cmd))
+	}
+
+	if failOnNew == "" {
+		return nil
+	}
+	count := 0
+	for _, issue := range sim.Issues.New {
+		if issue.Severity >= threshold {
+			count++
+		}
+	}
+	if count > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("the change introduces %d issue(s) at or above %s severity", count, failOnNew)
+	}
+	return nil
+}
+
+func outputSimulateJSON(sim *audit.Simulation) error {
+	convert := func(issues []types.Issue) []audit.JSONIssue {
+		out := make([]audit.JSONIssue, len(issues))
+		for i, issue := range issues {
+			out[i] = audit.NewJSONIssue(issue)
+		}
+		return out
+	}
+	type counts struct {
+		New      int `json:"new"`
+		Resolved int `json:"resolved"`
+	}
+	output := struct {
+		audit.UnitChange
+		Counts           counts                     `json:"counts"`
+		NewIssues        []audit.JSONIssue          `json:"new_issues"`
+		Resolved         []audit.JSONIssue          `json:"resolved_issues"`
+		CriticalPaths    []audit.CriticalPathChange `json:"critical_paths"`
+		NewCascades      []audit.CascadeRisk        `json:"new_cascade_risks"`
+		ResolvedCascades []audit.CascadeRisk        `json:"resolved_cascade_risks"`
+	}{
+		UnitChange:       sim.Change,
+		Counts:           counts{len(sim.Issues.New), len(sim.Issues.Resolved)},
+		NewIssues:        convert(sim.Issues.New),
+		Resolved:         convert(sim.Issues.Resolved),
+		CriticalPaths:    sim.CriticalPaths,
+		NewCascades:      sim.NewCascades,
+		ResolvedCascades: sim.ResolvedCascades,
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+func outputSimulateText(sim *audit.Simulation, p style.Provider) {
+	printSection(p, 1, "Change Simulation")
+	fmt.Printf("\nUnit: %s\n", sim.Change.Unit)
+	if sim.Change.Patch != "" {
+		fmt.Printf("Patch: %s\n", sim.Change.Patch)
+	}
+	for _, edit := range sim.Change.Edits {
+		fmt.Printf("Set: %s\n", edit)
+	}
+	fmt.Printf("\n%s new, %s resolved\n", p.Red(fmt.Sprint(len(sim.Issues.New))), p.Green(fmt.Sprint(len(sim.Issues.Resolved))))
+
+	printIssueList(p, "New Issues", sim.Issues.New, p.Red("+"))
+	printIssueList(p, "Resolved Issues", sim.Issues.Resolved, p.Green("-"))
+
+	if len(sim.CriticalPaths) > 0 {
+		printSection(p, 2, fmt.Sprintf("Critical Path Changes (%d)", len(sim.CriticalPaths)))
+		arrow := " " + p.Text("→") + " "
+		for _, c := range sim.CriticalPaths {
+			delta := audit.FormatDuration(c.Delta().Abs())
+			if c.Delta() >= 0 {
+				delta = p.Red("+" + delta)
+			} else {
+				delta = p.Green("-" + delta)
+			}
+			fmt.Printf("  %s: %s to %s (%s)\n", c.Unit, audit.FormatDuration(c.Before.TotalTime), audit.FormatDuration(c.After.TotalTime), delta)
+			fmt.Printf("          Path: %s\n", strings.ReplaceAll(c.After.PathDescription(), " -> ", arrow))
+		}
+	}
+
+	printRisks := func(title string, risks []audit.CascadeRisk, mark string) {
+		if len(risks) == 0 {
+			return
+		}
+		printSection(p, 2, fmt.Sprintf("%s (%d)", title, len(risks)))
+		for _, risk := range risks {
+			fmt.Printf("  %s [%s] %s: %s\n", mark, strings.ToUpper(risk.Risk), risk.Unit, risk.Description)
+		}
+	}
+	printRisks("New Cascade Risks", sim.NewCascades, p.Red("+"))
+	printRisks("Resolved Cascade Risks", sim.ResolvedCascades, p.Green("-"))
+	fmt.Println()
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/audit"
+)
+
+var timingCmd = &cobra.Command{
+	Use:   "timing [unit]",
+	Short: "Analyze startup timeouts and critical paths",
+	Long: `Analyze worst-case startup timing from unit files.
+
+Each unit's critical path is the longest chain of After= dependencies leading
+to it, timed by each unit's TimeoutStartSec= (falling back to the defaults in
+system.conf). Without arguments, the longest critical paths, the units that
+are most often their bottleneck and the timeout cascade risks are reported.
+Given a unit, its timeouts, critical path and risks are shown.
+
+With --threshold, only critical paths longer than the given duration are
+listed.
+
+With --observed, the critical paths are chosen by the time each unit took to
+start at boot, as the boot command measures it, instead of by its timeout,
+and their worst case is shown beside them. Units that did not start count as
+starting at once. Cascade risks are still computed from the timeouts.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTiming,
+}
+
+func init() {
+	timingCmd.Flags().Duration("threshold", 0, "Only show critical paths longer than this, e.g. 2m")
+	timingCmd.Flags().Bool("observed", false, "Time critical paths by the start times measured at boot instead of the timeouts")
+	rootCmd.AddCommand(timingCmd)
+}
+
+// timingTopPaths is how many critical paths and bottlenecks the timing
+// overview lists without --threshold
+const timingTopPaths = 10
+
+func runTiming(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	root, _ := cmd.Flags().GetString("root")
+	threshold, _ := cmd.Flags().GetDuration("threshold")
+	observed, _ := cmd.Flags().GetBool("observed")
+
+	if observed && root != "" {
+		return fmt.Errorf("--observed measures the running system and cannot be combined with --root")
+	}
+
+	units, err := audit.LoadSystemUnits(cmd.Context(), root)
+	if err != nil {
+		return err
+	}
+	t, err := audit.NewTiming(units, root)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		analysis := t.Unit(args[0])
+		if analysis == nil {
+			return fmt.Errorf("unit %s not found", args[0])
+		}
+		switch format {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(analysis)
+		default:
+			fmt.Print(outputStyle(cmd).Text(analysis.Summary()))
+			return nil
+		}
+	}
+
+	paths := t.CriticalPaths(nil)
+	cascades := t.Cascades(paths)
+	if observed {
+		analysis, err := audit.AnalyzeBoot(cmd.Context(), audit.BootOptions{Journal: true, Boots: 5, Fallback: true})
+		if err != nil {
+			return fmt.Errorf("boot analysis failed: %w", err)
+		}
+		paths = t.CriticalPaths(analysis.ObservedTimes())
+	}
+
+	longest := paths.PathsExceedingThreshold(threshold)
+	bottlenecks := paths.BottleneckUnits
+	if threshold == 0 && len(longest) > timingTopPaths {
+		longest = longest[:timingTopPaths]
+	}
+	if len(bottlenecks) > timingTopPaths {
+		bottlenecks = bottlenecks[:timingTopPaths]
+	}
+
+	switch format {
+	case "json":
+		output := struct {
+			UnitCount       int                  `json:"unit_count"`
+			Weighting       string               `json:"weighting"`
+			Threshold       time.Duration        `json:"threshold,omitempty"`
+			CriticalPaths   []audit.CriticalPath `json:"critical_paths"`
+			BottleneckUnits []string             `json:"bottleneck_units"`
+			Cascades        audit.CascadeResult  `json:"cascades"`
+		}{
+			UnitCount:       len(units),
+			Weighting:       "timeout",
+			Threshold:       threshold,
+			CriticalPaths:   longest,
+			BottleneckUnits: bottlenecks,
+			Cascades:        cascades,
+		}
+		if observed {
+			output.Weighting = "observed"
+		}
+		if output.CriticalPaths == nil {
+			output.CriticalPaths = []audit.CriticalPath{}
+		}
+		if output.BottleneckUnits == nil {
+			output.BottleneckUnits = []string{}
+		}
+		if output.Cascades.Risks == nil {
+			output.Cascades.Risks = []audit.CascadeRisk{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(output)
+	default:
+		return outputTimingText(len(units), threshold, observed, longest, bottlenecks, cascades, outputStyle(cmd))
+	}
+}
+
+func outputTimingText(unitCount int, threshold time.Duration, observed bool, paths []audit.CriticalPath, bottlenecks []string, cascades audit.CascadeResult, p style.Provider) error {
+	printSection(p, 1, "Timing Analysis")
+	fmt.Printf("\nTotal units: %d\n", unitCount)
+
+	title := "Longest Critical Paths"
+	if threshold > 0 {
+		title = fmt.Sprintf("Critical Paths Over %s (%d)", audit.FormatDuration(threshold), len(paths))
+	}
+	if observed {
+		title += " (observed start times)"
+	}
+	printSection(p, 2, title)
+	if len(paths) == 0 {
+		fmt.Println("  None")
+	}
+	for _, path := range paths {
+		fmt.Printf("  %-10s %s\n", audit.FormatDuration(path.TotalTime), strings.ReplaceAll(path.PathDescription(), " -> ", " "+p.Text("→")+" "))
+		if observed {
+			var unobserved []string
+			for _, node := range path.Path {
+				if node.Unobserved {
+					unobserved = append(unobserved, node.Unit)
+				}
+			}
+			fmt.Printf("             Worst case: %s\n", audit.FormatDuration(path.WorstCase))
+			if len(unobserved) > 0 {
+				fmt.Printf("             No start time: %s\n", strings.Join(unobserved, ", "))
+			}
+		}
+		if path.Bottleneck != "" && len(path.Path) > 1 {
+			fmt.Printf("             Bottleneck: %s\n", path.Bottleneck)
+		}
+	}
+
+	if len(bottlenecks) > 0 {
+		printSection(p, 2, "Bottleneck Units")
+		for _, unit := range bottlenecks {
+			fmt.Printf("  %s\n", unit)
+		}
+	}
+
+	printSection(p, 2, fmt.Sprintf("Cascade Risks (%d)", cascades.TotalRisks))
+	if cascades.TotalRisks == 0 {
+		fmt.Println("  None")
+	}
+	for _, level := range []string{"critical", "high", "medium", "low"} {
+		var risks []audit.CascadeRisk
+		for _, risk := range cascades.Risks {
+			if risk.Risk == level {
+				risks = append(risks, risk)
+			}
+		}
+		if len(risks) == 0 {
+			continue
+		}
+		fmt.Printf("\n  %s (%d)\n", strings.ToUpper(level), len(risks))
+		for _, risk := range risks {
+			fmt.Printf("    %s: %s\n", risk.Unit, risk.Description)
+			if risk.Recommendation != "" {
+				fmt.Printf("          Suggestion: %s\n", risk.Recommendation)
+			}
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
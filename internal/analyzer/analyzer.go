@@ -1,11 +1,24 @@
 package analyzer
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/supabase/sdaudit/internal/cache"
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/journal"
 	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/internal/validation"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -13,8 +26,45 @@ import (
 type Analyzer struct {
 	config    *rules.Config
 	unitPaths []string
+	// linkDirs are the directories whose dependency and alias links the
+	// graph gets, the unit paths unless LoadFiles read other directories
+	linkDirs       []string
+	systemdVersion int
+	root           string
+	quick          bool
+	noGraph        bool
+	systemConf     *timing.SystemConfig
+	// graph is the dependency graph of the scanned units, nil if it is not analyzed
+	graph *graph.Graph
+	// timeouts are the parsed timeouts of the scanned units, shared by their contexts
+	timeouts map[string]timing.TimeoutConfig
+	// fs is the filesystem rules look paths, users and groups up in, shared
+	// by the contexts of a scan so that each path is stat'ed once
+	fs validation.FileSystem
+	// live is set when scanning the running system, where live unit state
+	// is expected, and runtime once it has been collected
+	live      bool
+	runtime   bool
+	journal   journal.Reader
+	progress  ProgressFunc
+	cacheDir  string
+	version   string
+	stdin     io.Reader
+	stdinName string
 }
 
+// Stages of a scan reported to a ProgressFunc, in the order they run.
+const (
+	StageLoad  = "load"  // Reading the unit directories and paths
+	StageParse = "parse" // Parsing each unit file
+	StageCheck = "check" // Running the rules on each unit
+)
+
+// ProgressFunc is called as a scan advances through a stage, with the number
+// of items done so far and the total for the stage. It is called from the
+// goroutine running the scan.
+type ProgressFunc func(stage string, done, total int)
+
 // Options configures the analyzer
 type Options struct {
 	UnitPaths   []string
@@ -22,6 +72,37 @@ type Options struct {
 	Category    *types.Category
 	MinSeverity *types.Severity
 	Tags        []string
+	// Profile names the bundled profile Config was built from, for reports
+	Profile string
+	// SystemdVersion is the major systemd version of the target, 0 if unknown
+	SystemdVersion int
+	// Root is the root directory of an offline system image, empty for the live system
+	Root string
+	// IncludeRuntime also loads the units generators and systemd-run write
+	// under /run/systemd. They are part of the graph and the cross-unit
+	// rules, but their own files are not checked.
+	IncludeRuntime bool
+	// Quick runs only rules that inspect a unit's own directives
+	Quick bool
+	// Journal reads restart history for the current boot from the journal
+	Journal bool
+	// NoGraph skips the rules that analyze the dependency graph of all units
+	NoGraph bool
+	// ScoreWeights are the points an issue of each severity takes off its
+	// unit's score; nil uses types.DefaultScoreWeights
+	ScoreWeights types.ScoreWeights
+	// Progress, if set, is called as units are loaded, parsed and checked
+	Progress ProgressFunc
+	// CacheDir, if set, keeps the issues of per-unit rules between runs, so
+	// that only changed unit files are checked again by them
+	CacheDir string
+	// Version is the sdaudit version, part of the cache key so that an
+	// upgrade does not reuse the issues of older rules
+	Version string
+	// Stdin is read for the path "-" in LoadFiles, as the unit named
+	// StdinName, such as "app.service"
+	Stdin     io.Reader
+	StdinName string
 }
 
 // New creates a new Analyzer with the given options
@@ -29,6 +110,14 @@ func New(opts Options) *Analyzer {
 	paths := opts.UnitPaths
 	if len(paths) == 0 {
 		paths = DefaultUnitPaths()
+		if opts.IncludeRuntime {
+			paths = RuntimeUnitPaths()
+		}
+		if opts.Root != "" {
+			for i, p := range paths {
+				paths[i] = filepath.Join(opts.Root, p)
+			}
+		}
 	}
 
 	config := opts.Config
@@ -36,33 +125,41 @@ func New(opts Options) *Analyzer {
 		config = rules.DefaultConfig()
 	}
 
-	return &Analyzer{
-		config:    config,
-		unitPaths: paths,
+	a := &Analyzer{
+		config:         config,
+		unitPaths:      paths,
+		linkDirs:       paths,
+		systemdVersion: opts.SystemdVersion,
+		root:           opts.Root,
+		quick:          opts.Quick,
+		noGraph:        opts.NoGraph,
+		progress:       opts.Progress,
+		cacheDir:       opts.CacheDir,
+		version:        opts.Version,
+		stdin:          opts.Stdin,
+		stdinName:      opts.StdinName,
+	}
+	if opts.Journal {
+		a.journal = journal.Journalctl{}
 	}
+	return a
 }
 
 // ScanResult contains the results of a scan
-type ScanResult struct {
-	Units   []*types.UnitFile
-	Issues  []types.Issue
-	Summary Summary
-}
+type ScanResult = types.ScanResult
 
 // Summary provides aggregate statistics
-type Summary struct {
-	TotalUnits   int
-	TotalIssues  int
-	BySeverity   map[types.Severity]int
-	ByCategory   map[types.Category]int
-	RulesChecked int
-}
+type Summary = types.Summary
 
-// Scan performs a full system audit
-func (a *Analyzer) Scan(opts Options) (*ScanResult, error) {
-	allUnits, err := LoadUnitsFromPaths(a.unitPaths)
+// Scan performs a full system audit. It stops with ctx's error when ctx is
+// cancelled before the rules have run on every unit.
+func (a *Analyzer) Scan(ctx context.Context, opts Options) (*ScanResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	allUnits, err := a.loadPaths(ctx, a.unitPaths)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load units: %w", err)
+		return nil, err
 	}
 
 	if len(allUnits) == 0 {
@@ -74,160 +171,581 @@ func (a *Analyzer) Scan(opts Options) (*ScanResult, error) {
 		}, nil
 	}
 
-	var allIssues []types.Issue
-	var units []*types.UnitFile
+	if err := a.prepare(ctx, allUnits); err != nil {
+		return nil, err
+	}
+	return a.scanUnits(ctx, allUnits, opts)
+}
 
-	for _, unit := range allUnits {
-		units = append(units, unit)
+// prepare reads system.conf and, on the running system, the live state of
+// the units
+func (a *Analyzer) prepare(ctx context.Context, allUnits map[string]*types.UnitFile) error {
+	systemConf, err := timing.LoadSystemConfig(a.root)
+	if err != nil {
+		return fmt.Errorf("failed to load system.conf: %w", err)
+	}
+	a.systemConf = systemConf
 
-		ctx := rules.NewContextWithUnits(unit, allUnits)
-		ctx.Config = a.config
+	// Live state only describes the target when scanning the running system
+	if a.root == "" && !a.quick {
+		a.live = true
+		a.runtime = CollectRuntimeState(ctx, allUnits) == nil
 
-		var issues []types.Issue
-		if opts.Category != nil || opts.MinSeverity != nil || len(opts.Tags) > 0 {
-			issues = rules.RunFiltered(ctx, opts.Category, opts.MinSeverity, opts.Tags)
-		} else {
-			issues = rules.RunAll(ctx)
+		if a.journal != nil {
+			if err := a.collectJournal(ctx, allUnits); err != nil {
+				return fmt.Errorf("failed to read journal: %w", err)
+			}
 		}
-
-		allIssues = append(allIssues, issues...)
 	}
+	return nil
+}
 
-	sort.Slice(units, func(i, j int) bool {
-		return units[i].Name < units[j].Name
-	})
+// scanUnits builds the dependency graph of the units and runs the rules on
+// the unit files among them
+func (a *Analyzer) scanUnits(ctx context.Context, allUnits map[string]*types.UnitFile, opts Options) (*ScanResult, error) {
+	if a.unavailable()&rules.CapabilityGraph == 0 {
+		a.graph = a.BuildGraph(allUnits)
+	}
 
-	sort.Slice(allIssues, func(i, j int) bool {
-		if allIssues[i].Severity != allIssues[j].Severity {
-			return allIssues[i].Severity > allIssues[j].Severity
+	// Generated and transient units are rewritten by systemd, so only the
+	// unit files are checked and scored
+	var units []*types.UnitFile
+	for _, unit := range allUnits {
+		if unit.Origin == "" {
+			units = append(units, unit)
 		}
-		return allIssues[i].Unit < allIssues[j].Unit
-	})
-
-	summary := Summary{
-		TotalUnits:   len(units),
-		TotalIssues:  len(allIssues),
-		BySeverity:   make(map[types.Severity]int),
-		ByCategory:   make(map[types.Category]int),
-		RulesChecked: rules.Count(),
 	}
 
-	for _, issue := range allIssues {
-		summary.BySeverity[issue.Severity]++
-		summary.ByCategory[issue.Category]++
-	}
+	sort.Slice(units, func(i, j int) bool {
+		return units[i].Name < units[j].Name
+	})
 
-	return &ScanResult{
-		Units:   units,
-		Issues:  allIssues,
-		Summary: summary,
-	}, nil
+	return a.run(ctx, units, allUnits, opts)
 }
 
 // LoadUnits loads all units from the configured paths and returns them as a map.
-func (a *Analyzer) LoadUnits() (map[string]*types.UnitFile, error) {
-	return LoadUnitsFromPaths(a.unitPaths)
+func (a *Analyzer) LoadUnits(ctx context.Context) (map[string]*types.UnitFile, error) {
+	return a.loadPaths(ctx, a.unitPaths)
 }
 
-// LoadFiles loads units from specific files or directories.
-func (a *Analyzer) LoadFiles(paths []string) (map[string]*types.UnitFile, error) {
-	allUnits := make(map[string]*types.UnitFile)
+// BuildGraph builds the dependency graph of units loaded from the configured
+// paths, or by LoadFiles, with the dependencies and aliases that symlinks in
+// their directories add and the configured synchronization units
+func (a *Analyzer) BuildGraph(units map[string]*types.UnitFile) *graph.Graph {
+	g := graph.Build(units)
+	addLinks(g, unitfile.LoadLinks(a.linkDirs))
+	g.AddSynchronizationUnits(a.config.SynchronizationUnits...)
+	return g
+}
 
-	for _, path := range paths {
-		info, err := os.Stat(path)
-		if err != nil {
-			return nil, fmt.Errorf("cannot access %s: %w", path, err)
+// LoadFiles loads units from specific files, directories, which are searched
+// recursively, and glob patterns, expanded here for shells that do not.
+// Symlinks are followed, and a file reached through several of them is
+// loaded once. Units get the drop-ins in .d directories next to them.
+func (a *Analyzer) LoadFiles(paths []string) (map[string]*types.UnitFile, error) {
+	var files, dirs, linkDirs []string
+	// explicit holds the files named directly, which must load
+	explicit := make(map[string]bool)
+	var stdinUnit *types.UnitFile
+
+	for i, pattern := range paths {
+		if pattern == "-" {
+			unit, err := a.readStdin()
+			if err != nil {
+				return nil, err
+			}
+			stdinUnit = unit
+			a.report(StageLoad, i+1, len(paths))
+			continue
 		}
 
-		if info.IsDir() {
-			dirUnits, err := LoadUnitsFromDirectory(path)
+		matches := []string{pattern}
+		if _, err := os.Stat(pattern); err != nil && isGlob(pattern) {
+			matches, err = filepath.Glob(pattern)
 			if err != nil {
-				return nil, fmt.Errorf("failed to load units from %s: %w", path, err)
+				return nil, fmt.Errorf("bad pattern %s: %w", pattern, err)
 			}
-			for name, unit := range dirUnits {
-				allUnits[name] = unit
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", pattern)
 			}
-		} else {
-			unit, err := ParseUnitFile(path)
+		}
+
+		for _, path := range matches {
+			info, err := os.Stat(path)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+				return nil, fmt.Errorf("cannot access %s: %w", path, err)
+			}
+
+			if info.IsDir() {
+				dirFiles, err := unitfile.Walk(path)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load units from %s: %w", path, err)
+				}
+				files = append(files, dirFiles...)
+				dirs = append(dirs, path)
+				linkDirs = append(linkDirs, path)
+				for _, file := range dirFiles {
+					if dir := filepath.Dir(file); !slices.Contains(linkDirs, dir) {
+						linkDirs = append(linkDirs, dir)
+					}
+				}
+			} else if unitfile.IsUnitFile(path) || pattern == path {
+				files = append(files, path)
+				explicit[path] = true
 			}
-			allUnits[unit.Name] = unit
 		}
+		a.report(StageLoad, i+1, len(paths))
+	}
+
+	set := unitfile.NewSet(a.root, dirs...)
+	for i, path := range files {
+		if err := set.Load(path); err != nil && explicit[path] {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		a.report(StageParse, i+1, len(files))
+	}
+	set.ApplyDropIns()
+	unitfile.MarkEnabled(set.Units, unitfile.LoadLinks(linkDirs))
+	a.linkDirs = linkDirs
+	if stdinUnit != nil {
+		set.Units[stdinUnit.Name] = stdinUnit
 	}
 
-	return allUnits, nil
+	return set.Units, nil
 }
 
-// CheckFiles checks specific unit files
-func (a *Analyzer) CheckFiles(paths []string, opts Options) (*ScanResult, error) {
-	allUnits := make(map[string]*types.UnitFile)
-	var units []*types.UnitFile
+// readStdin parses the unit given on stdin for the path "-"
+func (a *Analyzer) readStdin() (*types.UnitFile, error) {
+	if a.stdin == nil {
+		return nil, fmt.Errorf("no standard input to read a unit from")
+	}
+	if !unitfile.IsUnitFile(a.stdinName) || filepath.Base(a.stdinName) != a.stdinName {
+		return nil, fmt.Errorf("a unit read from stdin needs a unit name such as app.service, not %q", a.stdinName)
+	}
+	content, err := io.ReadAll(a.stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	unit, err := unitfile.ParseContent(a.stdinName, string(content))
+	if err != nil {
+		return nil, err
+	}
+	unit.Path = types.StdinPath
+	return unit, nil
+}
+
+// isGlob reports whether path is a pattern for filepath.Glob
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// loadPaths loads the units in the unit search paths, skipping paths and
+// files that cannot be read as LoadUnitsFromPaths does, and reports progress.
+// A file reached through several paths is loaded once, and of the files of
+// the same name the one in the earliest path wins.
+func (a *Analyzer) loadPaths(ctx context.Context, paths []string) (map[string]*types.UnitFile, error) {
+	if err := a.checkRoot(); err != nil {
+		return nil, err
+	}
+	var files []string
+	for i, path := range paths {
+		if pathFiles, err := unitfile.Files(path); err == nil {
+			files = append(files, pathFiles...)
+		}
+		a.report(StageLoad, i+1, len(paths))
+	}
+
+	set := unitfile.NewSet(a.root, paths...)
+	for i, path := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		_ = set.Load(path)
+		a.report(StageParse, i+1, len(files))
+	}
+	set.ApplyDropIns()
+	unitfile.MarkEnabled(set.Units, unitfile.LoadLinks(paths))
+	return set.Units, nil
+}
 
-	for _, path := range paths {
-		info, err := os.Stat(path)
+// checkRoot returns an error when the root of an offline image is missing or
+// not a directory. The unit paths below it would all be skipped as missing,
+// and the image would pass with nothing audited.
+func (a *Analyzer) checkRoot() error {
+	if a.root == "" {
+		return nil
+	}
+	info, err := os.Stat(a.root)
+	if err != nil {
+		return fmt.Errorf("cannot access root: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("root %s is not a directory", a.root)
+	}
+	return nil
+}
+
+// report passes progress to the ProgressFunc, if there is one
+func (a *Analyzer) report(stage string, done, total int) {
+	if a.progress != nil {
+		a.progress(stage, done, total)
+	}
+}
+
+// CheckFiles checks specific unit files or the units in directories
+func (a *Analyzer) CheckFiles(ctx context.Context, paths []string, opts Options) (*ScanResult, error) {
+	allUnits, err := a.LoadFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	return a.CheckUnits(ctx, allUnits, opts)
+}
+
+// CheckUnits runs the rules on units loaded by the caller, with the other
+// units as the set cross-unit rules and the dependency graph look at. Live
+// state is not collected.
+func (a *Analyzer) CheckUnits(ctx context.Context, allUnits map[string]*types.UnitFile, opts Options) (*ScanResult, error) {
+	if err := a.checkRoot(); err != nil {
+		return nil, err
+	}
+	if a.unavailable()&rules.CapabilityGraph == 0 {
+		a.graph = a.BuildGraph(allUnits)
+	}
+
+	units := make([]*types.UnitFile, 0, len(allUnits))
+	for _, unit := range allUnits {
+		units = append(units, unit)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		return units[i].Name < units[j].Name
+	})
+
+	// Host configuration only describes the target when auditing an image
+	if a.root != "" {
+		systemConf, err := timing.LoadSystemConfig(a.root)
 		if err != nil {
-			return nil, fmt.Errorf("cannot access %s: %w", path, err)
+			return nil, fmt.Errorf("failed to load system.conf: %w", err)
 		}
+		a.systemConf = systemConf
+	}
 
-		if info.IsDir() {
-			dirUnits, err := LoadUnitsFromDirectory(path)
-			if err != nil {
-				return nil, fmt.Errorf("failed to load units from %s: %w", path, err)
-			}
-			for name, unit := range dirUnits {
-				allUnits[name] = unit
-				units = append(units, unit)
-			}
-		} else {
-			unit, err := ParseUnitFile(path)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	return a.run(ctx, units, allUnits, opts)
+}
+
+// run executes the rules against units and builds the result
+func (a *Analyzer) run(ctx context.Context, units []*types.UnitFile, allUnits map[string]*types.UnitFile, opts Options) (*ScanResult, error) {
+	var allIssues []types.Issue
+	a.timeouts = timing.ParseAllTimeouts(allUnits, a.systemConf)
+	a.fs = validation.NewRealFileSystem(a.root)
+	hostCtx := a.newContext(nil, allUnits)
+
+	// Aggregate rules summarize the findings of every rule, so when one runs
+	// the per-unit rules run unfiltered and their findings are filtered after
+	unitOpts := opts
+	aggregate := aggregating(hostCtx, opts)
+	if aggregate {
+		unitOpts.Category, unitOpts.MinSeverity, unitOpts.Tags = nil, nil, nil
+	}
+	c := a.openCache(unitOpts)
+
+	for i, unit := range units {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		unitCtx := a.newContext(unit, allUnits)
+
+		allIssues = append(allIssues, a.checkUnit(unitCtx, c, unitOpts)...)
+		a.report(StageCheck, i+1, len(units))
+	}
+
+	if c != nil {
+		if err := c.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	hostIssues := rules.RunHost(hostCtx, opts.Category, opts.MinSeverity, opts.Tags)
+	aggregated := rules.RunAggregate(hostCtx, append(slices.Clone(allIssues), hostIssues...), opts.Category, opts.MinSeverity, opts.Tags)
+	if aggregate {
+		allIssues = filterIssues(allIssues, opts)
+	}
+	allIssues = append(append(allIssues, hostIssues...), aggregated...)
+	runtimeSuggestions(allIssues, allUnits)
+
+	// Rules are filtered by their default severity; drop the findings of
+	// rules that rate each finding on its own
+	if opts.MinSeverity != nil {
+		kept := allIssues[:0]
+		for _, issue := range allIssues {
+			if issue.Severity >= *opts.MinSeverity {
+				kept = append(kept, issue)
 			}
-			allUnits[unit.Name] = unit
-			units = append(units, unit)
 		}
+		allIssues = kept
 	}
 
-	var allIssues []types.Issue
+	// Parse errors are reported whatever the filters: the rules could not
+	// see the lines they are on
+	parseErrors := 0
+	for _, unit := range units {
+		allIssues = append(allIssues, parseIssues(unit)...)
+		parseErrors += len(unit.ParseErrors)
+	}
+
+	// The same units give the same report: tags and issues are sorted
+	for i := range allIssues {
+		if !slices.IsSorted(allIssues[i].Tags) {
+			allIssues[i].Tags = slices.Sorted(slices.Values(allIssues[i].Tags))
+		}
+	}
+	allIssues = types.SortIssues(allIssues, types.OrderSeverity)
+
+	skipped := len(rules.SkippedForVersion(a.systemdVersion))
+
+	summary := Summary{
+		TotalUnits:     len(units),
+		RulesChecked:   rules.Count() - skipped,
+		RulesSkipped:   skipped,
+		SystemdVersion: a.systemdVersion,
+		Quick:          a.quick,
+		ParseErrors:    parseErrors,
+		RulesRun:       rulesRun(hostCtx, opts),
+		Options: types.ScanOptions{
+			MinSeverity: opts.MinSeverity,
+			Category:    opts.Category,
+			Tags:        opts.Tags,
+			Profile:     opts.Profile,
+		},
+	}
+
+	for _, rule := range rules.SkippedForCapabilities(a.unavailable()) {
+		if rules.Supported(rule, a.systemdVersion) {
+			summary.RulesChecked--
+		}
+	}
+	summary.SkippedAnalyses = a.skipped().Names()
 
 	for _, unit := range units {
-		ctx := rules.NewContextWithUnits(unit, allUnits)
-		ctx.Config = a.config
+		if unit.Runtime.IsFailed() {
+			summary.FailedUnits++
+		}
+	}
 
-		var issues []types.Issue
-		if opts.Category != nil || opts.MinSeverity != nil || len(opts.Tags) > 0 {
-			issues = rules.RunFiltered(ctx, opts.Category, opts.MinSeverity, opts.Tags)
+	summary.ScoreWeights = opts.ScoreWeights
+	result := &ScanResult{
+		Units:   units,
+		Issues:  allIssues,
+		Summary: summary,
+	}
+	result.Recount()
+	return result, nil
+}
+
+// runtimeSuggestions points the suggestions of issues on generated and
+// transient units at what creates them, as changes to their files are lost
+// when systemd writes them again
+func runtimeSuggestions(issues []types.Issue, allUnits map[string]*types.UnitFile) {
+	for i := range issues {
+		unit, ok := allUnits[issues[i].Unit]
+		if !ok {
+			continue
+		}
+		var source string
+		switch unit.Origin {
+		case types.UnitOriginGenerator:
+			source = fmt.Sprintf("%s is generated at boot: change what it is generated from, such as /etc/fstab or the kernel command line, or add a drop-in in /etc/systemd/system/%s.d/, rather than its file.", unit.Name, unit.Name)
+		case types.UnitOriginTransient:
+			source = fmt.Sprintf("%s is a transient unit: change the command that creates it, such as systemd-run, rather than its file.", unit.Name)
+		default:
+			continue
+		}
+		if issues[i].Suggestion == "" {
+			issues[i].Suggestion = source
 		} else {
-			issues = rules.RunAll(ctx)
+			issues[i].Suggestion = source + " " + issues[i].Suggestion
 		}
+	}
+}
 
-		allIssues = append(allIssues, issues...)
+// aggregating reports whether an aggregate rule runs with the filters of
+// opts
+func aggregating(ctx *rules.Context, opts Options) bool {
+	if opts.Category == nil && opts.MinSeverity == nil && len(opts.Tags) == 0 {
+		return false
+	}
+	for _, rule := range rules.All() {
+		if _, ok := rule.(rules.AggregateRule); ok && ctx.CanRun(rule) && rules.Matches(rule, opts.Category, opts.MinSeverity, opts.Tags) {
+			return true
+		}
 	}
+	return false
+}
 
-	sort.Slice(allIssues, func(i, j int) bool {
-		if allIssues[i].Severity != allIssues[j].Severity {
-			return allIssues[i].Severity > allIssues[j].Severity
+// filterIssues keeps the issues of the rules that pass the filters of opts
+func filterIssues(issues []types.Issue, opts Options) []types.Issue {
+	kept := issues[:0]
+	for _, issue := range issues {
+		if rule := rules.Get(issue.RuleID); rule == nil || rules.Matches(rule, opts.Category, opts.MinSeverity, opts.Tags) {
+			kept = append(kept, issue)
 		}
-		return allIssues[i].Unit < allIssues[j].Unit
-	})
+	}
+	return kept
+}
 
-	summary := Summary{
-		TotalUnits:   len(units),
-		TotalIssues:  len(allIssues),
-		BySeverity:   make(map[types.Severity]int),
-		ByCategory:   make(map[types.Category]int),
-		RulesChecked: rules.Count(),
+// rulesRun returns the IDs of the rules a scan runs: those enabled, supported
+// by the target systemd, with the capabilities they need, and passing the
+// filters
+func rulesRun(ctx *rules.Context, opts Options) []string {
+	ids := []string{}
+	for _, rule := range rules.All() {
+		if ctx.CanRun(rule) && rules.Matches(rule, opts.Category, opts.MinSeverity, opts.Tags) {
+			ids = append(ids, rule.ID())
+		}
+	}
+	return ids
+}
+
+// checkUnit runs the rules on one unit. With a cache, the issues of rules
+// that only read the unit's own directives are reused while its file is
+// unchanged, and only the rules that look beyond the unit run again.
+func (a *Analyzer) checkUnit(ctx *rules.Context, c *cache.Cache, opts Options) []types.Issue {
+	if c == nil {
+		if opts.Category != nil || opts.MinSeverity != nil || len(opts.Tags) > 0 {
+			return rules.RunFiltered(ctx, opts.Category, opts.MinSeverity, opts.Tags)
+		}
+		return rules.RunAll(ctx)
+	}
+
+	static := func(rule rules.Rule) bool { return rules.Capabilities(rule) == rules.CapabilityStatic }
+	cached, ok := c.Get(ctx.Unit)
+	if !ok {
+		cached = rules.RunWhere(ctx, opts.Category, opts.MinSeverity, opts.Tags, static)
+		c.Put(ctx.Unit, cached)
 	}
 
-	for _, issue := range allIssues {
-		summary.BySeverity[issue.Severity]++
-		summary.ByCategory[issue.Category]++
+	issues := slices.Clone(cached)
+	return append(issues, rules.RunWhere(ctx, opts.Category, opts.MinSeverity, opts.Tags, func(rule rules.Rule) bool {
+		return !static(rule)
+	})...)
+}
+
+// openCache opens the cache of per-unit rule issues, or returns nil when
+// caching is off. The key covers the sdaudit version, the rule set, the
+// rule configuration, the filters and the target systemd version, which all
+// change what the rules report.
+func (a *Analyzer) openCache(opts Options) *cache.Cache {
+	if a.cacheDir == "" {
+		return nil
+	}
+
+	var ids []string
+	for _, rule := range rules.All() {
+		ids = append(ids, rules.Fingerprint(rule))
 	}
+	config, _ := json.Marshal(a.config)
+	filters, _ := json.Marshal(struct {
+		Category    *types.Category
+		MinSeverity *types.Severity
+		Tags        []string
+	}{opts.Category, opts.MinSeverity, opts.Tags})
+
+	key := cache.Key(a.version, strings.Join(ids, ","), string(config), string(filters), strconv.Itoa(a.systemdVersion))
+	return cache.Open(a.cacheDir, key)
+}
 
-	return &ScanResult{
+// NewResult builds a result and its summary from issues found outside the
+// rule engine, such as by the dependency analyses
+func NewResult(units []*types.UnitFile, issues []types.Issue, rulesChecked int) *ScanResult {
+	summary := Summary{
+		TotalUnits:   len(units),
+		RulesChecked: rulesChecked,
+	}
+	result := &ScanResult{
 		Units:   units,
-		Issues:  allIssues,
+		Issues:  issues,
 		Summary: summary,
-	}, nil
+	}
+	result.Recount()
+	return result
+}
+
+// collectJournal attaches the current boot's start history and run durations to the units
+func (a *Analyzer) collectJournal(ctx context.Context, allUnits map[string]*types.UnitFile) error {
+	events, err := a.journal.Events(ctx, "0")
+	if err != nil {
+		return err
+	}
+
+	runtimeFor := func(name string) *types.RuntimeState {
+		unit, ok := allUnits[name]
+		if !ok {
+			return nil
+		}
+		if unit.Runtime == nil {
+			unit.Runtime = &types.RuntimeState{}
+		}
+		return unit.Runtime
+	}
+
+	for name, starts := range journal.StartsByUnit(events) {
+		if state := runtimeFor(name); state != nil {
+			state.StartHistory = starts
+		}
+	}
+	for name, durations := range journal.RunDurations(events) {
+		if state := runtimeFor(name); state != nil {
+			state.RunDurations = durations
+		}
+	}
+
+	a.runtime = true
+	return nil
+}
+
+// newContext creates the rule context for a unit
+func (a *Analyzer) newContext(unit *types.UnitFile, allUnits map[string]*types.UnitFile) *rules.Context {
+	ctx := rules.NewContextWithUnits(unit, allUnits)
+	ctx.Config = a.config
+	ctx.SystemConfig = a.systemConf
+	ctx.Graph = a.graph
+	ctx.Timeouts = a.timeouts
+	ctx.FileSystem = a.fs
+	ctx.Root = a.root
+	ctx.Unavailable = a.unavailable()
+	if unit != nil && unit.Path == types.StdinPath {
+		// Nothing is known of where a unit from stdin will be deployed, so
+		// its paths, users and groups are not looked up
+		ctx.FileSystem = nil
+		ctx.Unavailable |= rules.CapabilityFilesystem
+	}
+	if a.systemdVersion > 0 {
+		ctx.SystemInfo = &rules.SystemInfo{SystemdVersion: strconv.Itoa(a.systemdVersion)}
+	}
+	return ctx
+}
+
+// unavailable returns the capabilities rules may not use in this scan
+func (a *Analyzer) unavailable() rules.Capability {
+	if a.quick {
+		return rules.CapabilityAll
+	}
+	var unavailable rules.Capability
+	if a.noGraph {
+		unavailable |= rules.CapabilityGraph
+	}
+	if !a.runtime {
+		unavailable |= rules.CapabilityRuntime
+	}
+	return unavailable
+}
+
+// skipped returns the capabilities reported as skipped: those unavailable,
+// leaving out live state where it is never read, when checking files or
+// auditing an image
+func (a *Analyzer) skipped() rules.Capability {
+	skipped := a.unavailable()
+	if !a.quick && !a.live {
+		skipped &^= rules.CapabilityRuntime
+	}
+	return skipped
 }
@@ -0,0 +1,818 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/journal"
+	_ "github.com/supabase/sdaudit/internal/rules/bestpractice"
+	_ "github.com/supabase/sdaudit/internal/rules/performance"
+	_ "github.com/supabase/sdaudit/internal/rules/reliability"
+	_ "github.com/supabase/sdaudit/internal/rules/security"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestScanOfflineRoot(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "etc/systemd/system/app.service"), "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\n")
+	writeTestFile(t, filepath.Join(root, "etc/systemd/system.conf"), "[Manager]\nDefaultTimeoutStartSec=15min\n")
+
+	opts := Options{Root: root}
+	result, err := New(opts).Scan(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	if result.Summary.TotalUnits != 1 {
+		t.Errorf("TotalUnits = %d, want 1 (units loaded from the root)", result.Summary.TotalUnits)
+	}
+
+	found := false
+	for _, issue := range result.Issues {
+		if issue.RuleID == "PERF006" {
+			found = true
+			if issue.File != filepath.Join(root, "etc/systemd/system.conf") {
+				t.Errorf("PERF006 File = %q, want system.conf under root", issue.File)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected PERF006 for raised DefaultTimeoutStartSec in the root's system.conf")
+	}
+}
+
+func TestScanMissingRoot(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "image.raw")
+	writeTestFile(t, file, "")
+
+	for _, root := range []string{filepath.Join(t.TempDir(), "missing"), file} {
+		opts := Options{Root: root}
+		if _, err := New(opts).Scan(context.Background(), opts); err == nil {
+			t.Errorf("Scan with root %s should fail rather than audit no units", root)
+		}
+	}
+}
+
+func TestCheckParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "broken.service"), "[Service\nExecStart=/usr/bin/app\n[Install]\nWantedBy=multi-user.target\nbogus\n")
+
+	severity := types.SeverityCritical
+	opts := Options{MinSeverity: &severity}
+	result, err := New(opts).CheckFiles(context.Background(), []string{dir}, opts)
+	if err != nil {
+		t.Fatalf("CheckFiles failed: %v", err)
+	}
+
+	if result.Summary.TotalUnits != 1 {
+		t.Fatalf("TotalUnits = %d, want the broken unit loaded", result.Summary.TotalUnits)
+	}
+	if result.Summary.ParseErrors != 2 {
+		t.Errorf("ParseErrors = %d, want 2", result.Summary.ParseErrors)
+	}
+	var lines []int
+	for _, issue := range result.Issues {
+		if issue.RuleID != types.ParseRuleID {
+			continue
+		}
+		if issue.Severity != types.SeverityHigh || issue.Line == nil {
+			t.Errorf("PARSE issue = %+v, want high severity with a line", issue)
+			continue
+		}
+		lines = append(lines, *issue.Line)
+	}
+	if fmt.Sprint(lines) != "[1 5]" {
+		t.Errorf("PARSE issues on lines %v, want [1 5] despite the severity filter", lines)
+	}
+}
+
+func TestCheckAggregateSeverityFilter(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "app.service"), "[Service]\nExecStart=/usr/bin/app\n")
+
+	summaries := make(map[string]string)
+	for _, severity := range []types.Severity{types.SeverityHigh, types.SeverityCritical} {
+		opts := Options{MinSeverity: &severity}
+		result, err := New(opts).CheckFiles(context.Background(), []string{dir}, opts)
+		if err != nil {
+			t.Fatalf("CheckFiles failed: %v", err)
+		}
+		for _, issue := range result.Issues {
+			if issue.RuleID == "SECAGG" {
+				summaries[severity.String()] = issue.Severity.String() + " " + issue.Description
+			}
+			if issue.Severity < severity {
+				t.Errorf("%s issue %s of severity %s passed the filter", issue.RuleID, issue.Description, issue.Severity)
+			}
+		}
+	}
+	// The summary counts the hardening findings below the filter too
+	if summaries["critical"] == "" || summaries["critical"] != summaries["high"] {
+		t.Errorf("SECAGG with -s critical = %q, with -s high = %q, want the same critical summary", summaries["critical"], summaries["high"])
+	}
+}
+
+func TestCheckFilesDirectoryAndGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "app.service"), "[Unit]\nDescription=App\nRequires=db.service\n\n[Service]\nExecStart=/usr/bin/app\n")
+	writeTestFile(t, filepath.Join(dir, "app.service.d", "user.conf"), "[Service]\nUser=app\n")
+	writeTestFile(t, filepath.Join(dir, "deps", "db.service"), "[Unit]\nDescription=DB\n\n[Service]\nExecStart=/usr/bin/db\n")
+
+	tests := []struct {
+		name  string
+		paths []string
+		units []string
+	}{
+		{"directory", []string{dir}, []string{"app.service", "db.service"}},
+		{"glob", []string{filepath.Join(dir, "*.service"), filepath.Join(dir, "deps")}, []string{"app.service", "db.service"}},
+		{"single file", []string{filepath.Join(dir, "app.service")}, []string{"app.service"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New(Options{}).CheckFiles(context.Background(), tt.paths, Options{})
+			if err != nil {
+				t.Fatalf("CheckFiles failed: %v", err)
+			}
+			var units []string
+			for _, unit := range result.Units {
+				units = append(units, unit.Name)
+			}
+			if fmt.Sprint(units) != fmt.Sprint(tt.units) {
+				t.Fatalf("units = %v, want %v", units, tt.units)
+			}
+
+			missing := false
+			for _, issue := range result.Issues {
+				if issue.RuleID == "REL009" && issue.Unit == "app.service" {
+					missing = true
+				}
+				if issue.RuleID == "SEC005" && issue.Unit == "app.service" {
+					t.Error("SEC005 reported for app.service, whose drop-in sets User=")
+				}
+			}
+			if want := len(tt.units) == 1; missing != want {
+				t.Errorf("REL009 for app.service = %v, want %v: db.service is given only with the set", missing, want)
+			}
+		})
+	}
+
+	if _, err := New(Options{}).CheckFiles(context.Background(), []string{filepath.Join(dir, "*.socket")}, Options{}); err == nil {
+		t.Error("CheckFiles succeeded for a pattern matching nothing")
+	}
+}
+
+func TestCheckFilesStdin(t *testing.T) {
+	content := "[Unit]\nDescription=Generated\n\n[Service]\nExecStart=/usr/bin/gen\nUser=no-such-user\nEnvironmentFile=/no/such/env\n"
+	opts := Options{Stdin: strings.NewReader(content), StdinName: "gen.service"}
+	result, err := New(opts).CheckFiles(context.Background(), []string{"-"}, opts)
+	if err != nil {
+		t.Fatalf("CheckFiles failed: %v", err)
+	}
+
+	if len(result.Units) != 1 || result.Units[0].Name != "gen.service" || !result.Units[0].IsService() {
+		t.Fatalf("units = %v, want gen.service", result.Units)
+	}
+	if len(result.Issues) == 0 {
+		t.Fatal("no issues for the unit from stdin")
+	}
+	for _, issue := range result.Issues {
+		if issue.File != types.StdinPath {
+			t.Errorf("%s File = %q, want %q", issue.RuleID, issue.File, types.StdinPath)
+		}
+		if issue.RuleID == "BP009" || issue.RuleID == "SEC017" {
+			t.Errorf("%s looked up the filesystem for a unit from stdin: %s", issue.RuleID, issue.Description)
+		}
+	}
+
+	opts = Options{Stdin: strings.NewReader(content), StdinName: "gen"}
+	if _, err := New(opts).CheckFiles(context.Background(), []string{"-"}, opts); err == nil {
+		t.Error("CheckFiles succeeded for stdin without a unit name")
+	}
+}
+
+func TestQuickScan(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "etc/systemd/system/app.service"), "[Unit]\nDescription=App\nRequires=missing.service\n\n[Service]\nExecStart=/usr/bin/app\nUser=no-such-user-sdaudit\n")
+
+	full := Options{Root: root}
+	fullResult, err := New(full).Scan(context.Background(), full)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	quick := Options{Root: root, Quick: true}
+	quickResult, err := New(quick).Scan(context.Background(), quick)
+	if err != nil {
+		t.Fatalf("Quick scan failed: %v", err)
+	}
+
+	// Live state is never read from an image, so it is not reported skipped
+	if len(fullResult.Summary.SkippedAnalyses) != 0 {
+		t.Errorf("Scan of a root skipped %v, want nothing", fullResult.Summary.SkippedAnalyses)
+	}
+	if !quickResult.Summary.Quick || !slices.Contains(quickResult.Summary.SkippedAnalyses, "runtime") {
+		t.Errorf("Quick scan should be labeled with skipped analyses, got %+v", quickResult.Summary)
+	}
+	if quickResult.Summary.RulesChecked >= fullResult.Summary.RulesChecked {
+		t.Errorf("Quick scan checked %d rules, full scan %d; quick should run fewer", quickResult.Summary.RulesChecked, fullResult.Summary.RulesChecked)
+	}
+
+	if len(fullResult.Summary.RulesRun) != fullResult.Summary.RulesChecked || !slices.Contains(fullResult.Summary.RulesRun, "REL009") {
+		t.Errorf("Full scan ran %v, want %d rules with REL009", fullResult.Summary.RulesRun, fullResult.Summary.RulesChecked)
+	}
+	if slices.Contains(quickResult.Summary.RulesRun, "REL009") {
+		t.Error("Quick scan lists REL009 among the rules run")
+	}
+
+	for _, issue := range quickResult.Issues {
+		if issue.RuleID == "BP009" || issue.RuleID == "REL009" {
+			t.Errorf("Quick scan should skip %s", issue.RuleID)
+		}
+	}
+
+	foundCrossUnit := false
+	for _, issue := range fullResult.Issues {
+		if issue.RuleID == "REL009" {
+			foundCrossUnit = true
+		}
+	}
+	if !foundCrossUnit {
+		t.Error("Full scan should report REL009 for the missing dependency")
+	}
+}
+
+func TestScanNoGraph(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "etc/systemd/system/a.service"), "[Unit]\nBindsTo=b.service\nAfter=b.service\n\n[Service]\nExecStart=/usr/bin/a\nRestart=always\n")
+	writeTestFile(t, filepath.Join(root, "etc/systemd/system/b.service"), "[Unit]\nBindsTo=a.service\nAfter=a.service\n\n[Service]\nExecStart=/usr/bin/b\nRestart=always\n")
+
+	hasRule := func(result *ScanResult, id string) bool {
+		for _, issue := range result.Issues {
+			if issue.RuleID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	full := Options{Root: root}
+	fullResult, err := New(full).Scan(context.Background(), full)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if !hasRule(fullResult, "PROP001") {
+		t.Error("Full scan should report PROP001 for the mutual BindsTo= restart storm")
+	}
+
+	noGraph := Options{Root: root, NoGraph: true}
+	noGraphResult, err := New(noGraph).Scan(context.Background(), noGraph)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if hasRule(noGraphResult, "PROP001") {
+		t.Error("Scan with NoGraph should skip PROP001")
+	}
+	if !slices.Equal(noGraphResult.Summary.SkippedAnalyses, []string{"graph"}) {
+		t.Errorf("SkippedAnalyses = %v, want graph only", noGraphResult.Summary.SkippedAnalyses)
+	}
+}
+
+func TestCheckFilesDependencyGraph(t *testing.T) {
+	dir := t.TempDir()
+	for _, unit := range [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}} {
+		writeTestFile(t, filepath.Join(dir, unit[0]+".service"), "[Unit]\nAfter="+unit[1]+".service\n\n[Service]\nExecStart=/usr/bin/"+unit[0]+"\n")
+	}
+
+	cycles := func(opts Options) int {
+		result, err := New(opts).CheckFiles(context.Background(), []string{dir}, opts)
+		if err != nil {
+			t.Fatalf("CheckFiles failed: %v", err)
+		}
+		if slices.Contains(result.Summary.SkippedAnalyses, "runtime") {
+			t.Errorf("CheckFiles reported runtime as skipped: %v", result.Summary.SkippedAnalyses)
+		}
+		n := 0
+		for _, issue := range result.Issues {
+			if issue.RuleID == "GRAPH002" {
+				n++
+			}
+		}
+		return n
+	}
+	if n := cycles(Options{}); n != 1 {
+		t.Errorf("CheckFiles reported %d GRAPH002 ordering cycles, want 1", n)
+	}
+	if n := cycles(Options{NoGraph: true}); n != 0 {
+		t.Errorf("CheckFiles with NoGraph reported %d GRAPH002 ordering cycles, want 0", n)
+	}
+}
+
+func TestScanEnablementLinks(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "etc/systemd/system")
+	writeTestFile(t, filepath.Join(dir, "graphical.target"), "[Unit]\nDescription=Graphical Interface\n")
+	writeTestFile(t, filepath.Join(dir, "linked.service"), "[Service]\nExecStart=/usr/bin/linked\n")
+	writeTestFile(t, filepath.Join(dir, "orphan.service"), "[Service]\nExecStart=/usr/bin/orphan\n")
+	if err := os.MkdirAll(filepath.Join(dir, "graphical.target.wants"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../linked.service", filepath.Join(dir, "graphical.target.wants/linked.service")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Root: root}
+	result, err := New(opts).Scan(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	var dead []string
+	for _, issue := range result.Issues {
+		if issue.RuleID == "GRAPH005" {
+			dead = append(dead, issue.Unit)
+		}
+	}
+	if len(dead) != 1 || dead[0] != "orphan.service" {
+		t.Errorf("GRAPH005 reported %v, want only orphan.service", dead)
+	}
+}
+
+func TestScanSymlinkedUnitsOnce(t *testing.T) {
+	root := t.TempDir()
+	etc := filepath.Join(root, "etc/systemd/system")
+	writeTestFile(t, filepath.Join(root, "usr/lib/systemd/system/app.service"), "[Service]\nExecStart=/usr/bin/app\n\n[Install]\nWantedBy=multi-user.target\n")
+	writeTestFile(t, filepath.Join(root, "usr/lib/systemd/system/multi-user.target"), "[Unit]\nDescription=Multi-User System\n")
+	if err := os.MkdirAll(filepath.Join(etc, "multi-user.target.wants"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{
+		"lib": "usr/lib",
+		"etc/systemd/system/multi-user.target.wants/app.service": "/lib/systemd/system/app.service",
+		"etc/systemd/system/dbus-org.example.App.service":        "/usr/lib/systemd/system/app.service",
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := Options{Root: root}
+	result, err := New(opts).Scan(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	var names []string
+	for _, unit := range result.Units {
+		names = append(names, unit.Name)
+	}
+	if got := strings.Join(names, ","); got != "app.service,multi-user.target" {
+		t.Fatalf("units = %s, want each unit once", got)
+	}
+	app := result.Units[0]
+	if !app.Enabled || len(app.WantedBySymlinks) != 1 {
+		t.Errorf("app.service Enabled = %v, WantedBySymlinks = %v, want the wants link", app.Enabled, app.WantedBySymlinks)
+	}
+	seen := make(map[string]bool)
+	for _, issue := range result.Issues {
+		key := issue.RuleID + " " + issue.Unit + " " + issue.Description
+		if seen[key] {
+			t.Errorf("issue reported twice: %s", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestScanRuntimeUnits(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "etc/systemd/system/app.service"), "[Unit]\nRequires=legacy.service\n\n[Service]\nExecStart=/usr/bin/app\n")
+	writeTestFile(t, filepath.Join(root, "run/systemd/generator.late/legacy.service"), "[Service]\nType=forking\nExecStart=/etc/init.d/legacy start\n")
+
+	for _, includeRuntime := range []bool{false, true} {
+		opts := Options{Root: root, IncludeRuntime: includeRuntime}
+		result, err := New(opts).Scan(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		// The generated unit is not checked or scored, only looked up
+		if len(result.Units) != 1 || result.Units[0].Name != "app.service" {
+			t.Errorf("IncludeRuntime=%v: units = %v, want app.service alone", includeRuntime, result.Units)
+		}
+		missing := false
+		for _, issue := range result.Issues {
+			if issue.Unit == "legacy.service" {
+				t.Errorf("IncludeRuntime=%v: issue on the generated unit: %+v", includeRuntime, issue)
+			}
+			missing = missing || issue.RuleID == "REL009"
+		}
+		if missing == includeRuntime {
+			t.Errorf("IncludeRuntime=%v: REL009 reported = %v", includeRuntime, missing)
+		}
+	}
+}
+
+func TestRuntimeSuggestions(t *testing.T) {
+	units := map[string]*types.UnitFile{
+		"app.service":    {Name: "app.service"},
+		"data.mount":     {Name: "data.mount", Origin: types.UnitOriginGenerator},
+		"run-u1.service": {Name: "run-u1.service", Origin: types.UnitOriginTransient},
+	}
+	issues := []types.Issue{
+		{Unit: "app.service", Suggestion: "Fix it."},
+		{Unit: "data.mount", Suggestion: "Fix it."},
+		{Unit: "run-u1.service"},
+	}
+	runtimeSuggestions(issues, units)
+
+	if issues[0].Suggestion != "Fix it." {
+		t.Errorf("suggestion for a unit file = %q, want it unchanged", issues[0].Suggestion)
+	}
+	if s := issues[1].Suggestion; !strings.Contains(s, "/etc/fstab") || !strings.Contains(s, "/etc/systemd/system/data.mount.d/") || !strings.HasSuffix(s, " Fix it.") {
+		t.Errorf("suggestion for a generated unit = %q", s)
+	}
+	if s := issues[2].Suggestion; !strings.Contains(s, "systemd-run") {
+		t.Errorf("suggestion for a transient unit = %q", s)
+	}
+}
+
+func TestScanCancelled(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "etc/systemd/system/app.service"), "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	opts := Options{Root: root}
+	if _, err := New(opts).Scan(ctx, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("Scan with a cancelled context returned %v, want context.Canceled", err)
+	}
+	units := map[string]*types.UnitFile{"app.service": {Name: "app.service", Type: "service"}}
+	if _, err := New(opts).CheckUnits(ctx, units, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("CheckUnits with a cancelled context returned %v, want context.Canceled", err)
+	}
+	path := filepath.Join(root, "etc/systemd/system/app.service")
+	if _, err := New(opts).RecheckFile(ctx, path, units, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("RecheckFile with a cancelled context returned %v, want context.Canceled", err)
+	}
+	if _, err := AnalyzeDeadlocks(ctx, units); !errors.Is(err, context.Canceled) {
+		t.Errorf("AnalyzeDeadlocks with a cancelled context returned %v, want context.Canceled", err)
+	}
+}
+
+func TestScanProgress(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 3; i++ {
+		writeTestFile(t, filepath.Join(root, fmt.Sprintf("etc/systemd/system/app%d.service", i)), "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\n")
+	}
+	writeTestFile(t, filepath.Join(root, "etc/systemd/system/README"), "not a unit")
+
+	type call struct {
+		stage       string
+		done, total int
+	}
+	var calls []call
+	opts := Options{Root: root, Progress: func(stage string, done, total int) {
+		calls = append(calls, call{stage, done, total})
+	}}
+	if _, err := New(opts).Scan(context.Background(), opts); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	// Each stage counts up by one to its total, and stages run in order
+	order := map[string]int{StageLoad: 0, StageParse: 1, StageCheck: 2}
+	last := map[string]call{}
+	prevStage := -1
+	for _, c := range calls {
+		rank, ok := order[c.stage]
+		if !ok {
+			t.Fatalf("unknown stage %q", c.stage)
+		}
+		if rank < prevStage {
+			t.Errorf("stage %s reported after a later stage", c.stage)
+		}
+		prevStage = rank
+		if c.done != last[c.stage].done+1 || c.done > c.total {
+			t.Errorf("%s: done = %d of %d after %d", c.stage, c.done, c.total, last[c.stage].done)
+		}
+		last[c.stage] = c
+	}
+
+	want := map[string]int{StageLoad: len(DefaultUnitPaths()), StageParse: 3, StageCheck: 3}
+	for stage, total := range want {
+		if c := last[stage]; c.done != total || c.total != total {
+			t.Errorf("%s ended at %d/%d, want %d/%d", stage, c.done, c.total, total, total)
+		}
+	}
+}
+
+func TestScanCache(t *testing.T) {
+	root := t.TempDir()
+	unitPath := filepath.Join(root, "etc/systemd/system/app.service")
+	writeTestFile(t, unitPath, "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\n")
+	writeTestFile(t, filepath.Join(root, "etc/systemd/system/other.service"), "[Unit]\nDescription=Other\n\n[Service]\nExecStart=/usr/bin/other\nRestart=on-failure\n")
+
+	scan := func(cacheDir string) []string {
+		t.Helper()
+		opts := Options{Root: root, CacheDir: cacheDir}
+		result, err := New(opts).Scan(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		var found []string
+		for _, issue := range result.Issues {
+			found = append(found, issue.Unit+" "+issue.RuleID+" "+issue.Description)
+		}
+		return found
+	}
+	same := func(a, b []string) bool {
+		return strings.Join(a, "\n") == strings.Join(b, "\n")
+	}
+
+	cacheDir := t.TempDir()
+	uncached := scan("")
+	if first := scan(cacheDir); !same(first, uncached) {
+		t.Errorf("first cached scan differs:\n%v\nwant\n%v", first, uncached)
+	}
+	if second := scan(cacheDir); !same(second, uncached) {
+		t.Errorf("scan from the cache differs:\n%v\nwant\n%v", second, uncached)
+	}
+
+	// An edited unit is checked again
+	writeTestFile(t, unitPath, "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\nRestart=on-failure\nUser=app\n")
+	if edited := scan(cacheDir); !same(edited, scan("")) {
+		t.Errorf("scan after an edit differs from an uncached scan:\n%v", edited)
+	}
+
+	// A corrupted cache file is ignored
+	files, _ := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	if len(files) == 0 {
+		t.Fatal("no cache file written")
+	}
+	for _, f := range files {
+		writeTestFile(t, f, "{corrupted")
+	}
+	if recovered := scan(cacheDir); !same(recovered, scan("")) {
+		t.Errorf("scan with a corrupted cache differs from an uncached scan:\n%v", recovered)
+	}
+}
+
+func makeBenchRoot(b testing.TB, n int) string {
+	b.Helper()
+	root := b.TempDir()
+	dir := filepath.Join(root, "etc/systemd/system")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		content := fmt.Sprintf("[Unit]\nDescription=Unit %d\nAfter=unit%d.service\nRequires=unit%d.service\n\n[Service]\nExecStart=/usr/bin/app%d\nUser=svc%d\nRestart=always\n\n[Install]\nWantedBy=multi-user.target\n", i, i+1, i+1, i, i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("unit%d.service", i)), []byte(content), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return root
+}
+
+// quickScanBudget is how long a quick scan of a few hundred units may take
+const quickScanBudget = time.Second
+
+// TestQuickScanBudget fails when the interactive quick mode goes over its
+// budget. The best of a few runs counts, so a busy machine does not fail it.
+func TestQuickScanBudget(t *testing.T) {
+	root := makeBenchRoot(t, 500)
+	opts := Options{Root: root, Quick: true}
+
+	var best time.Duration
+	for i := range 3 {
+		start := time.Now()
+		if _, err := New(opts).Scan(context.Background(), opts); err != nil {
+			t.Fatal(err)
+		}
+		if took := time.Since(start); i == 0 || took < best {
+			best = took
+		}
+	}
+	if best > quickScanBudget {
+		t.Errorf("quick scan of 500 units took %v, over the %v budget", best, quickScanBudget)
+	}
+}
+
+// BenchmarkQuickScan measures the interactive quick mode, which
+// TestQuickScanBudget keeps under a second for a few hundred units
+func BenchmarkQuickScan(b *testing.B) {
+	root := makeBenchRoot(b, 500)
+	opts := Options{Root: root, Quick: true}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := New(opts).Scan(context.Background(), opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFullScan(b *testing.B) {
+	root := makeBenchRoot(b, 500)
+	opts := Options{Root: root}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := New(opts).Scan(context.Background(), opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseRuntimeState(t *testing.T) {
+	output := `Id=nginx.service
+ActiveState=failed
+SubState=failed
+Result=exit-code
+NRestarts=0
+ExecMainStatus=1
+
+Id=worker.service
+ActiveState=activating
+SubState=auto-restart
+Result=exit-code
+NRestarts=17
+ExecMainStatus=2
+`
+	states, err := parseRuntimeState(strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("parseRuntimeState failed: %v", err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("got %d states, want 2", len(states))
+	}
+
+	nginx := states["nginx.service"]
+	if !nginx.IsFailed() || nginx.ExecMainStatus != 1 || nginx.Result != "exit-code" {
+		t.Errorf("nginx.service = %+v, want failed with exit status 1", nginx)
+	}
+
+	worker := states["worker.service"]
+	if worker.NRestarts != 17 || worker.SubState != "auto-restart" {
+		t.Errorf("worker.service = %+v, want 17 restarts in auto-restart", worker)
+	}
+}
+
+type fakeJournal []journal.Event
+
+func (f fakeJournal) Events(_ context.Context, boot string) ([]journal.Event, error) { return f, nil }
+
+func TestCollectJournal(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	a := New(Options{})
+	a.journal = fakeJournal{
+		{Unit: "app.service", Time: base, Kind: journal.EventStarted},
+		{Unit: "app.service", Time: base.Add(time.Second), Kind: journal.EventFailed},
+		{Unit: "app.service", Time: base.Add(2 * time.Second), Kind: journal.EventStarted},
+		{Unit: "gone.service", Time: base, Kind: journal.EventStarted},
+	}
+
+	units := map[string]*types.UnitFile{"app.service": {Name: "app.service"}}
+	if err := a.collectJournal(context.Background(), units); err != nil {
+		t.Fatalf("collectJournal failed: %v", err)
+	}
+
+	history := units["app.service"].Runtime.StartHistory
+	if len(history) != 2 || !history[1].Equal(base.Add(2*time.Second)) {
+		t.Errorf("StartHistory = %v, want the two start events", history)
+	}
+	if !a.runtime {
+		t.Error("runtime analysis should be available after reading the journal")
+	}
+}
+
+func TestCompareBoots(t *testing.T) {
+	base := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	boot := func(id string, day int, units map[string]time.Duration) BootSnapshot {
+		return BootSnapshot{BootID: id, Time: base.AddDate(0, 0, day), Units: units}
+	}
+
+	previous := []BootSnapshot{
+		boot("a", 0, map[string]time.Duration{"db.service": 2 * time.Second, "tiny.service": 10 * time.Millisecond, "web.service": time.Second}),
+		boot("b", 1, map[string]time.Duration{"db.service": 3 * time.Second, "tiny.service": 10 * time.Millisecond, "web.service": time.Second}),
+		boot("c", 2, map[string]time.Duration{"db.service": 2 * time.Second, "tiny.service": 10 * time.Millisecond}),
+	}
+	current := boot("d", 3, map[string]time.Duration{
+		"db.service":   6 * time.Second,       // +4s absolute
+		"tiny.service": 40 * time.Millisecond, // +300% but below the noise floor
+		"web.service":  1500 * time.Millisecond,
+		"new.service":  10 * time.Second, // no baseline
+	})
+
+	history := CompareBoots(current, previous, 0, RegressionThresholds{Percent: 40, Absolute: 3 * time.Second})
+
+	if len(history.Boots) != 4 || history.Boots[3].BootID != "d" {
+		t.Fatalf("Boots should end with the current boot, got %d boots", len(history.Boots))
+	}
+	if len(history.Regressions) != 2 {
+		t.Fatalf("got %d regressions, want 2: %+v", len(history.Regressions), history.Regressions)
+	}
+	if r := history.Regressions[0]; r.Unit != "db.service" || r.Baseline != 2*time.Second || r.Delta != 4*time.Second {
+		t.Errorf("Regressions[0] = %+v, want db.service from 2s baseline", r)
+	}
+	if r := history.Regressions[1]; r.Unit != "web.service" || r.Percent != 50 {
+		t.Errorf("Regressions[1] = %+v, want web.service at +50%%", r)
+	}
+
+	web := history.Series["web.service"]
+	if len(web) != 4 || web[2] != nil || *web[3] != 1500*time.Millisecond {
+		t.Errorf("web.service series not aligned with boots: %v", web)
+	}
+
+	limited := CompareBoots(current, previous, 1, RegressionThresholds{Absolute: 3 * time.Second})
+	if len(limited.Boots) != 2 || limited.Boots[0].BootID != "c" {
+		t.Errorf("limit should keep only the most recent earlier boot, got %+v", limited.Boots)
+	}
+}
+
+func TestBootSnapshotRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "boots")
+
+	older := BootSnapshot{BootID: "older", Time: time.Unix(1700000000, 0), Units: map[string]time.Duration{"a.service": time.Second}}
+	newer := BootSnapshot{BootID: "newer", Time: time.Unix(1700100000, 0), TotalTime: 30 * time.Second}
+	for _, snap := range []BootSnapshot{newer, older} {
+		if err := SaveBootSnapshot(dir, snap); err != nil {
+			t.Fatalf("SaveBootSnapshot failed: %v", err)
+		}
+	}
+
+	snaps, err := LoadBootSnapshots(dir)
+	if err != nil {
+		t.Fatalf("LoadBootSnapshots failed: %v", err)
+	}
+	if len(snaps) != 2 || snaps[0].BootID != "older" || snaps[1].TotalTime != 30*time.Second {
+		t.Errorf("LoadBootSnapshots() = %+v, want both boots oldest first", snaps)
+	}
+	if snaps[0].Units["a.service"] != time.Second {
+		t.Errorf("unit timings not preserved: %v", snaps[0].Units)
+	}
+
+	missing, err := LoadBootSnapshots(filepath.Join(dir, "missing"))
+	if err != nil || missing != nil {
+		t.Errorf("missing directory should yield no snapshots, got %v, %v", missing, err)
+	}
+}
+
+func TestRecheckFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.service")
+	writeTestFile(t, path, "[Unit]\nRequires=missing.service\n\n[Service]\nExecStart=/usr/bin/app\n")
+
+	opts := Options{}
+	result, err := New(opts).CheckFiles(context.Background(), []string{dir}, opts)
+	if err != nil {
+		t.Fatalf("CheckFiles failed: %v", err)
+	}
+	units := map[string]*types.UnitFile{"app.service": result.Units[0]}
+	result.Units[0].Runtime = &types.RuntimeState{ActiveState: "failed"}
+
+	writeTestFile(t, path, "[Unit]\nRequires=missing.service\n\n[Service]\nExecStart=/usr/bin/app\nNoNewPrivileges=yes\n")
+	r, err := New(opts).RecheckFile(context.Background(), path, units, opts)
+	if err != nil {
+		t.Fatalf("RecheckFile failed: %v", err)
+	}
+
+	ran := strings.Join(r.Rules, ",")
+	if !strings.Contains(ran, "SEC001") || strings.Contains(ran, "REL009") {
+		t.Errorf("Rules = %s, want the per-unit rules such as SEC001 and not REL009", ran)
+	}
+	for _, issue := range r.Issues {
+		if issue.RuleID == "SEC001" || issue.RuleID == "REL009" {
+			t.Errorf("RecheckFile reported %s: %s", issue.RuleID, issue.Description)
+		}
+	}
+	var aggregate *types.Issue
+	for i, issue := range r.Issues {
+		if issue.RuleID == "SECAGG" {
+			aggregate = &r.Issues[i]
+		}
+	}
+	if !strings.Contains(ran, "SECAGG") || aggregate == nil || slices.Contains(aggregate.Refs, types.RuleReference("SEC001")) {
+		t.Errorf("SECAGG should summarize the rechecked issues, got %+v", aggregate)
+	}
+	if r.Unit.Runtime == nil || r.Unit.Runtime.ActiveState != "failed" {
+		t.Errorf("rechecked unit lost its live state: %+v", r.Unit.Runtime)
+	}
+
+	if _, err := New(opts).RecheckFile(context.Background(), filepath.Join(dir, "gone.service"), units, opts); err == nil {
+		t.Error("RecheckFile of a missing file should fail")
+	}
+}
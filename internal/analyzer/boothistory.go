@@ -0,0 +1,216 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBootHistoryDir is where boot snapshots are kept between boots
+const DefaultBootHistoryDir = "/var/lib/sdaudit/boots"
+
+// regressionNoiseFloor is the smallest increase counted as a relative
+// regression, so that a 10ms unit taking 30ms is not reported as +200%
+const regressionNoiseFloor = 100 * time.Millisecond
+
+// BootSnapshot records the timing of a single boot
+type BootSnapshot struct {
+	BootID        string                   `json:"boot_id"`
+	Time          time.Time                `json:"time"`
+	TotalTime     time.Duration            `json:"total_time"`
+	UserspaceTime time.Duration            `json:"userspace_time"`
+	Units         map[string]time.Duration `json:"units"`
+}
+
+// RegressionThresholds configures when a unit counts as regressed.
+// A unit regresses when its start time grew by at least Absolute, or by at
+// least Percent relative to its baseline.
+type RegressionThresholds struct {
+	Percent  float64
+	Absolute time.Duration
+}
+
+// BootRegression is a unit whose start time grew compared to previous boots
+type BootRegression struct {
+	Unit     string        `json:"unit"`
+	Baseline time.Duration `json:"baseline"`
+	Current  time.Duration `json:"current"`
+	Delta    time.Duration `json:"delta"`
+	Percent  float64       `json:"percent"`
+}
+
+// BootHistory compares the current boot against earlier snapshots
+type BootHistory struct {
+	// Boots are ordered oldest first and end with the current boot
+	Boots []BootSnapshot
+	// Series holds each unit's start time per boot, aligned with Boots; nil where the unit did not start
+	Series      map[string][]*time.Duration
+	Regressions []BootRegression
+}
+
+// Snapshot captures the analysis as a snapshot of the given boot. Units record
+// their start time in this boot, not a median over earlier ones.
+func (a *BootAnalysis) Snapshot(bootID string, at time.Time) BootSnapshot {
+	snap := BootSnapshot{
+		BootID:        bootID,
+		Time:          at,
+		TotalTime:     a.TotalTime,
+		UserspaceTime: a.UserspaceTime,
+		Units:         make(map[string]time.Duration),
+	}
+	for _, u := range a.Units {
+		if u.Current > 0 {
+			snap.Units[u.Name] = u.Current
+		}
+	}
+	return snap
+}
+
+// CurrentBootID returns the kernel's identifier of the running boot
+func CurrentBootID() (string, error) {
+	data, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(strings.TrimSpace(string(data)), "-", ""), nil
+}
+
+// SaveBootSnapshot writes a snapshot to dir, replacing an earlier snapshot of the same boot
+func SaveBootSnapshot(dir string, snap BootSnapshot) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, snap.BootID+".json"), data, 0644)
+}
+
+// LoadBootSnapshots reads all snapshots in dir, oldest first. A missing
+// directory yields no snapshots.
+func LoadBootSnapshots(dir string) ([]BootSnapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snaps []BootSnapshot
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var snap BootSnapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return nil, fmt.Errorf("invalid boot snapshot %s: %w", entry.Name(), err)
+		}
+		snaps = append(snaps, snap)
+	}
+
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].Time.Before(snaps[j].Time)
+	})
+
+	return snaps, nil
+}
+
+// CompareBoots compares the current boot against the most recent limit
+// previous boots (all of them if limit is 0). Snapshots of the current boot
+// itself are ignored. Each unit's baseline is its median over previous boots.
+func CompareBoots(current BootSnapshot, previous []BootSnapshot, limit int, thresholds RegressionThresholds) *BootHistory {
+	var boots []BootSnapshot
+	for _, snap := range previous {
+		if snap.BootID != current.BootID {
+			boots = append(boots, snap)
+		}
+	}
+	if limit > 0 && len(boots) > limit {
+		boots = boots[len(boots)-limit:]
+	}
+	history := &BootHistory{
+		Boots:  append(boots, current),
+		Series: make(map[string][]*time.Duration),
+	}
+
+	for i, snap := range history.Boots {
+		for name, d := range snap.Units {
+			series, ok := history.Series[name]
+			if !ok {
+				series = make([]*time.Duration, len(history.Boots))
+				history.Series[name] = series
+			}
+			series[i] = &d
+		}
+	}
+
+	for name, current := range current.Units {
+		var earlier []time.Duration
+		for _, d := range history.Series[name][:len(boots)] {
+			if d != nil {
+				earlier = append(earlier, *d)
+			}
+		}
+		if len(earlier) == 0 {
+			continue
+		}
+
+		baseline := median(earlier)
+		delta := current - baseline
+		if delta <= 0 {
+			continue
+		}
+
+		var percent float64
+		if baseline > 0 {
+			percent = float64(delta) / float64(baseline) * 100
+		}
+
+		absolute := thresholds.Absolute > 0 && delta >= thresholds.Absolute
+		relative := thresholds.Percent > 0 && baseline > 0 && percent >= thresholds.Percent && delta >= regressionNoiseFloor
+		if !absolute && !relative {
+			continue
+		}
+
+		history.Regressions = append(history.Regressions, BootRegression{
+			Unit:     name,
+			Baseline: baseline,
+			Current:  current,
+			Delta:    delta,
+			Percent:  percent,
+		})
+	}
+
+	sort.Slice(history.Regressions, func(i, j int) bool {
+		if history.Regressions[i].Delta != history.Regressions[j].Delta {
+			return history.Regressions[i].Delta > history.Regressions[j].Delta
+		}
+		return history.Regressions[i].Unit < history.Regressions[j].Unit
+	})
+
+	return history
+}
+
+func median(values []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
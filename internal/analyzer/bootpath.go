@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"time"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/timing"
+)
+
+// BootPath is the critical path to the boot target that the unit files
+// predict, with each unit counted at the time it took to start, set against
+// the critical chain systemd reported
+type BootPath struct {
+	Target string `json:"target"`
+	// Path is chosen by observed start times. Its WorstCase is the same
+	// chain counted at the units' start timeouts.
+	Path timing.CriticalPath `json:"path"`
+	// InChain are the units of the path that are in systemd's critical chain
+	InChain []string `json:"in_chain"`
+	// Unobserved are the units of the path without a start time, which
+	// count as starting at once
+	Unobserved []string `json:"unobserved"`
+}
+
+// ObservedTimes returns the start time of each unit: the journal median, or
+// the blame time of this boot
+func (a *BootAnalysis) ObservedTimes() map[string]time.Duration {
+	observed := make(map[string]time.Duration, len(a.Units))
+	for _, u := range a.Units {
+		observed[u.Name] = u.Time
+	}
+	return observed
+}
+
+// bootTarget returns the target the boot reached, or the root of the
+// critical chain when systemd-analyze did not name it
+func (a *BootAnalysis) bootTarget() string {
+	if a.ReachedTarget != "" {
+		return a.ReachedTarget
+	}
+	for _, link := range a.CriticalChain {
+		if link.Depth == 0 {
+			return link.Name
+		}
+	}
+	return ""
+}
+
+// CorrelateCriticalPath sets PredictedPath to the critical path to the boot
+// target in the dependency graph, weighted by the observed start times. It
+// is left nil when the target is not in the graph.
+func (a *BootAnalysis) CorrelateCriticalPath(g *graph.Graph, timeouts map[string]timing.TimeoutConfig) {
+	a.PredictedPath = nil
+	target := a.bootTarget()
+	if target == "" || g.Unit(target) == nil {
+		return
+	}
+
+	paths := timing.ComputeCriticalPathsWith(g, timeouts, a.ObservedTimes(), timing.WeightObserved)
+	bootPath := &BootPath{Target: target, Path: paths.Paths[target], InChain: []string{}, Unobserved: []string{}}
+
+	inChain := make(map[string]bool, len(a.CriticalChain))
+	for _, link := range a.CriticalChain {
+		inChain[link.Name] = true
+	}
+	for _, node := range bootPath.Path.Path {
+		if inChain[node.Unit] {
+			bootPath.InChain = append(bootPath.InChain, node.Unit)
+		}
+		if node.Unobserved {
+			bootPath.Unobserved = append(bootPath.Unobserved, node.Unit)
+		}
+	}
+	a.PredictedPath = bootPath
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/timing"
+)
+
+func TestCorrelateCriticalPath(t *testing.T) {
+	g := loadTestGraph(t, "linear_chain")
+	analysis := &BootAnalysis{
+		Units: []UnitTiming{
+			{Name: "s1.service", Time: 2 * time.Second},
+			{Name: "s3.service", Time: 500 * time.Millisecond},
+			{Name: "unrelated.service", Time: time.Minute},
+		},
+		// systemd-analyze did not name the target, so the chain's root is used
+		CriticalChain: []ChainLink{
+			{Name: "s3.service", Depth: 0},
+			{Name: "s1.service", Depth: 1, Parent: "s3.service"},
+		},
+	}
+
+	analysis.CorrelateCriticalPath(g, nil)
+	predicted := analysis.PredictedPath
+	if predicted == nil {
+		t.Fatal("PredictedPath = nil, want the path to s3.service")
+	}
+	if predicted.Target != "s3.service" {
+		t.Errorf("Target = %s, want s3.service", predicted.Target)
+	}
+	if got := predicted.Path.PathDescription(); got != "s1.service -> s2.service -> s3.service" {
+		t.Errorf("PathDescription() = %q", got)
+	}
+	if predicted.Path.Observed != 2500*time.Millisecond || predicted.Path.TotalTime != predicted.Path.Observed {
+		t.Errorf("Observed = %s, TotalTime = %s, want 2.5s for both", predicted.Path.Observed, predicted.Path.TotalTime)
+	}
+	if want := 3 * timing.DefaultTimeoutStartSec; predicted.Path.WorstCase != want {
+		t.Errorf("WorstCase = %s, want %s", predicted.Path.WorstCase, want)
+	}
+	if want := []string{"s1.service", "s3.service"}; !reflect.DeepEqual(predicted.InChain, want) {
+		t.Errorf("InChain = %v, want %v", predicted.InChain, want)
+	}
+	if want := []string{"s2.service"}; !reflect.DeepEqual(predicted.Unobserved, want) {
+		t.Errorf("Unobserved = %v, want %v", predicted.Unobserved, want)
+	}
+
+	analysis.ReachedTarget = "graphical.target"
+	analysis.CorrelateCriticalPath(g, nil)
+	if analysis.PredictedPath != nil {
+		t.Errorf("PredictedPath = %+v, want nil for a target the graph does not have", analysis.PredictedPath)
+	}
+}
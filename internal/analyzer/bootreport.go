@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// BootJSONVersion is the version of the boot command's JSON output. Output
+// without a version field is version 1; version 2 added the per-unit report.
+const BootJSONVersion = 2
+
+// Suggestions for units that slow down boot without a finding to explain why
+const (
+	suggestCriticalChain = "This unit blocks boot progress - optimize or defer"
+	suggestSlowUnit      = "Consider optimizing startup or using socket activation"
+)
+
+// bootTags are the tags of the rules whose findings bear on how a unit starts
+// at boot, such as PERF001 for socket activation, the start timeout rules and
+// the timeout cascade rules
+var bootTags = []string{"boot", "startup", "timeout"}
+
+// BootReport joins, for each unit that slows down boot, its start time, its
+// place in the critical chain and the findings of a scan about its start
+type BootReport struct {
+	Units []BootUnitReport `json:"units"`
+}
+
+// BootUnitReport is what is known about one unit's start at boot
+type BootUnitReport struct {
+	Unit string `json:"unit"`
+	// Time is the start time: the journal median, the blame time, the
+	// activation interval or, for a unit only in the critical chain, the
+	// time it took there. WallClock is its share of boot wall-clock time
+	// with timestamp timings, which Slow is judged by then.
+	Time      time.Duration `json:"time"`
+	WallClock time.Duration `json:"wall_clock,omitempty"`
+	Slow      bool          `json:"slow"`
+	// InChain is set when the unit is in systemd's critical chain, and
+	// Critical when it takes a significant share of userspace boot there
+	InChain  bool          `json:"in_critical_chain"`
+	ActiveAt time.Duration `json:"active_at,omitempty"`
+	Critical bool          `json:"critical"`
+	// OnPredictedPath is set when the unit is on the critical path the unit
+	// files predict
+	OnPredictedPath bool `json:"on_predicted_path"`
+	// Findings are the unit's issues from rules about starting, most severe
+	// first
+	Findings       []types.Issue `json:"findings"`
+	Recommendation string        `json:"recommendation"`
+}
+
+// Correlate sets Report to the units that start slowly, are critical in the
+// critical chain, or are in it with findings about their start, joined with
+// those of issues. Critical units come first, then the slowest.
+func (a *BootAnalysis) Correlate(issues []types.Issue, opts BootOptions) {
+	slowUnit := opts.SlowUnit
+	if slowUnit <= 0 {
+		slowUnit = DefaultSlowUnit
+	}
+
+	findings := make(map[string][]types.Issue)
+	for _, issue := range issues {
+		if slices.ContainsFunc(issue.Tags, func(tag string) bool { return slices.Contains(bootTags, tag) }) {
+			findings[issue.Unit] = append(findings[issue.Unit], issue)
+		}
+	}
+	predicted := make(map[string]bool)
+	if a.PredictedPath != nil {
+		for _, node := range a.PredictedPath.Path.Path {
+			predicted[node.Unit] = true
+		}
+	}
+
+	units := make(map[string]*BootUnitReport)
+	var order []string
+	report := func(name string) *BootUnitReport {
+		if u, ok := units[name]; ok {
+			return u
+		}
+		units[name] = &BootUnitReport{Unit: name, OnPredictedPath: predicted[name], Findings: findings[name]}
+		order = append(order, name)
+		return units[name]
+	}
+	for _, unit := range a.Units {
+		u := report(unit.Name)
+		u.Time, u.WallClock = unit.Time, unit.WallClock
+		u.Slow = a.startCost(unit) > slowUnit
+	}
+	for _, link := range a.CriticalChain {
+		u := report(link.Name)
+		u.InChain, u.ActiveAt, u.Critical = true, link.ActiveAt, link.IsCritical
+		if u.Time == 0 {
+			u.Time = link.Time
+		}
+	}
+
+	a.Report = &BootReport{Units: []BootUnitReport{}}
+	for _, name := range order {
+		u := units[name]
+		if !u.Slow && !u.Critical && !(u.InChain && len(u.Findings) > 0) {
+			continue
+		}
+		sort.SliceStable(u.Findings, func(i, j int) bool {
+			if u.Findings[i].Severity != u.Findings[j].Severity {
+				return u.Findings[i].Severity > u.Findings[j].Severity
+			}
+			return u.Findings[i].RuleID < u.Findings[j].RuleID
+		})
+		if u.Findings == nil {
+			u.Findings = []types.Issue{}
+		}
+		u.Recommendation = u.recommendation()
+		a.Report.Units = append(a.Report.Units, *u)
+	}
+	sort.SliceStable(a.Report.Units, func(i, j int) bool {
+		ui, uj := a.Report.Units[i], a.Report.Units[j]
+		if ui.Critical != uj.Critical {
+			return ui.Critical
+		}
+		if ui.Time != uj.Time {
+			return ui.Time > uj.Time
+		}
+		return ui.Unit < uj.Unit
+	})
+}
+
+// recommendation returns what to do about the unit: the suggestion of its
+// most severe finding, which names the cause, or else the general advice for
+// a unit in the critical chain or a slow one
+func (u *BootUnitReport) recommendation() string {
+	for _, finding := range u.Findings {
+		if finding.Suggestion != "" {
+			return finding.Suggestion
+		}
+	}
+	if u.Critical || u.InChain {
+		return suggestCriticalChain
+	}
+	return suggestSlowUnit
+}
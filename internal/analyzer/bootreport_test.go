@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestCorrelate(t *testing.T) {
+	analysis := &BootAnalysis{
+		Units: []UnitTiming{
+			{Name: "slow.service", Time: 12 * time.Second},
+			{Name: "blocking.service", Time: 4 * time.Second},
+			{Name: "chained.service", Time: time.Second},
+			{Name: "fast.service", Time: 100 * time.Millisecond},
+		},
+		CriticalChain: []ChainLink{
+			{Name: "multi-user.target", ActiveAt: 20 * time.Second},
+			{Name: "blocking.service", ActiveAt: 19 * time.Second, Time: 4 * time.Second, IsCritical: true, Depth: 1},
+			{Name: "chained.service", ActiveAt: 15 * time.Second, Time: time.Second, Depth: 2},
+		},
+		PredictedPath: &BootPath{Path: criticalPathOf("chained.service", "blocking.service")},
+	}
+	issues := []types.Issue{
+		{RuleID: "PERF001", Unit: "slow.service", Severity: types.SeverityLow, Tags: []string{"boot", "socket-activation"}, Suggestion: "Use socket activation."},
+		{RuleID: "PERF005", Unit: "slow.service", Severity: types.SeverityMedium, Tags: []string{"timeout", "startup"}, Suggestion: "Lower TimeoutStartSec=."},
+		{RuleID: "SEC001", Unit: "slow.service", Severity: types.SeverityCritical, Tags: []string{"privilege"}, Suggestion: "Drop root."},
+		{RuleID: "TIME001", Unit: "chained.service", Severity: types.SeverityHigh, Tags: []string{"timeout", "boot"}, Suggestion: "Raise JobTimeoutSec=."},
+		{RuleID: "PERF003", Unit: "fast.service", Severity: types.SeverityInfo, Tags: []string{"startup"}, Suggestion: "Use Type=notify."},
+	}
+
+	analysis.Correlate(issues, BootOptions{})
+
+	type row struct {
+		unit            string
+		slow, critical  bool
+		inChain, onPath bool
+		findings        []string
+		recommendation  string
+	}
+	var got []row
+	for _, u := range analysis.Report.Units {
+		r := row{unit: u.Unit, slow: u.Slow, critical: u.Critical, inChain: u.InChain, onPath: u.OnPredictedPath, recommendation: u.Recommendation}
+		for _, f := range u.Findings {
+			r.findings = append(r.findings, f.RuleID)
+		}
+		got = append(got, r)
+	}
+	want := []row{
+		{unit: "blocking.service", critical: true, inChain: true, onPath: true, recommendation: suggestCriticalChain},
+		{unit: "slow.service", slow: true, findings: []string{"PERF005", "PERF001"}, recommendation: "Lower TimeoutStartSec=."},
+		{unit: "chained.service", inChain: true, onPath: true, findings: []string{"TIME001"}, recommendation: "Raise JobTimeoutSec=."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("report =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// A higher slow threshold leaves slow.service out
+	analysis.Correlate(issues, BootOptions{SlowUnit: 15 * time.Second})
+	for _, u := range analysis.Report.Units {
+		if u.Unit == "slow.service" {
+			t.Errorf("slow.service reported with SlowUnit=15s")
+		}
+	}
+}
+
+// criticalPathOf returns a critical path through the given units
+func criticalPathOf(units ...string) timing.CriticalPath {
+	path := timing.CriticalPath{Unit: units[len(units)-1]}
+	for _, unit := range units {
+		path.Path = append(path.Path, timing.PathNode{Unit: unit})
+	}
+	return path
+}
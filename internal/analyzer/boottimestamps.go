@@ -0,0 +1,226 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ActivationInterval is when a unit was activating during boot, in monotonic
+// time since the kernel started: from leaving the inactive state until it
+// became active
+type ActivationInterval struct {
+	Unit  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// timestampProperties are the unit properties systemctl show is asked for
+var timestampProperties = []string{"Id", "InactiveExitTimestampMonotonic", "ActiveEnterTimestampMonotonic"}
+
+// errNoActivationTimestamps is returned when no unit activated during boot
+var errNoActivationTimestamps = errors.New("no unit activation timestamps")
+
+// measureFromTimestamps sets unit timings to the activation intervals of the
+// units in this boot, read with systemctl show
+func (a *BootAnalysis) measureFromTimestamps(ctx context.Context) error {
+	output, err := exec.CommandContext(ctx, "systemctl", "show", "--property="+strings.Join(timestampProperties, ","), "*").Output()
+	if err != nil {
+		return err
+	}
+	intervals, err := parseShowTimestamps(output)
+	if err != nil {
+		return err
+	}
+
+	// The manager's own timestamps bound the boot
+	output, err = exec.CommandContext(ctx, "systemctl", "show", "--property=UserspaceTimestampMonotonic,FinishTimestampMonotonic").Output()
+	if err != nil {
+		return err
+	}
+	var userspace, finish time.Duration
+	for _, block := range parseShowBlocks(output) {
+		userspace, _ = monotonic(block["UserspaceTimestampMonotonic"])
+		finish, _ = monotonic(block["FinishTimestampMonotonic"])
+	}
+	return a.applyIntervals(boundIntervals(intervals, userspace, finish))
+}
+
+// parseShowBlocks parses the output of systemctl show into one map of
+// properties per unit. Units are separated by blank lines.
+func parseShowBlocks(output []byte) []map[string]string {
+	var blocks []map[string]string
+	var block map[string]string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			block = nil
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if block == nil {
+			block = make(map[string]string)
+			blocks = append(blocks, block)
+		}
+		block[key] = value
+	}
+	return blocks
+}
+
+// monotonic parses a monotonic timestamp of systemctl show, in microseconds.
+// Zero means the event did not happen.
+func monotonic(value string) (time.Duration, error) {
+	us, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(us) * time.Microsecond, nil
+}
+
+// parseShowTimestamps parses the output of
+//
+//	systemctl show --property=Id,InactiveExitTimestampMonotonic,ActiveEnterTimestampMonotonic '*'
+//
+// into the activation interval of each unit that activated. Units that never
+// left the inactive state or never became active are left out.
+func parseShowTimestamps(output []byte) ([]ActivationInterval, error) {
+	var intervals []ActivationInterval
+	for _, block := range parseShowBlocks(output) {
+		unit := block["Id"]
+		if unit == "" {
+			continue
+		}
+		start, err := monotonic(block["InactiveExitTimestampMonotonic"])
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid InactiveExitTimestampMonotonic: %w", unit, err)
+		}
+		end, err := monotonic(block["ActiveEnterTimestampMonotonic"])
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid ActiveEnterTimestampMonotonic: %w", unit, err)
+		}
+		if start == 0 || end < start {
+			continue
+		}
+		intervals = append(intervals, ActivationInterval{Unit: unit, Start: start, End: end})
+	}
+	return intervals, nil
+}
+
+// boundIntervals keeps the intervals of units that started activating in
+// userspace and were active by the time boot finished. Zero bounds are not
+// applied.
+func boundIntervals(intervals []ActivationInterval, userspace, finish time.Duration) []ActivationInterval {
+	var kept []ActivationInterval
+	for _, interval := range intervals {
+		if interval.Start < userspace || (finish > 0 && interval.End > finish) {
+			continue
+		}
+		kept = append(kept, interval)
+	}
+	return kept
+}
+
+// attributeWallClock splits the wall-clock time during which units were
+// activating among them: each instant is shared equally by the units
+// activating at it. It returns each unit's share and the wall-clock time
+// during which any unit was activating, which the shares add up to.
+func attributeWallClock(intervals []ActivationInterval) (map[string]time.Duration, time.Duration) {
+	type event struct {
+		at    time.Duration
+		index int
+		start bool
+	}
+	events := make([]event, 0, 2*len(intervals))
+	for i, interval := range intervals {
+		events = append(events, event{interval.Start, i, true}, event{interval.End, i, false})
+	}
+	// Ends go before starts at the same instant, so touching intervals do
+	// not share it
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at != events[j].at {
+			return events[i].at < events[j].at
+		}
+		return !events[i].start && events[j].start
+	})
+
+	shares := make([]float64, len(intervals))
+	active := make(map[int]bool)
+	var busy time.Duration
+	for i, e := range events {
+		if i > 0 && len(active) > 0 {
+			span := e.at - events[i-1].at
+			busy += span
+			for index := range active {
+				shares[index] += float64(span) / float64(len(active))
+			}
+		}
+		if e.start {
+			active[e.index] = true
+		} else {
+			delete(active, e.index)
+		}
+	}
+
+	wallClock := make(map[string]time.Duration, len(intervals))
+	for i, interval := range intervals {
+		wallClock[interval.Unit] += time.Duration(shares[i])
+	}
+	return wallClock, busy
+}
+
+// applyIntervals sets unit timings to the activation intervals, with each
+// unit's share of wall-clock time and how many units activated alongside it
+// on average, the units that held up boot longest first
+func (a *BootAnalysis) applyIntervals(intervals []ActivationInterval) error {
+	if len(intervals) == 0 {
+		return errNoActivationTimestamps
+	}
+	wallClock, busy := attributeWallClock(intervals)
+
+	a.Units = nil
+	var total time.Duration
+	for _, interval := range intervals {
+		took := interval.End - interval.Start
+		total += took
+		unit := UnitTiming{
+			Name:      interval.Unit,
+			Time:      took,
+			Current:   took,
+			Samples:   1,
+			WallClock: wallClock[interval.Unit],
+		}
+		if unit.WallClock > 0 {
+			unit.Parallelism = float64(took) / float64(unit.WallClock)
+		}
+		a.Units = append(a.Units, unit)
+	}
+	sort.Slice(a.Units, func(i, j int) bool {
+		if a.Units[i].WallClock != a.Units[j].WallClock {
+			return a.Units[i].WallClock > a.Units[j].WallClock
+		}
+		if a.Units[i].Time != a.Units[j].Time {
+			return a.Units[i].Time > a.Units[j].Time
+		}
+		return a.Units[i].Name < a.Units[j].Name
+	})
+	for i := range a.Units {
+		a.Units[i].Position = i
+	}
+
+	if busy > 0 {
+		a.Parallelism = float64(total) / float64(busy)
+	}
+	a.TimingSource = "timestamps"
+	return nil
+}
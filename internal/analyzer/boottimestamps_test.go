@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseShowTimestamps(t *testing.T) {
+	output, err := os.ReadFile("../../testdata/boot/timestamps/show.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	intervals, err := parseShowTimestamps(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// inactive.service never started and failed.service never became active
+	var names []string
+	for _, interval := range intervals {
+		names = append(names, interval.Unit)
+	}
+	want := []string{"a.service", "b.service", "c.service", "d.service", "initrd.service", "later.service"}
+	if len(names) != len(want) {
+		t.Fatalf("units = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("units = %v, want %v", names, want)
+		}
+	}
+	if got := intervals[0]; got.Start != 2*time.Second || got.End != 12*time.Second {
+		t.Errorf("a.service interval = %s to %s, want 2s to 12s", got.Start, got.End)
+	}
+
+	// initrd.service started before userspace, later.service after boot finished
+	bounded := boundIntervals(intervals, 2*time.Second, 20*time.Second)
+	if len(bounded) != 4 || bounded[3].Unit != "d.service" {
+		t.Errorf("boundIntervals() = %+v, want a to d.service", bounded)
+	}
+
+	if _, err := parseShowTimestamps([]byte("Id=x.service\nInactiveExitTimestampMonotonic=soon\n")); err == nil {
+		t.Error("parseShowTimestamps() accepted an invalid timestamp")
+	}
+}
+
+func TestApplyIntervals(t *testing.T) {
+	// a.service activates from 2s to 12s, alongside b.service from 2s to 4s
+	// and c.service from 6s to 8s; d.service starts as a.service finishes
+	intervals := []ActivationInterval{
+		{Unit: "a.service", Start: 2 * time.Second, End: 12 * time.Second},
+		{Unit: "b.service", Start: 2 * time.Second, End: 4 * time.Second},
+		{Unit: "c.service", Start: 6 * time.Second, End: 8 * time.Second},
+		{Unit: "d.service", Start: 12 * time.Second, End: 12500 * time.Millisecond},
+	}
+	a := &BootAnalysis{}
+	if err := a.applyIntervals(intervals); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name        string
+		took, wall  time.Duration
+		parallelism float64
+	}{
+		{"a.service", 10 * time.Second, 8 * time.Second, 1.25},
+		{"b.service", 2 * time.Second, time.Second, 2},
+		{"c.service", 2 * time.Second, time.Second, 2},
+		{"d.service", 500 * time.Millisecond, 500 * time.Millisecond, 1},
+	}
+	if len(a.Units) != len(want) {
+		t.Fatalf("got %d units, want %d", len(a.Units), len(want))
+	}
+	for i, w := range want {
+		got := a.Units[i]
+		if got.Name != w.name || got.Time != w.took || got.WallClock != w.wall || math.Abs(got.Parallelism-w.parallelism) > 1e-9 || got.Position != i {
+			t.Errorf("unit %d = %s took %s, %s wall-clock, parallelism %.2f, want %s took %s, %s wall-clock, parallelism %.2f",
+				i, got.Name, got.Time, got.WallClock, got.Parallelism, w.name, w.took, w.wall, w.parallelism)
+		}
+	}
+	// 14.5s of starting in 10.5s of wall-clock time
+	if math.Abs(a.Parallelism-14.5/10.5) > 1e-9 {
+		t.Errorf("Parallelism = %.3f, want %.3f", a.Parallelism, 14.5/10.5)
+	}
+	if a.TimingSource != "timestamps" {
+		t.Errorf("TimingSource = %q, want timestamps", a.TimingSource)
+	}
+
+	// a.service takes 10s but holds up boot for 8s of it
+	a.detectIssues(BootOptions{SlowUnit: 9 * time.Second})
+	if len(a.Issues) != 0 {
+		t.Errorf("Issues with SlowUnit=9s = %+v, want none", a.Issues)
+	}
+	a.detectIssues(BootOptions{SlowUnit: 5 * time.Second})
+	if len(a.Issues) != 1 || a.Issues[0].Unit != "a.service" {
+		t.Errorf("Issues with SlowUnit=5s = %+v, want a.service", a.Issues)
+	}
+
+	if err := (&BootAnalysis{}).applyIntervals(nil); err == nil {
+		t.Error("applyIntervals(nil) succeeded")
+	}
+}
@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"context"
+	"sort"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/rules/crossunit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// deadlockTags select the restart deadlock and restart storm rules
+var deadlockTags = []string{crossunit.TagDeadlock, crossunit.TagRestartStorm}
+
+// DeadlockRules returns the rules AnalyzeDeadlocks runs
+func DeadlockRules() []rules.Rule {
+	var selected []rules.Rule
+	for _, rule := range rules.All() {
+		for _, tag := range rule.Tags() {
+			if containsString(deadlockTags, tag) {
+				selected = append(selected, rule)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// AnalyzeDeadlocks runs the restart deadlock, Requisite= wait and restart
+// storm rules over units and returns their findings, most severe first. It
+// stops with ctx's error when ctx is cancelled before the rules run.
+func AnalyzeDeadlocks(ctx context.Context, units map[string]*types.UnitFile) ([]types.Issue, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	hostCtx := rules.NewHostContext(units)
+	hostCtx.Graph = graph.Build(units)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	issues := rules.RunHost(hostCtx, nil, nil, deadlockTags)
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		if a.Unit != b.Unit {
+			return a.Unit < b.Unit
+		}
+		return a.Description < b.Description
+	})
+
+	return issues, nil
+}
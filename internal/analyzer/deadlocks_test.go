@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestAnalyzeDeadlocks(t *testing.T) {
+	tests := []struct {
+		dir      string
+		wantRule string
+		wantUnit string
+		wantLine int
+	}{
+		{"deadlock", "PROP004", "primary.service", 3},
+		{"restart_storm", "PROP006", "a.service", 3},
+		{"restart_storm", "PROP001", "a.service", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir+"/"+tt.wantRule, func(t *testing.T) {
+			units, err := LoadUnitsFromDirectory("../../testdata/propagation/" + tt.dir)
+			if err != nil {
+				t.Fatalf("failed to load %s: %v", tt.dir, err)
+			}
+
+			var found *types.Issue
+			issues, err := AnalyzeDeadlocks(context.Background(), units)
+			if err != nil {
+				t.Fatalf("AnalyzeDeadlocks failed: %v", err)
+			}
+			for i := range issues {
+				if issues[i].RuleID == tt.wantRule {
+					found = &issues[i]
+					break
+				}
+			}
+			if found == nil {
+				t.Fatalf("no %s issue in %+v", tt.wantRule, issues)
+			}
+
+			if found.Unit != tt.wantUnit {
+				t.Errorf("Unit = %s, want %s", found.Unit, tt.wantUnit)
+			}
+			if !strings.HasSuffix(found.File, "/"+tt.wantUnit) {
+				t.Errorf("File = %q, want the path of %s", found.File, tt.wantUnit)
+			}
+			if found.Line == nil || *found.Line != tt.wantLine {
+				t.Errorf("Line = %v, want %d", found.Line, tt.wantLine)
+			}
+			if found.Severity != types.SeverityCritical || found.Suggestion == "" {
+				t.Errorf("Severity = %s, Suggestion = %q; want critical with a resolution", found.Severity, found.Suggestion)
+			}
+		})
+	}
+}
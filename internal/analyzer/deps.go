@@ -0,0 +1,462 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// DependencyGraph is the part of the dependency graph that is saved as a
+// baseline and compared between runs
+type DependencyGraph struct {
+	Units map[string]*DependencyNode
+	Edges []DependencyEdge
+}
+
+// DependencyNode represents a unit in the dependency graph
+type DependencyNode struct {
+	Name     string
+	Type     string // "service", "socket", "target", etc.
+	Requires []string
+	Wants    []string
+	After    []string
+	Before   []string
+}
+
+// DependencyEdge represents a dependency relationship
+type DependencyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"` // "requires", "wants", "after", "before", ...
+}
+
+// DependencyIssue represents a detected dependency issue
+type DependencyIssue struct {
+	Kind string `json:"kind"` // "cycle", "dangling", "ordering", "binding", "conflict"
+	// Rule is the ID of the scan rule reporting this kind of finding, so
+	// that both commands name it alike
+	Rule        string   `json:"rule,omitempty"`
+	Units       []string `json:"units"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Suggestion  string   `json:"suggestion,omitempty"`
+	File        string   `json:"file,omitempty"`
+	Line        int      `json:"line,omitempty"`
+	// Cycle lists the edges around the shortest path of a cycle, in order
+	Cycle []CycleEdge `json:"cycle,omitempty"`
+}
+
+// CycleEdge is an edge on the path of a dependency cycle, with the directive
+// that declares it
+type CycleEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Break marks the edges suggested for removal
+	Break bool `json:"break,omitempty"`
+}
+
+// Directive returns the name of the directive that declares the edge, such
+// as "Requires"
+func (e CycleEdge) Directive() string {
+	if et, ok := graph.ParseEdgeType(e.Type); ok {
+		return et.String()
+	}
+	return e.Type
+}
+
+// DependencyCount is how many units a unit pulls in when it starts
+type DependencyCount struct {
+	Unit       string `json:"unit"`
+	Direct     int    `json:"direct"`
+	Transitive int    `json:"transitive"`
+}
+
+// DependencyReport is the result of analyzing a dependency graph
+type DependencyReport struct {
+	// Unit is set when the report is limited to one unit and what it pulls in
+	Unit        string
+	UnitCount   int
+	EdgesByType map[string]int
+	// Counts are sorted by transitive dependencies, largest first
+	Counts []DependencyCount
+	Issues []DependencyIssue
+	Graph  *DependencyGraph
+}
+
+// AnalyzeDependencies reports on a graph built from unit files. If unitName
+// is set, only that unit, the units it pulls in and the issues involving it
+// are reported.
+func AnalyzeDependencies(g *graph.Graph, unitName string) (*DependencyReport, error) {
+	names := g.NodeNames()
+	if unitName != "" {
+		if !g.HasUnit(unitName) {
+			return nil, fmt.Errorf("unit %s not found", unitName)
+		}
+		names = append([]string{unitName}, pulledIn(g, unitName)...)
+		sort.Strings(names)
+	}
+	inScope := make(map[string]bool, len(names))
+	for _, name := range names {
+		inScope[name] = true
+	}
+
+	report := &DependencyReport{
+		Unit:        unitName,
+		EdgesByType: make(map[string]int),
+		Graph:       &DependencyGraph{Units: make(map[string]*DependencyNode)},
+	}
+
+	for _, name := range names {
+		if !g.HasUnit(name) {
+			continue
+		}
+		report.Graph.Units[name] = &DependencyNode{Name: name, Type: unitfile.UnitType(name)}
+	}
+	report.UnitCount = len(report.Graph.Units)
+
+	for _, e := range g.Edges() {
+		if !inScope[e.From] {
+			continue
+		}
+		edgeType := strings.ToLower(e.Type.String())
+		report.EdgesByType[edgeType]++
+		report.Graph.Edges = append(report.Graph.Edges, DependencyEdge{From: e.From, To: e.To, Type: edgeType})
+
+		node, ok := report.Graph.Units[e.From]
+		if !ok {
+			continue
+		}
+		switch e.Type {
+		case graph.EdgeRequires:
+			node.Requires = append(node.Requires, e.To)
+		case graph.EdgeWants:
+			node.Wants = append(node.Wants, e.To)
+		case graph.EdgeAfter:
+			node.After = append(node.After, e.To)
+		case graph.EdgeBefore:
+			node.Before = append(node.Before, e.To)
+		}
+	}
+	sortEdges(report.Graph.Edges)
+
+	if unitName != "" {
+		report.Counts = []DependencyCount{countDependencies(g, unitName)}
+	} else {
+		for name := range report.Graph.Units {
+			report.Counts = append(report.Counts, countDependencies(g, name))
+		}
+		sort.Slice(report.Counts, func(i, j int) bool {
+			if report.Counts[i].Transitive != report.Counts[j].Transitive {
+				return report.Counts[i].Transitive > report.Counts[j].Transitive
+			}
+			return report.Counts[i].Unit < report.Counts[j].Unit
+		})
+	}
+
+	involves := func(units ...string) bool {
+		if unitName == "" {
+			return true
+		}
+		for _, u := range units {
+			if u == unitName {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, cycle := range g.FindCycles() {
+		if !involves(cycle.Units...) {
+			continue
+		}
+		report.Issues = append(report.Issues, cycleIssue(cycle))
+	}
+	for _, d := range g.FindDanglingRefs() {
+		if !involves(d.From) {
+			continue
+		}
+		report.Issues = append(report.Issues, DependencyIssue{
+			Kind:        "dangling",
+			Units:       []string{d.From, d.To},
+			Description: fmt.Sprintf("%s has %s=%s but no such unit exists", d.From, d.EdgeType, d.To),
+			Severity:    d.Severity(),
+			Suggestion:  fmt.Sprintf("Install %s or remove the reference", d.To),
+			File:        d.File,
+			Line:        d.Line,
+		})
+	}
+	for _, o := range g.FindOrderingIssues() {
+		if !involves(o.Unit) {
+			continue
+		}
+		issue := DependencyIssue{
+			Kind:        "ordering",
+			Rule:        orderingRule(o),
+			Units:       []string{o.Unit, o.Related},
+			Description: o.Description,
+			Severity:    "low",
+			Suggestion:  fmt.Sprintf("Add Wants=%s if the ordering should always apply", o.Related),
+			File:        o.File,
+			Line:        o.Line,
+		}
+		if o.IssueType == "requires_without_after" {
+			issue.Severity = "medium"
+			issue.Suggestion = fmt.Sprintf("Add After=%s unless the units are meant to start in parallel", o.Related)
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+	for _, b := range g.FindBindingIssues() {
+		if !involves(b.Unit) {
+			continue
+		}
+		report.Issues = append(report.Issues, DependencyIssue{
+			Kind:        "binding",
+			Units:       []string{b.Unit, b.BoundTo},
+			Description: b.Description,
+			Severity:    "high",
+			Suggestion:  fmt.Sprintf("Add After=%s", b.BoundTo),
+			File:        b.File,
+			Line:        b.Line,
+		})
+	}
+	for _, c := range g.FindConflictingDependencies() {
+		if !involves(c.Unit) {
+			continue
+		}
+		report.Issues = append(report.Issues, DependencyIssue{
+			Kind:        "conflict",
+			Units:       []string{c.Unit, c.Target},
+			Description: c.Conflict,
+			Severity:    "high",
+			Suggestion:  "Remove either the requirement or Conflicts=",
+			File:        c.File,
+			Line:        c.Line,
+		})
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return types.ParseSeverity(report.Issues[i].Severity) > types.ParseSeverity(report.Issues[j].Severity)
+	})
+
+	return report, nil
+}
+
+// countDependencies counts the units a unit pulls in through requirement
+// edges (Requires=, Wants=, BindsTo=, Requisite=), directly and transitively
+func countDependencies(g *graph.Graph, unit string) DependencyCount {
+	direct := make(map[string]bool)
+	for _, e := range g.EdgesFrom(unit) {
+		if e.Type.IsRequirementEdge() && e.To != unit {
+			direct[e.To] = true
+		}
+	}
+
+	return DependencyCount{Unit: unit, Direct: len(direct), Transitive: len(pulledIn(g, unit))}
+}
+
+// pulledIn returns the units a unit transitively pulls in through
+// requirement edges, not counting the unit itself
+func pulledIn(g *graph.Graph, unit string) []string {
+	visited := map[string]bool{unit: true}
+	queue := []string{unit}
+	var units []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, e := range g.EdgesFrom(current) {
+			if e.Type.IsRequirementEdge() && !visited[e.To] {
+				visited[e.To] = true
+				queue = append(queue, e.To)
+				units = append(units, e.To)
+			}
+		}
+	}
+	return units
+}
+
+// AddRuntimeDependencies adds the dependencies systemd has loaded for the
+// units in the graph but that are not in their unit files, such as default
+// dependencies and those from generators. Units only known at runtime are
+// added so their references are not reported as dangling. It needs a
+// running systemd.
+func AddRuntimeDependencies(ctx context.Context, g *graph.Graph) error {
+	names := g.NodeNames()
+	if len(names) == 0 {
+		return nil
+	}
+
+	properties := make([]string, 0, len(graph.DirectiveToEdgeType)+2)
+	properties = append(properties, "Id", "LoadState")
+	for directive := range graph.DirectiveToEdgeType {
+		properties = append(properties, directive)
+	}
+	sort.Strings(properties[2:])
+
+	args := append([]string{"show", "--property=" + strings.Join(properties, ",")}, names...)
+	output, err := exec.CommandContext(ctx, "systemctl", args...).Output()
+	if err != nil {
+		return fmt.Errorf("failed to read runtime dependencies: %w", err)
+	}
+
+	addShowDependencies(g, string(output))
+	return nil
+}
+
+// addShowDependencies adds the edges in systemctl show output that the graph
+// does not have yet, marked as implicit. The output holds one block of
+// properties per unit, separated by blank lines. Units systemd could not find
+// are skipped so their references stay dangling.
+func addShowDependencies(g *graph.Graph, output string) {
+	type edgeKey struct {
+		from, to string
+		edgeType graph.EdgeType
+	}
+	existing := make(map[edgeKey]bool)
+	for _, e := range g.Edges() {
+		existing[edgeKey{e.From, e.To, e.Type}] = true
+	}
+
+	var edges []graph.Edge
+	var loaded []string
+	for _, block := range strings.Split(output, "\n\n") {
+		props := make(map[string]string)
+		for _, line := range strings.Split(block, "\n") {
+			if key, value, ok := strings.Cut(line, "="); ok {
+				props[key] = value
+			}
+		}
+		from := props["Id"]
+		if from == "" || props["LoadState"] == "not-found" {
+			continue
+		}
+		loaded = append(loaded, from)
+
+		directives := make([]string, 0, len(props))
+		for directive := range props {
+			directives = append(directives, directive)
+		}
+		sort.Strings(directives)
+
+		for _, directive := range directives {
+			edgeType, ok := graph.DirectiveToEdgeType[directive]
+			if !ok {
+				continue
+			}
+			targets := strings.Fields(props[directive])
+			sort.Strings(targets)
+			for _, to := range targets {
+				key := edgeKey{from, to, edgeType}
+				if existing[key] {
+					continue
+				}
+				existing[key] = true
+				edges = append(edges, graph.Edge{From: from, To: to, Type: edgeType, Implicit: true})
+			}
+		}
+	}
+
+	// systemd has loaded these units, so they exist even without a unit file
+	for _, e := range edges {
+		loaded = append(loaded, e.From, e.To)
+	}
+	for _, name := range loaded {
+		if !g.HasUnit(name) {
+			g.AddUnit(&types.UnitFile{Name: name, Type: unitfile.UnitType(name), Sections: map[string]*types.Section{}})
+		}
+	}
+	for _, e := range edges {
+		g.AddEdge(e)
+	}
+}
+
+// linkEdgeTypes maps the kinds of dependency links to edge types. Upholds=
+// restarts the unit when it stops, and otherwise pulls it in like Wants=.
+var linkEdgeTypes = map[string]graph.EdgeType{
+	"Wants":    graph.EdgeWants,
+	"Requires": graph.EdgeRequires,
+	"Upholds":  graph.EdgeWants,
+}
+
+// addLinks adds the dependencies of .wants/, .requires/ and .upholds/ links
+// that the graph does not have yet, usually from [Install] of an enabled unit,
+// and records alias links
+func addLinks(g *graph.Graph, links []unitfile.Link) {
+	type edgeKey struct {
+		from, to string
+		edgeType graph.EdgeType
+	}
+	existing := make(map[edgeKey]bool)
+	for _, e := range g.Edges() {
+		existing[edgeKey{e.From, e.To, e.Type}] = true
+	}
+
+	for _, link := range links {
+		if link.Kind == "Alias" {
+			g.AddAlias(link.From, link.To)
+			continue
+		}
+		edgeType, ok := linkEdgeTypes[link.Kind]
+		if !ok {
+			continue
+		}
+		key := edgeKey{link.From, link.To, edgeType}
+		if existing[key] {
+			continue
+		}
+		existing[key] = true
+		g.AddEdge(graph.Edge{From: link.From, To: link.To, Type: edgeType, File: link.Path})
+	}
+}
+
+// orderingRule returns the ID of the scan rule reporting an ordering issue
+func orderingRule(o graph.OrderingIssue) string {
+	switch {
+	case o.IssueType == "after_without_requires":
+		return "REL005"
+	case o.EdgeType == graph.EdgeBindsTo:
+		return "REL010"
+	default:
+		return "GRAPH003"
+	}
+}
+
+// cycleIssue reports a cycle along its shortest path, located at the edge
+// suggested for removal
+func cycleIssue(cycle graph.SCC) DependencyIssue {
+	issue := DependencyIssue{
+		Kind:        "cycle",
+		Units:       cycle.Units,
+		Description: fmt.Sprintf("Dependency cycle %s (%s)", cycle.CycleDescription(), cycle.InvolvedEdgeTypes()),
+		Severity:    cycle.CycleSeverity(),
+		Suggestion:  cycle.BreakSuggestion(),
+	}
+	breakStep, _ := cycle.BreakStep()
+	for _, step := range cycle.Path {
+		for _, e := range step.Edges {
+			edge := CycleEdge{
+				From: e.From,
+				To:   e.To,
+				Type: strings.ToLower(e.Type.String()),
+				File: e.File,
+				Line: e.Line,
+			}
+			if step.From == breakStep.From && step.To == breakStep.To {
+				edge.Break = true
+				if issue.File == "" {
+					issue.File, issue.Line = e.File, e.Line
+				}
+			}
+			issue.Cycle = append(issue.Cycle, edge)
+		}
+	}
+	return issue
+}
@@ -0,0 +1,157 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/supabase/sdaudit/internal/graph"
+)
+
+// loadTestGraph builds the graph of a fixture with the declared dependencies
+// only, so that counts and dependents do not include the default ones
+func loadTestGraph(t *testing.T, dir string) *graph.Graph {
+	t.Helper()
+	units, err := LoadUnitsFromDirectory("../../testdata/graph/" + dir)
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", dir, err)
+	}
+	return graph.BuildWithOptions(units, graph.BuilderOptions{})
+}
+
+func TestAnalyzeDependenciesIssues(t *testing.T) {
+	tests := []struct {
+		dir          string
+		wantKind     string
+		wantSeverity string
+		wantRule     string
+	}{
+		{"cycle_simple", "cycle", "critical", ""},
+		{"dangling_requires", "dangling", "high", ""},
+		{"after_without_requires", "ordering", "low", "REL005"},
+		{"requires_without_after", "ordering", "medium", "GRAPH003"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			report, err := AnalyzeDependencies(loadTestGraph(t, tt.dir), "")
+			if err != nil {
+				t.Fatalf("AnalyzeDependencies() error = %v", err)
+			}
+			for _, issue := range report.Issues {
+				if issue.Kind == tt.wantKind && issue.Severity == tt.wantSeverity && issue.Rule == tt.wantRule {
+					return
+				}
+			}
+			t.Errorf("no %s issue with severity %s and rule %q in %+v", tt.wantKind, tt.wantSeverity, tt.wantRule, report.Issues)
+		})
+	}
+}
+
+func TestAnalyzeDependenciesCyclePath(t *testing.T) {
+	report, err := AnalyzeDependencies(loadTestGraph(t, "cycle_path"), "")
+	if err != nil {
+		t.Fatalf("AnalyzeDependencies() error = %v", err)
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Kind != "cycle" {
+			continue
+		}
+		want := []CycleEdge{
+			{From: "b.service", To: "c.service", Type: "requires", Line: 3},
+			{From: "c.service", To: "b.service", Type: "wants", Line: 4, Break: true},
+		}
+		if len(issue.Cycle) != len(want) {
+			t.Fatalf("Cycle = %+v, want %+v", issue.Cycle, want)
+		}
+		for i, e := range issue.Cycle {
+			e.File = ""
+			if e != want[i] {
+				t.Errorf("Cycle[%d] = %+v, want %+v", i, e, want[i])
+			}
+		}
+		if filepath.Base(issue.File) != "c.service" || issue.Line != 4 {
+			t.Errorf("issue at %s:%d, want the Wants= line of c.service", issue.File, issue.Line)
+		}
+		return
+	}
+	t.Errorf("no cycle issue in %+v", report.Issues)
+}
+
+func TestAnalyzeDependenciesCounts(t *testing.T) {
+	g := loadTestGraph(t, "linear_chain")
+
+	report, err := AnalyzeDependencies(g, "")
+	if err != nil {
+		t.Fatalf("AnalyzeDependencies() error = %v", err)
+	}
+	if report.UnitCount != 3 {
+		t.Errorf("UnitCount = %d, want 3", report.UnitCount)
+	}
+	if report.EdgesByType["requires"] != 2 || report.EdgesByType["after"] != 2 {
+		t.Errorf("EdgesByType = %v, want 2 requires and 2 after", report.EdgesByType)
+	}
+	want := []DependencyCount{
+		{Unit: "s3.service", Direct: 1, Transitive: 2},
+		{Unit: "s2.service", Direct: 1, Transitive: 1},
+		{Unit: "s1.service", Direct: 0, Transitive: 0},
+	}
+	for i, c := range report.Counts {
+		if c != want[i] {
+			t.Errorf("Counts[%d] = %+v, want %+v", i, c, want[i])
+		}
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("Issues = %+v, want none", report.Issues)
+	}
+
+	single, err := AnalyzeDependencies(g, "s2.service")
+	if err != nil {
+		t.Fatalf("AnalyzeDependencies(s2.service) error = %v", err)
+	}
+	if single.UnitCount != 2 || len(single.Counts) != 1 || single.Counts[0] != want[1] {
+		t.Errorf("AnalyzeDependencies(s2.service) = %d units, counts %+v", single.UnitCount, single.Counts)
+	}
+	if _, ok := single.Graph.Units["s3.service"]; ok {
+		t.Error("s3.service is not pulled in by s2.service but is in its graph")
+	}
+
+	if _, err := AnalyzeDependencies(g, "missing.service"); err == nil {
+		t.Error("AnalyzeDependencies(missing.service) succeeded, want error")
+	}
+}
+
+func TestAddShowDependencies(t *testing.T) {
+	g := loadTestGraph(t, "dangling_requires")
+	output := "Id=app.service\nLoadState=loaded\nRequires=missing-db.service system.slice\nWants=\nAfter=missing-db.service sysinit.target\nBefore=\n\n" +
+		"Id=missing-db.service\nLoadState=loaded\nRequires=\nAfter=\n\n" +
+		"Id=gone.service\nLoadState=not-found\nRequires=\n"
+
+	addShowDependencies(g, output)
+
+	if refs := g.FindDanglingRefs(); len(refs) != 0 {
+		t.Errorf("dangling refs after adding runtime units = %+v, want none", refs)
+	}
+	if g.HasUnit("gone.service") {
+		t.Error("gone.service was not found by systemd but was added")
+	}
+
+	var implicit []graph.Edge
+	for _, e := range g.Edges() {
+		if e.Implicit {
+			implicit = append(implicit, e)
+		}
+	}
+	want := []graph.Edge{
+		{From: "app.service", To: "sysinit.target", Type: graph.EdgeAfter, Implicit: true},
+		{From: "app.service", To: "system.slice", Type: graph.EdgeRequires, Implicit: true},
+	}
+	if len(implicit) != len(want) {
+		t.Fatalf("implicit edges = %+v, want %+v", implicit, want)
+	}
+	for i := range want {
+		if implicit[i] != want[i] {
+			t.Errorf("implicit edge %d = %+v, want %+v", i, implicit[i], want[i])
+		}
+	}
+}
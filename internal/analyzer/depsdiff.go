@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// savedGraph is the on-disk form of a DependencyGraph
+type savedGraph struct {
+	Units []savedUnit      `json:"units"`
+	Edges []DependencyEdge `json:"edges"`
+}
+
+type savedUnit struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// DependencyDiff lists how a dependency graph changed against a baseline
+type DependencyDiff struct {
+	AddedUnits   []string         `json:"added_units"`
+	RemovedUnits []string         `json:"removed_units"`
+	AddedEdges   []DependencyEdge `json:"added_edges"`
+	RemovedEdges []DependencyEdge `json:"removed_edges"`
+	ChangedEdges []EdgeChange     `json:"changed_edges"`
+}
+
+// EdgeChange is a pair of units linked in both graphs by different dependency types
+type EdgeChange struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	OldTypes []string `json:"old_types"`
+	NewTypes []string `json:"new_types"`
+}
+
+// Empty reports whether the graphs were identical
+func (d *DependencyDiff) Empty() bool {
+	return len(d.AddedUnits) == 0 && len(d.RemovedUnits) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 && len(d.ChangedEdges) == 0
+}
+
+// SaveDependencyGraph writes a graph to path as JSON, with units and edges sorted
+func SaveDependencyGraph(path string, graph *DependencyGraph) error {
+	saved := savedGraph{Units: []savedUnit{}, Edges: append([]DependencyEdge{}, graph.Edges...)}
+	for _, node := range graph.Units {
+		saved.Units = append(saved.Units, savedUnit{Name: node.Name, Type: node.Type})
+	}
+	sort.Slice(saved.Units, func(i, j int) bool { return saved.Units[i].Name < saved.Units[j].Name })
+	sortEdges(saved.Edges)
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadDependencyGraph reads a graph written by SaveDependencyGraph
+func LoadDependencyGraph(path string) (*DependencyGraph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved savedGraph
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid dependency graph %s: %w", path, err)
+	}
+
+	graph := &DependencyGraph{
+		Units: make(map[string]*DependencyNode),
+		Edges: saved.Edges,
+	}
+	for _, u := range saved.Units {
+		graph.Units[u.Name] = &DependencyNode{Name: u.Name, Type: u.Type}
+	}
+	for _, e := range saved.Edges {
+		node, ok := graph.Units[e.From]
+		if !ok {
+			continue
+		}
+		switch e.Type {
+		case "requires":
+			node.Requires = append(node.Requires, e.To)
+		case "wants":
+			node.Wants = append(node.Wants, e.To)
+		case "after":
+			node.After = append(node.After, e.To)
+		case "before":
+			node.Before = append(node.Before, e.To)
+		}
+	}
+
+	return graph, nil
+}
+
+// DiffDependencyGraphs compares current against baseline. Edges between the
+// same pair of units whose types differ are reported as changed rather than
+// as a removal and an addition.
+func DiffDependencyGraphs(baseline, current *DependencyGraph) *DependencyDiff {
+	diff := &DependencyDiff{
+		AddedUnits:   []string{},
+		RemovedUnits: []string{},
+		AddedEdges:   []DependencyEdge{},
+		RemovedEdges: []DependencyEdge{},
+		ChangedEdges: []EdgeChange{},
+	}
+
+	for name := range current.Units {
+		if _, ok := baseline.Units[name]; !ok {
+			diff.AddedUnits = append(diff.AddedUnits, name)
+		}
+	}
+	for name := range baseline.Units {
+		if _, ok := current.Units[name]; !ok {
+			diff.RemovedUnits = append(diff.RemovedUnits, name)
+		}
+	}
+	sort.Strings(diff.AddedUnits)
+	sort.Strings(diff.RemovedUnits)
+
+	before, after := edgeTypes(baseline.Edges), edgeTypes(current.Edges)
+	for pair, types := range after {
+		old, ok := before[pair]
+		switch {
+		case !ok:
+			for _, t := range types {
+				diff.AddedEdges = append(diff.AddedEdges, DependencyEdge{From: pair[0], To: pair[1], Type: t})
+			}
+		case strings.Join(old, ",") != strings.Join(types, ","):
+			diff.ChangedEdges = append(diff.ChangedEdges, EdgeChange{From: pair[0], To: pair[1], OldTypes: old, NewTypes: types})
+		}
+	}
+	for pair, types := range before {
+		if _, ok := after[pair]; !ok {
+			for _, t := range types {
+				diff.RemovedEdges = append(diff.RemovedEdges, DependencyEdge{From: pair[0], To: pair[1], Type: t})
+			}
+		}
+	}
+	sortEdges(diff.AddedEdges)
+	sortEdges(diff.RemovedEdges)
+	sort.Slice(diff.ChangedEdges, func(i, j int) bool {
+		a, b := diff.ChangedEdges[i], diff.ChangedEdges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+
+	return diff
+}
+
+// edgeTypes groups edges by their (from, to) pair, with the types sorted
+func edgeTypes(edges []DependencyEdge) map[[2]string][]string {
+	pairs := make(map[[2]string][]string)
+	for _, e := range edges {
+		pair := [2]string{e.From, e.To}
+		if !containsString(pairs[pair], e.Type) {
+			pairs[pair] = append(pairs[pair], e.Type)
+		}
+	}
+	for _, types := range pairs {
+		sort.Strings(types)
+	}
+	return pairs
+}
+
+func sortEdges(edges []DependencyEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Type < b.Type
+	})
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/supabase/sdaudit/internal/unitfile"
+)
+
+func makeTestGraph(units []string, edges []DependencyEdge) *DependencyGraph {
+	graph := &DependencyGraph{Units: make(map[string]*DependencyNode)}
+	for _, name := range units {
+		graph.Units[name] = &DependencyNode{Name: name, Type: unitfile.UnitType(name)}
+	}
+	graph.Edges = edges
+	return graph
+}
+
+func TestDependencyGraphRoundTrip(t *testing.T) {
+	graph := makeTestGraph(
+		[]string{"multi-user.target", "nginx.service", "network.target"},
+		[]DependencyEdge{
+			{From: "nginx.service", To: "network.target", Type: "after"},
+			{From: "multi-user.target", To: "nginx.service", Type: "wants"},
+			{From: "nginx.service", To: "network.target", Type: "wants"},
+		},
+	)
+
+	path := filepath.Join(t.TempDir(), "deps.json")
+	if err := SaveDependencyGraph(path, graph); err != nil {
+		t.Fatalf("SaveDependencyGraph() error = %v", err)
+	}
+	loaded, err := LoadDependencyGraph(path)
+	if err != nil {
+		t.Fatalf("LoadDependencyGraph() error = %v", err)
+	}
+
+	if len(loaded.Units) != 3 || len(loaded.Edges) != 3 {
+		t.Fatalf("loaded %d units and %d edges, want 3 and 3", len(loaded.Units), len(loaded.Edges))
+	}
+	nginx := loaded.Units["nginx.service"]
+	if nginx == nil || nginx.Type != "service" {
+		t.Fatalf("nginx.service loaded as %+v", nginx)
+	}
+	if !reflect.DeepEqual(nginx.After, []string{"network.target"}) || !reflect.DeepEqual(nginx.Wants, []string{"network.target"}) {
+		t.Errorf("nginx.service dependencies = after %v, wants %v", nginx.After, nginx.Wants)
+	}
+
+	if diff := DiffDependencyGraphs(graph, loaded); !diff.Empty() {
+		t.Errorf("diff of a graph against its saved copy = %+v, want empty", diff)
+	}
+}
+
+func TestDiffDependencyGraphs(t *testing.T) {
+	baseline := makeTestGraph(
+		[]string{"app.service", "db.service", "legacy.service"},
+		[]DependencyEdge{
+			{From: "app.service", To: "db.service", Type: "wants"},
+			{From: "app.service", To: "db.service", Type: "after"},
+			{From: "app.service", To: "legacy.service", Type: "requires"},
+		},
+	)
+	current := makeTestGraph(
+		[]string{"app.service", "db.service", "cache.service"},
+		[]DependencyEdge{
+			{From: "app.service", To: "db.service", Type: "requires"},
+			{From: "app.service", To: "db.service", Type: "after"},
+			{From: "app.service", To: "cache.service", Type: "wants"},
+		},
+	)
+
+	diff := DiffDependencyGraphs(baseline, current)
+
+	if !reflect.DeepEqual(diff.AddedUnits, []string{"cache.service"}) {
+		t.Errorf("AddedUnits = %v", diff.AddedUnits)
+	}
+	if !reflect.DeepEqual(diff.RemovedUnits, []string{"legacy.service"}) {
+		t.Errorf("RemovedUnits = %v", diff.RemovedUnits)
+	}
+	if !reflect.DeepEqual(diff.AddedEdges, []DependencyEdge{{From: "app.service", To: "cache.service", Type: "wants"}}) {
+		t.Errorf("AddedEdges = %v", diff.AddedEdges)
+	}
+	if !reflect.DeepEqual(diff.RemovedEdges, []DependencyEdge{{From: "app.service", To: "legacy.service", Type: "requires"}}) {
+		t.Errorf("RemovedEdges = %v", diff.RemovedEdges)
+	}
+	wantChanged := []EdgeChange{{
+		From:     "app.service",
+		To:       "db.service",
+		OldTypes: []string{"after", "wants"},
+		NewTypes: []string{"after", "requires"},
+	}}
+	if !reflect.DeepEqual(diff.ChangedEdges, wantChanged) {
+		t.Errorf("ChangedEdges = %v, want %v", diff.ChangedEdges, wantChanged)
+	}
+	if diff.Empty() {
+		t.Error("Empty() = true for a changed graph")
+	}
+}
@@ -0,0 +1,49 @@
+package analyzer
+
+import "github.com/supabase/sdaudit/pkg/types"
+
+// IssueDiff lists how the issues of two scans differ, matched by
+// fingerprint as in baselines
+type IssueDiff struct {
+	// New are the issues of the new scan the old one did not have
+	New []types.Issue
+	// Resolved are the issues of the old scan the new one no longer has
+	Resolved []types.Issue
+	// Persisting are the issues of the new scan the old one had too
+	Persisting []types.Issue
+}
+
+// DiffIssues compares the issues of an old and a new scan. An issue found
+// twice in one scan, such as in two units of the same name, matches as many
+// issues of the same fingerprint in the other. Each list is sorted most
+// severe first.
+func DiffIssues(old, new []types.Issue) *IssueDiff {
+	unmatched := make(map[string][]int)
+	for i, issue := range old {
+		fp := issue.Fingerprint()
+		unmatched[fp] = append(unmatched[fp], i)
+	}
+
+	diff := &IssueDiff{New: []types.Issue{}, Resolved: []types.Issue{}, Persisting: []types.Issue{}}
+	matched := make([]bool, len(old))
+	for _, issue := range new {
+		fp := issue.Fingerprint()
+		if olds := unmatched[fp]; len(olds) > 0 {
+			matched[olds[0]] = true
+			unmatched[fp] = olds[1:]
+			diff.Persisting = append(diff.Persisting, issue)
+			continue
+		}
+		diff.New = append(diff.New, issue)
+	}
+	for i, issue := range old {
+		if !matched[i] {
+			diff.Resolved = append(diff.Resolved, issue)
+		}
+	}
+
+	diff.New = types.SortIssues(diff.New, types.OrderSeverity)
+	diff.Resolved = types.SortIssues(diff.Resolved, types.OrderSeverity)
+	diff.Persisting = types.SortIssues(diff.Persisting, types.OrderSeverity)
+	return diff
+}
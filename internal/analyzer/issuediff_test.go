@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestDiffIssues(t *testing.T) {
+	issue := func(rule, unit string, line int) types.Issue {
+		return types.Issue{RuleID: rule, Unit: unit, Severity: types.SeverityHigh, Description: rule + " in " + unit, Line: &line}
+	}
+	old := []types.Issue{
+		issue("SEC001", "app.service", 3),
+		issue("SEC002", "app.service", 3),
+		issue("REL001", "db.service", 1),
+		issue("REL001", "db.service", 1),
+	}
+	new := []types.Issue{
+		// Moved lines still match
+		issue("SEC001", "app.service", 9),
+		issue("REL001", "db.service", 1),
+		issue("SEC003", "app.service", 4),
+	}
+
+	diff := DiffIssues(old, new)
+	ids := func(issues []types.Issue) []string {
+		var out []string
+		for _, i := range issues {
+			out = append(out, i.RuleID+" "+i.Unit)
+		}
+		return out
+	}
+	tests := []struct {
+		name string
+		got  []types.Issue
+		want []string
+	}{
+		{"new", diff.New, []string{"SEC003 app.service"}},
+		{"resolved", diff.Resolved, []string{"SEC002 app.service", "REL001 db.service"}},
+		{"persisting", diff.Persisting, []string{"SEC001 app.service", "REL001 db.service"}},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.got); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if *diff.Persisting[0].Line != 9 {
+		t.Errorf("persisting issue line = %d, want the new scan's 9", *diff.Persisting[0].Line)
+	}
+}
@@ -1,162 +1,68 @@
 package analyzer
 
 import (
-	"bufio"
-	"os"
-	"path/filepath"
+	"fmt"
 	"strings"
 
+	"github.com/supabase/sdaudit/internal/unitfile"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
 // ParseUnitFile parses a systemd unit file from the given path
 func ParseUnitFile(path string) (*types.UnitFile, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	return ParseUnitFileContent(path, string(content))
+	return unitfile.Parse(path)
 }
 
 // ParseUnitFileContent parses a systemd unit file from string content
 func ParseUnitFileContent(path, content string) (*types.UnitFile, error) {
-	name := filepath.Base(path)
-	unitType := getUnitType(name)
-
-	unit := &types.UnitFile{
-		Name:     name,
-		Path:     path,
-		Type:     unitType,
-		Sections: make(map[string]*types.Section),
-		Raw:      content,
-	}
-
-	scanner := bufio.NewScanner(strings.NewReader(content))
-	var currentSection *types.Section
-	lineNum := 0
-
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
-			continue
-		}
-
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			sectionName := line[1 : len(line)-1]
-			currentSection = &types.Section{
-				Name:       sectionName,
-				Directives: make(map[string][]types.Directive),
-			}
-			unit.Sections[sectionName] = currentSection
-			continue
-		}
-
-		if currentSection != nil {
-			if idx := strings.Index(line, "="); idx > 0 {
-				key := strings.TrimSpace(line[:idx])
-				value := strings.TrimSpace(line[idx+1:])
-
-				directive := types.Directive{
-					Key:   key,
-					Value: value,
-					Line:  lineNum,
-				}
-
-				currentSection.Directives[key] = append(currentSection.Directives[key], directive)
-			}
-		}
-	}
-
-	return unit, scanner.Err()
-}
-
-func getUnitType(name string) string {
-	ext := filepath.Ext(name)
-	if ext == "" {
-		return "unknown"
-	}
-	return ext[1:]
+	return unitfile.ParseContent(path, content)
 }
 
 // LoadUnitsFromDirectory loads all unit files from a directory
 func LoadUnitsFromDirectory(dir string) (map[string]*types.UnitFile, error) {
-	units := make(map[string]*types.UnitFile)
-
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		name := entry.Name()
-		if !isUnitFile(name) {
-			continue
-		}
-
-		path := filepath.Join(dir, name)
-		unit, err := ParseUnitFile(path)
-		if err != nil {
-			continue
-		}
-
-		units[name] = unit
-	}
-
-	return units, nil
+	return unitfile.LoadDirectory(dir)
 }
 
 // LoadUnitsFromPaths loads unit files from multiple directories
 func LoadUnitsFromPaths(paths []string) (map[string]*types.UnitFile, error) {
-	allUnits := make(map[string]*types.UnitFile)
-
-	for _, path := range paths {
-		info, err := os.Stat(path)
-		if err != nil {
-			continue
-		}
-
-		if info.IsDir() {
-			units, err := LoadUnitsFromDirectory(path)
-			if err != nil {
-				continue
-			}
-			for name, unit := range units {
-				allUnits[name] = unit
-			}
-		} else {
-			unit, err := ParseUnitFile(path)
-			if err != nil {
-				continue
-			}
-			allUnits[unit.Name] = unit
-		}
-	}
+	return unitfile.LoadPaths(paths)
+}
 
-	return allUnits, nil
+// DefaultUnitPaths returns the default systemd unit file paths
+func DefaultUnitPaths() []string {
+	return unitfile.DefaultPaths()
 }
 
-func isUnitFile(name string) bool {
-	extensions := []string{".service", ".socket", ".timer", ".mount", ".automount", ".swap", ".target", ".path", ".slice", ".scope"}
-	for _, ext := range extensions {
-		if strings.HasSuffix(name, ext) {
-			return true
-		}
-	}
-	return false
+// RuntimeUnitPaths returns the default unit file paths with the directories
+// of generated and transient units under /run/systemd
+func RuntimeUnitPaths() []string {
+	return unitfile.RuntimePaths()
 }
 
-// DefaultUnitPaths returns the default systemd unit file paths
-func DefaultUnitPaths() []string {
-	return []string{
-		"/etc/systemd/system",
-		"/run/systemd/system",
-		"/lib/systemd/system",
-		"/usr/lib/systemd/system",
+// parseIssues reports each parse error of a unit file as a PARSE issue
+func parseIssues(unit *types.UnitFile) []types.Issue {
+	issues := make([]types.Issue, 0, len(unit.ParseErrors))
+	for _, e := range unit.ParseErrors {
+		issue := types.Issue{
+			RuleID:      types.ParseRuleID,
+			RuleName:    "Unit file does not parse",
+			Severity:    types.SeverityHigh,
+			Category:    types.CategoryReliability,
+			Tags:        []string{"syntax"},
+			Unit:        unit.Name,
+			File:        unit.Path,
+			Description: fmt.Sprintf("%s%s; systemd rejects or misreads this line.", strings.ToUpper(e.Message[:1]), e.Message[1:]),
+			Suggestion:  "Fix the line; systemd-analyze verify shows how systemd reads the file.",
+			References:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.syntax.html"},
+		}
+		if e.File != "" {
+			issue.File = e.File
+		}
+		if e.Line > 0 {
+			line := e.Line
+			issue.Line = &line
+		}
+		issues = append(issues, issue)
 	}
+	return issues
 }
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/propagation"
+)
+
+// Effects of a unit failing or stopping on a unit that depends on it
+const (
+	EffectStop        = "stop"
+	EffectFailToStart = "fail_to_start"
+	EffectOrdering    = "ordering"
+	EffectNone        = "none"
+)
+
+// ReverseDependency is a unit that depends on the queried unit, directly or
+// through the unit above it in the tree
+type ReverseDependency struct {
+	Unit string `json:"unit"`
+	// EdgeTypes are the dependencies the unit has on its parent in the tree
+	EdgeTypes []string `json:"edge_types"`
+	// Effect is the strongest effect the queried unit failing or stopping
+	// has on this unit
+	Effect string `json:"effect"`
+	// Affected holds the simulated impacts on this unit
+	Affected   []propagation.AffectedUnit `json:"affected,omitempty"`
+	Dependents []*ReverseDependency       `json:"dependents,omitempty"`
+}
+
+// ReverseDependencies lists the units that depend on a unit
+type ReverseDependencies struct {
+	Unit string `json:"unit"`
+	// Depth is the depth limit of the tree, 0 if unlimited
+	Depth      int `json:"depth,omitempty"`
+	Direct     int `json:"direct"`
+	Transitive int `json:"transitive"`
+	// ByType lists the direct dependents by dependency type
+	ByType     map[string][]string  `json:"by_type"`
+	Dependents []*ReverseDependency `json:"dependents"`
+}
+
+// FindReverseDependencies walks the graph's incoming edges from a unit and
+// returns the units that depend on it as a tree, down to depth levels (all of
+// them if depth is 0). Each unit appears once, at its shallowest depth.
+// A Before= on a unit counts as an After= on the unit it names. Units that
+// are only ordered after their parent are not followed further, as nothing
+// about them depends on the unit being there.
+func FindReverseDependencies(g *graph.Graph, unitName string, depth int) (*ReverseDependencies, error) {
+	if !g.HasUnit(unitName) && len(g.EdgesTo(unitName)) == 0 {
+		return nil, fmt.Errorf("unit %s not found", unitName)
+	}
+
+	affected := make(map[string][]propagation.AffectedUnit)
+	for _, a := range propagation.SimulateFailure(g, unitName).AffectedUnits {
+		affected[a.Name] = append(affected[a.Name], a)
+	}
+
+	result := &ReverseDependencies{
+		Unit:       unitName,
+		Depth:      depth,
+		ByType:     make(map[string][]string),
+		Dependents: []*ReverseDependency{},
+	}
+
+	visited := map[string]bool{unitName: true}
+	type level struct {
+		unit     string
+		children *[]*ReverseDependency
+	}
+	current := []level{{unitName, &result.Dependents}}
+	for d := 1; len(current) > 0 && (depth == 0 || d <= depth); d++ {
+		var next []level
+		for _, parent := range current {
+			dependents := directDependents(g, parent.unit)
+			names := make([]string, 0, len(dependents))
+			for name := range dependents {
+				if !visited[name] {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				visited[name] = true
+				dep := &ReverseDependency{
+					Unit:      name,
+					EdgeTypes: dependents[name],
+					Affected:  affected[name],
+				}
+				dep.Effect = reverseEffect(dep)
+				*parent.children = append(*parent.children, dep)
+				result.Transitive++
+
+				if dep.Effect != EffectOrdering {
+					next = append(next, level{name, &dep.Dependents})
+				}
+			}
+		}
+		current = next
+	}
+
+	result.Direct = len(result.Dependents)
+	for _, dep := range result.Dependents {
+		for _, edgeType := range dep.EdgeTypes {
+			result.ByType[edgeType] = append(result.ByType[edgeType], dep.Unit)
+		}
+	}
+
+	return result, nil
+}
+
+// directDependents returns the units with a dependency on unit, mapped to the
+// sorted names of their dependency types. Conflicts= is not a dependency.
+func directDependents(g *graph.Graph, unit string) map[string][]string {
+	edgeTypes := make(map[string][]string)
+	add := func(name, edgeType string) {
+		if name != unit && !containsString(edgeTypes[name], edgeType) {
+			edgeTypes[name] = append(edgeTypes[name], edgeType)
+		}
+	}
+
+	for _, e := range g.EdgesTo(unit) {
+		if e.Type != graph.EdgeConflicts && e.Type != graph.EdgeBefore {
+			add(e.From, e.Type.String())
+		}
+	}
+	for _, e := range g.EdgesFrom(unit) {
+		if e.Type == graph.EdgeBefore {
+			add(e.To, graph.EdgeAfter.String())
+		}
+	}
+
+	for _, types := range edgeTypes {
+		sort.Strings(types)
+	}
+	return edgeTypes
+}
+
+// reverseEffect classifies what happens to a dependent when the queried unit
+// fails or stops
+func reverseEffect(dep *ReverseDependency) string {
+	effect := EffectNone
+	for _, a := range dep.Affected {
+		switch a.Impact {
+		case "stop":
+			return EffectStop
+		case "fail_to_start":
+			effect = EffectFailToStart
+		}
+	}
+	if effect != EffectNone {
+		return effect
+	}
+
+	for _, edgeType := range dep.EdgeTypes {
+		if edgeType != graph.EdgeAfter.String() {
+			return EffectNone
+		}
+	}
+	return EffectOrdering
+}
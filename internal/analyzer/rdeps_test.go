@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindReverseDependencies(t *testing.T) {
+	g := loadTestGraph(t, "reverse_deps")
+
+	result, err := FindReverseDependencies(g, "db.service", 0)
+	if err != nil {
+		t.Fatalf("FindReverseDependencies() error = %v", err)
+	}
+	if result.Direct != 5 || result.Transitive != 7 {
+		t.Errorf("Direct = %d, Transitive = %d, want 5 and 7", result.Direct, result.Transitive)
+	}
+
+	type node struct {
+		edgeTypes []string
+		effect    string
+	}
+	want := map[string]node{
+		"app.service":     {[]string{"After", "Requires"}, EffectFailToStart},
+		"backup.service":  {[]string{"Wants"}, EffectNone},
+		"monitor.service": {[]string{"After"}, EffectOrdering},
+		"report.service":  {[]string{"After"}, EffectOrdering},
+		"sidecar.service": {[]string{"PartOf"}, EffectStop},
+	}
+	for _, dep := range result.Dependents {
+		w, ok := want[dep.Unit]
+		if !ok {
+			t.Errorf("unexpected dependent %s", dep.Unit)
+			continue
+		}
+		if !reflect.DeepEqual(dep.EdgeTypes, w.edgeTypes) || dep.Effect != w.effect {
+			t.Errorf("%s: edge types %v, effect %s; want %v, %s", dep.Unit, dep.EdgeTypes, dep.Effect, w.edgeTypes, w.effect)
+		}
+	}
+
+	if got := result.ByType["After"]; !reflect.DeepEqual(got, []string{"app.service", "monitor.service", "report.service"}) {
+		t.Errorf("ByType[After] = %v", got)
+	}
+
+	app := result.Dependents[0]
+	if len(app.Dependents) != 2 || app.Dependents[1].Unit != "worker.service" {
+		t.Fatalf("app.service dependents = %+v, want metrics.service and worker.service", app.Dependents)
+	}
+	worker := app.Dependents[1]
+	if worker.Effect != EffectFailToStart {
+		t.Errorf("worker.service effect = %s, want %s", worker.Effect, EffectFailToStart)
+	}
+	if len(worker.Affected) == 0 || !reflect.DeepEqual(worker.Affected[0].PropagationPath, []string{"db.service", "app.service", "worker.service"}) {
+		t.Errorf("worker.service affected = %+v", worker.Affected)
+	}
+}
+
+func TestFindReverseDependenciesDepth(t *testing.T) {
+	g := loadTestGraph(t, "reverse_deps")
+
+	result, err := FindReverseDependencies(g, "db.service", 1)
+	if err != nil {
+		t.Fatalf("FindReverseDependencies() error = %v", err)
+	}
+	if result.Transitive != 5 {
+		t.Errorf("Transitive = %d, want 5", result.Transitive)
+	}
+	for _, dep := range result.Dependents {
+		if len(dep.Dependents) > 0 {
+			t.Errorf("%s has dependents beyond depth 1", dep.Unit)
+		}
+	}
+
+	if _, err := FindReverseDependencies(g, "missing.service", 0); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
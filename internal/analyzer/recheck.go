@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Recheck is the outcome of checking one unit file again after it changed
+type Recheck struct {
+	Unit   *types.UnitFile
+	Issues []types.Issue
+	// Rules are the IDs of the rules that ran, sorted. Issues of other rules
+	// for the unit still stand as the scan found them.
+	Rules []string
+}
+
+// RecheckFile parses the unit file at path again and runs the rules that
+// read only the unit's own directives on it, with the scan's filters. Rules
+// that look at other units, the graph, the filesystem or the live system are
+// not run. Aggregate rules run on the unit's new issues, and the file's
+// parse errors are reported whatever the filters. The unit keeps the
+// drop-ins and live state allUnits has for it. It returns ctx's error when
+// ctx is already cancelled.
+func (a *Analyzer) RecheckFile(ctx context.Context, path string, allUnits map[string]*types.UnitFile, opts Options) (*Recheck, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	unit, err := ParseUnitFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if old, ok := allUnits[unit.Name]; ok {
+		unit.Runtime = old.Runtime
+		unitfile.ApplyDropIns(unit, old.DropIns)
+	}
+
+	// The file may have been edited to fix a missing path, so nothing
+	// looked up before is reused
+	a.fs = validation.NewRealFileSystem(a.root)
+	static := func(rule rules.Rule) bool { return rules.Capabilities(rule) == rules.CapabilityStatic }
+	unitCtx := a.newContext(unit, allUnits)
+
+	// Parse errors are found again too, replacing those of the scan
+	ran := []string{types.ParseRuleID}
+	for _, rule := range rules.All() {
+		if static(rule) && unitCtx.CanRun(rule) && rules.Matches(rule, opts.Category, opts.MinSeverity, opts.Tags) {
+			ran = append(ran, rule.ID())
+		}
+	}
+	sort.Strings(ran)
+
+	var issues []types.Issue
+	if aggregating(unitCtx, opts) {
+		found := rules.RunWhere(unitCtx, nil, nil, nil, static)
+		aggregated := rules.RunAggregate(unitCtx, found, opts.Category, opts.MinSeverity, opts.Tags)
+		issues = append(filterIssues(found, opts), aggregated...)
+	} else {
+		issues = rules.RunWhere(unitCtx, opts.Category, opts.MinSeverity, opts.Tags, static)
+		issues = append(issues, rules.RunAggregate(unitCtx, issues, opts.Category, opts.MinSeverity, opts.Tags)...)
+	}
+	if opts.MinSeverity != nil {
+		kept := issues[:0]
+		for _, issue := range issues {
+			if issue.Severity >= *opts.MinSeverity {
+				kept = append(kept, issue)
+			}
+		}
+		issues = kept
+	}
+	issues = append(issues, parseIssues(unit)...)
+	return &Recheck{Unit: unit, Issues: issues, Rules: ran}, nil
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// runtimeProperties are the unit properties requested from systemctl show
+const runtimeProperties = "Id,ActiveState,SubState,Result,NRestarts,ExecMainStatus"
+
+// runtimeBatchSize bounds the number of units passed to a single systemctl call
+const runtimeBatchSize = 200
+
+// CollectRuntimeState queries systemctl for the live state of the given units
+// and attaches it to them. Templates are skipped since they are never loaded.
+// An error means the service manager could not be reached; units then keep a
+// nil Runtime.
+func CollectRuntimeState(ctx context.Context, units map[string]*types.UnitFile) error {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return fmt.Errorf("systemctl not available: %w", err)
+	}
+
+	var names []string
+	for name := range units {
+		if strings.Contains(name, "@.") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for start := 0; start < len(names); start += runtimeBatchSize {
+		end := min(start+runtimeBatchSize, len(names))
+		args := append([]string{"show", "--property=" + runtimeProperties, "--"}, names[start:end]...)
+		output, err := exec.CommandContext(ctx, "systemctl", args...).Output()
+		if err != nil {
+			return fmt.Errorf("systemctl show failed: %w", err)
+		}
+
+		states, err := parseRuntimeState(bytes.NewReader(output))
+		if err != nil {
+			return err
+		}
+		for name, state := range states {
+			if unit, ok := units[name]; ok {
+				unit.Runtime = state
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseRuntimeState parses systemctl show output, where each unit is a block
+// of Key=Value lines separated by an empty line
+func parseRuntimeState(r io.Reader) (map[string]*types.RuntimeState, error) {
+	states := make(map[string]*types.RuntimeState)
+
+	var id string
+	state := &types.RuntimeState{}
+	flush := func() {
+		if id != "" {
+			states[id] = state
+		}
+		id = ""
+		state = &types.RuntimeState{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			flush()
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "Id":
+			id = value
+		case "ActiveState":
+			state.ActiveState = value
+		case "SubState":
+			state.SubState = value
+		case "Result":
+			state.Result = value
+		case "NRestarts":
+			state.NRestarts, _ = strconv.Atoi(value)
+		case "ExecMainStatus":
+			state.ExecMainStatus, _ = strconv.Atoi(value)
+		}
+	}
+	flush()
+
+	return states, scanner.Err()
+}
@@ -0,0 +1,230 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// DirectiveEdit is a change to one directive of a unit, written as
+// Section.Key=value
+type DirectiveEdit struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	// Value is assigned after the unit's own assignments, as a drop-in
+	// would: it overrides a directive that takes one value and adds to a
+	// list. An empty value resets a list.
+	Value string `json:"value"`
+	// Remove drops every assignment of the directive, written as Key=-
+	Remove bool `json:"remove,omitempty"`
+}
+
+// ParseDirectiveEdit parses an edit written as Section.Key=value, or as
+// Section.Key=- to remove the directive
+func ParseDirectiveEdit(s string) (DirectiveEdit, error) {
+	name, value, ok := strings.Cut(s, "=")
+	section, key, dotted := strings.Cut(name, ".")
+	if !ok || !dotted || section == "" || key == "" || strings.ContainsAny(name, " \t") {
+		return DirectiveEdit{}, fmt.Errorf("invalid directive %q, want Section.Key=value, such as Service.Restart=always", s)
+	}
+	if value == "-" {
+		return DirectiveEdit{Section: section, Key: key, Remove: true}, nil
+	}
+	return DirectiveEdit{Section: section, Key: key, Value: value}, nil
+}
+
+// String returns the edit as ParseDirectiveEdit reads it
+func (e DirectiveEdit) String() string {
+	if e.Remove {
+		return e.Section + "." + e.Key + "=-"
+	}
+	return e.Section + "." + e.Key + "=" + e.Value
+}
+
+// UnitChange is a proposed change to a unit: a drop-in applied to it, then
+// directive edits
+type UnitChange struct {
+	Unit string `json:"unit"`
+	// Patch is the path of a drop-in file applied to the unit, empty for none
+	Patch string          `json:"patch,omitempty"`
+	Edits []DirectiveEdit `json:"edits"`
+}
+
+// apply returns a copy of unit with the change made to it, leaving unit as
+// it is
+func (c UnitChange) apply(unit *types.UnitFile) *types.UnitFile {
+	changed := *unit
+	changed.Sections = make(map[string]*types.Section, len(unit.Sections))
+	for name, section := range unit.Sections {
+		directives := make(map[string][]types.Directive, len(section.Directives))
+		for key, values := range section.Directives {
+			directives[key] = slices.Clone(values)
+		}
+		changed.Sections[name] = &types.Section{Name: section.Name, Directives: directives}
+	}
+	changed.DropIns = slices.Clone(unit.DropIns)
+	changed.ParseErrors = slices.Clone(unit.ParseErrors)
+
+	if c.Patch != "" {
+		unitfile.ApplyDropIns(&changed, []string{c.Patch})
+	}
+	for _, edit := range c.Edits {
+		section := changed.Sections[edit.Section]
+		if section == nil {
+			if edit.Remove {
+				continue
+			}
+			section = &types.Section{Name: edit.Section, Directives: make(map[string][]types.Directive)}
+			changed.Sections[edit.Section] = section
+		}
+		if edit.Remove {
+			delete(section.Directives, edit.Key)
+			continue
+		}
+		section.Directives[edit.Key] = append(section.Directives[edit.Key], types.Directive{Key: edit.Key, Value: edit.Value})
+	}
+	return &changed
+}
+
+// CriticalPathChange is a unit whose worst-case start chain differs after a
+// change
+type CriticalPathChange struct {
+	Unit   string              `json:"unit"`
+	Before timing.CriticalPath `json:"before"`
+	After  timing.CriticalPath `json:"after"`
+}
+
+// Delta returns how much longer the chain takes after the change, negative
+// when it got shorter
+func (c CriticalPathChange) Delta() time.Duration {
+	return c.After.TotalTime - c.Before.TotalTime
+}
+
+// Simulation is how the findings and boot timing of a set of units differ
+// once a change is made to one of them
+type Simulation struct {
+	Change UnitChange
+	// Issues holds the issues the change introduces and resolves, matched by
+	// fingerprint as compare does
+	Issues *IssueDiff
+	// CriticalPaths are the units whose critical path changed, the largest
+	// change first
+	CriticalPaths []CriticalPathChange
+	// NewCascades and ResolvedCascades are the timeout cascade risks, matched
+	// by unit and kind, that the change introduces and resolves
+	NewCascades      []timing.CascadeRisk
+	ResolvedCascades []timing.CascadeRisk
+}
+
+// timingAnalysis holds the critical paths and cascade risks of a scan
+type timingAnalysis struct {
+	paths    timing.CriticalPathResult
+	cascades timing.CascadeResult
+}
+
+// Simulate scans the units of the configured paths as Scan does, then again
+// with change made to a copy of one of them, and reports the difference.
+// The files are not modified. The issues of the changed units are not
+// cached.
+func (a *Analyzer) Simulate(ctx context.Context, change UnitChange, opts Options) (*Simulation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	allUnits, err := a.loadPaths(ctx, a.unitPaths)
+	if err != nil {
+		return nil, err
+	}
+	unit, ok := allUnits[change.Unit]
+	if !ok {
+		return nil, fmt.Errorf("unit %s not found", change.Unit)
+	}
+	if change.Patch != "" {
+		// ApplyDropIns records a file it cannot read as a parse error
+		if _, err := os.Stat(change.Patch); err != nil {
+			return nil, fmt.Errorf("failed to read patch: %w", err)
+		}
+	}
+	if err := a.prepare(ctx, allUnits); err != nil {
+		return nil, err
+	}
+
+	before, err := a.scanUnits(ctx, allUnits, opts)
+	if err != nil {
+		return nil, err
+	}
+	beforeTiming := a.timingAnalysis()
+
+	changed := make(map[string]*types.UnitFile, len(allUnits))
+	for name, u := range allUnits {
+		changed[name] = u
+	}
+	changed[change.Unit] = change.apply(unit)
+	a.cacheDir = ""
+	after, err := a.scanUnits(ctx, changed, opts)
+	if err != nil {
+		return nil, err
+	}
+	afterTiming := a.timingAnalysis()
+
+	sim := &Simulation{
+		Change:           change,
+		Issues:           DiffIssues(before.Issues, after.Issues),
+		CriticalPaths:    []CriticalPathChange{},
+		NewCascades:      []timing.CascadeRisk{},
+		ResolvedCascades: []timing.CascadeRisk{},
+	}
+	if beforeTiming == nil || afterTiming == nil {
+		return sim, nil
+	}
+
+	for name, path := range afterTiming.paths.Paths {
+		old := beforeTiming.paths.Paths[name]
+		if old.TotalTime != path.TotalTime || old.PathDescription() != path.PathDescription() {
+			sim.CriticalPaths = append(sim.CriticalPaths, CriticalPathChange{Unit: name, Before: old, After: path})
+		}
+	}
+	sort.Slice(sim.CriticalPaths, func(i, j int) bool {
+		di, dj := sim.CriticalPaths[i].Delta().Abs(), sim.CriticalPaths[j].Delta().Abs()
+		if di != dj {
+			return di > dj
+		}
+		return sim.CriticalPaths[i].Unit < sim.CriticalPaths[j].Unit
+	})
+	sim.NewCascades = cascadesMissing(afterTiming.cascades.Risks, beforeTiming.cascades.Risks)
+	sim.ResolvedCascades = cascadesMissing(beforeTiming.cascades.Risks, afterTiming.cascades.Risks)
+	return sim, nil
+}
+
+// timingAnalysis computes the critical paths and cascade risks of the last
+// scan, or returns nil when it did not analyze the dependency graph
+func (a *Analyzer) timingAnalysis() *timingAnalysis {
+	if a.graph == nil {
+		return nil
+	}
+	paths := timing.ComputeCriticalPaths(a.graph, a.timeouts)
+	return &timingAnalysis{paths: paths, cascades: timing.DetectCascades(a.graph, paths, a.timeouts)}
+}
+
+// cascadesMissing returns the risks of risks that others has no risk of the
+// same unit and kind for
+func cascadesMissing(risks, others []timing.CascadeRisk) []timing.CascadeRisk {
+	found := make(map[[2]string]bool)
+	for _, risk := range others {
+		found[[2]string{risk.Unit, risk.Kind}] = true
+	}
+	missing := []timing.CascadeRisk{}
+	for _, risk := range risks {
+		if !found[[2]string{risk.Unit, risk.Kind}] {
+			missing = append(missing, risk)
+		}
+	}
+	return missing
+}
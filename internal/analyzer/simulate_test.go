@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestParseDirectiveEdit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    DirectiveEdit
+		wantErr bool
+	}{
+		{in: "Service.Restart=always", want: DirectiveEdit{Section: "Service", Key: "Restart", Value: "always"}},
+		{in: "Service.Environment=A=1 B=2", want: DirectiveEdit{Section: "Service", Key: "Environment", Value: "A=1 B=2"}},
+		{in: "Unit.After=", want: DirectiveEdit{Section: "Unit", Key: "After"}},
+		{in: "Unit.After=-", want: DirectiveEdit{Section: "Unit", Key: "After", Remove: true}},
+		{in: "Service.EnvironmentFile=-/etc/default/app", want: DirectiveEdit{Section: "Service", Key: "EnvironmentFile", Value: "-/etc/default/app"}},
+		{in: "Restart=always", wantErr: true},
+		{in: "Service.Restart", wantErr: true},
+		{in: ".Restart=always", wantErr: true},
+		{in: "Service .Restart=always", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDirectiveEdit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDirectiveEdit(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDirectiveEdit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if err == nil && got.String() != tt.in {
+			t.Errorf("%+v.String() = %q, want %q", got, got.String(), tt.in)
+		}
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "etc/systemd/system")
+	appPath := filepath.Join(dir, "app.service")
+	app := "[Unit]\nRequires=db.service\nAfter=db.service\n\n[Service]\nExecStart=/usr/bin/app\nRestartSec=5\n"
+	writeTestFile(t, appPath, app)
+	writeTestFile(t, filepath.Join(dir, "db.service"), "[Service]\nExecStart=/usr/bin/db\nRestart=on-failure\n")
+	writeTestFile(t, filepath.Join(dir, "monitor.service"), "[Service]\nExecStart=/usr/bin/monitor\nRestart=on-failure\nTimeoutStartSec=3min\n")
+	writeTestFile(t, filepath.Join(dir, "worker.service"), "[Unit]\nRequires=app.service\nAfter=app.service\n\n[Service]\nExecStart=/usr/bin/worker\nRestart=on-failure\n")
+	patch := filepath.Join(root, "monitor.conf")
+	writeTestFile(t, patch, "[Unit]\nAfter=monitor.service\n")
+
+	change := UnitChange{
+		Unit:  "app.service",
+		Patch: patch,
+		Edits: []DirectiveEdit{
+			{Section: "Service", Key: "Restart", Value: "on-failure"},
+			{Section: "Service", Key: "RestartSec", Remove: true},
+			{Section: "Service", Key: "TimeoutStartSec", Value: "5min"},
+		},
+	}
+	opts := Options{Root: root, CacheDir: t.TempDir()}
+	sim, err := New(opts).Simulate(context.Background(), change, opts)
+	if err != nil {
+		t.Fatalf("Simulate failed: %v", err)
+	}
+
+	issues := func(list []types.Issue) []string {
+		var ids []string
+		for _, issue := range list {
+			if issue.Unit == "app.service" {
+				ids = append(ids, issue.RuleID)
+			}
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	// After=monitor.service comes without Requires= or Wants=, and Restart=
+	// without StartLimitBurst=
+	if got := issues(sim.Issues.New); !reflect.DeepEqual(got, []string{"REL005", "REL006"}) {
+		t.Errorf("new issues of app.service = %v, want REL005 and REL006", got)
+	}
+	if got := issues(sim.Issues.Resolved); !reflect.DeepEqual(got, []string{"REL001"}) {
+		t.Errorf("resolved issues of app.service = %v, want REL001 for the missing Restart=", got)
+	}
+
+	var paths []string
+	for _, c := range sim.CriticalPaths {
+		paths = append(paths, c.Unit+" "+c.Delta().String()+" "+c.After.PathDescription())
+	}
+	want := []string{
+		"app.service 5m0s monitor.service -> app.service",
+		"worker.service 5m0s monitor.service -> app.service -> worker.service",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("critical path changes = %v, want %v", paths, want)
+	}
+
+	// Nothing of the change is left in the files or the cache
+	scan := func(cacheDir string) string {
+		t.Helper()
+		opts := Options{Root: root, CacheDir: cacheDir}
+		result, err := New(opts).Scan(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		var found []string
+		for _, issue := range result.Issues {
+			found = append(found, issue.Unit+" "+issue.RuleID)
+		}
+		return strings.Join(found, "\n")
+	}
+	if cached, uncached := scan(opts.CacheDir), scan(""); cached != uncached {
+		t.Errorf("scan from the cache after Simulate =\n%s\nwant\n%s", cached, uncached)
+	}
+
+	if _, err := New(opts).Simulate(context.Background(), UnitChange{Unit: "missing.service"}, opts); err == nil {
+		t.Error("Simulate of a missing unit succeeded")
+	}
+	if _, err := New(opts).Simulate(context.Background(), UnitChange{Unit: "app.service", Patch: filepath.Join(root, "missing.conf")}, opts); err == nil {
+		t.Error("Simulate with a missing patch succeeded")
+	}
+}
@@ -3,12 +3,21 @@ package analyzer
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/supabase/sdaudit/internal/journal"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/security"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/pkg/types"
 )
 
 // BootAnalysis contains the results of boot time analysis
@@ -17,24 +26,55 @@ type BootAnalysis struct {
 	KernelTime    time.Duration
 	InitrdTime    time.Duration
 	UserspaceTime time.Duration
+	// ReachedTarget is the default target and TargetReachedTime when userspace reached it
+	ReachedTarget     string
+	TargetReachedTime time.Duration
+	// TimingSource is where unit start times came from: "journal", "blame"
+	// or "timestamps"
+	TimingSource string
+	// TimingBoots is how many boots the journal timings cover
+	TimingBoots int
+	// Parallelism is how many units were activating at a time on average,
+	// with timestamp timings
+	Parallelism   float64
 	Units         []UnitTiming
 	CriticalChain []ChainLink
-	Issues        []BootIssue
+	// PredictedPath is the critical path the unit files predict, set by
+	// CorrelateCriticalPath
+	PredictedPath *BootPath
+	// Report joins the timings, the critical chain and the findings of a
+	// scan per unit, set by Correlate
+	Report *BootReport
+	Issues []BootIssue
 }
 
-// UnitTiming represents timing data for a single unit
+// UnitTiming represents timing data for a single unit. With journal timings,
+// Time is the median over the measured boots.
 type UnitTiming struct {
 	Name     string
 	Time     time.Duration
 	Position int
+	// Current is the start time in the current boot, zero if the unit did not start
+	Current time.Duration
+	P95     time.Duration
+	Samples int
+	// WallClock is the unit's share of the boot's wall-clock time, where
+	// units activating at the same time share it equally, and Parallelism
+	// is how many units were activating at a time on average while it was.
+	// Both are set with timestamp timings only.
+	WallClock   time.Duration
+	Parallelism float64
 }
 
-// ChainLink represents a unit in the critical boot chain
+// ChainLink represents a unit in the critical boot chain. Depth is 0 for the
+// root target, and Parent is the unit one level up that waited on this one.
 type ChainLink struct {
 	Name       string
 	Time       time.Duration
 	ActiveAt   time.Duration
 	IsCritical bool
+	Depth      int
+	Parent     string
 }
 
 // BootIssue represents a detected boot issue
@@ -45,178 +85,397 @@ type BootIssue struct {
 	Suggestion  string
 }
 
+// BootOptions configures where boot analysis takes unit start times from,
+// and when it reports them
+type BootOptions struct {
+	// Journal measures start times over the last Boots boots from the journal
+	Journal bool
+	Boots   int
+	// Fallback uses systemd-analyze blame when the journal has no start times
+	Fallback bool
+	// Timestamps takes start times from the activation timestamps of the
+	// units in this boot instead of the journal or blame
+	Timestamps bool
+	// SlowUnit is the start time above which a unit is reported, 5s if zero
+	SlowUnit time.Duration
+	// SlowUserspace is the userspace boot time above which the boot is
+	// reported, 30s if zero
+	SlowUserspace time.Duration
+}
+
+// Default thresholds of boot issues
+const (
+	DefaultSlowUnit      = 5 * time.Second
+	DefaultSlowUserspace = 30 * time.Second
+)
+
 // AnalyzeBoot runs boot analysis using systemd-analyze
-func AnalyzeBoot() (*BootAnalysis, error) {
+func AnalyzeBoot(ctx context.Context) (*BootAnalysis, error) {
+	return AnalyzeBootWith(ctx, BootOptions{})
+}
+
+// AnalyzeBootWith runs boot analysis, taking unit start times from the
+// activation timestamps or the journal when configured and from
+// systemd-analyze blame otherwise
+func AnalyzeBootWith(ctx context.Context, opts BootOptions) (*BootAnalysis, error) {
 	analysis := &BootAnalysis{}
 
 	// Get overall boot time
-	if err := analysis.parseBootTime(); err != nil {
+	if err := analysis.parseBootTime(ctx); err != nil {
 		return nil, fmt.Errorf("failed to get boot time: %w", err)
 	}
 
+	measured := false
+	if opts.Timestamps {
+		if err := analysis.measureFromTimestamps(ctx); err != nil {
+			return nil, fmt.Errorf("failed to read activation timestamps: %w", err)
+		}
+		measured = true
+	} else if opts.Journal && opts.Boots > 0 {
+		err := analysis.measureFromJournal(ctx, journal.Journalctl{}, opts.Boots)
+		switch {
+		case err == nil:
+			measured = true
+		case !opts.Fallback:
+			return nil, fmt.Errorf("failed to measure start times from the journal: %w", err)
+		}
+	}
+
 	// Get blame (unit timing)
-	if err := analysis.parseBlame(); err != nil {
-		return nil, fmt.Errorf("failed to get blame: %w", err)
+	if !measured {
+		if err := analysis.parseBlame(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get blame: %w", err)
+		}
 	}
 
 	// Get critical chain
-	if err := analysis.parseCriticalChain(); err != nil {
+	if err := analysis.parseCriticalChain(ctx); err != nil {
 		return nil, fmt.Errorf("failed to get critical-chain: %w", err)
 	}
+	analysis.applyMeasuredTimes()
 
 	// Analyze for issues
-	analysis.detectIssues()
+	analysis.detectIssues(opts)
 
 	return analysis, nil
 }
 
 // parseBootTime parses the output of systemd-analyze
-func (a *BootAnalysis) parseBootTime() error {
-	cmd := exec.Command("systemd-analyze")
+func (a *BootAnalysis) parseBootTime(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "systemd-analyze")
 	output, err := cmd.Output()
 	if err != nil {
 		return err
 	}
 
-	// Parse: "Startup finished in 2.5s (kernel) + 5.2s (initrd) + 45.3s (userspace) = 53.0s"
-	// Or: "Startup finished in 2.5s (kernel) + 45.3s (userspace) = 47.8s"
-	line := string(bytes.TrimSpace(output))
+	a.parseBootTimeOutput(string(output))
+	return nil
+}
 
-	// Extract times using regex
-	kernelRe := regexp.MustCompile(`([\d.]+)s \(kernel\)`)
-	initrdRe := regexp.MustCompile(`([\d.]+)s \(initrd\)`)
-	userspaceRe := regexp.MustCompile(`([\d.]+)s \(userspace\)`)
-	totalRe := regexp.MustCompile(`= ([\d.]+)s`)
+// Boot phase patterns. Values may be compound ("1min 32.415s"), so they are
+// matched as a run of number-unit pairs and handed to parseDuration.
+var (
+	bootSpan        = `((?:[\d.]+(?:h|min|ms|us|µs|s)\s*)+)`
+	kernelTimeRe    = regexp.MustCompile(bootSpan + `\(kernel\)`)
+	initrdTimeRe    = regexp.MustCompile(bootSpan + `\(initrd\)`)
+	userspaceTimeRe = regexp.MustCompile(bootSpan + `\(userspace\)`)
+	totalTimeRe     = regexp.MustCompile(`= ` + bootSpan)
+	targetReachedRe = regexp.MustCompile(`^(\S+) reached after ` + bootSpan + `in userspace`)
+)
 
-	if matches := kernelRe.FindStringSubmatch(line); len(matches) > 1 {
-		if secs, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			a.KernelTime = time.Duration(secs * float64(time.Second))
+// parseBootTimeOutput parses systemd-analyze output such as:
+//
+//	Startup finished in 2.5s (kernel) + 5.2s (initrd) + 1min 32.415s (userspace) = 1min 40.115s
+//	graphical.target reached after 1min 10s in userspace.
+func (a *BootAnalysis) parseBootTimeOutput(output string) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if matches := targetReachedRe.FindStringSubmatch(line); len(matches) > 2 {
+			a.ReachedTarget = matches[1]
+			a.TargetReachedTime, _ = timing.ParseDuration(matches[2])
+			continue
 		}
-	}
 
-	if matches := initrdRe.FindStringSubmatch(line); len(matches) > 1 {
-		if secs, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			a.InitrdTime = time.Duration(secs * float64(time.Second))
+		if !strings.HasPrefix(line, "Startup finished") {
+			continue
 		}
-	}
 
-	if matches := userspaceRe.FindStringSubmatch(line); len(matches) > 1 {
-		if secs, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			a.UserspaceTime = time.Duration(secs * float64(time.Second))
+		if matches := kernelTimeRe.FindStringSubmatch(line); len(matches) > 1 {
+			a.KernelTime, _ = timing.ParseDuration(matches[1])
 		}
-	}
-
-	if matches := totalRe.FindStringSubmatch(line); len(matches) > 1 {
-		if secs, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			a.TotalTime = time.Duration(secs * float64(time.Second))
+		if matches := initrdTimeRe.FindStringSubmatch(line); len(matches) > 1 {
+			a.InitrdTime, _ = timing.ParseDuration(matches[1])
+		}
+		if matches := userspaceTimeRe.FindStringSubmatch(line); len(matches) > 1 {
+			a.UserspaceTime, _ = timing.ParseDuration(matches[1])
+		}
+		if matches := totalTimeRe.FindStringSubmatch(line); len(matches) > 1 {
+			a.TotalTime, _ = timing.ParseDuration(matches[1])
 		}
 	}
-
-	return nil
 }
 
 // parseBlame parses systemd-analyze blame output
-func (a *BootAnalysis) parseBlame() error {
-	cmd := exec.Command("systemd-analyze", "blame")
+func (a *BootAnalysis) parseBlame(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "systemd-analyze", "blame")
 	output, err := cmd.Output()
 	if err != nil {
 		return err
 	}
 
+	if err := a.parseBlameOutput(output); err != nil {
+		return err
+	}
+	a.TimingSource = "blame"
+	return nil
+}
+
+// parseBlameOutput parses systemd-analyze blame output, where the time may
+// take several fields:
+//
+//	1min 1.234s apt-daily.service
+//	    45.234s nginx.service
+//	      234ms systemd-journald.service
+func (a *BootAnalysis) parseBlameOutput(output []byte) error {
 	scanner := bufio.NewScanner(bytes.NewReader(output))
 	position := 0
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 {
 			continue
 		}
 
-		// Parse: "  45.234s nginx.service"
-		parts := strings.Fields(line)
-		if len(parts) < 2 {
+		unit := parts[len(parts)-1]
+		duration, err := timing.ParseDuration(strings.Join(parts[:len(parts)-1], " "))
+		if err != nil {
 			continue
 		}
 
-		duration := parseDuration(parts[0])
-		unit := parts[1]
-
 		a.Units = append(a.Units, UnitTiming{
 			Name:     unit,
 			Time:     duration,
 			Position: position,
+			Current:  duration,
+			Samples:  1,
 		})
 		position++
 	}
-
 	return scanner.Err()
 }
 
+// errNoJournalTimings is returned when the journal holds no complete start jobs
+var errNoJournalTimings = errors.New("no unit start times in the journal")
+
+// measureFromJournal sets unit timings to the median start time over the
+// last boots, measured from the journal's start job messages
+func (a *BootAnalysis) measureFromJournal(ctx context.Context, r journal.Reader, boots int) error {
+	all, err := journal.ReadBoots(ctx, r, boots)
+	if err != nil {
+		return err
+	}
+
+	samples := make(map[string][]time.Duration)
+	current := make(map[string]time.Duration)
+	for i, events := range all {
+		for unit, durations := range journal.StartDurations(events) {
+			// A unit restarted during the boot counts with its first start
+			samples[unit] = append(samples[unit], durations[0])
+			if i == 0 {
+				current[unit] = durations[0]
+			}
+		}
+	}
+	if len(samples) == 0 {
+		return errNoJournalTimings
+	}
+
+	a.Units = nil
+	for unit, durations := range samples {
+		stats := journal.Summarize(durations)
+		a.Units = append(a.Units, UnitTiming{
+			Name:    unit,
+			Time:    stats.Median,
+			Current: current[unit],
+			P95:     stats.P95,
+			Samples: stats.Samples,
+		})
+	}
+	sort.Slice(a.Units, func(i, j int) bool {
+		if a.Units[i].Time != a.Units[j].Time {
+			return a.Units[i].Time > a.Units[j].Time
+		}
+		return a.Units[i].Name < a.Units[j].Name
+	})
+	for i := range a.Units {
+		a.Units[i].Position = i
+	}
+
+	a.TimingSource = "journal"
+	a.TimingBoots = len(all)
+	return nil
+}
+
+// applyMeasuredTimes replaces the single-boot durations in the critical chain
+// with the journal medians, so one slow boot does not skew the chain
+func (a *BootAnalysis) applyMeasuredTimes() {
+	if a.TimingSource != "journal" {
+		return
+	}
+
+	medians := make(map[string]time.Duration, len(a.Units))
+	for _, u := range a.Units {
+		medians[u.Name] = u.Time
+	}
+	for i := range a.CriticalChain {
+		link := &a.CriticalChain[i]
+		if median, ok := medians[link.Name]; ok {
+			link.Time = median
+			link.IsCritical = a.isCritical(link.Time)
+		}
+	}
+}
+
 // parseCriticalChain parses systemd-analyze critical-chain output
-func (a *BootAnalysis) parseCriticalChain() error {
-	cmd := exec.Command("systemd-analyze", "critical-chain")
+func (a *BootAnalysis) parseCriticalChain(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "systemd-analyze", "critical-chain")
 	output, err := cmd.Output()
 	if err != nil {
 		return err
 	}
 
-	scanner := bufio.NewScanner(bytes.NewReader(output))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
+	a.parseCriticalChainOutput(string(output))
+	return nil
+}
+
+// criticalChainShare is the share of userspace boot time a unit in the
+// critical chain must take to be marked critical
+const criticalChainShare = 0.10
 
-		// Parse lines like:
-		// "@45.234s" or "unit.service @12.345s +5.678s"
-		// The @time is when it activated, +time is how long it took
+var (
+	chainActiveRe = regexp.MustCompile(`@` + bootSpan)
+	chainTimeRe   = regexp.MustCompile(`\+` + bootSpan)
+)
 
-		// Skip header lines
-		if strings.HasPrefix(line, "The time") || strings.HasPrefix(line, "graphical.target") {
+// chainBranches are the tree markers in front of a unit, in UTF-8 and in the
+// ASCII fallback systemd uses for other locales
+var chainBranches = []string{"└─", "├─", "`-", "|-"}
+
+// parseCriticalChainOutput parses the tree printed by critical-chain, e.g.
+//
+//	graphical.target @1min 4.503s
+//	└─multi-user.target @1min 4.503s
+//	  └─nginx.service @45.123s +17.220s
+//
+// Each level is indented by two columns, so a unit's depth is the column its
+// name starts at divided by two, and its parent is the nearest unit above it
+// one level up.
+func (a *BootAnalysis) parseCriticalChainOutput(output string) {
+	var parents []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "The time") {
 			continue
 		}
 
-		link := ChainLink{}
+		runes := []rune(line)
+		start := chainNameStart(runes)
+		fields := strings.Fields(string(runes[start:]))
+		if len(fields) == 0 {
+			continue
+		}
 
-		// Extract unit name (before @)
-		atIdx := strings.Index(line, "@")
-		if atIdx > 0 {
-			// Get unit name from the beginning (strip tree characters)
-			unitPart := strings.TrimLeft(line[:atIdx], "│├└─ \t")
-			link.Name = strings.TrimSpace(unitPart)
+		link := ChainLink{
+			Name:  fields[0],
+			Depth: start / 2,
 		}
 
-		// Extract activation time (@X.XXXs)
-		atRe := regexp.MustCompile(`@([\d.]+)(ms|s)`)
-		if matches := atRe.FindStringSubmatch(line); len(matches) > 2 {
-			link.ActiveAt = parseDuration(matches[1] + matches[2])
+		// The unit name may contain '@', so only look for times after it
+		rest := strings.Join(fields[1:], " ")
+		if matches := chainActiveRe.FindStringSubmatch(rest); len(matches) > 1 {
+			link.ActiveAt, _ = timing.ParseDuration(matches[1])
+		}
+		if matches := chainTimeRe.FindStringSubmatch(rest); len(matches) > 1 {
+			link.Time, _ = timing.ParseDuration(matches[1])
+			link.IsCritical = a.isCritical(link.Time)
 		}
 
-		// Extract duration (+X.XXXs)
-		plusRe := regexp.MustCompile(`\+([\d.]+)(ms|s)`)
-		if matches := plusRe.FindStringSubmatch(line); len(matches) > 2 {
-			link.Time = parseDuration(matches[1] + matches[2])
-			// Mark as critical if it took significant time
-			link.IsCritical = link.Time > 5*time.Second
+		if link.Depth > len(parents) {
+			link.Depth = len(parents)
 		}
+		parents = parents[:link.Depth]
+		if link.Depth > 0 {
+			link.Parent = parents[link.Depth-1]
+		}
+		parents = append(parents, link.Name)
 
-		if link.Name != "" {
-			a.CriticalChain = append(a.CriticalChain, link)
+		a.CriticalChain = append(a.CriticalChain, link)
+	}
+}
+
+// chainNameStart returns the index of the first rune of the unit name after
+// the tree prefix of a critical-chain line
+func chainNameStart(runes []rune) int {
+	i := 0
+	for i < len(runes) {
+		rest := string(runes[i:min(i+2, len(runes))])
+		for _, branch := range chainBranches {
+			if rest == branch {
+				return i + 2
+			}
+		}
+		switch runes[i] {
+		case ' ', '\t', '│', '|':
+			i++
+		default:
+			return i
 		}
 	}
+	return i
+}
 
-	return scanner.Err()
+// isCritical reports whether a unit in the critical chain takes a significant
+// share of userspace boot time. Without a userspace time, 5s is used.
+func (a *BootAnalysis) isCritical(d time.Duration) bool {
+	if a.UserspaceTime <= 0 {
+		return d > 5*time.Second
+	}
+	return float64(d) >= criticalChainShare*float64(a.UserspaceTime)
+}
+
+// startCost returns how long a unit held up boot: its share of wall-clock
+// time with timestamp timings, where units starting in parallel share it, and
+// its start time otherwise
+func (a *BootAnalysis) startCost(unit UnitTiming) time.Duration {
+	if a.TimingSource == "timestamps" {
+		return unit.WallClock
+	}
+	return unit.Time
 }
 
 // detectIssues analyzes the boot data for issues
-func (a *BootAnalysis) detectIssues() {
-	// Check for slow units (>5s)
+func (a *BootAnalysis) detectIssues(opts BootOptions) {
+	slowUnit, slowUserspace := opts.SlowUnit, opts.SlowUserspace
+	if slowUnit <= 0 {
+		slowUnit = DefaultSlowUnit
+	}
+	if slowUserspace <= 0 {
+		slowUserspace = DefaultSlowUserspace
+	}
+
+	// Check for slow units
 	for _, unit := range a.Units {
-		if unit.Time > 5*time.Second {
+		if a.startCost(unit) > slowUnit {
+			description := fmt.Sprintf("Takes %.1fs to start", unit.Time.Seconds())
+			if a.TimingSource == "timestamps" {
+				description = fmt.Sprintf("Takes %.1fs to start, %.1fs of boot wall-clock time with %.1f units starting at a time",
+					unit.Time.Seconds(), unit.WallClock.Seconds(), unit.Parallelism)
+			}
 			a.Issues = append(a.Issues, BootIssue{
 				Unit:        unit.Name,
-				Description: fmt.Sprintf("Takes %.1fs to start", unit.Time.Seconds()),
+				Description: description,
 				Severity:    "medium",
-				Suggestion:  "Consider optimizing startup or using socket activation",
+				Suggestion:  suggestSlowUnit,
 			})
 		}
 	}
@@ -228,13 +487,13 @@ func (a *BootAnalysis) detectIssues() {
 				Unit:        link.Name,
 				Description: fmt.Sprintf("In critical chain, takes %.1fs", link.Time.Seconds()),
 				Severity:    "high",
-				Suggestion:  "This unit blocks boot progress - optimize or defer",
+				Suggestion:  suggestCriticalChain,
 			})
 		}
 	}
 
 	// Check for overall slow boot
-	if a.UserspaceTime > 30*time.Second {
+	if a.UserspaceTime > slowUserspace {
 		a.Issues = append(a.Issues, BootIssue{
 			Unit:        "system",
 			Description: fmt.Sprintf("Userspace boot takes %.1fs", a.UserspaceTime.Seconds()),
@@ -244,154 +503,15 @@ func (a *BootAnalysis) detectIssues() {
 	}
 }
 
-// parseDuration parses systemd time format (e.g., "45.234s", "123ms", "1min 2.345s")
-func parseDuration(s string) time.Duration {
-	s = strings.TrimSpace(s)
-
-	// Handle compound formats like "1min 2.345s"
-	if strings.Contains(s, "min") {
-		var total time.Duration
-		parts := strings.Fields(s)
-		for _, part := range parts {
-			total += parseSingleDuration(part)
-		}
-		return total
-	}
-
-	return parseSingleDuration(s)
-}
-
-func parseSingleDuration(s string) time.Duration {
-	s = strings.TrimSpace(s)
-
-	if strings.HasSuffix(s, "ms") {
-		if val, err := strconv.ParseFloat(strings.TrimSuffix(s, "ms"), 64); err == nil {
-			return time.Duration(val * float64(time.Millisecond))
-		}
-	}
-
-	if strings.HasSuffix(s, "s") {
-		if val, err := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64); err == nil {
-			return time.Duration(val * float64(time.Second))
-		}
-	}
-
-	if strings.HasSuffix(s, "min") {
-		if val, err := strconv.ParseFloat(strings.TrimSuffix(s, "min"), 64); err == nil {
-			return time.Duration(val * float64(time.Minute))
-		}
-	}
-
-	return 0
-}
-
-// DependencyGraph represents the systemd dependency graph
-type DependencyGraph struct {
-	Units map[string]*DependencyNode
-	Edges []DependencyEdge
-}
-
-// DependencyNode represents a unit in the dependency graph
-type DependencyNode struct {
-	Name     string
-	Type     string // "service", "socket", "target", etc.
-	State    string
-	Requires []string
-	Wants    []string
-	After    []string
-	Before   []string
-}
-
-// DependencyEdge represents a dependency relationship
-type DependencyEdge struct {
-	From string
-	To   string
-	Type string // "requires", "wants", "after", "before"
-}
-
-// DependencyIssue represents a detected dependency issue
-type DependencyIssue struct {
-	Units       []string
-	Description string
-	Severity    string
-	Suggestion  string
-}
-
-// AnalyzeDeps analyzes systemd dependencies
-func AnalyzeDeps(unitName string) (*DependencyGraph, []DependencyIssue, error) {
-	graph := &DependencyGraph{
-		Units: make(map[string]*DependencyNode),
-	}
-	var issues []DependencyIssue
-
-	// Get dependency tree
-	args := []string{"list-dependencies"}
-	if unitName != "" {
-		args = append(args, unitName)
-	}
-	args = append(args, "--all")
-
-	cmd := exec.Command("systemctl", args...)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to list dependencies: %w", err)
-	}
-
-	// Parse the tree output
-	scanner := bufio.NewScanner(bytes.NewReader(output))
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Extract unit name from tree format
-		unitName := strings.TrimLeft(line, "│├└─● \t")
-		unitName = strings.TrimSpace(unitName)
-		if unitName == "" {
-			continue
-		}
-
-		// Remove state indicators like (running), (dead), etc.
-		if idx := strings.Index(unitName, " "); idx > 0 {
-			unitName = unitName[:idx]
-		}
-
-		if _, exists := graph.Units[unitName]; !exists {
-			graph.Units[unitName] = &DependencyNode{Name: unitName}
-		}
-	}
-
-	// Check for cycles using systemd-analyze verify
-	cycleIssues := detectCycles()
-	issues = append(issues, cycleIssues...)
-
-	return graph, issues, nil
-}
-
-// detectCycles checks for circular dependencies
-func detectCycles() []DependencyIssue {
-	var issues []DependencyIssue
-
-	cmd := exec.Command("systemd-analyze", "verify", "--man=no", "default.target")
-	output, _ := cmd.CombinedOutput() // Ignore error, we check output for cycles
-
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "cycle") || strings.Contains(line, "circular") {
-			issues = append(issues, DependencyIssue{
-				Description: strings.TrimSpace(line),
-				Severity:    "critical",
-				Suggestion:  "Review and break the dependency cycle",
-			})
-		}
-	}
-
-	return issues
-}
-
 // SecurityScore represents a unit's security score
 type SecurityScore struct {
 	Unit     string
 	Score    float64
 	Exposure string // "SAFE", "OK", "MEDIUM", "EXPOSED", "UNSAFE"
 	Checks   []SecurityCheck
+	// Estimated is set when the score was computed from the unit file
+	// rather than by systemd-analyze
+	Estimated bool
 }
 
 // SecurityCheck represents an individual security check result
@@ -402,8 +522,10 @@ type SecurityCheck struct {
 	Weight      float64
 }
 
-// AnalyzeSecurity runs security analysis on units
-func AnalyzeSecurity(unitName string) ([]SecurityScore, error) {
+// AnalyzeSecurity runs security analysis on units. When systemd-analyze
+// security is unavailable or fails, scores are estimated from the unit files
+// in the default unit paths instead.
+func AnalyzeSecurity(ctx context.Context, unitName string) ([]SecurityScore, error) {
 	var scores []SecurityScore
 
 	args := []string{"security"}
@@ -412,10 +534,17 @@ func AnalyzeSecurity(unitName string) ([]SecurityScore, error) {
 	}
 	args = append(args, "--no-pager")
 
-	cmd := exec.Command("systemd-analyze", args...)
+	cmd := exec.CommandContext(ctx, "systemd-analyze", args...)
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to run security analysis: %w", err)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		units, loadErr := LoadUnitsFromPaths(DefaultUnitPaths())
+		if loadErr != nil {
+			return nil, fmt.Errorf("failed to run security analysis: %w", err)
+		}
+		return EstimateSecurity(units, unitName)
 	}
 
 	// Parse the security output
@@ -455,3 +584,72 @@ func AnalyzeSecurity(unitName string) ([]SecurityScore, error) {
 
 	return scores, scanner.Err()
 }
+
+// EstimateSecurity scores service units from their unit files, without
+// systemd-analyze. If unitName is set only that unit is scored. Templates are
+// skipped since their settings depend on the instance. Scores are sorted by
+// unit name.
+func EstimateSecurity(units map[string]*types.UnitFile, unitName string) ([]SecurityScore, error) {
+	if unitName != "" {
+		unit, ok := units[unitName]
+		if !ok {
+			return nil, fmt.Errorf("unit %s not found", unitName)
+		}
+		if !unit.IsService() {
+			return nil, fmt.Errorf("%s is not a service unit", unitName)
+		}
+		return []SecurityScore{estimateScore(unit)}, nil
+	}
+
+	var scores []SecurityScore
+	for name, unit := range units {
+		if !unit.IsService() || strings.HasSuffix(name, "@.service") {
+			continue
+		}
+		scores = append(scores, estimateScore(unit))
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].Unit < scores[j].Unit })
+	return scores, nil
+}
+
+func estimateScore(unit *types.UnitFile) SecurityScore {
+	assessed := security.Assess(unit)
+	score := SecurityScore{
+		Unit:      assessed.Unit,
+		Score:     assessed.Exposure,
+		Exposure:  assessed.Level,
+		Estimated: true,
+	}
+	for _, c := range assessed.Checks {
+		score.Checks = append(score.Checks, SecurityCheck{
+			Name:        c.Name,
+			Description: c.Description,
+			Result:      checkResult(c),
+			Weight:      c.Weight,
+		})
+	}
+	return score
+}
+
+// checkResult grades a check by how much of its weight it contributes
+func checkResult(c security.Check) string {
+	ratio := float64(c.Badness) / float64(c.Range)
+	switch {
+	case ratio == 0:
+		return "OK"
+	case ratio < 0.5:
+		return "MEDIUM"
+	case ratio < 1:
+		return "EXPOSED"
+	}
+	return "UNSAFE"
+}
+
+// DetectSystemdVersion returns the major version of the running systemd, or 0 if it cannot be determined
+func DetectSystemdVersion() int {
+	output, err := exec.Command("systemctl", "--version").Output()
+	if err != nil {
+		return 0
+	}
+	return rules.ParseSystemdVersion(string(output))
+}
@@ -0,0 +1,330 @@
+package analyzer
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/journal"
+)
+
+func TestParseBootTimeOutput(t *testing.T) {
+	tests := []struct {
+		name          string
+		output        string
+		wantKernel    time.Duration
+		wantInitrd    time.Duration
+		wantUserspace time.Duration
+		wantTotal     time.Duration
+		wantTarget    string
+		wantReached   time.Duration
+	}{
+		{
+			name:          "seconds, single line",
+			output:        "Startup finished in 2.5s (kernel) + 45.3s (userspace) = 47.8s\n",
+			wantKernel:    2500 * time.Millisecond,
+			wantUserspace: 45300 * time.Millisecond,
+			wantTotal:     47800 * time.Millisecond,
+		},
+		{
+			name:          "seconds, two lines with initrd",
+			output:        "Startup finished in 1.204s (kernel) + 3.310s (initrd) + 8.020s (userspace) = 12.534s\ngraphical.target reached after 7.998s in userspace.\n",
+			wantKernel:    1204 * time.Millisecond,
+			wantInitrd:    3310 * time.Millisecond,
+			wantUserspace: 8020 * time.Millisecond,
+			wantTotal:     12534 * time.Millisecond,
+			wantTarget:    "graphical.target",
+			wantReached:   7998 * time.Millisecond,
+		},
+		{
+			name:          "minutes, single line",
+			output:        "Startup finished in 2.5s (kernel) + 1min 32.415s (userspace) = 1min 34.915s\n",
+			wantKernel:    2500 * time.Millisecond,
+			wantUserspace: time.Minute + 32415*time.Millisecond,
+			wantTotal:     time.Minute + 34915*time.Millisecond,
+		},
+		{
+			name:          "minutes, two lines",
+			output:        "Startup finished in 4.1s (kernel) + 820ms (initrd) + 1min 10.5s (userspace) = 1min 15.420s\nmulti-user.target reached after 1min 10s in userspace.\n",
+			wantKernel:    4100 * time.Millisecond,
+			wantInitrd:    820 * time.Millisecond,
+			wantUserspace: time.Minute + 10500*time.Millisecond,
+			wantTotal:     time.Minute + 15420*time.Millisecond,
+			wantTarget:    "multi-user.target",
+			wantReached:   time.Minute + 10*time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &BootAnalysis{}
+			a.parseBootTimeOutput(tt.output)
+
+			if a.KernelTime != tt.wantKernel {
+				t.Errorf("KernelTime = %v, want %v", a.KernelTime, tt.wantKernel)
+			}
+			if a.InitrdTime != tt.wantInitrd {
+				t.Errorf("InitrdTime = %v, want %v", a.InitrdTime, tt.wantInitrd)
+			}
+			if a.UserspaceTime != tt.wantUserspace {
+				t.Errorf("UserspaceTime = %v, want %v", a.UserspaceTime, tt.wantUserspace)
+			}
+			if a.TotalTime != tt.wantTotal {
+				t.Errorf("TotalTime = %v, want %v", a.TotalTime, tt.wantTotal)
+			}
+			if a.ReachedTarget != tt.wantTarget || a.TargetReachedTime != tt.wantReached {
+				t.Errorf("target = %q after %v, want %q after %v", a.ReachedTarget, a.TargetReachedTime, tt.wantTarget, tt.wantReached)
+			}
+		})
+	}
+}
+
+func TestParseBlameOutput(t *testing.T) {
+	output := `1h 2min 3.456s long-migration.service
+1min 1.234s apt-daily-upgrade.service
+    45.234s nginx.service
+       2.5s docker.service
+      234ms systemd-journald.service
+      812us sys-kernel-tracing.mount
+      640µs dev-mapper-crypt\x2droot.device
+`
+	a := &BootAnalysis{}
+	if err := a.parseBlameOutput([]byte(output)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []UnitTiming{
+		{Name: "long-migration.service", Time: time.Hour + 2*time.Minute + 3456*time.Millisecond},
+		{Name: "apt-daily-upgrade.service", Time: time.Minute + 1234*time.Millisecond},
+		{Name: "nginx.service", Time: 45234 * time.Millisecond},
+		{Name: "docker.service", Time: 2500 * time.Millisecond},
+		{Name: "systemd-journald.service", Time: 234 * time.Millisecond},
+		{Name: "sys-kernel-tracing.mount", Time: 812 * time.Microsecond},
+		{Name: `dev-mapper-crypt\x2droot.device`, Time: 640 * time.Microsecond},
+	}
+	if len(a.Units) != len(want) {
+		t.Fatalf("parsed %d units, want %d: %+v", len(a.Units), len(want), a.Units)
+	}
+	for i, w := range want {
+		got := a.Units[i]
+		if got.Name != w.Name || got.Time != w.Time || got.Current != w.Time || got.Position != i {
+			t.Errorf("unit %d = %s %v at %d, want %s %v at %d", i, got.Name, got.Time, got.Position, w.Name, w.Time, i)
+		}
+	}
+}
+
+// fixtureJournal serves exported journal JSON from dir, one file per boot
+// named boot<N>.json, and fails for boots without a file
+type fixtureJournal string
+
+func (dir fixtureJournal) Events(_ context.Context, boot string) ([]journal.Event, error) {
+	f, err := os.Open(filepath.Join(string(dir), "boot"+boot+".json"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return journal.ParseJSON(f)
+}
+
+func TestMeasureFromJournal(t *testing.T) {
+	a := &BootAnalysis{
+		CriticalChain: []ChainLink{
+			{Name: "postgresql.service", Time: 12 * time.Second, IsCritical: true},
+			{Name: "unknown.service", Time: 7 * time.Second, IsCritical: true},
+		},
+	}
+	if err := a.measureFromJournal(context.Background(), fixtureJournal("../../testdata/journal/boots"), 5); err != nil {
+		t.Fatalf("measureFromJournal failed: %v", err)
+	}
+	a.applyMeasuredTimes()
+
+	if a.TimingSource != "journal" || a.TimingBoots != 3 {
+		t.Errorf("timing source = %s over %d boots, want journal over 3", a.TimingSource, a.TimingBoots)
+	}
+
+	want := []UnitTiming{
+		{Name: "slow.service", Time: 5 * time.Second, Position: 0, P95: 5 * time.Second, Samples: 1},
+		{Name: "postgresql.service", Time: 4100 * time.Millisecond, Position: 1, Current: 3500 * time.Millisecond, P95: 12 * time.Second, Samples: 3},
+		{Name: "nginx.service", Time: time.Second, Position: 2, Current: 1200 * time.Millisecond, P95: 1200 * time.Millisecond, Samples: 3},
+		{Name: "app.service", Time: 400 * time.Millisecond, Position: 3, Current: 400 * time.Millisecond, P95: 400 * time.Millisecond, Samples: 1},
+	}
+	if len(a.Units) != len(want) {
+		t.Fatalf("got %d units, want %d: %+v", len(a.Units), len(want), a.Units)
+	}
+	for i := range want {
+		if a.Units[i] != want[i] {
+			t.Errorf("Units[%d] = %+v, want %+v", i, a.Units[i], want[i])
+		}
+	}
+
+	if link := a.CriticalChain[0]; link.Time != 4100*time.Millisecond || link.IsCritical {
+		t.Errorf("critical chain should use the median, got %+v", link)
+	}
+	if link := a.CriticalChain[1]; link.Time != 7*time.Second {
+		t.Errorf("units without journal timings should keep their chain time, got %+v", link)
+	}
+
+	snap := a.Snapshot("current", time.Now())
+	if _, ok := snap.Units["slow.service"]; ok {
+		t.Error("snapshot should leave out units that did not start in the current boot")
+	}
+	if snap.Units["postgresql.service"] != 3500*time.Millisecond {
+		t.Errorf("snapshot should record the current boot, got %v", snap.Units["postgresql.service"])
+	}
+}
+
+func TestMeasureFromJournalUnavailable(t *testing.T) {
+	a := &BootAnalysis{}
+	if err := a.measureFromJournal(context.Background(), fixtureJournal(t.TempDir()), 5); err == nil {
+		t.Error("expected error when the journal has no current boot")
+	}
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "boot0.json"), "")
+	if err := a.measureFromJournal(context.Background(), fixtureJournal(dir), 5); err != errNoJournalTimings {
+		t.Errorf("measureFromJournal() = %v, want errNoJournalTimings", err)
+	}
+	if a.TimingSource != "" {
+		t.Errorf("TimingSource = %q, want unset so blame is used", a.TimingSource)
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestParseCriticalChainGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		userspace time.Duration
+	}{
+		{"ubuntu-22.04", time.Minute + 2*time.Second},
+		{"fedora-39", 6200 * time.Millisecond},
+		{"debian-12-ascii", 11 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := filepath.Join("../../testdata/boot/critical-chain", tt.name)
+			input, err := os.ReadFile(base + ".txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a := &BootAnalysis{UserspaceTime: tt.userspace}
+			a.parseCriticalChainOutput(string(input))
+
+			var got strings.Builder
+			for _, link := range a.CriticalChain {
+				fmt.Fprintf(&got, "%d %s parent=%q @%s +%s critical=%t\n", link.Depth, link.Name, link.Parent, link.ActiveAt, link.Time, link.IsCritical)
+			}
+
+			if *update {
+				if err := os.WriteFile(base+".golden", []byte(got.String()), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(base + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != string(want) {
+				t.Errorf("critical chain mismatch\ngot:\n%s\nwant:\n%s", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestChainNameStart(t *testing.T) {
+	tests := []struct {
+		line string
+		want int
+	}{
+		{"graphical.target @8.201s", 0},
+		{"└─multi-user.target @8.201s", 2},
+		{"    │ └─NetworkManager.service @6.530s +557ms", 8},
+		{"  |-network.target @7.088s", 4},
+		{"    `--.mount @8.010s", 6},
+	}
+
+	for _, tt := range tests {
+		if got := chainNameStart([]rune(tt.line)); got != tt.want {
+			t.Errorf("chainNameStart(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestDetectBootIssuesThresholds(t *testing.T) {
+	analysis := func() *BootAnalysis {
+		return &BootAnalysis{
+			UserspaceTime: 40 * time.Second,
+			Units:         []UnitTiming{{Name: "db.service", Time: 8 * time.Second}, {Name: "app.service", Time: 3 * time.Second}},
+		}
+	}
+	units := func(a *BootAnalysis) []string {
+		var names []string
+		for _, issue := range a.Issues {
+			names = append(names, issue.Unit)
+		}
+		return names
+	}
+
+	a := analysis()
+	a.detectIssues(BootOptions{})
+	if got := strings.Join(units(a), " "); got != "db.service system" {
+		t.Errorf("default thresholds reported %q, want db.service and the boot", got)
+	}
+
+	a = analysis()
+	a.detectIssues(BootOptions{SlowUnit: 10 * time.Second, SlowUserspace: time.Minute})
+	if got := units(a); len(got) != 0 {
+		t.Errorf("raised thresholds reported %v, want nothing", got)
+	}
+}
+
+func TestEstimateSecurity(t *testing.T) {
+	units, err := LoadUnitsFromDirectory("../../testdata/units")
+	if err != nil {
+		t.Fatalf("failed to load units: %v", err)
+	}
+	template, err := ParseUnitFileContent("/etc/systemd/system/worker@.service", "[Service]\nExecStart=/usr/bin/worker %i\n")
+	if err != nil {
+		t.Fatalf("failed to parse template: %v", err)
+	}
+	units[template.Name] = template
+
+	scores, err := EstimateSecurity(units, "")
+	if err != nil {
+		t.Fatalf("EstimateSecurity() error = %v", err)
+	}
+	if len(scores) != 2 {
+		t.Fatalf("EstimateSecurity() returned %d scores, want 2 (templates skipped)", len(scores))
+	}
+	if scores[0].Unit != "secure.service" || scores[1].Unit != "test.service" {
+		t.Errorf("EstimateSecurity() units = %s, %s; want sorted by name", scores[0].Unit, scores[1].Unit)
+	}
+	if scores[0].Score >= scores[1].Score {
+		t.Errorf("hardened unit scored %.1f, not below unhardened %.1f", scores[0].Score, scores[1].Score)
+	}
+	for _, score := range scores {
+		if !score.Estimated {
+			t.Errorf("%s not marked as estimated", score.Unit)
+		}
+		if len(score.Checks) == 0 {
+			t.Errorf("%s has no checks", score.Unit)
+		}
+	}
+
+	single, err := EstimateSecurity(units, "test.service")
+	if err != nil || len(single) != 1 || single[0].Unit != "test.service" {
+		t.Errorf("EstimateSecurity(test.service) = %v, %v", single, err)
+	}
+
+	if _, err := EstimateSecurity(units, "missing.service"); err == nil {
+		t.Error("EstimateSecurity(missing.service) succeeded, want error")
+	}
+}
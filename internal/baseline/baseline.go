@@ -0,0 +1,161 @@
+// Package baseline reads and writes the baseline file, which lists the
+// issues a team has acknowledged. Issues in the baseline are left out of
+// reports, so only new findings fail a check.
+//
+// Issues are matched by fingerprint, see types.Issue.Fingerprint. Each entry
+// keeps the rule and unit as well, so the file can be reviewed by hand.
+package baseline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// fileVersion changes when the layout of the baseline file does
+const fileVersion = 1
+
+// Baseline is the set of acknowledged issues
+type Baseline struct {
+	entries map[string]Entry
+}
+
+// Entry is an acknowledged issue
+type Entry struct {
+	Fingerprint string `json:"fingerprint"`
+	RuleID      string `json:"rule"`
+	Unit        string `json:"unit"`
+	// Reason says why the issue is acceptable, "" if no reason was given
+	Reason string `json:"reason,omitempty"`
+}
+
+type file struct {
+	Version int     `json:"version"`
+	Issues  []Entry `json:"issues"`
+}
+
+// New returns an empty baseline
+func New() *Baseline {
+	return &Baseline{entries: make(map[string]Entry)}
+}
+
+// Load reads a baseline file. A file that does not exist is an empty
+// baseline, so the first acknowledgement creates it.
+func Load(path string) (*Baseline, error) {
+	b := New()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("baseline %s has version %d, want %d", path, f.Version, fileVersion)
+	}
+	for _, e := range f.Issues {
+		b.entries[e.Fingerprint] = e
+	}
+	return b, nil
+}
+
+// Save writes the baseline to path, replacing the file in one step so that
+// an interrupted write leaves the old baseline in place
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(file{Version: fileVersion, Issues: b.Entries()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".baseline-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Entries returns the acknowledged issues sorted by unit, rule and
+// fingerprint, the order they are saved in
+func (b *Baseline) Entries() []Entry {
+	entries := make([]Entry, 0, len(b.entries))
+	for _, e := range b.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Unit != entries[j].Unit {
+			return entries[i].Unit < entries[j].Unit
+		}
+		if entries[i].RuleID != entries[j].RuleID {
+			return entries[i].RuleID < entries[j].RuleID
+		}
+		return entries[i].Fingerprint < entries[j].Fingerprint
+	})
+	return entries
+}
+
+// Len returns the number of acknowledged issues
+func (b *Baseline) Len() int {
+	return len(b.entries)
+}
+
+// Lookup returns the entry of an issue, false when it is not acknowledged
+func (b *Baseline) Lookup(issue types.Issue) (Entry, bool) {
+	e, ok := b.entries[issue.Fingerprint()]
+	return e, ok
+}
+
+// Contains reports whether an issue is acknowledged
+func (b *Baseline) Contains(issue types.Issue) bool {
+	_, ok := b.Lookup(issue)
+	return ok
+}
+
+// Add acknowledges an issue, replacing the reason if it already was
+func (b *Baseline) Add(issue types.Issue, reason string) {
+	fp := issue.Fingerprint()
+	b.entries[fp] = Entry{Fingerprint: fp, RuleID: issue.RuleID, Unit: issue.Unit, Reason: reason}
+}
+
+// Remove takes back the acknowledgement of an issue
+func (b *Baseline) Remove(issue types.Issue) {
+	delete(b.entries, issue.Fingerprint())
+}
+
+// Apply removes the acknowledged issues from a scan result and from its
+// summary counts, and returns how many it removed
+func (b *Baseline) Apply(result *types.ScanResult) int {
+	kept := result.Issues[:0]
+	removed := 0
+	for _, issue := range result.Issues {
+		if !b.Contains(issue) {
+			kept = append(kept, issue)
+			continue
+		}
+		removed++
+	}
+	result.Issues = kept
+	result.Recount()
+	return removed
+}
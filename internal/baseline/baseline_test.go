@@ -0,0 +1,94 @@
+package baseline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func issue(rule, unit string, sev types.Severity) types.Issue {
+	return types.Issue{RuleID: rule, Unit: unit, Severity: sev, Category: types.CategorySecurity, Description: rule + " on " + unit}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+
+	b, err := Load(path)
+	if err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+	if b.Len() != 0 {
+		t.Fatalf("missing file loaded %d entries", b.Len())
+	}
+
+	b.Add(issue("SEC001", "b.service", types.SeverityHigh), "runs in a container")
+	b.Add(issue("SEC001", "a.service", types.SeverityHigh), "")
+	if err := b.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := loaded.Entries()
+	if len(entries) != 2 || entries[0].Unit != "a.service" || entries[1].Reason != "runs in a container" {
+		t.Errorf("loaded %+v", entries)
+	}
+	if !loaded.Contains(issue("SEC001", "b.service", types.SeverityHigh)) {
+		t.Error("saved issue is not acknowledged after loading")
+	}
+
+	loaded.Remove(issue("SEC001", "b.service", types.SeverityHigh))
+	if loaded.Contains(issue("SEC001", "b.service", types.SeverityHigh)) {
+		t.Error("removed issue is still acknowledged")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"garbage.json": "not json",
+		"version.json": `{"version": 99, "issues": []}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("%s: err = %v, want an error naming the file", name, err)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	acked := issue("SEC001", "a.service", types.SeverityHigh)
+	kept := issue("SEC002", "a.service", types.SeverityLow)
+	result := &types.ScanResult{
+		Issues: []types.Issue{acked, kept},
+		Summary: types.Summary{
+			TotalIssues: 2,
+			BySeverity:  map[types.Severity]int{types.SeverityHigh: 1, types.SeverityLow: 1},
+			ByCategory:  map[types.Category]int{types.CategorySecurity: 2},
+		},
+	}
+
+	b := New()
+	b.Add(acked, "")
+	if removed := b.Apply(result); removed != 1 {
+		t.Errorf("removed %d issues, want 1", removed)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].RuleID != "SEC002" {
+		t.Errorf("issues = %+v", result.Issues)
+	}
+	s := result.Summary
+	if s.TotalIssues != 1 || s.ByCategory[types.CategorySecurity] != 1 || s.BySeverity[types.SeverityLow] != 1 {
+		t.Errorf("summary = %+v", s)
+	}
+	if _, ok := s.BySeverity[types.SeverityHigh]; ok {
+		t.Errorf("summary keeps a zero count for high: %+v", s.BySeverity)
+	}
+}
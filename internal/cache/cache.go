@@ -0,0 +1,226 @@
+// Package cache keeps the issues per-unit rules found in each unit file
+// between runs, so that a rescan only checks the files that changed.
+//
+// Entries are stored in one JSON file per cache key. The key covers
+// everything besides the unit file that decides what the rules report: the
+// sdaudit version and build, the rule set, the rule configuration and the
+// scan options.
+// A file that cannot be read or decoded is treated as an empty cache.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// EnvDir names the cache directory used when --cache is not given
+const EnvDir = "SDAUDIT_CACHE"
+
+// fileVersion changes when the layout of the cache file does
+const fileVersion = 1
+
+// Cache holds the cached issues of the unit files of one cache key
+type Cache struct {
+	path    string
+	entries map[string]entry
+	// used holds the entries looked up or stored in this run; only they are
+	// saved, so units that are gone drop out
+	used map[string]entry
+}
+
+type entry struct {
+	Hash   string        `json:"hash"`
+	Issues []types.Issue `json:"issues"`
+}
+
+type file struct {
+	Version int              `json:"version"`
+	Entries map[string]entry `json:"entries"`
+}
+
+// DefaultDir returns the cache directory from $SDAUDIT_CACHE, or sdaudit in
+// the user's cache directory
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(EnvDir); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sdaudit"), nil
+}
+
+// Key hashes the parts that decide what the rules report, together with the
+// sdaudit build of the given version, into a cache key
+func Key(version string, parts ...string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00", fileVersion, buildID(version))
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// buildID identifies the running sdaudit build: its version, or else its
+// module version, and for builds from a checkout the commit and whether it
+// had local changes. Development builds, whose version says nothing of their
+// rules, add a hash of the executable.
+func buildID(version string) string {
+	info, ok := debug.ReadBuildInfo()
+	if version == "" && ok {
+		version = info.Main.Version
+	}
+	id := version
+	if ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				id += " " + s.Value
+			}
+		}
+	}
+	switch version {
+	case "", "dev", "(devel)":
+		id += " " + executableHash()
+	}
+	return id
+}
+
+// executableHash hashes the running executable, once per process
+var executableHash = sync.OnceValue(func() string {
+	path, err := os.Executable()
+	if err != nil {
+		return "unknown"
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "unknown"
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(h.Sum(nil))
+})
+
+// Open returns the cache for key in dir. A missing, unreadable or corrupted
+// cache file gives an empty cache rather than an error.
+func Open(dir, key string) *Cache {
+	c := &Cache{
+		path:    filepath.Join(dir, key+".json"),
+		entries: make(map[string]entry),
+		used:    make(map[string]entry),
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return c
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil || f.Version != fileVersion {
+		return c
+	}
+	for path, e := range f.Entries {
+		if e.Hash != "" {
+			c.entries[path] = e
+		}
+	}
+	return c
+}
+
+// Get returns the issues cached for a unit, if its file has not changed
+func (c *Cache) Get(unit *types.UnitFile) ([]types.Issue, bool) {
+	e, ok := c.entries[unit.Path]
+	if !ok || e.Hash != hashUnit(unit) {
+		return nil, false
+	}
+	c.used[unit.Path] = e
+	return e.Issues, true
+}
+
+// Put stores the issues found for a unit
+func (c *Cache) Put(unit *types.UnitFile, issues []types.Issue) {
+	e := entry{Hash: hashUnit(unit), Issues: issues}
+	c.entries[unit.Path] = e
+	c.used[unit.Path] = e
+}
+
+// Save writes the entries used in this run. The file is replaced atomically,
+// so a concurrent run reads either the old or the new cache.
+func (c *Cache) Save() error {
+	data, err := json.Marshal(file{Version: fileVersion, Entries: c.used})
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+// Clear removes the cache files in dir and returns how many there were. A
+// missing directory is an empty cache.
+func Clear(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// hashUnit hashes what per-unit rules see of a unit: its name, contents and
+// drop-ins, whether it is masked and the files it shadows
+func hashUnit(unit *types.UnitFile) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%t\x00%s\x00", unit.Name, unit.Masked, strings.Join(unit.ShadowedPaths, "\x00"))
+	h.Write([]byte(unit.Raw))
+	for _, path := range unit.DropIns {
+		content, _ := os.ReadFile(path)
+		fmt.Fprintf(h, "\x00%s\x00", path)
+		h.Write(content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
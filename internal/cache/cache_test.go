@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func testUnit(raw string) *types.UnitFile {
+	return &types.UnitFile{Name: "app.service", Path: "/etc/systemd/system/app.service", Type: "service", Raw: raw}
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	key := Key("1.0", "rules", "config")
+	unit := testUnit("[Service]\nExecStart=/usr/bin/app\n")
+	line := 2
+	issues := []types.Issue{{RuleID: "SEC001", Severity: types.SeverityHigh, Unit: unit.Name, Line: &line}}
+
+	c := Open(dir, key)
+	if _, ok := c.Get(unit); ok {
+		t.Fatal("empty cache returned an entry")
+	}
+	c.Put(unit, issues)
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, ok := Open(dir, key).Get(unit)
+	if !ok || len(got) != 1 || got[0].RuleID != "SEC001" || got[0].Severity != types.SeverityHigh || *got[0].Line != 2 {
+		t.Errorf("Get() = %+v, %v, want the stored issue", got, ok)
+	}
+
+	// A changed file, a masked file or another key misses
+	if _, ok := Open(dir, key).Get(testUnit("[Service]\nExecStart=/usr/bin/other\n")); ok {
+		t.Error("Get() hit for changed contents")
+	}
+	masked := testUnit(unit.Raw)
+	masked.Masked = true
+	if _, ok := Open(dir, key).Get(masked); ok {
+		t.Error("Get() hit for a masked unit")
+	}
+	if _, ok := Open(dir, Key("1.0", "rules", "other config")).Get(unit); ok {
+		t.Error("Get() hit under another key")
+	}
+}
+
+func TestCacheDropsUnusedEntries(t *testing.T) {
+	dir := t.TempDir()
+	key := Key("1.0", "rules")
+	kept := testUnit("[Service]\nExecStart=/usr/bin/app\n")
+	gone := &types.UnitFile{Name: "gone.service", Path: "/etc/systemd/system/gone.service", Raw: "[Service]\n"}
+
+	c := Open(dir, key)
+	c.Put(kept, nil)
+	c.Put(gone, nil)
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = Open(dir, key)
+	if _, ok := c.Get(kept); !ok {
+		t.Fatal("Get() missed a stored unit")
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Open(dir, key).Get(gone); ok {
+		t.Error("entry of a unit not seen in the last run was kept")
+	}
+}
+
+func TestCorruptedCacheIgnored(t *testing.T) {
+	unit := testUnit("[Service]\nExecStart=/usr/bin/app\n")
+	key := Key("1.0", "rules")
+
+	for name, content := range map[string]string{
+		"garbage":     "not json at all",
+		"truncated":   `{"version":1,"entries":{"/etc/systemd/system/app.service":{"hash":"ab`,
+		"wrong type":  `{"version":1,"entries":[1,2,3]}`,
+		"old version": `{"version":0,"entries":{}}`,
+		"empty":       "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, key+".json"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			c := Open(dir, key)
+			if _, ok := c.Get(unit); ok {
+				t.Error("corrupted cache returned an entry")
+			}
+			c.Put(unit, nil)
+			if err := c.Save(); err != nil {
+				t.Fatalf("Save() over a corrupted file error = %v", err)
+			}
+			if _, ok := Open(dir, key).Get(unit); !ok {
+				t.Error("cache rewritten over a corrupted file misses")
+			}
+		})
+	}
+}
+
+func TestClear(t *testing.T) {
+	dir := t.TempDir()
+	c := Open(dir, Key("1.0", "rules"))
+	c.Put(testUnit(""), nil)
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Clear(dir)
+	if err != nil || removed != 1 {
+		t.Errorf("Clear() = %d, %v, want 1, nil", removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("Clear() removed a file that is not a cache file: %v", err)
+	}
+	if removed, err := Clear(filepath.Join(dir, "missing")); err != nil || removed != 0 {
+		t.Errorf("Clear() of a missing directory = %d, %v, want 0, nil", removed, err)
+	}
+}
+
+func TestKeyVersion(t *testing.T) {
+	if Key("0.1.0", "rules") == Key("0.2.0", "rules") {
+		t.Error("releases share a cache key")
+	}
+	// Development builds are told apart by their executable
+	if Key("dev", "rules") != Key("dev", "rules") || Key("dev", "rules") == Key("(devel)", "rules") {
+		t.Error("development build keys are not stable per executable and version")
+	}
+	if !strings.Contains(buildID("dev"), executableHash()) || strings.Contains(buildID("0.1.0"), executableHash()) {
+		t.Error("only development builds hash the executable")
+	}
+}
@@ -0,0 +1,102 @@
+// Package cgroup interprets resource control settings of units and slices.
+package cgroup
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// byteSuffixes are the size suffixes systemd accepts, all base 1024
+var byteSuffixes = map[byte]uint64{
+	'K': 1 << 10,
+	'M': 1 << 20,
+	'G': 1 << 30,
+	'T': 1 << 40,
+	'P': 1 << 50,
+	'E': 1 << 60,
+}
+
+// ParseBytes parses a byte size such as "512M", "1.5G" or "1024", using
+// systemd's base-1024 suffixes
+func ParseBytes(value string) (uint64, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	multiplier := uint64(1)
+	last := s[len(s)-1]
+	if last == 'B' || last == 'b' {
+		s = s[:len(s)-1]
+		if s == "" {
+			return 0, fmt.Errorf("invalid size %q", value)
+		}
+		last = s[len(s)-1]
+	}
+	if m, ok := byteSuffixes[byte(strings.ToUpper(string(last))[0])]; ok {
+		multiplier = m
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	bytes := n * float64(multiplier)
+	if bytes >= math.MaxUint64 {
+		return 0, fmt.Errorf("size %q out of range", value)
+	}
+	return uint64(bytes), nil
+}
+
+// Memory is the value of a memory directive such as MemoryMax=. It is either
+// a byte count, a percentage of physical memory, or infinity.
+type Memory struct {
+	Bytes    uint64
+	Percent  float64
+	Infinity bool
+}
+
+// ParseMemory parses the value of MemoryMin=, MemoryLow=, MemoryHigh= or MemoryMax=
+func ParseMemory(value string) (Memory, error) {
+	s := strings.TrimSpace(value)
+	switch {
+	case s == "infinity":
+		return Memory{Infinity: true}, nil
+	case strings.HasSuffix(s, "%"):
+		p, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || p < 0 || p > 100 {
+			return Memory{}, fmt.Errorf("invalid percentage %q", value)
+		}
+		return Memory{Percent: p}, nil
+	}
+
+	n, err := ParseBytes(s)
+	if err != nil {
+		return Memory{}, err
+	}
+	return Memory{Bytes: n}, nil
+}
+
+// Exceeds reports whether m is larger than other. ok is false when the two
+// cannot be compared, i.e. one is a percentage and the other a byte count.
+// An infinite m never exceeds, since it leaves the decision to the parent.
+func (m Memory) Exceeds(other Memory) (exceeds, ok bool) {
+	switch {
+	case m.Infinity || other.Infinity:
+		return false, true
+	case m.isPercent() && other.isPercent():
+		return m.Percent > other.Percent, true
+	case !m.isPercent() && !other.isPercent():
+		return m.Bytes > other.Bytes, true
+	default:
+		return false, false
+	}
+}
+
+func (m Memory) isPercent() bool {
+	return m.Percent > 0
+}
@@ -0,0 +1,109 @@
+package cgroup
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    uint64
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"512K", 512 << 10, false},
+		{"512M", 512 << 20, false},
+		{"1.5G", 3 << 29, false},
+		{"2T", 2 << 40, false},
+		{"1GB", 1 << 30, false},
+		{" 4G ", 4 << 30, false},
+		{"", 0, true},
+		{"G", 0, true},
+		{"-1M", 0, true},
+		{"lots", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseBytes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBytes(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseBytes(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryExceeds(t *testing.T) {
+	tests := []struct {
+		child, parent string
+		wantExceeds   bool
+		wantOK        bool
+	}{
+		{"2G", "1G", true, true},
+		{"1G", "1024M", false, true},
+		{"60%", "50%", true, true},
+		{"60%", "1G", false, false},
+		{"infinity", "1G", false, true},
+		{"2G", "infinity", false, true},
+	}
+
+	for _, tt := range tests {
+		child, err := ParseMemory(tt.child)
+		if err != nil {
+			t.Fatalf("ParseMemory(%q) failed: %v", tt.child, err)
+		}
+		parent, err := ParseMemory(tt.parent)
+		if err != nil {
+			t.Fatalf("ParseMemory(%q) failed: %v", tt.parent, err)
+		}
+
+		exceeds, ok := child.Exceeds(parent)
+		if exceeds != tt.wantExceeds || ok != tt.wantOK {
+			t.Errorf("%s.Exceeds(%s) = (%v, %v), want (%v, %v)", tt.child, tt.parent, exceeds, ok, tt.wantExceeds, tt.wantOK)
+		}
+	}
+
+	if _, err := ParseMemory("150%"); err == nil {
+		t.Error("expected error for a percentage above 100")
+	}
+}
+
+func TestSliceChain(t *testing.T) {
+	service := func(slice string) *types.UnitFile {
+		unit := &types.UnitFile{
+			Name:     "web.service",
+			Type:     "service",
+			Sections: map[string]*types.Section{"Service": {Name: "Service", Directives: map[string][]types.Directive{}}},
+		}
+		if slice != "" {
+			unit.Sections["Service"].Directives["Slice"] = []types.Directive{{Key: "Slice", Value: slice}}
+		}
+		return unit
+	}
+
+	tests := []struct {
+		name string
+		unit *types.UnitFile
+		want []string
+	}{
+		{"default slice", service(""), []string{"system.slice"}},
+		{"nested slice", service("app-web-api.slice"), []string{"app-web-api.slice", "app-web.slice", "app.slice"}},
+		{"slice unit", &types.UnitFile{Name: "app-web.slice", Type: "slice"}, []string{"app.slice"}},
+		{"top-level slice", &types.UnitFile{Name: "system.slice", Type: "slice"}, nil},
+		{"root slice", &types.UnitFile{Name: "-.slice", Type: "slice"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SliceChain(tt.unit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SliceChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
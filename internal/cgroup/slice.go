@@ -0,0 +1,58 @@
+package cgroup
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// RootSlice is the root of the slice tree. Its settings do not constrain
+// children the way other slices do.
+const RootSlice = "-.slice"
+
+// DefaultSlice is where system services are placed without Slice=
+const DefaultSlice = "system.slice"
+
+// SliceChain returns the slices a unit runs in, innermost first and without
+// the root slice. A slice's own chain starts with its parent. Slice names
+// encode their parents, so "app-web.slice" lives in "app.slice".
+func SliceChain(unit *types.UnitFile) []string {
+	var slice string
+	if unit.Type == "slice" {
+		slice = parentSlice(unit.Name)
+	} else {
+		slice = unit.GetDirective(ResourceSection(unit), "Slice")
+		if slice == "" {
+			slice = DefaultSlice
+		}
+	}
+
+	var chain []string
+	for slice != "" && slice != RootSlice {
+		chain = append(chain, slice)
+		slice = parentSlice(slice)
+	}
+	return chain
+}
+
+// ResourceSection returns the section holding a unit's resource control
+// settings, e.g. [Service] for a service and [Slice] for a slice
+func ResourceSection(unit *types.UnitFile) string {
+	if unit.Type == "" {
+		return ""
+	}
+	return strings.ToUpper(unit.Type[:1]) + unit.Type[1:]
+}
+
+// parentSlice returns the slice containing a slice, or "" for the root slice
+func parentSlice(name string) string {
+	if name == RootSlice {
+		return ""
+	}
+	base := strings.TrimSuffix(name, ".slice")
+	i := strings.LastIndex(base, "-")
+	if i <= 0 {
+		return RootSlice
+	}
+	return base[:i] + ".slice"
+}
@@ -0,0 +1,133 @@
+// Package config loads the sdaudit configuration file, a YAML file given
+// with --config:
+//
+//	score:
+//	  weights:
+//	    critical: 50
+//	    high: 25
+//
+//	ordering:
+//	  synchronization_units:
+//	    - app-stack-ready.target
+//
+//	rules:
+//	  PERF005:
+//	    max_timeout: 10min
+//
+//	boot:
+//	  slow_unit: 10s
+//
+// Settings the file leaves out keep their defaults.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// File is the configuration file
+type File struct {
+	Score    Score    `yaml:"score"`
+	Ordering Ordering `yaml:"ordering"`
+	// Rules sets rule parameters by rule ID and parameter name, such as
+	// PERF005's max_timeout
+	Rules map[string]map[string]string `yaml:"rules"`
+	Boot  Boot                         `yaml:"boot"`
+}
+
+// Score configures the audit scores of units
+type Score struct {
+	// Weights are the points an issue takes off its unit's score, by
+	// severity name
+	Weights map[string]int `yaml:"weights"`
+}
+
+// Ordering configures the ordering checks of REL005 and sdaudit deps
+type Ordering struct {
+	// SynchronizationUnits are units that are ordered against with After=
+	// without being required, such as targets a site's own services pull
+	// in, in addition to the special units of systemd
+	SynchronizationUnits []string `yaml:"synchronization_units"`
+}
+
+// Boot configures when sdaudit boot reports a unit or the boot as slow.
+// Both are systemd time spans, such as 10s.
+type Boot struct {
+	// SlowUnit is the start time above which a unit is slow, 5s if unset
+	SlowUnit string `yaml:"slow_unit"`
+	// SlowUserspace is the userspace boot time above which the boot is
+	// slow, 30s if unset
+	SlowUserspace string `yaml:"slow_userspace"`
+}
+
+// Load reads and checks the configuration file at path. Unknown settings,
+// severities, rules and rule parameters, negative weights, names that are
+// not unit names and values that do not fit a parameter are errors.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for name, weight := range f.Score.Weights {
+		if types.ParseSeverity(name).String() != name {
+			return nil, fmt.Errorf("%s: score.weights: unknown severity %q (use critical, high, medium, low or info)", path, name)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("%s: score.weights: %s weight %d is negative", path, name, weight)
+		}
+	}
+	for _, name := range f.Ordering.SynchronizationUnits {
+		if !unitfile.IsUnitFile(name) {
+			return nil, fmt.Errorf("%s: ordering.synchronization_units: %q is not a unit name", path, name)
+		}
+	}
+	if err := rules.DefaultConfig().SetParams(f.Rules); err != nil {
+		return nil, fmt.Errorf("%s: rules: %w", path, err)
+	}
+	for _, setting := range []struct{ name, value string }{
+		{"slow_unit", f.Boot.SlowUnit},
+		{"slow_userspace", f.Boot.SlowUserspace},
+	} {
+		if _, err := timing.ParseDuration(setting.value); err != nil {
+			return nil, fmt.Errorf("%s: boot.%s: %q is not a duration, such as 10s", path, setting.name, setting.value)
+		}
+	}
+	return &f, nil
+}
+
+// ScoreWeights returns the default score weights with those the file sets
+func (f *File) ScoreWeights() types.ScoreWeights {
+	weights := types.DefaultScoreWeights()
+	for name, weight := range f.Score.Weights {
+		weights[types.ParseSeverity(name)] = weight
+	}
+	return weights
+}
+
+// BootThresholds returns the start time above which sdaudit boot reports a
+// unit as slow and the userspace boot time above which it reports the boot,
+// zero for those the file leaves out
+func (f *File) BootThresholds() (slowUnit, slowUserspace time.Duration) {
+	slowUnit, _ = timing.ParseDuration(f.Boot.SlowUnit)
+	slowUserspace, _ = timing.ParseDuration(f.Boot.SlowUserspace)
+	return slowUnit, slowUserspace
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/pkg/types"
+
+	// Register the rules whose parameters the tests set
+	_ "github.com/supabase/sdaudit/internal/rules/performance"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sdaudit.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadScoreWeights(t *testing.T) {
+	f, err := Load(writeConfig(t, "score:\n  weights:\n    critical: 50\n    info: 1\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := types.DefaultScoreWeights()
+	want[types.SeverityCritical] = 50
+	want[types.SeverityInfo] = 1
+	got := f.ScoreWeights()
+	for sev, weight := range want {
+		if got[sev] != weight {
+			t.Errorf("weight of %s = %d, want %d", sev, got[sev], weight)
+		}
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	f, err := Load(writeConfig(t, ""))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := f.ScoreWeights(); got[types.SeverityHigh] != types.DefaultScoreWeights()[types.SeverityHigh] {
+		t.Errorf("empty config changed the weights: %v", got)
+	}
+}
+
+func TestLoadSynchronizationUnits(t *testing.T) {
+	f, err := Load(writeConfig(t, "ordering:\n  synchronization_units:\n    - app-stack.target\n    - vault-agent.service\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := strings.Join(f.Ordering.SynchronizationUnits, " "); got != "app-stack.target vault-agent.service" {
+		t.Errorf("SynchronizationUnits = %q", got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    string
+	}{
+		"unknown severity": {"score:\n  weights:\n    dire: 5\n", `unknown severity "dire"`},
+		"negative weight":  {"score:\n  weights:\n    high: -5\n", "high weight -5 is negative"},
+		"unknown setting":  {"scores:\n  weights: {}\n", "field scores not found"},
+		"not a number":     {"score:\n  weights:\n    high: lots\n", "cannot unmarshal"},
+		"not a unit":       {"ordering:\n  synchronization_units: [app-stack]\n", `"app-stack" is not a unit name`},
+		"unknown rule":     {"rules:\n  XXX999:\n    max_timeout: 5min\n", `rules: unknown rule "XXX999"`},
+		"unknown param":    {"rules:\n  PERF005:\n    timeout: 5min\n", `PERF005 has no parameter "timeout" (use max_timeout)`},
+		"bad param value":  {"rules:\n  PERF002:\n    max_exec_start_pre: many\n", `PERF002.max_exec_start_pre: "many" is not a whole number`},
+		"bad boot value":   {"boot:\n  slow_unit: soon\n", `boot.slow_unit: "soon" is not a duration`},
+	}
+	for name, tt := range tests {
+		path := writeConfig(t, tt.content)
+		_, err := Load(path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), path) {
+			t.Errorf("%s: Load() error = %v, want one naming %s and containing %q", name, err, path, tt.want)
+		}
+	}
+}
+
+func TestScoreWeightsRescore(t *testing.T) {
+	f, err := Load(writeConfig(t, "score:\n  weights:\n    medium: 30\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	result := &types.ScanResult{
+		Issues:  []types.Issue{{Severity: types.SeverityMedium, Unit: "a.service"}, {Severity: types.SeverityLow, Unit: "a.service"}},
+		Summary: types.Summary{ScoreWeights: f.ScoreWeights()},
+	}
+	result.Rescore()
+	// 100 - 30 (medium, from the file) - 3 (low, default)
+	if result.Summary.Score != 67 {
+		t.Errorf("score = %d, want 67", result.Summary.Score)
+	}
+}
+
+func TestLoadRuleParams(t *testing.T) {
+	f, err := Load(writeConfig(t, "rules:\n  PERF005:\n    max_timeout: 10min\n  PERF002:\n    max_exec_start_pre: 5\nboot:\n  slow_unit: 10s\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if f.Rules["PERF005"]["max_timeout"] != "10min" || f.Rules["PERF002"]["max_exec_start_pre"] != "5" {
+		t.Errorf("Rules = %v", f.Rules)
+	}
+	if slowUnit, slowUserspace := f.BootThresholds(); slowUnit != 10*time.Second || slowUserspace != 0 {
+		t.Errorf("BootThresholds() = %s, %s, want 10s and unset", slowUnit, slowUserspace)
+	}
+}
@@ -3,6 +3,8 @@ package graph
 import (
 	"fmt"
 	"sort"
+
+	"github.com/supabase/sdaudit/internal/unitname"
 )
 
 // DanglingRef represents a reference to a non-existent unit.
@@ -16,6 +18,8 @@ type DanglingRef struct {
 
 // FindDanglingRefs finds all references to units that don't exist.
 // Classifies by edge type - Requires= to missing is worse than Wants= to missing.
+// Implicit dependencies other than activation are left out: the special
+// units systemd adds them on are often not part of the scan.
 func (g *Graph) FindDanglingRefs() []DanglingRef {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
@@ -23,6 +27,9 @@ func (g *Graph) FindDanglingRefs() []DanglingRef {
 	var dangling []DanglingRef
 
 	for _, edge := range g.allEdges {
+		if edge.Implicit && edge.Type != EdgeTriggeredBy {
+			continue
+		}
 		// Check if target unit exists (has a parsed unit file, not just a node)
 		if _, exists := g.units[edge.To]; !exists {
 			dangling = append(dangling, DanglingRef{
@@ -85,7 +92,8 @@ func (d DanglingRef) Severity() string {
 type OrderingIssue struct {
 	Unit        string
 	Related     string
-	IssueType   string // "after_without_requires" or "requires_without_after"
+	IssueType   string   // "after_without_requires" or "requires_without_after"
+	EdgeType    EdgeType // Directive declaring the relationship
 	Description string
 	File        string
 	Line        int
@@ -94,16 +102,33 @@ type OrderingIssue struct {
 // FindOrderingIssues detects ordering inconsistencies:
 // - After= without Requires= or Wants= (ordering only honored if both happen to start)
 // - Requires= without After= (parallel start, may or may not be intentional)
+//
+// Before= on the required unit orders it like After= on the requiring one,
+// and implicit dependencies count but are not reported themselves.
+// After= on synchronization units, see AddSynchronizationUnits, is left out.
+// Each relationship is reported once, at the first directive declaring it,
+// however many files repeat it.
 func (g *Graph) FindOrderingIssues() []OrderingIssue {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
-	var issues []OrderingIssue
+	return g.orderingIssues(g.allEdges)
+}
 
+// FindOrderingIssuesFrom is FindOrderingIssues for the relationships
+// declared by one unit.
+func (g *Graph) FindOrderingIssuesFrom(unit string) []OrderingIssue {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.orderingIssues(g.outgoing[unitname.Normalize(unit)])
+}
+
+func (g *Graph) orderingIssues(allEdges []Edge) []OrderingIssue {
 	// Build maps for quick lookup
 	// Key: "from:to", Value: edge types present
 	edgeIndex := make(map[string]map[EdgeType]Edge)
-	for _, edge := range g.allEdges {
+	for _, edge := range allEdges {
 		key := edge.From + ":" + edge.To
 		if edgeIndex[key] == nil {
 			edgeIndex[key] = make(map[EdgeType]Edge)
@@ -111,9 +136,21 @@ func (g *Graph) FindOrderingIssues() []OrderingIssue {
 		edgeIndex[key][edge.Type] = edge
 	}
 
-	// Check for After= without Requires=/Wants=
-	for _, edge := range g.allEdges {
-		if edge.Type != EdgeAfter {
+	var issues []OrderingIssue
+	seen := make(map[string]bool)
+	add := func(issue OrderingIssue) {
+		key := issue.Unit + ":" + issue.Related + ":" + issue.IssueType
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		issues = append(issues, issue)
+	}
+
+	// Check for After= without Requires=/Wants=, except on units that are
+	// there without being pulled in
+	for _, edge := range allEdges {
+		if edge.Type != EdgeAfter || edge.Implicit || g.synchronization[edge.To] {
 			continue
 		}
 
@@ -130,10 +167,11 @@ func (g *Graph) FindOrderingIssues() []OrderingIssue {
 		}
 
 		if !hasRequirement {
-			issues = append(issues, OrderingIssue{
+			add(OrderingIssue{
 				Unit:      edge.From,
 				Related:   edge.To,
 				IssueType: "after_without_requires",
+				EdgeType:  edge.Type,
 				Description: fmt.Sprintf("%s has After=%s but no Requires= or Wants=. "+
 					"Ordering is only honored if both units happen to start.",
 					edge.From, edge.To),
@@ -144,8 +182,8 @@ func (g *Graph) FindOrderingIssues() []OrderingIssue {
 	}
 
 	// Check for Requires= without After=
-	for _, edge := range g.allEdges {
-		if edge.Type != EdgeRequires && edge.Type != EdgeBindsTo {
+	for _, edge := range allEdges {
+		if (edge.Type != EdgeRequires && edge.Type != EdgeBindsTo) || edge.Implicit {
 			continue
 		}
 
@@ -156,10 +194,11 @@ func (g *Graph) FindOrderingIssues() []OrderingIssue {
 		_, hasAfter := edges[EdgeAfter]
 
 		if !hasAfter {
-			issues = append(issues, OrderingIssue{
+			add(OrderingIssue{
 				Unit:      edge.From,
 				Related:   edge.To,
 				IssueType: "requires_without_after",
+				EdgeType:  edge.Type,
 				Description: fmt.Sprintf("%s has %s=%s but no After=. "+
 					"Units will start in parallel, which may cause race conditions.",
 					edge.From, edge.Type.String(), edge.To),
@@ -183,6 +222,100 @@ func (g *Graph) FindOrderingIssues() []OrderingIssue {
 	return issues
 }
 
+// OrderingContradiction is a pair of units each ordered after the other,
+// through After= and Before= declared by either of them. systemd drops one
+// of the two orderings at boot, so which unit starts first is arbitrary.
+type OrderingContradiction struct {
+	Unit        string // Unit of the pair that sorts first
+	Other       string
+	AfterOther  Edge // Edge ordering Unit after Other
+	BeforeOther Edge // Edge ordering Unit before Other
+}
+
+// Description describes both orderings with the directives declaring them
+func (c OrderingContradiction) Description() string {
+	return fmt.Sprintf("%s is ordered both after %s (%s) and before it (%s).",
+		c.Unit, c.Other, edgeSource(c.AfterOther), edgeSource(c.BeforeOther))
+}
+
+// edgeSource describes the directive that declares an edge and where
+func edgeSource(e Edge) string {
+	if e.Implicit {
+		return fmt.Sprintf("implicit %s=%s of %s", e.Type, e.To, e.From)
+	}
+	source := fmt.Sprintf("%s=%s in %s", e.Type, e.To, e.From)
+	if e.Line > 0 {
+		source += fmt.Sprintf(" line %d", e.Line)
+	}
+	return source
+}
+
+// FindOrderingContradictions detects pairs of units ordered both ways. Before=
+// is read as After= in the opposite direction, so a unit with After=b and
+// Before=b contradicts itself, while a pair declaring the same ordering from
+// both sides does not. When several directives order a pair the same way, the
+// first one by file and line is reported, preferring declared to implicit ones.
+func (g *Graph) FindOrderingContradictions() []OrderingContradiction {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	// waits maps each (unit, dependency) pair to the edge ordering unit after
+	// dependency
+	waits := make(map[[2]string]Edge)
+	for _, edge := range g.allEdges {
+		var key [2]string
+		switch {
+		case g.reversesBefore(edge):
+			continue
+		case edge.Type == EdgeAfter:
+			key = [2]string{edge.From, edge.To}
+		case edge.Type == EdgeBefore:
+			key = [2]string{edge.To, edge.From}
+		default:
+			continue
+		}
+		if key[0] == key[1] {
+			continue
+		}
+		if prev, ok := waits[key]; !ok || (prev.Implicit && !edge.Implicit) || (prev.Implicit == edge.Implicit && edgeLess(edge, prev)) {
+			waits[key] = edge
+		}
+	}
+
+	var contradictions []OrderingContradiction
+	for key, after := range waits {
+		if key[0] > key[1] {
+			continue
+		}
+		if before, ok := waits[[2]string{key[1], key[0]}]; ok {
+			contradictions = append(contradictions, OrderingContradiction{
+				Unit:        key[0],
+				Other:       key[1],
+				AfterOther:  after,
+				BeforeOther: before,
+			})
+		}
+	}
+
+	// Sort for determinism
+	sort.Slice(contradictions, func(i, j int) bool {
+		if contradictions[i].Unit != contradictions[j].Unit {
+			return contradictions[i].Unit < contradictions[j].Unit
+		}
+		return contradictions[i].Other < contradictions[j].Other
+	})
+
+	return contradictions
+}
+
+// edgeLess orders edges by the file and line that declare them
+func edgeLess(a, b Edge) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	return a.Line < b.Line
+}
+
 // BindsToWithoutAfter returns units that have BindsTo= without After=.
 // This is particularly dangerous as stop propagates immediately.
 type BindingIssue struct {
@@ -194,6 +327,7 @@ type BindingIssue struct {
 }
 
 // FindBindingIssues finds BindsTo= relationships without proper After= ordering.
+// Before= on the bound unit counts as After=.
 func (g *Graph) FindBindingIssues() []BindingIssue {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
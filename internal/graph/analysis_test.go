@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"path/filepath"
 	"testing"
 )
 
@@ -79,6 +80,45 @@ func TestFindOrderingIssues_AfterWithoutRequires(t *testing.T) {
 	}
 }
 
+func TestFindOrderingIssues_RepeatedInDropIn(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/after_repeated"))
+
+	for name, issues := range map[string][]OrderingIssue{
+		"FindOrderingIssues":     g.FindOrderingIssues(),
+		"FindOrderingIssuesFrom": g.FindOrderingIssuesFrom("web.service"),
+	} {
+		if len(issues) != 1 {
+			t.Fatalf("%s = %+v, want one issue for web.service", name, issues)
+		}
+		if issue := issues[0]; issue.Related != "database.service" || issue.EdgeType != EdgeAfter || issue.Line != 3 {
+			t.Errorf("%s = %+v, want After=database.service at line 3", name, issue)
+		}
+	}
+	if issues := g.FindOrderingIssuesFrom("database.service"); len(issues) != 0 {
+		t.Errorf("FindOrderingIssuesFrom(database.service) = %+v, want none", issues)
+	}
+}
+
+func TestFindOrderingIssues_SynchronizationUnits(t *testing.T) {
+	g := New()
+	for _, to := range []string{"network-online.target", "time-sync.target", "dbus.socket", "app-stack.target"} {
+		g.AddEdge(Edge{From: "web.service", To: to, Type: EdgeAfter})
+	}
+
+	issues := g.FindOrderingIssues()
+	if len(issues) != 1 || issues[0].Related != "app-stack.target" {
+		t.Fatalf("FindOrderingIssues() = %+v, want only app-stack.target", issues)
+	}
+
+	g.AddSynchronizationUnits("app-stack.target")
+	if !g.IsSynchronizationUnit("app-stack.target") {
+		t.Error("app-stack.target should be a synchronization unit once added")
+	}
+	if issues := g.FindOrderingIssues(); len(issues) != 0 {
+		t.Errorf("FindOrderingIssues() = %+v, want none", issues)
+	}
+}
+
 func TestFindOrderingIssues_RequiresWithoutAfter(t *testing.T) {
 	units := loadTestUnits(t, "../../testdata/graph/requires_without_after")
 	g := Build(units)
@@ -102,6 +142,37 @@ func TestFindOrderingIssues_RequiresWithoutAfter(t *testing.T) {
 	}
 }
 
+func TestFindOrderingContradictions(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/ordering_contradiction"))
+
+	// e.service After=f.service and f.service Before=e.service agree
+	contradictions := g.FindOrderingContradictions()
+	if len(contradictions) != 2 {
+		t.Fatalf("expected 2 contradictions, got %d: %+v", len(contradictions), contradictions)
+	}
+
+	ab := contradictions[0]
+	if ab.Unit != "a.service" || ab.Other != "b.service" {
+		t.Errorf("first contradiction = %s/%s, want a.service/b.service", ab.Unit, ab.Other)
+	}
+	if ab.AfterOther.From != "a.service" || ab.AfterOther.Line != 3 || ab.BeforeOther.From != "b.service" || ab.BeforeOther.Line != 3 {
+		t.Errorf("edges = %+v and %+v, want After= on line 3 of each unit", ab.AfterOther, ab.BeforeOther)
+	}
+	want := "a.service is ordered both after b.service (After=b.service in a.service line 3) and before it (After=a.service in b.service line 3)."
+	if got := ab.Description(); got != want {
+		t.Errorf("Description() = %q, want %q", got, want)
+	}
+
+	// c.service declares After=d.service and Before=d.service itself
+	cd := contradictions[1]
+	if cd.Unit != "c.service" || cd.Other != "d.service" {
+		t.Errorf("second contradiction = %s/%s, want c.service/d.service", cd.Unit, cd.Other)
+	}
+	if cd.AfterOther.Type != EdgeAfter || cd.AfterOther.Line != 3 || cd.BeforeOther.Type != EdgeBefore || cd.BeforeOther.Line != 4 {
+		t.Errorf("edges = %+v and %+v, want After= on line 3 and Before= on line 4", cd.AfterOther, cd.BeforeOther)
+	}
+}
+
 func TestGraphStats(t *testing.T) {
 	units := loadTestUnits(t, "../../testdata/graph/cycle_simple")
 	g := Build(units)
@@ -120,8 +191,9 @@ func TestGraphStats(t *testing.T) {
 		t.Errorf("expected 1 cycle, got %d", stats.CycleCount)
 	}
 
-	if stats.EdgesByType[EdgeRequires] != 3 {
-		t.Errorf("expected 3 Requires edges, got %d", stats.EdgesByType[EdgeRequires])
+	// 3 declared and the implicit Requires=sysinit.target of each service
+	if stats.EdgesByType[EdgeRequires] != 6 {
+		t.Errorf("expected 6 Requires edges, got %d", stats.EdgesByType[EdgeRequires])
 	}
 }
 
@@ -139,3 +211,31 @@ func TestFindBindingIssues(t *testing.T) {
 		t.Log("No binding issues found (as expected with proper After=)")
 	}
 }
+
+func TestBeforeOrdersLikeAfter(t *testing.T) {
+	// db.service Before=app.service is the only ordering of the pair
+	g := Build(loadTestUnits(t, "../../testdata/graph/before_only"))
+
+	var reverse []Edge
+	for _, e := range g.EdgesFrom("app.service") {
+		if e.Type == EdgeAfter && e.To == "db.service" {
+			reverse = append(reverse, e)
+		}
+	}
+	if len(reverse) != 1 || reverse[0].To != "db.service" || !reverse[0].Implicit || reverse[0].Line != 3 || filepath.Base(reverse[0].File) != "db.service" {
+		t.Fatalf("After= edges of app.service = %+v, want an implicit one to db.service from line 3 of db.service", reverse)
+	}
+
+	for _, o := range g.FindOrderingIssues() {
+		t.Errorf("FindOrderingIssues() found %+v, want nothing", o)
+	}
+	if issues := g.FindBindingIssues(); len(issues) != 0 {
+		t.Errorf("FindBindingIssues() = %+v, want nothing", issues)
+	}
+	if contradictions := g.FindOrderingContradictions(); len(contradictions) != 0 {
+		t.Errorf("FindOrderingContradictions() = %+v, want nothing", contradictions)
+	}
+	if cycles := g.FindCycles(); len(cycles) != 0 {
+		t.Errorf("FindCycles() = %+v, want nothing", cycles)
+	}
+}
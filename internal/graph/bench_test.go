@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// syntheticGraph builds a graph of n services shaped like a large host: each
+// unit depends on and orders itself against a few units added before it, and
+// one in fifty is ordered before a later unit, which closes cycles.
+func syntheticGraph(n int, seed int64) *Graph {
+	r := rand.New(rand.NewSource(seed))
+	edgeTypes := []EdgeType{EdgeRequires, EdgeWants, EdgeAfter, EdgeBefore, EdgeBindsTo, EdgePartOf}
+
+	g := New()
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("unit%05d.service", i)
+		g.AddUnit(&types.UnitFile{Name: names[i], Type: "service"})
+	}
+	for i := 1; i < n; i++ {
+		for k := 0; k < 4; k++ {
+			g.AddEdge(Edge{From: names[i], To: names[r.Intn(i)], Type: edgeTypes[r.Intn(len(edgeTypes))]})
+		}
+		if r.Intn(50) == 0 {
+			g.AddEdge(Edge{From: names[r.Intn(i)], To: names[i], Type: EdgeAfter})
+		}
+		if r.Intn(20) == 0 {
+			g.AddEdge(Edge{From: names[i], To: fmt.Sprintf("missing%05d.service", i), Type: EdgeWants})
+		}
+	}
+	return g
+}
+
+// randomGraph builds a graph of n services with m edges of any type between
+// random units, self-loops included. Each Before= gets the After= the
+// builder adds for it.
+func randomGraph(r *rand.Rand, n, m int) *Graph {
+	edgeTypes := []EdgeType{
+		EdgeRequires, EdgeWants, EdgeAfter, EdgeBefore, EdgeBindsTo, EdgePartOf,
+		EdgeConflicts, EdgeTriggeredBy, EdgeRequisite, EdgePropagatesReloadTo,
+	}
+
+	g := New()
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("unit%03d.service", i)
+		g.AddUnit(&types.UnitFile{Name: names[i], Type: "service"})
+	}
+	for line := 1; line <= m; line++ {
+		edge := Edge{From: names[r.Intn(n)], To: names[r.Intn(n)], Type: edgeTypes[r.Intn(len(edgeTypes))], Line: line}
+		g.AddEdge(edge)
+		if edge.Type == EdgeBefore {
+			g.AddEdge(Edge{From: edge.To, To: edge.From, Type: EdgeAfter, Line: line, Implicit: true})
+		}
+	}
+	return g
+}
+
+func BenchmarkTransitiveDependencies(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		g := syntheticGraph(5000, 1)
+		b.StartTimer()
+		for _, name := range g.NodeNames() {
+			g.TransitiveDependencies(name)
+		}
+	}
+}
+
+func BenchmarkFindCycles(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		g := syntheticGraph(5000, 1)
+		b.StartTimer()
+		g.FindCycles()
+	}
+}
+
+// BenchmarkStatsRepeated calls Stats as often as the cross-unit analyses of
+// a scan do
+func BenchmarkStatsRepeated(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		g := syntheticGraph(5000, 1)
+		b.StartTimer()
+		for j := 0; j < 10; j++ {
+			g.Stats()
+		}
+	}
+}
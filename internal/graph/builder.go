@@ -7,15 +7,38 @@ import (
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
+// BuilderOptions control the edges a Builder adds besides the ones unit
+// files declare.
+type BuilderOptions struct {
+	// ImplicitDependencies adds the dependencies systemd adds on its own,
+	// marked Implicit: default dependencies, the ordering of sockets before
+	// their service, and the mounts of the paths a unit uses. See
+	// implicit.go. The mounts of RequiresMountsFor= are always added.
+	ImplicitDependencies bool
+}
+
+// DefaultBuilderOptions returns the options of NewBuilder and Build, with
+// implicit dependencies.
+func DefaultBuilderOptions() BuilderOptions {
+	return BuilderOptions{ImplicitDependencies: true}
+}
+
 // Builder constructs a Graph from parsed unit files.
 type Builder struct {
 	graph *Graph
+	opts  BuilderOptions
 }
 
-// NewBuilder creates a new graph builder.
+// NewBuilder creates a new graph builder with DefaultBuilderOptions.
 func NewBuilder() *Builder {
+	return NewBuilderWithOptions(DefaultBuilderOptions())
+}
+
+// NewBuilderWithOptions creates a new graph builder.
+func NewBuilderWithOptions(opts BuilderOptions) *Builder {
 	return &Builder{
 		graph: New(),
+		opts:  opts,
 	}
 }
 
@@ -40,6 +63,14 @@ func (b *Builder) BuildFromUnits(units map[string]*types.UnitFile) *Graph {
 		b.extractEdges(unit)
 	}
 
+	// Third pass: the dependencies systemd adds, which need all units to
+	// find the mount units of paths
+	if b.opts.ImplicitDependencies {
+		for _, name := range names {
+			b.addImplicitEdges(units[name])
+		}
+	}
+
 	return b.graph
 }
 
@@ -54,6 +85,25 @@ func (b *Builder) extractEdges(unit *types.UnitFile) {
 				}
 			}
 		}
+
+		for _, d := range unitSection.Directives["Before"] {
+			for _, target := range splitDirectiveValue(d.Value) {
+				b.addReverseAfter(Edge{From: unit.Name, To: target, Type: EdgeBefore, File: unit.Path, Line: d.Line})
+			}
+		}
+
+		// RequiresMountsFor= and WantsMountsFor= pull in the mount units
+		// of their paths, which the first pass has added
+		for _, d := range unitSection.Directives["RequiresMountsFor"] {
+			for _, p := range splitDirectiveValue(d.Value) {
+				b.addMountsFor(unit, p, EdgeRequires, d.Line)
+			}
+		}
+		for _, d := range unitSection.Directives["WantsMountsFor"] {
+			for _, p := range splitDirectiveValue(d.Value) {
+				b.addMountsFor(unit, p, EdgeWants, d.Line)
+			}
+		}
 	}
 
 	// Install section - reverse dependencies
@@ -75,6 +125,19 @@ func (b *Builder) extractEdges(unit *types.UnitFile) {
 			}
 		}
 
+		// Alias gives the unit another name other units can depend on.
+		// Several targets offer Alias=default.target, and only the
+		// default.target link says which one is the default.
+		if directives, ok := installSection.Directives["Alias"]; ok {
+			for _, d := range directives {
+				for _, alias := range splitDirectiveValue(d.Value) {
+					if alias != "default.target" {
+						b.graph.AddAlias(alias, unit.Name)
+					}
+				}
+			}
+		}
+
 		// RequiredBy creates a reverse Requires edge
 		if directives, ok := installSection.Directives["RequiredBy"]; ok {
 			for _, d := range directives {
@@ -165,6 +228,20 @@ func (b *Builder) extractEdges(unit *types.UnitFile) {
 	}
 }
 
+// addReverseAfter adds the After= that a Before= edge implies for its
+// target, as systemd records it, so that analyses of After= see orderings
+// declared either way
+func (b *Builder) addReverseAfter(before Edge) {
+	b.graph.AddEdge(Edge{
+		From:     before.To,
+		To:       before.From,
+		Type:     EdgeAfter,
+		File:     before.File,
+		Line:     before.Line,
+		Implicit: true,
+	})
+}
+
 // addEdgesFromDirective parses a directive value and adds edges for each target.
 func (b *Builder) addEdgesFromDirective(from string, directive types.Directive, edgeType EdgeType, file string) {
 	targets := splitDirectiveValue(directive.Value)
@@ -234,3 +311,8 @@ func splitDirectiveValue(value string) []string {
 func Build(units map[string]*types.UnitFile) *Graph {
 	return NewBuilder().BuildFromUnits(units)
 }
+
+// BuildWithOptions builds a graph from units with the given options.
+func BuildWithOptions(units map[string]*types.UnitFile, opts BuilderOptions) *Graph {
+	return NewBuilderWithOptions(opts).BuildFromUnits(units)
+}
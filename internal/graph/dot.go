@@ -8,24 +8,25 @@ import (
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
-// DOTOptions configures DOT output generation.
+// DOTOptions configures DOT output generation. The JSON and Mermaid exporters
+// take the same options.
 type DOTOptions struct {
 	Title          string     // Graph title
 	IncludeEdges   []EdgeType // Only include these edge types (nil = all)
 	ExcludeEdges   []EdgeType // Exclude these edge types
 	HighlightUnits []string   // Units to highlight
-	HighlightCycle bool       // Highlight units in cycles
+	HighlightCycle bool       // Highlight units in cycles and the edges of their shortest path
 	ShowMissing    bool       // Show missing units (dangling refs)
+	HideImplicit   bool       // Leave out the dependencies systemd adds implicitly
 	Clustered      bool       // Group by unit type
 }
 
 // DefaultDOTOptions returns sensible defaults for DOT output.
 func DefaultDOTOptions() DOTOptions {
 	return DOTOptions{
-		Title:          "Systemd Unit Dependencies",
-		ShowMissing:    true,
-		Clustered:      false,
-		HighlightCycle: true,
+		Title:       "Systemd Unit Dependencies",
+		ShowMissing: true,
+		Clustered:   false,
 	}
 }
 
@@ -42,73 +43,26 @@ func (g *Graph) ToDOT(opts DOTOptions) string {
 	sb.WriteString("  node [shape=box, style=filled, fillcolor=white];\n")
 	sb.WriteString("\n")
 
-	// Find units in cycles for highlighting
-	cycleUnits := make(map[string]bool)
-	if opts.HighlightCycle {
-		for _, cycle := range g.FindCycles() {
-			for _, unit := range cycle.Units {
-				cycleUnits[unit] = true
-			}
-		}
-	}
-
-	// Find missing units
-	missingUnits := make(map[string]bool)
-	for _, edge := range g.allEdges {
-		if _, exists := g.units[edge.To]; !exists {
-			missingUnits[edge.To] = true
-		}
-	}
-
-	// Build highlight set
-	highlightSet := make(map[string]bool)
-	for _, u := range opts.HighlightUnits {
-		highlightSet[u] = true
-	}
-
-	// Build include/exclude sets
-	includeSet := make(map[EdgeType]bool)
-	for _, et := range opts.IncludeEdges {
-		includeSet[et] = true
-	}
-	excludeSet := make(map[EdgeType]bool)
-	for _, et := range opts.ExcludeEdges {
-		excludeSet[et] = true
-	}
+	view := g.view(opts)
 
 	// Output nodes
 	if opts.Clustered {
-		g.writeDOTClustered(&sb, cycleUnits, missingUnits, highlightSet, opts.ShowMissing)
+		g.writeDOTClustered(&sb, view)
 	} else {
-		g.writeDOTNodes(&sb, cycleUnits, missingUnits, highlightSet, opts.ShowMissing)
+		g.writeDOTNodes(&sb, view)
 	}
 
 	// Output edges
 	sb.WriteString("\n  // Edges\n")
-	edgesSorted := make([]Edge, len(g.allEdges))
-	copy(edgesSorted, g.allEdges)
-	sort.Slice(edgesSorted, func(i, j int) bool {
-		if edgesSorted[i].From != edgesSorted[j].From {
-			return edgesSorted[i].From < edgesSorted[j].From
-		}
-		return edgesSorted[i].To < edgesSorted[j].To
-	})
-
-	for _, edge := range edgesSorted {
-		// Filter by include/exclude
-		if len(includeSet) > 0 && !includeSet[edge.Type] {
-			continue
-		}
-		if excludeSet[edge.Type] {
-			continue
+	for _, edge := range view.edges {
+		style := edgeStyle(edge.Type)
+		if edge.Implicit {
+			style += ", style=dotted"
 		}
-
-		// Skip edges to missing units if not showing missing
-		if !opts.ShowMissing && missingUnits[edge.To] {
-			continue
+		if view.cycleEdges[edge] {
+			// Later attributes win, so the cycle path is drawn in red
+			style += ", color=red, penwidth=3"
 		}
-
-		style := edgeStyle(edge.Type)
 		fmt.Fprintf(&sb, "  %q -> %q [%s];\n", edge.From, edge.To, style)
 	}
 
@@ -117,48 +71,27 @@ func (g *Graph) ToDOT(opts DOTOptions) string {
 }
 
 // writeDOTNodes writes node definitions without clustering.
-func (g *Graph) writeDOTNodes(sb *strings.Builder, cycleUnits, missingUnits, highlightSet map[string]bool, showMissing bool) {
+func (g *Graph) writeDOTNodes(sb *strings.Builder, view exportView) {
 	sb.WriteString("  // Units\n")
 
-	// Collect all nodes
-	nodes := make([]string, 0, len(g.nodeIDs))
-	for name := range g.nodeIDs {
-		nodes = append(nodes, name)
-	}
-	sort.Strings(nodes)
-
-	for _, name := range nodes {
-		isMissing := missingUnits[name]
-		if isMissing && !showMissing {
-			continue
-		}
-
-		attrs := nodeAttributes(name, g.units[name], cycleUnits[name], isMissing, highlightSet[name])
+	for _, name := range view.nodes {
+		attrs := nodeAttributes(name, g.units[name], view.inCycle[name], view.missing[name], view.highlighted[name])
 		fmt.Fprintf(sb, "  %q [%s];\n", name, attrs)
 	}
 }
 
 // writeDOTClustered writes node definitions grouped by unit type.
-func (g *Graph) writeDOTClustered(sb *strings.Builder, cycleUnits, missingUnits, highlightSet map[string]bool, showMissing bool) {
-	// Group units by type
+func (g *Graph) writeDOTClustered(sb *strings.Builder, view exportView) {
+	// Group units by type, and missing units in a separate group
 	byType := make(map[string][]string)
-	for name, unit := range g.units {
-		if unit != nil {
+	for _, name := range view.nodes {
+		if unit := g.units[name]; unit != nil {
 			byType[unit.Type] = append(byType[unit.Type], name)
+		} else if view.missing[name] {
+			byType["missing"] = append(byType["missing"], name)
 		}
 	}
 
-	// Add missing units to a separate group
-	var missing []string
-	for name := range missingUnits {
-		if showMissing {
-			missing = append(missing, name)
-		}
-	}
-	if len(missing) > 0 {
-		byType["missing"] = missing
-	}
-
 	// Sort types
 	types := make([]string, 0, len(byType))
 	for t := range byType {
@@ -168,7 +101,6 @@ func (g *Graph) writeDOTClustered(sb *strings.Builder, cycleUnits, missingUnits,
 
 	for _, unitType := range types {
 		units := byType[unitType]
-		sort.Strings(units)
 
 		fmt.Fprintf(sb, "  subgraph cluster_%s {\n", unitType)
 		fmt.Fprintf(sb, "    label=%q;\n", unitType)
@@ -179,8 +111,7 @@ func (g *Graph) writeDOTClustered(sb *strings.Builder, cycleUnits, missingUnits,
 		}
 
 		for _, name := range units {
-			isMissing := missingUnits[name]
-			attrs := nodeAttributes(name, g.units[name], cycleUnits[name], isMissing, highlightSet[name])
+			attrs := nodeAttributes(name, g.units[name], view.inCycle[name], view.missing[name], view.highlighted[name])
 			fmt.Fprintf(sb, "    %q [%s];\n", name, attrs)
 		}
 
@@ -245,6 +176,10 @@ func edgeStyle(et EdgeType) string {
 		return "color=green, style=dashed, label=ReloadPropagatedFrom"
 	case EdgeTriggeredBy:
 		return "color=cyan, label=TriggeredBy"
+	case EdgeOnFailure:
+		return "color=red, style=dotted, label=OnFailure"
+	case EdgeOnSuccess:
+		return "color=darkgreen, style=dotted, label=OnSuccess"
 	default:
 		return ""
 	}
@@ -252,6 +187,12 @@ func edgeStyle(et EdgeType) string {
 
 // ToDOTFiltered exports a subgraph containing only the specified units and their direct dependencies.
 func (g *Graph) ToDOTFiltered(units []string, opts DOTOptions) string {
+	return g.Neighborhood(units).ToDOT(opts)
+}
+
+// Neighborhood returns the subgraph of the specified units, their direct
+// dependencies and their direct dependents.
+func (g *Graph) Neighborhood(units []string) *Graph {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
@@ -271,7 +212,15 @@ func (g *Graph) ToDOTFiltered(units []string, opts DOTOptions) string {
 
 	// Create a filtered graph
 	filtered := New()
+	for name := range g.synchronization {
+		filtered.synchronization[name] = true
+	}
+	names := make([]string, 0, len(includeUnits))
 	for name := range includeUnits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
 		if unit, ok := g.units[name]; ok && unit != nil {
 			filtered.AddUnit(unit)
 		}
@@ -283,5 +232,5 @@ func (g *Graph) ToDOTFiltered(units []string, opts DOTOptions) string {
 		}
 	}
 
-	return filtered.ToDOT(opts)
+	return filtered
 }
@@ -0,0 +1,187 @@
+package graph
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// exportView is the part of the graph selected by export options, shared by
+// the DOT, JSON and Mermaid exporters
+type exportView struct {
+	nodes       []string // sorted, without missing units unless ShowMissing is set
+	edges       []Edge   // sorted by source, target and type
+	inCycle     map[string]bool
+	cycleEdges  map[Edge]bool // edges on the shortest path of a cycle
+	missing     map[string]bool
+	highlighted map[string]bool
+}
+
+// view selects the nodes and edges to export. The caller must hold g.mu.
+func (g *Graph) view(opts DOTOptions) exportView {
+	v := exportView{
+		inCycle:     make(map[string]bool),
+		cycleEdges:  make(map[Edge]bool),
+		missing:     make(map[string]bool),
+		highlighted: make(map[string]bool),
+	}
+
+	// Find units in cycles for highlighting
+	if opts.HighlightCycle {
+		for _, cycle := range g.FindCycles() {
+			for _, unit := range cycle.Units {
+				v.inCycle[unit] = true
+			}
+			for _, step := range cycle.Path {
+				for _, edge := range step.Edges {
+					v.cycleEdges[edge] = true
+				}
+			}
+		}
+	}
+
+	// Find missing units, and the ones only implicit dependencies name,
+	// such as sysinit.target, that HideImplicit leaves out
+	external := make(map[string]bool)
+	declared := make(map[string]bool)
+	for _, edge := range g.allEdges {
+		if _, exists := g.units[edge.To]; !exists {
+			v.missing[edge.To] = true
+			if edge.Implicit && edge.Type != EdgeTriggeredBy {
+				external[edge.To] = true
+			} else {
+				declared[edge.To] = true
+			}
+		}
+	}
+	for name := range declared {
+		delete(external, name)
+	}
+
+	for _, u := range opts.HighlightUnits {
+		v.highlighted[u] = true
+	}
+
+	for name := range g.nodeIDs {
+		if (external[name] && opts.HideImplicit) || (v.missing[name] && !opts.ShowMissing) {
+			continue
+		}
+		v.nodes = append(v.nodes, name)
+	}
+	sort.Strings(v.nodes)
+
+	// Build include/exclude sets
+	includeSet := make(map[EdgeType]bool)
+	for _, et := range opts.IncludeEdges {
+		includeSet[et] = true
+	}
+	excludeSet := make(map[EdgeType]bool)
+	for _, et := range opts.ExcludeEdges {
+		excludeSet[et] = true
+	}
+
+	for _, edge := range g.allEdges {
+		if len(includeSet) > 0 && !includeSet[edge.Type] {
+			continue
+		}
+		if excludeSet[edge.Type] || (opts.HideImplicit && edge.Implicit) {
+			continue
+		}
+		// Skip edges to missing units if not showing missing
+		if !opts.ShowMissing && (v.missing[edge.From] || v.missing[edge.To]) {
+			continue
+		}
+		v.edges = append(v.edges, edge)
+	}
+	sort.SliceStable(v.edges, func(i, j int) bool {
+		if v.edges[i].From != v.edges[j].From {
+			return v.edges[i].From < v.edges[j].From
+		}
+		if v.edges[i].To != v.edges[j].To {
+			return v.edges[i].To < v.edges[j].To
+		}
+		return v.edges[i].Type < v.edges[j].Type
+	})
+
+	return v
+}
+
+// JSONSchemaVersion is the version of the JSON export schema. It changes
+// only when fields are renamed or removed.
+const JSONSchemaVersion = 1
+
+// JSONGraph is the JSON export of a graph
+type JSONGraph struct {
+	SchemaVersion int        `json:"schema_version"`
+	Title         string     `json:"title,omitempty"`
+	Nodes         []JSONNode `json:"nodes"`
+	Edges         []JSONEdge `json:"edges"`
+}
+
+// JSONNode is a unit in the JSON export. Missing units are referenced but
+// have no unit file.
+type JSONNode struct {
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Masked  bool   `json:"masked"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// JSONEdge is an edge in the JSON export
+type JSONEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Type     string `json:"type"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Implicit bool   `json:"implicit"`
+	Cycle    bool   `json:"cycle,omitempty"` // On the shortest path of a cycle
+}
+
+// exportNode describes a node for the structured exporters
+func (g *Graph) exportNode(name string, view exportView) JSONNode {
+	n := JSONNode{Name: name, Missing: view.missing[name]}
+	if unit := g.units[name]; unit != nil {
+		n.Type = unit.Type
+		n.Path = unit.Path
+		n.Masked = unit.Masked
+	}
+	return n
+}
+
+// ToJSON exports the graph as indented JSON with nodes sorted by name and
+// edges by source, target and type.
+func (g *Graph) ToJSON(opts DOTOptions) ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	view := g.view(opts)
+	out := JSONGraph{
+		SchemaVersion: JSONSchemaVersion,
+		Title:         opts.Title,
+		Nodes:         make([]JSONNode, 0, len(view.nodes)),
+		Edges:         make([]JSONEdge, 0, len(view.edges)),
+	}
+
+	for _, name := range view.nodes {
+		out.Nodes = append(out.Nodes, g.exportNode(name, view))
+	}
+
+	for _, edge := range view.edges {
+		out.Edges = append(out.Edges, JSONEdge{
+			From:     edge.From,
+			To:       edge.To,
+			Type:     edge.Type.String(),
+			File:     edge.File,
+			Line:     edge.Line,
+			Implicit: edge.Implicit,
+			Cycle:    view.cycleEdges[edge],
+		})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
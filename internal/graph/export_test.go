@@ -0,0 +1,280 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestToMermaid(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/dangling_requires"))
+
+	opts := DefaultDOTOptions()
+	opts.HideImplicit = true
+	out := g.ToMermaid(opts)
+
+	for _, want := range []string{
+		"title: Systemd Unit Dependencies",
+		"flowchart LR",
+		`n0["app.service"]:::service`,
+		`n1["missing-db.service"]:::missing`,
+		"n0 ==>|Requires| n1",
+		"n0 -.->|After| n1",
+		"linkStyle 0 stroke:blue,stroke-width:2px",
+		"linkStyle 1 stroke:gray",
+		"classDef missing",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, out)
+		}
+	}
+
+	opts.ShowMissing = false
+	if out := g.ToMermaid(opts); strings.Contains(out, "missing-db.service") {
+		t.Errorf("Mermaid output shows missing unit with ShowMissing unset:\n%s", out)
+	}
+}
+
+func TestExportCyclePath(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/cycle_path"))
+	opts := DefaultDOTOptions()
+
+	if dot := g.ToDOT(opts); strings.Contains(dot, "color=red, penwidth=3") {
+		t.Errorf("DOT output draws the cycle path in red without HighlightCycle:\n%s", dot)
+	}
+
+	opts.HighlightCycle = true
+	dot := g.ToDOT(opts)
+	if !strings.Contains(dot, `"c.service" -> "b.service" [color=blue, style=dashed, label=Wants, color=red, penwidth=3]`) {
+		t.Errorf("DOT output does not draw the cycle path in red:\n%s", dot)
+	}
+	if strings.Contains(dot, `"c.service" -> "d.service" [color=blue, penwidth=2, label=Requires, color=red`) {
+		t.Errorf("DOT output draws an edge off the shortest path in red:\n%s", dot)
+	}
+
+	data, err := g.ToJSON(opts)
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	var out JSONGraph
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("failed to decode JSON: %v", err)
+	}
+	var onPath []string
+	for _, e := range out.Edges {
+		if e.Cycle {
+			onPath = append(onPath, e.From+" "+e.Type+" "+e.To)
+		}
+	}
+	if got := strings.Join(onPath, ", "); got != "b.service Requires c.service, c.service Wants b.service" {
+		t.Errorf("cycle edges = %s", got)
+	}
+}
+
+func TestToMermaidClustered(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/cycle_simple"))
+
+	opts := DefaultDOTOptions()
+	opts.Clustered = true
+	opts.HighlightCycle = true
+	opts.IncludeEdges = []EdgeType{EdgeAfter}
+	out := g.ToMermaid(opts)
+
+	if !strings.Contains(out, "subgraph cluster_service [service]") {
+		t.Errorf("clustered output has no service subgraph:\n%s", out)
+	}
+	if !strings.Contains(out, `n0["a.service"]:::cycle`) {
+		t.Errorf("cycle units not highlighted:\n%s", out)
+	}
+	if strings.Contains(out, "|Requires|") {
+		t.Errorf("Requires edges not filtered out:\n%s", out)
+	}
+}
+
+// edgeKeys lists edges in a comparable form, sorted
+func edgeKeys(edges []Edge) []string {
+	keys := make([]string, 0, len(edges))
+	for _, e := range edges {
+		keys = append(keys, fmt.Sprintf("%s %s %s %s:%d %v", e.From, e.To, e.Type, e.File, e.Line, e.Implicit))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestToJSONRoundTrip(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/graph/dangling_requires")
+	for _, u := range loadTestUnits(t, "../../testdata/graph/linear_chain") {
+		units[u.Name] = u
+	}
+	units["s1.service"].Masked = true
+	g := Build(units)
+	g.AddEdge(Edge{From: "s3.service", To: "sysinit.target", Type: EdgeAfter, Implicit: true})
+
+	data, err := g.ToJSON(DefaultDOTOptions())
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	again, _ := g.ToJSON(DefaultDOTOptions())
+	if string(data) != string(again) {
+		t.Error("ToJSON() output is not deterministic")
+	}
+
+	var out JSONGraph
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if out.SchemaVersion != JSONSchemaVersion {
+		t.Errorf("schema_version = %d, want %d", out.SchemaVersion, JSONSchemaVersion)
+	}
+
+	var names []string
+	for _, n := range out.Nodes {
+		names = append(names, n.Name)
+		unit := g.Unit(n.Name)
+		if unit == nil {
+			if !n.Missing {
+				t.Errorf("%s has no unit but is not marked missing", n.Name)
+			}
+			continue
+		}
+		if n.Type != unit.Type || n.Path != unit.Path || n.Masked != unit.Masked || n.Missing {
+			t.Errorf("node %+v does not match unit %s (%s, %s, masked %v)", n, unit.Name, unit.Type, unit.Path, unit.Masked)
+		}
+	}
+	if strings.Join(names, ",") != strings.Join(g.NodeNames(), ",") {
+		t.Errorf("nodes = %v, want %v", names, g.NodeNames())
+	}
+
+	var edges []Edge
+	for _, e := range out.Edges {
+		et, ok := ParseEdgeType(e.Type)
+		if !ok {
+			t.Fatalf("unknown edge type %q", e.Type)
+		}
+		edges = append(edges, Edge{From: e.From, To: e.To, Type: et, File: e.File, Line: e.Line, Implicit: e.Implicit})
+	}
+	if got, want := edgeKeys(edges), edgeKeys(g.Edges()); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+}
+
+func TestToJSONFiltered(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/dangling_requires"))
+
+	opts := DefaultDOTOptions()
+	opts.IncludeEdges = []EdgeType{EdgeRequires}
+	opts.ShowMissing = false
+	data, err := g.ToJSON(opts)
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+
+	var out JSONGraph
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(out.Nodes) != 1 || len(out.Edges) != 0 {
+		t.Errorf("expected only app.service without edges, got %+v", out)
+	}
+}
+
+func TestToGraphMLRoundTrip(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/dangling_requires"))
+
+	data, err := g.ToGraphML(DefaultDOTOptions())
+	if err != nil {
+		t.Fatalf("ToGraphML() error = %v", err)
+	}
+	if !strings.HasPrefix(string(data), "<?xml") {
+		t.Errorf("GraphML output has no XML header")
+	}
+
+	var doc graphML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid GraphML: %v", err)
+	}
+	if doc.Graph.EdgeDefault != "directed" {
+		t.Errorf("edgedefault = %q, want directed", doc.Graph.EdgeDefault)
+	}
+
+	var names []string
+	for _, n := range doc.Graph.Nodes {
+		names = append(names, n.ID)
+	}
+	if strings.Join(names, ",") != strings.Join(g.NodeNames(), ",") {
+		t.Errorf("nodes = %v, want %v", names, g.NodeNames())
+	}
+
+	var edges []Edge
+	for _, e := range doc.Graph.Edges {
+		edge := Edge{From: e.Source, To: e.Target}
+		for _, d := range e.Data {
+			switch d.Key {
+			case "edge_type":
+				edge.Type, _ = ParseEdgeType(d.Value)
+			case "file":
+				edge.File = d.Value
+			case "line":
+				edge.Line, _ = strconv.Atoi(d.Value)
+			case "implicit":
+				edge.Implicit, _ = strconv.ParseBool(d.Value)
+			}
+		}
+		edges = append(edges, edge)
+	}
+	if got, want := edgeKeys(edges), edgeKeys(g.Edges()); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("edges = %v, want %v", got, want)
+	}
+}
+
+func TestNeighborhood(t *testing.T) {
+	g := BuildWithOptions(loadTestUnits(t, "../../testdata/graph/linear_chain"), BuilderOptions{})
+
+	sub := g.Neighborhood([]string{"s1.service"})
+
+	names := sub.NodeNames()
+	if strings.Join(names, ",") != "s1.service,s2.service" {
+		t.Errorf("neighborhood of s1.service = %v, want s1 and s2", names)
+	}
+	for _, e := range sub.Edges() {
+		if e.From == "s3.service" || e.To == "s3.service" {
+			t.Errorf("unexpected edge %+v", e)
+		}
+	}
+}
+
+func TestParseEdgeType(t *testing.T) {
+	for _, name := range []string{"Requires", "requires", "BINDSTO", "After"} {
+		if _, ok := ParseEdgeType(name); !ok {
+			t.Errorf("ParseEdgeType(%q) not recognized", name)
+		}
+	}
+	if et, _ := ParseEdgeType("bindsto"); et != EdgeBindsTo {
+		t.Errorf("ParseEdgeType(bindsto) = %v", et)
+	}
+	if _, ok := ParseEdgeType("Depends"); ok {
+		t.Error("ParseEdgeType(Depends) recognized")
+	}
+}
+
+func TestEdgeTypeText(t *testing.T) {
+	data, err := json.Marshal(EdgeBindsTo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"BindsTo"` {
+		t.Errorf("marshaled BindsTo as %s", data)
+	}
+
+	var et EdgeType
+	if err := json.Unmarshal(data, &et); err != nil || et != EdgeBindsTo {
+		t.Errorf("unmarshaled %s as %v (%v)", data, et, err)
+	}
+	if err := json.Unmarshal([]byte(`"Depends"`), &et); err == nil {
+		t.Error("expected an error for an unknown edge type")
+	}
+}
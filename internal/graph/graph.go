@@ -2,9 +2,12 @@
 package graph
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 	"sync"
 
+	"github.com/supabase/sdaudit/internal/unitname"
 	"github.com/supabase/sdaudit/pkg/types"
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/multi"
@@ -34,6 +37,10 @@ const (
 
 	// Trigger edges
 	EdgeTriggeredBy // Activated by another unit (e.g., socket activation)
+
+	// Handler edges - started when the unit fails or succeeds
+	EdgeOnFailure // Started when the unit enters the failed state
+	EdgeOnSuccess // Started when the unit becomes inactive after success
 )
 
 // String returns the string representation of an edge type.
@@ -61,6 +68,10 @@ func (e EdgeType) String() string {
 		return "ReloadPropagatedFrom"
 	case EdgeTriggeredBy:
 		return "TriggeredBy"
+	case EdgeOnFailure:
+		return "OnFailure"
+	case EdgeOnSuccess:
+		return "OnSuccess"
 	default:
 		return "Unknown"
 	}
@@ -79,6 +90,33 @@ var DirectiveToEdgeType = map[string]EdgeType{
 	"PropagatesReloadTo":   EdgePropagatesReloadTo,
 	"ReloadPropagatedFrom": EdgeReloadPropagatedFrom,
 	"TriggeredBy":          EdgeTriggeredBy,
+	"OnFailure":            EdgeOnFailure,
+	"OnSuccess":            EdgeOnSuccess,
+}
+
+// ParseEdgeType returns the edge type for a directive name, ignoring case.
+func ParseEdgeType(name string) (EdgeType, bool) {
+	for directive, et := range DirectiveToEdgeType {
+		if strings.EqualFold(directive, name) {
+			return et, true
+		}
+	}
+	return 0, false
+}
+
+// MarshalText encodes an edge type as its directive name.
+func (e EdgeType) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText decodes an edge type from its directive name.
+func (e *EdgeType) UnmarshalText(text []byte) error {
+	et, ok := ParseEdgeType(string(text))
+	if !ok {
+		return fmt.Errorf("unknown edge type %q", text)
+	}
+	*e = et
+	return nil
 }
 
 // IsRequirementEdge returns true if the edge type represents a requirement dependency.
@@ -91,6 +129,12 @@ func (e EdgeType) IsOrderingEdge() bool {
 	return e == EdgeAfter || e == EdgeBefore
 }
 
+// IsHandlerEdge returns true if the edge type starts its target when the
+// source fails or succeeds.
+func (e EdgeType) IsHandlerEdge() bool {
+	return e == EdgeOnFailure || e == EdgeOnSuccess
+}
+
 // PropagatesStartFailure returns true if start failure propagates through this edge.
 func (e EdgeType) PropagatesStartFailure() bool {
 	return e == EdgeRequires || e == EdgeRequisite || e == EdgeBindsTo
@@ -151,13 +195,23 @@ type Graph struct {
 	outgoing map[string][]Edge // unit -> edges from unit
 	incoming map[string][]Edge // unit -> edges to unit
 
+	aliases map[string]string // alias -> unit it names
+
+	// synchronization holds the units After= needs no requirement on
+	synchronization map[string]bool
+
 	nextNodeID int64
 	nextEdgeID int64
+
+	// version counts the changes to the graph, and memo holds the results
+	// of analyses for the current version
+	version uint64
+	memo    memo
 }
 
 // New creates a new empty Graph.
 func New() *Graph {
-	return &Graph{
+	g := &Graph{
 		g:        multi.NewDirectedGraph(),
 		units:    make(map[string]*types.UnitFile),
 		nodeIDs:  make(map[string]int64),
@@ -166,7 +220,14 @@ func New() *Graph {
 		allEdges: make([]Edge, 0),
 		outgoing: make(map[string][]Edge),
 		incoming: make(map[string][]Edge),
+		aliases:  make(map[string]string),
+
+		synchronization: make(map[string]bool),
+	}
+	for _, name := range DefaultSynchronizationUnits {
+		g.synchronization[name] = true
 	}
+	return g
 }
 
 // AddUnit adds a unit to the graph.
@@ -179,6 +240,11 @@ func (g *Graph) AddUnit(unit *types.UnitFile) {
 	}
 
 	g.units[unit.Name] = unit
+	g.changed()
+	// A placeholder node created for a dangling reference becomes this unit
+	if _, exists := g.nodeIDs[unit.Name]; exists {
+		return
+	}
 	id := g.nextNodeID
 	g.nextNodeID++
 	g.nodeIDs[unit.Name] = id
@@ -186,11 +252,17 @@ func (g *Graph) AddUnit(unit *types.UnitFile) {
 	g.g.AddNode(unitNode{id: id, name: unit.Name})
 }
 
-// AddEdge adds a typed edge between two units.
+// AddEdge adds a typed edge between two units. Escapes in their names are
+// spelled as in the names of unit files, so that references written either
+// way meet.
 func (g *Graph) AddEdge(edge Edge) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	edge.From = unitname.Normalize(edge.From)
+	edge.To = unitname.Normalize(edge.To)
+	g.changed()
+
 	// Ensure both nodes exist (create placeholder if needed for dangling refs)
 	if _, exists := g.nodeIDs[edge.From]; !exists {
 		id := g.nextNodeID
@@ -223,6 +295,54 @@ func (g *Graph) AddEdge(edge Edge) {
 	g.incoming[edge.To] = append(g.incoming[edge.To], edge)
 }
 
+// reversesBefore reports whether an edge is the After= that the builder adds
+// for a Before= of its target. Analyses that read Before= themselves skip it.
+// The caller must hold g.mu.
+func (g *Graph) reversesBefore(e Edge) bool {
+	if e.Type != EdgeAfter || !e.Implicit {
+		return false
+	}
+	for _, before := range g.outgoing[e.To] {
+		if before.Type == EdgeBefore && before.To == e.From && before.File == e.File && before.Line == e.Line {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAlias records another name of a unit, from [Install] Alias= or a
+// symlink. Dependencies on the alias are dependencies on the unit.
+func (g *Graph) AddAlias(alias, unit string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	alias, unit = unitname.Normalize(alias), unitname.Normalize(unit)
+	if alias != unit {
+		g.aliases[alias] = unit
+		g.changed()
+	}
+}
+
+// Resolve returns the unit an alias names, or name itself
+func (g *Graph) Resolve(name string) string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.resolve(name)
+}
+
+// resolve follows aliases, stopping at loops. The caller must hold g.mu.
+func (g *Graph) resolve(name string) string {
+	name = unitname.Normalize(name)
+	for i := 0; i < len(g.aliases); i++ {
+		unit, ok := g.aliases[name]
+		if !ok {
+			break
+		}
+		name = unit
+	}
+	return name
+}
+
 // Units returns all units in the graph (sorted by name for determinism).
 func (g *Graph) Units() []*types.UnitFile {
 	g.mu.RLock()
@@ -245,14 +365,14 @@ func (g *Graph) Units() []*types.UnitFile {
 func (g *Graph) Unit(name string) *types.UnitFile {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
-	return g.units[name]
+	return g.units[unitname.Normalize(name)]
 }
 
 // HasUnit returns true if the unit exists in the graph.
 func (g *Graph) HasUnit(name string) bool {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
-	_, exists := g.units[name]
+	_, exists := g.units[unitname.Normalize(name)]
 	return exists
 }
 
@@ -284,6 +404,7 @@ func (g *Graph) EdgesFrom(unit string) []Edge {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
+	unit = unitname.Normalize(unit)
 	edges := make([]Edge, len(g.outgoing[unit]))
 	copy(edges, g.outgoing[unit])
 	return edges
@@ -294,6 +415,7 @@ func (g *Graph) EdgesTo(unit string) []Edge {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
+	unit = unitname.Normalize(unit)
 	edges := make([]Edge, len(g.incoming[unit]))
 	copy(edges, g.incoming[unit])
 	return edges
@@ -322,7 +444,7 @@ func (g *Graph) GoNumGraph() *multi.DirectedGraph {
 func (g *Graph) NodeID(name string) (int64, bool) {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
-	id, ok := g.nodeIDs[name]
+	id, ok := g.nodeIDs[unitname.Normalize(name)]
 	return id, ok
 }
 
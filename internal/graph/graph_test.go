@@ -4,7 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
-	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/unitfile"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -14,7 +14,7 @@ func loadTestUnits(t *testing.T, path string) map[string]*types.UnitFile {
 	if err != nil {
 		t.Fatalf("failed to get absolute path: %v", err)
 	}
-	units, err := analyzer.LoadUnitsFromDirectory(absPath)
+	units, err := unitfile.LoadDirectory(absPath)
 	if err != nil {
 		t.Fatalf("failed to load units from %s: %v", path, err)
 	}
@@ -38,12 +38,12 @@ func TestBuildGraph(t *testing.T) {
 	// Check that edges were created
 	requiresCount := 0
 	for _, e := range edges {
-		if e.Type == EdgeRequires {
+		if e.Type == EdgeRequires && !e.Implicit {
 			requiresCount++
 		}
 	}
 	if requiresCount != 3 {
-		t.Errorf("expected 3 Requires edges, got %d", requiresCount)
+		t.Errorf("expected 3 declared Requires edges, got %d", requiresCount)
 	}
 }
 
@@ -68,6 +68,58 @@ func TestGraphEdgesFrom(t *testing.T) {
 	}
 }
 
+func TestAddUnitReplacesPlaceholder(t *testing.T) {
+	g := New()
+	g.AddUnit(&types.UnitFile{Name: "a.service", Type: "service"})
+	g.AddEdge(Edge{From: "a.service", To: "b.service", Type: EdgeRequires})
+
+	if len(g.FindDanglingRefs()) != 1 {
+		t.Fatalf("expected b.service to be dangling before it is added")
+	}
+
+	g.AddUnit(&types.UnitFile{Name: "b.service", Type: "service"})
+
+	if len(g.FindDanglingRefs()) != 0 {
+		t.Errorf("expected no dangling refs after adding b.service")
+	}
+	if len(g.NodeNames()) != 2 {
+		t.Errorf("expected 2 nodes, got %v", g.NodeNames())
+	}
+	if len(g.TransitiveDependencies("a.service")) != 1 {
+		t.Errorf("expected a.service to still reach b.service")
+	}
+}
+
+func TestEscapedReferences(t *testing.T) {
+	crypt, err := unitfile.ParseContent("/run/systemd/generator/dev-mapper-crypt\\x2Droot.device", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	units := map[string]*types.UnitFile{crypt.Name: crypt}
+	for name, content := range map[string]string{
+		"home.mount":    "[Unit]\nRequires=dev-mapper-crypt\\x2droot.device\n",
+		"backup.mount":  "[Unit]\nBindsTo=dev-mapper-crypt\\x2Droot.device\n",
+		"restore.mount": "[Unit]\nAfter=dev-mapper-crypt\\x2droot.device home.mount\n",
+	} {
+		unit, err := unitfile.ParseContent("/etc/systemd/system/"+name, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		units[name] = unit
+	}
+
+	g := Build(units)
+	if refs := g.FindDanglingRefs(); len(refs) != 0 {
+		t.Errorf("dangling refs = %v, want none", refs)
+	}
+	if !g.HasUnit("dev-mapper-crypt\\x2Droot.device") {
+		t.Error("the device is not found by an upper-case escape")
+	}
+	if got := len(g.EdgesTo("dev-mapper-crypt\\x2droot.device")); got != 3 {
+		t.Errorf("edges to the device = %d, want 3", got)
+	}
+}
+
 func TestGraphEdgesTo(t *testing.T) {
 	units := loadTestUnits(t, "../../testdata/graph/cycle_simple")
 	g := Build(units)
@@ -101,6 +153,8 @@ func TestEdgeTypeStrings(t *testing.T) {
 		{EdgeBefore, "Before"},
 		{EdgeConflicts, "Conflicts"},
 		{EdgePartOf, "PartOf"},
+		{EdgeOnFailure, "OnFailure"},
+		{EdgeOnSuccess, "OnSuccess"},
 	}
 
 	for _, tt := range tests {
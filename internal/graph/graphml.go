@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// GraphML document structure, as read by Gephi, yEd and networkx
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLKeys declares the attributes of the GraphML export
+var graphMLKeys = []graphMLKey{
+	{ID: "title", For: "graph", AttrName: "title", AttrType: "string"},
+	{ID: "schema_version", For: "graph", AttrName: "schema_version", AttrType: "int"},
+	{ID: "type", For: "node", AttrName: "type", AttrType: "string"},
+	{ID: "path", For: "node", AttrName: "path", AttrType: "string"},
+	{ID: "masked", For: "node", AttrName: "masked", AttrType: "boolean"},
+	{ID: "missing", For: "node", AttrName: "missing", AttrType: "boolean"},
+	{ID: "edge_type", For: "edge", AttrName: "type", AttrType: "string"},
+	{ID: "file", For: "edge", AttrName: "file", AttrType: "string"},
+	{ID: "line", For: "edge", AttrName: "line", AttrType: "int"},
+	{ID: "implicit", For: "edge", AttrName: "implicit", AttrType: "boolean"},
+}
+
+// ToGraphML exports the graph as GraphML with the same nodes, edges and
+// ordering as the JSON export. Unit names are the node IDs.
+func (g *Graph) ToGraphML(opts DOTOptions) ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	view := g.view(opts)
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLKeys,
+		Graph: graphMLGraph{
+			ID:          "systemd",
+			EdgeDefault: "directed",
+			Data:        []graphMLData{{Key: "schema_version", Value: strconv.Itoa(JSONSchemaVersion)}},
+		},
+	}
+	if opts.Title != "" {
+		doc.Graph.Data = append(doc.Graph.Data, graphMLData{Key: "title", Value: opts.Title})
+	}
+
+	for _, name := range view.nodes {
+		n := g.exportNode(name, view)
+		node := graphMLNode{ID: n.Name}
+		if n.Type != "" {
+			node.Data = append(node.Data, graphMLData{Key: "type", Value: n.Type})
+		}
+		if n.Path != "" {
+			node.Data = append(node.Data, graphMLData{Key: "path", Value: n.Path})
+		}
+		node.Data = append(node.Data,
+			graphMLData{Key: "masked", Value: strconv.FormatBool(n.Masked)},
+			graphMLData{Key: "missing", Value: strconv.FormatBool(n.Missing)},
+		)
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+
+	for i, edge := range view.edges {
+		e := graphMLEdge{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: edge.From,
+			Target: edge.To,
+			Data:   []graphMLData{{Key: "edge_type", Value: edge.Type.String()}},
+		}
+		if edge.File != "" {
+			e.Data = append(e.Data, graphMLData{Key: "file", Value: edge.File})
+		}
+		if edge.Line > 0 {
+			e.Data = append(e.Data, graphMLData{Key: "line", Value: strconv.Itoa(edge.Line)})
+		}
+		e.Data = append(e.Data, graphMLData{Key: "implicit", Value: strconv.FormatBool(edge.Implicit)})
+		doc.Graph.Edges = append(doc.Graph.Edges, e)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(xml.Header), data...), '\n'), nil
+}
@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"path"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/unitname"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// The implicit dependencies systemd adds to units, from systemd.unit(5),
+// systemd.special(7) and the man pages of the unit types. Only the ones that
+// affect start order and failure propagation are modeled: slices, the
+// ordering of targets after the units they want and dependencies on the
+// journal and D-Bus are left out.
+
+// activationTargets are the targets that DefaultDependencies= orders
+// sockets, timers and paths before
+var activationTargets = map[string]string{
+	"socket": "sockets.target",
+	"timer":  "timers.target",
+	"path":   "paths.target",
+}
+
+// execDirectories are the directories that StateDirectory= and the like
+// create their directories in, for system units
+var execDirectories = map[string]string{
+	"RuntimeDirectory":       "/run",
+	"StateDirectory":         "/var/lib",
+	"CacheDirectory":         "/var/cache",
+	"LogsDirectory":          "/var/log",
+	"ConfigurationDirectory": "/etc",
+}
+
+// networkFileSystems are the file system types systemd mounts after the
+// network, as in fstype_is_network()
+var networkFileSystems = map[string]bool{
+	"afs": true, "ceph": true, "cifs": true, "smb3": true, "smbfs": true,
+	"sshfs": true, "ncpfs": true, "ncp": true, "nfs": true, "nfs4": true,
+	"gfs": true, "gfs2": true, "glusterfs": true, "pvfs2": true, "ocfs2": true,
+	"lustre": true, "davfs": true,
+}
+
+// addImplicitEdges adds the dependencies systemd adds to a unit, marked
+// Implicit and without a line:
+//   - with DefaultDependencies=yes, the default, services, sockets, timers and
+//     paths require and start after sysinit.target, services start after
+//     basic.target, sockets, timers and paths before sockets.target,
+//     timers.target and paths.target, and all of them, and targets,
+//     conflict with and stop before shutdown.target. Mounts start after
+//     local-fs-pre.target and before local-fs.target unless nofail, or for
+//     network file systems after the network and before remote-fs.target,
+//     and stop before umount.target.
+//   - sockets start before the service they activate.
+//   - the paths of WorkingDirectory=, RootDirectory=, RootImage= and
+//     StateDirectory= and the like, /tmp and /var/tmp with PrivateTmp=, and
+//     the mount point of a mount unit need the mount units of the path and
+//     its parents that the scan found, as with RequiresMountsFor=.
+//   - mounts of a device bind to and start after its device unit.
+func (b *Builder) addImplicitEdges(unit *types.UnitFile) {
+	add := func(to string, edgeTypes ...EdgeType) {
+		for _, edgeType := range edgeTypes {
+			edge := Edge{From: unit.Name, To: to, Type: edgeType, File: unit.Path, Implicit: true}
+			b.graph.AddEdge(edge)
+			if edgeType == EdgeBefore {
+				b.addReverseAfter(edge)
+			}
+		}
+	}
+
+	if defaultDependencies(unit) {
+		switch unit.Type {
+		case "service", "socket", "timer", "path":
+			add("sysinit.target", EdgeRequires, EdgeAfter)
+			if target, ok := activationTargets[unit.Type]; ok {
+				add(target, EdgeBefore)
+			} else {
+				add("basic.target", EdgeAfter)
+			}
+			add("shutdown.target", EdgeConflicts, EdgeBefore)
+		case "target":
+			add("shutdown.target", EdgeConflicts, EdgeBefore)
+		case "mount":
+			target := "local-fs.target"
+			if isNetworkMount(unit) {
+				add("remote-fs-pre.target", EdgeAfter)
+				add("network.target", EdgeAfter)
+				add("network-online.target", EdgeWants, EdgeAfter)
+				target = "remote-fs.target"
+			} else {
+				add("local-fs-pre.target", EdgeAfter)
+			}
+			if !hasMountOption(unit, "nofail") {
+				add(target, EdgeBefore)
+			}
+			add("umount.target", EdgeConflicts, EdgeBefore)
+		}
+	}
+
+	if unit.Type == "socket" {
+		if service := b.getSocketService(unit); service != "" {
+			add(service, EdgeBefore)
+		}
+	}
+
+	var paths []string
+	if dir := strings.TrimPrefix(unit.GetDirective("Service", "WorkingDirectory"), "-"); dir != "~" {
+		paths = append(paths, dir)
+	}
+	paths = append(paths, unit.GetDirective("Service", "RootDirectory"), unit.GetDirective("Service", "RootImage"))
+	for _, key := range []string{"RuntimeDirectory", "StateDirectory", "CacheDirectory", "LogsDirectory", "ConfigurationDirectory"} {
+		for _, d := range unit.GetDirectives("Service", key) {
+			for _, dir := range splitDirectiveValue(d.Value) {
+				dir, _, _ = strings.Cut(dir, ":")
+				paths = append(paths, path.Join(execDirectories[key], dir))
+			}
+		}
+	}
+	if isTrue(unit.GetDirective("Service", "PrivateTmp")) {
+		paths = append(paths, "/tmp", "/var/tmp")
+	}
+	if unit.Type == "mount" {
+		where := unit.GetDirective("Mount", "Where")
+		if where == "" {
+			where = unitname.UnescapePath(strings.TrimSuffix(unit.Name, ".mount"))
+		}
+		if parent := path.Dir(path.Clean(where)); parent != where {
+			paths = append(paths, parent)
+		}
+		if what := unit.GetDirective("Mount", "What"); strings.HasPrefix(what, "/dev/") {
+			add(unitname.EscapePath(what)+".device", EdgeBindsTo, EdgeAfter)
+		}
+	}
+	for _, p := range paths {
+		b.addMountsFor(unit, p, EdgeRequires, 0)
+	}
+}
+
+// addMountsFor adds a requirement of the given type and ordering on the
+// mount units of a path and its parents that are in the graph, as
+// RequiresMountsFor= does. Paths with specifiers are left out.
+func (b *Builder) addMountsFor(unit *types.UnitFile, p string, edgeType EdgeType, line int) {
+	if !strings.HasPrefix(p, "/") || strings.Contains(p, "%") {
+		return
+	}
+	for _, mount := range MountUnitsFor(p) {
+		if mount == unit.Name || !b.graph.HasUnit(mount) {
+			continue
+		}
+		for _, t := range []EdgeType{edgeType, EdgeAfter} {
+			b.graph.AddEdge(Edge{From: unit.Name, To: mount, Type: t, File: unit.Path, Line: line, Implicit: true})
+		}
+	}
+}
+
+// MountUnitsFor returns the names of the mount units a path may be on, from
+// the root file system, -.mount, to the path itself
+func MountUnitsFor(p string) []string {
+	p = path.Clean(p)
+	names := []string{"-.mount"}
+	for i := 1; i <= len(p); i++ {
+		if i == len(p) || p[i] == '/' {
+			if prefix := p[:i]; prefix != "/" {
+				names = append(names, unitname.EscapePath(prefix)+".mount")
+			}
+		}
+	}
+	return names
+}
+
+// defaultDependencies reports whether a unit keeps DefaultDependencies=yes
+func defaultDependencies(unit *types.UnitFile) bool {
+	value := unit.GetDirective("Unit", "DefaultDependencies")
+	return value == "" || isTrue(value)
+}
+
+// isNetworkMount reports whether a mount unit mounts a network file system,
+// by its type or the _netdev option
+func isNetworkMount(unit *types.UnitFile) bool {
+	fsType := strings.TrimPrefix(unit.GetDirective("Mount", "Type"), "fuse.")
+	return networkFileSystems[fsType] || hasMountOption(unit, "_netdev")
+}
+
+// hasMountOption reports whether a mount unit has an option in Options=
+func hasMountOption(unit *types.UnitFile, name string) bool {
+	for _, option := range strings.Split(unit.GetDirective("Mount", "Options"), ",") {
+		if strings.TrimSpace(option) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrue reports whether a boolean directive value is true
+func isTrue(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "yes", "y", "true", "t", "on":
+		return true
+	}
+	return false
+}
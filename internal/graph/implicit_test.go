@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// implicitEdges returns the implicit edges from a unit as "Type=To", sorted
+func implicitEdges(g *Graph, unit string) []string {
+	var edges []string
+	for _, e := range g.EdgesFrom(unit) {
+		if e.Implicit {
+			edges = append(edges, fmt.Sprintf("%s=%s", e.Type, e.To))
+		}
+	}
+	sort.Strings(edges)
+	return edges
+}
+
+func TestImplicitDependencies(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/implicit"))
+
+	tests := []struct {
+		unit string
+		want []string
+	}{
+		{"app.service", []string{
+			"After=app.socket", "After=basic.target", "After=srv-data.mount", "After=srv.mount", "After=sysinit.target", "After=tmp.mount",
+			"Before=shutdown.target", "Conflicts=shutdown.target",
+			"Requires=srv-data.mount", "Requires=srv.mount", "Requires=sysinit.target", "Requires=tmp.mount",
+		}},
+		{"app.socket", []string{
+			"Before=app.service", "Before=shutdown.target", "Before=sockets.target",
+			"After=sysinit.target", "Conflicts=shutdown.target", "Requires=sysinit.target", "TriggeredBy=app.service",
+		}},
+		{"early.service", nil},
+		{"srv.mount", []string{
+			"After=network-online.target", "After=network.target", "After=remote-fs-pre.target",
+			"Before=remote-fs.target", "Before=umount.target", "Conflicts=umount.target", "Wants=network-online.target",
+		}},
+		{"srv-data.mount", []string{
+			"After=dev-sdb1.device", "After=local-fs-pre.target", "After=srv.mount",
+			"Before=local-fs.target", "Before=umount.target",
+			"BindsTo=dev-sdb1.device", "Conflicts=umount.target", "Requires=srv.mount",
+		}},
+	}
+	for _, tt := range tests {
+		sort.Strings(tt.want)
+		if got := implicitEdges(g, tt.unit); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("implicit edges of %s = %v, want %v", tt.unit, got, tt.want)
+		}
+	}
+
+	for _, e := range g.EdgesFrom("app.service") {
+		if e.To == "srv-data.mount" && e.Line != 3 {
+			t.Errorf("%s=%s is on line %d, want the RequiresMountsFor= on line 3", e.Type, e.To, e.Line)
+		}
+	}
+	if refs := g.FindDanglingRefs(); len(refs) != 0 {
+		t.Errorf("FindDanglingRefs() = %+v, want the implicit targets left out", refs)
+	}
+	if cycles := g.FindCycles(); len(cycles) != 0 {
+		t.Errorf("FindCycles() = %+v, want none", cycles)
+	}
+}
+
+func TestBuildWithoutImplicitDependencies(t *testing.T) {
+	g := BuildWithOptions(loadTestUnits(t, "../../testdata/graph/implicit"), BuilderOptions{})
+	mounts := 0
+	for _, e := range g.Edges() {
+		switch {
+		case !e.Implicit || e.Type == EdgeTriggeredBy:
+			// Triggers are set by the unit's own [Socket], [Timer] or [Path]
+		case e.From == "app.service" && e.Line == 3:
+			mounts++
+		default:
+			t.Errorf("implicit edge %+v with ImplicitDependencies unset", e)
+		}
+	}
+	// RequiresMountsFor= is declared, so its mounts are still there
+	if mounts != 4 {
+		t.Errorf("%d edges from RequiresMountsFor=, want Requires= and After= on srv.mount and srv-data.mount", mounts)
+	}
+}
+
+func TestMountUnitsFor(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/", []string{"-.mount"}},
+		{"/var/lib/my-app/", []string{"-.mount", "var.mount", "var-lib.mount", `var-lib-my\x2dapp.mount`}},
+	}
+	for _, tt := range tests {
+		if got := MountUnitsFor(tt.path); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("MountUnitsFor(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExportImplicitEdges(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/implicit"))
+
+	opts := DefaultDOTOptions()
+	dot := g.ToDOT(opts)
+	if !strings.Contains(dot, `"app.service" -> "tmp.mount" [color=blue, penwidth=2, label=Requires, style=dotted];`) {
+		t.Errorf("DOT output does not draw the implicit Requires=tmp.mount dotted:\n%s", dot)
+	}
+
+	opts.HideImplicit = true
+	dot = g.ToDOT(opts)
+	for _, unit := range []string{"tmp.mount", "sysinit.target"} {
+		if strings.Contains(dot, `"app.service" -> "`+unit+`"`) {
+			t.Errorf("DOT output with HideImplicit has an edge to %s:\n%s", unit, dot)
+		}
+	}
+	if strings.Contains(dot, `"sysinit.target"`) {
+		t.Errorf("DOT output with HideImplicit has sysinit.target:\n%s", dot)
+	}
+	if !strings.Contains(dot, `"tmp.mount"`) {
+		t.Errorf("DOT output with HideImplicit leaves out the scanned tmp.mount:\n%s", dot)
+	}
+}
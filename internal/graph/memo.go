@@ -0,0 +1,44 @@
+package graph
+
+import "sync"
+
+// memo keeps the results of analyses of the whole graph, such as its cycles,
+// until the graph changes. Analyses run under the read lock of the graph, so
+// the memo has a lock of its own.
+type memo struct {
+	mu      sync.Mutex
+	version uint64 // The version of the graph the values are for
+	values  map[string]any
+}
+
+// changed invalidates the memoized results. The caller must hold g.mu for
+// writing.
+func (g *Graph) changed() {
+	g.version++
+}
+
+// memoized returns the result of an analysis of the graph, computing it once
+// per version of the graph. The caller must hold g.mu.
+func (g *Graph) memoized(key string, compute func() any) any {
+	g.memo.mu.Lock()
+	if g.memo.version == g.version {
+		if value, ok := g.memo.values[key]; ok {
+			g.memo.mu.Unlock()
+			return value
+		}
+	}
+	g.memo.mu.Unlock()
+
+	// Concurrent readers may compute the same value, which is cheaper than
+	// holding the memo lock while computing
+	value := compute()
+
+	g.memo.mu.Lock()
+	defer g.memo.mu.Unlock()
+	if g.memo.version != g.version || g.memo.values == nil {
+		g.memo.version = g.version
+		g.memo.values = make(map[string]any)
+	}
+	g.memo.values[key] = value
+	return value
+}
@@ -0,0 +1,167 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// mermaidEdge describes how an edge type is drawn in Mermaid. Requirement
+// edges are thick, soft and ordering edges dotted, as in the DOT output.
+type mermaidEdge struct {
+	arrow string
+	style string
+}
+
+func mermaidEdgeStyle(et EdgeType) mermaidEdge {
+	switch et {
+	case EdgeRequires:
+		return mermaidEdge{"==>", "stroke:blue,stroke-width:2px"}
+	case EdgeWants:
+		return mermaidEdge{"-.->", "stroke:blue"}
+	case EdgeBindsTo:
+		return mermaidEdge{"==>", "stroke:purple,stroke-width:2px"}
+	case EdgeRequisite:
+		return mermaidEdge{"==>", "stroke:blue,stroke-width:3px"}
+	case EdgeAfter, EdgeBefore:
+		return mermaidEdge{"-.->", "stroke:gray"}
+	case EdgeConflicts:
+		return mermaidEdge{"-.-x", "stroke:red"}
+	case EdgePartOf:
+		return mermaidEdge{"-->", "stroke:orange"}
+	case EdgePropagatesReloadTo, EdgeReloadPropagatedFrom:
+		return mermaidEdge{"-.->", "stroke:green"}
+	case EdgeTriggeredBy:
+		return mermaidEdge{"-->", "stroke:cyan"}
+	case EdgeOnFailure:
+		return mermaidEdge{"-.->", "stroke:red"}
+	case EdgeOnSuccess:
+		return mermaidEdge{"-.->", "stroke:darkgreen"}
+	default:
+		return mermaidEdge{"-->", ""}
+	}
+}
+
+// mermaidClasses are the node styles, matching the DOT fill colors
+var mermaidClasses = []struct {
+	name  string
+	style string
+}{
+	{"service", "fill:#e0e0ff"},
+	{"socket", "fill:#e0ffe0"},
+	{"timer", "fill:#ffe0e0"},
+	{"target", "fill:#f0f0f0"},
+	{"mount", "fill:#fff0e0"},
+	{"path", "fill:#e0f0ff"},
+	{"highlight", "fill:#aaffaa,stroke-width:2px"},
+	{"cycle", "fill:#ffeeaa,stroke:red,stroke-width:2px"},
+	{"missing", "fill:#ffcccc,stroke-dasharray:5 5"},
+}
+
+// ToMermaid exports the graph as a Mermaid flowchart, which GitHub and many
+// CI systems render inline in Markdown.
+func (g *Graph) ToMermaid(opts DOTOptions) string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	view := g.view(opts)
+
+	var sb strings.Builder
+	if opts.Title != "" {
+		fmt.Fprintf(&sb, "---\ntitle: %s\n---\n", mermaidText(opts.Title))
+	}
+	sb.WriteString("flowchart LR\n")
+
+	// Mermaid node IDs cannot hold every character a unit name can
+	ids := make(map[string]string, len(view.nodes))
+	for i, name := range view.nodes {
+		ids[name] = fmt.Sprintf("n%d", i)
+	}
+
+	writeNode := func(indent, name string) {
+		unitType := ""
+		if unit := g.units[name]; unit != nil {
+			unitType = unit.Type
+		}
+		shape := `["%s"]`
+		if unitType == "target" {
+			shape = `(["%s"])`
+		}
+		fmt.Fprintf(&sb, "%s%s"+shape, indent, ids[name], mermaidText(name))
+
+		// Same precedence as the DOT node colors
+		switch {
+		case view.missing[name]:
+			sb.WriteString(":::missing")
+		case view.inCycle[name]:
+			sb.WriteString(":::cycle")
+		case view.highlighted[name]:
+			sb.WriteString(":::highlight")
+		case unitType != "":
+			for _, c := range mermaidClasses {
+				if c.name == unitType {
+					sb.WriteString(":::" + unitType)
+					break
+				}
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if opts.Clustered {
+		byType := make(map[string][]string)
+		for _, name := range view.nodes {
+			group := "missing"
+			if unit := g.units[name]; unit != nil {
+				group = unit.Type
+			}
+			byType[group] = append(byType[group], name)
+		}
+		groups := make([]string, 0, len(byType))
+		for group := range byType {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+
+		for _, group := range groups {
+			fmt.Fprintf(&sb, "  subgraph cluster_%s [%s]\n", group, group)
+			for _, name := range byType[group] {
+				writeNode("    ", name)
+			}
+			sb.WriteString("  end\n")
+		}
+	} else {
+		for _, name := range view.nodes {
+			writeNode("  ", name)
+		}
+	}
+
+	var linkStyles []string
+	for i, edge := range view.edges {
+		style := mermaidEdgeStyle(edge.Type)
+		if edge.Implicit && (style.arrow == "==>" || style.arrow == "-->") {
+			style.arrow = "-.->"
+		}
+		fmt.Fprintf(&sb, "  %s %s|%s| %s\n", ids[edge.From], style.arrow, edge.Type, ids[edge.To])
+		switch {
+		case view.cycleEdges[edge]:
+			linkStyles = append(linkStyles, fmt.Sprintf("  linkStyle %d stroke:red,stroke-width:3px\n", i))
+		case style.style != "":
+			linkStyles = append(linkStyles, fmt.Sprintf("  linkStyle %d %s\n", i, style.style))
+		}
+	}
+	for _, ls := range linkStyles {
+		sb.WriteString(ls)
+	}
+
+	for _, c := range mermaidClasses {
+		fmt.Fprintf(&sb, "  classDef %s %s\n", c.name, c.style)
+	}
+
+	return sb.String()
+}
+
+// mermaidText escapes text for a quoted Mermaid label
+func mermaidText(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
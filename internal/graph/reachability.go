@@ -1,93 +1,93 @@
 package graph
 
 import (
+	"math/bits"
 	"sort"
+	"strconv"
 	"strings"
+
+	"gonum.org/v1/gonum/graph/topo"
 )
 
 // ReachabilityResult contains units categorized by reachability.
 type ReachabilityResult struct {
-	Reachable   []string // Units reachable from targets
-	Unreachable []string // Potentially dead units
-	Targets     []string // .target units used as roots
+	Reachable   []string // Units started at boot
+	Unreachable []string // Units nothing started at boot pulls in
+	Targets     []string // Roots: the default target and enabled timers, sockets and paths
 }
 
-// AnalyzeReachability walks backward from default.target and other targets.
-// Reports units that are never pulled in by any target.
-// This identifies potentially dead or orphaned units.
-func (g *Graph) AnalyzeReachability() ReachabilityResult {
+// onDemandTargets are reached through events or explicit requests rather than
+// at boot, so units wanted by them are not dead. See systemd.special(7).
+var onDemandTargets = map[string]bool{
+	"emergency.target":              true,
+	"rescue.target":                 true,
+	"shutdown.target":               true,
+	"halt.target":                   true,
+	"poweroff.target":               true,
+	"reboot.target":                 true,
+	"kexec.target":                  true,
+	"soft-reboot.target":            true,
+	"exit.target":                   true,
+	"final.target":                  true,
+	"umount.target":                 true,
+	"ctrl-alt-del.target":           true,
+	"sleep.target":                  true,
+	"suspend.target":                true,
+	"hibernate.target":              true,
+	"hybrid-sleep.target":           true,
+	"suspend-then-hibernate.target": true,
+	"system-update.target":          true,
+	"system-update-pre.target":      true,
+	"factory-reset.target":          true,
+	"initrd.target":                 true,
+	"initrd-root-device.target":     true,
+	"initrd-root-fs.target":         true,
+	"initrd-usr-fs.target":          true,
+	"initrd-fs.target":              true,
+	"initrd-switch-root.target":     true,
+	"bluetooth.target":              true,
+	"printer.target":                true,
+	"smartcard.target":              true,
+	"sound.target":                  true,
+	"usb-gadget.target":             true,
+	"tpm2.target":                   true,
+	"network-online.target":         true,
+	"boot-complete.target":          true,
+}
+
+// DefaultTarget returns the unit default.target names. Without
+// default.target it is graphical.target, as in systemd. It returns "" when
+// neither unit exists, such as for a directory of unrelated unit files.
+func (g *Graph) DefaultTarget() string {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
+	return g.defaultTarget()
+}
 
-	// Find all target units to use as roots
-	var targets []string
-	for name, unit := range g.units {
-		if unit != nil && unit.Type == "target" {
-			targets = append(targets, name)
-		}
-	}
-
-	// Also include default.target if it exists as a node but not a unit
-	for name := range g.nodeIDs {
-		if strings.HasSuffix(name, ".target") {
-			found := false
-			for _, t := range targets {
-				if t == name {
-					found = true
-					break
-				}
-			}
-			if !found {
-				targets = append(targets, name)
-			}
+func (g *Graph) defaultTarget() string {
+	for _, name := range []string{"default.target", "graphical.target"} {
+		if _, ok := g.aliases[name]; ok {
+			return g.resolve(name)
 		}
-	}
-
-	sort.Strings(targets)
-
-	// BFS backward from all targets
-	visited := make(map[string]bool)
-	queue := make([]string, 0, len(targets))
-
-	// Start with all targets
-	for _, t := range targets {
-		if !visited[t] {
-			visited[t] = true
-			queue = append(queue, t)
+		if _, ok := g.units[name]; ok {
+			return name
 		}
 	}
+	return ""
+}
 
-	// Walk backward through dependency edges
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		// Find all edges pointing TO this unit (dependencies of this unit)
-		for _, edge := range g.incoming[current] {
-			// Only follow requirement edges backward (these pull units in)
-			if edge.Type.IsRequirementEdge() || edge.Type == EdgeTriggeredBy {
-				if !visited[edge.From] {
-					visited[edge.From] = true
-					queue = append(queue, edge.From)
-				}
-			}
-		}
+// AnalyzeReachability walks the requirement and activation edges forward from
+// the default target and from enabled timers, sockets and paths, following
+// aliases. Without a default target nothing is reachable.
+func (g *Graph) AnalyzeReachability() ReachabilityResult {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 
-		// Also follow WantedBy/RequiredBy relationships (outgoing edges from targets)
-		for _, edge := range g.outgoing[current] {
-			if edge.Type.IsRequirementEdge() || edge.Type == EdgeTriggeredBy {
-				if !visited[edge.To] {
-					visited[edge.To] = true
-					queue = append(queue, edge.To)
-				}
-			}
-		}
-	}
+	visited, roots := g.reachable()
 
-	// Categorize units
 	var reachable, unreachable []string
 	for name := range g.units {
-		if visited[name] {
+		if visited[g.resolve(name)] {
 			reachable = append(reachable, name)
 		} else {
 			unreachable = append(unreachable, name)
@@ -100,11 +100,82 @@ func (g *Graph) AnalyzeReachability() ReachabilityResult {
 	return ReachabilityResult{
 		Reachable:   reachable,
 		Unreachable: unreachable,
-		Targets:     targets,
+		Targets:     roots,
 	}
 }
 
-// IsReachable returns true if a unit is reachable from any target.
+// pullsIn reports whether an edge starts its target when its source starts
+func pullsIn(e Edge) bool {
+	return e.Type.IsRequirementEdge() || e.Type == EdgeTriggeredBy
+}
+
+// reachable returns the resolved names of the units started at boot and the
+// roots the walk started from. The caller must hold g.mu.
+func (g *Graph) reachable() (map[string]bool, []string) {
+	visited := make(map[string]bool)
+	root := g.defaultTarget()
+	if root == "" {
+		return visited, nil
+	}
+
+	roots := []string{root}
+	for name, unit := range g.units {
+		if unit == nil || (unit.Type != "timer" && unit.Type != "socket" && unit.Type != "path") {
+			continue
+		}
+		for _, edge := range g.incoming[name] {
+			if pullsIn(edge) && strings.HasSuffix(edge.From, ".target") {
+				roots = append(roots, name)
+				break
+			}
+		}
+	}
+	sort.Strings(roots[1:])
+
+	names := g.aliasGroups()
+	var queue []string
+	visit := func(name string) {
+		name = g.resolve(name)
+		if !visited[name] {
+			visited[name] = true
+			queue = append(queue, name)
+		}
+	}
+	for _, r := range roots {
+		visit(r)
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, name := range names(current) {
+			for _, edge := range g.outgoing[name] {
+				if pullsIn(edge) {
+					visit(edge.To)
+				}
+			}
+		}
+	}
+
+	return visited, roots
+}
+
+// aliasGroups returns a function listing a resolved unit name together with
+// its aliases. The caller must hold g.mu.
+func (g *Graph) aliasGroups() func(string) []string {
+	groups := make(map[string][]string)
+	for alias := range g.aliases {
+		unit := g.resolve(alias)
+		groups[unit] = append(groups[unit], alias)
+	}
+	for _, aliases := range groups {
+		sort.Strings(aliases)
+	}
+	return func(name string) []string {
+		return append([]string{name}, groups[name]...)
+	}
+}
+
+// IsReachable returns true if a unit is started at boot.
 func (g *Graph) IsReachable(unit string) bool {
 	result := g.AnalyzeReachability()
 	for _, u := range result.Reachable {
@@ -115,60 +186,215 @@ func (g *Graph) IsReachable(unit string) bool {
 	return false
 }
 
-// UnreachableUnits returns a list of units not reachable from any target.
+// UnreachableUnits returns a list of units not started at boot.
 func (g *Graph) UnreachableUnits() []string {
 	return g.AnalyzeReachability().Unreachable
 }
 
-// ReachableFrom returns all units reachable from a specific starting unit.
-// Direction can be "forward" (what does this unit depend on) or
-// "backward" (what depends on this unit).
-func (g *Graph) ReachableFrom(unit string, direction string) []string {
+// Kinds of dead units found by FindDeadUnits
+const (
+	DeadUnreferenced      = "unreferenced"       // Nothing pulls the unit in or activates it
+	DeadUnreachableTarget = "unreachable_target" // Only wanted by targets that are not started at boot
+)
+
+// DeadUnit is a unit file that is never started at boot.
+type DeadUnit struct {
+	Unit   string `json:"unit"`
+	Kind   string `json:"kind"`
+	Target string `json:"target,omitempty"` // A target that wants the unit, for DeadUnreachableTarget
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// FindDeadUnits returns the unit files that are not started at boot and
+// either are wanted only by targets that are not started at boot either, or
+// are not referenced at all. [Install] sections count as if the unit were
+// enabled. Left out are templates, aliases, masked units, units wanted by
+// on-demand targets such as sleep.target, units that can only be enabled
+// under an alias, D-Bus activated services, mounts and swaps that systemd
+// pulls in for paths, and units pulled in or activated by another unit that
+// is not started either, which is reported instead. It returns nil when the
+// graph has no default target.
+func (g *Graph) FindDeadUnits() []DeadUnit {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
-	visited := make(map[string]bool)
-	queue := []string{unit}
-	visited[unit] = true
+	visited, roots := g.reachable()
+	if len(roots) == 0 {
+		return nil
+	}
+	names := g.aliasGroups()
 
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
+	// Units other units can start, by resolved name
+	referenced := make(map[string]bool)
+	for _, edge := range g.allEdges {
+		if (pullsIn(edge) && g.resolve(edge.From) != g.resolve(edge.To)) || edge.Type.IsHandlerEdge() {
+			referenced[g.resolve(edge.To)] = true
+		}
+	}
+	for _, unit := range g.units {
+		if unit == nil {
+			continue
+		}
+		if section, ok := unit.Sections["Install"]; ok {
+			for _, d := range section.Directives["Also"] {
+				for _, name := range splitDirectiveValue(d.Value) {
+					referenced[g.resolve(name)] = true
+				}
+			}
+		}
+	}
 
-		var edges []Edge
-		if direction == "forward" {
-			edges = g.outgoing[current]
-		} else {
-			edges = g.incoming[current]
+	unitNames := make([]string, 0, len(g.units))
+	for name := range g.units {
+		unitNames = append(unitNames, name)
+	}
+	sort.Strings(unitNames)
+
+	var dead []DeadUnit
+	for _, name := range unitNames {
+		unit := g.units[name]
+		resolved := g.resolve(name)
+		if unit == nil || unit.Masked || visited[resolved] || resolved != name || strings.Contains(name, "@") {
+			continue
+		}
+		switch unit.Type {
+		case "target", "slice", "scope", "device":
+			continue
 		}
 
-		for _, edge := range edges {
-			var target string
-			if direction == "forward" {
-				target = edge.To
-			} else {
-				target = edge.From
+		// Targets that want the unit, none of which is started at boot
+		var wantedBy []Edge
+		onDemand := false
+		for _, alias := range names(name) {
+			for _, edge := range g.incoming[alias] {
+				if !pullsIn(edge) || !strings.HasSuffix(edge.From, ".target") {
+					continue
+				}
+				if onDemandTargets[g.resolve(edge.From)] || onDemandTargets[edge.From] {
+					onDemand = true
+				}
+				wantedBy = append(wantedBy, edge)
 			}
+		}
+		if onDemand {
+			continue
+		}
+		if len(wantedBy) > 0 {
+			sort.Slice(wantedBy, func(i, j int) bool {
+				return wantedBy[i].From < wantedBy[j].From
+			})
+			e := wantedBy[0]
+			dead = append(dead, DeadUnit{Unit: name, Kind: DeadUnreachableTarget, Target: e.From, File: e.File, Line: e.Line})
+			continue
+		}
 
-			if !visited[target] {
-				visited[target] = true
-				queue = append(queue, target)
-			}
+		if referenced[name] || unit.Type == "mount" || unit.Type == "automount" || unit.Type == "swap" {
+			continue
 		}
+		if install, ok := unit.Sections["Install"]; ok && len(install.Directives) > 0 {
+			continue
+		}
+		if unit.Type == "service" && (unit.GetDirective("Service", "BusName") != "" || unit.GetDirective("Service", "Type") == "dbus") {
+			continue
+		}
+		dead = append(dead, DeadUnit{Unit: name, Kind: DeadUnreferenced, File: unit.Path})
 	}
 
-	// Remove the starting unit from the result
-	delete(visited, unit)
+	return dead
+}
 
-	result := make([]string, 0, len(visited))
-	for name := range visited {
-		result = append(result, name)
-	}
-	sort.Strings(result)
+// ReachableFrom returns all units reachable from a specific starting unit.
+// Direction can be "forward" (what does this unit depend on) or
+// "backward" (what depends on this unit).
+func (g *Graph) ReachableFrom(unit string, direction string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 
+	forward := direction == "forward"
+	c := g.memoized("closure "+strconv.FormatBool(forward), func() any {
+		return g.closure(forward)
+	}).(*reachClosure)
+
+	i, ok := c.index[unit]
+	if !ok {
+		return []string{}
+	}
+	reach := c.reach[c.component[i]]
+	result := make([]string, 0, c.counts[c.component[i]])
+	for j, name := range c.names {
+		if j != i && reach[j/64]&(1<<(j%64)) != 0 {
+			result = append(result, name)
+		}
+	}
 	return result
 }
 
+// reachClosure holds the units each unit of a graph reaches
+type reachClosure struct {
+	names     []string       // Units, sorted
+	index     map[string]int // Unit -> position in names
+	component []int          // Position -> strongly connected component
+	reach     [][]uint64     // Component -> bit set of the positions it reaches, its own included
+	counts    []int          // Component -> number of positions it reaches
+}
+
+// closure computes the units each unit reaches following edges forward or
+// backward, in one pass over the strongly connected components: Tarjan's
+// algorithm returns each component after the ones it reaches, and a
+// component reaches its own units and whatever the components it has edges
+// to reach. The caller must hold g.mu.
+func (g *Graph) closure(forward bool) *reachClosure {
+	c := &reachClosure{index: make(map[string]int, len(g.nodeIDs))}
+	for name := range g.nodeIDs {
+		c.names = append(c.names, name)
+	}
+	sort.Strings(c.names)
+	for i, name := range c.names {
+		c.index[name] = i
+	}
+
+	d := g.directedBy(func(e Edge) (string, string, bool) {
+		if forward {
+			return e.From, e.To, true
+		}
+		return e.To, e.From, true
+	})
+
+	words := (len(c.names) + 63) / 64
+	sccs := topo.TarjanSCC(d)
+	c.component = make([]int, len(c.names))
+	c.reach = make([][]uint64, len(sccs))
+	c.counts = make([]int, len(sccs))
+	for k, scc := range sccs {
+		for _, node := range scc {
+			c.component[c.index[g.nodes[node.ID()]]] = k
+		}
+	}
+	for k, scc := range sccs {
+		reach := make([]uint64, words)
+		for _, node := range scc {
+			i := c.index[g.nodes[node.ID()]]
+			reach[i/64] |= 1 << (i % 64)
+			to := d.From(node.ID())
+			for to.Next() {
+				other := c.reach[c.component[c.index[g.nodes[to.Node().ID()]]]]
+				if other == nil {
+					continue // Same component, still being filled in
+				}
+				for w := range reach {
+					reach[w] |= other[w]
+				}
+			}
+		}
+		c.reach[k] = reach
+		for _, w := range reach {
+			c.counts[k] += bits.OnesCount64(w)
+		}
+	}
+	return c
+}
+
 // TransitiveDependencies returns all units that a unit transitively depends on.
 func (g *Graph) TransitiveDependencies(unit string) []string {
 	return g.ReachableFrom(unit, "forward")
@@ -219,3 +445,79 @@ func (g *Graph) PathBetween(from, to string) []string {
 
 	return nil // No path found
 }
+
+// RequirementPath returns the shortest chain of Requires=, Requisite= and
+// BindsTo= edges from one unit to another, following aliases, or nil if
+// there is none. Starting from fails when any unit on the chain fails to
+// start.
+func (g *Graph) RequirementPath(from, to string) []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.shortestPath(from, to, func(e Edge) bool {
+		return e.Type.PropagatesStartFailure()
+	})
+}
+
+// PullInPath returns the shortest chain of Requires=, Wants= and BindsTo=
+// edges from one unit to another, following aliases, or nil if there is
+// none. Starting from starts every unit on the chain along with it. Units
+// for which skip returns true are not passed through; skip may be nil.
+func (g *Graph) PullInPath(from, to string, skip func(unit string) bool) []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.shortestPath(from, to, func(e Edge) bool {
+		switch e.Type {
+		case EdgeRequires, EdgeWants, EdgeBindsTo:
+			return skip == nil || g.resolve(e.To) == g.resolve(to) || !skip(g.resolve(e.To))
+		}
+		return false
+	})
+}
+
+// shortestPath returns the shortest chain of edges that follow accepts from
+// one unit to another, following aliases, or nil if there is none. The
+// caller must hold g.mu.
+func (g *Graph) shortestPath(from, to string, follow func(Edge) bool) []Edge {
+	from, to = g.resolve(from), g.resolve(to)
+	if from == to {
+		return nil
+	}
+	names := g.aliasGroups()
+	visited := map[string]bool{from: true}
+	parent := make(map[string]Edge)
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current == to {
+			var path []Edge
+			for name := to; name != from; name = g.resolve(parent[name].From) {
+				path = append([]Edge{parent[name]}, path...)
+			}
+			return path
+		}
+
+		var edges []Edge
+		for _, name := range names(current) {
+			for _, edge := range g.outgoing[name] {
+				if follow(edge) {
+					edges = append(edges, edge)
+				}
+			}
+		}
+		// Equally short chains are chosen by where they are declared, not by map order
+		sort.SliceStable(edges, func(i, j int) bool { return edgeLess(edges[i], edges[j]) })
+		for _, edge := range edges {
+			next := g.resolve(edge.To)
+			if !visited[next] {
+				visited[next] = true
+				parent[next] = edge
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
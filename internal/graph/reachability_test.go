@@ -0,0 +1,227 @@
+package graph
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestAnalyzeReachability(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/reachability"))
+
+	if got := g.DefaultTarget(); got != "graphical.target" {
+		t.Errorf("DefaultTarget() = %q, want graphical.target without default.target", got)
+	}
+
+	result := g.AnalyzeReachability()
+	if want := []string{"graphical.target", "web.socket"}; !reflect.DeepEqual(result.Targets, want) {
+		t.Errorf("Targets = %v, want %v", result.Targets, want)
+	}
+	for _, unit := range []string{"app.service", "gdm.service", "multi-user.target", "web.service"} {
+		if !g.IsReachable(unit) {
+			t.Errorf("%s should be reachable", unit)
+		}
+	}
+	for _, unit := range []string{"orphan.service", "helper.service", "tool.service", "admin.target"} {
+		if g.IsReachable(unit) {
+			t.Errorf("%s should not be reachable", unit)
+		}
+	}
+}
+
+func TestFindDeadUnits(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/reachability"))
+
+	dead := g.FindDeadUnits()
+	got := make(map[string]DeadUnit)
+	for _, d := range dead {
+		got[d.Unit] = d
+	}
+
+	if d := got["orphan.service"]; d.Kind != DeadUnreferenced {
+		t.Errorf("orphan.service = %+v, want unreferenced", d)
+	}
+	if d := got["tool.service"]; d.Kind != DeadUnreachableTarget || d.Target != "admin.target" || d.Line != 8 {
+		t.Errorf("tool.service = %+v, want wanted by admin.target at line 8", d)
+	}
+	if d := got["typo.service"]; d.Kind != DeadUnreachableTarget || d.Target != "multiuser.target" {
+		t.Errorf("typo.service = %+v, want wanted by the missing multiuser.target", d)
+	}
+
+	// Pulled in by orphan.service, woken up by suspend, activated by D-Bus, a
+	// template and an alias
+	for _, unit := range []string{"helper.service", "suspend-hook.service", "bus.service", "worker@.service", "gdm.service", "admin.target"} {
+		if d, ok := got[unit]; ok {
+			t.Errorf("%s should not be reported, got %+v", unit, d)
+		}
+	}
+	if len(dead) != 3 {
+		t.Errorf("found %d dead units, want 3: %+v", len(dead), dead)
+	}
+}
+
+func TestFindDeadUnitsNoDefaultTarget(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/linear_chain"))
+
+	if dead := g.FindDeadUnits(); dead != nil {
+		t.Errorf("FindDeadUnits() = %+v, want nil without a default target", dead)
+	}
+}
+
+func TestAliasReachability(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/graph/reachability")
+	delete(units, "graphical.target")
+	g := Build(units)
+
+	// default.target names multi-user.target through a link
+	g.AddAlias("default.target", "multi-user.target")
+	if got := g.DefaultTarget(); got != "multi-user.target" {
+		t.Errorf("DefaultTarget() = %q, want multi-user.target", got)
+	}
+	if !g.IsReachable("app.service") || g.IsReachable("gdm.service") {
+		t.Error("multi-user.target should pull in app.service but not the display manager")
+	}
+}
+
+func TestRequirementPath(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/handlers"))
+
+	onFailure := g.EdgesOfType(EdgeOnFailure)
+	if len(onFailure) != 1 || onFailure[0].From != "app.service" || onFailure[0].To != "notify.service" || onFailure[0].Line != 3 {
+		t.Errorf("OnFailure edges = %+v, want app.service to notify.service on line 3", onFailure)
+	}
+	if onSuccess := g.EdgesOfType(EdgeOnSuccess); len(onSuccess) != 1 || onSuccess[0].To != "cleanup.service" {
+		t.Errorf("OnSuccess edges = %+v, want app.service to cleanup.service", onSuccess)
+	}
+
+	// notify.service requires app.target, which requires app.service
+	path := g.RequirementPath("notify.service", "app.service")
+	var steps []string
+	for _, e := range path {
+		steps = append(steps, e.From+" "+e.Type.String()+"="+e.To)
+	}
+	want := []string{"notify.service Requires=app.target", "app.target Requires=app.service"}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("RequirementPath(notify.service, app.service) = %v, want %v", steps, want)
+	}
+
+	// Handler edges do not pull in their target
+	if path := g.RequirementPath("app.service", "notify.service"); path != nil {
+		t.Errorf("RequirementPath(app.service, notify.service) = %+v, want nil", path)
+	}
+	if path := g.RequirementPath("cleanup.service", "app.service"); path != nil {
+		t.Errorf("RequirementPath(cleanup.service, app.service) = %+v, want nil", path)
+	}
+}
+
+func TestPullInPath(t *testing.T) {
+	g := New()
+	for _, e := range []Edge{
+		{From: "early.service", To: "helper.service", Type: EdgeWants, Line: 2},
+		{From: "early.service", To: "time-sync.target", Type: EdgeAfter, Line: 3},
+		{From: "helper.service", To: "sysinit.target", Type: EdgeRequires, Implicit: true},
+		{From: "helper.service", To: "remote-fs.target", Type: EdgeWants, Line: 4},
+		{From: "sysinit.target", To: "other.service", Type: EdgeWants, Line: 9},
+		{From: "other.service", To: "network-online.target", Type: EdgeWants, Line: 5},
+	} {
+		g.AddEdge(e)
+	}
+	steps := func(path []Edge) []string {
+		var steps []string
+		for _, e := range path {
+			steps = append(steps, e.From+" "+e.Type.String()+"="+e.To)
+		}
+		return steps
+	}
+
+	want := []string{"early.service Wants=helper.service", "helper.service Wants=remote-fs.target"}
+	if got := steps(g.PullInPath("early.service", "remote-fs.target", nil)); !reflect.DeepEqual(got, want) {
+		t.Errorf("PullInPath(early.service, remote-fs.target) = %v, want %v", got, want)
+	}
+	// After= does not pull in its target
+	if path := g.PullInPath("early.service", "time-sync.target", nil); path != nil {
+		t.Errorf("PullInPath(early.service, time-sync.target) = %v, want nil", steps(path))
+	}
+
+	want = []string{"early.service Wants=helper.service", "helper.service Requires=sysinit.target", "sysinit.target Wants=other.service", "other.service Wants=network-online.target"}
+	if got := steps(g.PullInPath("early.service", "network-online.target", nil)); !reflect.DeepEqual(got, want) {
+		t.Errorf("PullInPath(early.service, network-online.target) = %v, want %v", got, want)
+	}
+	skip := func(unit string) bool { return unit == "sysinit.target" }
+	if path := g.PullInPath("early.service", "network-online.target", skip); path != nil {
+		t.Errorf("PullInPath(early.service, network-online.target) skipping sysinit.target = %v, want nil", steps(path))
+	}
+}
+
+// reachableByBFS is a breadth-first search from a unit, the reference for
+// the closure ReachableFrom computes
+func reachableByBFS(g *Graph, unit string, direction string) []string {
+	visited := map[string]bool{unit: true}
+	queue := []string{unit}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		edges := g.EdgesTo(current)
+		if direction == "forward" {
+			edges = g.EdgesFrom(current)
+		}
+		for _, edge := range edges {
+			target := edge.From
+			if direction == "forward" {
+				target = edge.To
+			}
+			if !visited[target] {
+				visited[target] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+	delete(visited, unit)
+
+	result := make([]string, 0, len(visited))
+	for name := range visited {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestReachableFromMatchesBFS(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		n := 1 + r.Intn(150)
+		g := randomGraph(r, n, r.Intn(3*n))
+		for _, unit := range append(g.NodeNames(), "unknown.service") {
+			for _, direction := range []string{"forward", "backward"} {
+				got, want := g.ReachableFrom(unit, direction), reachableByBFS(g, unit, direction)
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("graph %d: ReachableFrom(%s, %s) = %v, want %v", i, unit, direction, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestReachableFromAfterChange(t *testing.T) {
+	g := New()
+	for _, name := range []string{"a.service", "b.service", "c.service"} {
+		g.AddUnit(&types.UnitFile{Name: name, Type: "service"})
+	}
+	g.AddEdge(Edge{From: "a.service", To: "b.service", Type: EdgeRequires})
+	if got := g.TransitiveDependencies("a.service"); !reflect.DeepEqual(got, []string{"b.service"}) {
+		t.Fatalf("TransitiveDependencies(a.service) = %v, want [b.service]", got)
+	}
+
+	g.AddEdge(Edge{From: "b.service", To: "c.service", Type: EdgeWants})
+	if got := g.TransitiveDependencies("a.service"); !reflect.DeepEqual(got, []string{"b.service", "c.service"}) {
+		t.Errorf("TransitiveDependencies(a.service) after adding b.service Wants=c.service = %v, want [b.service c.service]", got)
+	}
+	g.AddUnit(&types.UnitFile{Name: "d.service", Type: "service"})
+	g.AddEdge(Edge{From: "d.service", To: "a.service", Type: EdgeBindsTo})
+	if got := g.TransitiveDependents("c.service"); !reflect.DeepEqual(got, []string{"a.service", "b.service", "d.service"}) {
+		t.Errorf("TransitiveDependents(c.service) after adding d.service = %v, want [a.service b.service d.service]", got)
+	}
+}
@@ -0,0 +1,62 @@
+package graph
+
+import "github.com/supabase/sdaudit/internal/unitname"
+
+// DefaultSynchronizationUnits are the special units of systemd.special(7)
+// that units order themselves against without pulling them in: targets that
+// systemd, a generator or another service starts, such as network.target or
+// time-sync.target, the boot and shutdown targets that default dependencies
+// order units against, and the D-Bus, logging sockets and slices that are
+// always there. After= on them needs no Requires= or Wants=.
+var DefaultSynchronizationUnits = []string{
+	// Boot and default dependencies
+	"basic.target", "sysinit.target", "sockets.target", "timers.target",
+	"paths.target", "slices.target", "swap.target", "local-fs-pre.target",
+	"local-fs.target", "remote-fs-pre.target", "remote-fs.target",
+	"cryptsetup-pre.target", "cryptsetup.target", "remote-cryptsetup.target",
+	"veritysetup-pre.target", "veritysetup.target", "remote-veritysetup.target",
+	"integritysetup-pre.target", "integritysetup.target",
+	"first-boot-complete.target", "getty-pre.target", "getty.target",
+	"multi-user.target", "graphical.target", "default.target",
+	"machines.target", "system-update-pre.target", "system-update.target",
+	"initrd.target", "initrd-fs.target", "initrd-root-device.target",
+	"initrd-root-fs.target", "initrd-usr-fs.target", "initrd-switch-root.target",
+	"emergency.target", "rescue.target",
+	// Shutdown and sleep
+	"shutdown.target", "umount.target", "final.target", "halt.target",
+	"poweroff.target", "reboot.target", "kexec.target", "soft-reboot.target",
+	"sleep.target", "suspend.target", "hibernate.target", "hybrid-sleep.target",
+	"suspend-then-hibernate.target",
+	// Passive targets pulled in by the services that provide them
+	"network-pre.target", "network.target", "network-online.target",
+	"nss-lookup.target", "nss-user-lookup.target", "time-set.target",
+	"time-sync.target", "rpcbind.target", "ssh-access.target", "syslog.target",
+	// Device targets started by udev
+	"bluetooth.target", "printer.target", "smartcard.target", "sound.target",
+	"usb-gadget.target", "tpm2.target",
+	// Sockets and slices that are always there
+	"dbus.socket", "syslog.socket", "systemd-journald.socket",
+	"-.slice", "system.slice", "user.slice", "machine.slice",
+}
+
+// AddSynchronizationUnits adds units, such as a site's own targets, that
+// FindOrderingIssues does not report After= on, besides
+// DefaultSynchronizationUnits.
+func (g *Graph) AddSynchronizationUnits(names ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, name := range names {
+		g.synchronization[unitname.Normalize(name)] = true
+	}
+	g.changed()
+}
+
+// IsSynchronizationUnit reports whether units order themselves after a unit
+// without requiring it
+func (g *Graph) IsSynchronizationUnit(name string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.synchronization[unitname.Normalize(name)]
+}
@@ -1,8 +1,11 @@
 package graph
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 
+	"gonum.org/v1/gonum/graph/simple"
 	"gonum.org/v1/gonum/graph/topo"
 )
 
@@ -11,90 +14,334 @@ type SCC struct {
 	Units     []string   // Units in the cycle (sorted for determinism)
 	Edges     []Edge     // Edges forming the cycle
 	EdgeTypes []EdgeType // Which relationship types are involved
+	// Path is the shortest cycle through the component, one step per unit in
+	// cycle order. A step lists every edge between its two units.
+	Path []CycleStep
+}
+
+// CycleStep is one step of a cycle path, from one unit to the next
+type CycleStep struct {
+	From  string
+	To    string
+	Edges []Edge // Edges that make From wait for or pull in To, sorted by type
 }
 
 // FindCycles returns all non-trivial SCCs (cycles) in the graph.
-// A cycle exists when len(SCC.Units) > 1.
+// A cycle exists when len(SCC.Units) > 1. Before= is read in the direction
+// of After=, so two units declaring the same ordering from both sides do not
+// form a cycle. Conflicts= neither pulls in nor waits for the other unit,
+// and a socket, timer or path starts its unit later, on demand, so both are
+// left out: the implicit Conflicts= and Before= on shutdown.target, and a
+// socket ordered before the service it activates, are not cycles.
 // Uses Tarjan's algorithm via gonum - O(V+E) complexity.
 func (g *Graph) FindCycles() []SCC {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
+	return g.memoizedCycles("cycles", g.dependencyArc)
+}
 
-	// Use gonum's Tarjan SCC implementation
-	sccs := topo.TarjanSCC(g.g)
+// FindOrderingCycles returns the cycles in start ordering, the ones systemd
+// breaks by dropping a job at boot. After= and Before= are both read as one
+// unit waiting for another, so a pair of units declaring the same ordering
+// from both sides is not a cycle. Requirement edges are ignored: systemd
+// accepts cycles of Requires= or Wants= as long as the ordering is acyclic.
+func (g *Graph) FindOrderingCycles() []SCC {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.memoizedCycles("ordering cycles", g.orderingArc)
+}
 
-	var cycles []SCC
-	for _, scc := range sccs {
-		// Only interested in non-trivial SCCs (more than one node)
+// FindStopCycles returns the cycles of edges that propagate stops (BindsTo=
+// and PartOf=), in which a unit stopping can stop itself again.
+func (g *Graph) FindStopCycles() []SCC {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.memoizedCycles("stop cycles", stopArc)
+}
+
+// dependencyArc maps an edge to the arc FindCycles follows, if any
+func (g *Graph) dependencyArc(e Edge) (string, string, bool) {
+	if g.reversesBefore(e) || e.Type == EdgeConflicts || e.Type == EdgeTriggeredBy {
+		return "", "", false
+	}
+	if e.Type == EdgeBefore {
+		return e.To, e.From, true
+	}
+	return e.From, e.To, true
+}
+
+// orderingArc maps an edge to an arc from a unit to a unit it starts after.
+// The After= added for Before= is left out for the Before= edge itself,
+// which cycle paths report as declared.
+func (g *Graph) orderingArc(e Edge) (string, string, bool) {
+	switch {
+	case g.reversesBefore(e):
+	case e.Type == EdgeAfter:
+		return e.From, e.To, true
+	case e.Type == EdgeBefore:
+		return e.To, e.From, true
+	}
+	return "", "", false
+}
+
+// stopArc maps an edge that propagates stops to its arc
+func stopArc(e Edge) (string, string, bool) {
+	return e.From, e.To, e.Type.PropagatesStop()
+}
+
+// memoizedCycles returns the cycles of the arcs arc maps edges to, computed
+// once per version of the graph. The caller must hold g.mu.
+func (g *Graph) memoizedCycles(key string, arc func(Edge) (from, to string, ok bool)) []SCC {
+	cycles := g.memoized(key, func() any {
+		return g.cyclesOf(g.directedBy(arc), arc)
+	}).([]SCC)
+	return append([]SCC(nil), cycles...)
+}
+
+// directedBy returns a simple directed graph with the nodes of g and the
+// arcs arc maps its edges to
+func (g *Graph) directedBy(arc func(Edge) (from, to string, ok bool)) *simple.DirectedGraph {
+	d := g.emptyDirected()
+	for _, edge := range g.allEdges {
+		if from, to, ok := arc(edge); ok {
+			g.setArc(d, from, to)
+		}
+	}
+	return d
+}
+
+// emptyDirected returns a simple directed graph with the nodes of g
+func (g *Graph) emptyDirected() *simple.DirectedGraph {
+	d := simple.NewDirectedGraph()
+	for id := range g.nodes {
+		d.AddNode(simple.Node(id))
+	}
+	return d
+}
+
+// setArc adds an arc between two units, ignoring self-loops and duplicates
+func (g *Graph) setArc(d *simple.DirectedGraph, from, to string) {
+	fromID, toID := g.nodeIDs[from], g.nodeIDs[to]
+	if fromID != toID && !d.HasEdgeFromTo(fromID, toID) {
+		d.SetEdge(d.NewEdge(simple.Node(fromID), simple.Node(toID)))
+	}
+}
+
+// cyclesOf returns the non-trivial SCCs of d as cycles of units. arc tells
+// which edges of g belong to d and in which direction they point there.
+func (g *Graph) cyclesOf(d *simple.DirectedGraph, arc func(Edge) (from, to string, ok bool)) []SCC {
+	type component struct {
+		units     []string
+		edges     []Edge
+		edgeTypes map[EdgeType]bool
+		steps     map[[2]string][]Edge
+	}
+	var components []*component
+	componentOf := make(map[string]*component)
+	for _, scc := range topo.TarjanSCC(d) {
 		if len(scc) <= 1 {
 			continue
 		}
-
-		// Convert gonum nodes to unit names
-		unitNames := make([]string, 0, len(scc))
-		unitSet := make(map[string]bool)
+		c := &component{edgeTypes: make(map[EdgeType]bool), steps: make(map[[2]string][]Edge)}
 		for _, node := range scc {
-			name, ok := g.nodes[node.ID()]
-			if ok {
-				unitNames = append(unitNames, name)
-				unitSet[name] = true
-			}
+			name := g.nodes[node.ID()]
+			c.units = append(c.units, name)
+			componentOf[name] = c
 		}
+		sort.Strings(c.units)
+		components = append(components, c)
+	}
 
-		// Sort for determinism
-		sort.Strings(unitNames)
-
-		// Find edges within this SCC
-		var cycleEdges []Edge
-		edgeTypeSet := make(map[EdgeType]bool)
-		for _, edge := range g.allEdges {
-			if unitSet[edge.From] && unitSet[edge.To] {
-				cycleEdges = append(cycleEdges, edge)
-				edgeTypeSet[edge.Type] = true
-			}
+	// One pass over the edges collects the edges inside each component
+	for _, edge := range g.allEdges {
+		from, to, ok := arc(edge)
+		if !ok || from == to || componentOf[from] == nil || componentOf[from] != componentOf[to] {
+			continue
 		}
+		c := componentOf[from]
+		c.edges = append(c.edges, edge)
+		c.edgeTypes[edge.Type] = true
+		c.steps[[2]string{from, to}] = append(c.steps[[2]string{from, to}], edge)
+	}
 
-		// Collect unique edge types
+	var cycles []SCC
+	for _, c := range components {
 		var edgeTypes []EdgeType
-		for et := range edgeTypeSet {
+		for et := range c.edgeTypes {
 			edgeTypes = append(edgeTypes, et)
 		}
-		// Sort edge types for determinism
 		sort.Slice(edgeTypes, func(i, j int) bool {
 			return edgeTypes[i] < edgeTypes[j]
 		})
 
 		cycles = append(cycles, SCC{
-			Units:     unitNames,
-			Edges:     cycleEdges,
+			Units:     c.units,
+			Edges:     c.edges,
 			EdgeTypes: edgeTypes,
+			Path:      shortestCycle(c.units, c.steps),
 		})
 	}
 
-	// Sort cycles by first unit name for determinism
 	sort.Slice(cycles, func(i, j int) bool {
-		if len(cycles[i].Units) == 0 {
-			return true
-		}
-		if len(cycles[j].Units) == 0 {
-			return false
-		}
 		return cycles[i].Units[0] < cycles[j].Units[0]
 	})
 
 	return cycles
 }
 
+// shortestCycle returns the shortest cycle through the units of a strongly
+// connected component, found by a breadth-first search from each unit. Ties
+// go to the cycle starting at the unit that sorts first. A search stops at
+// the depth of the shortest cycle found so far, which it cannot improve on.
+func shortestCycle(units []string, steps map[[2]string][]Edge) []CycleStep {
+	next := make(map[string][]string)
+	for step := range steps {
+		next[step[0]] = append(next[step[0]], step[1])
+	}
+	for _, to := range next {
+		sort.Strings(to)
+	}
+
+	var best []string
+	for _, start := range units {
+		parent := map[string]string{start: ""}
+		depth := map[string]int{start: 0}
+		queue := []string{start}
+		var found []string
+		for len(queue) > 0 && found == nil {
+			u := queue[0]
+			queue = queue[1:]
+			if best != nil && depth[u]+1 >= len(best) {
+				break
+			}
+			for _, v := range next[u] {
+				if v == start {
+					for w := u; w != ""; w = parent[w] {
+						found = append([]string{w}, found...)
+					}
+					break
+				}
+				if _, seen := parent[v]; !seen {
+					parent[v] = u
+					depth[v] = depth[u] + 1
+					queue = append(queue, v)
+				}
+			}
+		}
+		if found != nil && (best == nil || len(found) < len(best)) {
+			best = found
+		}
+	}
+
+	path := make([]CycleStep, 0, len(best))
+	for i, from := range best {
+		to := best[(i+1)%len(best)]
+		edges := append([]Edge(nil), steps[[2]string{from, to}]...)
+		sort.SliceStable(edges, func(a, b int) bool {
+			return edges[a].Type < edges[b].Type
+		})
+		path = append(path, CycleStep{From: from, To: to, Edges: edges})
+	}
+	return path
+}
+
+// breakCost ranks how much removing an edge changes what a unit means:
+// ordering first, then weak dependencies, then requirements. Implicit
+// dependencies come last, as only DefaultDependencies=no or another change
+// to the unit removes them.
+func breakCost(e Edge) int {
+	if e.Implicit {
+		return 10
+	}
+	switch e.Type {
+	case EdgeAfter, EdgeBefore:
+		return 0
+	case EdgeWants:
+		return 1
+	case EdgeRequires:
+		return 3
+	case EdgeRequisite, EdgeBindsTo:
+		return 4
+	}
+	return 2
+}
+
+// BreakStep returns the step of the cycle path that is cheapest to remove,
+// preferring steps made only of ordering or Wants= edges over requirements.
+// All edges of the step have to go to break the cycle.
+func (s SCC) BreakStep() (CycleStep, bool) {
+	var best CycleStep
+	bestCost := -1
+	for _, step := range s.Path {
+		cost := 0
+		for _, e := range step.Edges {
+			cost = max(cost, breakCost(e))
+		}
+		if bestCost < 0 || cost < bestCost || (cost == bestCost && len(step.Edges) < len(best.Edges)) {
+			best, bestCost = step, cost
+		}
+	}
+	return best, bestCost >= 0
+}
+
+// BreakSuggestion describes the edges of BreakStep to remove
+func (s SCC) BreakSuggestion() string {
+	step, ok := s.BreakStep()
+	if !ok {
+		return "Review and break the dependency cycle"
+	}
+	parts := make([]string, 0, len(step.Edges))
+	for _, e := range step.Edges {
+		part := fmt.Sprintf("%s=%s from %s", e.Type, e.To, e.From)
+		if e.Implicit {
+			part = fmt.Sprintf("the implicit %s=%s of %s", e.Type, e.To, e.From)
+		}
+		if loc := e.Location(); loc != "" {
+			part += " (" + loc + ")"
+		}
+		parts = append(parts, part)
+	}
+	return "Remove " + strings.Join(parts, " and ") + " to break the cycle"
+}
+
+// Location returns the file and line that define the edge, empty if unknown
+func (e Edge) Location() string {
+	if e.File == "" {
+		return ""
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	return e.File
+}
+
+// PathUnits returns the units of the cycle path in order, starting and
+// ending at the same unit
+func (s SCC) PathUnits() []string {
+	if len(s.Path) == 0 {
+		return nil
+	}
+	units := make([]string, 0, len(s.Path)+1)
+	for _, step := range s.Path {
+		units = append(units, step.From)
+	}
+	return append(units, s.Path[0].From)
+}
+
 // HasCycles returns true if the graph contains any cycles.
 func (g *Graph) HasCycles() bool {
 	return len(g.FindCycles()) > 0
 }
 
-// CycleDescription returns a human-readable description of a cycle.
+// CycleDescription returns a human-readable description of a cycle, along
+// its shortest path when it has one.
 func (s SCC) CycleDescription() string {
 	if len(s.Units) == 0 {
 		return "empty cycle"
 	}
+	if len(s.Path) > 0 {
+		return strings.Join(s.PathUnits(), " -> ")
+	}
 
 	desc := s.Units[0]
 	for i := 1; i < len(s.Units); i++ {
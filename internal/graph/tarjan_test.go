@@ -1,7 +1,14 @@
 package graph
 
 import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+	"gonum.org/v1/gonum/graph/topo"
 )
 
 func TestFindCycles_SimpleCycle(t *testing.T) {
@@ -98,6 +105,46 @@ func TestCycleDescription(t *testing.T) {
 	}
 }
 
+func TestCyclePath(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/cycle_path"))
+
+	cycles := g.FindCycles()
+	if len(cycles) != 1 || len(cycles[0].Units) != 4 {
+		t.Fatalf("expected 1 cycle of 4 units, got %+v", cycles)
+	}
+	cycle := cycles[0]
+
+	// c.service Wants=b.service closes a shorter cycle than the one through all four units
+	if got := cycle.CycleDescription(); got != "b.service -> c.service -> b.service" {
+		t.Errorf("cycle = %s, want the shortest path b -> c -> b", got)
+	}
+
+	step, ok := cycle.BreakStep()
+	if !ok || len(step.Edges) != 1 || step.Edges[0].Type != EdgeWants {
+		t.Fatalf("BreakStep() = %+v, want the Wants= edge", step)
+	}
+	if e := step.Edges[0]; e.From != "c.service" || e.To != "b.service" || e.Line != 4 {
+		t.Errorf("break edge = %s %s=%s line %d, want c.service Wants=b.service line 4", e.From, e.Type, e.To, e.Line)
+	}
+	if got := cycle.BreakSuggestion(); !strings.HasPrefix(got, "Remove Wants=b.service from c.service (") {
+		t.Errorf("BreakSuggestion() = %q", got)
+	}
+}
+
+func TestFindCyclesBeforeAfter(t *testing.T) {
+	// d.service After=e.service and e.service Before=d.service declare one
+	// ordering twice, and are not a cycle
+	g := Build(loadTestUnits(t, "../../testdata/graph/ordering_cycle"))
+
+	cycles := g.FindCycles()
+	if len(cycles) != 1 {
+		t.Fatalf("expected 1 cycle, got %+v", cycles)
+	}
+	if got := cycles[0].CycleDescription(); got != "a.service -> b.service -> c.service -> a.service" {
+		t.Errorf("cycle = %s", got)
+	}
+}
+
 func TestFindCyclesInvolving(t *testing.T) {
 	units := loadTestUnits(t, "../../testdata/graph/cycle_simple")
 	g := Build(units)
@@ -114,3 +161,157 @@ func TestFindCyclesInvolving(t *testing.T) {
 		t.Errorf("expected 0 cycles involving nonexistent.service, got %d", len(cycles))
 	}
 }
+
+func TestFindOrderingCycles(t *testing.T) {
+	g := Build(loadTestUnits(t, "../../testdata/graph/ordering_cycle"))
+
+	cycles := g.FindOrderingCycles()
+	if len(cycles) != 1 {
+		t.Fatalf("expected 1 ordering cycle, got %d: %+v", len(cycles), cycles)
+	}
+	if got := cycles[0].CycleDescription(); got != "a.service -> b.service -> c.service -> a.service" {
+		t.Errorf("cycle = %s", got)
+	}
+	if got := cycles[0].InvolvedEdgeTypes(); got != "After, Before" {
+		t.Errorf("edge types = %s, want After, Before", got)
+	}
+
+	// A cycle of Requires= alone does not constrain start order
+	requires := Build(loadTestUnits(t, "../../testdata/graph/cycle_simple"))
+	if cycles := requires.FindOrderingCycles(); len(cycles) != 0 {
+		t.Errorf("expected no ordering cycles in a Requires= cycle, got %+v", cycles)
+	}
+}
+
+// cyclesByComponentScan is the reference for cyclesOf: it scans all edges for
+// each component and searches every unit of it for the shortest cycle
+func cyclesByComponentScan(g *Graph, arc func(Edge) (string, string, bool)) []SCC {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var cycles []SCC
+	for _, scc := range topo.TarjanSCC(g.directedBy(arc)) {
+		if len(scc) <= 1 {
+			continue
+		}
+		unitSet := make(map[string]bool)
+		var units []string
+		for _, node := range scc {
+			unitSet[g.nodes[node.ID()]] = true
+			units = append(units, g.nodes[node.ID()])
+		}
+		sort.Strings(units)
+
+		var edges []Edge
+		edgeTypeSet := make(map[EdgeType]bool)
+		steps := make(map[[2]string][]Edge)
+		for _, edge := range g.allEdges {
+			from, to, ok := arc(edge)
+			if !ok || from == to || !unitSet[from] || !unitSet[to] {
+				continue
+			}
+			edges = append(edges, edge)
+			edgeTypeSet[edge.Type] = true
+			steps[[2]string{from, to}] = append(steps[[2]string{from, to}], edge)
+		}
+		var edgeTypes []EdgeType
+		for et := range edgeTypeSet {
+			edgeTypes = append(edgeTypes, et)
+		}
+		sort.Slice(edgeTypes, func(i, j int) bool { return edgeTypes[i] < edgeTypes[j] })
+
+		cycles = append(cycles, SCC{Units: units, Edges: edges, EdgeTypes: edgeTypes, Path: shortestCycleByFullSearch(units, steps)})
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].Units[0] < cycles[j].Units[0] })
+	return cycles
+}
+
+// shortestCycleByFullSearch is shortestCycle without cutting searches short
+func shortestCycleByFullSearch(units []string, steps map[[2]string][]Edge) []CycleStep {
+	var best []string
+	for _, start := range units {
+		parent := map[string]string{start: ""}
+		queue := []string{start}
+		var found []string
+		for len(queue) > 0 && found == nil {
+			u := queue[0]
+			queue = queue[1:]
+			for _, v := range units {
+				if _, ok := steps[[2]string{u, v}]; !ok {
+					continue
+				}
+				if v == start {
+					for w := u; w != ""; w = parent[w] {
+						found = append([]string{w}, found...)
+					}
+					break
+				}
+				if _, seen := parent[v]; !seen {
+					parent[v] = u
+					queue = append(queue, v)
+				}
+			}
+		}
+		if found != nil && (best == nil || len(found) < len(best)) {
+			best = found
+		}
+	}
+
+	path := make([]CycleStep, 0, len(best))
+	for i, from := range best {
+		to := best[(i+1)%len(best)]
+		edges := append([]Edge(nil), steps[[2]string{from, to}]...)
+		sort.SliceStable(edges, func(a, b int) bool { return edges[a].Type < edges[b].Type })
+		path = append(path, CycleStep{From: from, To: to, Edges: edges})
+	}
+	return path
+}
+
+func TestFindCyclesMatchesComponentScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		n := 2 + r.Intn(60)
+		g := randomGraph(r, n, r.Intn(3*n))
+		finders := []struct {
+			name string
+			find func() []SCC
+			arc  func(Edge) (string, string, bool)
+		}{
+			{"FindCycles", g.FindCycles, g.dependencyArc},
+			{"FindOrderingCycles", g.FindOrderingCycles, g.orderingArc},
+			{"FindStopCycles", g.FindStopCycles, stopArc},
+		}
+		for _, f := range finders {
+			got, want := f.find(), cyclesByComponentScan(g, f.arc)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("graph %d: %s() = %+v, want %+v", i, f.name, got, want)
+			}
+		}
+	}
+}
+
+func TestFindCyclesAfterChange(t *testing.T) {
+	g := New()
+	for _, name := range []string{"a.service", "b.service"} {
+		g.AddUnit(&types.UnitFile{Name: name, Type: "service"})
+	}
+	g.AddEdge(Edge{From: "a.service", To: "b.service", Type: EdgeRequires})
+	if cycles := g.FindCycles(); len(cycles) != 0 {
+		t.Fatalf("FindCycles() = %+v, want none", cycles)
+	}
+
+	g.AddEdge(Edge{From: "b.service", To: "a.service", Type: EdgeBindsTo})
+	if cycles := g.FindCycles(); len(cycles) != 1 {
+		t.Errorf("FindCycles() after closing a.service -> b.service -> a.service = %+v, want one cycle", cycles)
+	}
+	if cycles := g.FindStopCycles(); len(cycles) != 0 {
+		t.Errorf("FindStopCycles() = %+v, want none", cycles)
+	}
+
+	// Callers may sort or trim the cycles they get
+	cycles := g.FindCycles()
+	cycles[0] = SCC{}
+	if again := g.FindCycles(); len(again[0].Units) != 2 {
+		t.Errorf("FindCycles() = %+v after a caller changed its result, want the cycle again", again)
+	}
+}
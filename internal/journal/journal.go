@@ -0,0 +1,242 @@
+// Package journal reads unit lifecycle events from the systemd journal.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// EventKind classifies a service manager message about a unit
+type EventKind int
+
+const (
+	// EventStarted is a completed start job, successful or not
+	EventStarted EventKind = iota
+	// EventStopped is a completed stop job
+	EventStopped
+	// EventFailed is the unit entering the failed state
+	EventFailed
+	// EventRestartScheduled is an automatic restart queued by Restart=
+	EventRestartScheduled
+	// EventStarting is a start job beginning
+	EventStarting
+	// EventDeactivated is the unit finishing successfully
+	EventDeactivated
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventStarted:
+		return "started"
+	case EventStopped:
+		return "stopped"
+	case EventFailed:
+		return "failed"
+	case EventRestartScheduled:
+		return "restart-scheduled"
+	case EventStarting:
+		return "starting"
+	case EventDeactivated:
+		return "deactivated"
+	default:
+		return "unknown"
+	}
+}
+
+// Message IDs from systemd's catalog for the events above
+var messageKinds = map[string]EventKind{
+	"39f53479d3a045ac8e11786248231fbf": EventStarted,
+	"9d1aaa27d60140bd96365438aad20286": EventStopped,
+	"be02cf6855d2428ba40df7e9d022f03d": EventFailed,
+	"5eb03494b6584870a536b337290809b3": EventRestartScheduled,
+	"7d4958e842da4a758f6c1cdc7b36dcc5": EventStarting,
+	"7ad2d189f7e94e70a38c781354912448": EventDeactivated,
+}
+
+// Event is a single lifecycle transition of a unit
+type Event struct {
+	Unit string
+	Time time.Time
+	Kind EventKind
+}
+
+// Reader provides unit lifecycle events for a boot. Boot "0" is the current boot.
+type Reader interface {
+	Events(ctx context.Context, boot string) ([]Event, error)
+}
+
+// Journalctl reads events by running journalctl
+type Journalctl struct{}
+
+// Events returns the service manager's lifecycle events for a boot
+func (Journalctl) Events(ctx context.Context, boot string) ([]Event, error) {
+	args := []string{"-b", boot, "-o", "json", "--no-pager", "_PID=1"}
+	ids := make([]string, 0, len(messageKinds))
+	for id := range messageKinds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		args = append(args, "MESSAGE_ID="+id)
+	}
+
+	output, err := exec.CommandContext(ctx, "journalctl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("journalctl failed: %w", err)
+	}
+	return ParseJSON(bytes.NewReader(output))
+}
+
+// ParseJSON parses journalctl -o json output, one JSON object per line.
+// Entries without a unit or with an unknown message ID are ignored.
+func ParseJSON(r io.Reader) ([]Event, error) {
+	var events []Event
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		// Binary fields are arrays, so only decode into raw values
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(line, &fields); err != nil {
+			return nil, fmt.Errorf("invalid journal entry: %w", err)
+		}
+
+		kind, ok := messageKinds[stringField(fields, "MESSAGE_ID")]
+		if !ok {
+			continue
+		}
+		unit := stringField(fields, "UNIT")
+		if unit == "" {
+			continue
+		}
+
+		usec, err := strconv.ParseInt(stringField(fields, "__REALTIME_TIMESTAMP"), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		events = append(events, Event{Unit: unit, Time: time.UnixMicro(usec), Kind: kind})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events, scanner.Err()
+}
+
+// StartsByUnit returns the start attempts of each unit in chronological order
+func StartsByUnit(events []Event) map[string][]time.Time {
+	starts := make(map[string][]time.Time)
+	for _, e := range events {
+		if e.Kind == EventStarted {
+			starts[e.Unit] = append(starts[e.Unit], e.Time)
+		}
+	}
+	return starts
+}
+
+// RunDurations returns how long each run of a unit lasted, from the start job
+// beginning until the unit stopped, failed or finished. Runs still in progress
+// are left out.
+func RunDurations(events []Event) map[string][]time.Duration {
+	durations := make(map[string][]time.Duration)
+	starting := make(map[string]time.Time)
+	for _, e := range events {
+		switch e.Kind {
+		case EventStarting:
+			if _, running := starting[e.Unit]; !running {
+				starting[e.Unit] = e.Time
+			}
+		case EventStopped, EventFailed, EventDeactivated:
+			if start, running := starting[e.Unit]; running {
+				durations[e.Unit] = append(durations[e.Unit], e.Time.Sub(start))
+				delete(starting, e.Unit)
+			}
+		}
+	}
+	return durations
+}
+
+// StartDurations returns how long each start job of a unit took, from the job
+// beginning until the unit was up. Starts without a recorded beginning are
+// left out.
+func StartDurations(events []Event) map[string][]time.Duration {
+	durations := make(map[string][]time.Duration)
+	starting := make(map[string]time.Time)
+	for _, e := range events {
+		switch e.Kind {
+		case EventStarting:
+			starting[e.Unit] = e.Time
+		case EventStarted:
+			if start, ok := starting[e.Unit]; ok {
+				durations[e.Unit] = append(durations[e.Unit], e.Time.Sub(start))
+				delete(starting, e.Unit)
+			}
+		}
+	}
+	return durations
+}
+
+// ReadBoots returns the events of the current boot and up to boots-1 earlier
+// boots, newest first. Only the current boot is required; reading stops at the
+// first earlier boot the journal no longer has, or with ctx's error when ctx
+// is cancelled.
+func ReadBoots(ctx context.Context, r Reader, boots int) ([][]Event, error) {
+	var all [][]Event
+	for i := 0; i < boots; i++ {
+		events, err := r.Events(ctx, strconv.Itoa(-i))
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			break
+		}
+		all = append(all, events)
+	}
+	return all, nil
+}
+
+// MaxInWindow returns the largest number of times that fall within any window
+// of the given length, and the index of the last time in that window. The
+// times must be sorted.
+func MaxInWindow(times []time.Time, window time.Duration) (count, end int) {
+	start := 0
+	for i := range times {
+		for times[i].Sub(times[start]) > window {
+			start++
+		}
+		if n := i - start + 1; n > count {
+			count, end = n, i
+		}
+	}
+	return count, end
+}
+
+func stringField(fields map[string]json.RawMessage, key string) string {
+	raw, ok := fields[key]
+	if !ok {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,196 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJSON(t *testing.T) {
+	output := `{"__REALTIME_TIMESTAMP":"1700000002000000","MESSAGE_ID":"39f53479d3a045ac8e11786248231fbf","UNIT":"app.service","MESSAGE":"Started app.service."}
+{"__REALTIME_TIMESTAMP":"1700000001000000","MESSAGE_ID":"5eb03494b6584870a536b337290809b3","UNIT":"app.service","N_RESTARTS":"3"}
+{"__REALTIME_TIMESTAMP":"1700000003000000","MESSAGE_ID":"39f53479d3a045ac8e11786248231fbf","MESSAGE":"no unit field"}
+{"__REALTIME_TIMESTAMP":"1700000004000000","MESSAGE_ID":"00000000000000000000000000000000","UNIT":"app.service"}
+{"__REALTIME_TIMESTAMP":"1700000005000000","MESSAGE_ID":"be02cf6855d2428ba40df7e9d022f03d","UNIT":"db.service","MESSAGE":[100,98]}
+`
+	events, err := ParseJSON(strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	if events[0].Kind != EventRestartScheduled || events[1].Kind != EventStarted || events[2].Kind != EventFailed {
+		t.Errorf("events not in chronological order: %+v", events)
+	}
+	if events[2].Unit != "db.service" {
+		t.Errorf("events[2].Unit = %q, want db.service", events[2].Unit)
+	}
+	if !events[1].Time.Equal(time.UnixMicro(1700000002000000)) {
+		t.Errorf("events[1].Time = %v", events[1].Time)
+	}
+}
+
+func TestParseJSONInvalid(t *testing.T) {
+	if _, err := ParseJSON(strings.NewReader("not json\n")); err == nil {
+		t.Error("expected error for invalid entry")
+	}
+}
+
+func TestMaxInWindow(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	at := func(secs ...int) []time.Time {
+		var times []time.Time
+		for _, s := range secs {
+			times = append(times, base.Add(time.Duration(s)*time.Second))
+		}
+		return times
+	}
+
+	tests := []struct {
+		name      string
+		times     []time.Time
+		window    time.Duration
+		wantCount int
+		wantEnd   int
+	}{
+		{"empty", nil, 10 * time.Second, 0, 0},
+		{"spread out", at(0, 20, 40), 10 * time.Second, 1, 0},
+		{"burst at end", at(0, 30, 31, 32, 33), 10 * time.Second, 4, 4},
+		{"window boundary inclusive", at(0, 10), 10 * time.Second, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, end := MaxInWindow(tt.times, tt.window)
+			if count != tt.wantCount || end != tt.wantEnd {
+				t.Errorf("MaxInWindow() = (%d, %d), want (%d, %d)", count, end, tt.wantCount, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestRunDurations(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	at := func(secs int) time.Time { return base.Add(time.Duration(secs) * time.Second) }
+
+	events := []Event{
+		{Unit: "backup.service", Time: at(0), Kind: EventStarting},
+		{Unit: "backup.service", Time: at(90), Kind: EventStarted},
+		{Unit: "backup.service", Time: at(90), Kind: EventDeactivated},
+		{Unit: "backup.service", Time: at(3600), Kind: EventStarting},
+		{Unit: "backup.service", Time: at(3700), Kind: EventFailed},
+		{Unit: "web.service", Time: at(5), Kind: EventStarting},
+		{Unit: "web.service", Time: at(6), Kind: EventStarted},
+	}
+
+	durations := RunDurations(events)
+	backup := durations["backup.service"]
+	if len(backup) != 2 || backup[0] != 90*time.Second || backup[1] != 100*time.Second {
+		t.Errorf("backup.service durations = %v, want [1m30s 1m40s]", backup)
+	}
+	if _, ok := durations["web.service"]; ok {
+		t.Error("runs still in progress should not have a duration")
+	}
+}
+
+func TestStartDurations(t *testing.T) {
+	f, err := os.Open("../../testdata/journal/boots/boot0.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	events, err := ParseJSON(f)
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+
+	durations := StartDurations(events)
+	tests := []struct {
+		unit string
+		want []time.Duration
+	}{
+		{"postgresql.service", []time.Duration{3500 * time.Millisecond}},
+		{"nginx.service", []time.Duration{1200 * time.Millisecond}},
+		{"app.service", []time.Duration{400 * time.Millisecond, 2 * time.Second}},
+		{"slow.service", nil},
+	}
+	for _, tt := range tests {
+		got := durations[tt.unit]
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s start durations = %v, want %v", tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	ms := func(values ...int) []time.Duration {
+		var durations []time.Duration
+		for _, v := range values {
+			durations = append(durations, time.Duration(v)*time.Millisecond)
+		}
+		return durations
+	}
+
+	tests := []struct {
+		name string
+		in   []time.Duration
+		want Stats
+	}{
+		{"empty", nil, Stats{}},
+		{"single", ms(300), Stats{Samples: 1, Median: 300 * time.Millisecond, P95: 300 * time.Millisecond}},
+		{"even count", ms(400, 100, 300, 200), Stats{Samples: 4, Median: 250 * time.Millisecond, P95: 400 * time.Millisecond}},
+		{"p95 below max", ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 500), Stats{Samples: 21, Median: 11 * time.Millisecond, P95: 20 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.in); got != tt.want {
+				t.Errorf("Summarize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// bootJournal serves events for the boots it has and fails for any other
+type bootJournal map[string][]Event
+
+func (b bootJournal) Events(_ context.Context, boot string) ([]Event, error) {
+	events, ok := b[boot]
+	if !ok {
+		return nil, fmt.Errorf("no boot %s", boot)
+	}
+	return events, nil
+}
+
+func TestReadBoots(t *testing.T) {
+	r := bootJournal{
+		"0":  {{Unit: "a.service"}},
+		"-1": {{Unit: "b.service"}},
+		"-3": {{Unit: "c.service"}},
+	}
+
+	boots, err := ReadBoots(context.Background(), r, 5)
+	if err != nil {
+		t.Fatalf("ReadBoots failed: %v", err)
+	}
+	if len(boots) != 2 || boots[1][0].Unit != "b.service" {
+		t.Errorf("ReadBoots should stop at the first missing boot, got %+v", boots)
+	}
+
+	if _, err := ReadBoots(context.Background(), bootJournal{}, 5); err == nil {
+		t.Error("expected error when the current boot is unavailable")
+	}
+
+	// A cancelled read is not mistaken for the end of the journal
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ReadBoots(ctx, bootJournal{"0": r["0"]}, 5); err != context.Canceled {
+		t.Errorf("ReadBoots with a cancelled context = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,38 @@
+package journal
+
+import (
+	"sort"
+	"time"
+)
+
+// Stats summarizes repeated measurements of a duration
+type Stats struct {
+	Samples int
+	Median  time.Duration
+	P95     time.Duration
+}
+
+// Summarize returns the median and 95th percentile of durations. The
+// percentile uses the nearest-rank method, so with few samples it is the
+// slowest one.
+func Summarize(durations []time.Duration) Stats {
+	if len(durations) == 0 {
+		return Stats{}
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	n := len(sorted)
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	rank := (95*n + 99) / 100
+	return Stats{
+		Samples: n,
+		Median:  median,
+		P95:     sorted[rank-1],
+	}
+}
@@ -0,0 +1,95 @@
+// Package progress draws the progress of a long scan on a terminal.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/style"
+)
+
+// barWidth is the number of cells of the bar
+const barWidth = 30
+
+// interval is the shortest time between two redraws of the bar
+const interval = 100 * time.Millisecond
+
+// labels name the stages of a scan
+var labels = map[string]string{
+	analyzer.StageLoad:  "Reading unit paths",
+	analyzer.StageParse: "Parsing units",
+	analyzer.StageCheck: "Checking units",
+}
+
+// Bar draws a progress bar on one line, redrawn in place. In ASCII mode it
+// prints one line as each stage finishes instead, which screen readers can
+// follow.
+type Bar struct {
+	w     io.Writer
+	style style.Provider
+	// now returns the current time, replaced in tests
+	now  func() time.Time
+	last time.Time
+	// drawn is the length of the line on screen, 0 when there is none
+	drawn int
+}
+
+// New returns a bar writing to w, which should be a terminal other than the
+// one the report is written to.
+func New(w io.Writer, p style.Provider) *Bar {
+	return &Bar{w: w, style: p, now: time.Now}
+}
+
+// Update shows that done of total items of a stage are finished. It has the
+// signature of analyzer.ProgressFunc.
+func (b *Bar) Update(stage string, done, total int) {
+	label, ok := labels[stage]
+	if !ok {
+		label = stage
+	}
+	finished := done >= total
+
+	if b.style.ASCII() {
+		if finished {
+			fmt.Fprintf(b.w, "%s: %d of %d done\n", label, done, total)
+		}
+		return
+	}
+
+	// Redraw at most every interval, but always show the end of a stage
+	now := b.now()
+	if !finished && now.Sub(b.last) < interval {
+		return
+	}
+	b.last = now
+
+	filled := 0
+	if total > 0 {
+		filled = done * barWidth / total
+	}
+	line := fmt.Sprintf("%-18s %s %d/%d", label, b.style.Bar(barWidth, filled), done, total)
+	b.draw(line)
+}
+
+// Done removes the bar, so that output written after it starts on an empty
+// line
+func (b *Bar) Done() {
+	if b.drawn > 0 {
+		fmt.Fprint(b.w, "\r"+strings.Repeat(" ", b.drawn)+"\r")
+		b.drawn = 0
+	}
+}
+
+// draw replaces the line on screen, padding it to cover a longer one
+func (b *Bar) draw(line string) {
+	width := len([]rune(line))
+	pad := ""
+	if b.drawn > width {
+		pad = strings.Repeat(" ", b.drawn-width)
+	}
+	fmt.Fprint(b.w, "\r"+line+pad)
+	b.drawn = width + len(pad)
+}
@@ -0,0 +1,63 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/style"
+)
+
+func TestBarRedrawsInPlace(t *testing.T) {
+	var buf bytes.Buffer
+	b := New(&buf, style.New(false, false))
+	clock := time.Unix(0, 0)
+	b.now = func() time.Time { return clock }
+
+	b.Update(analyzer.StageParse, 1, 4)
+	// Within the interval only the end of a stage is drawn
+	b.Update(analyzer.StageParse, 2, 4)
+	clock = clock.Add(time.Second)
+	b.Update(analyzer.StageParse, 3, 4)
+	b.Update(analyzer.StageParse, 4, 4)
+
+	out := buf.String()
+	if strings.Contains(out, "\n") {
+		t.Errorf("bar wrote a newline: %q", out)
+	}
+	if strings.Contains(out, "2/4") {
+		t.Errorf("bar redrew within the interval: %q", out)
+	}
+	for _, want := range []string{"Parsing units", "1/4", "3/4", "4/4"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+
+	buf.Reset()
+	b.Done()
+	if strings.TrimSpace(buf.String()) != "" || !strings.HasSuffix(buf.String(), "\r") {
+		t.Errorf("Done() wrote %q, want the line blanked", buf.String())
+	}
+	buf.Reset()
+	b.Done()
+	if buf.Len() != 0 {
+		t.Errorf("second Done() wrote %q", buf.String())
+	}
+}
+
+func TestBarASCII(t *testing.T) {
+	var buf bytes.Buffer
+	b := New(&buf, style.New(false, true))
+
+	for i := 1; i <= 3; i++ {
+		b.Update(analyzer.StageCheck, i, 3)
+	}
+	b.Done()
+
+	if got, want := buf.String(), "Checking units: 3 of 3 done\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
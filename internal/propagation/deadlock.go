@@ -8,10 +8,20 @@ import (
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
+// Deadlock patterns found by DetectDeadlocks.
+const (
+	DeadlockBoundRequires   = "bound_requires"    // A has After= and BindsTo=B, B has Requires=A
+	DeadlockMutualAfter     = "mutual_after"      // A has After= and BindsTo=B, B has After=A
+	DeadlockMutualBindsTo   = "mutual_binds_to"   // A and B have After= and BindsTo= on each other
+	DeadlockTransitive      = "transitive"        // A BindsTo B, B After C, C Requires A
+	DeadlockBindsToConflict = "binds_to_conflict" // A BindsTo B and Conflicts with a unit B requires
+)
+
 // RestartDeadlock represents a scenario where units cannot restart properly.
 type RestartDeadlock struct {
 	UnitA      string       // Unit A has After=B and BindsTo=B
 	UnitB      string       // Unit B depends on A somehow
+	Pattern    string       // One of the Deadlock* patterns
 	Scenario   string       // Description of the deadlock
 	Severity   string       // "critical", "high", "medium"
 	Edges      []graph.Edge // Edges involved
@@ -79,8 +89,9 @@ func DetectDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) DeadlockR
 				// Direct Requires
 				if requiresDeps[unitB] != nil && requiresDeps[unitB][unitA] {
 					deadlocks = append(deadlocks, RestartDeadlock{
-						UnitA: unitA,
-						UnitB: unitB,
+						UnitA:   unitA,
+						UnitB:   unitB,
+						Pattern: DeadlockBoundRequires,
 						Scenario: fmt.Sprintf(
 							"%s has After=%s and BindsTo=%s. "+
 								"%s has Requires=%s. "+
@@ -100,8 +111,9 @@ func DetectDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) DeadlockR
 				// Direct After (ordering deadlock)
 				if afterDeps[unitB] != nil && afterDeps[unitB][unitA] {
 					deadlocks = append(deadlocks, RestartDeadlock{
-						UnitA: unitA,
-						UnitB: unitB,
+						UnitA:   unitA,
+						UnitB:   unitB,
+						Pattern: DeadlockMutualAfter,
 						Scenario: fmt.Sprintf(
 							"%s has After=%s and BindsTo=%s. "+
 								"%s has After=%s. "+
@@ -116,8 +128,9 @@ func DetectDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) DeadlockR
 				// BindsTo in both directions
 				if bindsToDeps[unitB] != nil && bindsToDeps[unitB][unitA] {
 					deadlocks = append(deadlocks, RestartDeadlock{
-						UnitA: unitA,
-						UnitB: unitB,
+						UnitA:   unitA,
+						UnitB:   unitB,
+						Pattern: DeadlockMutualBindsTo,
 						Scenario: fmt.Sprintf(
 							"Mutual BindsTo between %s and %s with After= ordering. "+
 								"If either stops, both stop and may not restart correctly.",
@@ -145,8 +158,9 @@ func DetectDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) DeadlockR
 				// Check if C requires A
 				if requiresDeps[unitC] != nil && requiresDeps[unitC][unitA] {
 					deadlocks = append(deadlocks, RestartDeadlock{
-						UnitA: unitA,
-						UnitB: unitB,
+						UnitA:   unitA,
+						UnitB:   unitB,
+						Pattern: DeadlockTransitive,
 						Scenario: fmt.Sprintf(
 							"Transitive deadlock: %s BindsTo %s, %s After %s, %s Requires %s. "+
 								"If %s stops, %s stops. %s can't start until %s which needs %s.",
@@ -185,8 +199,9 @@ func DetectDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) DeadlockR
 			for conflictUnit := range conflictsDeps[unitA] {
 				if requiresDeps[unitB][conflictUnit] {
 					deadlocks = append(deadlocks, RestartDeadlock{
-						UnitA: unitA,
-						UnitB: unitB,
+						UnitA:   unitA,
+						UnitB:   unitB,
+						Pattern: DeadlockBindsToConflict,
 						Scenario: fmt.Sprintf(
 							"%s BindsTo %s, but %s Conflicts with %s which %s Requires. "+
 								"This creates an impossible state.",
@@ -199,9 +214,19 @@ func DetectDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) DeadlockR
 		}
 	}
 
-	// Sort by severity
+	// Sort by severity, then units and pattern so the kept duplicate is stable
 	sort.Slice(deadlocks, func(i, j int) bool {
-		return severityOrder(deadlocks[i].Severity) < severityOrder(deadlocks[j].Severity)
+		a, b := deadlocks[i], deadlocks[j]
+		if severityOrder(a.Severity) != severityOrder(b.Severity) {
+			return severityOrder(a.Severity) < severityOrder(b.Severity)
+		}
+		if a.UnitA != b.UnitA {
+			return a.UnitA < b.UnitA
+		}
+		if a.UnitB != b.UnitB {
+			return a.UnitB < b.UnitB
+		}
+		return a.Pattern < b.Pattern
 	})
 
 	// Deduplicate (A-B and B-A are the same deadlock)
@@ -241,6 +266,7 @@ type TimeoutDeadlock struct {
 	Unit        string
 	Description string
 	Severity    string
+	Resolution  string
 }
 
 // DetectTimeoutDeadlocks finds scenarios where job-level timeouts
@@ -282,7 +308,8 @@ func DetectTimeoutDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) []
 						"Job timeout starts at transaction begin, so waiting for dependencies "+
 						"consumes the timeout budget.",
 					name, jobTimeout, len(transDeps), afterCount),
-				Severity: "medium",
+				Severity:   "medium",
+				Resolution: "Raise JobTimeoutSec or remove it and rely on TimeoutStartSec",
 			})
 		}
 	}
@@ -292,10 +319,11 @@ func DetectTimeoutDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) []
 
 // WaitDeadlock represents units that might wait indefinitely.
 type WaitDeadlock struct {
-	Unit     string
-	WaitsFor string
-	Reason   string
-	Severity string
+	Unit       string
+	WaitsFor   string
+	Reason     string
+	Severity   string
+	Resolution string
 }
 
 // DetectWaitDeadlocks finds scenarios where units might wait indefinitely.
@@ -321,7 +349,8 @@ func DetectWaitDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) []Wai
 					"%s has Requisite=%s but %s doesn't exist. "+
 						"%s will never start.",
 					edge.From, edge.To, edge.To, edge.From),
-				Severity: "critical",
+				Severity:   "critical",
+				Resolution: fmt.Sprintf("Install %s or remove Requisite=%s", edge.To, edge.To),
 			})
 			continue
 		}
@@ -337,7 +366,8 @@ func DetectWaitDeadlocks(g *graph.Graph, units map[string]*types.UnitFile) []Wai
 							"%s has Requisite=%s, but %s has %s conditions. "+
 								"If conditions fail, %s cannot start.",
 							edge.From, edge.To, edge.To, key, edge.From),
-						Severity: "medium",
+						Severity:   "medium",
+						Resolution: fmt.Sprintf("Use Requires= with After=%s unless %s must only start when %s already runs", edge.To, edge.From, edge.To),
 					})
 					break
 				}
@@ -2,6 +2,8 @@
 package propagation
 
 import (
+	"sort"
+
 	"github.com/supabase/sdaudit/internal/graph"
 	"github.com/supabase/sdaudit/pkg/types"
 )
@@ -75,117 +77,216 @@ func GetSemantics(et graph.EdgeType) PropagationSemantics {
 
 // FailureImpact represents the impact of a unit failing.
 type FailureImpact struct {
-	FailedUnit    string
-	AffectedUnits []AffectedUnit
-	TotalAffected int
-	CriticalChain []string // Most severe propagation chain
+	FailedUnit    string         `json:"failed_unit"`
+	Scenarios     []string       `json:"scenarios"`
+	AffectedUnits []AffectedUnit `json:"affected_units"`
+	TotalAffected int            `json:"total_affected"`
+	CriticalChain []string       `json:"critical_chain"` // Most severe propagation chain
 }
 
 // AffectedUnit represents a unit affected by a failure.
 type AffectedUnit struct {
-	Name            string
-	Impact          string // "stop", "fail_to_start", "restart"
-	PropagationPath []string
-	EdgeType        graph.EdgeType
-	Severity        string // "critical", "high", "medium", "low"
+	Name string `json:"name"`
+	// Impact is the strongest impact on the unit, "stop" or "fail_to_start".
+	// PropagationPath, EdgeType and Severity are those of its path.
+	Impact          string         `json:"impact"`
+	PropagationPath []string       `json:"propagation_path"`
+	EdgeType        graph.EdgeType `json:"edge_type"`
+	Severity        string         `json:"severity"` // "critical", "high", "medium", "low"
+	// Paths holds the shortest propagation path of each impact on the unit
+	Paths map[string][]string `json:"paths"`
 }
 
-// SimulateFailure simulates what happens when a unit fails.
-// Returns all units that would be affected and how.
-func SimulateFailure(g *graph.Graph, failedUnit string) FailureImpact {
-	impact := FailureImpact{
-		FailedUnit: failedUnit,
+// Impacts returns the impacts on the unit, the strongest first
+func (a AffectedUnit) Impacts() []string {
+	var impacts []string
+	for _, impact := range []string{ImpactStop, ImpactFailToStart} {
+		if _, ok := a.Paths[impact]; ok {
+			impacts = append(impacts, impact)
+		}
 	}
+	return impacts
+}
 
-	visited := make(map[string]bool)
-	var propagate func(unit string, path []string, impactType string)
+// Scenarios for Simulate
+const (
+	ScenarioFail = "fail" // The unit fails to start
+	ScenarioStop = "stop" // The unit stops
+)
 
-	propagate = func(unit string, path []string, impactType string) {
-		if visited[unit] {
-			return
-		}
-		visited[unit] = true
+// Impacts on affected units. A running unit being stopped is a stronger
+// effect than a unit not being started.
+const (
+	ImpactFailToStart = "fail_to_start"
+	ImpactStop        = "stop"
+)
 
-		// Find units that depend on this one
-		edges := g.EdgesTo(unit)
-		for _, edge := range edges {
-			sem := GetSemantics(edge.Type)
-			dependent := edge.From
+// impactRank orders impacts from the weakest to the strongest
+var impactRank = map[string]int{
+	ImpactFailToStart: 1,
+	ImpactStop:        2,
+}
 
-			newPath := append([]string{}, path...)
-			newPath = append(newPath, dependent)
+// severityRank orders the severities of the edges impacts travel along
+var severityRank = map[string]int{
+	"medium":   1,
+	"high":     2,
+	"critical": 3,
+}
 
-			var newImpact string
-			shouldPropagate := false
+// edgeSeverity returns how severe an impact arriving along an edge type is
+func edgeSeverity(et graph.EdgeType) string {
+	switch et {
+	case graph.EdgeRequisite:
+		return "critical"
+	case graph.EdgeRequires, graph.EdgeBindsTo:
+		return "high"
+	}
+	return "medium"
+}
 
-			switch impactType {
-			case "fail":
-				if sem.StartFailure {
-					newImpact = "fail_to_start"
-					shouldPropagate = true
-				}
-			case "stop":
-				if sem.StopPropagates {
-					newImpact = "stop"
-					shouldPropagate = true
-				}
-			}
+// propagates reports whether an impact on a unit travels along a dependency
+// on it, and the impact it has on the dependent
+func propagates(sem PropagationSemantics, impact string) (string, bool) {
+	switch impact {
+	case ScenarioFail, ImpactFailToStart:
+		return ImpactFailToStart, sem.StartFailure
+	case ImpactStop:
+		return ImpactStop, sem.StopPropagates
+	}
+	return "", false
+}
 
-			if shouldPropagate && !visited[dependent] {
-				severity := "medium"
-				if edge.Type == graph.EdgeBindsTo || edge.Type == graph.EdgeRequires {
-					severity = "high"
-				}
-				if edge.Type == graph.EdgeRequisite {
-					severity = "critical"
-				}
+// SimulateFailure simulates what happens when a unit fails.
+// Returns all units that would be affected and how.
+func SimulateFailure(g *graph.Graph, failedUnit string) FailureImpact {
+	return Simulate(g, failedUnit, ScenarioFail, ScenarioStop)
+}
 
-				impact.AffectedUnits = append(impact.AffectedUnits, AffectedUnit{
-					Name:            dependent,
-					Impact:          newImpact,
-					PropagationPath: newPath,
-					EdgeType:        edge.Type,
-					Severity:        severity,
-				})
+// Simulate simulates the given scenarios for a unit, in order.
+// Returns all units that would be affected and how, each once with its
+// strongest impact, in the order the impacts reach them. The critical chain
+// is the longest of the shortest paths along which an impact travels through
+// Requires=, Requisite= and BindsTo= alone.
+func Simulate(g *graph.Graph, failedUnit string, scenarios ...string) FailureImpact {
+	impact := FailureImpact{
+		FailedUnit:    failedUnit,
+		Scenarios:     scenarios,
+		AffectedUnits: []AffectedUnit{},
+		CriticalChain: []string{},
+	}
 
-				propagate(dependent, newPath, newImpact)
-			}
+	reached, order := propagate(g, failedUnit, scenarios, false)
+	index := make(map[string]int)
+	for _, s := range order {
+		r := reached[s]
+		i, seen := index[s.unit]
+		if !seen {
+			i = len(impact.AffectedUnits)
+			index[s.unit] = i
+			impact.AffectedUnits = append(impact.AffectedUnits, AffectedUnit{
+				Name:  s.unit,
+				Paths: make(map[string][]string),
+			})
+		}
+		affected := &impact.AffectedUnits[i]
+		affected.Paths[s.impact] = r.path
+		if impactRank[s.impact] > impactRank[affected.Impact] {
+			affected.Impact = s.impact
+			affected.PropagationPath = r.path
+			affected.EdgeType = r.edge
+			affected.Severity = edgeSeverity(r.edge)
 		}
 	}
+	impact.TotalAffected = len(impact.AffectedUnits)
 
-	// Simulate both failure and stop scenarios
-	propagate(failedUnit, []string{failedUnit}, "fail")
+	strong, order := propagate(g, failedUnit, scenarios, true)
+	for _, s := range order {
+		if path := strong[s].path; len(path) > len(impact.CriticalChain) {
+			impact.CriticalChain = path
+		}
+	}
 
-	// Reset for stop propagation
-	visited = make(map[string]bool)
-	visited[failedUnit] = true
-	propagate(failedUnit, []string{failedUnit}, "stop")
+	return impact
+}
 
-	impact.TotalAffected = len(impact.AffectedUnits)
+// impactState is an impact on a unit
+type impactState struct {
+	unit   string
+	impact string
+}
+
+// impactPath is the shortest path an impact takes to a unit, and the edge
+// type of its last step
+type impactPath struct {
+	path []string
+	edge graph.EdgeType
+}
 
-	// Find critical chain (longest high-severity path)
-	var longestCritical []string
-	for _, affected := range impact.AffectedUnits {
-		if affected.Severity == "critical" || affected.Severity == "high" {
-			if len(affected.PropagationPath) > len(longestCritical) {
-				longestCritical = affected.PropagationPath
+// propagate follows the impacts of the scenarios for a unit breadth first,
+// from each unit to the units that depend on it, and returns the shortest
+// path of each impact on each unit, in the order they are reached. Of the
+// paths of the same length the one with the most severe last step is kept.
+// With strongOnly, impacts only travel along Requires=, Requisite= and
+// BindsTo=.
+func propagate(g *graph.Graph, failedUnit string, scenarios []string, strongOnly bool) (map[impactState]*impactPath, []impactState) {
+	reached := make(map[impactState]*impactPath)
+	var order []impactState
+	for _, scenario := range scenarios {
+		start := impactState{failedUnit, scenario}
+		if scenario == ScenarioFail {
+			start.impact = ImpactFailToStart
+		}
+		if _, seen := reached[start]; seen {
+			continue
+		}
+		reached[start] = &impactPath{path: []string{failedUnit}}
+
+		queue := []impactState{start}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			from := reached[current]
+
+			// Units that depend on this one, in a stable order
+			edges := g.EdgesTo(current.unit)
+			sort.Slice(edges, func(i, j int) bool {
+				if edges[i].From != edges[j].From {
+					return edges[i].From < edges[j].From
+				}
+				return edges[i].Type < edges[j].Type
+			})
+			for _, edge := range edges {
+				impact, ok := propagates(GetSemantics(edge.Type), current.impact)
+				if !ok || edge.From == failedUnit || (strongOnly && edgeSeverity(edge.Type) == "medium") {
+					continue
+				}
+				next := impactState{edge.From, impact}
+				path := append(append([]string(nil), from.path...), edge.From)
+				if r, seen := reached[next]; seen {
+					if len(r.path) == len(path) && severityRank[edgeSeverity(edge.Type)] > severityRank[edgeSeverity(r.edge)] {
+						r.path, r.edge = path, edge.Type
+					}
+					continue
+				}
+				reached[next] = &impactPath{path: path, edge: edge.Type}
+				order = append(order, next)
+				queue = append(queue, next)
 			}
 		}
 	}
-	impact.CriticalChain = longestCritical
-
-	return impact
+	return reached, order
 }
 
 // SilentFailure represents a critical unit using weak dependencies.
 type SilentFailure struct {
-	Unit        string         // The critical unit
-	DependedBy  string         // Unit that should require it
-	EdgeType    graph.EdgeType // Wants= instead of Requires=
-	Risk        string         // Severity
-	Description string
-	File        string
-	Line        int
+	Unit        string         `json:"unit"`        // The critical unit
+	DependedBy  string         `json:"depended_by"` // Unit that should require it
+	EdgeType    graph.EdgeType `json:"edge_type"`   // Wants= instead of Requires=
+	Risk        string         `json:"risk"`        // Severity
+	Description string         `json:"description"`
+	File        string         `json:"file,omitempty"`
+	Line        int            `json:"line,omitempty"`
 }
 
 // DetectSilentFailures finds critical units pulled in via Wants= instead of Requires=.
@@ -251,10 +352,10 @@ func DetectSilentFailures(g *graph.Graph, criticalUnits []string) []SilentFailur
 
 // StopOrderInversion represents a potential stop ordering issue.
 type StopOrderInversion struct {
-	Unit        string
-	BoundTo     string
-	Description string
-	Severity    string
+	Unit        string `json:"unit"`
+	BoundTo     string `json:"bound_to"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
 }
 
 // DetectStopOrderInversions finds BindsTo= relationships that may cause
@@ -336,3 +437,38 @@ func Analyze(g *graph.Graph, units map[string]*types.UnitFile) PropagationAnalys
 
 	return analysis
 }
+
+// UnitImpact is the failure impact of a unit together with the dependencies
+// on it that hide or misorder that impact.
+type UnitImpact struct {
+	FailureImpact
+	SilentFailures      []SilentFailure      `json:"silent_failures"`
+	StopOrderInversions []StopOrderInversion `json:"stop_order_inversions"`
+}
+
+// AnalyzeUnit simulates the given scenarios for a unit and finds the units
+// that depend on it through Wants= only, or through BindsTo= without After=.
+func AnalyzeUnit(g *graph.Graph, unit string, scenarios ...string) UnitImpact {
+	result := UnitImpact{
+		FailureImpact:       Simulate(g, unit, scenarios...),
+		SilentFailures:      DetectSilentFailures(g, []string{unit}),
+		StopOrderInversions: []StopOrderInversion{},
+	}
+	if result.SilentFailures == nil {
+		result.SilentFailures = []SilentFailure{}
+	}
+
+	for _, inv := range DetectStopOrderInversions(g) {
+		if inv.BoundTo == unit {
+			result.StopOrderInversions = append(result.StopOrderInversions, inv)
+		}
+	}
+	sort.Slice(result.SilentFailures, func(i, j int) bool {
+		return result.SilentFailures[i].DependedBy < result.SilentFailures[j].DependedBy
+	})
+	sort.Slice(result.StopOrderInversions, func(i, j int) bool {
+		return result.StopOrderInversions[i].Unit < result.StopOrderInversions[j].Unit
+	})
+
+	return result
+}
@@ -0,0 +1,143 @@
+package propagation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Group patterns found by DetectGroupIssues.
+const (
+	GroupNeverStarted      = "group_never_started" // A has PartOf=B, nothing pulls in B
+	GroupReloadUnsupported = "reload_unsupported"  // Reloads of A propagate to B, which cannot reload
+	GroupReloadAsymmetric  = "reload_asymmetric"   // A has ReloadPropagatedFrom=B, B lists its receivers without A
+)
+
+// GroupIssue represents a PartOf= or reload propagation directive that does
+// not do what the unit declaring it expects.
+type GroupIssue struct {
+	Unit       string     // Unit declaring the directive
+	Related    string     // Unit the directive names
+	Pattern    string     // One of the Group* patterns
+	Edge       graph.Edge // Edge of the directive
+	Reason     string
+	Severity   string
+	Resolution string
+}
+
+// DetectGroupIssues checks the PartOf= and reload propagation directives that
+// tie units into groups, such as services PartOf= an application target:
+//
+//   - PartOf= a unit that nothing Requires= or Wants=, so the group is never
+//     started or restarted as a whole
+//   - PropagatesReloadTo= or ReloadPropagatedFrom= sending reloads to a
+//     service without ExecReload= or Type=notify-reload, which cannot reload
+//   - ReloadPropagatedFrom= on a unit left out of the PropagatesReloadTo= list
+//     of the unit it names
+func DetectGroupIssues(g *graph.Graph, units map[string]*types.UnitFile) []GroupIssue {
+	var issues []GroupIssue
+
+	// pulled holds the units another unit, socket, timer or path starts
+	pulled := make(map[string]bool)
+	if root := g.DefaultTarget(); root != "" {
+		pulled[root] = true
+	}
+	// reloadsTo maps each unit to the units its PropagatesReloadTo= names
+	reloadsTo := make(map[string]map[string]bool)
+	for _, edge := range g.Edges() {
+		switch {
+		case edge.Type.IsRequirementEdge() || edge.Type == graph.EdgeTriggeredBy:
+			pulled[g.Resolve(edge.To)] = true
+		case edge.Type == graph.EdgePropagatesReloadTo:
+			if reloadsTo[edge.From] == nil {
+				reloadsTo[edge.From] = make(map[string]bool)
+			}
+			reloadsTo[edge.From][g.Resolve(edge.To)] = true
+		}
+	}
+
+	for _, edge := range g.Edges() {
+		switch edge.Type {
+		case graph.EdgePartOf:
+			if pulled[g.Resolve(edge.To)] {
+				continue
+			}
+			reason := fmt.Sprintf("%s has PartOf=%s, but no unit requires or wants %s, so the group is never started or restarted as a whole.",
+				edge.From, edge.To, edge.To)
+			if _, ok := units[g.Resolve(edge.To)]; !ok {
+				reason = fmt.Sprintf("%s has PartOf=%s, but %s does not exist, so the group is never started or restarted as a whole.",
+					edge.From, edge.To, edge.To)
+			}
+			issues = append(issues, GroupIssue{
+				Unit:       edge.From,
+				Related:    edge.To,
+				Pattern:    GroupNeverStarted,
+				Edge:       edge,
+				Reason:     reason,
+				Severity:   "medium",
+				Resolution: fmt.Sprintf("Pull in %s with WantedBy= in its [Install] section or Wants= on another unit", edge.To),
+			})
+
+		case graph.EdgePropagatesReloadTo, graph.EdgeReloadPropagatedFrom:
+			receiver := edge.To
+			if edge.Type == graph.EdgeReloadPropagatedFrom {
+				receiver = edge.From
+			}
+			if unit := units[g.Resolve(receiver)]; unit != nil && !canReload(unit) {
+				issues = append(issues, GroupIssue{
+					Unit:    edge.From,
+					Related: edge.To,
+					Pattern: GroupReloadUnsupported,
+					Edge:    edge,
+					Reason: fmt.Sprintf("%s has %s=%s, but %s has no ExecReload= and is not Type=notify-reload, so systemd skips the propagated reload.",
+						edge.From, edge.Type, edge.To, receiver),
+					Severity:   "medium",
+					Resolution: fmt.Sprintf("Add ExecReload= to %s, or remove %s=%s", receiver, edge.Type, edge.To),
+				})
+			}
+
+			if edge.Type != graph.EdgeReloadPropagatedFrom {
+				continue
+			}
+			listed, ok := reloadsTo[g.Resolve(edge.To)]
+			if !ok || listed[g.Resolve(edge.From)] {
+				continue
+			}
+			issues = append(issues, GroupIssue{
+				Unit:    edge.From,
+				Related: edge.To,
+				Pattern: GroupReloadAsymmetric,
+				Edge:    edge,
+				Reason: fmt.Sprintf("%s has ReloadPropagatedFrom=%s, but %s lists the units it reloads with PropagatesReloadTo= and leaves out %s.",
+					edge.From, edge.To, edge.To, edge.From),
+				Severity:   "low",
+				Resolution: fmt.Sprintf("Add %s to PropagatesReloadTo= of %s, or remove ReloadPropagatedFrom=%s if %s should not reload with it", edge.From, edge.To, edge.To, edge.From),
+			})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Unit != issues[j].Unit {
+			return issues[i].Unit < issues[j].Unit
+		}
+		if issues[i].Related != issues[j].Related {
+			return issues[i].Related < issues[j].Related
+		}
+		return issues[i].Pattern < issues[j].Pattern
+	})
+
+	return issues
+}
+
+// canReload reports whether a unit can be reloaded. Only services need
+// ExecReload=; other unit types are left alone.
+func canReload(unit *types.UnitFile) bool {
+	if !unit.IsService() {
+		return true
+	}
+	return unit.HasDirective("Service", "ExecReload") ||
+		strings.EqualFold(unit.GetDirective("Service", "Type"), "notify-reload")
+}
@@ -1,11 +1,16 @@
 package propagation
 
 import (
+	"fmt"
+	"math/rand"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
-	"github.com/supabase/sdaudit/internal/analyzer"
 	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/unitfile"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -15,7 +20,7 @@ func loadTestUnits(t *testing.T, path string) map[string]*types.UnitFile {
 	if err != nil {
 		t.Fatalf("failed to get absolute path: %v", err)
 	}
-	units, err := analyzer.LoadUnitsFromDirectory(absPath)
+	units, err := unitfile.LoadDirectory(absPath)
 	if err != nil {
 		t.Fatalf("failed to load units from %s: %v", path, err)
 	}
@@ -53,6 +58,29 @@ func TestDetectRestartStorms(t *testing.T) {
 	}
 }
 
+func TestDetectRestartStormsCycle(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/propagation/binds_to_cycle")
+	g := graph.Build(units)
+
+	result := DetectRestartStorms(g, units)
+
+	// p.service and q.service only order each other from both sides, which
+	// is not a cycle stops can travel around
+	if len(result.Storms) != 1 {
+		t.Fatalf("expected 1 storm, got %+v", result.Storms)
+	}
+	storm := result.Storms[0]
+	if storm.Pattern != StormBindsToCycle {
+		t.Errorf("Pattern = %s, want %s", storm.Pattern, StormBindsToCycle)
+	}
+	if !reflect.DeepEqual(storm.Units, []string{"x.service", "y.service", "z.service"}) {
+		t.Errorf("Units = %v, want x, y and z", storm.Units)
+	}
+	if storm.Severity != "critical" {
+		t.Errorf("Severity = %s, want critical with two restarting units", storm.Severity)
+	}
+}
+
 func TestDetectDeadlocks(t *testing.T) {
 	units := loadTestUnits(t, "../../testdata/propagation/deadlock")
 	g := graph.Build(units)
@@ -102,6 +130,267 @@ func TestSimulateFailure(t *testing.T) {
 	}
 }
 
+func TestSimulateFailureTransitive(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/graph/reverse_deps")
+	g := graph.Build(units)
+
+	impact := SimulateFailure(g, "db.service")
+
+	got := make(map[string]string)
+	for _, affected := range impact.AffectedUnits {
+		got[affected.Name+" "+affected.Impact] = strings.Join(affected.PropagationPath, " ")
+	}
+	want := map[string]string{
+		"app.service fail_to_start":     "db.service app.service",
+		"worker.service fail_to_start":  "db.service app.service worker.service",
+		"metrics.service fail_to_start": "db.service app.service metrics.service",
+		"sidecar.service stop":          "db.service sidecar.service",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("affected units = %v, want %v", got, want)
+	}
+}
+
+func TestSimulateScenario(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/graph/reverse_deps")
+	g := graph.Build(units)
+
+	tests := []struct {
+		scenario string
+		want     []string
+	}{
+		{ScenarioFail, []string{"app.service", "metrics.service", "worker.service"}},
+		{ScenarioStop, []string{"sidecar.service"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			impact := Simulate(g, "db.service", tt.scenario)
+			var got []string
+			for _, affected := range impact.AffectedUnits {
+				got = append(got, affected.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("affected = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// handGraph builds a graph of services from edges written as "from Type=to"
+func handGraph(t *testing.T, edges ...string) *graph.Graph {
+	t.Helper()
+	g := graph.New()
+	for _, e := range edges {
+		from, dep, _ := strings.Cut(e, " ")
+		key, to, _ := strings.Cut(dep, "=")
+		edgeType, ok := graph.DirectiveToEdgeType[key]
+		if !ok {
+			t.Fatalf("unknown dependency %s in %q", key, e)
+		}
+		for _, name := range []string{from, to} {
+			if !g.HasUnit(name) {
+				g.AddUnit(&types.UnitFile{Name: name, Type: "service"})
+			}
+		}
+		g.AddEdge(graph.Edge{From: from, To: to, Type: edgeType})
+	}
+	return g
+}
+
+// impactsOf returns the impacts of each affected unit as "unit impact,..."
+func impactsOf(impact FailureImpact) []string {
+	var got []string
+	for _, a := range impact.AffectedUnits {
+		got = append(got, a.Name+" "+strings.Join(a.Impacts(), ","))
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestSimulateStrongestImpact(t *testing.T) {
+	g := handGraph(t,
+		"b Requires=a", "b PartOf=a",
+		"c BindsTo=b",
+		"d PartOf=b",
+		"e Requisite=a",
+		"f Wants=a",
+	)
+
+	impact := SimulateFailure(g, "a")
+	want := []string{"b stop,fail_to_start", "c stop,fail_to_start", "d stop", "e fail_to_start"}
+	if got := impactsOf(impact); !reflect.DeepEqual(got, want) {
+		t.Errorf("impacts = %v, want %v", got, want)
+	}
+	if impact.TotalAffected != len(want) {
+		t.Errorf("TotalAffected = %d, want %d", impact.TotalAffected, len(want))
+	}
+
+	for _, a := range impact.AffectedUnits {
+		switch a.Name {
+		case "b":
+			// The stop along PartOf= is stronger than the failure along Requires=
+			if a.Impact != ImpactStop || a.EdgeType != graph.EdgePartOf || a.Severity != "medium" {
+				t.Errorf("b = %+v, want a medium stop along PartOf=", a)
+			}
+			if !reflect.DeepEqual(a.Paths[ImpactFailToStart], []string{"a", "b"}) {
+				t.Errorf("b fail_to_start path = %v, want a b", a.Paths[ImpactFailToStart])
+			}
+		case "c":
+			if a.Impact != ImpactStop || !reflect.DeepEqual(a.PropagationPath, []string{"a", "b", "c"}) {
+				t.Errorf("c = %+v, want a stop along a b c", a)
+			}
+		case "e":
+			if a.Severity != "critical" {
+				t.Errorf("e severity = %s, want critical along Requisite=", a.Severity)
+			}
+		}
+	}
+
+	// Each scenario on its own reaches the units of its impact only
+	if got := impactsOf(Simulate(g, "a", ScenarioStop)); !reflect.DeepEqual(got, []string{"b stop", "c stop", "d stop"}) {
+		t.Errorf("stop impacts = %v", got)
+	}
+	if got := impactsOf(Simulate(g, "a", ScenarioFail)); !reflect.DeepEqual(got, []string{"b fail_to_start", "c fail_to_start", "e fail_to_start"}) {
+		t.Errorf("fail impacts = %v", got)
+	}
+}
+
+func TestSimulateCriticalChain(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges []string
+		want  []string
+	}{
+		// c is reached along BindsTo=, but b only along PartOf=
+		{"weak step", []string{"b PartOf=a", "c BindsTo=b", "d Requires=a"}, []string{"a", "d"}},
+		{"strong steps", []string{"b PartOf=a", "c BindsTo=b", "d Requires=a", "e BindsTo=d"}, []string{"a", "d", "e"}},
+		// c fails along its own Requisite= before it would along b's failure
+		{"shortest path", []string{"b Requires=a", "c Requires=b", "c Requisite=a"}, []string{"a", "b"}},
+		{"cycle", []string{"b BindsTo=a", "a BindsTo=b"}, []string{"a", "b"}},
+		{"weak only", []string{"b PartOf=a", "c Wants=a"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impact := SimulateFailure(handGraph(t, tt.edges...), "a")
+			if !reflect.DeepEqual(impact.CriticalChain, tt.want) {
+				t.Errorf("CriticalChain = %v, want %v", impact.CriticalChain, tt.want)
+			}
+		})
+	}
+}
+
+// reachedBy returns the units an impact reaches from a unit, following the
+// dependencies on each unit it reaches
+func reachedBy(g *graph.Graph, unit string, impact string) map[string]bool {
+	reached := map[string]bool{unit: true}
+	queue := []string{unit}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, e := range g.EdgesTo(current) {
+			if _, ok := propagates(GetSemantics(e.Type), impact); ok && !reached[e.From] {
+				reached[e.From] = true
+				queue = append(queue, e.From)
+			}
+		}
+	}
+	delete(reached, unit)
+	return reached
+}
+
+// hasEdge reports whether a unit has a dependency on another that one of
+// the edge types matches
+func hasEdge(g *graph.Graph, from, to string, match func(graph.EdgeType) bool) bool {
+	for _, e := range g.EdgesFrom(from) {
+		if e.To == to && match(e.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSimulateProperties(t *testing.T) {
+	edgeTypes := []string{"Requires", "Requisite", "BindsTo", "Wants", "PartOf", "After", "Conflicts"}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		n := 2 + r.Intn(8)
+		var edges []string
+		for j := r.Intn(3 * n); j > 0; j-- {
+			edges = append(edges, fmt.Sprintf("u%d %s=u%d", r.Intn(n), edgeTypes[r.Intn(len(edgeTypes))], r.Intn(n)))
+		}
+		g := handGraph(t, edges...)
+
+		for _, failed := range g.NodeNames() {
+			impact := SimulateFailure(g, failed)
+			context := fmt.Sprintf("graph %v, %s failing", edges, failed)
+
+			want := make(map[string][]string)
+			for _, imp := range []string{ImpactStop, ImpactFailToStart} {
+				for unit := range reachedBy(g, failed, imp) {
+					want[unit] = append(want[unit], imp)
+				}
+			}
+			got := make(map[string][]string)
+			for _, a := range impact.AffectedUnits {
+				if _, dup := got[a.Name]; dup {
+					t.Fatalf("%s: %s is affected twice", context, a.Name)
+				}
+				got[a.Name] = a.Impacts()
+				if a.Impact != a.Impacts()[0] || !reflect.DeepEqual(a.PropagationPath, a.Paths[a.Impact]) {
+					t.Errorf("%s: %s has impact %s along %v, want its strongest impact of %v", context, a.Name, a.Impact, a.PropagationPath, a.Paths)
+				}
+
+				// Each path starts at the failed unit and each step is a
+				// dependency the impact travels along
+				for imp, path := range a.Paths {
+					if path[0] != failed || path[len(path)-1] != a.Name {
+						t.Errorf("%s: %s path %v does not lead from %s to %s", context, imp, path, failed, a.Name)
+					}
+					for k := 1; k < len(path); k++ {
+						propagatesImpact := func(et graph.EdgeType) bool {
+							_, ok := propagates(GetSemantics(et), imp)
+							return ok
+						}
+						if !hasEdge(g, path[k], path[k-1], propagatesImpact) {
+							t.Errorf("%s: %s path %v has no dependency of %s on %s", context, imp, path, path[k], path[k-1])
+						}
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: impacts = %v, want %v", context, got, want)
+			}
+
+			for k := 1; k < len(impact.CriticalChain); k++ {
+				strong := func(et graph.EdgeType) bool {
+					return et == graph.EdgeRequires || et == graph.EdgeRequisite || et == graph.EdgeBindsTo
+				}
+				if !hasEdge(g, impact.CriticalChain[k], impact.CriticalChain[k-1], strong) {
+					t.Errorf("%s: critical chain %v has no strong dependency of %s on %s", context, impact.CriticalChain, impact.CriticalChain[k], impact.CriticalChain[k-1])
+				}
+			}
+		}
+	}
+}
+
+func TestAnalyzeUnit(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/graph/reverse_deps")
+	g := graph.Build(units)
+
+	db := AnalyzeUnit(g, "db.service", ScenarioFail)
+	if len(db.SilentFailures) != 1 || db.SilentFailures[0].DependedBy != "backup.service" {
+		t.Errorf("silent failures = %+v, want backup.service", db.SilentFailures)
+	}
+
+	app := AnalyzeUnit(g, "app.service", ScenarioFail, ScenarioStop)
+	if len(app.StopOrderInversions) != 1 || app.StopOrderInversions[0].Unit != "metrics.service" {
+		t.Errorf("stop order inversions = %+v, want metrics.service", app.StopOrderInversions)
+	}
+	if len(app.CriticalChain) != 2 {
+		t.Errorf("critical chain = %v", app.CriticalChain)
+	}
+}
+
 func TestGetSemantics(t *testing.T) {
 	// Test Requires semantics
 	reqSem := GetSemantics(graph.EdgeRequires)
@@ -214,3 +503,26 @@ func TestDetectSilentFailures(t *testing.T) {
 		t.Error("expected to detect silent failure risk for dbus.service")
 	}
 }
+
+func TestDetectGroupIssues(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/propagation/stack")
+	g := graph.Build(units)
+
+	type finding struct{ unit, related, pattern string }
+	var got []finding
+	for _, issue := range DetectGroupIssues(g, units) {
+		got = append(got, finding{issue.Unit, issue.Related, issue.Pattern})
+	}
+
+	// app.target is wanted by multi-user.target, batch.target by nothing;
+	// metrics.service reloads with Type=notify-reload and cache.service does
+	// not list the units it reloads
+	want := []finding{
+		{"api.service", "worker.service", GroupReloadUnsupported},
+		{"batch.service", "batch.target", GroupNeverStarted},
+		{"cache.service", "api.service", GroupReloadAsymmetric},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectGroupIssues() = %+v, want %+v", got, want)
+	}
+}
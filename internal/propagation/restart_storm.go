@@ -8,13 +8,22 @@ import (
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
+// Restart storm patterns found by DetectRestartStorms.
+const (
+	StormMutualBindsTo = "mutual_binds_to" // A and B have BindsTo= on each other and Restart=
+	StormBindsToCycle  = "binds_to_cycle"  // A dependency cycle with BindsTo= and Restart=
+	StormBoundRestart  = "bound_restart"   // A BindsTo B, B restarts but A does not
+)
+
 // RestartStorm represents a potential cascading restart scenario.
 type RestartStorm struct {
 	Units       []string // Units involved in the storm
 	Trigger     string   // Initial failure point
 	Cycle       []string // If cyclic, the cycle path
+	Pattern     string   // One of the Storm* patterns
 	Severity    string   // "critical", "high", "medium"
 	Description string
+	Resolution  string
 	Evidence    []StormEdge // Edges causing propagation
 }
 
@@ -96,12 +105,14 @@ func DetectRestartStorms(g *graph.Graph, units map[string]*types.UnitFile) Resta
 							Units:    []string{unitA, unitB},
 							Trigger:  unitA,
 							Cycle:    []string{unitA, unitB, unitA},
+							Pattern:  StormMutualBindsTo,
 							Severity: severity,
 							Description: fmt.Sprintf(
 								"Mutual BindsTo between %s and %s with Restart= enabled. "+
 									"If either fails, both will stop and attempt to restart, "+
 									"potentially causing a restart loop.",
 								unitA, unitB),
+							Resolution: "Replace BindsTo= with Requires= in one direction",
 							Evidence: []StormEdge{
 								{From: unitA, To: unitB, Type: graph.EdgeBindsTo, Reason: "BindsTo triggers stop on failure"},
 								{From: unitB, To: unitA, Type: graph.EdgeBindsTo, Reason: "BindsTo triggers stop on failure"},
@@ -133,9 +144,20 @@ func DetectRestartStorms(g *graph.Graph, units map[string]*types.UnitFile) Resta
 	}
 
 	// Pattern 2: BindsTo chain with Restart forming a cycle
-	// A BindsTo B, B BindsTo C, C has something that triggers A
-	cycles := g.FindCycles()
+	// A BindsTo B, B BindsTo C, C PartOf A: a stop anywhere comes back around
+	cycles := g.FindStopCycles()
 	for _, scc := range cycles {
+		// Mutual BindsTo between two units is pattern 1
+		if len(scc.Units) == 2 {
+			key := scc.Units[0] + ":" + scc.Units[1]
+			if scc.Units[1] < scc.Units[0] {
+				key = scc.Units[1] + ":" + scc.Units[0]
+			}
+			if checked[key] {
+				continue
+			}
+		}
+
 		// Check if cycle involves BindsTo and Restart
 		hasBindsTo := false
 		hasRestart := false
@@ -176,12 +198,14 @@ func DetectRestartStorms(g *graph.Graph, units map[string]*types.UnitFile) Resta
 				Units:    scc.Units,
 				Trigger:  scc.Units[0],
 				Cycle:    append(scc.Units, scc.Units[0]),
+				Pattern:  StormBindsToCycle,
 				Severity: severity,
 				Description: fmt.Sprintf(
 					"Dependency cycle involving %d units with BindsTo and Restart=. "+
 						"Units: %v. A failure in this cycle could cause cascading restarts.",
 					len(scc.Units), scc.Units),
-				Evidence: evidence,
+				Resolution: "Break the dependency cycle or replace BindsTo= with Requires=",
+				Evidence:   evidence,
 			})
 		}
 	}
@@ -204,12 +228,14 @@ func DetectRestartStorms(g *graph.Graph, units map[string]*types.UnitFile) Resta
 				storms = append(storms, RestartStorm{
 					Units:    []string{unitA, unitB},
 					Trigger:  unitB,
+					Pattern:  StormBoundRestart,
 					Severity: "medium",
 					Description: fmt.Sprintf(
 						"%s has BindsTo=%s, and %s has Restart=%s. "+
 							"If %s fails and restarts, %s will be stopped but has no Restart= policy "+
 							"to automatically recover.",
 						unitA, unitB, unitB, policy, unitB, unitA),
+					Resolution: fmt.Sprintf("Add Restart= to %s so it recovers when %s restarts", unitA, unitB),
 					Evidence: []StormEdge{
 						{From: unitA, To: unitB, Type: graph.EdgeBindsTo, Reason: "BindsTo causes stop when " + unitB + " stops"},
 						{From: unitB, To: unitB, Type: graph.EdgeRequires, Reason: fmt.Sprintf("Restart=%s", policy)},
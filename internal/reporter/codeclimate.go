@@ -0,0 +1,109 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// CodeClimateReporter outputs issues as a CodeClimate JSON array, the Code
+// Quality report GitLab shows on merge requests
+type CodeClimateReporter struct {
+	w           io.Writer
+	pretty      bool
+	stripPrefix string
+}
+
+// NewCodeClimateReporter creates a new CodeClimate reporter. stripPrefix is
+// cut from the start of file paths, which are written as given otherwise.
+func NewCodeClimateReporter(w io.Writer, pretty bool, stripPrefix string) *CodeClimateReporter {
+	return &CodeClimateReporter{w: w, pretty: pretty, stripPrefix: stripPrefix}
+}
+
+// CodeClimateIssue is one entry of a CodeClimate report
+type CodeClimateIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Categories  []string            `json:"categories"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    CodeClimateLocation `json:"location"`
+}
+
+type CodeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines CodeClimateLines `json:"lines"`
+}
+
+type CodeClimateLines struct {
+	Begin int `json:"begin"`
+}
+
+// Report writes the result's issues in their order. Issues without a line
+// are placed on line 1, as the format needs one; issues without a file are
+// placed on the unit's name.
+func (r *CodeClimateReporter) Report(result *analyzer.ScanResult) error {
+	issues := make([]CodeClimateIssue, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		path := issue.File
+		if path == "" {
+			path = issue.Unit
+		}
+		line := 1
+		if issue.Line != nil && *issue.Line > 0 {
+			line = *issue.Line
+		}
+		issues = append(issues, CodeClimateIssue{
+			Type:        "issue",
+			CheckName:   issue.RuleID,
+			Description: issue.Unit + ": " + issue.Description,
+			Categories:  []string{codeClimateCategory(issue.Category)},
+			Fingerprint: issue.Fingerprint(),
+			Severity:    codeClimateSeverity(issue.Severity),
+			Location: CodeClimateLocation{
+				Path:  StripPath(path, r.stripPrefix),
+				Lines: CodeClimateLines{Begin: line},
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(r.w)
+	if r.pretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(issues)
+}
+
+// codeClimateSeverity maps a severity to the CodeClimate one a level up in
+// name: CodeClimate's critical is below its blocker
+func codeClimateSeverity(sev types.Severity) string {
+	switch sev {
+	case types.SeverityCritical:
+		return "blocker"
+	case types.SeverityHigh:
+		return "critical"
+	case types.SeverityMedium:
+		return "major"
+	case types.SeverityLow:
+		return "minor"
+	default:
+		return "info"
+	}
+}
+
+// codeClimateCategory maps a category to the nearest CodeClimate one
+func codeClimateCategory(c types.Category) string {
+	switch c {
+	case types.CategorySecurity:
+		return "Security"
+	case types.CategoryPerformance:
+		return "Performance"
+	case types.CategoryReliability:
+		return "Bug Risk"
+	default:
+		return "Style"
+	}
+}
@@ -0,0 +1,97 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// GitHubReporter writes issues as GitHub Actions workflow commands, which
+// the runner turns into annotations on the lines of a pull request
+type GitHubReporter struct {
+	w           io.Writer
+	stripPrefix string
+}
+
+// NewGitHubReporter creates a new GitHub Actions reporter. stripPrefix is
+// cut from the start of file paths, so that they are relative to the
+// workspace as annotations need.
+func NewGitHubReporter(w io.Writer, stripPrefix string) *GitHubReporter {
+	return &GitHubReporter{w: w, stripPrefix: stripPrefix}
+}
+
+// Report writes one command per issue, in the result's order, then a
+// summary line
+//
+//nolint:errcheck // Output errors are not actionable for a text reporter
+func (r *GitHubReporter) Report(result *analyzer.ScanResult) error {
+	for _, issue := range result.Issues {
+		var props []string
+		if issue.File != "" {
+			props = append(props, "file="+githubProperty(StripPath(issue.File, r.stripPrefix)))
+			if issue.Line != nil {
+				props = append(props, fmt.Sprintf("line=%d", *issue.Line))
+			}
+		}
+		props = append(props, "title="+githubProperty(issue.RuleID+" "+issue.RuleName))
+
+		message := issue.Unit + ": " + issue.Description
+		if issue.Suggestion != "" {
+			message += "\n" + issue.Suggestion
+		}
+		fmt.Fprintf(r.w, "::%s %s::%s\n", githubLevel(issue.Severity), strings.Join(props, ","), githubData(message))
+	}
+
+	summary := result.Summary
+	var counts []string
+	for sev := types.SeverityCritical; sev >= types.SeverityInfo; sev-- {
+		if n := summary.BySeverity[sev]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, sev))
+		}
+	}
+	line := fmt.Sprintf("sdaudit: %d issues in %d units", summary.TotalIssues, summary.TotalUnits)
+	if len(counts) > 0 {
+		line += " (" + strings.Join(counts, ", ") + ")"
+	}
+	fmt.Fprintln(r.w, line)
+	return nil
+}
+
+// githubLevel maps a severity to the command that annotates at its level
+func githubLevel(sev types.Severity) string {
+	switch sev {
+	case types.SeverityCritical, types.SeverityHigh:
+		return "error"
+	case types.SeverityMedium:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// githubData escapes the message of a workflow command
+func githubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubProperty escapes a property value of a workflow command, which also
+// may not hold the ':' and ',' that delimit properties
+func githubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// StripPath cuts prefix from the start of path, and the separator after it,
+// leaving paths without the prefix as they are
+func StripPath(path, prefix string) string {
+	if prefix == "" {
+		return path
+	}
+	rest, ok := strings.CutPrefix(path, prefix)
+	if !ok {
+		return path
+	}
+	return strings.TrimPrefix(rest, "/")
+}
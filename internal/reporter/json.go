@@ -1,17 +1,33 @@
 package reporter
 
 import (
+	_ "embed"
 	"encoding/json"
+	"fmt"
 	"io"
+	"slices"
 	"time"
 
 	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
 )
 
+// JSONSchemaVersion is the version of the JSON report format, which
+// JSONSchema describes. The minor version grows when fields are added, the
+// major version when fields change or go away.
+const JSONSchemaVersion = "1.5.0"
+
+// JSONSchema is the JSON Schema document of the JSON report format
+//
+//go:embed schema.json
+var JSONSchema []byte
+
 // JSONReporter outputs scan results in JSON format
 type JSONReporter struct {
 	w      io.Writer
 	pretty bool
+	tool   JSONTool
 }
 
 // NewJSONReporter creates a new JSON reporter
@@ -19,36 +35,170 @@ func NewJSONReporter(w io.Writer, pretty bool) *JSONReporter {
 	return &JSONReporter{w: w, pretty: pretty}
 }
 
+// NewJSONReporterFor creates a new JSON reporter that records the sdaudit
+// run in its reports
+func NewJSONReporterFor(w io.Writer, pretty bool, tool JSONTool) *JSONReporter {
+	return &JSONReporter{w: w, pretty: pretty, tool: tool}
+}
+
 // JSONOutput represents the JSON output structure
 type JSONOutput struct {
 	Version   string      `json:"version"`
 	Timestamp string      `json:"timestamp"`
+	Tool      JSONTool    `json:"tool"`
+	Options   JSONOptions `json:"options"`
 	Summary   JSONSummary `json:"summary"`
-	Issues    []JSONIssue `json:"issues"`
+	Rules     []JSONRule  `json:"rules"`
+	// Scores are the audit scores of the units, lowest first
+	Scores []JSONUnitScore `json:"scores"`
+	Issues []JSONIssue     `json:"issues"`
+}
+
+// JSONTool describes the sdaudit run that wrote a report
+type JSONTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Hostname names the host sdaudit ran on, empty when left out
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// JSONOptions are the filters a scan ran with
+type JSONOptions struct {
+	MinSeverity string   `json:"min_severity,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Profile     string   `json:"profile,omitempty"`
+}
+
+// JSONRule describes a rule that ran, so that a rule without issues can be
+// told apart from one that did not run
+type JSONRule struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Severity string   `json:"severity"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+	Origin   string   `json:"origin,omitempty"`
+	// Issues counts the rule's issues in the report
+	Issues int `json:"issues"`
 }
 
 // JSONSummary represents the summary in JSON output
 type JSONSummary struct {
-	TotalUnits   int            `json:"total_units"`
-	TotalIssues  int            `json:"total_issues"`
-	RulesChecked int            `json:"rules_checked"`
-	BySeverity   map[string]int `json:"by_severity"`
-	ByCategory   map[string]int `json:"by_category"`
+	TotalUnits      int            `json:"total_units"`
+	TotalIssues     int            `json:"total_issues"`
+	RulesChecked    int            `json:"rules_checked"`
+	RulesSkipped    int            `json:"rules_skipped"`
+	SystemdVersion  int            `json:"systemd_version,omitempty"`
+	Quick           bool           `json:"quick,omitempty"`
+	SkippedAnalyses []string       `json:"skipped_analyses,omitempty"`
+	FailedUnits     int            `json:"failed_units"`
+	ParseErrors     int            `json:"parse_errors"`
+	BySeverity      map[string]int `json:"by_severity"`
+	ByCategory      map[string]int `json:"by_category"`
+	// WorstUnits are the units with the most issues weighted by severity
+	WorstUnits []JSONUnitWeight `json:"worst_units"`
+	ByRule     map[string]int   `json:"by_rule"`
+	ByUnit     map[string]int   `json:"by_unit"`
+	// ByCategorySeverity counts the issues of each category by severity
+	ByCategorySeverity map[string]map[string]int `json:"by_category_severity"`
+	// Score is the mean of the unit scores, weighted as ScoreWeights sets
+	Score        int            `json:"score"`
+	ScoreWeights map[string]int `json:"score_weights"`
+}
+
+// JSONUnitScore is a unit's audit score
+type JSONUnitScore struct {
+	Unit  string `json:"unit"`
+	Score int    `json:"score"`
+}
+
+// JSONUnitWeight is a unit's issues weighted by severity
+type JSONUnitWeight struct {
+	Unit   string `json:"unit"`
+	Weight int    `json:"weight"`
+	Issues int    `json:"issues"`
 }
 
 // JSONIssue represents an issue in JSON output
 type JSONIssue struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Severity    string   `json:"severity"`
-	Category    string   `json:"category"`
-	Tags        []string `json:"tags"`
-	Unit        string   `json:"unit"`
-	File        string   `json:"file"`
-	Line        *int     `json:"line,omitempty"`
-	Description string   `json:"description"`
-	Suggestion  string   `json:"suggestion"`
-	References  []string `json:"references"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Severity    string            `json:"severity"`
+	Category    string            `json:"category"`
+	Tags        []string          `json:"tags"`
+	Unit        string            `json:"unit"`
+	File        string            `json:"file"`
+	Line        *int              `json:"line,omitempty"`
+	Column      *int              `json:"column,omitempty"`
+	Description string            `json:"description"`
+	Suggestion  string            `json:"suggestion"`
+	References  []string          `json:"references"`
+	Refs        []types.Reference `json:"refs,omitempty"`
+	Origin      string            `json:"origin,omitempty"`
+	// Fingerprint identifies the issue across scans, as in baselines
+	Fingerprint string `json:"fingerprint"`
+	Directive   string `json:"directive,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Section     string `json:"section,omitempty"`
+	Expected    string `json:"expected,omitempty"`
+}
+
+// NewJSONIssue converts an issue to its JSON form
+func NewJSONIssue(issue types.Issue) JSONIssue {
+	return JSONIssue{
+		ID:          issue.RuleID,
+		Name:        issue.RuleName,
+		Severity:    issue.Severity.String(),
+		Category:    issue.Category.String(),
+		Tags:        issue.Tags,
+		Unit:        issue.Unit,
+		File:        issue.File,
+		Line:        issue.Line,
+		Column:      issue.Column,
+		Description: issue.Description,
+		Suggestion:  issue.Suggestion,
+		References:  issue.References,
+		Refs:        issue.Refs,
+		Origin:      issue.Origin,
+		Fingerprint: issue.Fingerprint(),
+		Directive:   issue.Directive,
+		Value:       issue.Value,
+		Section:     issue.Section,
+		Expected:    issue.Expected,
+	}
+}
+
+// Issue converts the JSON form back to an issue
+func (j JSONIssue) Issue() (types.Issue, error) {
+	severity := types.ParseSeverity(j.Severity)
+	if severity.String() != j.Severity {
+		return types.Issue{}, fmt.Errorf("issue %s: unknown severity %q", j.ID, j.Severity)
+	}
+	category := types.ParseCategory(j.Category)
+	if category.String() != j.Category {
+		return types.Issue{}, fmt.Errorf("issue %s: unknown category %q", j.ID, j.Category)
+	}
+	return types.Issue{
+		RuleID:      j.ID,
+		RuleName:    j.Name,
+		Severity:    severity,
+		Category:    category,
+		Tags:        j.Tags,
+		Unit:        j.Unit,
+		File:        j.File,
+		Line:        j.Line,
+		Column:      j.Column,
+		Description: j.Description,
+		Suggestion:  j.Suggestion,
+		References:  j.References,
+		Refs:        j.Refs,
+		Origin:      j.Origin,
+		Directive:   j.Directive,
+		Value:       j.Value,
+		Section:     j.Section,
+		Expected:    j.Expected,
+	}, nil
 }
 
 // Report writes the scan result as JSON
@@ -64,31 +214,92 @@ func (r *JSONReporter) Report(result *analyzer.ScanResult) error {
 	}
 
 	issues := make([]JSONIssue, len(result.Issues))
+	counts := make(map[string]int)
 	for i, issue := range result.Issues {
-		issues[i] = JSONIssue{
-			ID:          issue.RuleID,
-			Name:        issue.RuleName,
-			Severity:    issue.Severity.String(),
-			Category:    issue.Category.String(),
-			Tags:        issue.Tags,
-			Unit:        issue.Unit,
-			File:        issue.File,
-			Line:        issue.Line,
-			Description: issue.Description,
-			Suggestion:  issue.Suggestion,
-			References:  issue.References,
+		issues[i] = NewJSONIssue(issue)
+		counts[issue.RuleID]++
+	}
+
+	ruleList := make([]JSONRule, 0, len(result.Summary.RulesRun))
+	for _, id := range result.Summary.RulesRun {
+		rule := rules.Get(id)
+		if rule == nil {
+			continue
+		}
+		tags := slices.Sorted(slices.Values(rule.Tags()))
+		if tags == nil {
+			tags = []string{}
 		}
+		ruleList = append(ruleList, JSONRule{
+			ID:       rule.ID(),
+			Name:     rule.Name(),
+			Severity: rule.Severity().String(),
+			Category: rule.Category().String(),
+			Tags:     tags,
+			Origin:   rules.Origin(rule),
+			Issues:   counts[id],
+		})
+	}
+
+	byCategorySeverity := make(map[string]map[string]int)
+	for cat, counts := range result.Summary.ByCategorySeverity {
+		bySev := make(map[string]int)
+		for sev, count := range counts {
+			bySev[sev.String()] = count
+		}
+		byCategorySeverity[cat.String()] = bySev
+	}
+
+	worstUnits := make([]JSONUnitWeight, len(result.Summary.WorstUnits))
+	for i, u := range result.Summary.WorstUnits {
+		worstUnits[i] = JSONUnitWeight(u)
+	}
+
+	scores := make([]JSONUnitScore, len(result.Scores))
+	for i, s := range result.Scores {
+		scores[i] = JSONUnitScore(s)
+	}
+	scoreWeights := make(map[string]int)
+	for sev, weight := range result.Summary.ScoreWeights {
+		scoreWeights[sev.String()] = weight
+	}
+
+	tool := r.tool
+	tool.Name = "sdaudit"
+	scanOpts := result.Summary.Options
+	options := JSONOptions{Tags: scanOpts.Tags, Profile: scanOpts.Profile}
+	if scanOpts.MinSeverity != nil {
+		options.MinSeverity = scanOpts.MinSeverity.String()
+	}
+	if scanOpts.Category != nil {
+		options.Category = scanOpts.Category.String()
 	}
 
 	output := JSONOutput{
-		Version:   "1.0.0",
+		Version:   JSONSchemaVersion,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Tool:      tool,
+		Options:   options,
+		Rules:     ruleList,
+		Scores:    scores,
 		Summary: JSONSummary{
-			TotalUnits:   result.Summary.TotalUnits,
-			TotalIssues:  result.Summary.TotalIssues,
-			RulesChecked: result.Summary.RulesChecked,
-			BySeverity:   bySeverity,
-			ByCategory:   byCategory,
+			TotalUnits:         result.Summary.TotalUnits,
+			TotalIssues:        result.Summary.TotalIssues,
+			RulesChecked:       result.Summary.RulesChecked,
+			RulesSkipped:       result.Summary.RulesSkipped,
+			SystemdVersion:     result.Summary.SystemdVersion,
+			Quick:              result.Summary.Quick,
+			SkippedAnalyses:    result.Summary.SkippedAnalyses,
+			FailedUnits:        result.Summary.FailedUnits,
+			ParseErrors:        result.Summary.ParseErrors,
+			BySeverity:         bySeverity,
+			ByCategory:         byCategory,
+			WorstUnits:         worstUnits,
+			ByRule:             nonNil(result.Summary.ByRule),
+			ByUnit:             nonNil(result.Summary.ByUnit),
+			ByCategorySeverity: byCategorySeverity,
+			Score:              result.Summary.Score,
+			ScoreWeights:       scoreWeights,
 		},
 		Issues: issues,
 	}
@@ -100,3 +311,92 @@ func (r *JSONReporter) Report(result *analyzer.ScanResult) error {
 
 	return encoder.Encode(output)
 }
+
+// ReadJSON reads a report written by JSONReporter back into a scan result.
+// The report does not list the units scanned, so the result has none.
+// Reports of version 1.0.0 have no rules or options, and reports before 1.4.0
+// no scores, which are left empty.
+func ReadJSON(r io.Reader) (*analyzer.ScanResult, error) {
+	var output JSONOutput
+	if err := json.NewDecoder(r).Decode(&output); err != nil {
+		return nil, fmt.Errorf("not a JSON report: %w", err)
+	}
+
+	summary := output.Summary
+	result := &analyzer.ScanResult{
+		Issues: make([]types.Issue, 0, len(output.Issues)),
+		Summary: types.Summary{
+			TotalUnits:      summary.TotalUnits,
+			TotalIssues:     summary.TotalIssues,
+			BySeverity:      make(map[types.Severity]int),
+			ByCategory:      make(map[types.Category]int),
+			RulesChecked:    summary.RulesChecked,
+			RulesSkipped:    summary.RulesSkipped,
+			SystemdVersion:  summary.SystemdVersion,
+			Quick:           summary.Quick,
+			SkippedAnalyses: summary.SkippedAnalyses,
+			FailedUnits:     summary.FailedUnits,
+			ParseErrors:     summary.ParseErrors,
+		},
+	}
+	for sev, count := range summary.BySeverity {
+		result.Summary.BySeverity[types.ParseSeverity(sev)] = count
+	}
+	for cat, count := range summary.ByCategory {
+		result.Summary.ByCategory[types.ParseCategory(cat)] = count
+	}
+	result.Summary.ByRule = summary.ByRule
+	result.Summary.ByUnit = summary.ByUnit
+	if len(summary.ByCategorySeverity) > 0 {
+		result.Summary.ByCategorySeverity = make(map[types.Category]map[types.Severity]int)
+		for cat, counts := range summary.ByCategorySeverity {
+			bySev := make(map[types.Severity]int)
+			for sev, count := range counts {
+				bySev[types.ParseSeverity(sev)] = count
+			}
+			result.Summary.ByCategorySeverity[types.ParseCategory(cat)] = bySev
+		}
+	}
+	for _, u := range summary.WorstUnits {
+		result.Summary.WorstUnits = append(result.Summary.WorstUnits, types.UnitWeight(u))
+	}
+	for _, s := range output.Scores {
+		result.Scores = append(result.Scores, types.UnitScore(s))
+	}
+	result.Summary.Score = summary.Score
+	if len(summary.ScoreWeights) > 0 {
+		result.Summary.ScoreWeights = make(types.ScoreWeights)
+		for sev, weight := range summary.ScoreWeights {
+			result.Summary.ScoreWeights[types.ParseSeverity(sev)] = weight
+		}
+	}
+	for _, rule := range output.Rules {
+		result.Summary.RulesRun = append(result.Summary.RulesRun, rule.ID)
+	}
+	opts := output.Options
+	result.Summary.Options = types.ScanOptions{Tags: opts.Tags, Profile: opts.Profile}
+	if opts.MinSeverity != "" {
+		severity := types.ParseSeverity(opts.MinSeverity)
+		result.Summary.Options.MinSeverity = &severity
+	}
+	if opts.Category != "" {
+		category := types.ParseCategory(opts.Category)
+		result.Summary.Options.Category = &category
+	}
+	for _, j := range output.Issues {
+		issue, err := j.Issue()
+		if err != nil {
+			return nil, err
+		}
+		result.Issues = append(result.Issues, issue)
+	}
+	return result, nil
+}
+
+// nonNil returns counts, or an empty map for nil, which JSON writes as null
+func nonNil(counts map[string]int) map[string]int {
+	if counts == nil {
+		return map[string]int{}
+	}
+	return counts
+}
@@ -0,0 +1,112 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// MarkdownReporter outputs scan results as a Markdown document, for pull
+// request comments and wikis
+type MarkdownReporter struct {
+	w io.Writer
+}
+
+// NewMarkdownReporter creates a new Markdown reporter
+func NewMarkdownReporter(w io.Writer) *MarkdownReporter {
+	return &MarkdownReporter{w: w}
+}
+
+// Report writes the scan result to the output
+//
+//nolint:errcheck // Output errors are not actionable for a text reporter
+func (r *MarkdownReporter) Report(result *analyzer.ScanResult) error {
+	summary := result.Summary
+	fmt.Fprint(r.w, "# sdaudit scan results\n\n")
+
+	if summary.Quick {
+		fmt.Fprintf(r.w, "Quick scan: skipped %s analysis.\n\n", strings.Join(summary.SkippedAnalyses, ", "))
+	} else if len(summary.SkippedAnalyses) > 0 {
+		fmt.Fprintf(r.w, "Skipped analyses: %s (unavailable).\n\n", strings.Join(summary.SkippedAnalyses, ", "))
+	}
+	fmt.Fprintf(r.w, "- Units scanned: %d\n", summary.TotalUnits)
+	fmt.Fprintf(r.w, "- Rules checked: %d\n", summary.RulesChecked)
+	if summary.FailedUnits > 0 {
+		fmt.Fprintf(r.w, "- Failed units: %d\n", summary.FailedUnits)
+	}
+	if summary.ParseErrors > 0 {
+		fmt.Fprintf(r.w, "- Parse errors: %d\n", summary.ParseErrors)
+	}
+	if summary.RulesSkipped > 0 {
+		fmt.Fprintf(r.w, "- Rules skipped: %d (systemd %d too old)\n", summary.RulesSkipped, summary.SystemdVersion)
+	}
+	fmt.Fprintf(r.w, "- Issues found: %d\n\n", summary.TotalIssues)
+
+	if len(result.Issues) == 0 {
+		fmt.Fprintln(r.w, "No issues found.")
+		return nil
+	}
+
+	fmt.Fprint(r.w, "| Severity | Issues |\n|----------|-------:|\n")
+	for _, sev := range reportSeverities {
+		if count := summary.BySeverity[sev]; count > 0 {
+			fmt.Fprintf(r.w, "| %s | %d |\n", sev, count)
+		}
+	}
+	fmt.Fprint(r.w, "\n| Category | Issues |\n|----------|-------:|\n")
+	for _, cat := range reportCategories {
+		if count := summary.ByCategory[cat]; count > 0 {
+			fmt.Fprintf(r.w, "| %s | %d |\n", cat, count)
+		}
+	}
+
+	fmt.Fprint(r.w, "\n## Issues\n")
+	for i, issue := range result.Issues {
+		fmt.Fprintf(r.w, "\n### %d. %s %s\n\n", i+1, issue.RuleID, issue.RuleName)
+		fmt.Fprintf(r.w, "- Severity: %s\n", issue.Severity)
+		fmt.Fprintf(r.w, "- Category: %s\n", issue.Category)
+		fmt.Fprintf(r.w, "- Unit: `%s`\n", issue.Unit)
+		if issue.Origin != "" {
+			fmt.Fprintf(r.w, "- From: %s\n", issue.Origin)
+		}
+		if issue.File != "" {
+			location := issue.File
+			if issue.Line != nil {
+				location += fmt.Sprintf(":%d", *issue.Line)
+			}
+			fmt.Fprintf(r.w, "- File: `%s`\n", location)
+		}
+		fmt.Fprintf(r.w, "\n%s\n", issue.Description)
+		if issue.Suggestion != "" {
+			fmt.Fprintf(r.w, "\n**Fix:** %s\n", issue.Suggestion)
+		}
+		if refs := markdownRefs(issue); len(refs) > 0 {
+			fmt.Fprint(r.w, "\nReferences:\n\n")
+			for _, ref := range refs {
+				fmt.Fprintf(r.w, "- %s\n", ref)
+			}
+		}
+	}
+
+	return nil
+}
+
+// markdownRefs returns the references of an issue as Markdown links where
+// they have a URL
+func markdownRefs(issue types.Issue) []string {
+	var refs []string
+	for _, ref := range issue.Refs {
+		if ref.URL != "" && ref.Kind != types.ReferenceURL {
+			refs = append(refs, fmt.Sprintf("[%s](%s)", ref, ref.URL))
+		} else {
+			refs = append(refs, ref.String())
+		}
+	}
+	if len(refs) == 0 {
+		refs = issue.References
+	}
+	return refs
+}
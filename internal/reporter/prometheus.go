@@ -0,0 +1,51 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+)
+
+// PrometheusReporter outputs the scores and issue counts of a scan in the
+// Prometheus text exposition format, for the node exporter's textfile
+// collector
+type PrometheusReporter struct {
+	w io.Writer
+}
+
+// NewPrometheusReporter creates a new Prometheus reporter
+func NewPrometheusReporter(w io.Writer) *PrometheusReporter {
+	return &PrometheusReporter{w: w}
+}
+
+// Report writes the result's host score, the score of each unit and the
+// issues of each severity
+//
+//nolint:errcheck // Output errors are not actionable for a text reporter
+func (r *PrometheusReporter) Report(result *analyzer.ScanResult) error {
+	fmt.Fprintln(r.w, "# HELP sdaudit_host_score Audit score of the host, the mean of the unit scores, from 0 to 100.")
+	fmt.Fprintln(r.w, "# TYPE sdaudit_host_score gauge")
+	fmt.Fprintf(r.w, "sdaudit_host_score %d\n", result.Summary.Score)
+
+	fmt.Fprintln(r.w, "# HELP sdaudit_unit_score Audit score of a unit, 100 less the weights of its issues, down to 0.")
+	fmt.Fprintln(r.w, "# TYPE sdaudit_unit_score gauge")
+	for _, s := range result.Scores {
+		fmt.Fprintf(r.w, "sdaudit_unit_score{unit=\"%s\"} %d\n", prometheusLabel(s.Unit), s.Score)
+	}
+
+	fmt.Fprintln(r.w, "# HELP sdaudit_issues Issues found, by severity.")
+	fmt.Fprintln(r.w, "# TYPE sdaudit_issues gauge")
+	for _, sev := range reportSeverities {
+		fmt.Fprintf(r.w, "sdaudit_issues{severity=\"%s\"} %d\n", sev, result.Summary.BySeverity[sev])
+	}
+	return nil
+}
+
+var prometheusEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusLabel escapes a label value
+func prometheusLabel(value string) string {
+	return prometheusEscaper.Replace(value)
+}
@@ -3,10 +3,17 @@ package reporter
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/supabase/sdaudit/internal/analyzer"
+	_ "github.com/supabase/sdaudit/internal/rules/reliability"
+	_ "github.com/supabase/sdaudit/internal/rules/security"
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/internal/validation"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -27,6 +34,9 @@ func makeScanResult() *analyzer.ScanResult {
 				Description: "Service does not set NoNewPrivileges=yes",
 				Suggestion:  "Add NoNewPrivileges=yes to [Service]",
 				References:  []string{"https://example.com/docs"},
+				Directive:   "NoNewPrivileges",
+				Section:     "Service",
+				Expected:    "yes",
 			},
 			{
 				RuleID:      "REL001",
@@ -74,8 +84,8 @@ func TestJSONReporter(t *testing.T) {
 	}
 
 	// Check structure
-	if output.Version != "1.0.0" {
-		t.Errorf("Version = %q, want %q", output.Version, "1.0.0")
+	if output.Version != JSONSchemaVersion {
+		t.Errorf("Version = %q, want %q", output.Version, JSONSchemaVersion)
 	}
 	if output.Summary.TotalUnits != 1 {
 		t.Errorf("TotalUnits = %d, want %d", output.Summary.TotalUnits, 1)
@@ -94,6 +104,93 @@ func TestJSONReporter(t *testing.T) {
 	if output.Issues[0].Severity != "high" {
 		t.Errorf("First issue Severity = %q, want %q", output.Issues[0].Severity, "high")
 	}
+	if output.Issues[0].Fingerprint != result.Issues[0].Fingerprint() {
+		t.Errorf("First issue Fingerprint = %q, want %q", output.Issues[0].Fingerprint, result.Issues[0].Fingerprint())
+	}
+	if got := output.Issues[0]; got.Directive != "NoNewPrivileges" || got.Value != "" || got.Section != "Service" || got.Expected != "yes" {
+		t.Errorf("First issue directive, value, section, expected = %q, %q, %q, %q", got.Directive, got.Value, got.Section, got.Expected)
+	}
+	if !strings.Contains(buf.String(), `"expected": "yes"`) || strings.Contains(buf.String(), `"value": ""`) {
+		t.Errorf("structured fields not written as given, or empty ones not left out:\n%s", buf.String())
+	}
+}
+
+func TestJSONReporterRules(t *testing.T) {
+	result := makeScanResult()
+	medium := types.SeverityMedium
+	result.Summary.RulesRun = []string{"REL001", "SEC001", "SEC002"}
+	result.Summary.Options = types.ScanOptions{MinSeverity: &medium, Profile: "server"}
+	var buf bytes.Buffer
+	if err := NewJSONReporterFor(&buf, false, JSONTool{Version: "1.2.3", Hostname: "web1"}).Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	var output JSONOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if want := (JSONTool{Name: "sdaudit", Version: "1.2.3", Hostname: "web1"}); output.Tool != want {
+		t.Errorf("Tool = %+v, want %+v", output.Tool, want)
+	}
+	if output.Options.MinSeverity != "medium" || output.Options.Profile != "server" || output.Options.Category != "" {
+		t.Errorf("Options = %+v", output.Options)
+	}
+
+	// SEC002 ran without findings, which the report must tell apart from
+	// rules that did not run at all
+	got := make(map[string]int)
+	for _, rule := range output.Rules {
+		got[rule.ID] = rule.Issues
+	}
+	want := map[string]int{"REL001": 1, "SEC001": 1, "SEC002": 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rule issue counts = %v, want %v", got, want)
+	}
+
+	read, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSON failed: %v", err)
+	}
+	if !reflect.DeepEqual(read.Summary.RulesRun, result.Summary.RulesRun) || !reflect.DeepEqual(read.Summary.Options, result.Summary.Options) {
+		t.Errorf("ReadJSON summary = %+v, want rules %v and options %+v", read.Summary, result.Summary.RulesRun, result.Summary.Options)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	result := makeScanResult()
+	line := 7
+	result.Issues[0].Line = &line
+	result.Issues[0].Value = "no"
+	result.Recount()
+	var buf bytes.Buffer
+	if err := NewJSONReporter(&buf, true).Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	read, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSON failed: %v", err)
+	}
+	if !reflect.DeepEqual(read.Issues, result.Issues) {
+		t.Errorf("Issues = %+v, want %+v", read.Issues, result.Issues)
+	}
+	if !reflect.DeepEqual(read.Summary.BySeverity, result.Summary.BySeverity) || read.Summary.TotalIssues != 2 {
+		t.Errorf("Summary = %+v, want %+v", read.Summary, result.Summary)
+	}
+	if !reflect.DeepEqual(read.Scores, result.Scores) || read.Summary.Score != result.Summary.Score ||
+		!reflect.DeepEqual(read.Summary.ScoreWeights, result.Summary.ScoreWeights) {
+		t.Errorf("scores = %v, %d, %v, want %v, %d, %v", read.Scores, read.Summary.Score, read.Summary.ScoreWeights,
+			result.Scores, result.Summary.Score, result.Summary.ScoreWeights)
+	}
+	for i, issue := range read.Issues {
+		if issue.Fingerprint() != result.Issues[i].Fingerprint() {
+			t.Errorf("issue %d fingerprint changed", i)
+		}
+	}
+
+	if _, err := ReadJSON(strings.NewReader(`{"issues": [{"id": "X", "severity": "dire", "category": "security"}]}`)); err == nil {
+		t.Error("ReadJSON accepted an unknown severity")
+	}
 }
 
 func TestTextReporter(t *testing.T) {
@@ -127,6 +224,87 @@ func TestTextReporter(t *testing.T) {
 	}
 }
 
+func TestTextReporterColor(t *testing.T) {
+	for _, color := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := NewTextReporter(&buf, color).Report(makeScanResult()); err != nil {
+			t.Fatalf("Report failed: %v", err)
+		}
+		if got := strings.Contains(buf.String(), "\033["); got != color {
+			t.Errorf("color %v: output has escape sequences = %v:\n%s", color, got, buf.String())
+		}
+	}
+}
+
+func TestTextReporterSummaryOnly(t *testing.T) {
+	result := makeScanResult()
+	result.Summary.CountIssues(result.Issues)
+
+	for _, ascii := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := NewSummaryTextReporter(&buf, style.New(false, ascii)).Report(result); err != nil {
+			t.Fatalf("Report failed: %v", err)
+		}
+		output := buf.String()
+		if strings.Contains(output, "NoNewPrivileges") || strings.Contains(output, "Fix:") {
+			t.Errorf("ascii=%v: summary-only output lists issues:\n%s", ascii, output)
+		}
+		if !strings.Contains(output, "Issues found") || !strings.Contains(strings.ToLower(output), "worst offenders") {
+			t.Errorf("ascii=%v: output lacks the summary or the worst offenders:\n%s", ascii, output)
+		}
+		// One high (5) and one medium (2) issue
+		want := "test.service       7       2"
+		if ascii {
+			want = "test.service: weight 7, 2 issues"
+		}
+		if !strings.Contains(output, want) {
+			t.Errorf("ascii=%v: output lacks %q:\n%s", ascii, want, output)
+		}
+	}
+}
+
+func TestTextReporterTopRulesAndUnits(t *testing.T) {
+	result := makeScanResult()
+	result.Summary.CountIssues(result.Issues)
+
+	var buf bytes.Buffer
+	if err := NewTextReporter(&buf, false).Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{"Top Rules:\n  REL001     1   50%\n  SEC001     1   50%\n", "Top Units:\n  test.service     2  100%\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output lacks %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestTextReporterScores(t *testing.T) {
+	result := makeScanResult()
+	result.Units = append(result.Units, &types.UnitFile{Name: "clean.service"})
+	result.Recount()
+
+	var buf bytes.Buffer
+	if err := NewTextReporter(&buf, false).Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	output := buf.String()
+	// test.service loses 20 for a high and 8 for a medium issue
+	for _, want := range []string{"Audit score:   86/100\n", "Lowest Scores:\n  test.service — score 72/100\n\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output lacks %q:\n%s", want, output)
+		}
+	}
+
+	buf.Reset()
+	if err := NewStyledTextReporter(&buf, style.New(false, true)).Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if want := "test.service -- score 72/100\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("ASCII output lacks %q:\n%s", want, buf.String())
+	}
+}
+
 func TestTextReporterNoIssues(t *testing.T) {
 	result := &analyzer.ScanResult{
 		Units: []*types.UnitFile{
@@ -199,6 +377,13 @@ func TestSARIFReporter(t *testing.T) {
 	if run.Results[0].Level != "error" {
 		t.Errorf("First result Level = %q, want %q (high severity maps to error)", run.Results[0].Level, "error")
 	}
+	want := map[string]string{"directive": "NoNewPrivileges", "section": "Service", "expected": "yes"}
+	if !reflect.DeepEqual(run.Results[0].Properties, want) {
+		t.Errorf("First result Properties = %v, want %v", run.Results[0].Properties, want)
+	}
+	if run.Results[1].Properties != nil {
+		t.Errorf("Second result Properties = %v, want none", run.Results[1].Properties)
+	}
 }
 
 func TestSeverityToLevel(t *testing.T) {
@@ -220,3 +405,340 @@ func TestSeverityToLevel(t *testing.T) {
 		}
 	}
 }
+
+func TestBestHelpURI(t *testing.T) {
+	refs := []types.Reference{
+		{Kind: types.ReferenceURL, Title: "blog", URL: "https://example.com/blog"},
+		types.ManPage("systemd.exec", "Sandboxing"),
+		types.ManPage("systemd.exec", "PrivateTmp="),
+	}
+	if got := bestHelpURI(refs); got != refs[2].URL {
+		t.Errorf("bestHelpURI() = %q, want directive man page %q", got, refs[2].URL)
+	}
+	if got := bestHelpURI(refs[:1]); got != "https://example.com/blog" {
+		t.Errorf("bestHelpURI() = %q, want the only URL", got)
+	}
+	if got := bestHelpURI(nil); got != "" {
+		t.Errorf("bestHelpURI(nil) = %q, want empty", got)
+	}
+}
+
+func TestTextReporterShortReferences(t *testing.T) {
+	result := makeScanResult()
+	result.Issues[0].Refs = []types.Reference{types.ManPage("systemd.exec", "NoNewPrivileges=")}
+	var buf bytes.Buffer
+
+	if err := NewTextReporter(&buf, false).Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "systemd.exec(5) §NoNewPrivileges=") {
+		t.Errorf("text output should use the short reference form:\n%s", buf.String())
+	}
+}
+
+func TestTextReporterASCII(t *testing.T) {
+	result := makeScanResult()
+	result.Issues[0].Refs = []types.Reference{types.ManPage("systemd.exec", "NoNewPrivileges=")}
+
+	var buf bytes.Buffer
+	if err := NewStyledTextReporter(&buf, style.New(false, true)).Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	output := buf.String()
+
+	for _, r := range output {
+		if r > 127 {
+			t.Fatalf("ASCII output contains %q:\n%s", r, output)
+		}
+	}
+	if strings.Contains(output, "=====") || strings.Contains(output, "-----") {
+		t.Errorf("ASCII output should use headings instead of rule lines:\n%s", output)
+	}
+
+	// Headings and facts come in reading order, one per line
+	want := []string{
+		"# sdaudit scan results",
+		"## Summary",
+		"Issues found: 2",
+		"## Issues by severity",
+		"HIGH: 1",
+		"## Issues",
+		"### Issue 1 of 2: SEC001 NoNewPrivileges not set",
+		"Severity: HIGH",
+		"File: /etc/systemd/system/test.service",
+		"- systemd.exec(5) section NoNewPrivileges=",
+		"### Issue 2 of 2: REL001 Restart policy not configured",
+		"Severity: MEDIUM",
+		"- https://example.com/docs",
+	}
+	rest := output
+	for _, line := range want {
+		i := strings.Index(rest, line+"\n")
+		if i < 0 {
+			t.Fatalf("missing or out of order line %q in:\n%s", line, output)
+		}
+		rest = rest[i+len(line):]
+	}
+}
+
+func TestMarkdownReporter(t *testing.T) {
+	result := makeScanResult()
+	line := 7
+	result.Issues[0].Line = &line
+	result.Issues[0].Refs = []types.Reference{types.ManPage("systemd.exec", "NoNewPrivileges=")}
+
+	var buf bytes.Buffer
+	if err := NewMarkdownReporter(&buf).Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	output := buf.String()
+	for _, check := range []string{
+		"# sdaudit scan results\n",
+		"- Issues found: 2\n",
+		"| high | 1 |\n",
+		"| reliability | 1 |\n",
+		"### 1. SEC001 NoNewPrivileges not set\n",
+		"- File: `/etc/systemd/system/test.service:7`\n",
+		"**Fix:** Add NoNewPrivileges=yes to [Service]\n",
+		"- [systemd.exec(5) §NoNewPrivileges=](https://www.freedesktop.org/software/systemd/man/systemd.exec.html#NoNewPrivileges=)\n",
+		"### 2. REL001 Restart policy not configured\n",
+		"- https://example.com/docs\n",
+	} {
+		if !strings.Contains(output, check) {
+			t.Errorf("output missing %q:\n%s", check, output)
+		}
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestGitHubReporter(t *testing.T) {
+	result := makeScanResult()
+	line := 12
+	result.Issues[0].Line = &line
+	result.Issues = append(result.Issues, types.Issue{
+		RuleID:      "PARSE",
+		RuleName:    "Unit file does not parse",
+		Severity:    types.SeverityLow,
+		Unit:        "other.service",
+		File:        "/etc/systemd/system/other,1.service",
+		Description: "Line 3 reads:\nExecStart=/bin/echo 100%\r\nand is cut short",
+	}, types.Issue{
+		RuleID:      "BP001",
+		RuleName:    "Missing description",
+		Severity:    types.SeverityInfo,
+		Unit:        "loaded.service",
+		Description: "Unit has no Description=",
+	})
+	result.Summary.TotalIssues = 4
+	result.Summary.BySeverity[types.SeverityLow] = 1
+	result.Summary.BySeverity[types.SeverityInfo] = 1
+
+	var buf bytes.Buffer
+	if err := NewGitHubReporter(&buf, "/etc/systemd").Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	golden := "../../testdata/reporter/github.golden"
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(want) {
+		t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", golden, buf.String(), want)
+	}
+}
+
+func TestStripPath(t *testing.T) {
+	tests := []struct {
+		path, prefix, want string
+	}{
+		{"/work/repo/units/a.service", "/work/repo", "units/a.service"},
+		{"/work/repo/units/a.service", "/work/repo/", "units/a.service"},
+		{"/other/a.service", "/work/repo", "/other/a.service"},
+		{"units/a.service", "", "units/a.service"},
+	}
+	for _, tt := range tests {
+		if got := StripPath(tt.path, tt.prefix); got != tt.want {
+			t.Errorf("StripPath(%q, %q) = %q, want %q", tt.path, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeClimateReporter(t *testing.T) {
+	result := makeScanResult()
+	line := 7
+	result.Issues[0].Line = &line
+	var buf bytes.Buffer
+	if err := NewCodeClimateReporter(&buf, false, "").Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	var got []CodeClimateIssue
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	want := []CodeClimateIssue{
+		{
+			Type: "issue", CheckName: "SEC001", Description: "test.service: Service does not set NoNewPrivileges=yes",
+			Categories: []string{"Security"}, Fingerprint: result.Issues[0].Fingerprint(), Severity: "critical",
+			Location: CodeClimateLocation{Path: "/etc/systemd/system/test.service", Lines: CodeClimateLines{Begin: 7}},
+		},
+		{
+			Type: "issue", CheckName: "REL001", Description: "test.service: Service has no restart policy",
+			Categories: []string{"Bug Risk"}, Fingerprint: result.Issues[1].Fingerprint(), Severity: "major",
+			Location: CodeClimateLocation{Path: "/etc/systemd/system/test.service", Lines: CodeClimateLines{Begin: 1}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Report() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	buf.Reset()
+	if err := NewCodeClimateReporter(&buf, false, "").Report(&analyzer.ScanResult{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty report = %q, want []", buf.String())
+	}
+}
+
+func TestCodeClimateSeverity(t *testing.T) {
+	want := map[types.Severity]string{
+		types.SeverityCritical: "blocker",
+		types.SeverityHigh:     "critical",
+		types.SeverityMedium:   "major",
+		types.SeverityLow:      "minor",
+		types.SeverityInfo:     "info",
+	}
+	for sev, name := range want {
+		if got := codeClimateSeverity(sev); got != name {
+			t.Errorf("codeClimateSeverity(%s) = %q, want %q", sev, got, name)
+		}
+	}
+}
+
+func TestPrometheusReporter(t *testing.T) {
+	result := makeScanResult()
+	result.Units = append(result.Units, &types.UnitFile{Name: `odd"name\\.service`})
+	result.Recount()
+
+	var buf bytes.Buffer
+	if err := NewPrometheusReporter(&buf).Report(result); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	golden := "../../testdata/reporter/prometheus.golden"
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(want) {
+		t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", golden, buf.String(), want)
+	}
+}
+
+// countingFS counts the reads of each file
+type countingFS struct {
+	*validation.MockFileSystem
+	reads map[string]int
+}
+
+func (fs *countingFS) ReadFile(path string) ([]byte, error) {
+	fs.reads[path]++
+	return fs.MockFileSystem.ReadFile(path)
+}
+
+func TestTextReporterShowSource(t *testing.T) {
+	result := makeScanResult()
+	line, lastLine, missingLine := 2, 5, 2
+	result.Issues[0].Line = &line
+	result.Issues = append(result.Issues, types.Issue{
+		RuleID:      "SEC018",
+		RuleName:    "Privileged exec prefix",
+		Severity:    types.SeverityMedium,
+		Category:    types.CategorySecurity,
+		Unit:        "test.service",
+		File:        "/etc/systemd/system/test.service",
+		Line:        &lastLine,
+		Description: "ExecStart= runs without sandboxing",
+	}, types.Issue{
+		RuleID:      "BP004",
+		RuleName:    "Missing documentation",
+		Severity:    types.SeverityLow,
+		Category:    types.CategoryBestPractice,
+		Unit:        "gone.service",
+		File:        "/etc/systemd/system/gone.service",
+		Line:        &missingLine,
+		Description: "Unit has no Documentation=",
+	})
+	result.Recount()
+
+	fs := &countingFS{MockFileSystem: validation.NewMockFileSystem(), reads: make(map[string]int)}
+	fs.Contents["/etc/systemd/system/test.service"] = "[Unit]\nDescription=Test\n\n[Service]\nExecStart=/usr/bin/test\n"
+
+	for _, tt := range []struct {
+		golden string
+		style  style.Provider
+	}{
+		{"source.golden", style.New(false, false)},
+		{"source_ascii.golden", style.New(false, true)},
+	} {
+		var buf bytes.Buffer
+		if err := NewStyledTextReporter(&buf, tt.style).ShowSource(fs).Report(result); err != nil {
+			t.Fatalf("Report failed: %v", err)
+		}
+
+		golden := "../../testdata/reporter/" + tt.golden
+		if *update {
+			if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != string(want) {
+			t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", golden, buf.String(), want)
+		}
+	}
+
+	// Each file is read once per report, however many issues it has
+	if fs.reads["/etc/systemd/system/test.service"] != 2 || fs.reads["/etc/systemd/system/gone.service"] != 2 {
+		t.Errorf("reads = %v, want each file read once per report", fs.reads)
+	}
+}
+
+func TestSourceFrame(t *testing.T) {
+	fs := validation.NewMockFileSystem()
+	fs.Contents["a.service"] = "one\r\ntwo\r\nthree\r\n"
+	source := newSourceFiles(fs)
+
+	tests := []struct {
+		line int
+		want []sourceLine
+	}{
+		{1, []sourceLine{{1, "one", true}, {2, "two", false}}},
+		{2, []sourceLine{{1, "one", false}, {2, "two", true}, {3, "three", false}}},
+		{3, []sourceLine{{2, "two", false}, {3, "three", true}}},
+		{4, nil},
+		{0, nil},
+	}
+	for _, tt := range tests {
+		if got := source.frame("a.service", tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("frame(%d) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
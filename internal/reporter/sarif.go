@@ -3,6 +3,7 @@ package reporter
 import (
 	"encoding/json"
 	"io"
+	"strings"
 
 	"github.com/supabase/sdaudit/internal/analyzer"
 	"github.com/supabase/sdaudit/internal/rules"
@@ -59,7 +60,8 @@ type SARIFConfiguration struct {
 }
 
 type SARIFMessage struct {
-	Text string `json:"text"`
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
 }
 
 type SARIFResult struct {
@@ -69,6 +71,8 @@ type SARIFResult struct {
 	Message   SARIFMessage    `json:"message"`
 	Locations []SARIFLocation `json:"locations,omitempty"`
 	Fixes     []SARIFFix      `json:"fixes,omitempty"`
+	// Properties hold the directive an issue is about, for tools acting on it
+	Properties map[string]string `json:"properties,omitempty"`
 }
 
 type SARIFLocation struct {
@@ -106,6 +110,41 @@ func severityToLevel(sev types.Severity) string {
 	}
 }
 
+// bestHelpURI picks the most specific documentation link: a man page anchored
+// at a directive, then any man page, then the first link of any kind
+func bestHelpURI(refs []types.Reference) string {
+	best, bestRank := "", 0
+	for _, ref := range refs {
+		if ref.URL == "" {
+			continue
+		}
+		rank := 1
+		if ref.Kind == types.ReferenceManPage {
+			rank = 2
+			if strings.HasSuffix(ref.Locator, "=") {
+				rank = 3
+			}
+		}
+		if rank > bestRank {
+			best, bestRank = ref.URL, rank
+		}
+	}
+	return best
+}
+
+// helpMarkdown renders the suggestion followed by titled reference links
+func helpMarkdown(suggestion string, refs []types.Reference) string {
+	if len(refs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(suggestion + "\n\nSee also:\n")
+	for _, ref := range refs {
+		b.WriteString("- " + ref.Markdown() + "\n")
+	}
+	return b.String()
+}
+
 // Report writes the scan result as SARIF
 func (r *SARIFReporter) Report(result *analyzer.ScanResult) error {
 	// Build rule index map and rules list
@@ -116,15 +155,14 @@ func (r *SARIFReporter) Report(result *analyzer.ScanResult) error {
 	for i, rule := range allRules {
 		ruleIndex[rule.ID()] = i
 
-		var helpURI string
-		refs := rule.References()
-		if len(refs) > 0 {
-			helpURI = refs[0]
-		}
+		refs := rules.TypedReferences(rule)
 
 		props := map[string]any{
 			"tags": append([]string{rule.Category().String()}, rule.Tags()...),
 		}
+		if origin := rules.Origin(rule); origin != "" {
+			props["origin"] = origin
+		}
 
 		sarifRules[i] = SARIFReportingDescriptor{
 			ID:   rule.ID(),
@@ -135,9 +173,10 @@ func (r *SARIFReporter) Report(result *analyzer.ScanResult) error {
 			FullDescription: SARIFMessage{
 				Text: rule.Description(),
 			},
-			HelpURI: helpURI,
+			HelpURI: bestHelpURI(refs),
 			Help: &SARIFMessage{
-				Text: rule.Suggestion(),
+				Text:     rule.Suggestion(),
+				Markdown: helpMarkdown(rule.Suggestion(), refs),
 			},
 			Properties: props,
 			DefaultConfiguration: &SARIFConfiguration{
@@ -151,7 +190,23 @@ func (r *SARIFReporter) Report(result *analyzer.ScanResult) error {
 	for i, issue := range result.Issues {
 		idx, ok := ruleIndex[issue.RuleID]
 		if !ok {
-			idx = 0
+			// Issues found outside the rule registry, such as parse errors,
+			// describe their rule themselves
+			idx = len(sarifRules)
+			ruleIndex[issue.RuleID] = idx
+			sarifRules = append(sarifRules, SARIFReportingDescriptor{
+				ID:               issue.RuleID,
+				Name:             issue.RuleName,
+				ShortDescription: SARIFMessage{Text: issue.RuleName},
+				FullDescription:  SARIFMessage{Text: issue.RuleName},
+				Help:             &SARIFMessage{Text: issue.Suggestion},
+				Properties: map[string]any{
+					"tags": append([]string{issue.Category.String()}, issue.Tags...),
+				},
+				DefaultConfiguration: &SARIFConfiguration{
+					Level: severityToLevel(issue.Severity),
+				},
+			})
 		}
 
 		sarifResult := SARIFResult{
@@ -161,6 +216,7 @@ func (r *SARIFReporter) Report(result *analyzer.ScanResult) error {
 			Message: SARIFMessage{
 				Text: issue.Description,
 			},
+			Properties: issueProperties(issue),
 		}
 
 		// Add location if we have file info
@@ -215,3 +271,22 @@ func (r *SARIFReporter) Report(result *analyzer.ScanResult) error {
 
 	return encoder.Encode(output)
 }
+
+// issueProperties returns the structured fields an issue has, nil for none
+func issueProperties(issue types.Issue) map[string]string {
+	props := make(map[string]string)
+	for key, value := range map[string]string{
+		"directive": issue.Directive,
+		"value":     issue.Value,
+		"section":   issue.Section,
+		"expected":  issue.Expected,
+	} {
+		if value != "" {
+			props[key] = value
+		}
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	return props
+}
@@ -0,0 +1,170 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestJSONSchema(t *testing.T) {
+	var schema map[string]any
+	if err := json.Unmarshal(JSONSchema, &schema); err != nil {
+		t.Fatalf("schema is not JSON: %v", err)
+	}
+	if version := schema["properties"].(map[string]any)["version"].(map[string]any)["enum"]; !reflect.DeepEqual(version, []any{JSONSchemaVersion}) {
+		t.Errorf("schema describes version %v, reports are %s", version, JSONSchemaVersion)
+	}
+
+	full := makeScanResult()
+	line, column := 4, 12
+	full.Issues[0].Line, full.Issues[0].Column = &line, &column
+	full.Issues[0].Refs = []types.Reference{types.ManPage("systemd.exec", "Security")}
+	full.Issues[1].Tags, full.Issues[1].References = nil, nil
+	full.Recount()
+	full.Summary.SystemdVersion = 255
+	full.Summary.SkippedAnalyses = []string{"runtime"}
+	full.Summary.RulesRun = []string{"REL001", "SEC001", "SEC002"}
+	severity, category := types.SeverityLow, types.CategorySecurity
+	full.Summary.Options = types.ScanOptions{MinSeverity: &severity, Category: &category, Tags: []string{"hardening"}, Profile: "server"}
+
+	empty := &analyzer.ScanResult{Summary: analyzer.Summary{
+		BySeverity: map[types.Severity]int{},
+		ByCategory: map[types.Category]int{},
+	}}
+
+	for name, tt := range map[string]struct {
+		result *analyzer.ScanResult
+		tool   JSONTool
+	}{
+		"full":  {full, JSONTool{Version: "1.2.3", Hostname: "web1"}},
+		"empty": {empty, JSONTool{}},
+	} {
+		var buf bytes.Buffer
+		if err := NewJSONReporterFor(&buf, true, tt.tool).Report(tt.result); err != nil {
+			t.Fatalf("%s: Report failed: %v", name, err)
+		}
+		var doc any
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("%s: invalid JSON output: %v", name, err)
+		}
+		for _, err := range validate(schema, schema, doc, "") {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	// The validator must reject what the schema forbids
+	bad := map[string]any{"version": "1.0.0", "surprise": true}
+	if errs := validate(schema, schema, bad, ""); len(errs) == 0 {
+		t.Error("validate accepted a report with an old version, unknown and missing fields")
+	}
+}
+
+// validate checks doc against the parts of JSON Schema that schema.json
+// uses: type, enum, required, properties, additionalProperties,
+// propertyNames, items, minimum, maximum and local $refs
+func validate(root, schema map[string]any, doc any, path string) []error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := root
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			def = def[part].(map[string]any)
+		}
+		return validate(root, def, doc, path)
+	}
+
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", "/"+path, fmt.Sprintf(format, args...)))
+	}
+
+	if want, ok := schema["type"]; ok {
+		var allowed []string
+		switch want := want.(type) {
+		case string:
+			allowed = []string{want}
+		case []any:
+			for _, t := range want {
+				allowed = append(allowed, t.(string))
+			}
+		}
+		if !slices.Contains(allowed, jsonType(doc)) {
+			fail("is %s, want %s", jsonType(doc), strings.Join(allowed, " or "))
+			return errs
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, doc) {
+		fail("%v is not one of %v", doc, enum)
+	}
+	if min, ok := schema["minimum"].(float64); ok {
+		if n, ok := doc.(float64); ok && n < min {
+			fail("%v is below %v", n, min)
+		}
+	}
+	if max, ok := schema["maximum"].(float64); ok {
+		if n, ok := doc.(float64); ok && n > max {
+			fail("%v is above %v", n, max)
+		}
+	}
+
+	switch doc := doc.(type) {
+	case map[string]any:
+		required, _ := schema["required"].([]any)
+		for _, key := range required {
+			if _, ok := doc[key.(string)]; !ok {
+				fail("missing %q", key)
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for key, value := range doc {
+			if names, ok := schema["propertyNames"].(map[string]any); ok {
+				errs = append(errs, validate(root, names, key, path+key)...)
+			}
+			if prop, ok := props[key].(map[string]any); ok {
+				errs = append(errs, validate(root, prop, value, path+key+"/")...)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					fail("unexpected %q", key)
+				}
+			case map[string]any:
+				errs = append(errs, validate(root, extra, value, path+key+"/")...)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range doc {
+				errs = append(errs, validate(root, items, item, fmt.Sprintf("%s%d/", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
@@ -0,0 +1,56 @@
+package reporter
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/validation"
+)
+
+// sourceContext is how many lines a code frame shows on each side of the
+// line of an issue
+const sourceContext = 1
+
+// sourceLine is a line of a code frame
+type sourceLine struct {
+	Number int
+	Text   string
+	// Marked is the line of the issue
+	Marked bool
+}
+
+// sourceFiles reads the files of issues for code frames, each file once
+type sourceFiles struct {
+	fs validation.FileSystem
+	// lines holds the lines of each file read, nil for files that could not
+	// be read
+	lines map[string][]string
+}
+
+func newSourceFiles(fs validation.FileSystem) *sourceFiles {
+	return &sourceFiles{fs: fs, lines: make(map[string][]string)}
+}
+
+// frame returns the lines of file around line, or nothing when the file
+// cannot be read or has no such line
+func (s *sourceFiles) frame(file string, line int) []sourceLine {
+	lines, ok := s.lines[file]
+	if !ok {
+		if data, err := s.fs.ReadFile(file); err == nil {
+			lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		}
+		s.lines[file] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+
+	var frame []sourceLine
+	for n := max(line-sourceContext, 1); n <= min(line+sourceContext, len(lines)); n++ {
+		frame = append(frame, sourceLine{
+			Number: n,
+			Text:   strings.TrimSuffix(lines[n-1], "\r"),
+			Marked: n == line,
+		})
+	}
+	return frame
+}
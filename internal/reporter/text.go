@@ -6,80 +6,237 @@ import (
 	"strings"
 
 	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/internal/validation"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
 // TextReporter outputs scan results in human-readable format
 type TextReporter struct {
-	w        io.Writer
-	useColor bool
+	w     io.Writer
+	style style.Provider
+	// summaryOnly leaves out the issues, for a short report of the counts
+	// and the worst units
+	summaryOnly bool
+	// source reads the unit files for code frames, nil to leave them out
+	source *sourceFiles
 }
 
 // NewTextReporter creates a new text reporter
 func NewTextReporter(w io.Writer, useColor bool) *TextReporter {
-	return &TextReporter{w: w, useColor: useColor}
+	return NewStyledTextReporter(w, style.New(useColor, false))
 }
 
+// NewStyledTextReporter creates a text reporter rendering through the given style
+func NewStyledTextReporter(w io.Writer, p style.Provider) *TextReporter {
+	return &TextReporter{w: w, style: p}
+}
+
+// NewSummaryTextReporter creates a text reporter writing only the summary
+// and the units with the worst issues, as for cron jobs
+func NewSummaryTextReporter(w io.Writer, p style.Provider) *TextReporter {
+	return &TextReporter{w: w, style: p, summaryOnly: true}
+}
+
+// ShowSource makes the reporter print the lines of the unit file around
+// each issue that has a line, read from fs. Files that cannot be read are
+// left out silently.
+func (r *TextReporter) ShowSource(fs validation.FileSystem) *TextReporter {
+	r.source = newSourceFiles(fs)
+	return r
+}
+
+// topMax is how many rules and units the report lists as the noisiest
+const topMax = 10
+
+var (
+	reportSeverities = []types.Severity{types.SeverityCritical, types.SeverityHigh, types.SeverityMedium, types.SeverityLow, types.SeverityInfo}
+	reportCategories = []types.Category{types.CategorySecurity, types.CategoryReliability, types.CategoryPerformance, types.CategoryBestPractice}
+)
+
 // Report writes the scan result to the output
 //
 //nolint:errcheck // Output errors are not actionable for a text reporter
 func (r *TextReporter) Report(result *analyzer.ScanResult) error {
-	fmt.Fprintf(r.w, "\n%s\n", r.bold("sdaudit scan results"))
+	if r.style.ASCII() {
+		return r.reportPlain(result)
+	}
+
+	fmt.Fprintf(r.w, "\n%s\n", r.style.Bold("sdaudit scan results"))
 	fmt.Fprintf(r.w, "%s\n\n", strings.Repeat("=", 50))
 
+	if result.Summary.Quick {
+		fmt.Fprintf(r.w, "%s skipped %s analysis\n\n", r.style.Bold("Quick scan:"), strings.Join(result.Summary.SkippedAnalyses, ", "))
+	} else if len(result.Summary.SkippedAnalyses) > 0 {
+		fmt.Fprintf(r.w, "Skipped analyses: %s (unavailable)\n", strings.Join(result.Summary.SkippedAnalyses, ", "))
+	}
+
 	fmt.Fprintf(r.w, "Units scanned: %d\n", result.Summary.TotalUnits)
 	fmt.Fprintf(r.w, "Rules checked: %d\n", result.Summary.RulesChecked)
-	fmt.Fprintf(r.w, "Issues found:  %d\n\n", result.Summary.TotalIssues)
+	if result.Summary.FailedUnits > 0 {
+		fmt.Fprintf(r.w, "Failed units:  %s\n", r.style.Red(fmt.Sprintf("%d", result.Summary.FailedUnits)))
+	}
+	if result.Summary.ParseErrors > 0 {
+		fmt.Fprintf(r.w, "Parse errors:  %s\n", r.style.Red(fmt.Sprintf("%d", result.Summary.ParseErrors)))
+	}
+	if result.Summary.RulesSkipped > 0 {
+		fmt.Fprintf(r.w, "Rules skipped: %d (systemd %d too old)\n", result.Summary.RulesSkipped, result.Summary.SystemdVersion)
+	}
+	fmt.Fprintf(r.w, "Issues found:  %d\n", result.Summary.TotalIssues)
+	if len(result.Scores) > 0 {
+		fmt.Fprintf(r.w, "Audit score:   %d/%d\n", result.Summary.Score, types.ScoreMax)
+	}
+	_, _ = fmt.Fprintln(r.w)
 
 	if result.Summary.TotalIssues > 0 {
-		fmt.Fprintf(r.w, "%s\n", r.bold("By Severity:"))
-		for _, sev := range []types.Severity{types.SeverityCritical, types.SeverityHigh, types.SeverityMedium, types.SeverityLow, types.SeverityInfo} {
+		fmt.Fprintf(r.w, "%s\n", r.style.Bold("By Severity:"))
+		for _, sev := range reportSeverities {
 			if count := result.Summary.BySeverity[sev]; count > 0 {
-				fmt.Fprintf(r.w, "  %s: %d\n", r.colorSeverity(sev), count)
+				fmt.Fprintf(r.w, "  %s: %d\n", r.style.Severity(sev), count)
 			}
 		}
 		_, _ = fmt.Fprintln(r.w)
 
-		fmt.Fprintf(r.w, "%s\n", r.bold("By Category:"))
-		for _, cat := range []types.Category{types.CategorySecurity, types.CategoryReliability, types.CategoryPerformance, types.CategoryBestPractice} {
+		fmt.Fprintf(r.w, "%s\n", r.style.Bold("By Category:"))
+		for _, cat := range reportCategories {
 			if count := result.Summary.ByCategory[cat]; count > 0 {
 				fmt.Fprintf(r.w, "  %s: %d\n", cat.String(), count)
 			}
 		}
 		_, _ = fmt.Fprintln(r.w)
+
+		if len(result.Summary.ByRule) > 0 {
+			fmt.Fprintf(r.w, "%s\n", r.style.Bold("Top Rules:"))
+			r.printTop(result.Summary.ByRule, result.Summary.TotalIssues)
+			fmt.Fprintf(r.w, "%s\n", r.style.Bold("Top Units:"))
+			r.printTop(result.Summary.ByUnit, result.Summary.TotalIssues)
+		}
+
+		if lowest := lowestScores(result.Scores); len(lowest) > 0 {
+			fmt.Fprintf(r.w, "%s\n", r.style.Bold("Lowest Scores:"))
+			r.printScores(lowest)
+		}
+	}
+
+	if r.summaryOnly {
+		if len(result.Summary.WorstUnits) > 0 {
+			fmt.Fprintf(r.w, "%s\n", r.style.Bold("Worst Offenders:"))
+			r.printWorstUnits(result.Summary.WorstUnits)
+		} else if result.Summary.TotalIssues == 0 {
+			fmt.Fprintf(r.w, "%s\n", r.style.Green("No issues found!"))
+		}
+		return nil
 	}
 
 	if len(result.Issues) > 0 {
-		fmt.Fprintf(r.w, "%s\n", r.bold("Issues:"))
+		fmt.Fprintf(r.w, "%s\n", r.style.Bold("Issues:"))
 		fmt.Fprintf(r.w, "%s\n\n", strings.Repeat("-", 50))
 
 		for i, issue := range result.Issues {
 			r.printIssue(i+1, &issue)
 		}
 	} else {
-		fmt.Fprintf(r.w, "%s\n", r.green("No issues found!"))
+		fmt.Fprintf(r.w, "%s\n", r.style.Green("No issues found!"))
 	}
 
 	return nil
 }
 
+// printTop writes the topMax largest counts with their share of total, as a
+// table or a line per count in ASCII mode
+//
+//nolint:errcheck // Output errors are not actionable for a text reporter
+func (r *TextReporter) printTop(counts map[string]int, total int) {
+	top := types.TopCounts(counts, topMax)
+	width := 0
+	for _, c := range top {
+		width = max(width, len(c.Name))
+	}
+	for _, c := range top {
+		share := c.Count * 100 / max(total, 1)
+		if r.style.ASCII() {
+			fmt.Fprintf(r.w, "%s: %d issues, %d%%\n", c.Name, c.Count, share)
+			continue
+		}
+		fmt.Fprintf(r.w, "  %-*s  %4d  %3d%%\n", width, c.Name, c.Count, share)
+	}
+	_, _ = fmt.Fprintln(r.w)
+}
+
+// lowestScores returns the topMax lowest scores below ScoreMax, from scores
+// sorted lowest first
+func lowestScores(scores []types.UnitScore) []types.UnitScore {
+	n := 0
+	for n < len(scores) && n < topMax && scores[n].Score < types.ScoreMax {
+		n++
+	}
+	return scores[:n]
+}
+
+// printScores writes a line per unit score
+//
+//nolint:errcheck // Output errors are not actionable for a text reporter
+func (r *TextReporter) printScores(scores []types.UnitScore) {
+	indent := "  "
+	if r.style.ASCII() {
+		indent = ""
+	}
+	for _, s := range scores {
+		fmt.Fprintf(r.w, "%s%s\n", indent, r.style.Text(fmt.Sprintf("%s — score %d/%d", s.Unit, s.Score, types.ScoreMax)))
+	}
+	_, _ = fmt.Fprintln(r.w)
+}
+
+// printWorstUnits writes a table of units with their weighted issues, or a
+// line per unit in ASCII mode
+//
+//nolint:errcheck // Output errors are not actionable for a text reporter
+func (r *TextReporter) printWorstUnits(units []types.UnitWeight) {
+	if r.style.ASCII() {
+		for _, u := range units {
+			fmt.Fprintf(r.w, "%s: weight %d, %d issues\n", u.Unit, u.Weight, u.Issues)
+		}
+		_, _ = fmt.Fprintln(r.w)
+		return
+	}
+
+	width := len("Unit")
+	for _, u := range units {
+		width = max(width, len(u.Unit))
+	}
+	fmt.Fprintf(r.w, "  %-*s  %6s  %6s\n", width, "Unit", "Weight", "Issues")
+	for _, u := range units {
+		fmt.Fprintf(r.w, "  %-*s  %6d  %6d\n", width, u.Unit, u.Weight, u.Issues)
+	}
+	_, _ = fmt.Fprintln(r.w)
+}
+
 //nolint:errcheck // Output errors are not actionable for a text reporter
 func (r *TextReporter) printIssue(num int, issue *types.Issue) {
-	fmt.Fprintf(r.w, "%d. [%s] %s: %s\n", num, r.colorSeverity(issue.Severity), r.bold(issue.RuleID), issue.RuleName)
+	fmt.Fprintf(r.w, "%d. [%s] %s: %s\n", num, r.style.Severity(issue.Severity), r.style.Bold(issue.RuleID), issue.RuleName)
 	fmt.Fprintf(r.w, "   Unit: %s\n", issue.Unit)
+	if issue.Origin != "" {
+		fmt.Fprintf(r.w, "   From: %s\n", issue.Origin)
+	}
 	if issue.File != "" {
 		fmt.Fprintf(r.w, "   File: %s", issue.File)
 		if issue.Line != nil {
 			fmt.Fprintf(r.w, ":%d", *issue.Line)
 		}
 		_, _ = fmt.Fprintln(r.w)
+		r.printSource(issue)
 	}
-	fmt.Fprintf(r.w, "   %s\n", issue.Description)
+	fmt.Fprintf(r.w, "   %s\n", strings.ReplaceAll(issue.Description, "\n", "\n   "))
 	if issue.Suggestion != "" {
-		fmt.Fprintf(r.w, "   %s %s\n", r.bold("Fix:"), issue.Suggestion)
+		fmt.Fprintf(r.w, "   %s %s\n", r.style.Bold("Fix:"), issue.Suggestion)
 	}
-	if len(issue.References) > 0 {
-		fmt.Fprintf(r.w, "   %s\n", r.bold("References:"))
+	if len(issue.Refs) > 0 {
+		fmt.Fprintf(r.w, "   %s\n", r.style.Bold("References:"))
+		for _, ref := range issue.Refs {
+			fmt.Fprintf(r.w, "     - %s\n", ref)
+		}
+	} else if len(issue.References) > 0 {
+		fmt.Fprintf(r.w, "   %s\n", r.style.Bold("References:"))
 		for _, ref := range issue.References {
 			fmt.Fprintf(r.w, "     - %s\n", ref)
 		}
@@ -87,45 +244,151 @@ func (r *TextReporter) printIssue(num int, issue *types.Issue) {
 	_, _ = fmt.Fprintln(r.w)
 }
 
-const (
-	colorReset  = "\033[0m"
-	colorBold   = "\033[1m"
-	colorRed    = "\033[31m"
-	colorYellow = "\033[33m"
-	colorGreen  = "\033[32m"
-	colorCyan   = "\033[36m"
-	colorGray   = "\033[90m"
-)
+// printSource writes the code frame of an issue with a line: a gutter of line
+// numbers with the issue's line marked and in bold, or in ASCII mode a line
+// per source line naming its number
+//
+//nolint:errcheck // Output errors are not actionable for a text reporter
+func (r *TextReporter) printSource(issue *types.Issue) {
+	if r.source == nil || issue.Line == nil {
+		return
+	}
+	frame := r.source.frame(issue.File, *issue.Line)
+	if len(frame) == 0 {
+		return
+	}
 
-func (r *TextReporter) bold(s string) string {
-	if !r.useColor {
-		return s
+	if r.style.ASCII() {
+		for _, line := range frame {
+			label := fmt.Sprintf("Source line %d", line.Number)
+			if line.Marked {
+				label += ", this issue"
+			}
+			fmt.Fprintf(r.w, "%s\n", strings.TrimRight(label+": "+r.style.Text(line.Text), " "))
+		}
+		return
 	}
-	return colorBold + s + colorReset
-}
 
-func (r *TextReporter) green(s string) string {
-	if !r.useColor {
-		return s
+	width := len(fmt.Sprint(frame[len(frame)-1].Number))
+	for _, line := range frame {
+		text := strings.TrimRight(fmt.Sprintf("%*d | %s", width, line.Number, line.Text), " ")
+		if line.Marked {
+			fmt.Fprintf(r.w, "   %s\n", r.style.Bold("> "+text))
+		} else {
+			fmt.Fprintf(r.w, "     %s\n", text)
+		}
 	}
-	return colorGreen + s + colorReset
 }
 
-func (r *TextReporter) colorSeverity(sev types.Severity) string {
-	name := strings.ToUpper(sev.String())
-	if !r.useColor {
-		return name
-	}
-	switch sev {
-	case types.SeverityCritical:
-		return colorBold + colorRed + name + colorReset
-	case types.SeverityHigh:
-		return colorRed + name + colorReset
-	case types.SeverityMedium:
-		return colorYellow + name + colorReset
-	case types.SeverityLow:
-		return colorCyan + name + colorReset
-	default:
-		return colorGray + name + colorReset
+// reportPlain writes the result as an ordered, heading-structured ASCII
+// document with one fact per line, for reading line by line with a screen reader
+//
+//nolint:errcheck // Output errors are not actionable for a text reporter
+func (r *TextReporter) reportPlain(result *analyzer.ScanResult) error {
+	summary := result.Summary
+	fmt.Fprintf(r.w, "%s\n\n", r.style.Heading(1, "sdaudit scan results"))
+
+	fmt.Fprintf(r.w, "%s\n", r.style.Heading(2, "Summary"))
+	if summary.Quick {
+		fmt.Fprintf(r.w, "Quick scan: skipped %s analysis\n", strings.Join(summary.SkippedAnalyses, ", "))
+	} else if len(summary.SkippedAnalyses) > 0 {
+		fmt.Fprintf(r.w, "Skipped analyses: %s (unavailable)\n", strings.Join(summary.SkippedAnalyses, ", "))
+	}
+	fmt.Fprintf(r.w, "Units scanned: %d\n", summary.TotalUnits)
+	fmt.Fprintf(r.w, "Rules checked: %d\n", summary.RulesChecked)
+	if summary.FailedUnits > 0 {
+		fmt.Fprintf(r.w, "Failed units: %d\n", summary.FailedUnits)
+	}
+	if summary.ParseErrors > 0 {
+		fmt.Fprintf(r.w, "Parse errors: %d\n", summary.ParseErrors)
+	}
+	if summary.RulesSkipped > 0 {
+		fmt.Fprintf(r.w, "Rules skipped: %d (systemd %d too old)\n", summary.RulesSkipped, summary.SystemdVersion)
 	}
+	fmt.Fprintf(r.w, "Issues found: %d\n", summary.TotalIssues)
+	if len(result.Scores) > 0 {
+		fmt.Fprintf(r.w, "Audit score: %d/%d\n", summary.Score, types.ScoreMax)
+	}
+	_, _ = fmt.Fprintln(r.w)
+
+	if summary.TotalIssues > 0 {
+		fmt.Fprintf(r.w, "%s\n", r.style.Heading(2, "Issues by severity"))
+		for _, sev := range reportSeverities {
+			if count := summary.BySeverity[sev]; count > 0 {
+				fmt.Fprintf(r.w, "%s: %d\n", r.style.Severity(sev), count)
+			}
+		}
+		fmt.Fprintf(r.w, "\n%s\n", r.style.Heading(2, "Issues by category"))
+		for _, cat := range reportCategories {
+			if count := summary.ByCategory[cat]; count > 0 {
+				fmt.Fprintf(r.w, "%s: %d\n", cat.String(), count)
+			}
+		}
+		_, _ = fmt.Fprintln(r.w)
+
+		if len(summary.ByRule) > 0 {
+			fmt.Fprintf(r.w, "%s\n", r.style.Heading(2, "Rules with the most issues"))
+			r.printTop(summary.ByRule, summary.TotalIssues)
+			fmt.Fprintf(r.w, "%s\n", r.style.Heading(2, "Units with the most issues"))
+			r.printTop(summary.ByUnit, summary.TotalIssues)
+		}
+
+		if lowest := lowestScores(result.Scores); len(lowest) > 0 {
+			fmt.Fprintf(r.w, "%s\n", r.style.Heading(2, "Lowest scores"))
+			r.printScores(lowest)
+		}
+	}
+
+	if r.summaryOnly {
+		if len(summary.WorstUnits) > 0 {
+			fmt.Fprintf(r.w, "%s\n", r.style.Heading(2, "Worst offenders"))
+			r.printWorstUnits(summary.WorstUnits)
+		} else if summary.TotalIssues == 0 {
+			fmt.Fprintln(r.w, "No issues found.")
+		}
+		return nil
+	}
+
+	if len(result.Issues) == 0 {
+		fmt.Fprintln(r.w, "No issues found.")
+		return nil
+	}
+
+	fmt.Fprintf(r.w, "%s\n\n", r.style.Heading(2, "Issues"))
+	for i, issue := range result.Issues {
+		fmt.Fprintf(r.w, "%s\n", r.style.Heading(3, fmt.Sprintf("Issue %d of %d: %s %s", i+1, len(result.Issues), issue.RuleID, issue.RuleName)))
+		fmt.Fprintf(r.w, "Severity: %s\n", r.style.Severity(issue.Severity))
+		fmt.Fprintf(r.w, "Unit: %s\n", issue.Unit)
+		if issue.Origin != "" {
+			fmt.Fprintf(r.w, "From: %s\n", issue.Origin)
+		}
+		if issue.File != "" {
+			fmt.Fprintf(r.w, "File: %s", issue.File)
+			if issue.Line != nil {
+				fmt.Fprintf(r.w, ", line %d", *issue.Line)
+			}
+			_, _ = fmt.Fprintln(r.w)
+			r.printSource(&issue)
+		}
+		fmt.Fprintf(r.w, "Description: %s\n", r.style.Text(issue.Description))
+		if issue.Suggestion != "" {
+			fmt.Fprintf(r.w, "Fix: %s\n", r.style.Text(issue.Suggestion))
+		}
+		refs := issue.References
+		if len(issue.Refs) > 0 {
+			refs = nil
+			for _, ref := range issue.Refs {
+				refs = append(refs, ref.String())
+			}
+		}
+		if len(refs) > 0 {
+			fmt.Fprintln(r.w, "References:")
+			for _, ref := range refs {
+				fmt.Fprintf(r.w, "- %s\n", r.style.Text(ref))
+			}
+		}
+		_, _ = fmt.Fprintln(r.w)
+	}
+
+	return nil
 }
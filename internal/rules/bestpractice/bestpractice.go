@@ -1,10 +1,14 @@
 package bestpractice
 
 import (
-	"os/user"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/internal/validation"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -19,36 +23,38 @@ func init() {
 	rules.Register(&BP008{})
 	rules.Register(&BP009{})
 	rules.Register(&BP010{})
+	rules.Register(&BP011{})
+	rules.Register(&BP012{})
 }
 
-// BP001 - Override in /etc without drop-in
+// BP001 - Full override of a unit file instead of a drop-in
 type BP001 struct{}
 
 func (r *BP001) ID() string   { return "BP001" }
-func (r *BP001) Name() string { return "Full override in /etc instead of drop-in" }
+func (r *BP001) Name() string { return "Full override instead of drop-in" }
 func (r *BP001) Description() string {
-	return "Prefer drop-ins over full overrides for maintainability."
+	return "A unit file that shadows one of the same name in a later search path, such as a copy of the vendor file in /etc, hides every later change to the vendor file. Prefer drop-ins over full overrides for maintainability."
 }
 func (r *BP001) Category() types.Category { return types.CategoryBestPractice }
 func (r *BP001) Severity() types.Severity { return types.SeverityInfo }
 func (r *BP001) Tags() []string           { return []string{"override", "maintainability"} }
-func (r *BP001) Suggestion() string       { return "Use /etc/systemd/system/unit.d/*.conf drop-ins instead." }
+func (r *BP001) Suggestion() string {
+	return "Move the changed directives to a drop-in such as /etc/systemd/system/unit.d/override.conf (systemctl edit) and remove the copy."
+}
 func (r *BP001) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html"}
 }
+
+// Check reports units whose file shadows another of the same name in a later
+// search path, such as a copy of the vendor unit in /etc. Masks are left
+// alone; they are meant to shadow the unit.
 func (r *BP001) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil {
+	if unit == nil || unit.Masked || len(unit.ShadowedPaths) == 0 {
 		return nil
 	}
-	if strings.HasPrefix(unit.Path, "/etc/systemd/system/") && !strings.Contains(unit.Path, ".d/") {
-		// Check if there's a corresponding unit in /lib
-		libPath := strings.Replace(unit.Path, "/etc/systemd/system/", "/lib/systemd/system/", 1)
-		if _, exists := ctx.AllUnits[unit.Name]; exists && strings.HasPrefix(libPath, "/lib") {
-			return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Full override in /etc, consider drop-in instead.", Suggestion: r.Suggestion(), References: r.References()}}
-		}
-	}
-	return nil
+	description := fmt.Sprintf("%s fully overrides %s, so later changes to that file are ignored; consider a drop-in instead.", unit.Path, unit.ShadowedPaths[0])
+	return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: description, Suggestion: r.Suggestion(), References: r.References()}}
 }
 
 // BP002 - Deprecated directives
@@ -98,11 +104,11 @@ func (r *BP003) Suggestion() string       { return "Use absolute path in ExecSta
 func (r *BP003) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#ExecStart="}
 }
+func (r *BP003) ExampleBad() string  { return "[Service]\nExecStart=app --serve\n" }
+func (r *BP003) ExampleGood() string { return "[Service]\nExecStart=/usr/bin/app --serve\n" }
+func (r *BP003) AppliesTo() []string { return []string{"service"} }
 func (r *BP003) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
 	execStart := unit.GetDirective("Service", "ExecStart")
 	if execStart == "" {
 		return nil
@@ -155,13 +161,14 @@ func (r *BP005) Suggestion() string       { return "Move environment variables t
 func (r *BP005) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#EnvironmentFile="}
 }
+func (r *BP005) AppliesTo() []string { return []string{"service"} }
+func (r *BP005) Params() []rules.Param {
+	return []rules.Param{{Name: "max_environment", Kind: rules.ParamInt, Default: "3", Description: "Most Environment= lines accepted"}}
+}
 func (r *BP005) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
 	envs := unit.GetDirectives("Service", "Environment")
-	if len(envs) > 3 {
+	if len(envs) > ctx.IntParam(r, "max_environment") {
 		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service has many inline Environment= directives.", Suggestion: r.Suggestion(), References: r.References()}}
 	}
 	return nil
@@ -232,9 +239,11 @@ func (r *BP008) Check(ctx *rules.Context) []types.Issue {
 // BP009 - Non-existent User/Group
 type BP009 struct{}
 
-func (r *BP009) ID() string               { return "BP009" }
-func (r *BP009) Name() string             { return "User or Group may not exist" }
-func (r *BP009) Description() string      { return "Specified User/Group should exist on the system." }
+func (r *BP009) ID() string   { return "BP009" }
+func (r *BP009) Name() string { return "User or Group may not exist" }
+func (r *BP009) Description() string {
+	return "User=, Group= and SupplementaryGroups= should name accounts that exist on the system, or valid numeric IDs. A numeric ID without a passwd or group entry works, but leaves the service without a user name, home directory or shell from NSS."
+}
 func (r *BP009) Category() types.Category { return types.CategoryBestPractice }
 func (r *BP009) Severity() types.Severity { return types.SeverityHigh }
 func (r *BP009) Tags() []string           { return []string{"user", "permissions"} }
@@ -242,18 +251,148 @@ func (r *BP009) Suggestion() string       { return "Ensure the user/group exists
 func (r *BP009) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#User="}
 }
+func (r *BP009) ExampleBad() string {
+	return "[Service]\nExecStart=/usr/bin/app\nUser=app\nGroup=app\n"
+}
+func (r *BP009) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nDynamicUser=yes\n"
+}
+func (r *BP009) Capabilities() rules.Capability { return rules.CapabilityFilesystem }
+func (r *BP009) AppliesTo() []string            { return []string{"service"} }
 func (r *BP009) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	fs := ctx.FileSystem
+	if fs == nil {
+		return nil
+	}
+	// systemd allocates the user and group of a DynamicUser= service itself
+	if v := unit.GetDirective("Service", "DynamicUser"); v == "yes" || v == "true" {
 		return nil
 	}
-	userName := unit.GetDirective("Service", "User")
-	if userName != "" && userName != "root" {
-		if _, err := user.Lookup(userName); err != nil {
-			return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "User '" + userName + "' may not exist.", Suggestion: r.Suggestion(), References: r.References()}}
+
+	var issues []types.Issue
+	for _, a := range serviceAccounts(unit) {
+		if a.name == "root" || a.name == "0" {
+			continue
+		}
+		issue := types.Issue{
+			RuleID:     r.ID(),
+			RuleName:   r.Name(),
+			Severity:   r.Severity(),
+			Category:   r.Category(),
+			Tags:       r.Tags(),
+			Unit:       unit.Name,
+			File:       unit.Path,
+			Suggestion: r.Suggestion(),
+			References: r.References(),
+			Directive:  a.directive,
+			Value:      a.name,
+			Section:    "Service",
 		}
+		if a.line > 0 {
+			line := a.line
+			issue.Line = &line
+		}
+
+		id, numeric, valid := parseAccountID(a.name)
+		switch {
+		case numeric && !valid:
+			issue.Description = a.directive + "=" + a.name + " is not a valid " + a.idKind() + "; systemd refuses to start the service."
+		case numeric && !a.idExists(fs, id):
+			issue.Severity = types.SeverityInfo
+			issue.Description = a.directive + "=" + a.name + " has no " + a.database() + " entry. The service runs with that " + a.idKind() + ", but without a name, home directory or shell from NSS."
+		case !numeric && !a.exists(fs):
+			issue.Description = a.label() + " '" + a.name + "' may not exist."
+		default:
+			continue
+		}
+		issues = append(issues, issue)
 	}
-	return nil
+	return issues
+}
+
+// account is a user or group a service runs with
+type account struct {
+	directive string // User, Group or SupplementaryGroups
+	name      string
+	line      int
+}
+
+// serviceAccounts returns the accounts of User=, Group= and each group of
+// SupplementaryGroups=, which an empty assignment resets
+func serviceAccounts(unit *types.UnitFile) []account {
+	var accounts []account
+	for _, key := range []string{"User", "Group"} {
+		if directives := unit.GetDirectives("Service", key); len(directives) > 0 {
+			if d := directives[len(directives)-1]; d.Value != "" {
+				accounts = append(accounts, account{directive: key, name: d.Value, line: d.Line})
+			}
+		}
+	}
+	var groups []account
+	for _, d := range unit.GetDirectives("Service", "SupplementaryGroups") {
+		if d.Value == "" {
+			groups = nil
+			continue
+		}
+		for _, name := range strings.Fields(d.Value) {
+			groups = append(groups, account{directive: "SupplementaryGroups", name: name, line: d.Line})
+		}
+	}
+	return append(accounts, groups...)
+}
+
+func (a account) isUser() bool { return a.directive == "User" }
+
+func (a account) label() string {
+	switch a.directive {
+	case "User":
+		return "User"
+	case "Group":
+		return "Group"
+	}
+	return "Supplementary group"
+}
+
+func (a account) idKind() string {
+	if a.isUser() {
+		return "UID"
+	}
+	return "GID"
+}
+
+func (a account) database() string {
+	if a.isUser() {
+		return "passwd"
+	}
+	return "group"
+}
+
+func (a account) exists(fs validation.FileSystem) bool {
+	if a.isUser() {
+		return fs.UserExists(a.name)
+	}
+	return fs.GroupExists(a.name)
+}
+
+func (a account) idExists(fs validation.FileSystem, id uint32) bool {
+	if a.isUser() {
+		return fs.UserIDExists(id)
+	}
+	return fs.GroupIDExists(id)
+}
+
+// parseAccountID parses a numeric UID or GID. systemd refuses -1 in both its
+// 32-bit and 16-bit forms, 4294967295 and 65535, which mean "no ID".
+func parseAccountID(s string) (id uint32, numeric, valid bool) {
+	if s == "" || strings.Trim(s, "0123456789") != "" {
+		return 0, false, false
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n == 4294967295 || n == 65535 {
+		return 0, true, false
+	}
+	return uint32(n), true, true
 }
 
 // BP010 - Type=oneshot without RemainAfterExit
@@ -271,11 +410,13 @@ func (r *BP010) Suggestion() string       { return "Consider adding RemainAfterE
 func (r *BP010) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#RemainAfterExit="}
 }
+func (r *BP010) ExampleBad() string { return "[Service]\nType=oneshot\nExecStart=/usr/bin/app-setup\n" }
+func (r *BP010) ExampleGood() string {
+	return "[Service]\nType=oneshot\nRemainAfterExit=yes\nExecStart=/usr/bin/app-setup\n"
+}
+func (r *BP010) AppliesTo() []string { return []string{"service"} }
 func (r *BP010) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
 	if unit.GetDirective("Service", "Type") == "oneshot" {
 		if unit.GetDirective("Service", "RemainAfterExit") == "" {
 			return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Oneshot service without RemainAfterExit.", Suggestion: r.Suggestion(), References: r.References()}}
@@ -283,3 +424,124 @@ func (r *BP010) Check(ctx *rules.Context) []types.Issue {
 	}
 	return nil
 }
+
+// BP011 - Directive newer than the target systemd
+type BP011 struct{}
+
+func (r *BP011) ID() string   { return "BP011" }
+func (r *BP011) Name() string { return "Directive unsupported by systemd version" }
+func (r *BP011) Description() string {
+	return "Directives introduced after the target's systemd version are ignored with a warning."
+}
+func (r *BP011) Category() types.Category { return types.CategoryBestPractice }
+func (r *BP011) Severity() types.Severity { return types.SeverityHigh }
+func (r *BP011) Tags() []string           { return []string{"compatibility", "version"} }
+func (r *BP011) Suggestion() string {
+	return "Remove the directive or upgrade systemd; it has no effect on this version."
+}
+func (r *BP011) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.directives.html"}
+}
+func (r *BP011) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	version := ctx.SystemdVersion()
+	if unit == nil || version == 0 {
+		return nil
+	}
+
+	sections := make([]string, 0, len(unit.Sections))
+	for name := range unit.Sections {
+		sections = append(sections, name)
+	}
+	sort.Strings(sections)
+
+	var issues []types.Issue
+	for _, name := range sections {
+		keys := make([]string, 0, len(unit.Sections[name].Directives))
+		for key := range unit.Sections[name].Directives {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			minVersion, ok := rules.DirectiveVersions[key]
+			if !ok || minVersion <= version {
+				continue
+			}
+			line := unit.Sections[name].Directives[key][0].Line
+			issues = append(issues, types.Issue{
+				RuleID:      r.ID(),
+				RuleName:    r.Name(),
+				Severity:    r.Severity(),
+				Category:    r.Category(),
+				Tags:        r.Tags(),
+				Unit:        unit.Name,
+				File:        unit.Path,
+				Line:        &line,
+				Description: key + "= requires systemd " + strconv.Itoa(minVersion) + ", target runs " + strconv.Itoa(version) + ".",
+				Suggestion:  r.Suggestion(),
+				References:  r.References(),
+			})
+		}
+	}
+	return issues
+}
+
+// BP012 - Override that copies the vendor file or changes little of it
+type BP012 struct{}
+
+// dropInMax is the most directives an override may change for BP012 to
+// suggest a drop-in instead
+const dropInMax = 2
+
+func (r *BP012) ID() string   { return "BP012" }
+func (r *BP012) Name() string { return "Override should be a drop-in" }
+func (r *BP012) Description() string {
+	return "A full override that is a copy of the vendor file changes nothing but stops the unit from picking up vendor updates; one that changes only a directive or two is better written as a drop-in."
+}
+func (r *BP012) Category() types.Category { return types.CategoryBestPractice }
+func (r *BP012) Severity() types.Severity { return types.SeverityLow }
+func (r *BP012) Tags() []string           { return []string{"override", "maintainability"} }
+func (r *BP012) Suggestion() string {
+	return "Remove the copy, putting any changed directives in a drop-in such as /etc/systemd/system/unit.d/override.conf (systemctl edit)."
+}
+func (r *BP012) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html"}
+}
+func (r *BP012) Capabilities() rules.Capability { return rules.CapabilityFilesystem }
+
+// Check compares an override with the vendor file it shadows, directive by
+// directive
+func (r *BP012) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	if unit == nil || unit.Masked || len(unit.ShadowedPaths) == 0 {
+		return nil
+	}
+	vendor, err := unitfile.Parse(unit.ShadowedPaths[0])
+	if err != nil || vendor.Masked {
+		return nil
+	}
+	override := unit
+	if len(unit.DropIns) > 0 {
+		// The drop-ins apply on top of the override; compare the file itself
+		if override, err = unitfile.Parse(unit.Path); err != nil {
+			return nil
+		}
+	}
+
+	changes := unitfile.Diff(vendor, override)
+	var description string
+	switch {
+	case len(changes) == 0:
+		description = fmt.Sprintf("%s is a copy of %s, identical directive for directive; remove it.", unit.Path, vendor.Path)
+	case len(changes) <= dropInMax:
+		keys := make([]string, len(changes))
+		for i, c := range changes {
+			keys[i] = c.Key + "="
+		}
+		description = fmt.Sprintf("%s changes only %s of %s; move the change to a drop-in.", unit.Path, strings.Join(keys, " and "), vendor.Path)
+	default:
+		return nil
+	}
+	return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: description, Suggestion: r.Suggestion(), References: r.References()}}
+}
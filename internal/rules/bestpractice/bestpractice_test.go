@@ -1,9 +1,14 @@
 package bestpractice
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/internal/validation"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -72,6 +77,84 @@ func makeTestUnitWithMultipleDirectives(section, key string, values []string) *t
 	return unit
 }
 
+func TestBP001_FullOverride(t *testing.T) {
+	rule := &BP001{}
+
+	tests := []struct {
+		name       string
+		shadowed   []string
+		masked     bool
+		wantIssues int
+	}{
+		{"own unit", nil, false, 0},
+		{"copy of the vendor unit", []string{"/usr/lib/systemd/system/test.service"}, false, 1},
+		{"mask", []string{"/usr/lib/systemd/system/test.service"}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(map[string]string{"ExecStart": "/usr/bin/test"}, nil, nil)
+			unit.ShadowedPaths = tt.shadowed
+			unit.Masked = tt.masked
+			issues := rule.Check(rules.NewContext(unit))
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("got %d issues, want %d: %+v", len(issues), tt.wantIssues, issues)
+			}
+			if tt.wantIssues > 0 && issues[0].Description != "/etc/systemd/system/test.service fully overrides /usr/lib/systemd/system/test.service, so later changes to that file are ignored; consider a drop-in instead." {
+				t.Errorf("description = %q", issues[0].Description)
+			}
+		})
+	}
+}
+
+func TestBP012_OverrideShouldBeDropIn(t *testing.T) {
+	rule := &BP012{}
+	vendor := "[Unit]\nDescription=Test\n\n[Service]\nExecStart=/usr/bin/test\nUser=test\nRestart=no\n"
+
+	tests := []struct {
+		name     string
+		override string
+		want     string
+	}{
+		{"verbatim copy", "# copied\n[Service]\nRestart=no\nUser=test\nExecStart=/usr/bin/test\n\n[Unit]\nDescription=Test\n", "is a copy of"},
+		{"one directive changed", "[Unit]\nDescription=Test\n\n[Service]\nExecStart=/usr/bin/test\nUser=test\nRestart=always\n", "changes only Restart= of"},
+		{"rewritten", "[Unit]\nDescription=Other\n\n[Service]\nExecStart=/usr/bin/other\nRestart=always\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			vendorPath := filepath.Join(dir, "vendor.service")
+			overridePath := filepath.Join(dir, "test.service")
+			if err := os.WriteFile(vendorPath, []byte(vendor), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(overridePath, []byte(tt.override), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			unit, err := unitfile.Parse(overridePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			unit.ShadowedPaths = []string{vendorPath}
+
+			issues := rule.Check(rules.NewContext(unit))
+			if tt.want == "" {
+				if len(issues) != 0 {
+					t.Fatalf("got issues for a rewritten unit: %+v", issues)
+				}
+				return
+			}
+			if len(issues) != 1 {
+				t.Fatalf("got %d issues, want 1: %+v", len(issues), issues)
+			}
+			if !strings.Contains(issues[0].Description, tt.want) {
+				t.Errorf("description = %q, want it to contain %q", issues[0].Description, tt.want)
+			}
+		})
+	}
+}
+
 func TestBP002_DeprecatedDirectives(t *testing.T) {
 	rule := &BP002{}
 
@@ -299,6 +382,157 @@ func TestBP010_OneshotWithoutRemainAfterExit(t *testing.T) {
 	}
 }
 
+func TestBP009_UsersAndGroups(t *testing.T) {
+	rule := &BP009{}
+	fs := validation.NewMockFileSystem()
+	fs.Users["app"] = true
+	fs.Groups["app"] = true
+	fs.Groups["adm"] = true
+	fs.UserIDs[1000] = true
+	fs.GroupIDs[1000] = true
+
+	tests := []struct {
+		name         string
+		service      map[string]string
+		wantSeverity []types.Severity
+		wantValue    []string
+	}{
+		{
+			name:    "existing user and group",
+			service: map[string]string{"User": "app", "Group": "app", "SupplementaryGroups": "adm"},
+		},
+		{
+			name:    "root",
+			service: map[string]string{"User": "root", "Group": "0"},
+		},
+		{
+			name:         "missing user",
+			service:      map[string]string{"User": "ghost"},
+			wantSeverity: []types.Severity{types.SeverityHigh},
+			wantValue:    []string{"ghost"},
+		},
+		{
+			name:         "missing group",
+			service:      map[string]string{"User": "app", "Group": "ghost"},
+			wantSeverity: []types.Severity{types.SeverityHigh},
+			wantValue:    []string{"ghost"},
+		},
+		{
+			name:         "missing supplementary group",
+			service:      map[string]string{"SupplementaryGroups": "adm ghost"},
+			wantSeverity: []types.Severity{types.SeverityHigh},
+			wantValue:    []string{"ghost"},
+		},
+		{
+			name:    "DynamicUser",
+			service: map[string]string{"DynamicUser": "yes", "User": "ghost", "Group": "ghost"},
+		},
+		{
+			name:    "numeric IDs with entries",
+			service: map[string]string{"User": "1000", "Group": "1000"},
+		},
+		{
+			name:         "numeric UID without passwd entry",
+			service:      map[string]string{"User": "4242"},
+			wantSeverity: []types.Severity{types.SeverityInfo},
+			wantValue:    []string{"4242"},
+		},
+		{
+			name:         "invalid UID and GID",
+			service:      map[string]string{"User": "65535", "Group": "4294967296"},
+			wantSeverity: []types.Severity{types.SeverityHigh, types.SeverityHigh},
+			wantValue:    []string{"65535", "4294967296"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := rules.NewContext(makeTestUnit(tt.service, nil, nil))
+			ctx.FileSystem = fs
+			issues := rule.Check(ctx)
+
+			if len(issues) != len(tt.wantSeverity) {
+				t.Fatalf("got %d issues, want %d: %v", len(issues), len(tt.wantSeverity), issues)
+			}
+			for i, issue := range issues {
+				if issue.Severity != tt.wantSeverity[i] || issue.Value != tt.wantValue[i] {
+					t.Errorf("issue %d = %s %q, want %s %q", i, issue.Severity, issue.Value, tt.wantSeverity[i], tt.wantValue[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBP009_SupplementaryGroupsReset(t *testing.T) {
+	unit := makeTestUnitWithMultipleDirectives("Service", "SupplementaryGroups", []string{"ghost", "", "adm"})
+	fs := validation.NewMockFileSystem()
+	fs.Groups["adm"] = true
+	ctx := rules.NewContext(unit)
+	ctx.FileSystem = fs
+
+	if issues := (&BP009{}).Check(ctx); len(issues) != 0 {
+		t.Errorf("groups before an empty SupplementaryGroups= were checked: %v", issues)
+	}
+}
+
+func TestBP009_NoFileSystem(t *testing.T) {
+	ctx := rules.NewContext(makeTestUnit(map[string]string{"User": "ghost"}, nil, nil))
+	if issues := (&BP009{}).Check(ctx); len(issues) != 0 {
+		t.Errorf("got %d issues without a FileSystem, want 0", len(issues))
+	}
+}
+
+func TestBP011_DirectiveNewerThanSystemd(t *testing.T) {
+	rule := &BP011{}
+
+	tests := []struct {
+		name       string
+		service    map[string]string
+		version    string
+		wantIssues int
+	}{
+		{
+			name:       "unknown version",
+			service:    map[string]string{"ProtectProc": "invisible"},
+			version:    "",
+			wantIssues: 0,
+		},
+		{
+			name:       "supported directive",
+			service:    map[string]string{"ProtectProc": "invisible"},
+			version:    "252",
+			wantIssues: 0,
+		},
+		{
+			name:       "directive too new",
+			service:    map[string]string{"ProtectProc": "invisible", "NoNewPrivileges": "yes"},
+			version:    "219",
+			wantIssues: 1,
+		},
+		{
+			name:       "several directives too new",
+			service:    map[string]string{"ProtectProc": "invisible", "LoadCredential": "key:/etc/key", "RestrictSUIDSGID": "yes"},
+			version:    "219",
+			wantIssues: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(tt.service, nil, nil)
+			ctx := rules.NewContext(unit)
+			if tt.version != "" {
+				ctx.SystemInfo = &rules.SystemInfo{SystemdVersion: tt.version}
+			}
+			issues := rule.Check(ctx)
+
+			if len(issues) != tt.wantIssues {
+				t.Errorf("got %d issues, want %d", len(issues), tt.wantIssues)
+			}
+		})
+	}
+}
+
 func TestRuleMetadata(t *testing.T) {
 	testRules := []rules.Rule{
 		&BP001{},
@@ -311,6 +545,8 @@ func TestRuleMetadata(t *testing.T) {
 		&BP008{},
 		&BP009{},
 		&BP010{},
+		&BP011{},
+		&BP012{},
 	}
 
 	for _, rule := range testRules {
@@ -354,10 +590,15 @@ func TestNonServiceUnit(t *testing.T) {
 		},
 	}
 
-	ctx := rules.NewContext(unit)
-	issues := rule.Check(ctx)
-
-	if len(issues) != 0 {
-		t.Errorf("Best practice rules should not apply to non-service units, got %d issues", len(issues))
+	if rules.AppliesTo(rule, unit) {
+		t.Errorf("%s should not apply to %s units", rule.ID(), unit.Type)
+	}
+	if !rules.AppliesTo(rule, &types.UnitFile{Name: "test.service", Type: "service"}) {
+		t.Errorf("%s should apply to service units", rule.ID())
+	}
+	for _, issue := range rules.RunAll(rules.NewContext(unit)) {
+		if issue.RuleID == rule.ID() {
+			t.Errorf("the runner should skip %s on %s units", rule.ID(), unit.Type)
+		}
 	}
 }
@@ -0,0 +1,58 @@
+package rules
+
+// Capability describes what a rule needs beyond the directives of the unit it checks
+type Capability uint8
+
+const (
+	// CapabilityCrossUnit reads other units through Context.AllUnits
+	CapabilityCrossUnit Capability = 1 << iota
+	// CapabilityGraph runs dependency graph analysis
+	CapabilityGraph
+	// CapabilityFilesystem stats files or looks up users and groups
+	CapabilityFilesystem
+	// CapabilityRuntime queries the running system
+	CapabilityRuntime
+	// CapabilityExternal runs programs outside sdaudit, such as plugins
+	CapabilityExternal
+)
+
+// CapabilityStatic marks pure directive inspection of a single unit
+const CapabilityStatic Capability = 0
+
+// CapabilityAll is the set of all capabilities
+const CapabilityAll = CapabilityCrossUnit | CapabilityGraph | CapabilityFilesystem | CapabilityRuntime | CapabilityExternal
+
+var capabilityNames = []struct {
+	cap  Capability
+	name string
+}{
+	{CapabilityCrossUnit, "cross-unit"},
+	{CapabilityGraph, "graph"},
+	{CapabilityFilesystem, "filesystem"},
+	{CapabilityRuntime, "runtime"},
+	{CapabilityExternal, "external"},
+}
+
+// Names returns the names of the capabilities in the set
+func (c Capability) Names() []string {
+	var names []string
+	for _, cn := range capabilityNames {
+		if c&cn.cap != 0 {
+			names = append(names, cn.name)
+		}
+	}
+	return names
+}
+
+// CapableRule is implemented by rules that need more than the unit's own directives
+type CapableRule interface {
+	Capabilities() Capability
+}
+
+// Capabilities returns what a rule needs to run, CapabilityStatic if it does not say
+func Capabilities(rule Rule) Capability {
+	if c, ok := rule.(CapableRule); ok {
+		return c.Capabilities()
+	}
+	return CapabilityStatic
+}
@@ -1,15 +1,47 @@
 package rules
 
 import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/internal/unitname"
+	"github.com/supabase/sdaudit/internal/validation"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
-// Context provides the execution context for rules
+// Context provides the execution context for rules.
+//
+// Unit, AllUnits and Config are always set; Unit is nil for host rules. The
+// other fields are filled in by the analyzer when the scan has them and are
+// nil otherwise, such as Graph with --no-graph or FileSystem for a unit read
+// from stdin. A rule that needs one declares the capability with
+// Capabilities, so that it is skipped when the capability is unavailable,
+// and still returns no issues rather than panicking when the field is nil.
 type Context struct {
 	Unit       *types.UnitFile
 	AllUnits   map[string]*types.UnitFile
 	SystemInfo *SystemInfo
 	Config     *Config
+	// SystemConfig holds the manager defaults from system.conf, nil if unknown
+	SystemConfig *timing.SystemConfig
+	// Graph is the dependency graph of AllUnits, nil when cross-unit analysis is not run
+	Graph *graph.Graph
+	// FileSystem looks up paths, users and groups on the target, nil when unavailable
+	FileSystem validation.FileSystem
+	// Root is the directory of the offline image scanned with --root, empty
+	// for the live system. Unit file paths include it, FileSystem paths do not
+	Root string
+	// Timeouts holds the parsed timeouts of AllUnits by unit name, nil when
+	// the analyzer has not computed them; rules read them through UnitTimeouts
+	Timeouts map[string]timing.TimeoutConfig
+	// cascades holds the timeout cascade risks of Graph once
+	// TimeoutCascades has computed them
+	cascades *timing.CascadeResult
+	// Unavailable lists capabilities that may not be used; rules needing any of them are skipped
+	Unavailable Capability
 }
 
 // SystemInfo contains information about the target system
@@ -24,14 +56,14 @@ type SystemInfo struct {
 type Config struct {
 	DisabledRules     map[string]bool
 	SeverityOverrides map[string]types.Severity
-	Thresholds        Thresholds
-}
-
-// Thresholds contains configurable threshold values for rules
-type Thresholds struct {
-	SecurityScoreMax     float64
-	BootCriticalChainMax float64
-	RestartSecMin        float64
+	// StatefulServicePatterns are glob patterns for database and broker service names, without the .service suffix
+	StatefulServicePatterns []string
+	// SynchronizationUnits are units, besides graph.DefaultSynchronizationUnits,
+	// that REL005 does not expect a requirement on with After=
+	SynchronizationUnits []string
+	// Params are the rule parameters the configuration sets, by rule ID and
+	// parameter name; rules read them with IntParam and DurationParam
+	Params map[string]map[string]string
 }
 
 // DefaultConfig returns a Config with default values
@@ -39,11 +71,6 @@ func DefaultConfig() *Config {
 	return &Config{
 		DisabledRules:     make(map[string]bool),
 		SeverityOverrides: make(map[string]types.Severity),
-		Thresholds: Thresholds{
-			SecurityScoreMax:     5.0,
-			BootCriticalChainMax: 30.0,
-			RestartSecMin:        1.0,
-		},
 	}
 }
 
@@ -65,6 +92,77 @@ func NewContextWithUnits(unit *types.UnitFile, allUnits map[string]*types.UnitFi
 	}
 }
 
+// SystemdVersion returns the major systemd version of the target, or 0 if unknown
+func (c *Context) SystemdVersion() int {
+	if c.SystemInfo == nil {
+		return 0
+	}
+	return ParseSystemdVersion(c.SystemInfo.SystemdVersion)
+}
+
+// UnitTimeouts returns the timeouts of AllUnits, with the manager defaults
+// from SystemConfig, parsing them once when the analyzer did not
+func (c *Context) UnitTimeouts() map[string]timing.TimeoutConfig {
+	if c.Timeouts == nil {
+		c.Timeouts = timing.ParseAllTimeouts(c.AllUnits, c.SystemConfig)
+	}
+	return c.Timeouts
+}
+
+// TimeoutCascades returns the timeout cascade risks of Graph, found from
+// the critical paths of UnitTimeouts, computing them once so that the rules
+// for each kind of risk share them. It returns nil when there is no graph.
+func (c *Context) TimeoutCascades() []timing.CascadeRisk {
+	if c.Graph == nil {
+		return nil
+	}
+	if c.cascades == nil {
+		timeouts := c.UnitTimeouts()
+		cascades := timing.DetectCascades(c.Graph, timing.ComputeCriticalPaths(c.Graph, timeouts), timeouts)
+		c.cascades = &cascades
+	}
+	return c.cascades.Risks
+}
+
+// TargetPath returns where the unit or drop-in file read from path is on the
+// target, the path below Root for an offline image, for looking it up with
+// FileSystem. It returns false for units from stdin, which have no file on
+// the target, and for paths outside Root or relative to the working
+// directory on the live system.
+func (c *Context) TargetPath(path string) (string, bool) {
+	if path == types.StdinPath {
+		return "", false
+	}
+	if c.Root == "" {
+		if !filepath.IsAbs(path) {
+			return "", false
+		}
+		return filepath.Clean(path), true
+	}
+	rel, err := filepath.Rel(c.Root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return filepath.Join("/", rel), true
+}
+
+// NewHostContext creates a Context for host-wide checks that are not tied to a unit
+func NewHostContext(allUnits map[string]*types.UnitFile) *Context {
+	return &Context{
+		AllUnits: allUnits,
+		Config:   DefaultConfig(),
+	}
+}
+
+// CanRun reports whether a rule is enabled, supported by the target systemd and
+// only needs available capabilities
+func (c *Context) CanRun(rule Rule) bool {
+	if c.IsRuleDisabled(rule.ID()) || !Supported(rule, c.SystemdVersion()) {
+		return false
+	}
+	return Capabilities(rule)&c.Unavailable == 0
+}
+
 // IsRuleDisabled checks if a rule is disabled
 func (c *Context) IsRuleDisabled(ruleID string) bool {
 	if c.Config == nil {
@@ -81,3 +179,105 @@ func (c *Context) GetSeverityOverride(ruleID string) (types.Severity, bool) {
 	severity, ok := c.Config.SeverityOverrides[ruleID]
 	return severity, ok
 }
+
+// SocketsFor returns the socket units in AllUnits that activate the given service
+func (c *Context) SocketsFor(service *types.UnitFile) []*types.UnitFile {
+	if service == nil || !service.IsService() {
+		return nil
+	}
+
+	var sockets []*types.UnitFile
+	for _, unit := range c.AllUnits {
+		if unit == nil || !unit.IsSocket() {
+			continue
+		}
+		if SocketServiceName(unit) == service.Name {
+			sockets = append(sockets, unit)
+		}
+	}
+
+	sort.Slice(sockets, func(i, j int) bool {
+		return sockets[i].Name < sockets[j].Name
+	})
+
+	return sockets
+}
+
+// TimersFor returns the timer units in AllUnits that trigger the given unit
+func (c *Context) TimersFor(service *types.UnitFile) []*types.UnitFile {
+	if service == nil {
+		return nil
+	}
+
+	var timers []*types.UnitFile
+	for _, unit := range c.AllUnits {
+		if unit == nil || !unit.IsTimer() {
+			continue
+		}
+		if TimerServiceName(unit) == service.Name {
+			timers = append(timers, unit)
+		}
+	}
+
+	sort.Slice(timers, func(i, j int) bool {
+		return timers[i].Name < timers[j].Name
+	})
+
+	return timers
+}
+
+// HasUnit reports whether AllUnits has a unit of the name, or the template of
+// an instance such as getty@tty1.service. Names with specifiers count as
+// present, since they are not known before systemd expands them.
+func (c *Context) HasUnit(name string) bool {
+	if _, ok := c.FindUnit(name); ok || strings.Contains(name, "%") {
+		return true
+	}
+	if at := strings.Index(name, "@"); at >= 0 {
+		if dot := strings.LastIndex(name, "."); dot > at {
+			_, ok := c.FindUnit(name[:at+1] + name[dot:])
+			return ok
+		}
+	}
+	return false
+}
+
+// FindUnit returns the unit of AllUnits a reference names, such as the value
+// of Requires=, whichever way the escapes in the name are spelled
+func (c *Context) FindUnit(name string) (*types.UnitFile, bool) {
+	unit, ok := c.AllUnits[unitname.Normalize(name)]
+	return unit, ok
+}
+
+// DirectiveLine returns the line of the last assignment of a directive,
+// which is the one systemd uses, or 0 if the unit does not set it
+func DirectiveLine(unit *types.UnitFile, section, key string) int {
+	if unit == nil {
+		return 0
+	}
+	directives := unit.GetDirectives(section, key)
+	if len(directives) == 0 {
+		return 0
+	}
+	return directives[len(directives)-1].Line
+}
+
+// TimerServiceName returns the name of the unit a timer unit triggers
+func TimerServiceName(timer *types.UnitFile) string {
+	if unit := timer.GetDirective("Timer", "Unit"); unit != "" {
+		return unit
+	}
+	return strings.TrimSuffix(timer.Name, ".timer") + ".service"
+}
+
+// SocketServiceName returns the name of the service a socket unit activates
+func SocketServiceName(socket *types.UnitFile) string {
+	if svc := socket.GetDirective("Socket", "Service"); svc != "" {
+		return svc
+	}
+	base := strings.TrimSuffix(socket.Name, ".socket")
+	if accept := socket.GetDirective("Socket", "Accept"); accept == "yes" || accept == "true" {
+		return base + "@.service"
+	}
+	return base + ".service"
+}
@@ -0,0 +1,131 @@
+package crossunit
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "PERF009",
+			RuleName:        "Early unit pulls in a slow target",
+			RuleDescription: "A unit installed into sysinit.target or another target reached before basic.target starts in early boot. When it pulls in network-online.target, remote-fs.target or time-sync.target, early boot waits for the network, remote file systems or the clock to be synchronized, and so does every unit after basic.target.",
+			RuleCategory:    types.CategoryPerformance,
+			RuleSeverity:    types.SeverityMedium,
+			RuleTags:        []string{"boot", "dependencies", "network"},
+			RuleSuggestion:  "Install the unit into multi-user.target instead, or drop the dependency on the slow target if the unit does not need it in early boot.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.special", "network-online.target"), types.ManPage("systemd.unit", "WantedBy=")},
+		check: checkEarlySlowTargets,
+	})
+}
+
+// earlyTargets are the targets reached before basic.target: it pulls in and
+// starts after sysinit.target and the targets listed with it, and
+// sysinit.target after the file system, swap and encrypted volume targets
+var earlyTargets = []string{
+	"basic.target", "sysinit.target",
+	"sockets.target", "paths.target", "timers.target", "slices.target",
+	"local-fs-pre.target", "local-fs.target", "swap.target", "cryptsetup.target",
+}
+
+// slowTargets are the targets that wait for something outside the host
+var slowTargets = []string{"network-online.target", "remote-fs.target", "time-sync.target"}
+
+// installedEarly returns the edge of the [Install] section of a unit that
+// places it into an early target, or false when there is none
+func installedEarly(g *graph.Graph, unit *types.UnitFile) (graph.Edge, bool) {
+	var edges []graph.Edge
+	for _, e := range g.EdgesTo(unit.Name) {
+		declared := e.File == unit.Path || slices.Contains(unit.DropIns, e.File)
+		if declared && !e.Implicit && (e.Type == graph.EdgeWants || e.Type == graph.EdgeRequires) && slices.Contains(earlyTargets, g.Resolve(e.From)) {
+			edges = append(edges, e)
+		}
+	}
+	if len(edges) == 0 {
+		return graph.Edge{}, false
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].File != edges[j].File {
+			return edges[i].File < edges[j].File
+		}
+		return edges[i].Line < edges[j].Line
+	})
+	return edges[0], true
+}
+
+// describePullIn describes a chain of pull-in edges as the directives that
+// make it up, such as "Wants=helper.service, then Requires=remote-fs.target
+// of helper.service"
+func describePullIn(path []graph.Edge) string {
+	steps := make([]string, len(path))
+	for i, e := range path {
+		step := fmt.Sprintf("%s=%s", e.Type, e.To)
+		if e.Implicit {
+			step = "implicit " + step
+		}
+		if i > 0 {
+			step = fmt.Sprintf("%s of %s", step, e.From)
+		}
+		steps[i] = step
+	}
+	return strings.Join(steps, ", then ")
+}
+
+// checkEarlySlowTargets reports each unit installed into an early target and
+// each slow target it pulls in, at the directive that starts the chain. The
+// chain does not pass through the early targets, which every unit with
+// default dependencies requires.
+func checkEarlySlowTargets(r *crossRule, ctx *rules.Context) []types.Issue {
+	names := make([]string, 0, len(ctx.AllUnits))
+	for name, unit := range ctx.AllUnits {
+		if unit != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	early := func(unit string) bool { return slices.Contains(earlyTargets, unit) }
+	var issues []types.Issue
+	for _, name := range names {
+		unit := ctx.AllUnits[name]
+		install, ok := installedEarly(ctx.Graph, unit)
+		if !ok {
+			continue
+		}
+		for _, target := range slowTargets {
+			path := ctx.Graph.PullInPath(name, target, early)
+			if path == nil {
+				continue
+			}
+			description := fmt.Sprintf("%s is started before basic.target by %s=%s, but pulls in %s through %s, so early boot waits for it.",
+				name, installDirective(install.Type), install.From, target, describePullIn(path))
+			issue := r.newIssue(ctx, name, "", description, "")
+			issue.Value = target
+			first := path[0]
+			if first.File != "" && first.Line > 0 {
+				issue.File, issue.Line = first.File, lineRef(first.Line)
+			} else {
+				issue.File, issue.Line = install.File, lineRef(install.Line)
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// installDirective names the [Install] directive that creates an edge of the
+// given type into the unit
+func installDirective(t graph.EdgeType) string {
+	if t == graph.EdgeRequires {
+		return "RequiredBy"
+	}
+	return "WantedBy"
+}
@@ -0,0 +1,128 @@
+// Package crossunit holds the rules that analyze units together through the
+// dependency graph: missing and cyclic dependencies, restart deadlocks and
+// storms, timeout cascades, OnFailure= and OnSuccess= handlers, paths on
+// mounts the unit does not start after, units never started at boot, and
+// early units pulling in slow targets. They run once per scan as host rules
+// and find nothing when the scan did not build a graph.
+package crossunit
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// crossRule is a rule backed by one of the graph, propagation or timing
+// analyses. Several rules may share an analysis and keep one kind of finding each.
+type crossRule struct {
+	rules.BaseRule
+	refs  []types.Reference
+	check func(r *crossRule, ctx *rules.Context) []types.Issue
+}
+
+// register fills in the references of a rule from its typed references and
+// adds it to the registry
+func register(r *crossRule) {
+	for _, ref := range r.refs {
+		r.RuleReferences = append(r.RuleReferences, ref.URL)
+	}
+	rules.Register(r)
+}
+
+func (r *crossRule) Capabilities() rules.Capability     { return rules.CapabilityGraph }
+func (r *crossRule) TypedReferences() []types.Reference { return r.refs }
+
+func (r *crossRule) Check(ctx *rules.Context) []types.Issue {
+	// Host-wide only, see CheckHost
+	return nil
+}
+
+func (r *crossRule) CheckHost(ctx *rules.Context) []types.Issue {
+	if ctx.Graph == nil {
+		return nil
+	}
+	return r.check(r, ctx)
+}
+
+// newIssue creates an issue of the rule on a unit, in the unit's file when it
+// has one. An empty severity or suggestion keeps the rule's.
+func (r *crossRule) newIssue(ctx *rules.Context, unitName, severity, description, suggestion string) types.Issue {
+	issue := types.Issue{
+		RuleID:      r.RuleID,
+		RuleName:    r.RuleName,
+		Severity:    r.RuleSeverity,
+		Category:    r.RuleCategory,
+		Tags:        r.RuleTags,
+		Unit:        unitName,
+		Description: description,
+		Suggestion:  r.RuleSuggestion,
+		References:  r.RuleReferences,
+	}
+	if severity != "" {
+		issue.Severity = types.ParseSeverity(severity)
+	}
+	if suggestion != "" {
+		issue.Suggestion = suggestion
+	}
+	if unit := ctx.AllUnits[unitName]; unit != nil {
+		issue.File = unit.Path
+	}
+	return issue
+}
+
+// onEdge sets the directive of an issue to the [Unit] dependency it is about
+func onEdge(issue *types.Issue, edgeType graph.EdgeType, to string) {
+	issue.Section, issue.Directive, issue.Value = "Unit", edgeType.String(), to
+}
+
+// lineRef returns a pointer to a line number, nil if the line is unknown
+func lineRef(line int) *int {
+	if line <= 0 {
+		return nil
+	}
+	return &line
+}
+
+// edgeLine returns the line of the directive that created an edge, nil if unknown
+func edgeLine(g *graph.Graph, from, to string, edgeType graph.EdgeType) *int {
+	for _, e := range g.EdgesFrom(from) {
+		if e.To == to && e.Type == edgeType && e.Line > 0 {
+			return lineRef(e.Line)
+		}
+	}
+	return nil
+}
+
+// hasEdge reports whether the graph has an edge of the given type between two units
+func hasEdge(g *graph.Graph, from, to string, edgeType graph.EdgeType) bool {
+	for _, e := range g.EdgesFrom(from) {
+		if e.To == to && e.Type == edgeType {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutUnitFile reports whether a unit usually exists without a unit file of
+// its own: devices, scopes, mounts and swaps that systemd or fstab generators
+// create, instances of a template the scan found, and names with specifiers.
+func withoutUnitFile(units map[string]*types.UnitFile, name string) bool {
+	if strings.Contains(name, "%") {
+		return true
+	}
+	for _, suffix := range []string{".device", ".scope", ".mount", ".automount", ".swap"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	if at := strings.Index(name, "@"); at >= 0 {
+		if dot := strings.LastIndex(name, "."); dot > at {
+			if _, ok := units[name[:at+1]+name[dot:]]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,497 @@
+package crossunit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func parseUnits(t *testing.T, contents map[string]string) map[string]*types.UnitFile {
+	t.Helper()
+	units := make(map[string]*types.UnitFile)
+	for name, content := range contents {
+		unit, err := unitfile.ParseContent(filepath.Join("/etc/systemd/system", name), content)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", name, err)
+		}
+		units[name] = unit
+	}
+	return units
+}
+
+func loadUnits(t *testing.T, dir string) map[string]*types.UnitFile {
+	t.Helper()
+	units, err := unitfile.LoadDirectory(filepath.Join("../../../testdata", dir))
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", dir, err)
+	}
+	return units
+}
+
+func checkHost(t *testing.T, id string, units map[string]*types.UnitFile) []types.Issue {
+	t.Helper()
+	rule := rules.Get(id)
+	if rule == nil {
+		t.Fatalf("rule %s is not registered", id)
+	}
+	ctx := rules.NewHostContext(units)
+	ctx.Graph = graph.Build(units)
+	return rule.(rules.HostRule).CheckHost(ctx)
+}
+
+func TestCrossUnitRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		units    map[string]*types.UnitFile
+		wantUnit string
+		wantLine int
+		wantSev  types.Severity
+	}{
+		{
+			name: "missing bound unit",
+			rule: "GRAPH001",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nBindsTo=gone.service\nBindsTo=dev-sda.device\n\n[Service]\nExecStart=/usr/bin/app\n",
+			}),
+			wantUnit: "app.service", wantLine: 2, wantSev: types.SeverityHigh,
+		},
+		{
+			name:     "ordering cycle",
+			rule:     "GRAPH002",
+			units:    loadUnits(t, "graph/ordering_cycle"),
+			wantUnit: "a.service", wantLine: 3, wantSev: types.SeverityHigh,
+		},
+		{
+			name: "unit ordered after and before another",
+			rule: "GRAPH007",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nAfter=db.service\nBefore=db.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"db.service":  "[Service]\nExecStart=/usr/bin/db\n",
+			}),
+			wantUnit: "app.service", wantLine: 2, wantSev: types.SeverityHigh,
+		},
+		{
+			name:     "requires without after",
+			rule:     "GRAPH003",
+			units:    loadUnits(t, "graph/requires_without_after"),
+			wantUnit: "app.service", wantLine: 3, wantSev: types.SeverityMedium,
+		},
+		{
+			name: "requirement and conflict",
+			rule: "GRAPH004",
+			units: parseUnits(t, map[string]string{
+				"app.service":   "[Unit]\nWants=cache.service\nConflicts=cache.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"cache.service": "[Service]\nExecStart=/usr/bin/cache\n",
+			}),
+			wantUnit: "app.service", wantLine: 3, wantSev: types.SeverityHigh,
+		},
+		{
+			name:     "mutual BindsTo restart storm",
+			rule:     "PROP001",
+			units:    loadUnits(t, "propagation/restart_storm"),
+			wantUnit: "a.service", wantLine: 3, wantSev: types.SeverityCritical,
+		},
+		{
+			name:     "bound unit requires its dependent",
+			rule:     "PROP004",
+			units:    loadUnits(t, "propagation/deadlock"),
+			wantUnit: "primary.service", wantLine: 3, wantSev: types.SeverityCritical,
+		},
+		{
+			name: "requisite on a missing unit",
+			rule: "PROP009",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nRequisite=db.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+			}),
+			wantUnit: "app.service", wantLine: 2, wantSev: types.SeverityCritical,
+		},
+		{
+			name: "PartOf= a target nothing wants",
+			rule: "PROP011",
+			units: parseUnits(t, map[string]string{
+				"app.target":  "[Unit]\nDescription=App\n",
+				"app.service": "[Unit]\nPartOf=app.target\n\n[Service]\nExecStart=/usr/bin/app\n",
+			}),
+			wantUnit: "app.service", wantLine: 2, wantSev: types.SeverityMedium,
+		},
+		{
+			name:     "reload propagated to a service without ExecReload",
+			rule:     "PROP012",
+			units:    loadUnits(t, "propagation/stack"),
+			wantUnit: "api.service", wantLine: 4, wantSev: types.SeverityMedium,
+		},
+		{
+			name:     "ReloadPropagatedFrom= missing from PropagatesReloadTo=",
+			rule:     "PROP013",
+			units:    loadUnits(t, "propagation/stack"),
+			wantUnit: "cache.service", wantLine: 4, wantSev: types.SeverityLow,
+		},
+		{
+			name: "critical service pulled in with Wants",
+			rule: "PROP010",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nWants=dbus.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+			}),
+			wantUnit: "app.service", wantLine: 2, wantSev: types.SeverityMedium,
+		},
+		{
+			name: "dependencies outlast JobTimeoutSec",
+			rule: "TIME001",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nAfter=db.service\nJobTimeoutSec=10s\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"db.service":  "[Service]\nExecStart=/usr/bin/db\n",
+			}),
+			wantUnit: "app.service", wantLine: 3, wantSev: types.SeverityCritical,
+		},
+		{
+			name: "short timeout after the network",
+			rule: "TIME002",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nAfter=network-online.target\n\n[Service]\nExecStart=/usr/bin/app\nTimeoutStartSec=5s\n",
+			}),
+			wantUnit: "app.service", wantLine: 6, wantSev: types.SeverityCritical,
+		},
+		{
+			name: "unit nothing starts",
+			rule: "GRAPH005",
+			units: parseUnits(t, map[string]string{
+				"graphical.target": "[Unit]\nDescription=Graphical Interface\n",
+				"orphan.service":   "[Service]\nExecStart=/usr/bin/orphan\n",
+			}),
+			wantUnit: "orphan.service", wantSev: types.SeverityInfo,
+		},
+		{
+			name: "unit wanted by a target that is never reached",
+			rule: "GRAPH006",
+			units: parseUnits(t, map[string]string{
+				"graphical.target": "[Unit]\nDescription=Graphical Interface\n",
+				"admin.target":     "[Unit]\nDescription=Administration\n",
+				"tool.service":     "[Service]\nExecStart=/usr/bin/tool\n\n[Install]\nWantedBy=admin.target\n",
+			}),
+			wantUnit: "tool.service", wantLine: 5, wantSev: types.SeverityLow,
+		},
+		{
+			name: "failure handler template that does not exist",
+			rule: "GRAPH008",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nOnFailure=alert@%n.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+			}),
+			wantUnit: "app.service", wantLine: 2, wantSev: types.SeverityMedium,
+		},
+		{
+			name: "failure handler template requiring its instance",
+			rule: "GRAPH009",
+			units: parseUnits(t, map[string]string{
+				"app.service":    "[Unit]\nOnFailure=alert@%n.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"alert@.service": "[Unit]\nRequires=%i\n\n[Service]\nType=oneshot\nExecStart=/usr/bin/alert %i\n",
+			}),
+			wantUnit: "app.service", wantLine: 2, wantSev: types.SeverityHigh,
+		},
+		{
+			name:     "failure handler requiring a target that requires the unit",
+			rule:     "GRAPH009",
+			units:    loadUnits(t, "graph/handlers"),
+			wantUnit: "app.service", wantLine: 3, wantSev: types.SeverityHigh,
+		},
+		{
+			name: "writable path on a network mount",
+			rule: "GRAPH011",
+			units: parseUnits(t, map[string]string{
+				"srv-data.mount": "[Mount]\nWhat=storage:/export\nWhere=/srv/data\nType=nfs\n",
+				"app.service":    "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\nReadWritePaths=/srv/data/app\n",
+			}),
+			wantUnit: "app.service", wantLine: 6, wantSev: types.SeverityMedium,
+		},
+		{
+			name: "binary on a local mount without default dependencies",
+			rule: "GRAPH011",
+			units: parseUnits(t, map[string]string{
+				"opt.mount":   "[Mount]\nWhat=/dev/sdb1\nWhere=/opt\nType=ext4\n",
+				"app.service": "[Unit]\nDefaultDependencies=no\n\n[Service]\nExecStart=/opt/app/bin/app\n",
+			}),
+			wantUnit: "app.service", wantLine: 5, wantSev: types.SeverityMedium,
+		},
+		{
+			name: "early unit waiting for the network",
+			rule: "PERF009",
+			units: parseUnits(t, map[string]string{
+				"early.service": "[Unit]\nDefaultDependencies=no\nWants=network-online.target\nAfter=network-online.target\n\n[Service]\nExecStart=/usr/bin/early\n\n[Install]\nWantedBy=sysinit.target\n",
+			}),
+			wantUnit: "early.service", wantLine: 3, wantSev: types.SeverityMedium,
+		},
+		{
+			// quiet.service reaches network-online.target only through
+			// sysinit.target, which its default dependencies require
+			name: "early unit needing a network mount",
+			rule: "PERF009",
+			units: parseUnits(t, map[string]string{
+				"srv-data.mount": "[Mount]\nWhat=storage:/export\nWhere=/srv/data\nType=nfs\n",
+				"app.service":    "[Unit]\nDefaultDependencies=no\nRequiresMountsFor=/srv/data\n\n[Service]\nExecStart=/usr/bin/app\n\n[Install]\nRequiredBy=local-fs.target\n",
+				"quiet.service":  "[Service]\nExecStart=/usr/bin/quiet\n\n[Install]\nWantedBy=sysinit.target\n",
+			}),
+			wantUnit: "app.service", wantLine: 3, wantSev: types.SeverityMedium,
+		},
+		{
+			name: "failure handler that keeps running",
+			rule: "GRAPH010",
+			units: parseUnits(t, map[string]string{
+				"app.service":   "[Unit]\nOnFailure=alert.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"alert.service": "[Service]\nExecStart=/usr/bin/alert\n",
+			}),
+			wantUnit: "alert.service", wantSev: types.SeverityMedium,
+		},
+		{
+			// The last Type= is the one systemd uses
+			name: "failure handler with Type= assigned twice",
+			rule: "GRAPH010",
+			units: parseUnits(t, map[string]string{
+				"app.service":   "[Unit]\nOnFailure=alert.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"alert.service": "[Service]\nType=oneshot\nType=exec\nExecStart=/usr/bin/alert\n",
+			}),
+			wantUnit: "alert.service", wantLine: 3, wantSev: types.SeverityMedium,
+		},
+		{
+			name: "failure handler that remains after exit",
+			rule: "GRAPH010",
+			units: parseUnits(t, map[string]string{
+				"app.service":    "[Unit]\nOnFailure=alert@%n.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"alert@.service": "[Service]\nType=oneshot\nRemainAfterExit=yes\nExecStart=/usr/bin/alert %i\n",
+			}),
+			wantUnit: "alert@.service", wantLine: 3, wantSev: types.SeverityMedium,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := checkHost(t, tt.rule, tt.units)
+			if len(issues) != 1 {
+				t.Fatalf("%s found %d issues, want 1: %+v", tt.rule, len(issues), issues)
+			}
+			issue := issues[0]
+			if issue.RuleID != tt.rule || issue.Unit != tt.wantUnit || issue.Severity != tt.wantSev {
+				t.Errorf("issue = %s on %s (%s), want %s on %s (%s)", issue.RuleID, issue.Unit, issue.Severity, tt.rule, tt.wantUnit, tt.wantSev)
+			}
+			if filepath.Base(issue.File) != tt.wantUnit {
+				t.Errorf("File = %q, want the file of %s", issue.File, tt.wantUnit)
+			}
+			switch {
+			case tt.wantLine == 0 && issue.Line != nil:
+				t.Errorf("Line = %d, want none", *issue.Line)
+			case tt.wantLine != 0 && (issue.Line == nil || *issue.Line != tt.wantLine):
+				t.Errorf("Line = %v, want %d", issue.Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestCrossUnitRulesSkipped(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		units map[string]*types.UnitFile
+	}{
+		{
+			name: "missing service left to REL009",
+			rule: "GRAPH001",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nRequires=gone.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+			}),
+		},
+		{
+			name:  "requirement cycle without ordering",
+			rule:  "GRAPH002",
+			units: loadUnits(t, "graph/cycle_simple"),
+		},
+		{
+			name: "two-unit ordering cycle left to GRAPH007",
+			rule: "GRAPH002",
+			units: parseUnits(t, map[string]string{
+				"a.service": "[Unit]\nAfter=b.service\n\n[Service]\nExecStart=/usr/bin/a\n",
+				"b.service": "[Unit]\nAfter=a.service\n\n[Service]\nExecStart=/usr/bin/b\n",
+			}),
+		},
+		{
+			name: "same ordering declared by both units",
+			rule: "GRAPH007",
+			units: parseUnits(t, map[string]string{
+				"a.service": "[Unit]\nAfter=b.service\n\n[Service]\nExecStart=/usr/bin/a\n",
+				"b.service": "[Unit]\nBefore=a.service\n\n[Service]\nExecStart=/usr/bin/b\n",
+			}),
+		},
+		{
+			name: "ordering declared by the dependency",
+			rule: "GRAPH003",
+			units: parseUnits(t, map[string]string{
+				"app.service":   "[Unit]\nRequires=cache.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"cache.service": "[Unit]\nBefore=app.service\n\n[Service]\nExecStart=/usr/bin/cache\n",
+			}),
+		},
+		{
+			name: "socket that activates the service",
+			rule: "GRAPH003",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nRequires=app.socket\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"app.socket":  "[Socket]\nListenStream=/run/app.sock\n",
+			}),
+		},
+		{
+			name: "path on a mount with RequiresMountsFor=",
+			rule: "GRAPH011",
+			units: parseUnits(t, map[string]string{
+				"srv-data.mount": "[Mount]\nWhat=storage:/export\nWhere=/srv/data\nType=nfs\n",
+				"app.service":    "[Unit]\nRequiresMountsFor=/srv/data\n\n[Service]\nExecStart=/usr/bin/app\nEnvironmentFile=/srv/data/app.env\n",
+			}),
+		},
+		{
+			name: "working directory, which systemd mounts implicitly",
+			rule: "GRAPH011",
+			units: parseUnits(t, map[string]string{
+				"srv-data.mount": "[Mount]\nWhat=storage:/export\nWhere=/srv/data\nType=nfs\n",
+				"app.service":    "[Service]\nExecStart=/usr/bin/app\nWorkingDirectory=/srv/data\n",
+			}),
+		},
+		{
+			name: "local mount before sysinit.target",
+			rule: "GRAPH011",
+			units: parseUnits(t, map[string]string{
+				"opt.mount":   "[Mount]\nWhat=/dev/sdb1\nWhere=/opt\nType=ext4\n",
+				"app.service": "[Service]\nExecStart=/opt/app/bin/app\n",
+			}),
+		},
+		{
+			name: "unit waiting for the network after boot",
+			rule: "PERF009",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nWants=network-online.target\nAfter=network-online.target\n\n[Service]\nExecStart=/usr/bin/app\n\n[Install]\nWantedBy=multi-user.target\n",
+			}),
+		},
+		{
+			name: "early unit ordered after the network without pulling it in",
+			rule: "PERF009",
+			units: parseUnits(t, map[string]string{
+				"early.service": "[Unit]\nDefaultDependencies=no\nAfter=network-online.target time-sync.target\n\n[Service]\nExecStart=/usr/bin/early\n\n[Install]\nWantedBy=sysinit.target\n",
+			}),
+		},
+		{
+			name: "Wants on a critical service from a target",
+			rule: "PROP010",
+			units: parseUnits(t, map[string]string{
+				"app.target": "[Unit]\nWants=dbus.service\n",
+			}),
+		},
+		{
+			name: "Wants on network.target",
+			rule: "PROP010",
+			units: parseUnits(t, map[string]string{
+				"app.service": "[Unit]\nWants=network.target\n\n[Service]\nExecStart=/usr/bin/app\n",
+			}),
+		},
+		{
+			name: "unit shipped in /usr/lib that nothing starts",
+			rule: "GRAPH005",
+			units: func() map[string]*types.UnitFile {
+				units := parseUnits(t, map[string]string{"graphical.target": "[Unit]\n"})
+				unit, err := unitfile.ParseContent("/usr/lib/systemd/system/quotaon.service", "[Service]\nExecStart=/usr/sbin/quotaon\n")
+				if err != nil {
+					t.Fatal(err)
+				}
+				units[unit.Name] = unit
+				return units
+			}(),
+		},
+		{
+			name: "no default target",
+			rule: "GRAPH005",
+			units: parseUnits(t, map[string]string{
+				"orphan.service": "[Service]\nExecStart=/usr/bin/orphan\n",
+			}),
+		},
+		{
+			name: "PartOf= a target wanted by multi-user.target",
+			rule: "PROP011",
+			units: parseUnits(t, map[string]string{
+				"app.target":  "[Unit]\nDescription=App\n\n[Install]\nWantedBy=multi-user.target\n",
+				"app.service": "[Unit]\nPartOf=app.target\n\n[Service]\nExecStart=/usr/bin/app\n",
+			}),
+		},
+		{
+			name: "reload propagated to a Type=notify-reload service",
+			rule: "PROP012",
+			units: parseUnits(t, map[string]string{
+				"app.service":    "[Unit]\nPropagatesReloadTo=worker.service\n\n[Service]\nExecStart=/usr/bin/app\nExecReload=/bin/true\n",
+				"worker.service": "[Service]\nType=notify-reload\nExecStart=/usr/bin/worker\n",
+			}),
+		},
+		{
+			name: "failure handler instance of a template",
+			rule: "GRAPH008",
+			units: parseUnits(t, map[string]string{
+				"app.service":    "[Unit]\nOnFailure=alert@%n.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"alert@.service": "[Service]\nType=oneshot\nExecStart=/usr/bin/alert %i\n",
+			}),
+		},
+		{
+			name: "failure handler that only wants the unit",
+			rule: "GRAPH009",
+			units: parseUnits(t, map[string]string{
+				"app.service":   "[Unit]\nOnFailure=alert.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"alert.service": "[Unit]\nWants=app.service\n\n[Service]\nType=oneshot\nExecStart=/usr/bin/alert\n",
+			}),
+		},
+		{
+			name: "oneshot failure handler",
+			rule: "GRAPH010",
+			units: parseUnits(t, map[string]string{
+				"app.service":   "[Unit]\nOnFailure=alert.service\n\n[Service]\nExecStart=/usr/bin/app\n",
+				"alert.service": "[Service]\nType=oneshot\nExecStart=/usr/bin/alert\n",
+			}),
+		},
+		{
+			name: "WantedBy on a critical service",
+			rule: "PROP010",
+			units: parseUnits(t, map[string]string{
+				"dbus.service": "[Service]\nExecStart=/usr/bin/dbus-daemon\n\n[Install]\nWantedBy=app.service\n",
+				"app.service":  "[Service]\nExecStart=/usr/bin/app\n",
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if issues := checkHost(t, tt.rule, tt.units); len(issues) != 0 {
+				t.Errorf("%s found %+v, want nothing", tt.rule, issues)
+			}
+		})
+	}
+
+	// Without a graph the rules find nothing
+	ctx := rules.NewHostContext(loadUnits(t, "propagation/restart_storm"))
+	if issues := rules.Get("PROP001").(rules.HostRule).CheckHost(ctx); len(issues) != 0 {
+		t.Errorf("PROP001 without a graph found %+v", issues)
+	}
+}
+
+func TestCascadeRulesShareAnalysis(t *testing.T) {
+	units := parseUnits(t, map[string]string{
+		"app.service": "[Unit]\nAfter=db.service\nJobTimeoutSec=10s\n\n[Service]\nExecStart=/usr/bin/app\n",
+		"db.service":  "[Service]\nExecStart=/usr/bin/db\n",
+		"web.service": "[Unit]\nAfter=network-online.target\n\n[Service]\nExecStart=/usr/bin/web\nTimeoutStartSec=5s\n",
+	})
+	ctx := rules.NewHostContext(units)
+	ctx.Graph = graph.Build(units)
+
+	risks := ctx.TimeoutCascades()
+	for _, id := range []string{"TIME001", "TIME002"} {
+		if issues := rules.Get(id).(rules.HostRule).CheckHost(ctx); len(issues) != 1 {
+			t.Errorf("%s reported %d issues, want 1: %+v", id, len(issues), issues)
+		}
+	}
+	if again := ctx.TimeoutCascades(); len(risks) == 0 || &again[0] != &risks[0] {
+		t.Errorf("TimeoutCascades() computed the risks again, want them kept on the context")
+	}
+}
@@ -0,0 +1,197 @@
+package crossunit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Requires= on a missing service, After= without Requires= and BindsTo=
+// without After= are left to REL009, REL005 and REL010, which report them from
+// the unit's own directives.
+
+func init() {
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH001",
+			RuleName:        "Requirement on a missing unit",
+			RuleDescription: "A unit bound to or requiring a unit that does not exist fails to start.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityHigh,
+			RuleTags:        []string{"dependencies", "missing-unit"},
+			RuleSuggestion:  "Install the missing unit, fix the unit name, or use Wants= if the dependency is optional.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "Requires=")},
+		check: checkMissingRequirements,
+	})
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH002",
+			RuleName:        "Ordering cycle",
+			RuleDescription: "systemd breaks an After=/Before= cycle at boot by dropping the start job of one of its units, which then does not start.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityHigh,
+			RuleTags:        []string{"dependencies", "ordering", "boot"},
+			RuleSuggestion:  "Remove one of the After= or Before= directives in the cycle.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "Before=")},
+		check: checkOrderingCycles,
+	})
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH003",
+			RuleName:        "Requires= without After=",
+			RuleDescription: "Requires= alone starts both units in parallel, so the unit may start before its dependency is ready.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityMedium,
+			RuleTags:        []string{"dependencies", "ordering"},
+			RuleSuggestion:  "Add After= for the required unit unless the units are meant to start in parallel.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "Requires=")},
+		check: checkRequiresWithoutAfter,
+	})
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH004",
+			RuleName:        "Requirement contradicts Conflicts=",
+			RuleDescription: "A unit that both pulls in and conflicts with another unit cannot run together with it.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityHigh,
+			RuleTags:        []string{"dependencies", "conflicts"},
+			RuleSuggestion:  "Remove either the requirement or Conflicts=.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "Conflicts=")},
+		check: checkConflictingDependencies,
+	})
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH007",
+			RuleName:        "Contradictory ordering",
+			RuleDescription: "Two units ordered both after and before each other form an ordering cycle, which systemd breaks at boot by dropping the start job of one of them.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityHigh,
+			RuleTags:        []string{"dependencies", "ordering", "boot"},
+			RuleSuggestion:  "Keep only the After= or Before= directive for the order the units need.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "Before=")},
+		check: checkOrderingContradictions,
+	})
+}
+
+// checkMissingRequirements reports BindsTo= and Requires= on units that were
+// not found. Requisite= is reported by PROP009 and missing Wants= are harmless.
+func checkMissingRequirements(r *crossRule, ctx *rules.Context) []types.Issue {
+	var issues []types.Issue
+	for _, d := range ctx.Graph.FindDanglingRefs() {
+		switch {
+		case d.EdgeType == graph.EdgeBindsTo:
+		case d.EdgeType == graph.EdgeRequires && unitfile.UnitType(d.To) != "service":
+		default:
+			continue
+		}
+		if withoutUnitFile(ctx.AllUnits, d.To) {
+			continue
+		}
+		issue := r.newIssue(ctx, d.From, "", fmt.Sprintf("%s has %s=%s but no such unit exists.", d.From, d.EdgeType, d.To), "")
+		onEdge(&issue, d.EdgeType, d.To)
+		if d.File != "" {
+			issue.File = d.File
+		}
+		issue.Line = lineRef(d.Line)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkOrderingCycles reports each cycle on the unit declaring the edge that
+// is suggested for removal, listing the directives along its shortest path.
+// Cycles of two units are left to GRAPH007.
+func checkOrderingCycles(r *crossRule, ctx *rules.Context) []types.Issue {
+	var issues []types.Issue
+	for _, cycle := range ctx.Graph.FindOrderingCycles() {
+		if len(cycle.Units) == 2 {
+			continue
+		}
+		var steps []string
+		for _, step := range cycle.Path {
+			for _, e := range step.Edges {
+				steps = append(steps, fmt.Sprintf("%s %s=%s", e.From, e.Type, e.To))
+			}
+		}
+		description := fmt.Sprintf("Ordering cycle %s: %s.", cycle.CycleDescription(), strings.Join(steps, ", "))
+
+		unit := cycle.Units[0]
+		step, ok := cycle.BreakStep()
+		if ok {
+			unit = step.Edges[0].From
+		}
+		issue := r.newIssue(ctx, unit, "", description, cycle.BreakSuggestion()+".")
+		if ok {
+			onEdge(&issue, step.Edges[0].Type, step.Edges[0].To)
+			issue.Line = lineRef(step.Edges[0].Line)
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkRequiresWithoutAfter skips targets, which systemd orders after the
+// units they require. Dependencies that order themselves with Before=, and
+// sockets, which systemd orders before their service, are left out by
+// FindOrderingIssues.
+func checkRequiresWithoutAfter(r *crossRule, ctx *rules.Context) []types.Issue {
+	var issues []types.Issue
+	for _, o := range ctx.Graph.FindOrderingIssues() {
+		if o.IssueType != "requires_without_after" || unitfile.UnitType(o.Unit) == "target" {
+			continue
+		}
+		if !hasEdge(ctx.Graph, o.Unit, o.Related, graph.EdgeRequires) {
+			continue
+		}
+		issue := r.newIssue(ctx, o.Unit, "", fmt.Sprintf("%s has Requires=%s but no After=, so both start in parallel.", o.Unit, o.Related), "")
+		onEdge(&issue, graph.EdgeRequires, o.Related)
+		issue.Line = edgeLine(ctx.Graph, o.Unit, o.Related, graph.EdgeRequires)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+func checkConflictingDependencies(r *crossRule, ctx *rules.Context) []types.Issue {
+	var issues []types.Issue
+	for _, c := range ctx.Graph.FindConflictingDependencies() {
+		issue := r.newIssue(ctx, c.Unit, "", c.Conflict, "")
+		onEdge(&issue, graph.EdgeConflicts, c.Target)
+		if c.File != "" {
+			issue.File = c.File
+		}
+		issue.Line = lineRef(c.Line)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkOrderingContradictions reports each pair on the unit declaring the
+// edge that orders the first unit after the other
+func checkOrderingContradictions(r *crossRule, ctx *rules.Context) []types.Issue {
+	var issues []types.Issue
+	for _, c := range ctx.Graph.FindOrderingContradictions() {
+		after, before := c.AfterOther, c.BeforeOther
+		suggestion := fmt.Sprintf("Remove %s=%s from %s or %s=%s from %s, keeping the order %s and %s need.",
+			after.Type, after.To, after.From, before.Type, before.To, before.From, c.Unit, c.Other)
+		if after.From == before.From {
+			suggestion = fmt.Sprintf("Remove either %s=%s or %s=%s from %s.", after.Type, after.To, before.Type, before.To, after.From)
+		}
+		issue := r.newIssue(ctx, after.From, "", c.Description(), suggestion)
+		onEdge(&issue, after.Type, after.To)
+		if after.File != "" {
+			issue.File = after.File
+		}
+		issue.Line = lineRef(after.Line)
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,274 @@
+package crossunit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitname"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH008",
+			RuleName:        "Handler unit does not exist",
+			RuleDescription: "OnFailure= and OnSuccess= on a unit that does not exist, or on an instance of a template that does not exist, start nothing, and systemd only logs the failed start job.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityMedium,
+			RuleTags:        []string{"dependencies", "missing-unit", "monitoring"},
+			RuleSuggestion:  "Install the handler unit or template, or fix its name.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "OnFailure=")},
+		check: checkHandlers(handlerMissing),
+	})
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH009",
+			RuleName:        "Handler requires the unit it handles",
+			RuleDescription: "A failure handler that requires the failed unit, directly or through other units, fails to start with it and never runs. A success handler that requires the unit starts it again.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityHigh,
+			RuleTags:        []string{"dependencies", "monitoring"},
+			RuleSuggestion:  "Remove the Requires=, Requisite= or BindsTo= chain from the handler to the unit it handles. A handler that needs the unit's name can take it as its instance, as in OnFailure=alert@%n.service, and look the unit up without depending on it.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "OnFailure="), types.ManPage("systemd.unit", "Requires=")},
+		check: checkHandlers(handlerRequiresUnit),
+	})
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH010",
+			RuleName:        "Handler keeps running",
+			RuleDescription: "OnFailure= and OnSuccess= start a handler that is not active yet. A handler that keeps running, a service of another type than oneshot or with RemainAfterExit=yes, runs for the first failure only and never again.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityMedium,
+			RuleTags:        []string{"monitoring"},
+			RuleSuggestion:  "Make the handler a Type=oneshot service without RemainAfterExit=yes, so that it is inactive again when it is done.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "OnFailure="), types.ManPage("systemd.service", "Type=")},
+		check: checkLongRunningHandlers,
+	})
+}
+
+// Kinds of findings on a single OnFailure= or OnSuccess= reference
+const (
+	handlerMissing = iota
+	handlerRequiresUnit
+)
+
+// handlerRef is a handler that a unit names with OnFailure= or OnSuccess=
+type handlerRef struct {
+	edge graph.Edge
+	name string          // The handler, with the unit's specifiers expanded
+	file *types.UnitFile // The handler's unit file or template, nil if missing
+}
+
+// handlerRefs returns the handlers the units of the graph name, ordered by
+// unit and handler. References in templates and with specifiers that cannot
+// be expanded without the running manager are left out.
+func handlerRefs(ctx *rules.Context) []handlerRef {
+	var refs []handlerRef
+	for _, edgeType := range []graph.EdgeType{graph.EdgeOnFailure, graph.EdgeOnSuccess} {
+		for _, e := range ctx.Graph.EdgesOfType(edgeType) {
+			unit := ctx.Graph.Unit(e.From)
+			if unit == nil || strings.Contains(e.From, "@.") {
+				continue
+			}
+			name, ok := validation.ExpandSpecifiers(unit, e.To)
+			if !ok {
+				continue
+			}
+			name = unitname.Normalize(name)
+			refs = append(refs, handlerRef{edge: e, name: name, file: handlerFile(ctx.Graph, name)})
+		}
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].edge.From != refs[j].edge.From {
+			return refs[i].edge.From < refs[j].edge.From
+		}
+		return refs[i].name < refs[j].name
+	})
+	return refs
+}
+
+// handlerFile returns the unit file of a handler, or for an instance without
+// a file of its own, the file of its template. It returns nil when neither exists.
+func handlerFile(g *graph.Graph, name string) *types.UnitFile {
+	if unit := g.Unit(g.Resolve(name)); unit != nil {
+		return unit
+	}
+	if template := templateName(name); template != "" {
+		return g.Unit(g.Resolve(template))
+	}
+	return nil
+}
+
+// templateName returns the template of an instance name, such as
+// alert@.service for alert@app.service, or "" for other names
+func templateName(name string) string {
+	at := strings.Index(name, "@")
+	dot := strings.LastIndex(name, ".")
+	if at < 0 || dot <= at+1 {
+		return ""
+	}
+	return name[:at+1] + name[dot:]
+}
+
+// handlerEvent describes when a handler edge starts its handler
+func handlerEvent(edgeType graph.EdgeType) string {
+	if edgeType == graph.EdgeOnSuccess {
+		return "succeeds"
+	}
+	return "fails"
+}
+
+// checkHandlers reports one kind of finding on the OnFailure= and OnSuccess=
+// references of units, at the directive
+func checkHandlers(kind int) func(r *crossRule, ctx *rules.Context) []types.Issue {
+	return func(r *crossRule, ctx *rules.Context) []types.Issue {
+		var issues []types.Issue
+		for _, ref := range handlerRefs(ctx) {
+			e := ref.edge
+			named := fmt.Sprintf("%s=%s", e.Type, e.To)
+			if ref.name != e.To {
+				named += fmt.Sprintf(" (%s)", ref.name)
+			}
+
+			var description string
+			switch kind {
+			case handlerMissing:
+				if ref.file != nil {
+					continue
+				}
+				description = fmt.Sprintf("%s has %s but no such unit exists, so nothing runs when it %s.", e.From, named, handlerEvent(e.Type))
+				if template := templateName(ref.name); template != "" {
+					description = fmt.Sprintf("%s has %s but neither that unit nor the template %s exists, so nothing runs when it %s.", e.From, named, template, handlerEvent(e.Type))
+				}
+			case handlerRequiresUnit:
+				if ref.file == nil {
+					continue
+				}
+				path := handlerRequirementPath(ctx.Graph, ref.name, ref.file, e.From)
+				if path == nil {
+					continue
+				}
+				var steps []string
+				for _, step := range path {
+					steps = append(steps, fmt.Sprintf("%s %s=%s", step.From, step.Type, step.To))
+				}
+				consequence := fmt.Sprintf("so when %s fails, %s fails to start with it and never runs", e.From, ref.name)
+				if e.Type == graph.EdgeOnSuccess {
+					consequence = fmt.Sprintf("so starting %s after %s succeeds starts %s again", ref.name, e.From, e.From)
+				}
+				description = fmt.Sprintf("%s has %s, which requires %s through %s, %s.", e.From, named, e.From, strings.Join(steps, ", "), consequence)
+			}
+
+			issue := r.newIssue(ctx, e.From, "", description, "")
+			onEdge(&issue, e.Type, ref.name)
+			if e.File != "" {
+				issue.File = e.File
+			}
+			issue.Line = lineRef(e.Line)
+			issues = append(issues, issue)
+		}
+		return issues
+	}
+}
+
+// handlerRequirementPath returns the shortest chain of requirements from a
+// handler to the unit it handles, nil if it does not require the unit. An
+// instance without a file of its own has the requirements of its template,
+// with the specifiers expanded for the instance, such as Requires=%i.
+func handlerRequirementPath(g *graph.Graph, handler string, file *types.UnitFile, unit string) []graph.Edge {
+	if file.Name == g.Resolve(handler) {
+		return g.RequirementPath(handler, unit)
+	}
+
+	instance := &types.UnitFile{Name: handler, Type: file.Type}
+	var shortest []graph.Edge
+	for _, e := range g.EdgesFrom(file.Name) {
+		if !e.Type.PropagatesStartFailure() {
+			continue
+		}
+		to, ok := validation.ExpandSpecifiers(instance, e.To)
+		if !ok {
+			continue
+		}
+		e.From, e.To = handler, unitname.Normalize(to)
+
+		path := []graph.Edge{e}
+		if g.Resolve(e.To) != g.Resolve(unit) {
+			rest := g.RequirementPath(e.To, unit)
+			if rest == nil {
+				continue
+			}
+			path = append(path, rest...)
+		}
+		if shortest == nil || len(path) < len(shortest) {
+			shortest = path
+		}
+	}
+	return shortest
+}
+
+// checkLongRunningHandlers reports each handler that stays active after it
+// runs once, on the handler's unit file or template, listing the units that
+// name it
+func checkLongRunningHandlers(r *crossRule, ctx *rules.Context) []types.Issue {
+	handlers := make(map[string][]string) // handler file -> directives naming it
+	var order []*types.UnitFile
+	for _, ref := range handlerRefs(ctx) {
+		if ref.file == nil || ref.file.Type != "service" {
+			continue
+		}
+		if _, seen := handlers[ref.file.Name]; !seen {
+			order = append(order, ref.file)
+		}
+		handlers[ref.file.Name] = append(handlers[ref.file.Name], fmt.Sprintf("%s %s=%s", ref.edge.From, ref.edge.Type, ref.edge.To))
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i].Name < order[j].Name })
+
+	var issues []types.Issue
+	for _, handler := range order {
+		serviceType := handler.GetDirective("Service", "Type")
+		if serviceType == "" {
+			// Without ExecStart=, only oneshot services are allowed
+			serviceType = "simple"
+			if !handler.HasDirective("Service", "ExecStart") {
+				serviceType = "oneshot"
+			}
+		}
+
+		var description, directive, value string
+		switch {
+		case serviceType != "oneshot":
+			description = fmt.Sprintf("%s is a Type=%s service, which stays active while it runs, so the units naming it cannot start it again until it stops.", handler.Name, serviceType)
+			directive, value = "Type", serviceType
+		case remainsAfterExit(handler):
+			description = fmt.Sprintf("%s has RemainAfterExit=yes, so it stays active after it runs once and the units naming it cannot start it again.", handler.Name)
+			directive, value = "RemainAfterExit", handler.GetDirective("Service", "RemainAfterExit")
+		default:
+			continue
+		}
+		description += fmt.Sprintf(" It is named by %s.", strings.Join(handlers[handler.Name], ", "))
+
+		issue := r.newIssue(ctx, handler.Name, "", description, "")
+		issue.Section, issue.Directive, issue.Value = "Service", directive, value
+		issue.Line = lineRef(rules.DirectiveLine(handler, "Service", directive))
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// remainsAfterExit reports whether a service sets RemainAfterExit= to true
+func remainsAfterExit(unit *types.UnitFile) bool {
+	switch strings.ToLower(unit.GetDirective("Service", "RemainAfterExit")) {
+	case "1", "yes", "y", "true", "t", "on":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package crossunit
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH011",
+			RuleName:        "Path on a mount without RequiresMountsFor=",
+			RuleDescription: "A service that uses a path on a separately mounted file system, in WorkingDirectory=, ReadWritePaths=, EnvironmentFile= or as its ExecStart= binary, can start before the file system is mounted at boot and fail, or use the directory below the mount point, unless it is ordered after the mount unit.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityMedium,
+			RuleTags:        []string{"dependencies", "ordering", "boot"},
+			RuleSuggestion:  "Add RequiresMountsFor= with the path to the [Unit] section. It pulls in and orders the unit after every mount unit the path needs.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "RequiresMountsFor=")},
+		check: checkPathsOnMounts,
+	})
+}
+
+// pathUse is a path a service uses, and the directive that names it
+type pathUse struct {
+	directive string
+	path      string
+	file      string // The drop-in naming it, empty for the unit file
+	line      int
+}
+
+// servicePaths returns the absolute paths of a service's WorkingDirectory=,
+// ReadWritePaths=, EnvironmentFile= and ExecStart= binaries, with the
+// specifiers expanded. Paths with specifiers that need the running manager
+// are left out.
+func servicePaths(unit *types.UnitFile) []pathUse {
+	var uses []pathUse
+	add := func(d types.Directive, p string) {
+		p, ok := validation.ExpandSpecifiers(unit, p)
+		if ok && strings.HasPrefix(p, "/") {
+			uses = append(uses, pathUse{directive: d.Key, path: path.Clean(p), file: d.File, line: d.Line})
+		}
+	}
+
+	for _, d := range unit.GetDirectives("Service", "WorkingDirectory") {
+		add(d, strings.TrimPrefix(d.Value, "-"))
+	}
+	for _, d := range unit.GetDirectives("Service", "ReadWritePaths") {
+		for _, p := range strings.Fields(d.Value) {
+			add(d, strings.TrimLeft(p, "-+"))
+		}
+	}
+	for _, d := range unit.GetDirectives("Service", "EnvironmentFile") {
+		add(d, strings.TrimPrefix(d.Value, "-"))
+	}
+	for _, d := range unit.GetDirectives("Service", "ExecStart") {
+		for _, command := range validation.ParseExecCommands(d.Value) {
+			add(d, command.Path)
+		}
+	}
+	return uses
+}
+
+// mountOf returns the mount unit of the scan that a path is on, the one with
+// the longest mount point, or "" when it is only on the root file system
+func mountOf(g *graph.Graph, p string) string {
+	mounts := graph.MountUnitsFor(p)
+	for i := len(mounts) - 1; i > 0; i-- {
+		if g.Unit(mounts[i]) != nil {
+			return mounts[i]
+		}
+	}
+	return ""
+}
+
+// orderedAfterMount reports whether a unit starts after a mount unit: with
+// After= on it, RequiresMountsFor= or another implicit dependency, or after a
+// target that the mount starts before. Units with default dependencies start
+// after sysinit.target, which starts after local-fs.target.
+func orderedAfterMount(g *graph.Graph, unit, mount string) bool {
+	after := make(map[string]bool)
+	for _, e := range g.EdgesFrom(unit) {
+		if e.Type == graph.EdgeAfter {
+			after[e.To] = true
+		}
+	}
+	if after[mount] {
+		return true
+	}
+	if after["sysinit.target"] || after["basic.target"] {
+		after["local-fs.target"] = true
+	}
+	for _, e := range g.EdgesTo(mount) {
+		if e.Type == graph.EdgeAfter && after[e.From] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPathsOnMounts reports each service and mount unit pair where the
+// service uses paths on the mount without starting after it, at the first
+// directive naming one of the paths
+func checkPathsOnMounts(r *crossRule, ctx *rules.Context) []types.Issue {
+	names := make([]string, 0, len(ctx.AllUnits))
+	for name, unit := range ctx.AllUnits {
+		if unit != nil && unit.Type == "service" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var issues []types.Issue
+	for _, name := range names {
+		byMount := make(map[string][]pathUse)
+		var mounts []string
+		for _, use := range servicePaths(ctx.AllUnits[name]) {
+			mount := mountOf(ctx.Graph, use.path)
+			if mount == "" {
+				continue
+			}
+			if _, seen := byMount[mount]; !seen {
+				mounts = append(mounts, mount)
+			}
+			byMount[mount] = append(byMount[mount], use)
+		}
+
+		for _, mount := range mounts {
+			if orderedAfterMount(ctx.Graph, name, mount) {
+				continue
+			}
+			var named, paths []string
+			seen := make(map[string]bool)
+			for _, use := range byMount[mount] {
+				named = append(named, fmt.Sprintf("%s=%s", use.directive, use.path))
+				if !seen[use.path] {
+					seen[use.path] = true
+					paths = append(paths, use.path)
+				}
+			}
+			description := fmt.Sprintf("%s uses %s on %s but does not start after it, so at boot it can start before the file system is mounted.", name, strings.Join(named, ", "), mount)
+			suggestion := fmt.Sprintf("Add RequiresMountsFor=%s to the [Unit] section of %s.", strings.Join(paths, " "), name)
+
+			first := byMount[mount][0]
+			issue := r.newIssue(ctx, name, "", description, suggestion)
+			issue.Section, issue.Directive, issue.Expected = "Unit", "RequiresMountsFor", strings.Join(paths, " ")
+			issue.Value = mount
+			if first.file != "" {
+				issue.File = first.file
+			}
+			issue.Line = lineRef(first.line)
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,212 @@
+package crossunit
+
+import (
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/propagation"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Tags of the rules the deadlocks command runs
+const (
+	TagDeadlock     = "deadlock"
+	TagRestartStorm = "restart-storm"
+)
+
+// patternRule describes a rule for one pattern of a propagation detector
+type patternRule struct {
+	id, name, description, suggestion string
+	severity                          types.Severity
+	directive                         string
+	pattern                           string
+}
+
+// stormRules keep one restart storm pattern each
+var stormRules = []patternRule{
+	{"PROP001", "Restart storm through mutual BindsTo=", "Two units bound to each other with Restart= stop and restart each other when either fails.",
+		"Replace BindsTo= with Requires= in one direction.", types.SeverityCritical, "BindsTo=", propagation.StormMutualBindsTo},
+	{"PROP002", "Restart storm through a BindsTo= cycle", "A failure anywhere in a dependency cycle with BindsTo= and Restart= can restart the whole cycle over and over.",
+		"Break the dependency cycle or replace BindsTo= with Requires=.", types.SeverityCritical, "BindsTo=", propagation.StormBindsToCycle},
+	{"PROP003", "Bound unit does not restart with its dependency", "A unit without Restart= stays stopped after the unit it is bound to restarts.",
+		"Add Restart= to the bound unit, or use PartOf= if it should follow restarts of its dependency.", types.SeverityMedium, "BindsTo=", propagation.StormBoundRestart},
+}
+
+// deadlockRules keep one restart deadlock pattern each
+var deadlockRules = []patternRule{
+	{"PROP004", "Bound unit requires its dependent", "A unit bound to and ordered after a unit that requires it cannot come back after that unit restarts.",
+		"Remove the circular dependency or change BindsTo= to Requires=.", types.SeverityCritical, "BindsTo=", propagation.DeadlockBoundRequires},
+	{"PROP005", "Mutual After= with BindsTo=", "Two units ordered after each other, one bound to the other, cannot both be started again.",
+		"Remove one of the After= directives.", types.SeverityHigh, "After=", propagation.DeadlockMutualAfter},
+	{"PROP006", "Mutual BindsTo= with After=", "Two units bound to and ordered after each other stop together and may not restart.",
+		"Use Requires= instead of BindsTo= in one direction.", types.SeverityCritical, "BindsTo=", propagation.DeadlockMutualBindsTo},
+	{"PROP007", "Transitive restart deadlock", "A BindsTo= chain that leads back to the bound unit through ordering and requirements can keep it from restarting.",
+		"Break the chain or replace BindsTo= with Requires=.", types.SeverityHigh, "BindsTo=", propagation.DeadlockTransitive},
+	{"PROP008", "BindsTo= conflicts with a requirement", "A unit bound to a unit whose requirements it conflicts with can never run together with it.",
+		"Remove Conflicts= or the requirement it contradicts.", types.SeverityCritical, "Conflicts=", propagation.DeadlockBindsToConflict},
+}
+
+// groupRules keep one PartOf= or reload propagation pattern each
+var groupRules = []patternRule{
+	{"PROP011", "PartOf= a unit that is never started", "PartOf= only stops and restarts the unit with the group it names, which does nothing if no unit requires or wants it.",
+		"Pull in the unit named by PartOf=, for example with WantedBy= in its [Install] section.", types.SeverityMedium, "PartOf=", propagation.GroupNeverStarted},
+	{"PROP012", "Reload propagated to a unit that cannot reload", "A propagated reload of a service without ExecReload= or Type=notify-reload is skipped, so it keeps running with the old configuration.",
+		"Add ExecReload= to the receiving service or stop propagating reloads to it.", types.SeverityMedium, "PropagatesReloadTo=", propagation.GroupReloadUnsupported},
+	{"PROP013", "ReloadPropagatedFrom= missing from PropagatesReloadTo=", "A unit that lists the units it reloads with PropagatesReloadTo= but leaves out one declaring ReloadPropagatedFrom= on it suggests one side was not updated.",
+		"Declare the reload propagation on both units, or remove it from the unit that should not reload.", types.SeverityLow, "ReloadPropagatedFrom=", propagation.GroupReloadAsymmetric},
+}
+
+func init() {
+	for _, p := range groupRules {
+		register(p.rule([]string{"dependencies", "reload"}, checkGroups(p.pattern)))
+	}
+	for _, p := range stormRules {
+		register(p.rule([]string{"dependencies", "restart", TagRestartStorm}, checkRestartStorms(p.pattern)))
+	}
+	for _, p := range deadlockRules {
+		register(p.rule([]string{"dependencies", "restart", TagDeadlock}, checkRestartDeadlocks(p.pattern)))
+	}
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "PROP009",
+			RuleName:        "Requisite= on a unit that may never be active",
+			RuleDescription: "Requisite= fails immediately unless the unit is already active, so a missing or conditional unit keeps the dependent from starting.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityCritical,
+			RuleTags:        []string{"dependencies", TagDeadlock},
+			RuleSuggestion:  "Use Requires= with After= unless the unit must only start when its dependency already runs.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "Requisite=")},
+		check: checkWaitDeadlocks,
+	})
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "PROP010",
+			RuleName:        "Critical unit pulled in with Wants=",
+			RuleDescription: "Wants= ignores failures, so a unit does not notice when a critical service such as dbus or journald fails.",
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    types.SeverityMedium,
+			RuleTags:        []string{"dependencies", "failure"},
+			RuleSuggestion:  "Use Requires= if the unit cannot work without the critical service.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "Wants=")},
+		check: checkSilentFailures,
+	})
+}
+
+func (p patternRule) rule(tags []string, check func(r *crossRule, ctx *rules.Context) []types.Issue) *crossRule {
+	return &crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          p.id,
+			RuleName:        p.name,
+			RuleDescription: p.description,
+			RuleCategory:    types.CategoryReliability,
+			RuleSeverity:    p.severity,
+			RuleTags:        tags,
+			RuleSuggestion:  p.suggestion,
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", p.directive)},
+		check: check,
+	}
+}
+
+// checkRestartStorms reports storms of a pattern on the first unit, by name,
+// whose BindsTo= is part of the storm
+func checkRestartStorms(pattern string) func(r *crossRule, ctx *rules.Context) []types.Issue {
+	return func(r *crossRule, ctx *rules.Context) []types.Issue {
+		var issues []types.Issue
+		for _, s := range propagation.DetectRestartStorms(ctx.Graph, ctx.AllUnits).Storms {
+			if s.Pattern != pattern {
+				continue
+			}
+			var bound *propagation.StormEdge
+			for i, e := range s.Evidence {
+				if e.Type == graph.EdgeBindsTo && (bound == nil || e.From < bound.From) {
+					bound = &s.Evidence[i]
+				}
+			}
+			unit := s.Trigger
+			if bound != nil {
+				unit = bound.From
+			}
+			issue := r.newIssue(ctx, unit, s.Severity, s.Description, s.Resolution)
+			if bound != nil {
+				onEdge(&issue, bound.Type, bound.To)
+				issue.Line = edgeLine(ctx.Graph, bound.From, bound.To, bound.Type)
+			}
+			issues = append(issues, issue)
+		}
+		return issues
+	}
+}
+
+// checkRestartDeadlocks reports deadlocks of a pattern on the bound unit,
+// pointing at its BindsTo= line
+func checkRestartDeadlocks(pattern string) func(r *crossRule, ctx *rules.Context) []types.Issue {
+	return func(r *crossRule, ctx *rules.Context) []types.Issue {
+		var issues []types.Issue
+		for _, d := range propagation.DetectDeadlocks(ctx.Graph, ctx.AllUnits).Deadlocks {
+			if d.Pattern != pattern {
+				continue
+			}
+			issue := r.newIssue(ctx, d.UnitA, d.Severity, d.Scenario, d.Resolution)
+			onEdge(&issue, graph.EdgeBindsTo, d.UnitB)
+			issue.Line = edgeLine(ctx.Graph, d.UnitA, d.UnitB, graph.EdgeBindsTo)
+			issues = append(issues, issue)
+		}
+		return issues
+	}
+}
+
+// checkGroups reports the PartOf= and reload propagation issues of a pattern
+// on the unit declaring the directive
+func checkGroups(pattern string) func(r *crossRule, ctx *rules.Context) []types.Issue {
+	return func(r *crossRule, ctx *rules.Context) []types.Issue {
+		var issues []types.Issue
+		for _, gi := range propagation.DetectGroupIssues(ctx.Graph, ctx.AllUnits) {
+			if gi.Pattern != pattern {
+				continue
+			}
+			issue := r.newIssue(ctx, gi.Unit, gi.Severity, gi.Reason, gi.Resolution)
+			onEdge(&issue, gi.Edge.Type, gi.Related)
+			if gi.Edge.File != "" {
+				issue.File = gi.Edge.File
+			}
+			issue.Line = lineRef(gi.Edge.Line)
+			issues = append(issues, issue)
+		}
+		return issues
+	}
+}
+
+// checkWaitDeadlocks skips Requisite= on units that usually have no unit file
+func checkWaitDeadlocks(r *crossRule, ctx *rules.Context) []types.Issue {
+	var issues []types.Issue
+	for _, d := range propagation.DetectWaitDeadlocks(ctx.Graph, ctx.AllUnits) {
+		if _, ok := ctx.AllUnits[d.WaitsFor]; !ok && withoutUnitFile(ctx.AllUnits, d.WaitsFor) {
+			continue
+		}
+		issue := r.newIssue(ctx, d.Unit, d.Severity, d.Reason, d.Resolution)
+		onEdge(&issue, graph.EdgeRequisite, d.WaitsFor)
+		issue.Line = edgeLine(ctx.Graph, d.Unit, d.WaitsFor, graph.EdgeRequisite)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkSilentFailures skips targets on either side, which never fail or act
+// on failures, and Wants= added by another unit's WantedBy=
+func checkSilentFailures(r *crossRule, ctx *rules.Context) []types.Issue {
+	var issues []types.Issue
+	for _, f := range propagation.DetectSilentFailures(ctx.Graph, nil) {
+		unit := ctx.AllUnits[f.DependedBy]
+		if unit == nil || unit.Path != f.File || unitfile.UnitType(f.DependedBy) == "target" || unitfile.UnitType(f.Unit) == "target" {
+			continue
+		}
+		issue := r.newIssue(ctx, f.DependedBy, f.Risk, f.Description, "")
+		onEdge(&issue, f.EdgeType, f.Unit)
+		issue.Line = lineRef(f.Line)
+		issues = append(issues, issue)
+	}
+	return issues
+}
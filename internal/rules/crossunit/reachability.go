@@ -0,0 +1,71 @@
+package crossunit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH005",
+			RuleName:        "Unit is never started",
+			RuleDescription: "Nothing pulls in, activates or enables this unit, so it only runs when started by hand.",
+			RuleCategory:    types.CategoryBestPractice,
+			RuleSeverity:    types.SeverityInfo,
+			RuleTags:        []string{"dependencies", "dead-config"},
+			RuleSuggestion:  "Add an [Install] section and enable the unit, reference it from the unit that needs it, or remove it.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.unit", "WantedBy=")},
+		check: checkDeadUnits(graph.DeadUnreferenced),
+	})
+	register(&crossRule{
+		BaseRule: rules.BaseRule{
+			RuleID:          "GRAPH006",
+			RuleName:        "Unit wanted by a target that is never reached",
+			RuleDescription: "The unit is only wanted by targets the default target does not pull in, so it does not start at boot.",
+			RuleCategory:    types.CategoryBestPractice,
+			RuleSeverity:    types.SeverityLow,
+			RuleTags:        []string{"dependencies", "dead-config", "boot"},
+			RuleSuggestion:  "Install the unit into a target the default target reaches, such as multi-user.target.",
+		},
+		refs:  []types.Reference{types.ManPage("systemd.special", "default.target")},
+		check: checkDeadUnits(graph.DeadUnreachableTarget),
+	})
+}
+
+// checkDeadUnits reports the dead units of one kind. Unreferenced units
+// shipped by systemd or packages are skipped: generators, udev rules and the
+// kernel command line start many of them, which the graph does not show.
+func checkDeadUnits(kind string) func(r *crossRule, ctx *rules.Context) []types.Issue {
+	return func(r *crossRule, ctx *rules.Context) []types.Issue {
+		root := ctx.Graph.DefaultTarget()
+
+		var issues []types.Issue
+		for _, d := range ctx.Graph.FindDeadUnits() {
+			if d.Kind != kind || (kind == graph.DeadUnreferenced && strings.Contains(d.File, "/lib/systemd/system/")) {
+				continue
+			}
+			var description string
+			switch {
+			case kind == graph.DeadUnreferenced:
+				description = fmt.Sprintf("%s is not started by %s, another unit, a socket, timer or path, or D-Bus.", d.Unit, root)
+			case ctx.Graph.HasUnit(ctx.Graph.Resolve(d.Target)):
+				description = fmt.Sprintf("%s is wanted by %s, which %s does not pull in.", d.Unit, d.Target, root)
+			default:
+				description = fmt.Sprintf("%s is wanted by %s, which does not exist.", d.Unit, d.Target)
+			}
+			issue := r.newIssue(ctx, d.Unit, "", description, "")
+			if d.File != "" {
+				issue.File = d.File
+			}
+			issue.Line = lineRef(d.Line)
+			issues = append(issues, issue)
+		}
+		return issues
+	}
+}
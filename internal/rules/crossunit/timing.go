@@ -0,0 +1,72 @@
+package crossunit
+
+import (
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Restart cycle risks are left to the timing command: dependencies that are
+// already active are not waited for again on restart, so the worst-case paths
+// it is based on overstate them.
+
+// cascadeRule describes a rule for one kind of timeout cascade risk
+type cascadeRule struct {
+	id, name, description, suggestion string
+	severity                          types.Severity
+	kind                              string
+	// section and directive locate the setting the risk is about
+	section, directive string
+	ref                types.Reference
+}
+
+var cascadeRules = []cascadeRule{
+	{"TIME001", "Dependencies outlast JobTimeoutSec=",
+		"JobTimeoutSec= starts when the job is queued, so a unit waiting for a long chain of dependencies can time out before it starts. Dependencies taking 80% of it or more are flagged as well.",
+		"Raise JobTimeoutSec= or remove it and rely on TimeoutStartSec=.",
+		types.SeverityCritical, timing.RiskJobTimeout, "Unit", "JobTimeoutSec", types.ManPage("systemd.unit", "JobTimeoutSec=")},
+	{"TIME002", "Short start timeout after the network",
+		"Network initialization can take 30 seconds or more, which a short TimeoutStartSec= on a unit ordered after the network does not allow for.",
+		"Raise TimeoutStartSec= to at least 60s for units that wait for the network.",
+		types.SeverityCritical, timing.RiskNetworkWait, "Service", "TimeoutStartSec", types.ManPage("systemd.service", "TimeoutStartSec=")},
+	{"TIME003", "Long dependency chain",
+		"A chain of ten or more After= dependencies makes the unit start late and adds up the timeouts of every unit in it.",
+		"Drop the After= dependencies the unit does not need.",
+		types.SeverityMedium, timing.RiskLongChain, "Unit", "After", types.ManPage("systemd.unit", "After=")},
+}
+
+func init() {
+	for _, c := range cascadeRules {
+		register(&crossRule{
+			BaseRule: rules.BaseRule{
+				RuleID:          c.id,
+				RuleName:        c.name,
+				RuleDescription: c.description,
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    c.severity,
+				RuleTags:        []string{"timeout", "boot", "dependencies"},
+				RuleSuggestion:  c.suggestion,
+			},
+			refs: []types.Reference{c.ref},
+			check: func(r *crossRule, ctx *rules.Context) []types.Issue {
+				return checkCascades(r, ctx, c)
+			},
+		})
+	}
+}
+
+// checkCascades reports the cascade risks of one kind, computed from each
+// unit's start timeout with DefaultTimeoutStartSec= from system.conf
+func checkCascades(r *crossRule, ctx *rules.Context, c cascadeRule) []types.Issue {
+	var issues []types.Issue
+	for _, risk := range ctx.TimeoutCascades() {
+		if risk.Kind != c.kind {
+			continue
+		}
+		issue := r.newIssue(ctx, risk.Unit, risk.Risk, risk.Description, risk.Recommendation)
+		issue.Section, issue.Directive = c.section, c.directive
+		issue.Line = lineRef(rules.DirectiveLine(ctx.AllUnits[risk.Unit], c.section, c.directive))
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,272 @@
+// Package custom loads rules declared in YAML or JSON files, for site
+// conventions that are not built-in rules. A rule file holds a list of
+// rules:
+//
+//	rules:
+//	  - id: ORG001
+//	    name: SyslogIdentifier not set
+//	    description: Services must set SyslogIdentifier= so logs can be routed.
+//	    category: bestpractice
+//	    severity: low
+//	    unit_types: [service]
+//	    require:
+//	      key: SyslogIdentifier
+//	      present: true
+//
+// A unit of the listed types that does not satisfy require is reported.
+// JSON files hold the same object.
+package custom
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Extensions are the file extensions LoadDir reads
+var Extensions = []string{".yaml", ".yml", ".json"}
+
+// Error is an invalid rule file, with the line at fault
+type Error struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Rule is a rule loaded from a rule file
+type Rule struct {
+	rules.BaseRule
+	unitTypes []string
+	message   string
+	require   *Matcher
+	// Source is the file and line the rule was declared at
+	Source string
+	// fingerprint changes whenever the rule's declaration does
+	fingerprint string
+}
+
+// AppliesTo returns the unit types the rule checks, nil for every type
+func (r *Rule) AppliesTo() []string { return r.unitTypes }
+
+// Fingerprint identifies the rule's declaration, so cached results are not
+// reused after the rule file changes
+func (r *Rule) Fingerprint() string { return r.fingerprint }
+
+// Origin names the rule file and line the rule was declared at
+func (r *Rule) Origin() string { return "custom " + r.Source }
+
+// Check reports the unit when it does not satisfy the rule's require
+// condition
+func (r *Rule) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	if unit == nil || r.require.Match(unit) {
+		return nil
+	}
+	return []types.Issue{r.NewIssue(unit, r.message, nil)}
+}
+
+var idPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_-]*$`)
+
+// ruleFields are the keys a rule may have
+var ruleFields = []string{"id", "name", "description", "category", "severity", "tags", "suggestion", "references", "unit_types", "message", "require"}
+
+// spec is a rule as declared in a rule file
+type spec struct {
+	ID          string   `yaml:"id"`
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Category    string   `yaml:"category"`
+	Severity    string   `yaml:"severity"`
+	Tags        []string `yaml:"tags"`
+	Suggestion  string   `yaml:"suggestion"`
+	References  []string `yaml:"references"`
+	UnitTypes   []string `yaml:"unit_types"`
+	Message     string   `yaml:"message"`
+	Require     *Matcher `yaml:"require"`
+}
+
+// Load reads the rules declared in one file
+func Load(path string) ([]*Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := parse(data)
+	if err != nil {
+		var e *Error
+		var typeErr *yaml.TypeError
+		switch {
+		case errors.As(err, &e):
+			e.File = path
+			return nil, e
+		case errors.As(err, &typeErr) && len(typeErr.Errors) > 0:
+			// "line 8: cannot unmarshal !!str `abc` into float64"
+			e = &Error{File: path, Msg: typeErr.Errors[0]}
+			if _, err := fmt.Sscanf(typeErr.Errors[0], "line %d: ", &e.Line); err == nil {
+				_, e.Msg, _ = strings.Cut(typeErr.Errors[0], ": ")
+			}
+			return nil, e
+		}
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, r := range loaded {
+		r.Source = fmt.Sprintf("%s:%s", path, r.Source)
+	}
+	return loaded, nil
+}
+
+// LoadDir reads the rules declared in the rule files of dir, in name order.
+// Rule IDs must not repeat across the files or clash with registered rules.
+func LoadDir(dir string) ([]*Rule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if !e.IsDir() && slices.Contains(Extensions, ext) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	seen := make(map[string]string)
+	var all []*Rule
+	for _, name := range names {
+		loaded, err := Load(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range loaded {
+			if other, ok := seen[r.ID()]; ok {
+				return nil, fmt.Errorf("%s: rule %s is already declared at %s", r.Source, r.ID(), other)
+			}
+			if rules.Get(r.ID()) != nil {
+				return nil, fmt.Errorf("%s: rule %s is already a registered rule", r.Source, r.ID())
+			}
+			seen[r.ID()] = r.Source
+		}
+		all = append(all, loaded...)
+	}
+	return all, nil
+}
+
+// parse decodes and checks a rule file. Rule sources are set to their line.
+func parse(data []byte) ([]*Rule, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if err := checkFields(root, []string{"rules"}); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	list := root.Content[1]
+	if list.Kind != yaml.SequenceNode {
+		return nil, lineError(list, "rules must be a list")
+	}
+
+	seen := make(map[string]int)
+	var out []*Rule
+	for _, node := range list.Content {
+		r, err := parseRule(node)
+		if err != nil {
+			return nil, err
+		}
+		if line, ok := seen[r.ID()]; ok {
+			return nil, lineError(node, "rule %s is already declared at line %d", r.ID(), line)
+		}
+		seen[r.ID()] = node.Line
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func parseRule(node *yaml.Node) (*Rule, error) {
+	if err := checkFields(node, ruleFields); err != nil {
+		return nil, err
+	}
+	var s spec
+	if err := node.Decode(&s); err != nil {
+		return nil, err
+	}
+
+	at := func(field string) *yaml.Node { return valueOf(node, field) }
+	switch {
+	case !idPattern.MatchString(s.ID):
+		return nil, lineError(at("id"), "rule id %q must be upper case letters, digits, '-' or '_'", s.ID)
+	case s.Name == "":
+		return nil, lineError(node, "rule %s has no name", s.ID)
+	case s.Require == nil:
+		return nil, lineError(node, "rule %s has no require condition", s.ID)
+	}
+
+	category := types.ParseCategory(s.Category)
+	if s.Category == "" || category.String() != s.Category {
+		return nil, lineError(at("category"), "unknown category %q, use security, performance, reliability or bestpractice", s.Category)
+	}
+	severity := types.ParseSeverity(s.Severity)
+	if s.Severity == "" || severity.String() != s.Severity {
+		return nil, lineError(at("severity"), "unknown severity %q, use critical, high, medium, low or info", s.Severity)
+	}
+
+	message := s.Message
+	if message == "" {
+		message = s.Description
+	}
+	if message == "" {
+		message = s.Name
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+
+	return &Rule{
+		BaseRule: rules.BaseRule{
+			RuleID:          s.ID,
+			RuleName:        s.Name,
+			RuleDescription: s.Description,
+			RuleCategory:    category,
+			RuleSeverity:    severity,
+			RuleTags:        s.Tags,
+			RuleSuggestion:  s.Suggestion,
+			RuleReferences:  s.References,
+		},
+		unitTypes:   s.UnitTypes,
+		message:     message,
+		require:     s.Require,
+		Source:      fmt.Sprint(node.Line),
+		fingerprint: hex.EncodeToString(sum[:8]),
+	}, nil
+}
@@ -0,0 +1,167 @@
+package custom
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+const orgRules = `rules:
+  - id: ORG001
+    name: SyslogIdentifier not set
+    description: Services must set SyslogIdentifier= so their logs can be routed.
+    category: bestpractice
+    severity: low
+    tags: [logging]
+    unit_types: [service]
+    require:
+      key: SyslogIdentifier
+      present: true
+  - id: ORG002
+    name: Restart limits out of range
+    category: reliability
+    severity: medium
+    message: StartLimitBurst= must be between 3 and 10.
+    require:
+      any:
+        - {section: Unit, key: StartLimitBurst, absent: true}
+        - all:
+            - {section: Unit, key: StartLimitBurst, ge: 3}
+            - {section: Unit, key: StartLimitBurst, le: 10}
+  - id: ORG003
+    name: Timer outside maintenance.slice
+    category: bestpractice
+    severity: low
+    unit_types: [timer]
+    require:
+      not:
+        key: Unit
+        regex: "^db-"
+`
+
+func writeRules(t *testing.T, name, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func unit(name, typ string, sections map[string]map[string]string) *types.UnitFile {
+	u := &types.UnitFile{Name: name, Path: "/etc/systemd/system/" + name, Type: typ, Sections: make(map[string]*types.Section)}
+	for section, directives := range sections {
+		s := &types.Section{Name: section, Directives: make(map[string][]types.Directive)}
+		for k, v := range directives {
+			s.Directives[k] = []types.Directive{{Key: k, Value: v}}
+		}
+		u.Sections[section] = s
+	}
+	return u
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := writeRules(t, "org.yaml", orgRules)
+	loaded, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 3 {
+		t.Fatalf("loaded %d rules, want 3", len(loaded))
+	}
+	r := loaded[0]
+	if r.ID() != "ORG001" || r.Category() != types.CategoryBestPractice || r.Severity() != types.SeverityLow || r.Source != filepath.Join(dir, "org.yaml")+":2" {
+		t.Errorf("ORG001 = %+v", r)
+	}
+
+	bare := unit("app.service", "service", map[string]map[string]string{"Service": {"ExecStart": "/usr/bin/app"}})
+	issues := r.Check(rules.NewContext(bare))
+	if len(issues) != 1 || issues[0].RuleID != "ORG001" || issues[0].Description != "Services must set SyslogIdentifier= so their logs can be routed." {
+		t.Errorf("ORG001 issues = %+v", issues)
+	}
+	named := unit("app.service", "service", map[string]map[string]string{"Service": {"SyslogIdentifier": "app"}})
+	if issues := r.Check(rules.NewContext(named)); len(issues) != 0 {
+		t.Errorf("ORG001 reported a unit that sets SyslogIdentifier=: %+v", issues)
+	}
+	if rules.AppliesTo(r, unit("app.timer", "timer", nil)) || !rules.AppliesTo(loaded[1], unit("app.timer", "timer", nil)) {
+		t.Error("unit_types should limit the rule and its absence apply it to every type")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	loaded, err := LoadDir(writeRules(t, "org.yml", orgRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits, timer := loaded[1], loaded[2]
+
+	tests := []struct {
+		name   string
+		rule   *Rule
+		unit   *types.UnitFile
+		report bool
+	}{
+		{"burst unset", limits, unit("a.service", "service", nil), false},
+		{"burst in range", limits, unit("a.service", "service", map[string]map[string]string{"Unit": {"StartLimitBurst": "5"}}), false},
+		{"burst too high", limits, unit("a.service", "service", map[string]map[string]string{"Unit": {"StartLimitBurst": "50"}}), true},
+		{"burst not a number", limits, unit("a.service", "service", map[string]map[string]string{"Unit": {"StartLimitBurst": "many"}}), true},
+		{"timer section by default", timer, unit("b.timer", "timer", map[string]map[string]string{"Timer": {"Unit": "db-backup.service"}}), true},
+		{"other timer", timer, unit("b.timer", "timer", map[string]map[string]string{"Timer": {"Unit": "logrotate.service"}}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.rule.Check(rules.NewContext(tt.unit))) > 0; got != tt.report {
+				t.Errorf("reported = %v, want %v", got, tt.report)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	rule := func(body string) string {
+		return "rules:\n  - id: ORG010\n    name: Test\n    category: bestpractice\n    severity: low\n" + body
+	}
+	tests := []struct {
+		name, file, content, want string
+	}{
+		{"unknown rule field", "a.yaml", rule("    categroy: x\n"), "a.yaml:6: unknown field \"categroy\""},
+		{"bad severity", "a.yaml", strings.Replace(rule("    require: {key: A, present: true}\n"), "low", "urgent", 1), "a.yaml:5: unknown severity \"urgent\""},
+		{"no require", "a.yaml", rule(""), "a.yaml:2: rule ORG010 has no require condition"},
+		{"bad regex", "a.yaml", rule("    require:\n      key: A\n      regex: \"a(\"\n"), "a.yaml:8: invalid regex for A"},
+		{"two tests", "a.yaml", rule("    require:\n      key: A\n      present: true\n      equals: b\n"), "a.yaml:7: A has more than one kind of test"},
+		{"unknown matcher field", "a.yaml", rule("    require:\n      all:\n        - {key: A, presnt: true}\n"), "a.yaml:8: unknown field \"presnt\""},
+		{"not a number", "a.yaml", rule("    require: {key: A, gt: lots}\n"), "a.yaml:6: cannot unmarshal"},
+		{"lower case id", "a.yaml", strings.Replace(rule("    require: {key: A, present: true}\n"), "ORG010", "org010", 1), "a.yaml:2: rule id \"org010\""},
+		{"duplicate id", "a.yaml", rule("    require: {key: A, present: true}\n") + strings.TrimPrefix(rule("    require: {key: A, present: true}\n"), "rules:\n"), "a.yaml:7: rule ORG010 is already declared at line 2"},
+		{"built-in id", "a.json", `{"rules": [{"id": "TEST201", "name": "x", "category": "security", "severity": "low", "require": {"key": "A", "present": true}}]}`, "rule TEST201 is already a registered rule"},
+	}
+	if rules.Get("TEST201") == nil {
+		rules.Register(&Rule{BaseRule: rules.BaseRule{RuleID: "TEST201"}})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadDir(writeRules(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, err := LoadDir(writeRules(t, "org.yaml", orgRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadDir(writeRules(t, "org.yaml", strings.Replace(orgRules, "present: true", "absent: true", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.Fingerprint(a[0]) == rules.Fingerprint(b[0]) || rules.Fingerprint(a[1]) != rules.Fingerprint(b[1]) {
+		t.Error("fingerprints should change with the rule's declaration only")
+	}
+}
@@ -0,0 +1,204 @@
+package custom
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Matcher is a condition on a unit's directives. It either combines other
+// matchers with all, any or not, or tests one directive:
+//
+//	section: Service          # defaults to the section of the unit's type
+//	key: SyslogIdentifier
+//	present: true             # or absent, equals, regex, gt, ge, lt, le
+//
+// equals, regex and the numeric comparisons test the directive's last value,
+// the one systemd uses.
+type Matcher struct {
+	All []*Matcher
+	Any []*Matcher
+	Not *Matcher
+
+	Section string
+	Key     string
+	Present bool
+	Absent  bool
+	Equals  *string
+	Regex   *regexp.Regexp
+	GT      *float64
+	GE      *float64
+	LT      *float64
+	LE      *float64
+}
+
+// matcherFields are the keys a matcher may have
+var matcherFields = []string{"all", "any", "not", "section", "key", "present", "absent", "equals", "regex", "gt", "ge", "lt", "le"}
+
+// UnmarshalYAML decodes a matcher and checks it is complete
+func (m *Matcher) UnmarshalYAML(node *yaml.Node) error {
+	if err := checkFields(node, matcherFields); err != nil {
+		return err
+	}
+	var raw struct {
+		All     []*Matcher `yaml:"all"`
+		Any     []*Matcher `yaml:"any"`
+		Not     *Matcher   `yaml:"not"`
+		Section string     `yaml:"section"`
+		Key     string     `yaml:"key"`
+		Present bool       `yaml:"present"`
+		Absent  bool       `yaml:"absent"`
+		Equals  *string    `yaml:"equals"`
+		Regex   *string    `yaml:"regex"`
+		GT      *float64   `yaml:"gt"`
+		GE      *float64   `yaml:"ge"`
+		LT      *float64   `yaml:"lt"`
+		LE      *float64   `yaml:"le"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*m = Matcher{
+		All: raw.All, Any: raw.Any, Not: raw.Not,
+		Section: raw.Section, Key: raw.Key,
+		Present: raw.Present, Absent: raw.Absent, Equals: raw.Equals,
+		GT: raw.GT, GE: raw.GE, LT: raw.LT, LE: raw.LE,
+	}
+
+	combinators := 0
+	for _, set := range []bool{raw.All != nil, raw.Any != nil, raw.Not != nil} {
+		if set {
+			combinators++
+		}
+	}
+	numeric := raw.GT != nil || raw.GE != nil || raw.LT != nil || raw.LE != nil
+	tests := 0
+	for _, set := range []bool{raw.Present, raw.Absent, raw.Equals != nil, raw.Regex != nil, numeric} {
+		if set {
+			tests++
+		}
+	}
+
+	switch {
+	case combinators > 1:
+		return lineError(node, "a matcher takes only one of all, any and not")
+	case combinators == 1 && (tests > 0 || raw.Key != "" || raw.Section != ""):
+		return lineError(node, "all, any and not cannot be combined with a directive test")
+	case combinators == 1:
+		if (raw.All != nil && len(raw.All) == 0) || (raw.Any != nil && len(raw.Any) == 0) {
+			return lineError(node, "all and any need at least one matcher")
+		}
+		return nil
+	case raw.Key == "":
+		return lineError(node, "a matcher needs all, any, not or a key")
+	case tests == 0:
+		return lineError(node, "the test of %s is missing: use present, absent, equals, regex, gt, ge, lt or le", raw.Key)
+	case tests > 1:
+		return lineError(node, "%s has more than one kind of test", raw.Key)
+	}
+
+	if raw.Regex != nil {
+		re, err := regexp.Compile(*raw.Regex)
+		if err != nil {
+			return lineError(valueOf(node, "regex"), "invalid regex for %s: %v", raw.Key, err)
+		}
+		m.Regex = re
+	}
+	return nil
+}
+
+// Match reports whether the unit satisfies the matcher
+func (m *Matcher) Match(unit *types.UnitFile) bool {
+	switch {
+	case m.All != nil:
+		for _, sub := range m.All {
+			if !sub.Match(unit) {
+				return false
+			}
+		}
+		return true
+	case m.Any != nil:
+		for _, sub := range m.Any {
+			if sub.Match(unit) {
+				return true
+			}
+		}
+		return false
+	case m.Not != nil:
+		return !m.Not.Match(unit)
+	}
+
+	section := m.Section
+	if section == "" {
+		section = unit.TypeSection()
+	}
+	directives := unit.GetDirectives(section, m.Key)
+	switch {
+	case m.Present:
+		return len(directives) > 0
+	case m.Absent:
+		return len(directives) == 0
+	case len(directives) == 0:
+		return false
+	}
+
+	value := directives[len(directives)-1].Value
+	switch {
+	case m.Equals != nil:
+		return value == *m.Equals
+	case m.Regex != nil:
+		return m.Regex.MatchString(value)
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return false
+	}
+	return (m.GT == nil || n > *m.GT) &&
+		(m.GE == nil || n >= *m.GE) &&
+		(m.LT == nil || n < *m.LT) &&
+		(m.LE == nil || n <= *m.LE)
+}
+
+// checkFields returns an error for the first key of a mapping node that is
+// not one of fields
+func checkFields(node *yaml.Node, fields []string) error {
+	if node.Kind != yaml.MappingNode {
+		return lineError(node, "expected a mapping")
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		known := false
+		for _, f := range fields {
+			if key.Value == f {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return lineError(key, "unknown field %q, use %s", key.Value, strings.Join(fields, ", "))
+		}
+	}
+	return nil
+}
+
+// valueOf returns the value of key in a mapping node, or the node itself
+// when it has no such key, to point errors at
+func valueOf(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return node
+}
+
+// lineError is an error found at a node of a rule file. Load adds the file
+// name.
+func lineError(node *yaml.Node, format string, args ...any) error {
+	return &Error{Line: node.Line, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,18 @@
+package rules
+
+// ExampleRule is implemented by rules that show a unit snippet they report
+// and the same snippet fixed. Both are unit file fragments with their
+// section headers.
+type ExampleRule interface {
+	ExampleBad() string
+	ExampleGood() string
+}
+
+// Examples returns a rule's reported and fixed snippets, or "" for both when
+// it has none
+func Examples(rule Rule) (bad, good string) {
+	if e, ok := rule.(ExampleRule); ok {
+		return e.ExampleBad(), e.ExampleGood()
+	}
+	return "", ""
+}
@@ -0,0 +1,166 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/timing"
+)
+
+// ParamKind is the type of a rule parameter's value
+type ParamKind int
+
+const (
+	// ParamInt is a count, such as the number of ExecStartPre= commands
+	ParamInt ParamKind = iota
+	// ParamDuration is a systemd time span, such as 5min or 90s
+	ParamDuration
+)
+
+func (k ParamKind) String() string {
+	if k == ParamDuration {
+		return "duration"
+	}
+	return "integer"
+}
+
+// Param is a threshold of a rule that the configuration file can change for
+// a site, such as the longest start timeout PERF005 accepts
+type Param struct {
+	Name        string
+	Kind        ParamKind
+	Default     string
+	Description string
+}
+
+// ParamRule is implemented by rules with parameters. Check reads their
+// values with Context.IntParam and Context.DurationParam.
+type ParamRule interface {
+	Params() []Param
+}
+
+// Params returns the parameters of a rule, nil for rules without any
+func Params(rule Rule) []Param {
+	if p, ok := rule.(ParamRule); ok {
+		return p.Params()
+	}
+	return nil
+}
+
+// LookupParam returns the parameter of a registered rule by name. The error
+// names the rule's parameters when it has no such one.
+func LookupParam(ruleID, name string) (Param, error) {
+	rule := Get(ruleID)
+	if rule == nil {
+		return Param{}, fmt.Errorf("unknown rule %q", ruleID)
+	}
+	params := Params(rule)
+	var names []string
+	for _, p := range params {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	if len(names) == 0 {
+		return Param{}, fmt.Errorf("%s has no parameters", ruleID)
+	}
+	return Param{}, fmt.Errorf("%s has no parameter %q (use %s)", ruleID, name, strings.Join(names, ", "))
+}
+
+// Parse checks a value for the parameter. Counts and durations may not be
+// negative, and a duration without a unit is in seconds, as in unit files.
+func (p Param) Parse(value string) error {
+	switch p.Kind {
+	case ParamDuration:
+		if strings.HasPrefix(strings.TrimSpace(value), "-") {
+			return fmt.Errorf("%s: %q is negative", p.Name, value)
+		}
+		if _, err := timing.ParseDuration(value); err != nil {
+			return fmt.Errorf("%s: %q is not a duration, such as 90s or 5min", p.Name, value)
+		}
+	default:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a whole number", p.Name, value)
+		}
+		if n < 0 {
+			return fmt.Errorf("%s: %d is negative", p.Name, n)
+		}
+	}
+	return nil
+}
+
+// SetParam sets a parameter of a rule for the scan, checking the rule has
+// it and the value fits its kind
+func (c *Config) SetParam(ruleID, name, value string) error {
+	p, err := LookupParam(ruleID, name)
+	if err != nil {
+		return err
+	}
+	if err := p.Parse(value); err != nil {
+		return fmt.Errorf("%s.%w", ruleID, err)
+	}
+	if c.Params == nil {
+		c.Params = make(map[string]map[string]string)
+	}
+	if c.Params[ruleID] == nil {
+		c.Params[ruleID] = make(map[string]string)
+	}
+	c.Params[ruleID][name] = strings.TrimSpace(value)
+	return nil
+}
+
+// SetParams sets the parameters of several rules, by rule ID and name, in
+// the order of the IDs and names so that the first error is always the same
+func (c *Config) SetParams(params map[string]map[string]string) error {
+	ids := make([]string, 0, len(params))
+	for id := range params {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		names := make([]string, 0, len(params[id]))
+		for name := range params[id] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := c.SetParam(id, name, params[id][name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ParamValue returns the value of a rule's parameter for the scan: the one
+// the configuration sets, or else the rule's default
+func (c *Config) ParamValue(rule Rule, name string) string {
+	if c != nil {
+		if value, ok := c.Params[rule.ID()][name]; ok {
+			return value
+		}
+	}
+	for _, p := range Params(rule) {
+		if p.Name == name {
+			return p.Default
+		}
+	}
+	panic("rule " + rule.ID() + " has no parameter " + name)
+}
+
+// IntParam returns the value of a count parameter of rule
+func (c *Context) IntParam(rule Rule, name string) int {
+	n, _ := strconv.Atoi(c.Config.ParamValue(rule, name))
+	return n
+}
+
+// DurationParam returns the value of a duration parameter of rule
+func (c *Context) DurationParam(rule Rule, name string) time.Duration {
+	d, _ := timing.ParseDuration(c.Config.ParamValue(rule, name))
+	return d
+}
@@ -1,10 +1,15 @@
 package performance
 
 import (
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/supabase/sdaudit/internal/cgroup"
 	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -14,6 +19,9 @@ func init() {
 	rules.Register(&PERF003{})
 	rules.Register(&PERF004{})
 	rules.Register(&PERF005{})
+	rules.Register(&PERF006{})
+	rules.Register(&PERF007{})
+	rules.Register(&PERF008{})
 }
 
 // PERF001 - Service in boot path not optimized
@@ -31,11 +39,10 @@ func (r *PERF001) Suggestion() string       { return "Consider socket activation
 func (r *PERF001) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html"}
 }
+func (r *PERF001) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
+func (r *PERF001) AppliesTo() []string            { return []string{"service"} }
 func (r *PERF001) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
 	wantedBy := unit.GetDirective("Install", "WantedBy")
 	if !strings.Contains(wantedBy, "multi-user.target") && !strings.Contains(wantedBy, "default.target") {
 		return nil
@@ -67,14 +74,15 @@ func (r *PERF002) Suggestion() string {
 func (r *PERF002) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#ExecStartPre="}
 }
+func (r *PERF002) AppliesTo() []string { return []string{"service"} }
+func (r *PERF002) Params() []rules.Param {
+	return []rules.Param{{Name: "max_exec_start_pre", Kind: rules.ParamInt, Default: "3", Description: "Most ExecStartPre= commands accepted"}}
+}
 func (r *PERF002) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
 	preCmds := unit.GetDirectives("Service", "ExecStartPre")
-	if len(preCmds) > 3 {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service has " + strconv.Itoa(len(preCmds)) + " ExecStartPre commands.", Suggestion: r.Suggestion(), References: r.References()}}
+	if len(preCmds) > ctx.IntParam(r, "max_exec_start_pre") {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service has " + strconv.Itoa(len(preCmds)) + " ExecStartPre commands.", Suggestion: r.Suggestion(), References: r.References(), Directive: "ExecStartPre", Section: "Service"}}
 	}
 	return nil
 }
@@ -132,35 +140,321 @@ func (r *PERF005) Suggestion() string       { return "Reduce TimeoutStartSec to
 func (r *PERF005) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#TimeoutStartSec="}
 }
+func (r *PERF005) AppliesTo() []string { return []string{"service"} }
+func (r *PERF005) Params() []rules.Param {
+	return []rules.Param{{Name: "max_timeout", Kind: rules.ParamDuration, Default: "5min", Description: "Longest TimeoutStartSec= accepted"}}
+}
 func (r *PERF005) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
 	timeout := unit.GetDirective("Service", "TimeoutStartSec")
 	if timeout == "" || timeout == "infinity" {
 		return nil
 	}
-	// Parse and check if > 5 minutes
-	seconds := parseTime(timeout)
-	if seconds > 300 {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "TimeoutStartSec=" + timeout + " is very long.", Suggestion: r.Suggestion(), References: r.References()}}
+	limit := ctx.DurationParam(r, "max_timeout")
+	if d, err := timing.ParseDuration(timeout); err == nil && d > limit {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "TimeoutStartSec=" + timeout + " is very long.", Suggestion: r.Suggestion(), References: r.References(), Directive: "TimeoutStartSec", Value: timeout, Section: "Service", Expected: ctx.Config.ParamValue(r, "max_timeout")}}
 	}
 	return nil
 }
 
-func parseTime(s string) float64 {
-	s = strings.TrimSpace(s)
-	multipliers := map[string]float64{"ms": 0.001, "s": 1, "sec": 1, "m": 60, "min": 60, "h": 3600}
-	for suffix, mult := range multipliers {
-		if strings.HasSuffix(s, suffix) {
-			if v, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64); err == nil {
-				return v * mult
+// PERF006 - DefaultTimeoutStartSec raised globally
+type PERF006 struct{}
+
+func (r *PERF006) ID() string   { return "PERF006" }
+func (r *PERF006) Name() string { return "DefaultTimeoutStartSec raised globally" }
+func (r *PERF006) Description() string {
+	return "A long global start timeout delays failure detection for every unit without its own TimeoutStartSec."
+}
+func (r *PERF006) Category() types.Category { return types.CategoryPerformance }
+func (r *PERF006) Severity() types.Severity { return types.SeverityMedium }
+func (r *PERF006) Tags() []string           { return []string{"timeout", "startup", "system.conf"} }
+func (r *PERF006) Suggestion() string {
+	return "Restore DefaultTimeoutStartSec= to 90s and set TimeoutStartSec= on the individual slow units instead."
+}
+func (r *PERF006) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd-system.conf.html#DefaultTimeoutStartSec="}
+}
+func (r *PERF006) Check(ctx *rules.Context) []types.Issue {
+	// Host-wide only, see CheckHost
+	return nil
+}
+func (r *PERF006) CheckHost(ctx *rules.Context) []types.Issue {
+	conf := ctx.SystemConfig
+	if conf == nil {
+		return nil
+	}
+	source, overridden := conf.Sources["DefaultTimeoutStartSec"]
+	if !overridden {
+		return nil
+	}
+	// A zero timeout means infinity
+	if conf.DefaultTimeoutStartSec != 0 && conf.DefaultTimeoutStartSec <= 300*time.Second {
+		return nil
+	}
+	line := source.Line
+	return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: filepath.Base(source.File), File: source.File, Line: &line, Description: "DefaultTimeoutStartSec=" + timing.FormatDuration(conf.DefaultTimeoutStartSec) + " applies to every unit without its own timeout.", Suggestion: r.Suggestion(), References: r.References(), Directive: "DefaultTimeoutStartSec", Value: timing.FormatDuration(conf.DefaultTimeoutStartSec), Section: "Manager", Expected: "90s"}}
+}
+
+// PERF007 - Memory setting conflicts with the enclosing slice
+type PERF007 struct{}
+
+func (r *PERF007) ID() string   { return "PERF007" }
+func (r *PERF007) Name() string { return "Memory setting exceeds its slice" }
+func (r *PERF007) Description() string {
+	return "A memory limit above its slice's limit is unreachable, and a memory protection above its slice's protection is silently lost."
+}
+func (r *PERF007) Category() types.Category { return types.CategoryPerformance }
+func (r *PERF007) Severity() types.Severity { return types.SeverityMedium }
+func (r *PERF007) Tags() []string           { return []string{"cgroup", "memory", "slice"} }
+func (r *PERF007) Suggestion() string {
+	return "Raise the slice's setting or lower the unit's, or move the unit to a slice sized for it with Slice=."
+}
+func (r *PERF007) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.resource-control.html#MemoryMax=bytes"}
+}
+func (r *PERF007) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
+
+// memorySettings are the memory directives compared against parent slices.
+// Limits cap usage; protections are only honored up to the parent's protection.
+var memorySettings = []struct {
+	key        string
+	protection bool
+}{
+	{"MemoryMax", false},
+	{"MemoryHigh", false},
+	{"MemoryMin", true},
+	{"MemoryLow", true},
+}
+
+func (r *PERF007) AppliesTo() []string { return []string{"service", "slice"} }
+
+func (r *PERF007) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	// Without any slice setting memory there is nothing to conflict with
+	if !slicesSetMemory(ctx.AllUnits) {
+		return nil
+	}
+
+	section := cgroup.ResourceSection(unit)
+	chain := cgroup.SliceChain(unit)
+	var issues []types.Issue
+	for _, setting := range memorySettings {
+		directives := unit.GetDirectives(section, setting.key)
+		if len(directives) == 0 {
+			continue
+		}
+		d := directives[len(directives)-1]
+		value, err := cgroup.ParseMemory(d.Value)
+		if err != nil {
+			continue
+		}
+
+		for _, sliceName := range chain {
+			slice, ok := ctx.FindUnit(sliceName)
+			if !ok {
+				continue
+			}
+			parentValue := slice.GetDirective("Slice", setting.key)
+			parent, err := cgroup.ParseMemory(parentValue)
+			if err != nil {
+				continue
 			}
+			if exceeds, ok := value.Exceeds(parent); !ok || !exceeds {
+				continue
+			}
+
+			consequence := "so " + unit.Name + " can never reach its own limit."
+			if setting.protection {
+				consequence = "so protection above " + parentValue + " is silently lost."
+			}
+			line := d.Line
+			issues = append(issues, types.Issue{
+				RuleID:      r.ID(),
+				RuleName:    r.Name(),
+				Severity:    r.Severity(),
+				Category:    r.Category(),
+				Tags:        r.Tags(),
+				Unit:        unit.Name,
+				File:        unit.Path,
+				Line:        &line,
+				Description: setting.key + "=" + d.Value + " on " + unit.Name + " exceeds " + setting.key + "=" + parentValue + " on its slice " + sliceName + ", " + consequence,
+				Suggestion:  r.Suggestion(),
+				References:  r.References(),
+				Directive:   setting.key,
+				Value:       d.Value,
+				Section:     section,
+				Expected:    parentValue,
+			})
+			break
 		}
 	}
-	if v, err := strconv.ParseFloat(s, 64); err == nil {
-		return v
+	return issues
+}
+
+// slicesSetMemory reports whether any loaded slice other than the root sets a memory directive
+func slicesSetMemory(units map[string]*types.UnitFile) bool {
+	for name, unit := range units {
+		if unit.Type != "slice" || name == cgroup.RootSlice {
+			continue
+		}
+		for _, setting := range memorySettings {
+			if unit.HasDirective("Slice", setting.key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PERF008 - Timers firing in the same minute
+type PERF008 struct{}
+
+func (r *PERF008) ID() string   { return "PERF008" }
+func (r *PERF008) Name() string { return "Schedule collision" }
+func (r *PERF008) Description() string {
+	return "Timers whose OnCalendar= schedules fire in the same minute start their services together and compete for CPU and I/O."
+}
+func (r *PERF008) Category() types.Category { return types.CategoryPerformance }
+func (r *PERF008) Severity() types.Severity { return types.SeverityInfo }
+func (r *PERF008) Tags() []string           { return []string{"timer", "scheduling"} }
+func (r *PERF008) Suggestion() string {
+	return "Stagger the OnCalendar= schedules, or add RandomizedDelaySec= so the timers spread their triggers."
+}
+func (r *PERF008) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.timer.html#RandomizedDelaySec="}
+}
+func (r *PERF008) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
+func (r *PERF008) Check(ctx *rules.Context) []types.Issue {
+	// Host-wide only, see CheckHost
+	return nil
+}
+
+// minScheduleCollision is the number of timers that must fire in the same
+// minute to be reported. Two timers sharing "daily" are common and harmless.
+const minScheduleCollision = 3
+
+// scheduleCollision is a set of timers firing together in some minutes
+type scheduleCollision struct {
+	timers []string
+	count  int
+	first  time.Time
+	zone   string
+}
+
+// CheckHost compares the fire times of every timer over a year at minute
+// granularity. Timers with RandomizedDelaySec= already spread their triggers
+// and schedules firing more often than hourly collide with everything, so
+// both are left out.
+func (r *PERF008) CheckHost(ctx *rules.Context) []types.Issue {
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	var names []string
+	for name, unit := range ctx.AllUnits {
+		if unit.IsTimer() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// firing maps a zone and minute to the timers firing in it, in name order
+	firing := make(map[string][]string)
+	lines := make(map[string]int)
+	for _, name := range names {
+		unit := ctx.AllUnits[name]
+		if d, err := timing.ParseDuration(unit.GetDirective("Timer", "RandomizedDelaySec")); err == nil && d > 0 {
+			continue
+		}
+		for _, d := range unit.GetDirectives("Timer", "OnCalendar") {
+			if interval, ok := timing.CalendarInterval(d.Value); ok && interval < time.Hour {
+				continue
+			}
+			calendar, err := timing.ParseCalendar(d.Value)
+			if err != nil {
+				continue
+			}
+			for _, minute := range calendar.FireMinutes(from, to) {
+				key := calendar.Zone + "|" + minute.Format(time.RFC3339)
+				if timers := firing[key]; len(timers) == 0 || timers[len(timers)-1] != name {
+					firing[key] = append(timers, name)
+				}
+			}
+			if _, ok := lines[name]; !ok {
+				lines[name] = d.Line
+			}
+		}
+	}
+
+	collisions := make(map[string]*scheduleCollision)
+	for key, timers := range firing {
+		if len(timers) < minScheduleCollision {
+			continue
+		}
+		zone, stamp, _ := strings.Cut(key, "|")
+		minute, _ := time.Parse(time.RFC3339, stamp)
+		set := strings.Join(timers, " ")
+		c, ok := collisions[set]
+		if !ok {
+			c = &scheduleCollision{timers: timers, first: minute, zone: zone}
+			collisions[set] = c
+		}
+		c.count++
+		if minute.Before(c.first) {
+			c.first = minute
+		}
+	}
+
+	var issues []types.Issue
+	for _, c := range collisions {
+		if containedInOther(c, collisions) {
+			continue
+		}
+		unit := ctx.AllUnits[c.timers[0]]
+		at := c.first.Format("15:04 on Mon 2006-01-02")
+		if c.zone != "" {
+			at += " " + c.zone
+		}
+		line := lines[unit.Name]
+		issues = append(issues, types.Issue{
+			RuleID:      r.ID(),
+			RuleName:    r.Name(),
+			Severity:    r.Severity(),
+			Category:    r.Category(),
+			Tags:        r.Tags(),
+			Unit:        unit.Name,
+			File:        unit.Path,
+			Line:        &line,
+			Description: strings.Join(c.timers[:len(c.timers)-1], ", ") + " and " + c.timers[len(c.timers)-1] + " fire in the same minute " + strconv.Itoa(c.count) + " times a year, for example at " + at + ".",
+			Suggestion:  r.Suggestion(),
+			References:  r.References(),
+			Directive:   "OnCalendar",
+			Section:     "Timer",
+		})
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Description < issues[j].Description
+	})
+	return issues
+}
+
+// containedInOther reports whether another collision includes every timer of c
+func containedInOther(c *scheduleCollision, collisions map[string]*scheduleCollision) bool {
+	for _, other := range collisions {
+		if len(other.timers) <= len(c.timers) {
+			continue
+		}
+		included := 0
+		for _, name := range c.timers {
+			for _, o := range other.timers {
+				if o == name {
+					included++
+					break
+				}
+			}
+		}
+		if included == len(c.timers) {
+			return true
+		}
 	}
-	return 0
+	return false
 }
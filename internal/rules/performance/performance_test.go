@@ -1,9 +1,11 @@
 package performance
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -219,30 +221,310 @@ func TestPERF005_TimeoutStartSec(t *testing.T) {
 	}
 }
 
-func TestParseTime(t *testing.T) {
+func TestPERF005_StructuredFields(t *testing.T) {
+	unit := makeTestUnit(map[string]string{"TimeoutStartSec": "10min"}, nil, nil)
+	issues := (&PERF005{}).Check(rules.NewContext(unit))
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1", len(issues))
+	}
+	issue := issues[0]
+	if issue.Directive != "TimeoutStartSec" || issue.Value != "10min" || issue.Section != "Service" || issue.Expected != "5min" {
+		t.Errorf("directive, value, section, expected = %q, %q, %q, %q", issue.Directive, issue.Value, issue.Section, issue.Expected)
+	}
+}
+
+func TestPERF006_GlobalTimeoutStartSec(t *testing.T) {
+	rule := &PERF006{}
+
+	tests := []struct {
+		name       string
+		content    string
+		wantIssues int
+	}{
+		{
+			name:       "defaults",
+			content:    "[Manager]\n#DefaultTimeoutStartSec=90s\n",
+			wantIssues: 0,
+		},
+		{
+			name:       "lowered",
+			content:    "[Manager]\nDefaultTimeoutStartSec=30s\n",
+			wantIssues: 0,
+		},
+		{
+			name:       "at threshold",
+			content:    "[Manager]\nDefaultTimeoutStartSec=5min\n",
+			wantIssues: 0,
+		},
+		{
+			name:       "raised",
+			content:    "[Manager]\nDefaultTimeoutStartSec=10min\n",
+			wantIssues: 1,
+		},
+		{
+			name:       "infinity",
+			content:    "[Manager]\nDefaultTimeoutStartSec=infinity\n",
+			wantIssues: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := timing.ParseSystemConfig("/etc/systemd/system.conf", tt.content)
+			if err != nil {
+				t.Fatalf("ParseSystemConfig failed: %v", err)
+			}
+			ctx := rules.NewHostContext(nil)
+			ctx.SystemConfig = conf
+			issues := rule.CheckHost(ctx)
+
+			if len(issues) != tt.wantIssues {
+				t.Errorf("got %d issues, want %d", len(issues), tt.wantIssues)
+			}
+			if len(issues) > 0 && (issues[0].Line == nil || *issues[0].Line != 2) {
+				t.Errorf("issue should point at line 2 of system.conf, got %v", issues[0].Line)
+			}
+		})
+	}
+
+	// Per-unit checks never report this rule
+	if issues := rule.Check(rules.NewContext(makeTestUnit(nil, nil, nil))); len(issues) != 0 {
+		t.Errorf("Check() returned %d issues, want 0", len(issues))
+	}
+}
+
+func makeTestSlice(name string, directives map[string]string) *types.UnitFile {
+	unit := &types.UnitFile{
+		Name: name,
+		Path: "/etc/systemd/system/" + name,
+		Type: "slice",
+		Sections: map[string]*types.Section{
+			"Slice": {
+				Name:       "Slice",
+				Directives: make(map[string][]types.Directive),
+			},
+		},
+	}
+	for k, v := range directives {
+		unit.Sections["Slice"].Directives[k] = []types.Directive{{Key: k, Value: v, Line: 2}}
+	}
+	return unit
+}
+
+func TestPERF007_MemoryExceedsSlice(t *testing.T) {
+	rule := &PERF007{}
+
+	tests := []struct {
+		name       string
+		unit       map[string]string
+		slices     []*types.UnitFile
+		wantIssues int
+		wantSlice  string
+	}{
+		{
+			name:       "no slice sets memory",
+			unit:       map[string]string{"MemoryMax": "2G"},
+			slices:     []*types.UnitFile{makeTestSlice("system.slice", nil)},
+			wantIssues: 0,
+		},
+		{
+			name:       "limit exceeds default slice",
+			unit:       map[string]string{"MemoryMax": "2G"},
+			slices:     []*types.UnitFile{makeTestSlice("system.slice", map[string]string{"MemoryMax": "1G"})},
+			wantIssues: 1,
+			wantSlice:  "system.slice",
+		},
+		{
+			name:       "limit within slice",
+			unit:       map[string]string{"MemoryMax": "512M"},
+			slices:     []*types.UnitFile{makeTestSlice("system.slice", map[string]string{"MemoryMax": "1G"})},
+			wantIssues: 0,
+		},
+		{
+			name:       "innermost exceeded slice is named",
+			unit:       map[string]string{"Slice": "app-web.slice", "MemoryHigh": "3G"},
+			slices:     []*types.UnitFile{makeTestSlice("app-web.slice", nil), makeTestSlice("app.slice", map[string]string{"MemoryHigh": "2G"})},
+			wantIssues: 1,
+			wantSlice:  "app.slice",
+		},
+		{
+			name:       "protection exceeds slice",
+			unit:       map[string]string{"MemoryLow": "512M", "MemoryMin": "64M"},
+			slices:     []*types.UnitFile{makeTestSlice("system.slice", map[string]string{"MemoryLow": "256M", "MemoryMin": "128M"})},
+			wantIssues: 1,
+			wantSlice:  "system.slice",
+		},
+		{
+			name:       "percentages compared",
+			unit:       map[string]string{"MemoryMax": "60%"},
+			slices:     []*types.UnitFile{makeTestSlice("system.slice", map[string]string{"MemoryMax": "50%"})},
+			wantIssues: 1,
+			wantSlice:  "system.slice",
+		},
+		{
+			name:       "percentage against bytes is not compared",
+			unit:       map[string]string{"MemoryMax": "60%"},
+			slices:     []*types.UnitFile{makeTestSlice("system.slice", map[string]string{"MemoryMax": "1G"})},
+			wantIssues: 0,
+		},
+		{
+			name:       "infinity defers to slice",
+			unit:       map[string]string{"MemoryMax": "infinity"},
+			slices:     []*types.UnitFile{makeTestSlice("system.slice", map[string]string{"MemoryMax": "1G"})},
+			wantIssues: 0,
+		},
+		{
+			name:       "root slice does not count",
+			unit:       map[string]string{"MemoryMax": "2G"},
+			slices:     []*types.UnitFile{makeTestSlice("-.slice", map[string]string{"MemoryMax": "1G"})},
+			wantIssues: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(tt.unit, nil, nil)
+			allUnits := map[string]*types.UnitFile{unit.Name: unit}
+			for _, slice := range tt.slices {
+				allUnits[slice.Name] = slice
+			}
+
+			issues := rule.Check(rules.NewContextWithUnits(unit, allUnits))
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("got %d issues, want %d: %+v", len(issues), tt.wantIssues, issues)
+			}
+			if tt.wantSlice != "" && !strings.Contains(issues[0].Description, "its slice "+tt.wantSlice) {
+				t.Errorf("description should name %s: %s", tt.wantSlice, issues[0].Description)
+			}
+		})
+	}
+
+	t.Run("slice exceeding its parent slice", func(t *testing.T) {
+		child := makeTestSlice("app-web.slice", map[string]string{"MemoryMax": "4G"})
+		parent := makeTestSlice("app.slice", map[string]string{"MemoryMax": "2G"})
+		allUnits := map[string]*types.UnitFile{child.Name: child, parent.Name: parent}
+
+		issues := rule.Check(rules.NewContextWithUnits(child, allUnits))
+		if len(issues) != 1 || issues[0].Unit != "app-web.slice" {
+			t.Errorf("got %+v, want one issue on app-web.slice", issues)
+		}
+	})
+}
+
+func makeTestTimer(name string, directives map[string]string) *types.UnitFile {
+	unit := &types.UnitFile{
+		Name: name,
+		Path: "/etc/systemd/system/" + name,
+		Type: "timer",
+		Sections: map[string]*types.Section{
+			"Timer": {
+				Name:       "Timer",
+				Directives: make(map[string][]types.Directive),
+			},
+		},
+	}
+	for k, v := range directives {
+		unit.Sections["Timer"].Directives[k] = []types.Directive{{Key: k, Value: v, Line: 2}}
+	}
+	return unit
+}
+
+func TestPERF008_ScheduleCollision(t *testing.T) {
+	rule := &PERF008{}
+
 	tests := []struct {
-		input string
-		want  float64
+		name     string
+		timers   map[string]map[string]string
+		wantText []string
 	}{
-		{"0", 0},
-		{"1", 1},
-		{"60", 60},
-		{"1s", 1},
-		{"1sec", 1},
-		{"100ms", 0.1},
-		{"1m", 60},
-		{"1min", 60},
-		{"1h", 3600},
-		{"5m", 300},
-		{"10min", 600},
-		{"invalid", 0},
+		{
+			name: "two timers at midnight",
+			timers: map[string]map[string]string{
+				"backup.timer": {"OnCalendar": "daily"},
+				"report.timer": {"OnCalendar": "*-*-* 00:00:00"},
+			},
+		},
+		{
+			name: "three equivalent expressions",
+			timers: map[string]map[string]string{
+				"backup.timer": {"OnCalendar": "daily"},
+				"report.timer": {"OnCalendar": "*-*-* 00:00:00"},
+				"rotate.timer": {"OnCalendar": "00:00"},
+			},
+			wantText: []string{"backup.timer, report.timer and rotate.timer fire in the same minute 366 times a year", "00:00 on Mon 2024-01-01"},
+		},
+		{
+			name: "collision on Mondays only",
+			timers: map[string]map[string]string{
+				"backup.timer": {"OnCalendar": "daily"},
+				"report.timer": {"OnCalendar": "weekly"},
+				"rotate.timer": {"OnCalendar": "Mon,Thu 00:00"},
+				"clean.timer":  {"OnCalendar": "Tue 00:00"},
+			},
+			wantText: []string{"backup.timer, report.timer and rotate.timer fire in the same minute 53 times a year"},
+		},
+		{
+			name: "subset collisions reported once",
+			timers: map[string]map[string]string{
+				"backup.timer": {"OnCalendar": "daily"},
+				"report.timer": {"OnCalendar": "daily"},
+				"rotate.timer": {"OnCalendar": "daily"},
+				"scrub.timer":  {"OnCalendar": "monthly"},
+			},
+			wantText: []string{"backup.timer, report.timer, rotate.timer and scrub.timer fire in the same minute 12 times a year"},
+		},
+		{
+			name: "randomized delay",
+			timers: map[string]map[string]string{
+				"backup.timer": {"OnCalendar": "daily"},
+				"report.timer": {"OnCalendar": "daily"},
+				"rotate.timer": {"OnCalendar": "daily", "RandomizedDelaySec": "1h"},
+			},
+		},
+		{
+			name: "frequent timer",
+			timers: map[string]map[string]string{
+				"backup.timer": {"OnCalendar": "daily"},
+				"report.timer": {"OnCalendar": "daily"},
+				"poll.timer":   {"OnCalendar": "*:0/5"},
+			},
+		},
+		{
+			name: "different zones",
+			timers: map[string]map[string]string{
+				"backup.timer": {"OnCalendar": "daily"},
+				"report.timer": {"OnCalendar": "daily"},
+				"rotate.timer": {"OnCalendar": "*-*-* 00:00:00 Europe/Berlin"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			got := parseTime(tt.input)
-			if got != tt.want {
-				t.Errorf("parseTime(%q) = %v, want %v", tt.input, got, tt.want)
+		t.Run(tt.name, func(t *testing.T) {
+			units := make(map[string]*types.UnitFile)
+			for name, directives := range tt.timers {
+				units[name] = makeTestTimer(name, directives)
+			}
+			issues := rule.CheckHost(rules.NewHostContext(units))
+
+			// Each case expects a single collision or none
+			wantIssues := 0
+			if len(tt.wantText) > 0 {
+				wantIssues = 1
+			}
+			if len(issues) != wantIssues {
+				t.Fatalf("got %d issues, want %d: %+v", len(issues), wantIssues, issues)
+			}
+			if wantIssues == 0 {
+				return
+			}
+			if issues[0].Unit != "backup.timer" || issues[0].Line == nil || *issues[0].Line != 2 {
+				t.Errorf("issue on %s line %v, want backup.timer line 2", issues[0].Unit, issues[0].Line)
+			}
+			for _, text := range tt.wantText {
+				if !strings.Contains(issues[0].Description, text) {
+					t.Errorf("description %q should contain %q", issues[0].Description, text)
+				}
 			}
 		})
 	}
@@ -255,6 +537,9 @@ func TestRuleMetadata(t *testing.T) {
 		&PERF003{},
 		&PERF004{},
 		&PERF005{},
+		&PERF006{},
+		&PERF007{},
+		&PERF008{},
 	}
 
 	for _, rule := range testRules {
@@ -298,10 +583,15 @@ func TestNonServiceUnit(t *testing.T) {
 		},
 	}
 
-	ctx := rules.NewContext(unit)
-	issues := rule.Check(ctx)
-
-	if len(issues) != 0 {
-		t.Errorf("Performance rules should not apply to non-service units, got %d issues", len(issues))
+	if rules.AppliesTo(rule, unit) {
+		t.Errorf("%s should not apply to %s units", rule.ID(), unit.Type)
+	}
+	if !rules.AppliesTo(rule, &types.UnitFile{Name: "test.service", Type: "service"}) {
+		t.Errorf("%s should apply to service units", rule.ID())
+	}
+	for _, issue := range rules.RunAll(rules.NewContext(unit)) {
+		if issue.RuleID == rule.ID() {
+			t.Errorf("the runner should skip %s on %s units", rule.ID(), unit.Type)
+		}
 	}
 }
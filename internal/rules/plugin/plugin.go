@@ -0,0 +1,294 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// DefaultTimeout is how long a plugin may take for one request when Options
+// does not say
+const DefaultTimeout = 10 * time.Second
+
+// maxOutput is the most a plugin may write to stdout for one request
+const maxOutput = 4 << 20
+
+// EnvPrefix starts the names of the environment variables passed on to
+// plugins. Other variables, apart from the basics in passedEnv, are not, so
+// credentials in sdaudit's environment do not leak to plugins.
+const EnvPrefix = "SDAUDIT_PLUGIN_"
+
+var passedEnv = []string{"PATH", "HOME", "TMPDIR", "LANG", "LC_ALL", "TZ"}
+
+var idPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_-]*$`)
+
+// Options configures how plugins are run
+type Options struct {
+	// Timeout bounds each request, DefaultTimeout if zero
+	Timeout time.Duration
+	// OnError is called when a check fails, such as on a timeout or an
+	// invalid response. The unit is then left without the plugin's issues.
+	// Nil ignores failures.
+	OnError func(error)
+}
+
+// Plugin is a loaded plugin program
+type Plugin struct {
+	// Name is the name the plugin gave itself
+	Name string
+	// Path is the program's path
+	Path string
+	// Rules are the plugin's rules, to register
+	Rules []*Rule
+
+	opts Options
+
+	mu     sync.Mutex
+	unit   *types.UnitFile
+	raw    string
+	issues map[string][]IssueSpec
+}
+
+// Load starts the program at path to describe its rules. path may also be a
+// program name looked up in PATH.
+func Load(path string, opts Options) (*Plugin, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	p := &Plugin{Path: resolved, opts: opts}
+
+	var desc Description
+	if err := p.call(Request{Protocol: ProtocolVersion, Request: "describe"}, &desc); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	switch {
+	case desc.Protocol != ProtocolVersion:
+		return nil, fmt.Errorf("plugin %s: speaks protocol version %d, sdaudit speaks %d", path, desc.Protocol, ProtocolVersion)
+	case desc.Name == "":
+		return nil, fmt.Errorf("plugin %s: describe response has no name", path)
+	case len(desc.Rules) == 0:
+		return nil, fmt.Errorf("plugin %s: describes no rules", path)
+	}
+	p.Name = desc.Name
+
+	seen := make(map[string]bool)
+	for _, spec := range desc.Rules {
+		rule, err := p.newRule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", desc.Name, err)
+		}
+		if seen[spec.ID] {
+			return nil, fmt.Errorf("plugin %s: rule %s is described twice", desc.Name, spec.ID)
+		}
+		if rules.Get(spec.ID) != nil {
+			return nil, fmt.Errorf("plugin %s: rule %s is already a registered rule", desc.Name, spec.ID)
+		}
+		seen[spec.ID] = true
+		p.Rules = append(p.Rules, rule)
+	}
+	return p, nil
+}
+
+func (p *Plugin) newRule(spec RuleSpec) (*Rule, error) {
+	if !idPattern.MatchString(spec.ID) {
+		return nil, fmt.Errorf("rule id %q must be upper case letters, digits, '-' or '_'", spec.ID)
+	}
+	if spec.Name == "" {
+		return nil, fmt.Errorf("rule %s has no name", spec.ID)
+	}
+	category := types.ParseCategory(spec.Category)
+	if category.String() != spec.Category {
+		return nil, fmt.Errorf("rule %s: unknown category %q, use security, performance, reliability or bestpractice", spec.ID, spec.Category)
+	}
+	severity := types.ParseSeverity(spec.Severity)
+	if severity.String() != spec.Severity {
+		return nil, fmt.Errorf("rule %s: unknown severity %q, use critical, high, medium, low or info", spec.ID, spec.Severity)
+	}
+	return &Rule{
+		BaseRule: rules.BaseRule{
+			RuleID:          spec.ID,
+			RuleName:        spec.Name,
+			RuleDescription: spec.Description,
+			RuleCategory:    category,
+			RuleSeverity:    severity,
+			RuleTags:        spec.Tags,
+			RuleSuggestion:  spec.Suggestion,
+			RuleReferences:  spec.References,
+		},
+		plugin:    p,
+		unitTypes: spec.UnitTypes,
+	}, nil
+}
+
+// check returns the plugin's issues for a unit by rule ID. The plugin runs
+// once per unit; the rules of the plugin share its answer.
+func (p *Plugin) check(unit *types.UnitFile) map[string][]IssueSpec {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.unit == unit && p.raw == unit.Raw {
+		return p.issues
+	}
+
+	issues, err := p.checkUnit(unit)
+	if err != nil {
+		if p.opts.OnError != nil {
+			p.opts.OnError(fmt.Errorf("plugin %s: %s: %w", p.Name, unit.Name, err))
+		}
+		issues = nil
+	}
+	p.unit, p.raw, p.issues = unit, unit.Raw, issues
+	return issues
+}
+
+func (p *Plugin) checkUnit(unit *types.UnitFile) (map[string][]IssueSpec, error) {
+	var resp Response
+	if err := p.call(Request{Protocol: ProtocolVersion, Request: "check", Unit: NewUnit(unit)}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Protocol != ProtocolVersion {
+		return nil, fmt.Errorf("answered with protocol version %d, sdaudit speaks %d", resp.Protocol, ProtocolVersion)
+	}
+
+	known := make(map[string]bool, len(p.Rules))
+	for _, r := range p.Rules {
+		known[r.ID()] = true
+	}
+	issues := make(map[string][]IssueSpec)
+	for _, issue := range resp.Issues {
+		if !known[issue.RuleID] {
+			return nil, fmt.Errorf("reported an issue of undescribed rule %q", issue.RuleID)
+		}
+		issues[issue.RuleID] = append(issues[issue.RuleID], issue)
+	}
+	return issues, nil
+}
+
+// call runs the plugin with a request on stdin and decodes its stdout. The
+// plugin runs in /, with only the basic and SDAUDIT_PLUGIN_ environment
+// variables, and is killed after the timeout.
+func (p *Plugin) call(req Request, resp any) error {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.Timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	stdout.limit, stderr.limit = maxOutput, 4096
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Dir = "/"
+	cmd.Env = pluginEnv()
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children that keep the pipes open must not hold up the scan
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Errorf("timed out after %s", p.opts.Timeout)
+	case stdout.exceeded:
+		return fmt.Errorf("wrote more than %d bytes", maxOutput)
+	case err != nil:
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+
+	dec := json.NewDecoder(&stdout.Buffer)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(resp); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// pluginEnv returns the environment plugins run with
+func pluginEnv() []string {
+	env := []string{fmt.Sprintf("%sPROTOCOL=%d", EnvPrefix, ProtocolVersion)}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if name == EnvPrefix+"PROTOCOL" {
+			continue
+		}
+		if strings.HasPrefix(name, EnvPrefix) || slices.Contains(passedEnv, name) {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// limitedBuffer keeps up to limit bytes and drops the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(data []byte) (int, error) {
+	if room := b.limit - b.Len(); len(data) > room {
+		b.exceeded = true
+		if room > 0 {
+			b.Buffer.Write(data[:room])
+		}
+		return 0, errors.New("output limit exceeded")
+	}
+	return b.Buffer.Write(data)
+}
+
+// Rule is a rule implemented by a plugin
+type Rule struct {
+	rules.BaseRule
+	plugin    *Plugin
+	unitTypes []string
+}
+
+// AppliesTo returns the unit types the rule checks, nil for every type
+func (r *Rule) AppliesTo() []string { return r.unitTypes }
+
+// Capabilities marks the rule as running an external program, so quick
+// scans skip it and its issues are never cached
+func (r *Rule) Capabilities() rules.Capability { return rules.CapabilityExternal }
+
+// Origin names the plugin the rule belongs to
+func (r *Rule) Origin() string { return "plugin " + r.plugin.Name }
+
+// Check returns the issues the plugin reported for the unit under this rule
+func (r *Rule) Check(ctx *rules.Context) []types.Issue {
+	if ctx.Unit == nil {
+		return nil
+	}
+	var issues []types.Issue
+	for _, spec := range r.plugin.check(ctx.Unit)[r.ID()] {
+		var line *int
+		if spec.Line > 0 {
+			line = &spec.Line
+		}
+		issue := r.NewIssue(ctx.Unit, spec.Description, line)
+		if spec.Suggestion != "" {
+			issue.Suggestion = spec.Suggestion
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
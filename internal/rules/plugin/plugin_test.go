@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+const describe = `{"protocol":1,"name":"cmdb","rules":[` +
+	`{"id":"CMDB001","name":"Service has no owner","description":"Services must be in the CMDB.","category":"bestpractice","severity":"medium","suggestion":"Register the service.","unit_types":["service"]},` +
+	`{"id":"CMDB002","name":"Service is retired","category":"reliability","severity":"high"}]}`
+
+// writePlugin writes a shell script plugin that answers the describe
+// request with describe and the check request by running check
+func writePlugin(t *testing.T, describe, check string) string {
+	t.Helper()
+	script := "#!/bin/sh\nread -r req\ncase \"$req\" in\n*'\"describe\"'*)\n  echo '" + describe + "' ;;\n*)\n  " + check + " ;;\nesac\n"
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testUnit() *types.UnitFile {
+	return &types.UnitFile{
+		Name: "app.service",
+		Path: "/etc/systemd/system/app.service",
+		Type: "service",
+		Sections: map[string]*types.Section{
+			"Service": {Name: "Service", Directives: map[string][]types.Directive{
+				"ExecStart": {{Key: "ExecStart", Value: "/usr/bin/app", Line: 2}},
+			}},
+		},
+		Raw: "[Service]\nExecStart=/usr/bin/app\n",
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	// The check answer echoes the unit's ExecStart line, to show the unit
+	// was sent
+	check := `case "$req" in *'"line":2'*) line=2 ;; *) line=0 ;; esac; ` +
+		`echo "{\"protocol\":1,\"issues\":[{\"rule_id\":\"CMDB001\",\"description\":\"app is not registered\",\"line\":$line},{\"rule_id\":\"CMDB002\",\"description\":\"retired\",\"suggestion\":\"Remove it.\"}]}"`
+	p, err := Load(writePlugin(t, describe, check), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "cmdb" || len(p.Rules) != 2 {
+		t.Fatalf("loaded %q with %d rules", p.Name, len(p.Rules))
+	}
+	owner, retired := p.Rules[0], p.Rules[1]
+	if owner.Severity() != types.SeverityMedium || retired.Category() != types.CategoryReliability || rules.Origin(owner) != "plugin cmdb" {
+		t.Errorf("rules = %+v, %+v", owner.BaseRule, retired.BaseRule)
+	}
+	if rules.Capabilities(owner) != rules.CapabilityExternal || !rules.AppliesTo(retired, &types.UnitFile{Type: "timer"}) || rules.AppliesTo(owner, &types.UnitFile{Type: "timer"}) {
+		t.Error("plugin rules should be external and keep their unit types")
+	}
+
+	ctx := rules.NewContext(testUnit())
+	issues := owner.Check(ctx)
+	if len(issues) != 1 || issues[0].Description != "app is not registered" || issues[0].Line == nil || *issues[0].Line != 2 || issues[0].Suggestion != "Register the service." {
+		t.Errorf("CMDB001 issues = %+v", issues)
+	}
+	issues = retired.Check(ctx)
+	if len(issues) != 1 || issues[0].Line != nil || issues[0].Suggestion != "Remove it." {
+		t.Errorf("CMDB002 issues = %+v", issues)
+	}
+}
+
+func TestPluginRunsOncePerUnit(t *testing.T) {
+	count := filepath.Join(t.TempDir(), "count")
+	t.Setenv("SDAUDIT_PLUGIN_COUNT", count)
+	p, err := Load(writePlugin(t, describe, `echo x >> "$SDAUDIT_PLUGIN_COUNT"; echo '{"protocol":1,"issues":[]}'`), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unit := testUnit()
+	p.Rules[0].Check(rules.NewContext(unit))
+	p.Rules[1].Check(rules.NewContext(unit))
+	p.Rules[0].Check(rules.NewContext(testUnit()))
+	data, _ := os.ReadFile(count)
+	if got := strings.Count(string(data), "x"); got != 2 {
+		t.Errorf("plugin ran %d times, want once for each of the 2 units", got)
+	}
+}
+
+func TestPluginEnvironment(t *testing.T) {
+	t.Setenv("SDAUDIT_PLUGIN_TOKEN", "passed")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "leaked")
+	check := `echo "{\"protocol\":1,\"issues\":[{\"rule_id\":\"CMDB001\",\"description\":\"$SDAUDIT_PLUGIN_PROTOCOL $SDAUDIT_PLUGIN_TOKEN $AWS_SECRET_ACCESS_KEY $(pwd)\"}]}"`
+	p, err := Load(writePlugin(t, describe, check), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := p.Rules[0].Check(rules.NewContext(testUnit()))
+	if len(issues) != 1 || issues[0].Description != "1 passed  /" {
+		t.Errorf("plugin saw %+v, want only its own variables, in /", issues)
+	}
+}
+
+func TestPluginErrors(t *testing.T) {
+	tests := []struct {
+		name, describe, check, want string
+		timeout                     time.Duration
+	}{
+		{name: "other protocol", describe: `{"protocol":2,"name":"cmdb","rules":[]}`, want: "speaks protocol version 2, sdaudit speaks 1"},
+		{name: "bad severity", describe: `{"protocol":1,"name":"cmdb","rules":[{"id":"CMDB001","name":"x","category":"security","severity":"urgent"}]}`, want: `unknown severity "urgent"`},
+		{name: "duplicate rule", describe: `{"protocol":1,"name":"cmdb","rules":[{"id":"CMDB001","name":"x","category":"security","severity":"low"},{"id":"CMDB001","name":"y","category":"security","severity":"low"}]}`, want: "rule CMDB001 is described twice"},
+		{name: "not json", describe: `hello`, want: "invalid response"},
+		{name: "undescribed rule", describe: describe, check: `echo '{"protocol":1,"issues":[{"rule_id":"SEC001","description":"x"}]}'`, want: `plugin cmdb: app.service: reported an issue of undescribed rule "SEC001"`},
+		{name: "exit status", describe: describe, check: `echo 'no database' >&2; exit 3`, want: "exit status 3: no database"},
+		{name: "timeout", describe: describe, check: `sleep 2`, timeout: 100 * time.Millisecond, want: "timed out after 100ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checkErr error
+			p, err := Load(writePlugin(t, tt.describe, tt.check), Options{Timeout: tt.timeout, OnError: func(err error) { checkErr = err }})
+			if err == nil {
+				if issues := p.Rules[0].Check(rules.NewContext(testUnit())); len(issues) != 0 {
+					t.Errorf("a failed check should report nothing, got %+v", issues)
+				}
+				err = checkErr
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
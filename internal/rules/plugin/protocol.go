@@ -0,0 +1,118 @@
+// Package plugin runs rules implemented by external programs, for checks
+// that need more than a unit's directives, such as a lookup in an inventory
+// database. A plugin is any executable speaking the JSON protocol below;
+// sdaudit starts it once to describe its rules and once per unit to check
+// it, writing one request to its stdin and reading one response from its
+// stdout.
+//
+// Describe request and response:
+//
+//	{"protocol": 1, "request": "describe"}
+//
+//	{"protocol": 1, "name": "cmdb", "rules": [{"id": "CMDB001",
+//	  "name": "Service has no owner", "description": "...",
+//	  "category": "bestpractice", "severity": "medium", "tags": ["inventory"],
+//	  "suggestion": "...", "references": ["https://..."],
+//	  "unit_types": ["service"]}]}
+//
+// Check request and response:
+//
+//	{"protocol": 1, "request": "check", "unit": {"name": "app.service",
+//	  "path": "/etc/systemd/system/app.service", "type": "service",
+//	  "sections": {"Service": {"ExecStart": [{"value": "/usr/bin/app", "line": 3}]}},
+//	  "raw": "[Service]\nExecStart=/usr/bin/app\n"}}
+//
+//	{"protocol": 1, "issues": [{"rule_id": "CMDB001",
+//	  "description": "app.service is not in the inventory", "line": 1,
+//	  "suggestion": "..."}]}
+//
+// The check is only sent for units of the types some rule of the plugin
+// applies to. Issues of rules the plugin did not describe are an error.
+// Plugins exit with status 0; anything they write to stderr is shown when
+// they fail.
+package plugin
+
+import "github.com/supabase/sdaudit/pkg/types"
+
+// ProtocolVersion is the version of the protocol sdaudit speaks. Plugins
+// answer with the version they were written for, and sdaudit refuses
+// plugins of another version.
+const ProtocolVersion = 1
+
+// Request is what sdaudit writes to a plugin's stdin
+type Request struct {
+	Protocol int    `json:"protocol"`
+	Request  string `json:"request"`
+	Unit     *Unit  `json:"unit,omitempty"`
+}
+
+// Unit is a parsed unit file as sent to plugins
+type Unit struct {
+	Name     string                        `json:"name"`
+	Path     string                        `json:"path"`
+	Type     string                        `json:"type"`
+	Sections map[string]map[string][]Value `json:"sections"`
+	Raw      string                        `json:"raw"`
+}
+
+// Value is one assignment of a directive, in file order
+type Value struct {
+	Value string `json:"value"`
+	Line  int    `json:"line"`
+}
+
+// Description is a plugin's answer to the describe request
+type Description struct {
+	Protocol int        `json:"protocol"`
+	Name     string     `json:"name"`
+	Rules    []RuleSpec `json:"rules"`
+}
+
+// RuleSpec describes one rule of a plugin
+type RuleSpec struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Category    string   `json:"category"`
+	Severity    string   `json:"severity"`
+	Tags        []string `json:"tags"`
+	Suggestion  string   `json:"suggestion"`
+	References  []string `json:"references"`
+	UnitTypes   []string `json:"unit_types"`
+}
+
+// Response is a plugin's answer to the check request
+type Response struct {
+	Protocol int         `json:"protocol"`
+	Issues   []IssueSpec `json:"issues"`
+}
+
+// IssueSpec is an issue a plugin found in the unit. Line and Suggestion are
+// optional; the rule's suggestion is used when Suggestion is empty.
+type IssueSpec struct {
+	RuleID      string `json:"rule_id"`
+	Description string `json:"description"`
+	Line        int    `json:"line,omitempty"`
+	Suggestion  string `json:"suggestion,omitempty"`
+}
+
+// NewUnit converts a parsed unit file to its protocol form
+func NewUnit(unit *types.UnitFile) *Unit {
+	u := &Unit{
+		Name:     unit.Name,
+		Path:     unit.Path,
+		Type:     unit.Type,
+		Sections: make(map[string]map[string][]Value, len(unit.Sections)),
+		Raw:      unit.Raw,
+	}
+	for name, section := range unit.Sections {
+		directives := make(map[string][]Value, len(section.Directives))
+		for key, values := range section.Directives {
+			for _, d := range values {
+				directives[key] = append(directives[key], Value{Value: d.Value, Line: d.Line})
+			}
+		}
+		u.Sections[name] = directives
+	}
+	return u
+}
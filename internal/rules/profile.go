@@ -0,0 +1,179 @@
+package rules
+
+import "github.com/supabase/sdaudit/pkg/types"
+
+// Profile is a named set of rules for one kind of environment, with the
+// severities it gives some of them
+type Profile struct {
+	Name        string
+	Description string
+	// Categories limits the profile to rules of these categories, nil for all
+	Categories []types.Category
+	// MinSeverity leaves out rules below this severity
+	MinSeverity types.Severity
+	// ExcludeTags leaves out rules with any of these tags
+	ExcludeTags []string
+	// Exclude leaves out rules by ID
+	Exclude []string
+	// RaiseTags raises rules with any of these tags one severity level, up to
+	// critical
+	RaiseTags []string
+	// Severities sets the severity of rules by ID, over RaiseTags
+	Severities map[string]types.Severity
+}
+
+// profiles are the bundled profiles, in the order they are listed
+var profiles = []Profile{
+	{
+		Name:        "baseline",
+		Description: "Every rule above info severity, for a first audit",
+		MinSeverity: types.SeverityLow,
+	},
+	{
+		Name:        "server",
+		Description: "Every rule, for long-running hosts",
+	},
+	{
+		Name:        "container",
+		Description: "Leaves out hardening of devices, kernel and cgroups that the container runtime provides",
+		ExcludeTags: []string{"kernel", "cgroups"},
+		Exclude:     []string{"SEC007"},
+	},
+	{
+		Name:        "paranoid",
+		Description: "Every rule, with hardening rules one severity level higher",
+		RaiseTags:   []string{"hardening"},
+	},
+	{
+		Name:        "ci",
+		Description: "Correctness of the unit files alone, for checking them before they reach a host",
+		Categories:  []types.Category{types.CategoryReliability, types.CategoryBestPractice},
+		ExcludeTags: []string{"runtime", "journal"},
+	},
+}
+
+// Profiles returns the bundled profiles
+func Profiles() []Profile {
+	out := make([]Profile, len(profiles))
+	copy(out, profiles)
+	return out
+}
+
+// LookupProfile returns the bundled profile with the given name
+func LookupProfile(name string) (Profile, bool) {
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// ProfileNames returns the names of the bundled profiles
+func ProfileNames() []string {
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// Includes reports whether the profile runs a rule
+func (p Profile) Includes(rule Rule) bool {
+	if len(p.Categories) > 0 && !containsCategory(p.Categories, rule.Category()) {
+		return false
+	}
+	if rule.Severity() < p.MinSeverity {
+		return false
+	}
+	for _, id := range p.Exclude {
+		if id == rule.ID() {
+			return false
+		}
+	}
+	return !hasAnyTag(rule, p.ExcludeTags)
+}
+
+// Severity returns the severity the profile gives a rule, and whether it
+// differs from the rule's own
+func (p Profile) Severity(rule Rule) (types.Severity, bool) {
+	if severity, ok := p.Severities[rule.ID()]; ok {
+		return severity, severity != rule.Severity()
+	}
+	if hasAnyTag(rule, p.RaiseTags) && rule.Severity() < types.SeverityCritical {
+		return rule.Severity() + 1, true
+	}
+	return rule.Severity(), false
+}
+
+// Resolve returns the IDs of the registered rules the profile runs, sorted
+func (p Profile) Resolve() []string {
+	var ids []string
+	for _, rule := range All() {
+		if p.Includes(rule) {
+			ids = append(ids, rule.ID())
+		}
+	}
+	return ids
+}
+
+// Apply disables the rules the profile leaves out and sets the severities it
+// changes. Severities the config already overrides are kept, so a config
+// file wins over the profile.
+func (p Profile) Apply(config *Config) {
+	if config.DisabledRules == nil {
+		config.DisabledRules = make(map[string]bool)
+	}
+	if config.SeverityOverrides == nil {
+		config.SeverityOverrides = make(map[string]types.Severity)
+	}
+	for _, rule := range All() {
+		if !p.Includes(rule) {
+			config.DisabledRules[rule.ID()] = true
+			continue
+		}
+		if _, ok := config.SeverityOverrides[rule.ID()]; ok {
+			continue
+		}
+		if severity, changed := p.Severity(rule); changed {
+			config.SeverityOverrides[rule.ID()] = severity
+		}
+	}
+}
+
+// ProfilesOf returns the names of the bundled profiles that run a rule
+func ProfilesOf(rule Rule) []string {
+	var names []string
+	for _, p := range profiles {
+		if p.Includes(rule) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+func containsCategory(categories []types.Category, c types.Category) bool {
+	for _, category := range categories {
+		if category == c {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyTag reports whether the rule has any of tags
+func hasAnyTag(rule Rule, tags []string) bool {
+	if len(tags) == 0 {
+		return false
+	}
+	set := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		set[t] = true
+	}
+	for _, t := range rule.Tags() {
+		if set[t] {
+			return true
+		}
+	}
+	return false
+}
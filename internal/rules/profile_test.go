@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestProfileIncludesAndSeverity(t *testing.T) {
+	hardening := &testRule{BaseRule{RuleID: "TEST101", RuleCategory: types.CategorySecurity, RuleSeverity: types.SeverityMedium, RuleTags: []string{"hardening", "kernel"}}}
+	critical := &testRule{BaseRule{RuleID: "TEST102", RuleCategory: types.CategorySecurity, RuleSeverity: types.SeverityCritical, RuleTags: []string{"hardening"}}}
+	info := &testRule{BaseRule{RuleID: "TEST103", RuleCategory: types.CategoryBestPractice, RuleSeverity: types.SeverityInfo}}
+
+	p := Profile{
+		Categories:  []types.Category{types.CategorySecurity},
+		MinSeverity: types.SeverityLow,
+		ExcludeTags: []string{"kernel"},
+	}
+	if p.Includes(hardening) || !p.Includes(critical) || p.Includes(info) {
+		t.Error("Includes should apply the categories, minimum severity and excluded tags")
+	}
+	if (Profile{Exclude: []string{"TEST102"}}).Includes(critical) {
+		t.Error("Includes should leave out excluded IDs")
+	}
+
+	p = Profile{RaiseTags: []string{"hardening"}, Severities: map[string]types.Severity{"TEST103": types.SeverityLow}}
+	if got, changed := p.Severity(hardening); got != types.SeverityHigh || !changed {
+		t.Errorf("raised severity = %s, %v, want high", got, changed)
+	}
+	if got, changed := p.Severity(critical); got != types.SeverityCritical || changed {
+		t.Errorf("critical rules stay critical, got %s, %v", got, changed)
+	}
+	if got, changed := p.Severity(info); got != types.SeverityLow || !changed {
+		t.Errorf("set severity = %s, %v, want low", got, changed)
+	}
+}
+
+func TestProfileApplyKeepsConfig(t *testing.T) {
+	const id = "TEST104"
+	if Get(id) == nil {
+		Register(&testRule{BaseRule{RuleID: id, RuleSeverity: types.SeverityLow, RuleTags: []string{"hardening"}}})
+	}
+	p := Profile{RaiseTags: []string{"hardening"}}
+
+	config := DefaultConfig()
+	p.Apply(config)
+	if config.SeverityOverrides[id] != types.SeverityMedium {
+		t.Errorf("profile severity not applied: %v", config.SeverityOverrides)
+	}
+
+	config = DefaultConfig()
+	config.SeverityOverrides[id] = types.SeverityInfo
+	p.Apply(config)
+	if config.SeverityOverrides[id] != types.SeverityInfo {
+		t.Errorf("the config's severity should win over the profile's: %v", config.SeverityOverrides)
+	}
+
+	config = DefaultConfig()
+	Profile{Exclude: []string{id}}.Apply(config)
+	if !config.DisabledRules[id] {
+		t.Error("rules the profile leaves out should be disabled")
+	}
+}
+
+func TestLookupProfile(t *testing.T) {
+	for _, name := range ProfileNames() {
+		if p, ok := LookupProfile(name); !ok || p.Name != name || p.Description == "" {
+			t.Errorf("LookupProfile(%q) = %+v, %v", name, p, ok)
+		}
+	}
+	if _, ok := LookupProfile("nope"); ok {
+		t.Error("LookupProfile(nope) found a profile")
+	}
+}
@@ -59,7 +59,7 @@ func RunAll(ctx *Context) []types.Issue {
 	var allIssues []types.Issue
 
 	for _, rule := range All() {
-		if ctx.IsRuleDisabled(rule.ID()) {
+		if !ctx.CanRun(rule) || !AppliesTo(rule, ctx.Unit) {
 			continue
 		}
 
@@ -69,6 +69,8 @@ func RunAll(ctx *Context) []types.Issue {
 			if override, ok := ctx.GetSeverityOverride(rule.ID()); ok {
 				issues[i].Severity = override
 			}
+			setRefs(rule, &issues[i])
+			issues[i].Origin = Origin(rule)
 		}
 
 		allIssues = append(allIssues, issues...)
@@ -79,44 +81,70 @@ func RunAll(ctx *Context) []types.Issue {
 
 // RunFiltered executes rules matching the filter criteria
 func RunFiltered(ctx *Context, category *types.Category, minSeverity *types.Severity, tags []string) []types.Issue {
+	return RunWhere(ctx, category, minSeverity, tags, nil)
+}
+
+// RunWhere executes the rules matching the filter criteria for which keep
+// returns true. A nil keep selects every rule, as RunFiltered does
+func RunWhere(ctx *Context, category *types.Category, minSeverity *types.Severity, tags []string, keep func(Rule) bool) []types.Issue {
 	var allIssues []types.Issue
 
 	for _, rule := range All() {
-		if ctx.IsRuleDisabled(rule.ID()) {
+		if !ctx.CanRun(rule) || !AppliesTo(rule, ctx.Unit) {
 			continue
 		}
 
-		if category != nil && rule.Category() != *category {
+		if !matchesFilter(rule, category, minSeverity, tags) {
 			continue
 		}
 
-		if minSeverity != nil && rule.Severity() < *minSeverity {
+		if keep != nil && !keep(rule) {
 			continue
 		}
 
-		if len(tags) > 0 {
-			hasTag := false
-			tagSet := make(map[string]bool)
-			for _, t := range tags {
-				tagSet[t] = true
-			}
-			for _, t := range rule.Tags() {
-				if tagSet[t] {
-					hasTag = true
-					break
-				}
-			}
-			if !hasTag {
-				continue
+		issues := rule.Check(ctx)
+
+		for i := range issues {
+			if override, ok := ctx.GetSeverityOverride(rule.ID()); ok {
+				issues[i].Severity = override
 			}
+			setRefs(rule, &issues[i])
+			issues[i].Origin = Origin(rule)
 		}
 
-		issues := rule.Check(ctx)
+		allIssues = append(allIssues, issues...)
+	}
+
+	return allIssues
+}
+
+// RunHost executes the host-wide checks of rules matching the filter criteria.
+// Nil filters match every rule
+func RunHost(ctx *Context, category *types.Category, minSeverity *types.Severity, tags []string) []types.Issue {
+	var allIssues []types.Issue
+
+	for _, rule := range All() {
+		hostRule, ok := rule.(HostRule)
+		if !ok {
+			continue
+		}
+
+		if !ctx.CanRun(rule) {
+			continue
+		}
+
+		if !matchesFilter(rule, category, minSeverity, tags) {
+			continue
+		}
+
+		issues := hostRule.CheckHost(ctx)
 
 		for i := range issues {
 			if override, ok := ctx.GetSeverityOverride(rule.ID()); ok {
 				issues[i].Severity = override
 			}
+			setRefs(rule, &issues[i])
+			issues[i].Origin = Origin(rule)
 		}
 
 		allIssues = append(allIssues, issues...)
@@ -124,3 +152,111 @@ func RunFiltered(ctx *Context, category *types.Category, minSeverity *types.Seve
 
 	return allIssues
 }
+
+// RunAggregate executes the aggregate checks of rules matching the filter
+// criteria on the issues found so far, which should be those of every rule
+// whatever the filters. The findings are filtered by their own severity.
+// Nil filters match every rule
+func RunAggregate(ctx *Context, found []types.Issue, category *types.Category, minSeverity *types.Severity, tags []string) []types.Issue {
+	var allIssues []types.Issue
+
+	for _, rule := range All() {
+		aggregateRule, ok := rule.(AggregateRule)
+		if !ok {
+			continue
+		}
+
+		if !ctx.CanRun(rule) {
+			continue
+		}
+
+		if !matchesFilter(rule, category, minSeverity, tags) {
+			continue
+		}
+
+		issues := aggregateRule.Aggregate(ctx, found)
+
+		for i := range issues {
+			if override, ok := ctx.GetSeverityOverride(rule.ID()); ok {
+				issues[i].Severity = override
+			}
+			if minSeverity != nil && issues[i].Severity < *minSeverity {
+				continue
+			}
+			setRefs(rule, &issues[i])
+			issues[i].Origin = Origin(rule)
+			allIssues = append(allIssues, issues[i])
+		}
+	}
+
+	return allIssues
+}
+
+// setRefs fills in an issue's typed references. Issues that carry their own
+// References keep them; otherwise the rule's typed references are used
+func setRefs(rule Rule, issue *types.Issue) {
+	if issue.Refs != nil {
+		return
+	}
+	if _, ok := rule.(ReferencedRule); ok || len(issue.References) == 0 {
+		issue.Refs = TypedReferences(rule)
+		return
+	}
+	issue.Refs = convertReferences(issue.References)
+}
+
+// Matches reports whether a rule passes the category, severity and tag
+// filters. Nil filters match every rule
+func Matches(rule Rule, category *types.Category, minSeverity *types.Severity, tags []string) bool {
+	return matchesFilter(rule, category, minSeverity, tags)
+}
+
+// matchesFilter reports whether a rule passes the category, severity and tag
+// filters. Aggregate rules rate each finding on its own, so they pass any
+// severity and RunAggregate filters their findings instead.
+func matchesFilter(rule Rule, category *types.Category, minSeverity *types.Severity, tags []string) bool {
+	if category != nil && rule.Category() != *category {
+		return false
+	}
+
+	if _, aggregate := rule.(AggregateRule); minSeverity != nil && !aggregate && rule.Severity() < *minSeverity {
+		return false
+	}
+
+	if len(tags) > 0 {
+		tagSet := make(map[string]bool)
+		for _, t := range tags {
+			tagSet[t] = true
+		}
+		for _, t := range rule.Tags() {
+			if tagSet[t] {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// SkippedForVersion returns the rules that do not apply to the given systemd version
+func SkippedForVersion(version int) []Rule {
+	var skipped []Rule
+	for _, rule := range All() {
+		if !Supported(rule, version) {
+			skipped = append(skipped, rule)
+		}
+	}
+	return skipped
+}
+
+// SkippedForCapabilities returns the rules that need any of the unavailable capabilities
+func SkippedForCapabilities(unavailable Capability) []Rule {
+	var skipped []Rule
+	for _, rule := range All() {
+		if Capabilities(rule)&unavailable != 0 {
+			skipped = append(skipped, rule)
+		}
+	}
+	return skipped
+}
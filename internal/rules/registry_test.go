@@ -1,7 +1,9 @@
 package rules
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/supabase/sdaudit/pkg/types"
 )
@@ -39,6 +41,44 @@ func TestContextWithUnits(t *testing.T) {
 	}
 }
 
+func TestContextTargetPath(t *testing.T) {
+	tests := []struct {
+		root, path string
+		want       string
+		wantOK     bool
+	}{
+		{"", "/etc/systemd/system/a.service", "/etc/systemd/system/a.service", true},
+		{"", "units/a.service", "", false},
+		{"", types.StdinPath, "", false},
+		{"/mnt/image", "/mnt/image/etc/systemd/system/a.service", "/etc/systemd/system/a.service", true},
+		{"/mnt/image/", "/mnt/image/usr/lib/systemd/system/a.service", "/usr/lib/systemd/system/a.service", true},
+		{"image", "image/etc/systemd/system/a.service", "/etc/systemd/system/a.service", true},
+		{"/mnt/image", "/home/dev/a.service", "", false},
+	}
+	for _, tt := range tests {
+		ctx := &Context{Root: tt.root}
+		got, ok := ctx.TargetPath(tt.path)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("TargetPath(%q) with root %q = %q, %v, want %q, %v", tt.path, tt.root, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestContextHasUnitEscapes(t *testing.T) {
+	ctx := &Context{AllUnits: map[string]*types.UnitFile{
+		`home-user\x2d1000.mount`: {Name: `home-user\x2d1000.mount`},
+		`backup@.service`:         {Name: `backup@.service`},
+	}}
+	for _, name := range []string{`home-user\x2d1000.mount`, `home-user\x2D1000.mount`, `backup@srv-data\x2Dold.service`} {
+		if !ctx.HasUnit(name) {
+			t.Errorf("HasUnit(%q) = false", name)
+		}
+	}
+	if ctx.HasUnit("home-user-1000.mount") {
+		t.Error("HasUnit found home-user-1000.mount, the unit of /home/user/1000")
+	}
+}
+
 func TestConfigRuleDisabled(t *testing.T) {
 	config := &Config{
 		DisabledRules: map[string]bool{"SEC001": true, "SEC002": true},
@@ -114,3 +154,223 @@ func TestAllRulesAreSorted(t *testing.T) {
 		}
 	}
 }
+
+type testRule struct {
+	BaseRule
+}
+
+func (r *testRule) Check(ctx *Context) []types.Issue { return nil }
+
+type versionedRule struct {
+	testRule
+	minVersion int
+}
+
+func (r *versionedRule) MinSystemdVersion() int { return r.minVersion }
+
+func TestParseSystemdVersion(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"252", 252},
+		{"252.22-1~deb12u1", 252},
+		{"systemd 219\n+PAM +AUDIT +SELINUX", 219},
+		{"systemd 255 (255.4-1ubuntu8)\n+PAM", 255},
+		{"", 0},
+		{"latest", 0},
+	}
+
+	for _, tt := range tests {
+		if got := ParseSystemdVersion(tt.input); got != tt.want {
+			t.Errorf("ParseSystemdVersion(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSupported(t *testing.T) {
+	plain := &testRule{BaseRule{RuleID: "TEST001"}}
+	versioned := &versionedRule{testRule: testRule{BaseRule{RuleID: "TEST002"}}, minVersion: 247}
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		version int
+		want    bool
+	}{
+		{"unversioned rule", plain, 219, true},
+		{"unknown version", versioned, 0, true},
+		{"version too old", versioned, 219, false},
+		{"exact version", versioned, 247, true},
+		{"newer version", versioned, 255, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Supported(tt.rule, tt.version); got != tt.want {
+				t.Errorf("Supported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextSystemdVersion(t *testing.T) {
+	ctx := NewContext(&types.UnitFile{Name: "test.service"})
+	if v := ctx.SystemdVersion(); v != 0 {
+		t.Errorf("SystemdVersion() without SystemInfo = %d, want 0", v)
+	}
+
+	ctx.SystemInfo = &SystemInfo{SystemdVersion: "245.4-4ubuntu3"}
+	if v := ctx.SystemdVersion(); v != 245 {
+		t.Errorf("SystemdVersion() = %d, want 245", v)
+	}
+}
+
+type capableRule struct {
+	testRule
+	caps Capability
+}
+
+func (r *capableRule) Capabilities() Capability { return r.caps }
+
+func TestCanRunCapabilities(t *testing.T) {
+	static := &testRule{BaseRule{RuleID: "TEST001"}}
+	fsRule := &capableRule{testRule: testRule{BaseRule{RuleID: "TEST002"}}, caps: CapabilityFilesystem}
+
+	ctx := NewContext(&types.UnitFile{Name: "test.service"})
+	if !ctx.CanRun(static) || !ctx.CanRun(fsRule) {
+		t.Error("All rules should run when every capability is available")
+	}
+
+	ctx.Unavailable = CapabilityAll
+	if !ctx.CanRun(static) {
+		t.Error("Static rules should run without capabilities")
+	}
+	if ctx.CanRun(fsRule) {
+		t.Error("Filesystem rule should be skipped when filesystem access is unavailable")
+	}
+}
+
+func TestCapabilityNames(t *testing.T) {
+	got := (CapabilityFilesystem | CapabilityRuntime).Names()
+	if len(got) != 2 || got[0] != "filesystem" || got[1] != "runtime" {
+		t.Errorf("Names() = %v, want [filesystem runtime]", got)
+	}
+	if names := CapabilityStatic.Names(); len(names) != 0 {
+		t.Errorf("CapabilityStatic.Names() = %v, want empty", names)
+	}
+}
+
+type referencedRule struct {
+	testRule
+}
+
+func (r *referencedRule) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "Sandboxing")}
+}
+
+func TestSetRefs(t *testing.T) {
+	plain := &testRule{BaseRule{RuleID: "TEST001", RuleReferences: []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#After="}}}
+	issue := types.Issue{References: plain.References()}
+	setRefs(plain, &issue)
+	if len(issue.Refs) != 1 || issue.Refs[0].Kind != types.ReferenceManPage || issue.Refs[0].Locator != "After=" {
+		t.Errorf("plain URL rule refs = %+v, want converted man page reference", issue.Refs)
+	}
+
+	typed := &referencedRule{testRule{BaseRule{RuleID: "TEST002"}}}
+	issue = types.Issue{}
+	setRefs(typed, &issue)
+	if len(issue.Refs) != 1 || issue.Refs[0].Locator != "Sandboxing" {
+		t.Errorf("typed rule refs = %+v, want the rule's typed references", issue.Refs)
+	}
+}
+
+type serviceRule struct {
+	testRule
+}
+
+func (r *serviceRule) AppliesTo() []string { return []string{"service"} }
+
+func TestAppliesTo(t *testing.T) {
+	untyped := &testRule{BaseRule{RuleID: "TEST001"}}
+	service := &serviceRule{testRule{BaseRule{RuleID: "TEST002"}}}
+	timer := &types.UnitFile{Name: "test.timer", Type: "timer"}
+
+	if !AppliesTo(untyped, timer) || !AppliesTo(untyped, nil) {
+		t.Error("rules without AppliesTo should apply to every unit")
+	}
+	if AppliesTo(service, timer) || AppliesTo(service, nil) {
+		t.Error("a service rule should not apply to a timer or to no unit")
+	}
+	if !AppliesTo(service, &types.UnitFile{Name: "test.service", Type: "service"}) {
+		t.Error("a service rule should apply to a service")
+	}
+}
+
+type externalRule struct {
+	testRule
+}
+
+func (r *externalRule) Origin() string { return "plugin test" }
+
+func TestOrigin(t *testing.T) {
+	if got := Origin(&testRule{BaseRule{RuleID: "TEST001"}}); got != "" {
+		t.Errorf("built-in rule origin = %q, want empty", got)
+	}
+	if got := Origin(&externalRule{testRule{BaseRule{RuleID: "TEST002"}}}); got != "plugin test" {
+		t.Errorf("origin = %q, want plugin test", got)
+	}
+}
+
+type paramRule struct {
+	testRule
+}
+
+func (r *paramRule) Params() []Param {
+	return []Param{
+		{Name: "max_count", Kind: ParamInt, Default: "3"},
+		{Name: "max_timeout", Kind: ParamDuration, Default: "5min"},
+	}
+}
+
+func TestParams(t *testing.T) {
+	const id = "TEST105"
+	rule := &paramRule{testRule{BaseRule{RuleID: id}}}
+	if Get(id) == nil {
+		Register(rule)
+	}
+
+	ctx := NewContext(nil)
+	if got := ctx.IntParam(rule, "max_count"); got != 3 {
+		t.Errorf("default max_count = %d, want 3", got)
+	}
+	if got := ctx.DurationParam(rule, "max_timeout"); got != 5*time.Minute {
+		t.Errorf("default max_timeout = %s, want 5m", got)
+	}
+
+	if err := ctx.Config.SetParams(map[string]map[string]string{id: {"max_count": "10", "max_timeout": "90"}}); err != nil {
+		t.Fatalf("SetParams() error = %v", err)
+	}
+	if got := ctx.IntParam(rule, "max_count"); got != 10 {
+		t.Errorf("max_count = %d, want 10", got)
+	}
+	if got := ctx.DurationParam(rule, "max_timeout"); got != 90*time.Second {
+		t.Errorf("max_timeout = %s, want 90s", got)
+	}
+
+	for _, tt := range []struct {
+		id, name, value, want string
+	}{
+		{id, "max_count", "many", `TEST105.max_count: "many" is not a whole number`},
+		{id, "max_count", "-1", "TEST105.max_count: -1 is negative"},
+		{id, "max_timeout", "soon", `TEST105.max_timeout: "soon" is not a duration`},
+		{id, "max_timeout", "-5s", `TEST105.max_timeout: "-5s" is negative`},
+		{id, "max_lines", "5", `TEST105 has no parameter "max_lines" (use max_count, max_timeout)`},
+		{"TEST001", "max_count", "5", `unknown rule "TEST001"`},
+	} {
+		err := DefaultConfig().SetParam(tt.id, tt.name, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("SetParam(%s, %s, %q) error = %v, want %q", tt.id, tt.name, tt.value, err, tt.want)
+		}
+	}
+}
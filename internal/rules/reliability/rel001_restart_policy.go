@@ -18,9 +18,10 @@ func (r *REL001) Description() string {
 	return "Long-running services should have a Restart= policy to recover from crashes."
 }
 
-func (r *REL001) Category() types.Category { return types.CategoryReliability }
-func (r *REL001) Severity() types.Severity { return types.SeverityHigh }
-func (r *REL001) Tags() []string           { return []string{"availability", "resilience", "recovery"} }
+func (r *REL001) Category() types.Category       { return types.CategoryReliability }
+func (r *REL001) Severity() types.Severity       { return types.SeverityHigh }
+func (r *REL001) Tags() []string                 { return []string{"availability", "resilience", "recovery"} }
+func (r *REL001) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
 
 func (r *REL001) Suggestion() string {
 	return "Add 'Restart=on-failure' or 'Restart=always' to the [Service] section."
@@ -30,14 +31,18 @@ func (r *REL001) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#Restart="}
 }
 
+func (r *REL001) ExampleBad() string { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *REL001) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nRestart=on-failure\n"
+}
+
+func (r *REL001) AppliesTo() []string { return []string{"service"} }
+
 func (r *REL001) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
-
-	// Oneshot services typically don't need restart
-	if unit.GetDirective("Service", "Type") == "oneshot" {
+	// Oneshot services typically don't need restart, and a timer starts its
+	// service again on schedule (see REL022)
+	if unit.GetDirective("Service", "Type") == "oneshot" || len(ctx.TimersFor(unit)) > 0 {
 		return nil
 	}
 
@@ -54,6 +59,10 @@ func (r *REL001) Check(ctx *rules.Context) []types.Issue {
 			Description: "Service has no restart policy. It will not recover from crashes.",
 			Suggestion:  r.Suggestion(),
 			References:  r.References(),
+			Directive:   "Restart",
+			Value:       restart,
+			Section:     "Service",
+			Expected:    "on-failure",
 		}}
 	}
 	return nil
@@ -1,10 +1,8 @@
 package reliability
 
 import (
-	"strconv"
-	"strings"
-
 	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -33,12 +31,21 @@ func (r *REL002) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#RestartSec="}
 }
 
+func (r *REL002) ExampleBad() string {
+	return "[Service]\nExecStart=/usr/bin/app\nRestart=always\nRestartSec=100ms\n"
+}
+func (r *REL002) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nRestart=always\nRestartSec=5s\n"
+}
+
+func (r *REL002) AppliesTo() []string { return []string{"service"} }
+
+func (r *REL002) Params() []rules.Param {
+	return []rules.Param{{Name: "min_restart_sec", Kind: rules.ParamDuration, Default: "1s", Description: "Shortest RestartSec= accepted"}}
+}
+
 func (r *REL002) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
-
 	restart := unit.GetDirective("Service", "Restart")
 	if restart == "" || restart == "no" {
 		return nil
@@ -49,13 +56,13 @@ func (r *REL002) Check(ctx *rules.Context) []types.Issue {
 		return nil
 	}
 
-	seconds := parseTimeValue(restartSec)
-	minSec := ctx.Config.Thresholds.RestartSecMin
-	if minSec == 0 {
-		minSec = 1.0
+	delay, err := timing.ParseDuration(restartSec)
+	if err != nil {
+		return nil
 	}
+	minDelay := ctx.DurationParam(r, "min_restart_sec")
 
-	if seconds < minSec {
+	if delay < minDelay {
 		return []types.Issue{{
 			RuleID:      r.ID(),
 			RuleName:    r.Name(),
@@ -67,32 +74,11 @@ func (r *REL002) Check(ctx *rules.Context) []types.Issue {
 			Description: "RestartSec=" + restartSec + " may cause rapid restart loops.",
 			Suggestion:  r.Suggestion(),
 			References:  r.References(),
+			Directive:   "RestartSec",
+			Value:       restartSec,
+			Section:     "Service",
+			Expected:    timing.FormatDuration(minDelay),
 		}}
 	}
 	return nil
 }
-
-func parseTimeValue(s string) float64 {
-	s = strings.TrimSpace(s)
-	suffixes := []struct {
-		suffix string
-		mult   float64
-	}{
-		{"ms", 0.001}, {"msec", 0.001},
-		{"s", 1}, {"sec", 1},
-		{"m", 60}, {"min", 60},
-		{"h", 3600}, {"hr", 3600},
-	}
-
-	for _, suf := range suffixes {
-		if strings.HasSuffix(s, suf.suffix) {
-			if val, err := strconv.ParseFloat(strings.TrimSuffix(s, suf.suffix), 64); err == nil {
-				return val * suf.mult
-			}
-		}
-	}
-	if val, err := strconv.ParseFloat(s, 64); err == nil {
-		return val
-	}
-	return 0
-}
@@ -1,9 +1,12 @@
 package reliability
 
 import (
+	"sort"
 	"strings"
 
+	"github.com/supabase/sdaudit/internal/graph"
 	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitname"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -18,7 +21,8 @@ func init() {
 	rules.Register(&REL010{})
 }
 
-// REL003 - Missing WantedBy/RequiredBy
+// REL003 - Missing WantedBy/RequiredBy. Services activated by a socket or
+// triggered by a timer are skipped: they are started through it, see REL016.
 type REL003 struct{}
 
 func (r *REL003) ID() string   { return "REL003" }
@@ -26,22 +30,24 @@ func (r *REL003) Name() string { return "Missing WantedBy or RequiredBy" }
 func (r *REL003) Description() string {
 	return "Services should specify how they integrate with targets."
 }
-func (r *REL003) Category() types.Category { return types.CategoryReliability }
-func (r *REL003) Severity() types.Severity { return types.SeverityMedium }
-func (r *REL003) Tags() []string           { return []string{"install", "targets"} }
-func (r *REL003) Suggestion() string       { return "Add 'WantedBy=multi-user.target' to [Install] section." }
+func (r *REL003) Category() types.Category       { return types.CategoryReliability }
+func (r *REL003) Severity() types.Severity       { return types.SeverityMedium }
+func (r *REL003) Tags() []string                 { return []string{"install", "targets"} }
+func (r *REL003) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
+func (r *REL003) Suggestion() string             { return "Add 'WantedBy=multi-user.target' to [Install] section." }
 func (r *REL003) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#WantedBy="}
 }
+func (r *REL003) AppliesTo() []string { return []string{"service"} }
 func (r *REL003) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	if len(ctx.SocketsFor(unit)) > 0 || len(ctx.TimersFor(unit)) > 0 {
 		return nil
 	}
 	wantedBy := unit.GetDirective("Install", "WantedBy")
 	requiredBy := unit.GetDirective("Install", "RequiredBy")
 	if wantedBy == "" && requiredBy == "" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service has no WantedBy or RequiredBy, won't start automatically.", Suggestion: r.Suggestion(), References: r.References()}}
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service has no WantedBy or RequiredBy, won't start automatically.", Suggestion: r.Suggestion(), References: r.References(), Directive: "WantedBy", Section: "Install", Expected: "multi-user.target"}}
 	}
 	return nil
 }
@@ -59,27 +65,28 @@ func (r *REL004) Suggestion() string       { return "Review dependency chain and
 func (r *REL004) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#Requires="}
 }
+func (r *REL004) Capabilities() rules.Capability { return rules.CapabilityGraph }
 func (r *REL004) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
 	if unit == nil || len(ctx.AllUnits) == 0 {
 		return nil
 	}
 	// Check if unit references itself
-	deps := []string{}
 	for _, d := range []string{"Requires", "Wants", "After", "Before", "BindsTo"} {
-		if v := unit.GetDirective("Unit", d); v != "" {
-			deps = append(deps, strings.Fields(v)...)
-		}
-	}
-	for _, dep := range deps {
-		if dep == unit.Name {
-			return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Unit references itself in dependencies.", Suggestion: r.Suggestion(), References: r.References()}}
+		for _, dep := range strings.Fields(unit.GetDirective("Unit", d)) {
+			if dep == unit.Name {
+				return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Unit references itself in dependencies.", Suggestion: r.Suggestion(), References: r.References(), Directive: d, Value: dep, Section: "Unit"}}
+			}
 		}
 	}
 	return nil
 }
 
-// REL005 - After without Requires
+// REL005 - After without Requires. With the dependency graph, requirements
+// from drop-ins and .wants/ and .requires/ symlinks count too. Each unit
+// ordered after is reported once, as in the ordering findings of sdaudit deps,
+// and synchronization units such as network-online.target are skipped, those
+// of graph.DefaultSynchronizationUnits and Config.SynchronizationUnits.
 type REL005 struct{}
 
 func (r *REL005) ID() string   { return "REL005" }
@@ -101,31 +108,84 @@ func (r *REL005) Check(ctx *rules.Context) []types.Issue {
 	if unit == nil {
 		return nil
 	}
-	after := strings.Fields(unit.GetDirective("Unit", "After"))
-	requires := strings.Fields(unit.GetDirective("Unit", "Requires"))
-	wants := strings.Fields(unit.GetDirective("Unit", "Wants"))
-	bindsTo := strings.Fields(unit.GetDirective("Unit", "BindsTo"))
-
-	ensured := make(map[string]bool)
-	for _, u := range requires {
-		ensured[u] = true
+	synchronization := make(map[string]bool)
+	for _, name := range graph.DefaultSynchronizationUnits {
+		synchronization[name] = true
 	}
-	for _, u := range wants {
-		ensured[u] = true
+	if ctx.Config != nil {
+		for _, name := range ctx.Config.SynchronizationUnits {
+			synchronization[unitname.Normalize(name)] = true
+		}
 	}
-	for _, u := range bindsTo {
-		ensured[u] = true
+
+	var issues []types.Issue
+	for _, o := range r.unrequired(ctx) {
+		if synchronization[o.Related] {
+			continue
+		}
+		issue := types.Issue{
+			RuleID:      r.ID(),
+			RuleName:    r.Name(),
+			Severity:    r.Severity(),
+			Category:    r.Category(),
+			Tags:        r.Tags(),
+			Unit:        unit.Name,
+			File:        unit.Path,
+			Description: "After=" + o.Related + " without Requires/Wants may not start the dependency.",
+			Suggestion:  r.Suggestion(),
+			References:  r.References(),
+			Directive:   "After",
+			Value:       o.Related,
+			Section:     "Unit",
+		}
+		if o.File != "" {
+			issue.File = o.File
+		}
+		if o.Line > 0 {
+			line := o.Line
+			issue.Line = &line
+		}
+		issues = append(issues, issue)
 	}
+	return issues
+}
 
-	// Skip common targets that don't need explicit Requires
-	skip := map[string]bool{"network.target": true, "network-online.target": true, "local-fs.target": true, "remote-fs.target": true, "sysinit.target": true, "basic.target": true, "multi-user.target": true}
+// unrequired returns the units the unit is ordered after without requiring
+// or wanting them, from the graph when there is one and from the unit's own
+// directives otherwise
+func (r *REL005) unrequired(ctx *rules.Context) []graph.OrderingIssue {
+	unit := ctx.Unit
+	if ctx.Graph != nil {
+		var unrequired []graph.OrderingIssue
+		for _, o := range ctx.Graph.FindOrderingIssuesFrom(unit.Name) {
+			if o.IssueType == "after_without_requires" {
+				unrequired = append(unrequired, o)
+			}
+		}
+		return unrequired
+	}
 
-	for _, a := range after {
-		if !ensured[a] && !skip[a] && !strings.HasSuffix(a, ".target") {
-			return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "After=" + a + " without Requires/Wants may not start the dependency.", Suggestion: r.Suggestion(), References: r.References()}}
+	ensured := make(map[string]bool)
+	for _, key := range []string{"Requires", "Wants", "BindsTo"} {
+		for _, d := range unit.GetDirectives("Unit", key) {
+			for _, u := range strings.Fields(d.Value) {
+				ensured[unitname.Normalize(u)] = true
+			}
 		}
 	}
-	return nil
+	var unrequired []graph.OrderingIssue
+	for _, d := range unit.GetDirectives("Unit", "After") {
+		for _, a := range strings.Fields(d.Value) {
+			a = unitname.Normalize(a)
+			if ensured[a] {
+				continue
+			}
+			ensured[a] = true
+			unrequired = append(unrequired, graph.OrderingIssue{Unit: unit.Name, Related: a, IssueType: "after_without_requires", EdgeType: graph.EdgeAfter, Line: d.Line})
+		}
+	}
+	sort.Slice(unrequired, func(i, j int) bool { return unrequired[i].Related < unrequired[j].Related })
+	return unrequired
 }
 
 // REL006 - StartLimitBurst not set
@@ -143,11 +203,16 @@ func (r *REL006) Suggestion() string {
 func (r *REL006) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#StartLimitBurst="}
 }
+func (r *REL006) MinSystemdVersion() int { return rules.DirectiveVersions["StartLimitIntervalSec"] }
+func (r *REL006) ExampleBad() string {
+	return "[Service]\nExecStart=/usr/bin/app\nRestart=on-failure\n"
+}
+func (r *REL006) ExampleGood() string {
+	return "[Unit]\nStartLimitIntervalSec=10\nStartLimitBurst=5\n\n[Service]\nExecStart=/usr/bin/app\nRestart=on-failure\n"
+}
+func (r *REL006) AppliesTo() []string { return []string{"service"} }
 func (r *REL006) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
 	restart := unit.GetDirective("Service", "Restart")
 	if restart == "" || restart == "no" {
 		return nil
@@ -155,7 +220,7 @@ func (r *REL006) Check(ctx *rules.Context) []types.Issue {
 	burst := unit.GetDirective("Unit", "StartLimitBurst")
 	interval := unit.GetDirective("Unit", "StartLimitIntervalSec")
 	if burst == "" && interval == "" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service has restart but no start rate limiting.", Suggestion: r.Suggestion(), References: r.References()}}
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service has restart but no start rate limiting.", Suggestion: r.Suggestion(), References: r.References(), Directive: "StartLimitBurst", Section: "Unit", Expected: "5"}}
 	}
 	return nil
 }
@@ -193,13 +258,13 @@ func (r *REL008) Suggestion() string       { return "Use KillMode=control-group
 func (r *REL008) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.kill.html#KillMode="}
 }
+func (r *REL008) ExampleBad() string  { return "[Service]\nExecStart=/usr/bin/app\nKillMode=none\n" }
+func (r *REL008) ExampleGood() string { return "[Service]\nExecStart=/usr/bin/app\nKillMode=mixed\n" }
+func (r *REL008) AppliesTo() []string { return []string{"service"} }
 func (r *REL008) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
 	if v := unit.GetDirective("Service", "KillMode"); v == "none" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "KillMode=none leaves child processes orphaned on stop.", Suggestion: r.Suggestion(), References: r.References()}}
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "KillMode=none leaves child processes orphaned on stop.", Suggestion: r.Suggestion(), References: r.References(), Directive: "KillMode", Value: v, Section: "Service", Expected: "control-group"}}
 	}
 	return nil
 }
@@ -217,6 +282,7 @@ func (r *REL009) Suggestion() string       { return "Remove or fix the dependenc
 func (r *REL009) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#Requires="}
 }
+func (r *REL009) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
 func (r *REL009) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
 	if unit == nil || len(ctx.AllUnits) == 0 {
@@ -225,8 +291,8 @@ func (r *REL009) Check(ctx *rules.Context) []types.Issue {
 	requires := strings.Fields(unit.GetDirective("Unit", "Requires"))
 	for _, req := range requires {
 		if strings.HasSuffix(req, ".service") {
-			if _, exists := ctx.AllUnits[req]; !exists {
-				return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Required unit not found: " + req, Suggestion: r.Suggestion(), References: r.References()}}
+			if _, exists := ctx.FindUnit(req); !exists {
+				return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Required unit not found: " + req, Suggestion: r.Suggestion(), References: r.References(), Directive: "Requires", Value: req, Section: "Unit"}}
 			}
 		}
 	}
@@ -246,6 +312,12 @@ func (r *REL010) Suggestion() string       { return "Add After= for units listed
 func (r *REL010) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#BindsTo="}
 }
+func (r *REL010) ExampleBad() string {
+	return "[Unit]\nBindsTo=db.service\n\n[Service]\nExecStart=/usr/bin/app\n"
+}
+func (r *REL010) ExampleGood() string {
+	return "[Unit]\nBindsTo=db.service\nAfter=db.service\n\n[Service]\nExecStart=/usr/bin/app\n"
+}
 func (r *REL010) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
 	if unit == nil {
@@ -259,7 +331,7 @@ func (r *REL010) Check(ctx *rules.Context) []types.Issue {
 	}
 	for _, b := range bindsTo {
 		if !afterSet[b] {
-			return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "BindsTo=" + b + " without corresponding After=.", Suggestion: r.Suggestion(), References: r.References()}}
+			return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "BindsTo=" + b + " without corresponding After=.", Suggestion: r.Suggestion(), References: r.References(), Directive: "BindsTo", Value: b, Section: "Unit"}}
 		}
 	}
 	return nil
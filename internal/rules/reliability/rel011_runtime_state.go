@@ -0,0 +1,118 @@
+package reliability
+
+import (
+	"strconv"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&REL011{})
+	rules.Register(&REL012{})
+}
+
+// REL011 - Unit currently in failed state
+type REL011 struct{}
+
+func (r *REL011) ID() string   { return "REL011" }
+func (r *REL011) Name() string { return "Unit in failed state" }
+
+func (r *REL011) Description() string {
+	return "The unit is currently in the failed state on this host."
+}
+
+func (r *REL011) Category() types.Category       { return types.CategoryReliability }
+func (r *REL011) Severity() types.Severity       { return types.SeverityCritical }
+func (r *REL011) Tags() []string                 { return []string{"availability", "runtime"} }
+func (r *REL011) Capabilities() rules.Capability { return rules.CapabilityRuntime }
+
+func (r *REL011) Suggestion() string {
+	return "Inspect 'systemctl status' and 'journalctl -u' for the unit, fix the cause, then run 'systemctl reset-failed'."
+}
+
+func (r *REL011) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemctl.html#reset-failed%20%5BPATTERN%E2%80%A6%5D"}
+}
+
+func (r *REL011) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	if unit == nil || !unit.Runtime.IsFailed() {
+		return nil
+	}
+
+	return []types.Issue{{
+		RuleID:      r.ID(),
+		RuleName:    r.Name(),
+		Severity:    r.Severity(),
+		Category:    r.Category(),
+		Tags:        r.Tags(),
+		Unit:        unit.Name,
+		File:        unit.Path,
+		Description: "Unit is in failed state (" + describeResult(unit.Runtime) + ").",
+		Suggestion:  r.Suggestion(),
+		References:  r.References(),
+	}}
+}
+
+// REL012 - Unit restarting repeatedly
+type REL012 struct{}
+
+func (r *REL012) ID() string   { return "REL012" }
+func (r *REL012) Name() string { return "Unit restart loop" }
+
+func (r *REL012) Description() string {
+	return "The service manager has restarted the unit many times since it was last started."
+}
+
+func (r *REL012) Category() types.Category       { return types.CategoryReliability }
+func (r *REL012) Severity() types.Severity       { return types.SeverityHigh }
+func (r *REL012) Tags() []string                 { return []string{"availability", "restart-loop", "runtime"} }
+func (r *REL012) Capabilities() rules.Capability { return rules.CapabilityRuntime }
+
+func (r *REL012) Suggestion() string {
+	return "Check the journal for why the service keeps exiting; a crash loop hidden by Restart= still loses requests."
+}
+
+func (r *REL012) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/org.freedesktop.systemd1.html#Properties2"}
+}
+
+func (r *REL012) Params() []rules.Param {
+	return []rules.Param{{Name: "max_restarts", Kind: rules.ParamInt, Default: "5", Description: "Most restarts since boot accepted"}}
+}
+
+func (r *REL012) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	if unit == nil || unit.Runtime == nil {
+		return nil
+	}
+
+	limit := ctx.IntParam(r, "max_restarts")
+
+	if unit.Runtime.NRestarts <= limit {
+		return nil
+	}
+
+	return []types.Issue{{
+		RuleID:      r.ID(),
+		RuleName:    r.Name(),
+		Severity:    r.Severity(),
+		Category:    r.Category(),
+		Tags:        r.Tags(),
+		Unit:        unit.Name,
+		File:        unit.Path,
+		Description: "Unit has restarted " + strconv.Itoa(unit.Runtime.NRestarts) + " times since boot (" + describeResult(unit.Runtime) + ").",
+		Suggestion:  r.Suggestion(),
+		References:  r.References(),
+	}}
+}
+
+// describeResult summarizes how the last run of the main process ended
+func describeResult(state *types.RuntimeState) string {
+	desc := "last exit code " + strconv.Itoa(state.ExecMainStatus)
+	if state.Result != "" && state.Result != "success" {
+		desc += ", result " + state.Result
+	}
+	return desc
+}
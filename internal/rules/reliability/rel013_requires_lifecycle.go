@@ -0,0 +1,138 @@
+package reliability
+
+import (
+	"path"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&REL013{})
+}
+
+// REL013 explains the lifecycle semantics of Requires= on stateful backends.
+// It only fires for backends matching Config.StatefulServicePatterns and skips
+// the case where the dependent is ordered after the backend and restarts
+// itself, since Requires= then already matches the usual intent.
+type REL013 struct{}
+
+func (r *REL013) ID() string   { return "REL013" }
+func (r *REL013) Name() string { return "Requires= on stateful backend may not match lifecycle intent" }
+
+func (r *REL013) Description() string {
+	return "Requires= only propagates explicit stops and restarts; BindsTo= or PartOf= often express the intended coupling to a database or broker."
+}
+
+func (r *REL013) Category() types.Category { return types.CategoryReliability }
+func (r *REL013) Severity() types.Severity { return types.SeverityInfo }
+func (r *REL013) Tags() []string           { return []string{"dependency", "lifecycle", "maintenance"} }
+
+func (r *REL013) Suggestion() string {
+	return "Pick the binding that matches the intent from the table; keep After= on the backend in every case."
+}
+
+func (r *REL013) References() []string {
+	return []string{
+		"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#Requires=",
+		"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#BindsTo=",
+		"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#PartOf=",
+	}
+}
+
+// DefaultStatefulServicePatterns match common databases and message brokers
+var DefaultStatefulServicePatterns = []string{
+	"*sql*", "postgres*", "mariadb*", "redis*", "valkey*", "rabbitmq*", "mongod*",
+	"memcached*", "etcd*", "kafka*", "zookeeper*", "elasticsearch*", "opensearch*",
+}
+
+// lifecycleTable compares the dependency directives on the events people
+// usually care about during maintenance
+const lifecycleTable = `
+  Directive   Starts backend  Stopped/restarted with it  Stopped if it fails
+  Wants=      yes             no                         no
+  Requires=   yes             yes                        no
+  BindsTo=    yes             yes                        yes
+  PartOf=     no              yes                        no`
+
+func (r *REL013) AppliesTo() []string { return []string{"service"} }
+
+func (r *REL013) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	patterns := DefaultStatefulServicePatterns
+	if ctx.Config != nil && len(ctx.Config.StatefulServicePatterns) > 0 {
+		patterns = ctx.Config.StatefulServicePatterns
+	}
+
+	after := directiveSet(unit, "After")
+	bound := directiveSet(unit, "BindsTo")
+	partOf := directiveSet(unit, "PartOf")
+	restart := unit.GetDirective("Service", "Restart")
+	restarts := restart != "" && restart != "no"
+
+	var issues []types.Issue
+	for _, d := range unit.GetDirectives("Unit", "Requires") {
+		for _, backend := range strings.Fields(d.Value) {
+			if backend == unit.Name || bound[backend] || partOf[backend] || !isStateful(backend, patterns) {
+				continue
+			}
+
+			var intent string
+			switch {
+			case !after[backend]:
+				intent = "Without After=" + backend + " both units start in parallel, so this service may run before the backend accepts connections. Add After= whichever binding you choose."
+			case !restarts:
+				intent = "This service has no Restart=, so if " + backend + " crashes it keeps running against a dead backend and never recovers. If it cannot work without the backend, BindsTo= stops it on failure as well; if it should only follow maintenance restarts of the backend, PartOf= does that without starting the backend."
+			default:
+				// Ordered and self-healing: Requires= matches the common intent
+				continue
+			}
+
+			line := d.Line
+			issues = append(issues, types.Issue{
+				RuleID:      r.ID(),
+				RuleName:    r.Name(),
+				Severity:    r.Severity(),
+				Category:    r.Category(),
+				Tags:        r.Tags(),
+				Unit:        unit.Name,
+				File:        unit.Path,
+				Line:        &line,
+				Description: "Requires=" + backend + " couples this service to a stateful backend. " + intent + "\n" + lifecycleTable,
+				Suggestion:  r.Suggestion(),
+				References:  r.References(),
+				Directive:   "Requires",
+				Value:       backend,
+				Section:     "Unit",
+			})
+		}
+	}
+
+	return issues
+}
+
+// directiveSet collects the space-separated unit names of all values of a [Unit] directive
+func directiveSet(unit *types.UnitFile, key string) map[string]bool {
+	set := make(map[string]bool)
+	for _, d := range unit.GetDirectives("Unit", key) {
+		for _, name := range strings.Fields(d.Value) {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// isStateful reports whether a unit name, without its suffix, matches one of the patterns
+func isStateful(name string, patterns []string) bool {
+	base := strings.TrimSuffix(name, ".service")
+	if base == name {
+		return false
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
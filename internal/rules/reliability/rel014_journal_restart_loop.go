@@ -0,0 +1,95 @@
+package reliability
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/journal"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&REL014{})
+}
+
+// recentStarts is how many start timestamps REL014 lists in its description
+const recentStarts = 5
+
+// REL014 - Restart loop found in the journal
+//
+// Unlike REL012, which relies on NRestarts and is reset by daemon-reload, this
+// uses the start history read from the journal with --with-journal.
+type REL014 struct{}
+
+func (r *REL014) ID() string   { return "REL014" }
+func (r *REL014) Name() string { return "Restart loop in journal" }
+
+func (r *REL014) Description() string {
+	return "The journal shows more starts within StartLimitIntervalSec than StartLimitBurst allows."
+}
+
+func (r *REL014) Category() types.Category { return types.CategoryReliability }
+func (r *REL014) Severity() types.Severity { return types.SeverityHigh }
+func (r *REL014) Tags() []string {
+	return []string{"availability", "restart-loop", "runtime", "journal"}
+}
+func (r *REL014) Capabilities() rules.Capability { return rules.CapabilityRuntime }
+
+func (r *REL014) Suggestion() string {
+	return "Check 'journalctl -b -u' for the unit around the listed times and fix the cause; keep StartLimitIntervalSec= enabled so a loop ends in a failed state instead of flapping."
+}
+
+func (r *REL014) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#StartLimitIntervalSec=interval"}
+}
+
+func (r *REL014) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	if unit == nil || unit.Runtime == nil || len(unit.Runtime.StartHistory) == 0 {
+		return nil
+	}
+
+	limit := timing.ParseStartLimit(unit, ctx.SystemConfig)
+	window := limit.Interval
+	if window == 0 {
+		// Rate limiting is disabled, judge against systemd's default window
+		window = timing.DefaultStartLimitIntervalSec
+	}
+
+	starts := unit.Runtime.StartHistory
+	count, end := journal.MaxInWindow(starts, window)
+	if count <= limit.Burst {
+		return nil
+	}
+
+	var limitDesc string
+	switch {
+	case limit.Interval == 0:
+		limitDesc = "start rate limiting is disabled (StartLimitIntervalSec=0)"
+	case limit.Configured:
+		limitDesc = "the unit allows StartLimitBurst=" + strconv.Itoa(limit.Burst) + " per StartLimitIntervalSec=" + timing.FormatDuration(limit.Interval)
+	default:
+		limitDesc = "no start limits are configured (see REL006), so the default " + strconv.Itoa(limit.Burst) + " per " + timing.FormatDuration(limit.Interval) + " applies"
+	}
+
+	first := max(0, end-recentStarts+1)
+	var times []string
+	for _, t := range starts[first : end+1] {
+		times = append(times, t.Format("15:04:05.000"))
+	}
+
+	return []types.Issue{{
+		RuleID:      r.ID(),
+		RuleName:    r.Name(),
+		Severity:    r.Severity(),
+		Category:    r.Category(),
+		Tags:        r.Tags(),
+		Unit:        unit.Name,
+		File:        unit.Path,
+		Description: "Unit started " + strconv.Itoa(count) + " times within " + timing.FormatDuration(window) + " this boot, but " + limitDesc + ". Last starts: " + strings.Join(times, ", ") + ".",
+		Suggestion:  r.Suggestion(),
+		References:  r.References(),
+	}}
+}
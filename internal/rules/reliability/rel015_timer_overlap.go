@@ -0,0 +1,152 @@
+package reliability
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&REL015{})
+}
+
+// REL015 - Timer fires more often than its service takes to run
+type REL015 struct{}
+
+func (r *REL015) ID() string   { return "REL015" }
+func (r *REL015) Name() string { return "Timer interval shorter than job runtime" }
+
+func (r *REL015) Description() string {
+	return "A timer that fires again before its service has finished leads to skipped triggers or overlapping runs."
+}
+
+func (r *REL015) Category() types.Category       { return types.CategoryReliability }
+func (r *REL015) Severity() types.Severity       { return types.SeverityMedium }
+func (r *REL015) Tags() []string                 { return []string{"timer", "scheduling", "concurrency"} }
+func (r *REL015) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
+
+func (r *REL015) Suggestion() string {
+	return "Lengthen the timer interval or speed up the job, and bound each run with RuntimeMaxSec= or a lock such as 'flock -n' in ExecStart=."
+}
+
+func (r *REL015) References() []string {
+	return []string{
+		"https://www.freedesktop.org/software/systemd/man/systemd.timer.html#OnCalendar=",
+		"https://www.freedesktop.org/software/systemd/man/systemd.service.html#RuntimeMaxSec=",
+	}
+}
+
+// lockWrappers are commands that serialize runs of the command they wrap
+var lockWrappers = []string{"flock", "lockf", "lockrun", "run-one", "solo", "setlock"}
+
+func (r *REL015) AppliesTo() []string { return []string{"timer"} }
+
+func (r *REL015) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	serviceName := rules.TimerServiceName(unit)
+	service, ok := ctx.FindUnit(serviceName)
+	if !ok {
+		return nil
+	}
+
+	interval, trigger, line := timerInterval(unit)
+	if interval == 0 {
+		return nil
+	}
+
+	duration, source := expectedRuntime(service)
+	if duration == 0 || duration <= interval {
+		return nil
+	}
+
+	description := "Timer fires every " + timing.FormatDuration(interval) + " (" + trigger + ") but " + serviceName + " is expected to run for " + timing.FormatDuration(duration) + " (" + source + "), so triggers are skipped or runs overlap."
+
+	guarded := service.HasDirective("Service", "RuntimeMaxSec") || hasLockWrapper(service)
+	if !guarded {
+		description += " The service has no concurrency guard: neither RuntimeMaxSec= nor a lock wrapper such as flock in ExecStart=."
+	}
+
+	return []types.Issue{{
+		RuleID:      r.ID(),
+		RuleName:    r.Name(),
+		Severity:    r.Severity(),
+		Category:    r.Category(),
+		Tags:        r.Tags(),
+		Unit:        unit.Name,
+		File:        unit.Path,
+		Line:        &line,
+		Description: description,
+		Suggestion:  r.Suggestion(),
+		References:  r.References(),
+	}}
+}
+
+// timerInterval returns the shortest interval between triggers of a timer and
+// the directive it came from. OnUnitInactiveSec= is ignored since it counts
+// from the end of the previous run and cannot overlap.
+func timerInterval(timer *types.UnitFile) (time.Duration, string, int) {
+	var shortest time.Duration
+	var trigger string
+	var line int
+
+	consider := func(d time.Duration, desc string, l int) {
+		if d > 0 && (shortest == 0 || d < shortest) {
+			shortest, trigger, line = d, desc, l
+		}
+	}
+
+	for _, d := range timer.GetDirectives("Timer", "OnCalendar") {
+		if interval, ok := timing.CalendarInterval(d.Value); ok {
+			consider(interval, "OnCalendar="+d.Value, d.Line)
+		}
+	}
+	for _, d := range timer.GetDirectives("Timer", "OnUnitActiveSec") {
+		if interval, err := timing.ParseDuration(d.Value); err == nil {
+			consider(interval, "OnUnitActiveSec="+d.Value, d.Line)
+		}
+	}
+
+	return shortest, trigger, line
+}
+
+// expectedRuntime estimates how long a service runs, preferring the median of
+// runs recorded in the journal over RuntimeMaxSec= as an upper bound, and
+// describes which source was used
+func expectedRuntime(service *types.UnitFile) (time.Duration, string) {
+	if service.Runtime != nil && len(service.Runtime.RunDurations) > 0 {
+		runs := append([]time.Duration(nil), service.Runtime.RunDurations...)
+		sort.Slice(runs, func(i, j int) bool { return runs[i] < runs[j] })
+		median := runs[len(runs)/2]
+		if len(runs)%2 == 0 {
+			median = (runs[len(runs)/2-1] + runs[len(runs)/2]) / 2
+		}
+		return median, "median of " + strconv.Itoa(len(runs)) + " runs in the journal"
+	}
+
+	if v := service.GetDirective("Service", "RuntimeMaxSec"); v != "" {
+		if d, err := timing.ParseDuration(v); err == nil && d > 0 {
+			return d, "upper bound from RuntimeMaxSec=" + v
+		}
+	}
+
+	return 0, ""
+}
+
+func hasLockWrapper(service *types.UnitFile) bool {
+	for _, d := range service.GetDirectives("Service", "ExecStart") {
+		for _, field := range strings.Fields(strings.TrimLeft(d.Value, "-@:+!")) {
+			base := field[strings.LastIndex(field, "/")+1:]
+			for _, wrapper := range lockWrappers {
+				if base == wrapper {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
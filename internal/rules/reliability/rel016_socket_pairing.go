@@ -0,0 +1,182 @@
+package reliability
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// socketRule reports one kind of problem found by validation.ValidateSocket,
+// on the socket or service unit whose directive causes it
+type socketRule struct {
+	rules.BaseRule
+	kinds        []string
+	capabilities rules.Capability
+	// skipVendor skips services shipped in /usr/lib, whose packages decide
+	// how they are started
+	skipVendor bool
+}
+
+func init() {
+	socketRules := []*socketRule{
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL016",
+				RuleName:        "Socket-activated service also enabled",
+				RuleDescription: "A service enabled with WantedBy= starts at boot on its own, defeating the on-demand start of its socket.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityMedium,
+				RuleTags:        []string{"socket-activation", "boot"},
+				RuleSuggestion:  "Remove WantedBy= from the service and enable the socket instead, with Also= in the service's [Install] section.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html"},
+			},
+			kinds:        []string{validation.SocketDoubleActivation},
+			capabilities: rules.CapabilityCrossUnit,
+			skipVendor:   true,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL017",
+				RuleName:        "Socket-activated service does not require its socket",
+				RuleDescription: "A service started directly rather than through its socket runs without the file descriptors it expects unless it pulls in the socket itself.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityLow,
+				RuleTags:        []string{"socket-activation", "dependencies"},
+				RuleSuggestion:  "Add Requires= and After= on the socket to the service's [Unit] section.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html"},
+			},
+			kinds:        []string{validation.SocketMissingDependency},
+			capabilities: rules.CapabilityCrossUnit,
+			skipVendor:   true,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL018",
+				RuleName:        "Accept=yes socket without a template service",
+				RuleDescription: "A socket with Accept=yes starts an instance of a template service for each connection and fails if only a plain service exists.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityCritical,
+				RuleTags:        []string{"socket-activation", "missing-unit"},
+				RuleSuggestion:  "Rename the service to a template such as foo@.service, or set Accept=no if the service accepts connections itself.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#Accept="},
+			},
+			kinds:        []string{validation.SocketAcceptNonTemplate},
+			capabilities: rules.CapabilityCrossUnit,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL019",
+				RuleName:        "Service= names a template",
+				RuleDescription: "A socket cannot activate a template service without an instance name, so systemd refuses to load it.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"socket-activation"},
+				RuleSuggestion:  "Name an instance in Service=, such as foo@main.service, or use Accept=yes to start one instance per connection.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#Service="},
+			},
+			kinds:        []string{validation.SocketTemplateService},
+			capabilities: rules.CapabilityCrossUnit,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL020",
+				RuleName:        "Socket owner does not exist",
+				RuleDescription: "systemd cannot create a socket owned by a user or group that does not exist, so the socket fails to start.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"socket-activation", "user", "permissions"},
+				RuleSuggestion:  "Create the user or group, for example with a sysusers.d entry, or fix SocketUser=/SocketGroup=.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#SocketUser="},
+			},
+			kinds:        []string{validation.SocketMissingUser, validation.SocketMissingGroup},
+			capabilities: rules.CapabilityFilesystem,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL037",
+				RuleName:        "Sockets listen on the same address",
+				RuleDescription: "Two sockets on the same port and protocol, on the same address or with either on every address, or on the same unix socket path or abstract name, cannot both bind it: the one started second fails with \"Address already in use\".",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"socket-activation", "network"},
+				RuleSuggestion:  "Move one of the sockets to another port or path, or bind each to its own address instead of every address.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#ListenStream="},
+			},
+			kinds:        []string{validation.SocketPortConflict},
+			capabilities: rules.CapabilityCrossUnit,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL038",
+				RuleName:        "Socket address already in use",
+				RuleDescription: "A process other than systemd already listens on the port or unix socket of an enabled or failed socket, so the socket cannot bind it and fails to start.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"socket-activation", "network"},
+				RuleSuggestion:  "Stop the process holding the address, for example a service that also listens itself instead of using the socket, or move the socket to another port or path.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#ListenStream="},
+			},
+			kinds: []string{validation.SocketPortInUse, validation.SocketPathInUse},
+			// Listening sockets are read from /proc/net of the running system
+			capabilities: rules.CapabilityFilesystem | rules.CapabilityRuntime,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL039",
+				RuleName:        "Stale socket file",
+				RuleDescription: "The unix socket path of an enabled or failed socket already exists, but no process listens on it, as a daemon that crashed leaves it behind. systemd removes such a file before binding, but a daemon that binds the path itself fails with \"Address already in use\", and a file that is not a socket cannot be bound at all.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityMedium,
+				RuleTags:        []string{"socket-activation", "filesystem"},
+				RuleSuggestion:  "Remove the stale file, and let the socket unit own the path, or set RemoveOnStop=yes so that the file goes away with the socket.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#RemoveOnStop="},
+			},
+			kinds:        []string{validation.SocketStalePath},
+			capabilities: rules.CapabilityFilesystem | rules.CapabilityRuntime,
+		},
+	}
+	for _, r := range socketRules {
+		rules.Register(r)
+	}
+}
+
+func (r *socketRule) Capabilities() rules.Capability { return r.capabilities }
+
+func (r *socketRule) AppliesTo() []string { return []string{"socket"} }
+
+func (r *socketRule) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	// The owner checks need the filesystem, the pairing checks other units
+	if r.capabilities&rules.CapabilityFilesystem != 0 && ctx.FileSystem == nil {
+		return nil
+	}
+
+	var issues []types.Issue
+	for _, p := range validation.ValidateSocket(unit, ctx.AllUnits, ctx.FileSystem).Problems {
+		if !containsKind(r.kinds, p.Kind) || (r.skipVendor && strings.Contains(p.File, "/lib/systemd/system/")) {
+			continue
+		}
+		issue := r.NewIssue(unit, p.Reason, nil)
+		issue.Unit = p.Unit
+		if p.File != "" {
+			issue.File = p.File
+		}
+		if p.Line > 0 {
+			line := p.Line
+			issue.Line = &line
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+func containsKind(kinds []string, kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
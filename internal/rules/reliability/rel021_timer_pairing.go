@@ -0,0 +1,209 @@
+package reliability
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&REL021{})
+	rules.Register(&REL022{})
+	rules.Register(&REL023{})
+}
+
+// REL021 - Several timers trigger the same service
+type REL021 struct{}
+
+func (r *REL021) ID() string   { return "REL021" }
+func (r *REL021) Name() string { return "Several timers trigger the same service" }
+
+func (r *REL021) Description() string {
+	return "A service triggered by more than one timer runs on each of their schedules, which is often a leftover rather than intended."
+}
+
+func (r *REL021) Category() types.Category       { return types.CategoryReliability }
+func (r *REL021) Severity() types.Severity       { return types.SeverityLow }
+func (r *REL021) Tags() []string                 { return []string{"timer", "scheduling"} }
+func (r *REL021) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
+
+func (r *REL021) Suggestion() string {
+	return "Remove the extra timer, or list every schedule as its own OnCalendar= line in a single timer."
+}
+
+func (r *REL021) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.timer.html#Unit="}
+}
+
+// Check reports every timer of a service but the first by name, so each
+// extra timer is reported once
+func (r *REL021) AppliesTo() []string { return []string{"timer"} }
+func (r *REL021) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	serviceName := rules.TimerServiceName(unit)
+	service, ok := ctx.FindUnit(serviceName)
+	if !ok {
+		return nil
+	}
+	timers := ctx.TimersFor(service)
+	if len(timers) < 2 || timers[0].Name == unit.Name {
+		return nil
+	}
+
+	var others []string
+	for _, timer := range timers {
+		if timer.Name != unit.Name {
+			others = append(others, timer.Name)
+		}
+	}
+
+	issue := types.Issue{
+		RuleID:      r.ID(),
+		RuleName:    r.Name(),
+		Severity:    r.Severity(),
+		Category:    r.Category(),
+		Tags:        r.Tags(),
+		Unit:        unit.Name,
+		File:        unit.Path,
+		Description: serviceName + " is triggered by " + unit.Name + " and also by " + strings.Join(others, ", ") + ", so it runs on each of their schedules.",
+		Suggestion:  r.Suggestion(),
+		References:  r.References(),
+	}
+	if d := lastDirective(unit, "Timer", "Unit"); d != nil {
+		line := d.Line
+		issue.Line = &line
+		issue.Directive, issue.Value, issue.Section = "Unit", d.Value, "Timer"
+	}
+	return []types.Issue{issue}
+}
+
+// REL022 - Timer triggers a service that restarts itself
+type REL022 struct{}
+
+func (r *REL022) ID() string   { return "REL022" }
+func (r *REL022) Name() string { return "Timer triggers a service with Restart=always" }
+
+func (r *REL022) Description() string {
+	return "A timer-triggered service that restarts after a successful run never stays stopped, so it runs continuously instead of on the timer's schedule."
+}
+
+func (r *REL022) Category() types.Category       { return types.CategoryReliability }
+func (r *REL022) Severity() types.Severity       { return types.SeverityMedium }
+func (r *REL022) Tags() []string                 { return []string{"timer", "scheduling", "restart"} }
+func (r *REL022) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
+
+func (r *REL022) Suggestion() string {
+	return "Use Restart=on-failure or Restart=no and let the timer start the service again."
+}
+
+func (r *REL022) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#Restart="}
+}
+
+func (r *REL022) AppliesTo() []string { return []string{"service"} }
+
+func (r *REL022) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	d := lastDirective(unit, "Service", "Restart")
+	if d == nil || (d.Value != "always" && d.Value != "on-success") {
+		return nil
+	}
+	timers := ctx.TimersFor(unit)
+	if len(timers) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, timer := range timers {
+		names = append(names, timer.Name)
+	}
+
+	line := d.Line
+	return []types.Issue{{
+		RuleID:      r.ID(),
+		RuleName:    r.Name(),
+		Severity:    r.Severity(),
+		Category:    r.Category(),
+		Tags:        r.Tags(),
+		Unit:        unit.Name,
+		File:        unit.Path,
+		Line:        &line,
+		Description: unit.Name + " is triggered by " + strings.Join(names, ", ") + " but has Restart=" + d.Value + ", so systemd restarts it after each successful run instead of waiting for the timer.",
+		Suggestion:  r.Suggestion(),
+		References:  r.References(),
+		Directive:   "Restart",
+		Value:       d.Value,
+		Section:     "Service",
+		Expected:    "on-failure",
+	}}
+}
+
+// REL023 - AccuracySec= longer than the timer interval
+type REL023 struct{}
+
+func (r *REL023) ID() string   { return "REL023" }
+func (r *REL023) Name() string { return "AccuracySec= longer than the timer interval" }
+
+func (r *REL023) Description() string {
+	return "systemd may delay each trigger by up to AccuracySec=, so an accuracy longer than the interval lets triggers drift past the next one and be merged."
+}
+
+func (r *REL023) Category() types.Category { return types.CategoryReliability }
+func (r *REL023) Severity() types.Severity { return types.SeverityMedium }
+func (r *REL023) Tags() []string           { return []string{"timer", "scheduling"} }
+
+func (r *REL023) Suggestion() string {
+	return "Lower AccuracySec= below the interval, or remove it to use the default of one minute."
+}
+
+func (r *REL023) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.timer.html#AccuracySec="}
+}
+
+func (r *REL023) AppliesTo() []string { return []string{"timer"} }
+
+func (r *REL023) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	d := lastDirective(unit, "Timer", "AccuracySec")
+	if d == nil {
+		return nil
+	}
+	accuracy, err := timing.ParseDuration(d.Value)
+	if err != nil {
+		return nil
+	}
+	interval, trigger, _ := timerInterval(unit)
+	if interval == 0 || accuracy <= interval {
+		return nil
+	}
+
+	line := d.Line
+	return []types.Issue{{
+		RuleID:      r.ID(),
+		RuleName:    r.Name(),
+		Severity:    r.Severity(),
+		Category:    r.Category(),
+		Tags:        r.Tags(),
+		Unit:        unit.Name,
+		File:        unit.Path,
+		Line:        &line,
+		Description: "AccuracySec=" + d.Value + " lets systemd delay each trigger by up to " + timing.FormatDuration(accuracy) + ", longer than the " + timing.FormatDuration(interval) + " interval of " + trigger + ".",
+		Suggestion:  r.Suggestion(),
+		References:  r.References(),
+		Directive:   "AccuracySec",
+		Value:       d.Value,
+		Section:     "Timer",
+	}}
+}
+
+// lastDirective returns the directive that takes effect when a key is set
+// more than once, or nil when it is not set
+func lastDirective(unit *types.UnitFile, section, key string) *types.Directive {
+	directives := unit.GetDirectives(section, key)
+	if len(directives) == 0 {
+		return nil
+	}
+	return &directives[len(directives)-1]
+}
@@ -0,0 +1,126 @@
+package reliability
+
+import (
+	"time"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&REL024{})
+	rules.Register(&REL025{})
+}
+
+// REL024 - OnCalendar= expression systemd cannot parse
+type REL024 struct{}
+
+func (r *REL024) ID() string   { return "REL024" }
+func (r *REL024) Name() string { return "Invalid OnCalendar= expression" }
+
+func (r *REL024) Description() string {
+	return "systemd ignores an OnCalendar= expression it cannot parse, so the timer never fires on that schedule."
+}
+
+func (r *REL024) Category() types.Category { return types.CategoryReliability }
+func (r *REL024) Severity() types.Severity { return types.SeverityHigh }
+func (r *REL024) Tags() []string           { return []string{"timer", "scheduling", "syntax"} }
+
+func (r *REL024) Suggestion() string {
+	return "Fix the component named in the description, and check the result with 'systemd-analyze calendar'."
+}
+
+func (r *REL024) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.time.html#Calendar%20Events"}
+}
+
+func (r *REL024) AppliesTo() []string { return []string{"timer"} }
+
+func (r *REL024) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	var issues []types.Issue
+	for _, invalid := range validation.ValidateTimer(unit, ctx.AllUnits).InvalidOnCalendar {
+		// An empty OnCalendar= resets the list, as drop-ins do
+		if invalid.Value == "" {
+			continue
+		}
+		line := invalid.Line
+		issues = append(issues, types.Issue{
+			RuleID:      r.ID(),
+			RuleName:    r.Name(),
+			Severity:    r.Severity(),
+			Category:    r.Category(),
+			Tags:        r.Tags(),
+			Unit:        unit.Name,
+			File:        unit.Path,
+			Line:        &line,
+			Description: "OnCalendar=" + invalid.Value + " cannot be parsed (" + invalid.Reason + "), so systemd ignores it.",
+			Suggestion:  r.Suggestion(),
+			References:  r.References(),
+			Directive:   "OnCalendar",
+			Value:       invalid.Value,
+			Section:     "Timer",
+		})
+	}
+	return issues
+}
+
+// REL025 - OnCalendar= expression that never elapses again
+type REL025 struct{}
+
+func (r *REL025) ID() string   { return "REL025" }
+func (r *REL025) Name() string { return "OnCalendar= expression never elapses" }
+
+func (r *REL025) Description() string {
+	return "An OnCalendar= expression for a date in the past or one that does not exist, such as February 30, never triggers the timer."
+}
+
+func (r *REL025) Category() types.Category { return types.CategoryReliability }
+func (r *REL025) Severity() types.Severity { return types.SeverityMedium }
+func (r *REL025) Tags() []string           { return []string{"timer", "scheduling"} }
+
+func (r *REL025) Suggestion() string {
+	return "Fix the date, or remove the expression if the one-off job has run."
+}
+
+func (r *REL025) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.time.html#Calendar%20Events"}
+}
+
+func (r *REL025) AppliesTo() []string { return []string{"timer"} }
+
+func (r *REL025) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	now := time.Now()
+	var issues []types.Issue
+	for _, d := range unit.GetDirectives("Timer", "OnCalendar") {
+		calendar, err := timing.ParseCalendar(d.Value)
+		if err != nil {
+			// Reported by REL024
+			continue
+		}
+		if _, ok := calendar.Next(now); ok {
+			continue
+		}
+		line := d.Line
+		issues = append(issues, types.Issue{
+			RuleID:      r.ID(),
+			RuleName:    r.Name(),
+			Severity:    r.Severity(),
+			Category:    r.Category(),
+			Tags:        r.Tags(),
+			Unit:        unit.Name,
+			File:        unit.Path,
+			Line:        &line,
+			Description: "OnCalendar=" + d.Value + " (" + calendar.String() + ") never elapses after " + now.Format("2006-01-02") + ", so it never triggers the timer.",
+			Suggestion:  r.Suggestion(),
+			References:  r.References(),
+			Directive:   "OnCalendar",
+			Value:       d.Value,
+			Section:     "Timer",
+		})
+	}
+	return issues
+}
@@ -0,0 +1,85 @@
+package reliability
+
+import (
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// envFileRule reports one kind of problem found by
+// validation.ValidateEnvironmentFiles, at the line to change
+type envFileRule struct {
+	rules.BaseRule
+	kind string
+}
+
+func init() {
+	envFileRules := []*envFileRule{
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL026",
+				RuleName:        "EnvironmentFile= is empty",
+				RuleDescription: "An environment file that exists but is empty usually means the configuration was never deployed, so the service starts without its settings.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityLow,
+				RuleTags:        []string{"environment", "configuration"},
+				RuleSuggestion:  "Fill in the environment file, or remove EnvironmentFile= if the service needs no settings from it.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#EnvironmentFile="},
+			},
+			kind: validation.EnvFileEmpty,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL027",
+				RuleName:        "Syntax error in environment file",
+				RuleDescription: "systemd ignores environment file lines without a valid KEY=VALUE assignment, and keeps unquoted spaces that a shell sourcing the same file would split on.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityMedium,
+				RuleTags:        []string{"environment", "syntax"},
+				RuleSuggestion:  "Write one KEY=VALUE per line, with a name of letters, digits and underscores, and quote values containing spaces.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#EnvironmentFile="},
+			},
+			kind: validation.EnvFileSyntax,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL028",
+				RuleName:        "Environment= overridden by EnvironmentFile=",
+				RuleDescription: "Variables from EnvironmentFile= override Environment= whatever the order of the directives, so an inline value that differs from the file's is silently ignored.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityLow,
+				RuleTags:        []string{"environment", "configuration"},
+				RuleSuggestion:  "Set the variable in one place: remove it from Environment= or from the environment file.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#EnvironmentFile="},
+			},
+			kind: validation.EnvFileOverride,
+		},
+	}
+	for _, r := range envFileRules {
+		rules.Register(r)
+	}
+}
+
+func (r *envFileRule) Capabilities() rules.Capability { return rules.CapabilityFilesystem }
+
+func (r *envFileRule) AppliesTo() []string { return []string{"service"} }
+
+func (r *envFileRule) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	if ctx.FileSystem == nil {
+		return nil
+	}
+
+	var issues []types.Issue
+	for _, p := range validation.ValidateEnvironmentFiles(unit, ctx.FileSystem).Problems {
+		if p.Kind != r.kind {
+			continue
+		}
+		issue := r.NewIssue(unit, p.Reason, nil)
+		issue.File = p.File
+		line := p.Line
+		issue.Line = &line
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,79 @@
+package reliability
+
+import (
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// execRule reports one kind of problem found by
+// validation.ValidateExecCommands, at the line of the command
+type execRule struct {
+	rules.BaseRule
+	kind string
+}
+
+func init() {
+	execRules := []*execRule{
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL029",
+				RuleName:        "Shell syntax in Exec command without a shell",
+				RuleDescription: "systemd does not run Exec commands through a shell, so pipes, redirections, && and $(...) are passed to the program as literal arguments.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityMedium,
+				RuleTags:        []string{"exec", "syntax"},
+				RuleSuggestion:  "Wrap the command in /bin/sh -c '...', move it into a script, or use StandardOutput= for redirections.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#Command%20lines"},
+			},
+			kind: validation.ExecShellSyntax,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL030",
+				RuleName:        "Several ExecStart= commands outside Type=oneshot",
+				RuleDescription: "Only Type=oneshot services may run more than one ExecStart= command; systemd refuses to load any other service that has several.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"exec", "syntax"},
+				RuleSuggestion:  "Move the extra commands to ExecStartPre=, or set Type=oneshot if the service only runs commands to completion. In a drop-in, reset the list with an empty ExecStart= first.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#ExecStart="},
+			},
+			kind: validation.ExecMultipleStart,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL031",
+				RuleName:        "Relative config path without WorkingDirectory=",
+				RuleDescription: "A relative path in an Exec command resolves against the service's working directory, which is / when WorkingDirectory= is not set.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityLow,
+				RuleTags:        []string{"exec", "paths"},
+				RuleSuggestion:  "Use an absolute path, or set WorkingDirectory= to the directory the path is relative to.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#WorkingDirectory="},
+			},
+			kind: validation.ExecRelativeConfig,
+		},
+	}
+	for _, r := range execRules {
+		rules.Register(r)
+	}
+}
+
+func (r *execRule) AppliesTo() []string { return []string{"service"} }
+
+func (r *execRule) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	var issues []types.Issue
+	for _, p := range validation.ValidateExecCommands(unit).Problems {
+		if p.Kind != r.kind {
+			continue
+		}
+		issue := r.NewIssue(unit, p.Reason, nil)
+		line := p.Line
+		issue.Line = &line
+		issue.Directive, issue.Section = p.Directive, "Service"
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,79 @@
+package reliability
+
+import (
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// pidFileRule reports one kind of problem found by
+// validation.ValidatePIDFile, at the PIDFile= line
+type pidFileRule struct {
+	rules.BaseRule
+	kind string
+}
+
+func init() {
+	pidFileRules := []*pidFileRule{
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL032",
+				RuleName:        "PIDFile= below /var/run",
+				RuleDescription: "/var/run is a legacy symlink to /run; systemd rewrites PIDFile= paths below it and warns each time the unit is loaded.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityLow,
+				RuleTags:        []string{"pidfile", "paths"},
+				RuleSuggestion:  "Replace /var/run with /run in PIDFile= and in the daemon's configuration.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#PIDFile="},
+			},
+			kind: validation.PIDFileLegacyRun,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL033",
+				RuleName:        "PIDFile= in a read-only directory",
+				RuleDescription: "A daemon cannot write its PID file where ProtectSystem= or ReadOnlyPaths= make the directory read-only, so a Type=forking service times out on start.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"pidfile", "sandboxing"},
+				RuleSuggestion:  "Move the PID file to /run/<name>/ with RuntimeDirectory=<name>, or add its directory to ReadWritePaths=.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#ProtectSystem="},
+			},
+			kind: validation.PIDFileNotWritable,
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL034",
+				RuleName:        "PIDFile= in /run without RuntimeDirectory=",
+				RuleDescription: "/run is emptied at boot, so a subdirectory holding the PID file is missing unless RuntimeDirectory= creates it before the service starts.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityMedium,
+				RuleTags:        []string{"pidfile", "paths"},
+				RuleSuggestion:  "Add RuntimeDirectory= naming the subdirectory of /run that holds the PID file.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#RuntimeDirectory="},
+			},
+			kind: validation.PIDFileNoRuntimeDirectory,
+		},
+	}
+	for _, r := range pidFileRules {
+		rules.Register(r)
+	}
+}
+
+func (r *pidFileRule) AppliesTo() []string { return []string{"service"} }
+
+func (r *pidFileRule) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	var issues []types.Issue
+	for _, p := range validation.ValidatePIDFile(unit) {
+		if p.Kind != r.kind {
+			continue
+		}
+		issue := r.NewIssue(unit, p.Reason, nil)
+		line := p.Line
+		issue.Line = &line
+		issue.Directive, issue.Value, issue.Section = "PIDFile", p.Path, "Service"
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,80 @@
+package reliability
+
+import (
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// execPathRule reports the Exec commands validation.ValidateDirectives finds
+// missing or not executable through the FileSystem, at the line of the command
+type execPathRule struct {
+	rules.BaseRule
+	// problems picks the commands the rule reports from the validation
+	problems func(validation.DirectiveValidation) []validation.MissingExec
+	reason   string
+}
+
+func init() {
+	execPathRules := []*execPathRule{
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL035",
+				RuleName:        "Exec command not found",
+				RuleDescription: "The program an Exec command runs does not exist on the target, so the command fails with status 203/EXEC.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"exec", "paths"},
+				RuleSuggestion:  "Install the program or fix the path. Prefix the command with - if it may be missing.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#Command%20lines"},
+			},
+			problems: func(v validation.DirectiveValidation) []validation.MissingExec { return v.MissingExecutables },
+			reason:   "does not exist",
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "REL036",
+				RuleName:        "Exec command not executable",
+				RuleDescription: "The program an Exec command runs has no execute permission, so the command fails with status 203/EXEC.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"exec", "paths"},
+				RuleSuggestion:  "Make the program executable, or run it through its interpreter, such as /bin/sh script.sh.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#Command%20lines"},
+			},
+			problems: func(v validation.DirectiveValidation) []validation.MissingExec { return v.NotExecutable },
+			reason:   "is not executable",
+		},
+	}
+	for _, r := range execPathRules {
+		rules.Register(r)
+	}
+}
+
+func (r *execPathRule) Capabilities() rules.Capability { return rules.CapabilityFilesystem }
+func (r *execPathRule) AppliesTo() []string            { return []string{"service", "socket"} }
+
+func (r *execPathRule) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	if ctx.FileSystem == nil {
+		return nil
+	}
+
+	var issues []types.Issue
+	for _, m := range r.problems(validation.ValidateDirectives(unit, ctx.FileSystem)) {
+		// systemd ignores the failure of a command prefixed with -
+		if m.Optional {
+			continue
+		}
+		issue := r.NewIssue(unit, m.Directive+"= runs "+m.Path+", which "+r.reason+".", nil)
+		line := m.Line
+		issue.Line = &line
+		issue.Directive, issue.Value = m.Directive, m.Path
+		issue.Section = "Service"
+		if unit.IsSocket() {
+			issue.Section = "Socket"
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
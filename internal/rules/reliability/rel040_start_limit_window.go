@@ -0,0 +1,152 @@
+package reliability
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&REL040{})
+}
+
+// REL040 - Restart delays that do not fit the start rate limit
+//
+// REL002 and REL006 look at RestartSec= and StartLimitBurst= on their own.
+// Whether start rate limiting ends a crash loop depends on both: a service
+// that crashes right after starting is started StartLimitBurst times within
+// the sum of its restart delays, and the limit stops it only when that sum is
+// shorter than StartLimitIntervalSec=.
+type REL040 struct{}
+
+func (r *REL040) ID() string   { return "REL040" }
+func (r *REL040) Name() string { return "Restart delay does not fit start limit" }
+
+func (r *REL040) Description() string {
+	return "When StartLimitBurst= restarts, RestartSec= apart, take longer than StartLimitIntervalSec=, start rate limiting never stops a crash loop and the service restarts forever. When they take only seconds, a short outage, such as a database that restarts, leaves the service permanently failed."
+}
+
+func (r *REL040) Category() types.Category { return types.CategoryReliability }
+func (r *REL040) Severity() types.Severity { return types.SeverityMedium }
+func (r *REL040) Tags() []string           { return []string{"availability", "restart-loop"} }
+
+func (r *REL040) Suggestion() string {
+	return "Size StartLimitIntervalSec= to more than StartLimitBurst= times RestartSec=, and RestartSec= so that the restarts span long enough to ride out a short outage, for example RestartSec=5s with StartLimitBurst=5 and StartLimitIntervalSec=60s."
+}
+
+func (r *REL040) References() []string {
+	return []string{
+		"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#StartLimitIntervalSec=interval",
+		"https://www.freedesktop.org/software/systemd/man/systemd.service.html#RestartSec=",
+	}
+}
+
+func (r *REL040) ExampleBad() string {
+	return "[Unit]\nStartLimitIntervalSec=10\nStartLimitBurst=5\n\n[Service]\nExecStart=/usr/bin/app\nRestart=on-failure\nRestartSec=30\n"
+}
+func (r *REL040) ExampleGood() string {
+	return "[Unit]\nStartLimitIntervalSec=300\nStartLimitBurst=5\n\n[Service]\nExecStart=/usr/bin/app\nRestart=on-failure\nRestartSec=30\n"
+}
+
+func (r *REL040) AppliesTo() []string { return []string{"service"} }
+
+func (r *REL040) Params() []rules.Param {
+	return []rules.Param{{Name: "min_give_up", Kind: rules.ParamDuration, Default: "10s", Description: "Shortest time a crash loop may restart for before the start limit stops it"}}
+}
+
+func (r *REL040) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	restart := unit.GetDirective("Service", "Restart")
+	if restart == "" || restart == "no" {
+		return nil
+	}
+	// With neither set, the manager defaults apply, which REL006 reports
+	limit := timing.ParseStartLimit(unit, ctx.SystemConfig)
+	if !limit.Configured && !unit.HasDirective("Service", "RestartSec") {
+		return nil
+	}
+	if limit.Interval == 0 || limit.Burst <= 0 {
+		return nil
+	}
+	timeouts := timing.ParseTimeouts(unit, ctx.SystemConfig)
+
+	crashes := restartSpan(unit, timeouts.RestartSec, limit.Burst, 0)
+	limits := fmt.Sprintf("StartLimitBurst=%d within StartLimitIntervalSec=%s", limit.Burst, timing.FormatDuration(limit.Interval))
+	var description, directive string
+	switch {
+	case crashes >= limit.Interval:
+		description = fmt.Sprintf("%d restarts with RestartSec=%s take at least %s, no less than %s, so start rate limiting never stops a crash loop and the service restarts forever.", limit.Burst, timing.FormatDuration(timeouts.RestartSec), timing.FormatDuration(crashes), limits)
+		directive = "StartLimitIntervalSec"
+	case crashes < ctx.DurationParam(r, "min_give_up"):
+		description = fmt.Sprintf("With RestartSec=%s and %s, a service that crashes on start is permanently failed after %d restarts in %s, too short to ride out an outage of a few seconds.", timing.FormatDuration(timeouts.RestartSec), limits, limit.Burst, timing.FormatDuration(crashes))
+		directive = "RestartSec"
+	case unit.HasDirective("Service", "TimeoutStartSec") && timeouts.TimeoutStartSec > 0:
+		// Starts that hang until TimeoutStartSec= each add the timeout
+		hangs := restartSpan(unit, timeouts.RestartSec, limit.Burst, timeouts.TimeoutStartSec)
+		if hangs < limit.Interval {
+			return nil
+		}
+		description = fmt.Sprintf("Starts that time out after TimeoutStartSec=%s take %s for %d restarts with RestartSec=%s, no less than %s, so start rate limiting never stops a service that hangs on start.", timing.FormatDuration(timeouts.TimeoutStartSec), timing.FormatDuration(hangs), limit.Burst, timing.FormatDuration(timeouts.RestartSec), limits)
+		directive = "StartLimitIntervalSec"
+	default:
+		return nil
+	}
+
+	issue := types.Issue{
+		RuleID:      r.ID(),
+		RuleName:    r.Name(),
+		Severity:    r.Severity(),
+		Category:    r.Category(),
+		Tags:        r.Tags(),
+		Unit:        unit.Name,
+		File:        unit.Path,
+		Description: description,
+		Suggestion:  r.Suggestion(),
+		References:  r.References(),
+		Directive:   directive,
+		Section:     "Unit",
+	}
+	if directive == "RestartSec" {
+		issue.Section = "Service"
+	}
+	if ds := unit.GetDirectives(issue.Section, directive); len(ds) > 0 {
+		d := ds[len(ds)-1]
+		issue.Value = d.Value
+		if d.Line > 0 {
+			line := d.Line
+			issue.Line = &line
+		}
+	}
+	return []types.Issue{issue}
+}
+
+// restartSpan returns how long a service takes to be started burst times
+// when each start fails after the given time, adding the delay of each
+// restart. With RestartSteps= and RestartMaxDelaySec=, the delay grows from
+// RestartSec= to RestartMaxDelaySec= over that many restarts.
+func restartSpan(unit *types.UnitFile, restartSec time.Duration, burst int, failAfter time.Duration) time.Duration {
+	maxDelay := restartSec
+	if d, err := timing.ParseDuration(unit.GetDirective("Service", "RestartMaxDelaySec")); err == nil && d > restartSec {
+		maxDelay = d
+	}
+	steps, err := strconv.Atoi(unit.GetDirective("Service", "RestartSteps"))
+	if err != nil || steps <= 0 || restartSec <= 0 {
+		steps = 0
+	}
+
+	var span time.Duration
+	for n := 0; n < burst; n++ {
+		delay := restartSec
+		if steps > 0 {
+			ratio := float64(maxDelay) / float64(restartSec)
+			delay = time.Duration(float64(restartSec) * math.Pow(ratio, float64(min(n, steps))/float64(steps)))
+		}
+		span += failAfter + delay
+	}
+	return span
+}
@@ -1,9 +1,16 @@
 package reliability
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/supabase/sdaudit/internal/graph"
 	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/internal/validation"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -82,6 +89,40 @@ func TestREL001_RestartPolicy(t *testing.T) {
 			}
 		})
 	}
+
+	// A timer starts its service again on schedule, see REL022
+	unit := makeTestUnit(nil, nil, nil)
+	timer := makeTestTimer("test.timer", map[string]string{"OnCalendar": "daily"})
+	ctx := rules.NewContextWithUnits(unit, map[string]*types.UnitFile{unit.Name: unit, timer.Name: timer})
+	if issues := rule.Check(ctx); len(issues) != 0 {
+		t.Errorf("timer-triggered service got %+v", issues)
+	}
+}
+
+func TestStructuredFields(t *testing.T) {
+	tests := []struct {
+		rule             rules.Rule
+		service, unitDir map[string]string
+		want             [4]string // directive, value, section, expected
+	}{
+		{&REL001{}, map[string]string{"Restart": "no"}, nil, [4]string{"Restart", "no", "Service", "on-failure"}},
+		{&REL002{}, map[string]string{"Restart": "always", "RestartSec": "100ms"}, nil, [4]string{"RestartSec", "100ms", "Service", "1s"}},
+		{&REL008{}, map[string]string{"KillMode": "none"}, nil, [4]string{"KillMode", "none", "Service", "control-group"}},
+		{&REL010{}, nil, map[string]string{"BindsTo": "db.service"}, [4]string{"BindsTo", "db.service", "Unit", ""}},
+	}
+	for _, tt := range tests {
+		unit := makeTestUnit(tt.service, tt.unitDir, map[string]string{"WantedBy": "multi-user.target"})
+		ctx := rules.NewContext(unit)
+		ctx.AllUnits = map[string]*types.UnitFile{unit.Name: unit}
+		issues := tt.rule.Check(ctx)
+		if len(issues) != 1 {
+			t.Fatalf("%s: got %d issues, want 1", tt.rule.ID(), len(issues))
+		}
+		issue := issues[0]
+		if got := [4]string{issue.Directive, issue.Value, issue.Section, issue.Expected}; got != tt.want {
+			t.Errorf("%s: directive, value, section, expected = %q, want %q", tt.rule.ID(), got, tt.want)
+		}
+	}
 }
 
 func TestREL002_RestartSec(t *testing.T) {
@@ -168,6 +209,70 @@ func TestREL003_MissingWantedBy(t *testing.T) {
 			}
 		})
 	}
+
+	// A service activated by a socket or triggered by a timer is started through it
+	unit := makeTestUnit(nil, nil, nil)
+	socket := &types.UnitFile{Name: "test.socket", Type: "socket", Sections: map[string]*types.Section{}}
+	ctx := rules.NewContextWithUnits(unit, map[string]*types.UnitFile{unit.Name: unit, socket.Name: socket})
+	if issues := rule.Check(ctx); len(issues) != 0 {
+		t.Errorf("socket-activated service got %+v", issues)
+	}
+	timer := makeTestTimer("test.timer", map[string]string{"OnCalendar": "daily"})
+	ctx = rules.NewContextWithUnits(unit, map[string]*types.UnitFile{unit.Name: unit, timer.Name: timer})
+	if issues := rule.Check(ctx); len(issues) != 0 {
+		t.Errorf("timer-triggered service got %+v", issues)
+	}
+}
+
+func TestREL005_AfterWithoutRequires(t *testing.T) {
+	rule := &REL005{}
+	content := "[Unit]\nAfter=network.target time-sync.target dbus.socket app-stack.target db.service cache.service\nAfter=db.service\nWants=log.service\nAfter=log.service\n\n[Service]\nExecStart=/usr/bin/web\n"
+	unit, err := unitfile.ParseContent("/etc/systemd/system/web.service", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	units := map[string]*types.UnitFile{"web.service": unit}
+
+	withGraph := rules.NewContextWithUnits(unit, units)
+	withGraph.Graph = graph.Build(units)
+	// A .wants/ symlink requires a unit without a directive in the unit file
+	withGraph.Graph.AddEdge(graph.Edge{From: "web.service", To: "cache.service", Type: graph.EdgeWants})
+
+	tests := []struct {
+		name string
+		ctx  *rules.Context
+		want []string
+	}{
+		{"directives", rules.NewContextWithUnits(unit, units), []string{"app-stack.target", "cache.service", "db.service"}},
+		{"graph", withGraph, []string{"app-stack.target", "db.service"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range rule.Check(tt.ctx) {
+				got = append(got, issue.Value)
+				if issue.Line == nil || *issue.Line != 2 {
+					t.Errorf("%s at line %v, want 2", issue.Value, issue.Line)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("REL005 reported %v, want %v", got, tt.want)
+			}
+
+			// A site's own target configured as a synchronization unit
+			tt.ctx.Config.SynchronizationUnits = []string{"app-stack.target"}
+			got = nil
+			for _, issue := range rule.Check(tt.ctx) {
+				got = append(got, issue.Value)
+				if issue.Line == nil || *issue.Line != 2 {
+					t.Errorf("%s at line %v, want 2", issue.Value, issue.Line)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want[1:], " ") {
+				t.Errorf("REL005 with app-stack.target configured reported %v, want %v", got, tt.want[1:])
+			}
+		})
+	}
 }
 
 func TestREL008_KillModeNone(t *testing.T) {
@@ -246,10 +351,897 @@ func TestREL010_BindsToWithoutAfter(t *testing.T) {
 	}
 }
 
+func TestREL011_FailedState(t *testing.T) {
+	rule := &REL011{}
+
+	tests := []struct {
+		name       string
+		runtime    *types.RuntimeState
+		wantIssues int
+	}{
+		{
+			name:       "no runtime state",
+			runtime:    nil,
+			wantIssues: 0,
+		},
+		{
+			name:       "active",
+			runtime:    &types.RuntimeState{ActiveState: "active", SubState: "running"},
+			wantIssues: 0,
+		},
+		{
+			name:       "failed",
+			runtime:    &types.RuntimeState{ActiveState: "failed", SubState: "failed", Result: "exit-code", ExecMainStatus: 1},
+			wantIssues: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(nil, nil, nil)
+			unit.Runtime = tt.runtime
+			issues := rule.Check(rules.NewContext(unit))
+
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("got %d issues, want %d", len(issues), tt.wantIssues)
+			}
+			if tt.wantIssues > 0 && !strings.Contains(issues[0].Description, "last exit code 1") {
+				t.Errorf("description should include the exit code, got %q", issues[0].Description)
+			}
+		})
+	}
+}
+
+func TestREL012_RestartLoop(t *testing.T) {
+	rule := &REL012{}
+
+	tests := []struct {
+		name       string
+		runtime    *types.RuntimeState
+		wantIssues int
+	}{
+		{
+			name:       "no runtime state",
+			runtime:    nil,
+			wantIssues: 0,
+		},
+		{
+			name:       "at threshold",
+			runtime:    &types.RuntimeState{ActiveState: "active", NRestarts: 5},
+			wantIssues: 0,
+		},
+		{
+			name:       "above threshold",
+			runtime:    &types.RuntimeState{ActiveState: "activating", SubState: "auto-restart", NRestarts: 42, ExecMainStatus: 203},
+			wantIssues: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(nil, nil, nil)
+			unit.Runtime = tt.runtime
+			issues := rule.Check(rules.NewContext(unit))
+
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("got %d issues, want %d", len(issues), tt.wantIssues)
+			}
+			if tt.wantIssues > 0 && !strings.Contains(issues[0].Description, "42 times") {
+				t.Errorf("description should include the restart count, got %q", issues[0].Description)
+			}
+		})
+	}
+}
+
+func TestREL013_RequiresLifecycle(t *testing.T) {
+	rule := &REL013{}
+
+	tests := []struct {
+		name       string
+		service    map[string]string
+		unit       map[string]string
+		wantIssues int
+		wantText   string
+	}{
+		{
+			name:       "non-stateful dependency",
+			unit:       map[string]string{"Requires": "app-helper.service"},
+			wantIssues: 0,
+		},
+		{
+			name:       "Requires without After",
+			unit:       map[string]string{"Requires": "postgresql.service"},
+			wantIssues: 1,
+			wantText:   "start in parallel",
+		},
+		{
+			name:       "Requires with After but no Restart",
+			unit:       map[string]string{"Requires": "redis-server.service", "After": "redis-server.service"},
+			wantIssues: 1,
+			wantText:   "BindsTo=",
+		},
+		{
+			name:       "ordered and self-healing",
+			service:    map[string]string{"Restart": "on-failure"},
+			unit:       map[string]string{"Requires": "mysql.service", "After": "mysql.service"},
+			wantIssues: 0,
+		},
+		{
+			name:       "already bound",
+			unit:       map[string]string{"Requires": "postgresql.service", "BindsTo": "postgresql.service"},
+			wantIssues: 0,
+		},
+		{
+			name:       "socket is not a backend service",
+			unit:       map[string]string{"Requires": "rabbitmq.socket"},
+			wantIssues: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(tt.service, tt.unit, nil)
+			issues := rule.Check(rules.NewContext(unit))
+
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("got %d issues, want %d", len(issues), tt.wantIssues)
+			}
+			if tt.wantIssues > 0 {
+				if !strings.Contains(issues[0].Description, tt.wantText) {
+					t.Errorf("description %q should contain %q", issues[0].Description, tt.wantText)
+				}
+				if !strings.Contains(issues[0].Description, "PartOf=") {
+					t.Error("description should include the comparison table")
+				}
+			}
+		})
+	}
+}
+
+func TestREL013_CustomPatterns(t *testing.T) {
+	rule := &REL013{}
+	unit := makeTestUnit(nil, map[string]string{"Requires": "ledger-db.service"}, nil)
+
+	ctx := rules.NewContext(unit)
+	if issues := rule.Check(ctx); len(issues) != 0 {
+		t.Fatalf("got %d issues with default patterns, want 0", len(issues))
+	}
+
+	ctx.Config.StatefulServicePatterns = []string{"*-db"}
+	if issues := rule.Check(ctx); len(issues) != 1 {
+		t.Errorf("got %d issues with custom patterns, want 1", len(issues))
+	}
+}
+
+func TestREL014_JournalRestartLoop(t *testing.T) {
+	rule := &REL014{}
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	every := func(n int, gap time.Duration) []time.Time {
+		var times []time.Time
+		for i := 0; i < n; i++ {
+			times = append(times, base.Add(time.Duration(i)*gap))
+		}
+		return times
+	}
+
+	tests := []struct {
+		name       string
+		unit       map[string]string
+		history    []time.Time
+		wantIssues int
+		wantText   string
+	}{
+		{
+			name:       "no journal data",
+			wantIssues: 0,
+		},
+		{
+			name:       "within default limits",
+			history:    every(5, time.Second),
+			wantIssues: 0,
+		},
+		{
+			name:       "slow restarts never exceed the window",
+			history:    every(20, time.Minute),
+			wantIssues: 0,
+		},
+		{
+			name:       "above default limits",
+			history:    every(8, time.Second),
+			wantIssues: 1,
+			wantText:   "default 5 per 10s",
+		},
+		{
+			name:       "rate limiting disabled",
+			unit:       map[string]string{"StartLimitIntervalSec": "0"},
+			history:    every(8, time.Second),
+			wantIssues: 1,
+			wantText:   "StartLimitIntervalSec=0",
+		},
+		{
+			name:       "configured burst",
+			unit:       map[string]string{"StartLimitBurst": "2", "StartLimitIntervalSec": "1min"},
+			history:    every(3, 10*time.Second),
+			wantIssues: 1,
+			wantText:   "StartLimitBurst=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(nil, tt.unit, nil)
+			if tt.history != nil {
+				unit.Runtime = &types.RuntimeState{StartHistory: tt.history}
+			}
+			issues := rule.Check(rules.NewContext(unit))
+
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("got %d issues, want %d", len(issues), tt.wantIssues)
+			}
+			if tt.wantIssues > 0 {
+				if !strings.Contains(issues[0].Description, tt.wantText) {
+					t.Errorf("description %q should contain %q", issues[0].Description, tt.wantText)
+				}
+				if !strings.Contains(issues[0].Description, "12:00:0") {
+					t.Errorf("description %q should list recent start times", issues[0].Description)
+				}
+			}
+		})
+	}
+}
+
+func makeTestTimer(name string, timer map[string]string) *types.UnitFile {
+	unit := &types.UnitFile{
+		Name: name,
+		Path: "/etc/systemd/system/" + name,
+		Type: "timer",
+		Sections: map[string]*types.Section{
+			"Timer": {Name: "Timer", Directives: make(map[string][]types.Directive)},
+		},
+	}
+	for k, v := range timer {
+		unit.Sections["Timer"].Directives[k] = []types.Directive{{Key: k, Value: v, Line: 2}}
+	}
+	return unit
+}
+
+func TestREL015_TimerOverlap(t *testing.T) {
+	rule := &REL015{}
+
+	tests := []struct {
+		name       string
+		timer      map[string]string
+		service    map[string]string
+		runs       []time.Duration
+		wantIssues int
+		wantText   []string
+	}{
+		{
+			name:       "no runtime estimate",
+			timer:      map[string]string{"OnCalendar": "hourly"},
+			service:    map[string]string{"ExecStart": "/usr/bin/backup"},
+			wantIssues: 0,
+		},
+		{
+			name:       "journal runtime longer than interval",
+			timer:      map[string]string{"OnCalendar": "hourly"},
+			service:    map[string]string{"ExecStart": "/usr/bin/backup"},
+			runs:       []time.Duration{80 * time.Minute, 90 * time.Minute, 100 * time.Minute},
+			wantIssues: 1,
+			wantText:   []string{"every 1h0m0s", "1h30m0s", "median of 3 runs in the journal", "no concurrency guard"},
+		},
+		{
+			name:       "journal runtime shorter than interval",
+			timer:      map[string]string{"OnCalendar": "daily"},
+			service:    map[string]string{"ExecStart": "/usr/bin/backup"},
+			runs:       []time.Duration{90 * time.Minute},
+			wantIssues: 0,
+		},
+		{
+			name:       "guarded by flock",
+			timer:      map[string]string{"OnCalendar": "*:0/15"},
+			service:    map[string]string{"ExecStart": "/usr/bin/flock -n /run/backup.lock /usr/bin/backup"},
+			runs:       []time.Duration{20 * time.Minute},
+			wantIssues: 1,
+			wantText:   []string{"every 15m0s"},
+		},
+		{
+			name:       "RuntimeMaxSec as proxy",
+			timer:      map[string]string{"OnUnitActiveSec": "30min"},
+			service:    map[string]string{"RuntimeMaxSec": "2h"},
+			wantIssues: 1,
+			wantText:   []string{"RuntimeMaxSec=2h"},
+		},
+		{
+			name:       "OnUnitInactiveSec cannot overlap",
+			timer:      map[string]string{"OnUnitInactiveSec": "5min"},
+			service:    map[string]string{"RuntimeMaxSec": "2h"},
+			wantIssues: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer := makeTestTimer("backup.timer", tt.timer)
+			service := makeTestUnit(tt.service, nil, nil)
+			service.Name = "backup.service"
+			if tt.runs != nil {
+				service.Runtime = &types.RuntimeState{RunDurations: tt.runs}
+			}
+
+			ctx := rules.NewContextWithUnits(timer, map[string]*types.UnitFile{timer.Name: timer, service.Name: service})
+			issues := rule.Check(ctx)
+
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("got %d issues, want %d", len(issues), tt.wantIssues)
+			}
+			for _, text := range tt.wantText {
+				if !strings.Contains(issues[0].Description, text) {
+					t.Errorf("description %q should contain %q", issues[0].Description, text)
+				}
+			}
+			if tt.name == "guarded by flock" && strings.Contains(issues[0].Description, "concurrency guard") {
+				t.Error("flock wrapper should count as a concurrency guard")
+			}
+		})
+	}
+}
+
+func TestSocketPairingRules(t *testing.T) {
+	units, err := unitfile.LoadDirectory("../../../testdata/validation/socket_pairing")
+	if err != nil {
+		t.Fatalf("failed to load units: %v", err)
+	}
+	fs := validation.NewMockFileSystem()
+	fs.Groups["api"] = true
+
+	tests := []struct {
+		rule     string
+		socket   string
+		wantUnit string
+		wantLine int
+	}{
+		{"REL016", "web.socket", "web.service", 8},
+		{"REL017", "web.socket", "web.service", 0},
+		{"REL018", "echo.socket", "echo.socket", 6},
+		{"REL019", "queue.socket", "queue.socket", 6},
+		{"REL020", "api.socket", "api.socket", 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule := rules.Get(tt.rule)
+			if rule == nil {
+				t.Fatalf("rule %s is not registered", tt.rule)
+			}
+
+			// Every other socket in the fixture pairs cleanly for this rule
+			for name, unit := range units {
+				if !unit.IsSocket() {
+					continue
+				}
+				ctx := rules.NewContextWithUnits(unit, units)
+				ctx.FileSystem = fs
+				issues := rule.Check(ctx)
+				if name != tt.socket {
+					if len(issues) != 0 {
+						t.Errorf("%s found %+v on %s", tt.rule, issues, name)
+					}
+					continue
+				}
+				if len(issues) != 1 {
+					t.Fatalf("%s found %d issues on %s, want 1", tt.rule, len(issues), name)
+				}
+				issue := issues[0]
+				if issue.Unit != tt.wantUnit || filepath.Base(issue.File) != tt.wantUnit {
+					t.Errorf("issue on %s (%s), want %s", issue.Unit, issue.File, tt.wantUnit)
+				}
+				switch {
+				case tt.wantLine == 0 && issue.Line != nil:
+					t.Errorf("Line = %d, want none", *issue.Line)
+				case tt.wantLine != 0 && (issue.Line == nil || *issue.Line != tt.wantLine):
+					t.Errorf("Line = %v, want %d", issue.Line, tt.wantLine)
+				}
+			}
+		})
+	}
+
+	// Socket owners are not checked without a filesystem
+	ctx := rules.NewContextWithUnits(units["api.socket"], units)
+	if issues := rules.Get("REL020").Check(ctx); len(issues) != 0 {
+		t.Errorf("REL020 without a filesystem found %+v", issues)
+	}
+}
+
+func TestSocketPortRules(t *testing.T) {
+	units := make(map[string]*types.UnitFile)
+	for name, content := range map[string]string{
+		"api.socket":   "[Socket]\nListenStream=8080\n",
+		"admin.socket": "[Unit]\nDescription=Admin\n\n[Socket]\nListenStream=127.0.0.1:8080\n",
+		"other.socket": "[Socket]\nListenStream=10.0.0.5:9000\n",
+		"local.socket": "[Socket]\nListenStream=127.0.0.1:9000\n",
+	} {
+		unit, err := unitfile.ParseContent("/etc/systemd/system/"+name, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		units[name] = unit
+	}
+
+	// Both sockets of a conflict report it at their own Listen line
+	for name, want := range map[string]int{"api.socket": 2, "admin.socket": 5, "other.socket": 0, "local.socket": 0} {
+		issues := rules.Get("REL037").Check(rules.NewContextWithUnits(units[name], units))
+		if want == 0 {
+			if len(issues) != 0 {
+				t.Errorf("REL037 found %+v on %s", issues, name)
+			}
+			continue
+		}
+		if len(issues) != 1 || issues[0].Unit != name || issues[0].Line == nil || *issues[0].Line != want {
+			t.Errorf("REL037 on %s = %+v, want one issue at line %d", name, issues, want)
+		}
+	}
+
+	fs := validation.NewMockFileSystem()
+	fs.Contents["/proc/net/tcp"] = "  sl  local_address rem_address   st\n   0: 0500000A:2328 00000000:0000 0A\n"
+	other := units["other.socket"]
+	other.Enabled = true
+	other.Runtime = &types.RuntimeState{ActiveState: "failed"}
+	ctx := rules.NewContextWithUnits(other, units)
+	ctx.FileSystem = fs
+	if issues := rules.Get("REL038").Check(ctx); len(issues) != 1 || !strings.Contains(issues[0].Description, "10.0.0.5:9000") {
+		t.Errorf("REL038 = %+v, want the port held on 10.0.0.5:9000", issues)
+	}
+
+	// A unix socket file left behind by a crashed daemon
+	app, err := unitfile.ParseContent("/etc/systemd/system/app.socket", "[Socket]\nListenStream=/run/app/app.sock\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Enabled = true
+	app.Runtime = &types.RuntimeState{ActiveState: "failed"}
+	fs.Files["/run/app/app.sock"] = true
+	fs.Modes["/run/app/app.sock"] = os.ModeSocket | 0660
+	ctx = rules.NewContextWithUnits(app, map[string]*types.UnitFile{"app.socket": app})
+	ctx.FileSystem = fs
+	if issues := rules.Get("REL039").Check(ctx); len(issues) != 1 || issues[0].Line == nil || *issues[0].Line != 2 {
+		t.Errorf("REL039 = %+v, want the stale socket at line 2", issues)
+	}
+}
+
+func TestTimerPairingRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		timers   map[string]map[string]string
+		service  map[string]string
+		wantUnit string
+		wantLine int
+		wantText string
+	}{
+		{
+			name: "two timers for one service",
+			rule: "REL021",
+			timers: map[string]map[string]string{
+				"backup.timer":  {"OnCalendar": "daily"},
+				"nightly.timer": {"OnCalendar": "*-*-* 03:00", "Unit": "backup.service"},
+			},
+			wantUnit: "nightly.timer", wantLine: 2,
+			wantText: "backup.service is triggered by nightly.timer and also by backup.timer",
+		},
+		{
+			name:   "one timer",
+			rule:   "REL021",
+			timers: map[string]map[string]string{"backup.timer": {"OnCalendar": "daily"}},
+		},
+		{
+			name:     "Restart=always on a timer-triggered service",
+			rule:     "REL022",
+			timers:   map[string]map[string]string{"backup.timer": {"OnCalendar": "daily"}},
+			service:  map[string]string{"Restart": "always"},
+			wantUnit: "backup.service",
+			wantText: "triggered by backup.timer but has Restart=always",
+		},
+		{
+			name:    "Restart=on-failure on a timer-triggered service",
+			rule:    "REL022",
+			timers:  map[string]map[string]string{"backup.timer": {"OnCalendar": "daily"}},
+			service: map[string]string{"Restart": "on-failure"},
+		},
+		{
+			name:     "AccuracySec longer than the interval",
+			rule:     "REL023",
+			timers:   map[string]map[string]string{"backup.timer": {"OnCalendar": "*:0/15", "AccuracySec": "1h"}},
+			wantUnit: "backup.timer", wantLine: 2,
+			wantText: "longer than the 15m0s interval of OnCalendar=*:0/15",
+		},
+		{
+			name:   "AccuracySec shorter than the interval",
+			rule:   "REL023",
+			timers: map[string]map[string]string{"backup.timer": {"OnCalendar": "daily", "AccuracySec": "1h"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := rules.Get(tt.rule)
+			if rule == nil {
+				t.Fatalf("rule %s is not registered", tt.rule)
+			}
+			service := makeTestUnit(tt.service, nil, nil)
+			service.Name = "backup.service"
+			service.Path = "/etc/systemd/system/backup.service"
+			units := map[string]*types.UnitFile{service.Name: service}
+			for name, directives := range tt.timers {
+				units[name] = makeTestTimer(name, directives)
+			}
+
+			var issues []types.Issue
+			for _, unit := range units {
+				issues = append(issues, rule.Check(rules.NewContextWithUnits(unit, units))...)
+			}
+
+			if tt.wantUnit == "" {
+				if len(issues) != 0 {
+					t.Errorf("%s found %+v, want nothing", tt.rule, issues)
+				}
+				return
+			}
+			if len(issues) != 1 {
+				t.Fatalf("%s found %d issues, want 1: %+v", tt.rule, len(issues), issues)
+			}
+			issue := issues[0]
+			if issue.Unit != tt.wantUnit || filepath.Base(issue.File) != tt.wantUnit {
+				t.Errorf("issue on %s (%s), want %s", issue.Unit, issue.File, tt.wantUnit)
+			}
+			if tt.wantLine != 0 && (issue.Line == nil || *issue.Line != tt.wantLine) {
+				t.Errorf("Line = %v, want %d", issue.Line, tt.wantLine)
+			}
+			if !strings.Contains(issue.Description, tt.wantText) {
+				t.Errorf("description %q should contain %q", issue.Description, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestCalendarRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		calendar string
+		wantText string
+	}{
+		{"hour out of range", "REL024", "Mon..Fri 25:00", `invalid hour "25": 25 is out of range 0-23`},
+		{"month out of range", "REL024", "2024-13-01", `invalid month "13"`},
+		{"valid expression", "REL024", "Mon *-05~07/1 03:00", ""},
+		{"reset", "REL024", "", ""},
+		{"date in the past", "REL025", "2020-01-01 00:00", "never elapses"},
+		{"day that does not exist", "REL025", "*-02-30", "(*-02-30 00:00:00) never elapses"},
+		{"recurring", "REL025", "weekly", ""},
+		{"invalid expression left to REL024", "REL025", "Mon..Fri 25:00", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer := makeTestTimer("backup.timer", map[string]string{"OnCalendar": tt.calendar})
+			issues := rules.Get(tt.rule).Check(rules.NewContext(timer))
+
+			if tt.wantText == "" {
+				if len(issues) != 0 {
+					t.Errorf("%s found %+v, want nothing", tt.rule, issues)
+				}
+				return
+			}
+			if len(issues) != 1 {
+				t.Fatalf("%s found %d issues, want 1: %+v", tt.rule, len(issues), issues)
+			}
+			if !strings.Contains(issues[0].Description, tt.wantText) {
+				t.Errorf("description %q should contain %q", issues[0].Description, tt.wantText)
+			}
+			if issues[0].Line == nil || *issues[0].Line != 2 {
+				t.Errorf("Line = %v, want 2", issues[0].Line)
+			}
+		})
+	}
+}
+
+func TestEnvironmentFileRules(t *testing.T) {
+	dir := "../../../testdata/validation/environment_files"
+	units, err := unitfile.LoadDirectory(dir)
+	if err != nil {
+		t.Fatalf("failed to load units: %v", err)
+	}
+	root, err := filepath.Abs(filepath.Join(dir, "root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := validation.NewRealFileSystem(root)
+
+	tests := []struct {
+		rule     string
+		unit     string
+		wantFile string
+		wantLine int
+		wantText string
+	}{
+		{"REL026", "empty.service", "empty.service", 5, "/etc/app/empty.env exists but is empty"},
+		{"REL027", "broken.service", "broken.env", 2, "unquoted value of DAEMON_OPTS contains spaces"},
+		{"REL028", "app.service", "app.service", 5, `sets it to "info"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule := rules.Get(tt.rule)
+			if rule == nil {
+				t.Fatalf("rule %s is not registered", tt.rule)
+			}
+			ctx := rules.NewContextWithUnits(units[tt.unit], units)
+			ctx.FileSystem = fs
+			issues := rule.Check(ctx)
+			if len(issues) == 0 {
+				t.Fatalf("%s found nothing on %s", tt.rule, tt.unit)
+			}
+			issue := issues[0]
+			if issue.Unit != tt.unit || filepath.Base(issue.File) != tt.wantFile || issue.Line == nil || *issue.Line != tt.wantLine {
+				t.Errorf("issue on %s at %s:%v, want %s at %s:%d", issue.Unit, issue.File, issue.Line, tt.unit, tt.wantFile, tt.wantLine)
+			}
+			if !strings.Contains(issue.Description, tt.wantText) {
+				t.Errorf("description %q should contain %q", issue.Description, tt.wantText)
+			}
+
+			// optional.service has no environment file to read
+			ctx = rules.NewContextWithUnits(units["optional.service"], units)
+			ctx.FileSystem = fs
+			if issues := rule.Check(ctx); len(issues) != 0 {
+				t.Errorf("%s found %+v on optional.service", tt.rule, issues)
+			}
+		})
+	}
+}
+
+func TestExecCommandRules(t *testing.T) {
+	units, err := unitfile.LoadDirectory("../../../testdata/validation/exec_commands")
+	if err != nil {
+		t.Fatalf("failed to load units: %v", err)
+	}
+
+	tests := []struct {
+		rule     string
+		unit     string
+		wantLine int
+		wantText string
+	}{
+		{"REL029", "pipe.service", 5, `passes "|" to /usr/bin/journalctl`},
+		{"REL030", "multi.service", 7, "ExecStart= is set 2 times"},
+		{"REL031", "relative.service", 5, "relative path conf/app.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule := rules.Get(tt.rule)
+			if rule == nil {
+				t.Fatalf("rule %s is not registered", tt.rule)
+			}
+			issues := rule.Check(rules.NewContextWithUnits(units[tt.unit], units))
+			if len(issues) != 1 {
+				t.Fatalf("%s on %s: got %d issues, want 1: %+v", tt.rule, tt.unit, len(issues), issues)
+			}
+			if issues[0].Line == nil || *issues[0].Line != tt.wantLine {
+				t.Errorf("issue at line %v, want %d", issues[0].Line, tt.wantLine)
+			}
+			if !strings.Contains(issues[0].Description, tt.wantText) {
+				t.Errorf("description %q should contain %q", issues[0].Description, tt.wantText)
+			}
+
+			for _, name := range []string{"workdir.service", "oneshot.service", "reset.service"} {
+				if issues := rule.Check(rules.NewContextWithUnits(units[name], units)); len(issues) != 0 {
+					t.Errorf("%s found %+v on %s", tt.rule, issues, name)
+				}
+			}
+		})
+	}
+}
+
+func TestExecPathRules(t *testing.T) {
+	unit, err := unitfile.ParseContent("/etc/systemd/system/app.service", `[Service]
+ExecStartPre=-/usr/local/bin/optional-setup
+ExecStartPre=/opt/app/bin/setup.sh
+ExecStart=/opt/app/bin/app --serve
+ExecStartPost=/bin/sh -c "echo started"
+ExecReload=kill -HUP $MAINPID
+ExecStop=%h/bin/stop
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := validation.NewMockFileSystem()
+	fs.Files["/opt/app/bin/setup.sh"] = true
+	fs.Files["/bin/sh"] = true
+	fs.Executables["/bin/sh"] = true
+
+	tests := []struct {
+		rule      string
+		wantLine  int
+		wantValue string
+	}{
+		{"REL035", 4, "/opt/app/bin/app"},
+		{"REL036", 3, "/opt/app/bin/setup.sh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule := rules.Get(tt.rule)
+			if rule == nil {
+				t.Fatalf("rule %s is not registered", tt.rule)
+			}
+			ctx := rules.NewContext(unit)
+			ctx.FileSystem = fs
+			issues := rule.Check(ctx)
+			if len(issues) != 1 {
+				t.Fatalf("%s: got %d issues, want 1: %+v", tt.rule, len(issues), issues)
+			}
+			if issues[0].Line == nil || *issues[0].Line != tt.wantLine {
+				t.Errorf("issue at line %v, want %d", issues[0].Line, tt.wantLine)
+			}
+			if issues[0].Value != tt.wantValue || !strings.Contains(issues[0].Description, tt.wantValue) {
+				t.Errorf("issue is about %q (%s), want %s", issues[0].Value, issues[0].Description, tt.wantValue)
+			}
+
+			if issues := rule.Check(rules.NewContext(unit)); len(issues) != 0 {
+				t.Errorf("%s without a filesystem found %+v", tt.rule, issues)
+			}
+		})
+	}
+}
+
+func TestPIDFileRules(t *testing.T) {
+	units, err := unitfile.LoadDirectory("../../../testdata/validation/pid_file")
+	if err != nil {
+		t.Fatalf("failed to load units: %v", err)
+	}
+
+	tests := []struct {
+		rule     string
+		unit     string
+		wantLine int
+		wantText string
+	}{
+		{"REL032", "legacy.service", 6, "use /run/legacy.pid"},
+		{"REL033", "strict.service", 7, "ProtectSystem=strict makes read-only"},
+		{"REL034", "norundir.service", 6, "no RuntimeDirectory= creates it"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule := rules.Get(tt.rule)
+			if rule == nil {
+				t.Fatalf("rule %s is not registered", tt.rule)
+			}
+			issues := rule.Check(rules.NewContextWithUnits(units[tt.unit], units))
+			if len(issues) != 1 {
+				t.Fatalf("%s on %s: got %d issues, want 1: %+v", tt.rule, tt.unit, len(issues), issues)
+			}
+			if issues[0].Line == nil || *issues[0].Line != tt.wantLine {
+				t.Errorf("issue at line %v, want %d", issues[0].Line, tt.wantLine)
+			}
+			if !strings.Contains(issues[0].Description, tt.wantText) {
+				t.Errorf("description %q should contain %q", issues[0].Description, tt.wantText)
+			}
+
+			for _, name := range []string{"rundir.service", "strict-rw.service"} {
+				if issues := rule.Check(rules.NewContextWithUnits(units[name], units)); len(issues) != 0 {
+					t.Errorf("%s found %+v on %s", tt.rule, issues, name)
+				}
+			}
+		})
+	}
+}
+
+func TestREL040_StartLimitWindow(t *testing.T) {
+	rule := &REL040{}
+
+	tests := []struct {
+		name      string
+		service   map[string]string
+		unit      map[string]string
+		directive string // empty for no issue
+		want      string
+	}{
+		{
+			name:    "no restart",
+			service: map[string]string{"RestartSec": "30"},
+			unit:    map[string]string{"StartLimitIntervalSec": "10"},
+		},
+		{
+			name:    "manager defaults only",
+			service: map[string]string{"Restart": "always"},
+		},
+		{
+			name:      "limit hit within seconds",
+			service:   map[string]string{"Restart": "on-failure", "RestartSec": "1"},
+			unit:      map[string]string{"StartLimitBurst": "5", "StartLimitIntervalSec": "10"},
+			directive: "RestartSec",
+			want:      "after 5 restarts in 5s",
+		},
+		{
+			name:    "restarts span exactly the minimum",
+			service: map[string]string{"Restart": "on-failure", "RestartSec": "2"},
+			unit:    map[string]string{"StartLimitBurst": "5", "StartLimitIntervalSec": "11"},
+		},
+		{
+			name:      "restarts span the interval exactly",
+			service:   map[string]string{"Restart": "on-failure", "RestartSec": "2"},
+			unit:      map[string]string{"StartLimitBurst": "5", "StartLimitIntervalSec": "10"},
+			directive: "StartLimitIntervalSec",
+			want:      "take at least 10s",
+		},
+		{
+			name:      "RestartSec longer than the default interval",
+			service:   map[string]string{"Restart": "always", "RestartSec": "30"},
+			directive: "StartLimitIntervalSec",
+			want:      "5 restarts with RestartSec=30s take at least 2m30s",
+		},
+		{
+			name:    "interval sized to the restarts",
+			service: map[string]string{"Restart": "always", "RestartSec": "30"},
+			unit:    map[string]string{"StartLimitBurst": "5", "StartLimitIntervalSec": "5min"},
+		},
+		{
+			name:    "rate limiting disabled",
+			service: map[string]string{"Restart": "always", "RestartSec": "30"},
+			unit:    map[string]string{"StartLimitIntervalSec": "0"},
+		},
+		{
+			name:      "growing delays",
+			service:   map[string]string{"Restart": "always", "RestartSec": "1", "RestartSteps": "2", "RestartMaxDelaySec": "16"},
+			unit:      map[string]string{"StartLimitBurst": "5", "StartLimitIntervalSec": "30"},
+			directive: "StartLimitIntervalSec",
+			want:      "take at least 53s",
+		},
+		{
+			name:      "hanging starts",
+			service:   map[string]string{"Restart": "always", "RestartSec": "5", "TimeoutStartSec": "20"},
+			unit:      map[string]string{"StartLimitBurst": "5", "StartLimitIntervalSec": "60"},
+			directive: "StartLimitIntervalSec",
+			want:      "take 2m5s",
+		},
+		{
+			name:    "starts time out within the interval",
+			service: map[string]string{"Restart": "always", "RestartSec": "5", "TimeoutStartSec": "5"},
+			unit:    map[string]string{"StartLimitBurst": "5", "StartLimitIntervalSec": "60"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := rule.Check(rules.NewContext(makeTestUnit(tt.service, tt.unit, nil)))
+			if tt.directive == "" {
+				if len(issues) != 0 {
+					t.Errorf("got %+v, want no issue", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || issues[0].Directive != tt.directive || !strings.Contains(issues[0].Description, tt.want) {
+				t.Errorf("got %+v, want one issue on %s saying %q", issues, tt.directive, tt.want)
+			}
+		})
+	}
+}
+
 func TestRuleMetadata(t *testing.T) {
 	testRules := []rules.Rule{
 		&REL001{},
 		&REL002{},
+		&REL011{},
+		&REL012{},
+		&REL013{},
+		&REL014{},
+		&REL015{},
+		&REL021{},
+		&REL022{},
+		&REL023{},
+		&REL024{},
+		&REL025{},
 	}
 
 	for _, rule := range testRules {
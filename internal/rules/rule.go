@@ -17,6 +17,98 @@ type Rule interface {
 	References() []string
 }
 
+// HostRule is implemented by rules that inspect host-wide configuration such
+// as system.conf. CheckHost runs once per scan with a Context whose Unit is nil
+type HostRule interface {
+	CheckHost(ctx *Context) []types.Issue
+}
+
+// AggregateRule is implemented by rules that report on the findings of other
+// rules rather than on unit files, such as a unit failing many rules at
+// once. Aggregate runs once per scan, after the unit and host checks, with
+// the issues they found; its issues come on top of them
+type AggregateRule interface {
+	Aggregate(ctx *Context, issues []types.Issue) []types.Issue
+}
+
+// UnitTypeRule is implemented by rules that only apply to some unit types,
+// such as "service" or "timer". The runners skip units of other types, so
+// Check only sees the types listed. Rules without it, or listing no types,
+// see every unit
+type UnitTypeRule interface {
+	AppliesTo() []string
+}
+
+// AppliesTo reports whether a rule applies to the type of unit. Rules
+// without AppliesTo apply to every unit
+func AppliesTo(rule Rule, unit *types.UnitFile) bool {
+	r, ok := rule.(UnitTypeRule)
+	if !ok || len(r.AppliesTo()) == 0 {
+		return true
+	}
+	if unit == nil {
+		return false
+	}
+	for _, t := range r.AppliesTo() {
+		if t == unit.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// FingerprintedRule is implemented by rules loaded at run time, whose checks
+// can change while sdaudit does not. The fingerprint changes with them.
+type FingerprintedRule interface {
+	Fingerprint() string
+}
+
+// Fingerprint identifies what a rule checks: its ID, and its fingerprint for
+// rules loaded at run time
+func Fingerprint(rule Rule) string {
+	if f, ok := rule.(FingerprintedRule); ok {
+		return rule.ID() + "@" + f.Fingerprint()
+	}
+	return rule.ID()
+}
+
+// OriginRule is implemented by rules that do not ship with sdaudit, such as
+// custom and plugin rules. Their issues carry the origin, so reports can
+// tell them apart from the built-in rules.
+type OriginRule interface {
+	Origin() string
+}
+
+// Origin returns where a rule comes from, empty for built-in rules
+func Origin(rule Rule) string {
+	if o, ok := rule.(OriginRule); ok {
+		return o.Origin()
+	}
+	return ""
+}
+
+// ReferencedRule is implemented by rules that provide typed references, such
+// as man page sections. Rules without it have their References() URLs converted
+type ReferencedRule interface {
+	TypedReferences() []types.Reference
+}
+
+// TypedReferences returns a rule's references in typed form
+func TypedReferences(rule Rule) []types.Reference {
+	if r, ok := rule.(ReferencedRule); ok {
+		return r.TypedReferences()
+	}
+	return convertReferences(rule.References())
+}
+
+func convertReferences(urls []string) []types.Reference {
+	var refs []types.Reference
+	for _, url := range urls {
+		refs = append(refs, types.ParseReference(url))
+	}
+	return refs
+}
+
 // BaseRule provides a partial implementation of Rule that can be embedded
 type BaseRule struct {
 	RuleID          string
@@ -0,0 +1,41 @@
+package security
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Hardening rules apply to the unit types that run processes under the
+// options of systemd.exec(5). Options that set up namespaces are left to
+// services and sockets: on a mount or swap unit they would hide the mount
+// from the rest of the system.
+var (
+	execTypes    = []string{"service", "socket", "mount", "swap"}
+	sandboxTypes = []string{"service", "socket"}
+)
+
+// socketCommands are the directives with which a socket unit runs processes
+var socketCommands = []string{"ExecStartPre", "ExecStartPost", "ExecStopPre", "ExecStopPost"}
+
+// execSection returns the section holding the unit's execution options, and
+// whether the unit runs any process they confine. Sockets only do with
+// ExecStartPre= and the like.
+func execSection(unit *types.UnitFile) (string, bool) {
+	section := unit.TypeSection()
+	if !unit.IsSocket() {
+		return section, true
+	}
+	for _, key := range socketCommands {
+		if unit.HasDirective(section, key) {
+			return section, true
+		}
+	}
+	return section, false
+}
+
+// inSection points a suggestion written for services at the section that
+// holds the unit's execution options
+func inSection(suggestion, section string) string {
+	return strings.ReplaceAll(suggestion, "[Service]", "["+section+"]")
+}
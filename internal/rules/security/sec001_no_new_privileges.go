@@ -30,13 +30,27 @@ func (r *SEC001) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#NoNewPrivileges="}
 }
 
+func (r *SEC001) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "NoNewPrivileges="), types.ManPage("systemd.exec", "Security")}
+}
+
+func (r *SEC001) MinSystemdVersion() int { return rules.DirectiveVersions["NoNewPrivileges"] }
+
+func (r *SEC001) ExampleBad() string { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC001) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nNoNewPrivileges=yes\n"
+}
+
+func (r *SEC001) AppliesTo() []string { return execTypes }
+
 func (r *SEC001) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
 
-	value := unit.GetDirective("Service", "NoNewPrivileges")
+	value := unit.GetDirective(section, "NoNewPrivileges")
 	if value == "" || value == "no" || value == "false" {
 		return []types.Issue{{
 			RuleID:      r.ID(),
@@ -46,9 +60,13 @@ func (r *SEC001) Check(ctx *rules.Context) []types.Issue {
 			Tags:        r.Tags(),
 			Unit:        unit.Name,
 			File:        unit.Path,
-			Description: "Service does not set NoNewPrivileges=yes, allowing potential privilege escalation.",
-			Suggestion:  r.Suggestion(),
+			Description: unit.TypeSection() + " does not set NoNewPrivileges=yes, allowing potential privilege escalation.",
+			Suggestion:  inSection(r.Suggestion(), section),
 			References:  r.References(),
+			Directive:   "NoNewPrivileges",
+			Value:       value,
+			Section:     section,
+			Expected:    "yes",
 		}}
 	}
 	return nil
@@ -30,13 +30,25 @@ func (r *SEC002) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#PrivateTmp="}
 }
 
+func (r *SEC002) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "PrivateTmp="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+
+func (r *SEC002) MinSystemdVersion() int { return rules.DirectiveVersions["PrivateTmp"] }
+
+func (r *SEC002) ExampleBad() string  { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC002) ExampleGood() string { return "[Service]\nExecStart=/usr/bin/app\nPrivateTmp=yes\n" }
+
+func (r *SEC002) AppliesTo() []string { return sandboxTypes }
+
 func (r *SEC002) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
 
-	value := unit.GetDirective("Service", "PrivateTmp")
+	value := unit.GetDirective(section, "PrivateTmp")
 	if value == "" || value == "no" || value == "false" {
 		return []types.Issue{{
 			RuleID:      r.ID(),
@@ -46,9 +58,13 @@ func (r *SEC002) Check(ctx *rules.Context) []types.Issue {
 			Tags:        r.Tags(),
 			Unit:        unit.Name,
 			File:        unit.Path,
-			Description: "Service does not enable PrivateTmp, exposing it to symlink attacks through /tmp.",
-			Suggestion:  r.Suggestion(),
+			Description: unit.TypeSection() + " does not enable PrivateTmp, exposing it to symlink attacks through /tmp.",
+			Suggestion:  inSection(r.Suggestion(), section),
 			References:  r.References(),
+			Directive:   "PrivateTmp",
+			Value:       value,
+			Section:     section,
+			Expected:    "yes",
 		}}
 	}
 	return nil
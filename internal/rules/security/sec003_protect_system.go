@@ -30,13 +30,27 @@ func (r *SEC003) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#ProtectSystem="}
 }
 
+func (r *SEC003) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "ProtectSystem="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+
+func (r *SEC003) MinSystemdVersion() int { return rules.DirectiveVersions["ProtectSystem"] }
+
+func (r *SEC003) ExampleBad() string { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC003) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nProtectSystem=strict\nReadWritePaths=/var/lib/app\n"
+}
+
+func (r *SEC003) AppliesTo() []string { return sandboxTypes }
+
 func (r *SEC003) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
 
-	value := unit.GetDirective("Service", "ProtectSystem")
+	value := unit.GetDirective(section, "ProtectSystem")
 	switch value {
 	case "strict", "full":
 		return nil
@@ -49,9 +63,13 @@ func (r *SEC003) Check(ctx *rules.Context) []types.Issue {
 			Tags:        r.Tags(),
 			Unit:        unit.Name,
 			File:        unit.Path,
-			Description: "Service uses ProtectSystem=yes which only protects /usr and /boot. Consider 'strict'.",
-			Suggestion:  r.Suggestion(),
+			Description: unit.TypeSection() + " uses ProtectSystem=yes which only protects /usr and /boot. Consider 'strict'.",
+			Suggestion:  inSection(r.Suggestion(), section),
 			References:  r.References(),
+			Directive:   "ProtectSystem",
+			Value:       value,
+			Section:     section,
+			Expected:    "strict",
 		}}
 	default:
 		return []types.Issue{{
@@ -62,9 +80,13 @@ func (r *SEC003) Check(ctx *rules.Context) []types.Issue {
 			Tags:        r.Tags(),
 			Unit:        unit.Name,
 			File:        unit.Path,
-			Description: "Service does not set ProtectSystem, allowing modification of system directories.",
-			Suggestion:  r.Suggestion(),
+			Description: unit.TypeSection() + " does not set ProtectSystem, allowing modification of system directories.",
+			Suggestion:  inSection(r.Suggestion(), section),
 			References:  r.References(),
+			Directive:   "ProtectSystem",
+			Value:       value,
+			Section:     section,
+			Expected:    "strict",
 		}}
 	}
 }
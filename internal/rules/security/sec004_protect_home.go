@@ -24,18 +24,31 @@ func (r *SEC004) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#ProtectHome="}
 }
 
+func (r *SEC004) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "ProtectHome="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+
+func (r *SEC004) MinSystemdVersion() int { return rules.DirectiveVersions["ProtectHome"] }
+
+func (r *SEC004) ExampleBad() string  { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC004) ExampleGood() string { return "[Service]\nExecStart=/usr/bin/app\nProtectHome=yes\n" }
+
+func (r *SEC004) AppliesTo() []string { return sandboxTypes }
+
 func (r *SEC004) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	value := unit.GetDirective("Service", "ProtectHome")
+	value := unit.GetDirective(section, "ProtectHome")
 	if value == "" || value == "no" || value == "false" {
 		return []types.Issue{{
 			RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(),
 			Tags: r.Tags(), Unit: unit.Name, File: unit.Path,
-			Description: "Service does not protect home directories from access.",
-			Suggestion:  r.Suggestion(), References: r.References(),
+			Description: unit.TypeSection() + " does not protect home directories from access.",
+			Suggestion:  inSection(r.Suggestion(), section), References: r.References(),
+			Directive: "ProtectHome", Value: value, Section: section, Expected: "yes",
 		}}
 	}
 	return nil
@@ -26,12 +26,19 @@ func (r *SEC005) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#User="}
 }
 
+func (r *SEC005) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "User="), types.ManPage("systemd.exec", "Credentials")}
+}
+
+func (r *SEC005) ExampleBad() string { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC005) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nUser=app\nGroup=app\n"
+}
+
+func (r *SEC005) AppliesTo() []string { return []string{"service"} }
+
 func (r *SEC005) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
-		return nil
-	}
-
 	user := unit.GetDirective("Service", "User")
 	dynamicUser := unit.GetDirective("Service", "DynamicUser")
 
@@ -70,5 +77,6 @@ func (r *SEC005) Check(ctx *rules.Context) []types.Issue {
 		Tags: r.Tags(), Unit: unit.Name, File: unit.Path,
 		Description: "Service runs as root without adequate security hardening.",
 		Suggestion:  r.Suggestion(), References: r.References(),
+		Directive: "User", Value: user, Section: "Service",
 	}}
 }
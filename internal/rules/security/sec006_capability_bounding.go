@@ -28,19 +28,32 @@ func (r *SEC006) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#CapabilityBoundingSet="}
 }
 
+func (r *SEC006) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "CapabilityBoundingSet="), types.ManPage("systemd.exec", "Capabilities")}
+}
+
+func (r *SEC006) ExampleBad() string { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC006) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nCapabilityBoundingSet=CAP_NET_BIND_SERVICE\n"
+}
+
+func (r *SEC006) AppliesTo() []string { return sandboxTypes }
+
 func (r *SEC006) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
 
-	value := unit.GetDirective("Service", "CapabilityBoundingSet")
+	value := unit.GetDirective(section, "CapabilityBoundingSet")
 	if value == "" {
 		return []types.Issue{{
 			RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(),
 			Tags: r.Tags(), Unit: unit.Name, File: unit.Path,
-			Description: "Service does not restrict Linux capabilities.",
-			Suggestion:  r.Suggestion(), References: r.References(),
+			Description: unit.TypeSection() + " does not restrict Linux capabilities.",
+			Suggestion:  inSection(r.Suggestion(), section), References: r.References(),
+			Directive: "CapabilityBoundingSet", Value: value, Section: section,
 		}}
 	}
 
@@ -51,8 +64,9 @@ func (r *SEC006) Check(ctx *rules.Context) []types.Issue {
 			return []types.Issue{{
 				RuleID: r.ID(), RuleName: r.Name(), Severity: types.SeverityMedium, Category: r.Category(),
 				Tags: r.Tags(), Unit: unit.Name, File: unit.Path,
-				Description: "Service allows dangerous capability: " + cap,
-				Suggestion:  r.Suggestion(), References: r.References(),
+				Description: unit.TypeSection() + " allows dangerous capability: " + cap,
+				Suggestion:  inSection(r.Suggestion(), section), References: r.References(),
+				Directive: "CapabilityBoundingSet", Value: value, Section: section,
 			}}
 		}
 	}
@@ -30,13 +30,23 @@ func (r *SEC007) Suggestion() string       { return "Add 'PrivateDevices=yes' to
 func (r *SEC007) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#PrivateDevices="}
 }
+func (r *SEC007) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "PrivateDevices="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+func (r *SEC007) MinSystemdVersion() int { return rules.DirectiveVersions["PrivateDevices"] }
+func (r *SEC007) ExampleBad() string     { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC007) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nPrivateDevices=yes\n"
+}
+func (r *SEC007) AppliesTo() []string { return sandboxTypes }
 func (r *SEC007) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	if v := unit.GetDirective("Service", "PrivateDevices"); v == "" || v == "no" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service has access to physical devices.", Suggestion: r.Suggestion(), References: r.References()}}
+	if v := unit.GetDirective(section, "PrivateDevices"); v == "" || v == "no" {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: unit.TypeSection() + " has access to physical devices.", Suggestion: inSection(r.Suggestion(), section), References: r.References(), Directive: "PrivateDevices", Value: v, Section: section, Expected: "yes"}}
 	}
 	return nil
 }
@@ -54,13 +64,23 @@ func (r *SEC008) Suggestion() string       { return "Add 'ProtectKernelTunables=
 func (r *SEC008) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#ProtectKernelTunables="}
 }
+func (r *SEC008) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "ProtectKernelTunables="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+func (r *SEC008) MinSystemdVersion() int { return rules.DirectiveVersions["ProtectKernelTunables"] }
+func (r *SEC008) ExampleBad() string     { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC008) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nProtectKernelTunables=yes\n"
+}
+func (r *SEC008) AppliesTo() []string { return sandboxTypes }
 func (r *SEC008) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	if v := unit.GetDirective("Service", "ProtectKernelTunables"); v == "" || v == "no" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service can modify kernel tunables.", Suggestion: r.Suggestion(), References: r.References()}}
+	if v := unit.GetDirective(section, "ProtectKernelTunables"); v == "" || v == "no" {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: unit.TypeSection() + " can modify kernel tunables.", Suggestion: inSection(r.Suggestion(), section), References: r.References(), Directive: "ProtectKernelTunables", Value: v, Section: section, Expected: "yes"}}
 	}
 	return nil
 }
@@ -78,13 +98,23 @@ func (r *SEC009) Suggestion() string       { return "Add 'ProtectKernelModules=y
 func (r *SEC009) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#ProtectKernelModules="}
 }
+func (r *SEC009) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "ProtectKernelModules="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+func (r *SEC009) MinSystemdVersion() int { return rules.DirectiveVersions["ProtectKernelModules"] }
+func (r *SEC009) ExampleBad() string     { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC009) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nProtectKernelModules=yes\n"
+}
+func (r *SEC009) AppliesTo() []string { return sandboxTypes }
 func (r *SEC009) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	if v := unit.GetDirective("Service", "ProtectKernelModules"); v == "" || v == "no" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service can load kernel modules.", Suggestion: r.Suggestion(), References: r.References()}}
+	if v := unit.GetDirective(section, "ProtectKernelModules"); v == "" || v == "no" {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: unit.TypeSection() + " can load kernel modules.", Suggestion: inSection(r.Suggestion(), section), References: r.References(), Directive: "ProtectKernelModules", Value: v, Section: section, Expected: "yes"}}
 	}
 	return nil
 }
@@ -102,13 +132,23 @@ func (r *SEC010) Suggestion() string       { return "Add 'ProtectControlGroups=y
 func (r *SEC010) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#ProtectControlGroups="}
 }
+func (r *SEC010) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "ProtectControlGroups="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+func (r *SEC010) MinSystemdVersion() int { return rules.DirectiveVersions["ProtectControlGroups"] }
+func (r *SEC010) ExampleBad() string     { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC010) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nProtectControlGroups=yes\n"
+}
+func (r *SEC010) AppliesTo() []string { return sandboxTypes }
 func (r *SEC010) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	if v := unit.GetDirective("Service", "ProtectControlGroups"); v == "" || v == "no" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service can modify control groups.", Suggestion: r.Suggestion(), References: r.References()}}
+	if v := unit.GetDirective(section, "ProtectControlGroups"); v == "" || v == "no" {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: unit.TypeSection() + " can modify control groups.", Suggestion: inSection(r.Suggestion(), section), References: r.References(), Directive: "ProtectControlGroups", Value: v, Section: section, Expected: "yes"}}
 	}
 	return nil
 }
@@ -126,13 +166,23 @@ func (r *SEC011) Suggestion() string       { return "Add 'RestrictSUIDSGID=yes'
 func (r *SEC011) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#RestrictSUIDSGID="}
 }
+func (r *SEC011) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "RestrictSUIDSGID="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+func (r *SEC011) MinSystemdVersion() int { return rules.DirectiveVersions["RestrictSUIDSGID"] }
+func (r *SEC011) ExampleBad() string     { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC011) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nRestrictSUIDSGID=yes\n"
+}
+func (r *SEC011) AppliesTo() []string { return execTypes }
 func (r *SEC011) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	if v := unit.GetDirective("Service", "RestrictSUIDSGID"); v == "" || v == "no" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service can create SUID/SGID files.", Suggestion: r.Suggestion(), References: r.References()}}
+	if v := unit.GetDirective(section, "RestrictSUIDSGID"); v == "" || v == "no" {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: unit.TypeSection() + " can create SUID/SGID files.", Suggestion: inSection(r.Suggestion(), section), References: r.References(), Directive: "RestrictSUIDSGID", Value: v, Section: section, Expected: "yes"}}
 	}
 	return nil
 }
@@ -150,13 +200,23 @@ func (r *SEC012) Suggestion() string       { return "Add 'RestrictNamespaces=yes
 func (r *SEC012) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#RestrictNamespaces="}
 }
+func (r *SEC012) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "RestrictNamespaces="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+func (r *SEC012) MinSystemdVersion() int { return rules.DirectiveVersions["RestrictNamespaces"] }
+func (r *SEC012) ExampleBad() string     { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC012) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nRestrictNamespaces=yes\n"
+}
+func (r *SEC012) AppliesTo() []string { return sandboxTypes }
 func (r *SEC012) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	if v := unit.GetDirective("Service", "RestrictNamespaces"); v == "" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service can create new namespaces.", Suggestion: r.Suggestion(), References: r.References()}}
+	if v := unit.GetDirective(section, "RestrictNamespaces"); v == "" {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: unit.TypeSection() + " can create new namespaces.", Suggestion: inSection(r.Suggestion(), section), References: r.References(), Directive: "RestrictNamespaces", Value: v, Section: section, Expected: "yes"}}
 	}
 	return nil
 }
@@ -176,13 +236,23 @@ func (r *SEC013) Suggestion() string {
 func (r *SEC013) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#SystemCallFilter="}
 }
+func (r *SEC013) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "SystemCallFilter=")}
+}
+func (r *SEC013) MinSystemdVersion() int { return rules.DirectiveVersions["SystemCallFilter"] }
+func (r *SEC013) ExampleBad() string     { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC013) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nSystemCallFilter=@system-service\n"
+}
+func (r *SEC013) AppliesTo() []string { return sandboxTypes }
 func (r *SEC013) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	if v := unit.GetDirective("Service", "SystemCallFilter"); v == "" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service has no syscall filtering (seccomp).", Suggestion: r.Suggestion(), References: r.References()}}
+	if v := unit.GetDirective(section, "SystemCallFilter"); v == "" {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: unit.TypeSection() + " has no syscall filtering (seccomp).", Suggestion: inSection(r.Suggestion(), section), References: r.References(), Directive: "SystemCallFilter", Value: v, Section: section, Expected: "@system-service"}}
 	}
 	return nil
 }
@@ -200,13 +270,23 @@ func (r *SEC014) Suggestion() string       { return "Add 'MemoryDenyWriteExecute
 func (r *SEC014) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#MemoryDenyWriteExecute="}
 }
+func (r *SEC014) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "MemoryDenyWriteExecute="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+func (r *SEC014) MinSystemdVersion() int { return rules.DirectiveVersions["MemoryDenyWriteExecute"] }
+func (r *SEC014) ExampleBad() string     { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC014) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nMemoryDenyWriteExecute=yes\n"
+}
+func (r *SEC014) AppliesTo() []string { return execTypes }
 func (r *SEC014) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	if v := unit.GetDirective("Service", "MemoryDenyWriteExecute"); v == "" || v == "no" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service allows writable-executable memory.", Suggestion: r.Suggestion(), References: r.References()}}
+	if v := unit.GetDirective(section, "MemoryDenyWriteExecute"); v == "" || v == "no" {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: unit.TypeSection() + " allows writable-executable memory.", Suggestion: inSection(r.Suggestion(), section), References: r.References(), Directive: "MemoryDenyWriteExecute", Value: v, Section: section, Expected: "yes"}}
 	}
 	return nil
 }
@@ -224,13 +304,23 @@ func (r *SEC015) Suggestion() string       { return "Add 'LockPersonality=yes' t
 func (r *SEC015) References() []string {
 	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#LockPersonality="}
 }
+func (r *SEC015) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "LockPersonality="), types.ManPage("systemd.exec", "Sandboxing")}
+}
+func (r *SEC015) MinSystemdVersion() int { return rules.DirectiveVersions["LockPersonality"] }
+func (r *SEC015) ExampleBad() string     { return "[Service]\nExecStart=/usr/bin/app\n" }
+func (r *SEC015) ExampleGood() string {
+	return "[Service]\nExecStart=/usr/bin/app\nLockPersonality=yes\n"
+}
+func (r *SEC015) AppliesTo() []string { return execTypes }
 func (r *SEC015) Check(ctx *rules.Context) []types.Issue {
 	unit := ctx.Unit
-	if unit == nil || !unit.IsService() {
+	section, confined := execSection(unit)
+	if !confined {
 		return nil
 	}
-	if v := unit.GetDirective("Service", "LockPersonality"); v == "" || v == "no" {
-		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: "Service execution personality not locked.", Suggestion: r.Suggestion(), References: r.References()}}
+	if v := unit.GetDirective(section, "LockPersonality"); v == "" || v == "no" {
+		return []types.Issue{{RuleID: r.ID(), RuleName: r.Name(), Severity: r.Severity(), Category: r.Category(), Tags: r.Tags(), Unit: unit.Name, File: unit.Path, Description: unit.TypeSection() + " execution personality not locked.", Suggestion: inSection(r.Suggestion(), section), References: r.References(), Directive: "LockPersonality", Value: v, Section: section, Expected: "yes"}}
 	}
 	return nil
 }
@@ -0,0 +1,198 @@
+package security
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&SEC016{})
+}
+
+// SEC016 flags bind privileges left on services whose privileged port is
+// already bound by systemd through socket activation. It only fires when an
+// activating socket exists, so it never overlaps with advice to adopt socket
+// activation (PERF001 and any capability-to-socket rule fire only without one).
+type SEC016 struct{}
+
+func (r *SEC016) ID() string   { return "SEC016" }
+func (r *SEC016) Name() string { return "Unneeded bind privileges on socket-activated service" }
+func (r *SEC016) Description() string {
+	return "Services activated by a socket on a privileged port do not need bind privileges themselves."
+}
+func (r *SEC016) Category() types.Category { return types.CategorySecurity }
+func (r *SEC016) Severity() types.Severity { return types.SeverityMedium }
+func (r *SEC016) Tags() []string {
+	return []string{"privilege", "capabilities", "socket-activation"}
+}
+func (r *SEC016) Suggestion() string {
+	return "Remove CAP_NET_BIND_SERVICE from AmbientCapabilities= and run the service as an unprivileged User= or DynamicUser=yes."
+}
+func (r *SEC016) References() []string {
+	return []string{
+		"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#ListenStream=",
+		"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#AmbientCapabilities=",
+	}
+}
+func (r *SEC016) TypedReferences() []types.Reference {
+	return []types.Reference{
+		types.ManPage("systemd.socket", "ListenStream="),
+		types.ManPage("systemd.exec", "AmbientCapabilities="),
+		types.ManPage("systemd.exec", "Capabilities"),
+	}
+}
+func (r *SEC016) Capabilities() rules.Capability { return rules.CapabilityCrossUnit }
+
+// socketListenDirectives are the [Socket] directives that may bind a network port
+var socketListenDirectives = []string{"ListenStream", "ListenDatagram", "ListenSequentialPacket"}
+
+func (r *SEC016) AppliesTo() []string { return []string{"service"} }
+
+func (r *SEC016) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	socket, port := privilegedSocketFor(ctx, unit)
+	if socket == nil {
+		return nil
+	}
+
+	var issues []types.Issue
+	reason := "socket " + socket.Name + " binds privileged port " + strconv.Itoa(port)
+
+	for _, d := range unit.GetDirectives("Service", "AmbientCapabilities") {
+		if hasCapability(d.Value, "CAP_NET_BIND_SERVICE") {
+			line := d.Line
+			issues = append(issues, types.Issue{
+				RuleID:      r.ID(),
+				RuleName:    r.Name(),
+				Severity:    r.Severity(),
+				Category:    r.Category(),
+				Tags:        r.Tags(),
+				Unit:        unit.Name,
+				File:        unit.Path,
+				Line:        &line,
+				Description: "AmbientCapabilities=CAP_NET_BIND_SERVICE is unnecessary: " + reason + ".",
+				Suggestion:  r.Suggestion(),
+				References:  r.References(),
+				Directive:   "AmbientCapabilities",
+				Value:       d.Value,
+				Section:     "Service",
+			})
+			break
+		}
+	}
+
+	if runsAsRoot(unit) && !needsRoot(unit) {
+		issues = append(issues, types.Issue{
+			RuleID:      r.ID(),
+			RuleName:    r.Name(),
+			Severity:    r.Severity(),
+			Category:    r.Category(),
+			Tags:        r.Tags(),
+			Unit:        unit.Name,
+			File:        unit.Path,
+			Description: "Service runs as root although nothing else requires it: " + reason + ".",
+			Suggestion:  r.Suggestion(),
+			References:  r.References(),
+			Directive:   "User",
+			Value:       unit.GetDirective("Service", "User"),
+			Section:     "Service",
+		})
+	}
+
+	return issues
+}
+
+// privilegedSocketFor returns the first activating socket that listens on a port below 1024
+func privilegedSocketFor(ctx *rules.Context, unit *types.UnitFile) (*types.UnitFile, int) {
+	for _, socket := range ctx.SocketsFor(unit) {
+		for _, key := range socketListenDirectives {
+			for _, d := range socket.GetDirectives("Socket", key) {
+				if port, ok := listenPort(d.Value); ok && port > 0 && port < 1024 {
+					return socket, port
+				}
+			}
+		}
+	}
+	return nil, 0
+}
+
+// listenPort extracts the port from a Listen*= value such as "443", "0.0.0.0:443" or "[::]:443"
+func listenPort(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasPrefix(value, "/") || strings.HasPrefix(value, "@") {
+		return 0, false
+	}
+	if port, err := strconv.Atoi(value); err == nil {
+		return port, true
+	}
+	_, portStr, err := net.SplitHostPort(value)
+	if err != nil {
+		return 0, false
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return 0, false
+	}
+	return port, true
+}
+
+// hasCapability reports whether a capability list grants the named capability
+func hasCapability(value, capability string) bool {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "~") {
+		return false
+	}
+	for _, field := range strings.Fields(value) {
+		if field == capability {
+			return true
+		}
+	}
+	return false
+}
+
+func runsAsRoot(unit *types.UnitFile) bool {
+	user := unit.GetDirective("Service", "User")
+	dynamicUser := unit.GetDirective("Service", "DynamicUser")
+	if dynamicUser == "yes" || dynamicUser == "true" {
+		return false
+	}
+	return user == "" || user == "root" || user == "0"
+}
+
+// needsRoot looks for signals that the service relies on root for something
+// other than binding its listening port
+func needsRoot(unit *types.UnitFile) bool {
+	for _, key := range []string{"AmbientCapabilities", "CapabilityBoundingSet"} {
+		for _, d := range unit.GetDirectives("Service", key) {
+			v := strings.TrimSpace(d.Value)
+			if strings.HasPrefix(v, "~") {
+				continue
+			}
+			for _, field := range strings.Fields(v) {
+				if field != "CAP_NET_BIND_SERVICE" {
+					return true
+				}
+			}
+		}
+	}
+
+	for _, key := range []string{"ExecStartPre", "ExecStart", "ExecStartPost", "ExecReload", "ExecStop"} {
+		for _, d := range unit.GetDirectives("Service", key) {
+			// "+" and "!" prefixes request full privileges for that command
+			prefix := strings.TrimLeft(d.Value, "-@:")
+			if strings.HasPrefix(prefix, "+") || strings.HasPrefix(prefix, "!") {
+				return true
+			}
+		}
+	}
+
+	if v := unit.GetDirective("Service", "PermissionsStartOnly"); v == "yes" || v == "true" {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package security
+
+import (
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&SEC017{})
+}
+
+// SEC017 - EnvironmentFile= writable by any user
+type SEC017 struct{}
+
+func (r *SEC017) ID() string   { return "SEC017" }
+func (r *SEC017) Name() string { return "World-writable EnvironmentFile=" }
+func (r *SEC017) Description() string {
+	return "Any local user can edit a world-writable environment file and set variables such as LD_PRELOAD or PATH for the service, running code with its privileges."
+}
+func (r *SEC017) Category() types.Category { return types.CategorySecurity }
+func (r *SEC017) Severity() types.Severity { return types.SeverityHigh }
+func (r *SEC017) Tags() []string           { return []string{"environment", "permissions"} }
+func (r *SEC017) Suggestion() string {
+	return "Make the file writable only by root, for example with 'chmod 0644' or 0600 if it holds secrets."
+}
+func (r *SEC017) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#EnvironmentFile="}
+}
+func (r *SEC017) Capabilities() rules.Capability { return rules.CapabilityFilesystem }
+
+func (r *SEC017) AppliesTo() []string { return []string{"service"} }
+
+func (r *SEC017) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	if ctx.FileSystem == nil {
+		return nil
+	}
+
+	var issues []types.Issue
+	for _, p := range validation.ValidateEnvironmentFiles(unit, ctx.FileSystem).Problems {
+		if p.Kind != validation.EnvFileWorldWritable {
+			continue
+		}
+		line := p.Line
+		issues = append(issues, types.Issue{
+			RuleID:      r.ID(),
+			RuleName:    r.Name(),
+			Severity:    r.Severity(),
+			Category:    r.Category(),
+			Tags:        r.Tags(),
+			Unit:        unit.Name,
+			File:        p.File,
+			Line:        &line,
+			Description: p.Reason,
+			Suggestion:  r.Suggestion(),
+			References:  r.References(),
+			Directive:   "EnvironmentFile",
+			Section:     "Service",
+		})
+	}
+	return issues
+}
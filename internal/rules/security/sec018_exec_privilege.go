@@ -0,0 +1,58 @@
+package security
+
+import (
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&SEC018{})
+}
+
+// SEC018 - Exec command switching user with sudo, su or runuser
+type SEC018 struct{}
+
+func (r *SEC018) ID() string   { return "SEC018" }
+func (r *SEC018) Name() string { return "sudo, su or runuser in Exec command" }
+func (r *SEC018) Description() string {
+	return "Switching user inside an Exec command keeps the service running as root up to that point and leaves the privilege change to PAM and sudoers instead of the unit."
+}
+func (r *SEC018) Category() types.Category { return types.CategorySecurity }
+func (r *SEC018) Severity() types.Severity { return types.SeverityMedium }
+func (r *SEC018) Tags() []string           { return []string{"exec", "privileges", "user"} }
+func (r *SEC018) Suggestion() string {
+	return "Set User= and Group= for the service, and prefix only the commands that need root with '+'."
+}
+func (r *SEC018) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#Command%20lines"}
+}
+
+func (r *SEC018) AppliesTo() []string { return []string{"service"} }
+
+func (r *SEC018) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	var issues []types.Issue
+	for _, p := range validation.ValidateExecCommands(unit).Problems {
+		if p.Kind != validation.ExecPrivilegeWrapper {
+			continue
+		}
+		line := p.Line
+		issues = append(issues, types.Issue{
+			RuleID:      r.ID(),
+			RuleName:    r.Name(),
+			Severity:    r.Severity(),
+			Category:    r.Category(),
+			Tags:        r.Tags(),
+			Unit:        unit.Name,
+			File:        unit.Path,
+			Line:        &line,
+			Description: p.Reason,
+			Suggestion:  r.Suggestion(),
+			References:  r.References(),
+			Directive:   p.Directive,
+			Section:     "Service",
+		})
+	}
+	return issues
+}
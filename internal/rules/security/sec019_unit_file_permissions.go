@@ -0,0 +1,170 @@
+package security
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&SEC019{})
+}
+
+// SEC019 - Unit file, drop-in or unit directory writable by users other than root
+type SEC019 struct{}
+
+func (r *SEC019) ID() string   { return "SEC019" }
+func (r *SEC019) Name() string { return "Unit file writable by non-root users" }
+func (r *SEC019) Description() string {
+	return "systemd runs whatever a unit file says, as root unless it says otherwise. A user who can write the unit file, one of its drop-ins or a directory they are read from can make the service run their own commands with its privileges."
+}
+func (r *SEC019) Category() types.Category { return types.CategorySecurity }
+func (r *SEC019) Severity() types.Severity { return types.SeverityHigh }
+func (r *SEC019) Tags() []string           { return []string{"permissions", "privileges"} }
+func (r *SEC019) Suggestion() string {
+	return "Make the file or directory owned by root and writable only by it, for example with 'chown root:root' and 'chmod 0644', or 0755 for a directory."
+}
+func (r *SEC019) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.unit.html#Unit%20File%20Load%20Path"}
+}
+func (r *SEC019) Capabilities() rules.Capability { return rules.CapabilityFilesystem }
+
+// Check reports the unit file, its drop-ins and its drop-in directories. The
+// directories holding the unit files are reported once by CheckHost.
+func (r *SEC019) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	// A masked unit links to /dev/null, which is world-writable by design
+	if ctx.FileSystem == nil || unit.Masked {
+		return nil
+	}
+	target, ok := deployedPath(ctx, unit.Path)
+	if !ok {
+		return nil
+	}
+
+	var issues []types.Issue
+	if reason := nonRootWritable(ctx.FileSystem, target); reason != "" {
+		issues = append(issues, r.issue(unit.Name, unit.Path, target+" is "+reason+", so users other than root can change what the unit runs."))
+	}
+
+	dirs := make(map[string]bool)
+	for _, dropIn := range unit.DropIns {
+		dropInTarget, ok := ctx.TargetPath(dropIn)
+		if !ok {
+			continue
+		}
+		dirs[path.Dir(dropInTarget)] = true
+		if reason := nonRootWritable(ctx.FileSystem, dropInTarget); reason != "" {
+			issues = append(issues, r.issue(unit.Name, dropIn, "Drop-in "+dropInTarget+" is "+reason+", so users other than root can change what the unit runs."))
+		}
+	}
+	// An empty drop-in directory is as good as a writable unit file
+	for _, dir := range unitfile.DefaultPaths() {
+		if d := dir + "/" + unit.Name + ".d"; ctx.FileSystem.IsDirectory(d) {
+			dirs[d] = true
+		}
+	}
+	for _, dir := range sortedKeys(dirs) {
+		if reason := nonRootWritable(ctx.FileSystem, dir); reason != "" {
+			issues = append(issues, r.issue(unit.Name, unit.Path, "Drop-in directory "+dir+" is "+reason+", so users other than root can add settings to the unit."))
+		}
+	}
+	return issues
+}
+
+// CheckHost reports each directory holding unit files once, rather than on
+// every unit in it
+func (r *SEC019) CheckHost(ctx *rules.Context) []types.Issue {
+	if ctx.FileSystem == nil {
+		return nil
+	}
+
+	dirs := make(map[string]string)
+	for _, unit := range ctx.AllUnits {
+		if unit == nil || unit.Masked {
+			continue
+		}
+		if target, ok := deployedPath(ctx, unit.Path); ok {
+			dirs[path.Dir(target)] = path.Dir(unit.Path)
+		}
+	}
+
+	var issues []types.Issue
+	for _, dir := range sortedKeys(dirs) {
+		if reason := nonRootWritable(ctx.FileSystem, dir); reason != "" {
+			issues = append(issues, r.issue(dir, dirs[dir], "Unit directory "+dir+" is "+reason+", so users other than root can add or replace unit files in it."))
+		}
+	}
+	return issues
+}
+
+func (r *SEC019) issue(unit, file, description string) types.Issue {
+	return types.Issue{
+		RuleID:      r.ID(),
+		RuleName:    r.Name(),
+		Severity:    r.Severity(),
+		Category:    r.Category(),
+		Tags:        r.Tags(),
+		Unit:        unit,
+		File:        file,
+		Description: description,
+		Suggestion:  r.Suggestion(),
+		References:  r.References(),
+	}
+}
+
+// deployedPath returns the path on the target of a unit file read from
+// path, if it is in one of the directories systemd loads units from. Files
+// checked elsewhere, such as with sdaudit check before deployment, are not
+// where their permissions matter.
+func deployedPath(ctx *rules.Context, file string) (string, bool) {
+	target, ok := ctx.TargetPath(file)
+	if !ok {
+		return "", false
+	}
+	for _, dir := range unitfile.DefaultPaths() {
+		if strings.HasPrefix(target, dir+"/") {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// nonRootWritable returns why users other than root can write a file or
+// directory, empty if only root can or it does not exist. Owners are only
+// compared when / is owned by root: an image unpacked without privileges
+// belongs to whoever unpacked it, throughout.
+func nonRootWritable(fs validation.FileSystem, p string) string {
+	mode, ok := fs.Mode(p)
+	if !ok {
+		return ""
+	}
+	var reasons []string
+	if rootOwner, ok := fs.Owner("/"); ok && rootOwner.UID == 0 {
+		if owner, ok := fs.Owner(p); ok && owner.UID != 0 {
+			reasons = append(reasons, fmt.Sprintf("owned by UID %d", owner.UID))
+		}
+	}
+	switch {
+	case mode&0002 != 0:
+		reasons = append(reasons, fmt.Sprintf("world-writable (mode %04o)", mode.Perm()))
+	case mode&0020 != 0:
+		reasons = append(reasons, fmt.Sprintf("group-writable (mode %04o)", mode.Perm()))
+	}
+	return strings.Join(reasons, " and ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
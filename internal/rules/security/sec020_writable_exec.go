@@ -0,0 +1,220 @@
+package security
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&SEC020{})
+}
+
+// interpreters run the script given as their first argument. Version
+// suffixes such as python3.11 are removed before looking a program up.
+var interpreters = map[string]bool{
+	"python": true, "perl": true, "ruby": true, "node": true, "nodejs": true,
+	"php": true, "lua": true, "tclsh": true, "Rscript": true,
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true,
+}
+
+var interpreterVersion = regexp.MustCompile(`[0-9.]+$`)
+
+// SEC020 - ExecStart= program writable by the service's own user
+type SEC020 struct{}
+
+func (r *SEC020) ID() string   { return "SEC020" }
+func (r *SEC020) Name() string { return "Service can modify its own program" }
+func (r *SEC020) Description() string {
+	return "When the user a service runs as can write its program, its script or a directory above them, a compromised service can replace the code that runs on its next start, which no sandboxing setting prevents."
+}
+func (r *SEC020) Category() types.Category { return types.CategorySecurity }
+func (r *SEC020) Severity() types.Severity { return types.SeverityHigh }
+func (r *SEC020) Tags() []string           { return []string{"exec", "permissions", "user"} }
+func (r *SEC020) Suggestion() string {
+	return "Install the program and its directories owned by root and not writable by the service's user, group or others, and keep the data the service writes elsewhere, such as in StateDirectory=."
+}
+func (r *SEC020) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#User="}
+}
+func (r *SEC020) Capabilities() rules.Capability { return rules.CapabilityFilesystem }
+
+func (r *SEC020) AppliesTo() []string { return []string{"service"} }
+
+func (r *SEC020) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	fs := ctx.FileSystem
+	if fs == nil || unit.Masked {
+		return nil
+	}
+	// Paths are inside the service's own root, and dynamic users own no files
+	dynamicUser := unit.GetDirective("Service", "DynamicUser")
+	if unit.HasDirective("Service", "RootDirectory") || unit.HasDirective("Service", "RootImage") || dynamicUser == "yes" || dynamicUser == "true" {
+		return nil
+	}
+	u, ok := serviceUser(unit, fs)
+	if !ok {
+		return nil
+	}
+
+	var issues []types.Issue
+	var commands []types.Directive
+	for _, d := range unit.GetDirectives("Service", "ExecStart") {
+		// An empty ExecStart= resets the commands before it
+		if strings.TrimSpace(d.Value) == "" {
+			commands = nil
+			continue
+		}
+		commands = append(commands, d)
+	}
+	for _, d := range commands {
+		for _, command := range validation.ParseExecCommands(d.Value) {
+			for _, target := range execTargets(unit, command) {
+				writable, reason := u.writablePath(fs, target.path)
+				if writable == "" {
+					continue
+				}
+				line := d.Line
+				description := fmt.Sprintf("ExecStart= runs %s, and %s is %s, so the service can replace the code it runs.", target.describe(), writable, reason)
+				if writable != target.path {
+					description = fmt.Sprintf("ExecStart= runs %s, and the directory %s above it is %s, so the service can replace the code it runs.", target.describe(), writable, reason)
+				}
+				issues = append(issues, types.Issue{
+					RuleID:      r.ID(),
+					RuleName:    r.Name(),
+					Severity:    r.Severity(),
+					Category:    r.Category(),
+					Tags:        r.Tags(),
+					Unit:        unit.Name,
+					File:        unit.Path,
+					Line:        &line,
+					Description: description,
+					Suggestion:  r.Suggestion(),
+					References:  r.References(),
+					Directive:   "ExecStart",
+					Value:       target.path,
+					Section:     "Service",
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// execTarget is a file whose contents an Exec command runs
+type execTarget struct {
+	path string
+	// interpreter runs the script at path, empty for the program itself
+	interpreter string
+}
+
+func (t execTarget) describe() string {
+	if t.interpreter == "" {
+		return t.path
+	}
+	return "the script " + t.path + " with " + t.interpreter
+}
+
+// execTargets returns the absolute paths of the program a command runs and
+// of the script, when the program is an interpreter, with specifiers
+// expanded. Programs found on the search path, such as with env, are left
+// out, and so are paths with specifiers not known before the unit runs.
+func execTargets(unit *types.UnitFile, command validation.ExecCommand) []execTarget {
+	program, ok := validation.ExpandSpecifiers(unit, command.Path)
+	if !ok {
+		return nil
+	}
+	var targets []execTarget
+	if path.IsAbs(program) {
+		targets = append(targets, execTarget{path: program})
+	}
+
+	args := command.Args
+	interpreter := program
+	if path.Base(program) == "env" {
+		// env runs the first argument that is not an option or assignment
+		interpreter = ""
+		for i, arg := range args {
+			if !strings.HasPrefix(arg.Value, "-") && !strings.Contains(arg.Value, "=") {
+				interpreter, args = arg.Value, args[i+1:]
+				break
+			}
+		}
+	}
+	if !interpreters[interpreterVersion.ReplaceAllString(path.Base(interpreter), "")] || len(args) == 0 {
+		return targets
+	}
+	if script, ok := validation.ExpandSpecifiers(unit, args[0].Value); ok && path.IsAbs(script) {
+		targets = append(targets, execTarget{path: script, interpreter: interpreter})
+	}
+	return targets
+}
+
+// runAs is the user a service runs as and its primary group, if known
+type runAs struct {
+	name   string
+	uid    uint32
+	gid    uint32
+	hasGID bool
+}
+
+// serviceUser returns the user of a service that is not root. Users that do
+// not exist are left to BP009.
+func serviceUser(unit *types.UnitFile, fs validation.FileSystem) (runAs, bool) {
+	name, ok := validation.ExpandSpecifiers(unit, unit.GetDirective("Service", "User"))
+	if !ok || name == "" || name == "root" || name == "0" {
+		return runAs{}, false
+	}
+	if uid, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return runAs{name: "UID " + name, uid: uint32(uid)}, true
+	}
+	ids, ok := fs.LookupUser(name)
+	if !ok || ids.UID == 0 {
+		return runAs{}, false
+	}
+	return runAs{name: name, uid: ids.UID, gid: ids.GID, hasGID: true}, true
+}
+
+// writablePath returns the file, or else the closest directory above it,
+// that the user can change, and why. Nothing is returned for a file that
+// does not exist.
+func (u runAs) writablePath(fs validation.FileSystem, file string) (string, string) {
+	if _, ok := fs.Mode(file); !ok {
+		return "", ""
+	}
+	for p := file; ; p = path.Dir(p) {
+		if reason := u.canWrite(fs, p, p != file); reason != "" {
+			return p, reason
+		}
+		if p == "/" {
+			return "", ""
+		}
+	}
+}
+
+// canWrite returns why the user can change a path, empty if it cannot. The
+// owner of a path can always change its mode, and a sticky directory such
+// as /tmp only lets users replace their own files.
+func (u runAs) canWrite(fs validation.FileSystem, p string, dir bool) string {
+	mode, ok := fs.Mode(p)
+	if !ok {
+		return ""
+	}
+	owner, hasOwner := fs.Owner(p)
+	switch {
+	case hasOwner && owner.UID == u.uid:
+		return "owned by " + u.name
+	case hasOwner && u.hasGID && owner.GID == u.gid && mode&0020 != 0:
+		return fmt.Sprintf("writable by the group of %s (mode %04o)", u.name, mode.Perm())
+	case mode&0002 != 0 && !(dir && mode&os.ModeSticky != 0):
+		return fmt.Sprintf("world-writable (mode %04o)", mode.Perm())
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package security
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	rules.Register(&SECAGG{})
+}
+
+// SECAGG reports units that fail several hardening rules at once. Each
+// missing directive is routine on its own and is still reported by its
+// rule; together, and in a service running as root, they leave the unit
+// without any real confinement.
+type SECAGG struct{}
+
+// aggregateMin is the number of failed hardening rules a unit needs for
+// SECAGG to report it
+const aggregateMin = 3
+
+func (r *SECAGG) ID() string   { return "SECAGG" }
+func (r *SECAGG) Name() string { return "Many hardening rules fail together" }
+func (r *SECAGG) Description() string {
+	return "Summarizes units that fail several hardening rules at once, which together leave the unit with little confinement. The severity grows with the number of rules failed and when the service runs as root. The individual findings are still reported."
+}
+func (r *SECAGG) Category() types.Category { return types.CategorySecurity }
+func (r *SECAGG) Severity() types.Severity { return types.SeverityHigh }
+func (r *SECAGG) Tags() []string           { return []string{"aggregate", "privilege"} }
+func (r *SECAGG) Suggestion() string {
+	return "Fix the individual hardening findings of the unit, or run it as an unprivileged User= first."
+}
+func (r *SECAGG) References() []string {
+	return []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#Security"}
+}
+
+func (r *SECAGG) TypedReferences() []types.Reference {
+	return []types.Reference{types.ManPage("systemd.exec", "Security")}
+}
+
+// Check reports nothing; SECAGG works on the findings of the other rules
+func (r *SECAGG) Check(ctx *rules.Context) []types.Issue { return nil }
+
+// Aggregate reports each unit with at least aggregateMin distinct hardening
+// findings. The description stays the same as findings come and go, so the
+// fingerprint does too; the failed rules are listed in Refs.
+func (r *SECAGG) Aggregate(ctx *rules.Context, issues []types.Issue) []types.Issue {
+	failed := make(map[string]map[string]bool)
+	var units []string
+	for _, issue := range issues {
+		if issue.RuleID == r.ID() || issue.Category != types.CategorySecurity || !slices.Contains(issue.Tags, "hardening") {
+			continue
+		}
+		if failed[issue.Unit] == nil {
+			failed[issue.Unit] = make(map[string]bool)
+			units = append(units, issue.Unit)
+		}
+		failed[issue.Unit][issue.RuleID] = true
+	}
+	sort.Strings(units)
+
+	var out []types.Issue
+	for _, name := range units {
+		if len(failed[name]) < aggregateMin {
+			continue
+		}
+		ids := make([]string, 0, len(failed[name]))
+		for id := range failed[name] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		unit := lookupUnit(ctx, name)
+		root := unit != nil && unit.IsService() && runsAsRoot(unit)
+		description := fmt.Sprintf("Unit fails %d or more hardening rules at once", aggregateMin)
+		if root {
+			description += " and runs as root"
+		}
+		refs := r.TypedReferences()
+		for _, id := range ids {
+			refs = append(refs, types.RuleReference(id))
+		}
+
+		issue := types.Issue{
+			RuleID:      r.ID(),
+			RuleName:    r.Name(),
+			Severity:    aggregateSeverity(len(ids), root),
+			Category:    r.Category(),
+			Tags:        r.Tags(),
+			Unit:        name,
+			Description: description + ".",
+			Suggestion:  r.Suggestion(),
+			References:  r.References(),
+			Refs:        refs,
+		}
+		if unit != nil {
+			issue.File = unit.Path
+		}
+		out = append(out, issue)
+	}
+	return out
+}
+
+// aggregateSeverity is medium for aggregateMin failed rules, high from 5
+// and critical from 7, one level higher for services running as root
+func aggregateSeverity(failed int, root bool) types.Severity {
+	severity := types.SeverityMedium
+	switch {
+	case failed >= 7:
+		severity = types.SeverityCritical
+	case failed >= 5:
+		severity = types.SeverityHigh
+	}
+	if root && severity < types.SeverityCritical {
+		severity++
+	}
+	return severity
+}
+
+// lookupUnit returns the unit named name, preferring the context's own unit
+// when a single unit is checked again
+func lookupUnit(ctx *rules.Context, name string) *types.UnitFile {
+	if ctx.Unit != nil && ctx.Unit.Name == name {
+		return ctx.Unit
+	}
+	return ctx.AllUnits[name]
+}
@@ -1,9 +1,12 @@
 package security
 
 import (
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -70,6 +73,31 @@ func TestSEC001_NoNewPrivileges(t *testing.T) {
 	}
 }
 
+func TestStructuredFields(t *testing.T) {
+	tests := []struct {
+		rule       rules.Rule
+		directives map[string]string
+		want       [4]string // directive, value, section, expected
+	}{
+		{&SEC001{}, map[string]string{"NoNewPrivileges": "no"}, [4]string{"NoNewPrivileges", "no", "Service", "yes"}},
+		{&SEC003{}, map[string]string{"ProtectSystem": "yes"}, [4]string{"ProtectSystem", "yes", "Service", "strict"}},
+		{&SEC003{}, nil, [4]string{"ProtectSystem", "", "Service", "strict"}},
+		{&SEC006{}, map[string]string{"CapabilityBoundingSet": "CAP_SYS_ADMIN"}, [4]string{"CapabilityBoundingSet", "CAP_SYS_ADMIN", "Service", ""}},
+		{&SEC013{}, nil, [4]string{"SystemCallFilter", "", "Service", "@system-service"}},
+		{&SEC005{}, map[string]string{"User": "root"}, [4]string{"User", "root", "Service", ""}},
+	}
+	for _, tt := range tests {
+		issues := tt.rule.Check(rules.NewContext(makeTestUnit(tt.directives)))
+		if len(issues) != 1 {
+			t.Fatalf("%s: got %d issues, want 1", tt.rule.ID(), len(issues))
+		}
+		issue := issues[0]
+		if got := [4]string{issue.Directive, issue.Value, issue.Section, issue.Expected}; got != tt.want {
+			t.Errorf("%s %v: directive, value, section, expected = %q, want %q", tt.rule.ID(), tt.directives, got, tt.want)
+		}
+	}
+}
+
 func TestSEC002_PrivateTmp(t *testing.T) {
 	rule := &SEC002{}
 
@@ -202,6 +230,451 @@ func TestSEC005_RootService(t *testing.T) {
 	}
 }
 
+func makeTestSocket(name string, directives map[string]string) *types.UnitFile {
+	unit := &types.UnitFile{
+		Name: name,
+		Path: "/etc/systemd/system/" + name,
+		Type: "socket",
+		Sections: map[string]*types.Section{
+			"Socket": {
+				Name:       "Socket",
+				Directives: make(map[string][]types.Directive),
+			},
+		},
+	}
+
+	for k, v := range directives {
+		unit.Sections["Socket"].Directives[k] = []types.Directive{{Key: k, Value: v}}
+	}
+
+	return unit
+}
+
+func TestSEC016_SocketPrivileges(t *testing.T) {
+	rule := &SEC016{}
+
+	tests := []struct {
+		name       string
+		directives map[string]string
+		socket     map[string]string
+		wantIssues int
+	}{
+		{
+			name:       "no socket",
+			directives: map[string]string{"AmbientCapabilities": "CAP_NET_BIND_SERVICE"},
+			wantIssues: 0,
+		},
+		{
+			name:       "unprivileged port",
+			directives: map[string]string{"AmbientCapabilities": "CAP_NET_BIND_SERVICE"},
+			socket:     map[string]string{"ListenStream": "8080"},
+			wantIssues: 0,
+		},
+		{
+			name:       "capability with privileged socket",
+			directives: map[string]string{"User": "www", "AmbientCapabilities": "CAP_NET_BIND_SERVICE"},
+			socket:     map[string]string{"ListenStream": "0.0.0.0:443"},
+			wantIssues: 1,
+		},
+		{
+			name:       "root with privileged socket",
+			directives: map[string]string{},
+			socket:     map[string]string{"ListenStream": "[::]:80"},
+			wantIssues: 1,
+		},
+		{
+			name:       "root and capability with privileged socket",
+			directives: map[string]string{"User": "root", "AmbientCapabilities": "CAP_NET_BIND_SERVICE"},
+			socket:     map[string]string{"ListenDatagram": "53"},
+			wantIssues: 2,
+		},
+		{
+			name:       "root needed for other capabilities",
+			directives: map[string]string{"CapabilityBoundingSet": "CAP_NET_BIND_SERVICE CAP_SYS_ADMIN"},
+			socket:     map[string]string{"ListenStream": "443"},
+			wantIssues: 0,
+		},
+		{
+			name:       "root needed for privileged ExecStartPre",
+			directives: map[string]string{"ExecStartPre": "+/usr/bin/setup"},
+			socket:     map[string]string{"ListenStream": "443"},
+			wantIssues: 0,
+		},
+		{
+			name:       "unprivileged user",
+			directives: map[string]string{"User": "www"},
+			socket:     map[string]string{"ListenStream": "443"},
+			wantIssues: 0,
+		},
+		{
+			name:       "DynamicUser",
+			directives: map[string]string{"DynamicUser": "yes"},
+			socket:     map[string]string{"ListenStream": "443"},
+			wantIssues: 0,
+		},
+		{
+			name:       "unix socket",
+			directives: map[string]string{},
+			socket:     map[string]string{"ListenStream": "/run/test.sock"},
+			wantIssues: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(tt.directives)
+			allUnits := map[string]*types.UnitFile{unit.Name: unit}
+			if tt.socket != nil {
+				socket := makeTestSocket("test.socket", tt.socket)
+				allUnits[socket.Name] = socket
+			}
+			ctx := rules.NewContextWithUnits(unit, allUnits)
+			issues := rule.Check(ctx)
+
+			if len(issues) != tt.wantIssues {
+				t.Errorf("got %d issues, want %d", len(issues), tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestSEC016_ExplicitServiceDirective(t *testing.T) {
+	unit := makeTestUnit(map[string]string{"AmbientCapabilities": "CAP_NET_BIND_SERVICE", "User": "www"})
+	socket := makeTestSocket("web.socket", map[string]string{"ListenStream": "443", "Service": "test.service"})
+	ctx := rules.NewContextWithUnits(unit, map[string]*types.UnitFile{unit.Name: unit, socket.Name: socket})
+
+	issues := (&SEC016{}).Check(ctx)
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1", len(issues))
+	}
+	if !strings.Contains(issues[0].Description, "web.socket") {
+		t.Errorf("description should cite the socket, got %q", issues[0].Description)
+	}
+}
+
+func TestSEC017_WorldWritableEnvironmentFile(t *testing.T) {
+	rule := &SEC017{}
+
+	tests := []struct {
+		name       string
+		mode       os.FileMode
+		wantIssues int
+	}{
+		{"root-owned file", 0644, 0},
+		{"group-writable file", 0664, 0},
+		{"world-writable file", 0666, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(map[string]string{"EnvironmentFile": "-/etc/default/app"})
+			fs := validation.NewMockFileSystem()
+			fs.Contents["/etc/default/app"] = "OPTS=-v\n"
+			fs.Modes["/etc/default/app"] = tt.mode
+			ctx := rules.NewContext(unit)
+			ctx.FileSystem = fs
+
+			issues := rule.Check(ctx)
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("got %d issues, want %d: %+v", len(issues), tt.wantIssues, issues)
+			}
+			if tt.wantIssues > 0 && !strings.Contains(issues[0].Description, "/etc/default/app is world-writable (mode 0666)") {
+				t.Errorf("description %q should name the file and its mode", issues[0].Description)
+			}
+		})
+	}
+
+	// Without a filesystem the file is not read
+	if issues := rule.Check(rules.NewContext(makeTestUnit(map[string]string{"EnvironmentFile": "/etc/default/app"}))); len(issues) != 0 {
+		t.Errorf("without a filesystem got %+v", issues)
+	}
+}
+
+func TestSEC018_PrivilegeWrapper(t *testing.T) {
+	rule := &SEC018{}
+
+	tests := []struct {
+		name       string
+		execStart  string
+		wantIssues int
+	}{
+		{"plain command", "/usr/bin/app", 0},
+		{"sudo", "/usr/bin/sudo -u app /usr/bin/app", 1},
+		{"su through env", "/usr/bin/env HOME=/srv su app -c /usr/bin/app", 1},
+		{"full privileges prefix", "+/usr/bin/app --setup", 0},
+		{"sudo as an argument", "/usr/bin/app --helper=/usr/bin/sudo", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := rule.Check(rules.NewContext(makeTestUnit(map[string]string{"ExecStart": tt.execStart})))
+			if len(issues) != tt.wantIssues {
+				t.Errorf("got %d issues, want %d: %+v", len(issues), tt.wantIssues, issues)
+			}
+		})
+	}
+}
+
+func TestSEC019_UnitFilePermissions(t *testing.T) {
+	rule := &SEC019{}
+
+	tests := []struct {
+		name  string
+		setup func(fs *validation.MockFileSystem, unit *types.UnitFile)
+		want  []string
+	}{
+		{"root-owned files", func(fs *validation.MockFileSystem, unit *types.UnitFile) {}, nil},
+		{"group-writable unit file", func(fs *validation.MockFileSystem, unit *types.UnitFile) {
+			fs.Modes[unit.Path] = 0664
+		}, []string{"/etc/systemd/system/test.service is group-writable (mode 0664)"}},
+		{"unit file of another user", func(fs *validation.MockFileSystem, unit *types.UnitFile) {
+			fs.Owners[unit.Path] = validation.FileOwner{UID: 1000, GID: 1000}
+			fs.Modes[unit.Path] = 0666
+		}, []string{"is owned by UID 1000 and world-writable (mode 0666)"}},
+		{"writable drop-in and its directory", func(fs *validation.MockFileSystem, unit *types.UnitFile) {
+			dropIn := "/etc/systemd/system/test.service.d/override.conf"
+			unit.DropIns = []string{dropIn}
+			fs.Files[dropIn] = true
+			fs.Modes[dropIn] = 0666
+			fs.Files["/etc/systemd/system/test.service.d"] = true
+			fs.Modes["/etc/systemd/system/test.service.d"] = 0777
+		}, []string{"Drop-in /etc/systemd/system/test.service.d/override.conf is world-writable", "Drop-in directory /etc/systemd/system/test.service.d is world-writable (mode 0777)"}},
+		{"empty drop-in directory", func(fs *validation.MockFileSystem, unit *types.UnitFile) {
+			fs.Directories["/run/systemd/system/test.service.d"] = true
+			fs.Files["/run/systemd/system/test.service.d"] = true
+			fs.Owners["/run/systemd/system/test.service.d"] = validation.FileOwner{UID: 1000}
+			fs.Modes["/run/systemd/system/test.service.d"] = 0755
+		}, []string{"Drop-in directory /run/systemd/system/test.service.d is owned by UID 1000"}},
+		{"unpacked without privileges", func(fs *validation.MockFileSystem, unit *types.UnitFile) {
+			fs.Owners["/"] = validation.FileOwner{UID: 1000}
+			fs.Owners[unit.Path] = validation.FileOwner{UID: 1000}
+		}, nil},
+		{"not deployed", func(fs *validation.MockFileSystem, unit *types.UnitFile) {
+			unit.Path = "/home/dev/test.service"
+			fs.Files[unit.Path] = true
+			fs.Modes[unit.Path] = 0666
+		}, nil},
+		{"masked", func(fs *validation.MockFileSystem, unit *types.UnitFile) {
+			unit.Masked = true
+			fs.Modes[unit.Path] = 0666
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(nil)
+			fs := validation.NewMockFileSystem()
+			fs.Files["/"] = true
+			fs.Files[unit.Path] = true
+			tt.setup(fs, unit)
+			ctx := rules.NewContext(unit)
+			ctx.FileSystem = fs
+
+			issues := rule.Check(ctx)
+			if len(issues) != len(tt.want) {
+				t.Fatalf("got %d issues, want %d: %+v", len(issues), len(tt.want), issues)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(issues[i].Description, want) {
+					t.Errorf("description %q should contain %q", issues[i].Description, want)
+				}
+			}
+		})
+	}
+
+	// Without a filesystem, as for a unit from stdin, nothing is looked up
+	if issues := rule.Check(rules.NewContext(makeTestUnit(nil))); len(issues) != 0 {
+		t.Errorf("without a filesystem got %+v", issues)
+	}
+}
+
+func TestSEC019_UnitDirectories(t *testing.T) {
+	rule := &SEC019{}
+	units := map[string]*types.UnitFile{
+		"a.service": {Name: "a.service", Path: "/mnt/image/etc/systemd/system/a.service", Type: "service"},
+		"b.service": {Name: "b.service", Path: "/mnt/image/etc/systemd/system/b.service", Type: "service"},
+		"c.service": {Name: "c.service", Path: "/mnt/image/usr/lib/systemd/system/c.service", Type: "service"},
+	}
+	fs := validation.NewMockFileSystem()
+	fs.Files["/"] = true
+	fs.Files["/etc/systemd/system"] = true
+	fs.Modes["/etc/systemd/system"] = 0775
+	fs.Files["/usr/lib/systemd/system"] = true
+	fs.Modes["/usr/lib/systemd/system"] = 0755
+
+	ctx := rules.NewHostContext(units)
+	ctx.FileSystem = fs
+	ctx.Root = "/mnt/image"
+	issues := rule.CheckHost(ctx)
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want the one directory once: %+v", len(issues), issues)
+	}
+	if issues[0].File != "/mnt/image/etc/systemd/system" || !strings.Contains(issues[0].Description, "Unit directory /etc/systemd/system is group-writable (mode 0775)") {
+		t.Errorf("got %+v, want the group-writable /etc/systemd/system", issues[0])
+	}
+}
+
+func TestSEC020_WritableProgram(t *testing.T) {
+	rule := &SEC020{}
+
+	tests := []struct {
+		name       string
+		directives map[string]string
+		setup      func(fs *validation.MockFileSystem)
+		want       string
+	}{
+		{"root-owned program", map[string]string{"User": "app", "ExecStart": "/usr/bin/app"}, nil, ""},
+		{"program owned by the user", map[string]string{"User": "app", "ExecStart": "/usr/bin/app --serve"}, func(fs *validation.MockFileSystem) {
+			fs.Owners["/usr/bin/app"] = validation.FileOwner{UID: 990, GID: 990}
+		}, "ExecStart= runs /usr/bin/app, and /usr/bin/app is owned by app"},
+		{"group-writable directory", map[string]string{"User": "app", "ExecStart": "/opt/app/bin/app"}, func(fs *validation.MockFileSystem) {
+			fs.Files["/opt/app/bin/app"] = true
+			fs.Files["/opt/app"] = true
+			fs.Owners["/opt/app"] = validation.FileOwner{UID: 0, GID: 990}
+			fs.Modes["/opt/app"] = 0775
+		}, "the directory /opt/app above it is writable by the group of app (mode 0775)"},
+		{"world-writable script", map[string]string{"User": "app", "ExecStart": "/usr/bin/python3.11 /opt/app/main.py"}, func(fs *validation.MockFileSystem) {
+			fs.Files["/opt/app/main.py"] = true
+			fs.Modes["/opt/app/main.py"] = 0666
+		}, "runs the script /opt/app/main.py with /usr/bin/python3.11, and /opt/app/main.py is world-writable (mode 0666)"},
+		{"script through env", map[string]string{"User": "app", "ExecStart": "/usr/bin/env PYTHONUNBUFFERED=1 python3 /srv/%p/main.py"}, func(fs *validation.MockFileSystem) {
+			fs.Files["/srv/test/main.py"] = true
+			fs.Owners["/srv/test/main.py"] = validation.FileOwner{UID: 990}
+		}, "the script /srv/test/main.py with python3"},
+		{"sticky directory", map[string]string{"User": "app", "ExecStart": "/tmp/tool"}, func(fs *validation.MockFileSystem) {
+			fs.Files["/tmp/tool"] = true
+			fs.Files["/tmp"] = true
+			fs.Modes["/tmp"] = 0777 | os.ModeSticky
+		}, ""},
+		{"numeric user", map[string]string{"User": "990", "ExecStart": "/usr/bin/app"}, func(fs *validation.MockFileSystem) {
+			fs.Owners["/usr/bin/app"] = validation.FileOwner{UID: 990}
+		}, "/usr/bin/app is owned by UID 990"},
+		{"command before a reset", map[string]string{"User": "app", "ExecStart": "/usr/bin/app"}, func(fs *validation.MockFileSystem) {
+			fs.Files["/opt/old"] = true
+			fs.Modes["/opt/old"] = 0777
+		}, ""},
+		{"root service", map[string]string{"ExecStart": "/usr/bin/app"}, func(fs *validation.MockFileSystem) {
+			fs.Modes["/usr/bin/app"] = 0777
+		}, ""},
+		{"dynamic user", map[string]string{"User": "app", "DynamicUser": "yes", "ExecStart": "/usr/bin/app"}, func(fs *validation.MockFileSystem) {
+			fs.Modes["/usr/bin/app"] = 0777
+		}, ""},
+		{"unknown user", map[string]string{"User": "ghost", "ExecStart": "/usr/bin/app"}, func(fs *validation.MockFileSystem) {
+			fs.Modes["/usr/bin/app"] = 0777
+		}, ""},
+		{"missing program", map[string]string{"User": "app", "ExecStart": "/opt/gone/app"}, func(fs *validation.MockFileSystem) {
+			fs.Files["/opt"] = true
+			fs.Modes["/opt"] = 0777
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := makeTestUnit(tt.directives)
+			if tt.name == "command before a reset" {
+				unit.Sections["Service"].Directives["ExecStart"] = []types.Directive{
+					{Key: "ExecStart", Value: "/opt/old", Line: 2},
+					{Key: "ExecStart", Value: "", Line: 3},
+					{Key: "ExecStart", Value: "/usr/bin/app", Line: 4},
+				}
+			}
+			fs := validation.NewMockFileSystem()
+			for _, p := range []string{"/", "/usr", "/usr/bin", "/usr/bin/app", "/usr/bin/python3.11", "/usr/bin/env"} {
+				fs.Files[p] = true
+			}
+			fs.UserOwners["app"] = validation.FileOwner{UID: 990, GID: 990}
+			if tt.setup != nil {
+				tt.setup(fs)
+			}
+			ctx := rules.NewContext(unit)
+			ctx.FileSystem = fs
+
+			issues := rule.Check(ctx)
+			if tt.want == "" {
+				if len(issues) != 0 {
+					t.Errorf("got %+v, want no issues", issues)
+				}
+				return
+			}
+			if len(issues) != 1 {
+				t.Fatalf("got %d issues, want 1: %+v", len(issues), issues)
+			}
+			if !strings.Contains(issues[0].Description, tt.want) {
+				t.Errorf("description %q should contain %q", issues[0].Description, tt.want)
+			}
+		})
+	}
+}
+
+func TestSECAGG_HardeningSummary(t *testing.T) {
+	rule := &SECAGG{}
+	issue := func(id, unit string, tags ...string) types.Issue {
+		return types.Issue{RuleID: id, Unit: unit, Category: types.CategorySecurity, Tags: tags}
+	}
+	hardening := func(unit string, ids ...string) []types.Issue {
+		var issues []types.Issue
+		for _, id := range ids {
+			issues = append(issues, issue(id, unit, "hardening"))
+		}
+		return issues
+	}
+
+	root := makeTestUnit(nil)
+	root.Name = "root.service"
+	user := makeTestUnit(map[string]string{"User": "app"})
+	user.Name = "user.service"
+	ctx := rules.NewContextWithUnits(nil, map[string]*types.UnitFile{root.Name: root, user.Name: user})
+
+	tests := []struct {
+		name         string
+		issues       []types.Issue
+		wantSeverity types.Severity
+		wantIssues   int
+	}{
+		{"two rules", hardening("user.service", "SEC001", "SEC002"), 0, 0},
+		{"same rule twice", hardening("user.service", "SEC001", "SEC001", "SEC002"), 0, 0},
+		{"other tags", append(hardening("user.service", "SEC001", "SEC002"), issue("SEC018", "user.service", "privilege")), 0, 0},
+		{"three rules", hardening("user.service", "SEC001", "SEC002", "SEC013"), types.SeverityMedium, 1},
+		{"five rules", hardening("user.service", "SEC001", "SEC002", "SEC003", "SEC004", "SEC013"), types.SeverityHigh, 1},
+		{"three rules as root", hardening("root.service", "SEC001", "SEC002", "SEC013"), types.SeverityHigh, 1},
+		{"seven rules as root", hardening("root.service", "SEC001", "SEC002", "SEC003", "SEC004", "SEC005", "SEC007", "SEC013"), types.SeverityCritical, 1},
+		{"unknown unit", hardening("gone.service", "SEC001", "SEC002", "SEC013"), types.SeverityMedium, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := rule.Aggregate(ctx, tt.issues)
+			if len(issues) != tt.wantIssues {
+				t.Fatalf("got %d issues, want %d: %+v", len(issues), tt.wantIssues, issues)
+			}
+			if tt.wantIssues > 0 && issues[0].Severity != tt.wantSeverity {
+				t.Errorf("severity = %v, want %v", issues[0].Severity, tt.wantSeverity)
+			}
+		})
+	}
+
+	issues := rule.Aggregate(ctx, hardening("root.service", "SEC013", "SEC001", "SEC002"))
+	if want := "Unit fails 3 or more hardening rules at once and runs as root."; issues[0].Description != want {
+		t.Errorf("description = %q, want %q", issues[0].Description, want)
+	}
+	var failed []string
+	for _, ref := range issues[0].Refs {
+		if ref.Kind == types.ReferenceRule {
+			failed = append(failed, ref.Title)
+		}
+	}
+	if got := strings.Join(failed, ", "); got != "SEC001, SEC002, SEC013" {
+		t.Errorf("rule refs = %q, want SEC001, SEC002, SEC013", got)
+	}
+	fewer := rule.Aggregate(ctx, hardening("root.service", "SEC001", "SEC002", "SEC003", "SEC013"))
+	if fewer[0].Description != issues[0].Description || fewer[0].Fingerprint() != issues[0].Fingerprint() {
+		t.Errorf("summary changed with the failed rules: %q", fewer[0].Description)
+	}
+	if issues[0].File != root.Path {
+		t.Errorf("file = %q, want %q", issues[0].File, root.Path)
+	}
+}
+
 func TestRuleMetadata(t *testing.T) {
 	testRules := []rules.Rule{
 		&SEC001{},
@@ -210,6 +683,12 @@ func TestRuleMetadata(t *testing.T) {
 		&SEC004{},
 		&SEC005{},
 		&SEC006{},
+		&SEC016{},
+		&SEC017{},
+		&SEC018{},
+		&SEC019{},
+		&SEC020{},
+		&SECAGG{},
 	}
 
 	for _, rule := range testRules {
@@ -240,24 +719,45 @@ func TestRuleMetadata(t *testing.T) {
 }
 
 func TestNonServiceUnit(t *testing.T) {
+	socket := func(directives map[string]string) *types.UnitFile {
+		unit := &types.UnitFile{
+			Name: "test.socket",
+			Type: "socket",
+			Sections: map[string]*types.Section{
+				"Socket": {
+					Name:       "Socket",
+					Directives: make(map[string][]types.Directive),
+				},
+			},
+		}
+		for k, v := range directives {
+			unit.Sections["Socket"].Directives[k] = []types.Directive{{Key: k, Value: v}}
+		}
+		return unit
+	}
 	rule := &SEC001{}
 
-	// Create a socket unit (not a service)
-	unit := &types.UnitFile{
-		Name: "test.socket",
-		Type: "socket",
-		Sections: map[string]*types.Section{
-			"Socket": {
-				Name:       "Socket",
-				Directives: make(map[string][]types.Directive),
-			},
-		},
+	// A socket that runs no commands has nothing to confine
+	if issues := rule.Check(rules.NewContext(socket(nil))); len(issues) != 0 {
+		t.Errorf("socket without commands got %d issues", len(issues))
 	}
 
-	ctx := rules.NewContext(unit)
-	issues := rule.Check(ctx)
+	issues := rule.Check(rules.NewContext(socket(map[string]string{"ExecStartPre": "/usr/bin/prepare"})))
+	if len(issues) != 1 || !strings.HasPrefix(issues[0].Description, "Socket ") || !strings.Contains(issues[0].Suggestion, "[Socket]") {
+		t.Fatalf("socket with ExecStartPre= should be reported for its [Socket] section: %+v", issues)
+	}
+	hardened := socket(map[string]string{"ExecStartPre": "/usr/bin/prepare", "NoNewPrivileges": "yes"})
+	if issues := rule.Check(rules.NewContext(hardened)); len(issues) != 0 {
+		t.Errorf("hardened socket got %d issues", len(issues))
+	}
 
-	if len(issues) != 0 {
-		t.Errorf("Security rules should not apply to non-service units, got %d issues", len(issues))
+	// Namespacing options would hide a mount from the system, so only the
+	// rules that do not set up namespaces apply to mounts
+	mount := &types.UnitFile{Name: "data.mount", Type: "mount"}
+	if !rules.AppliesTo(&SEC001{}, mount) || rules.AppliesTo(&SEC002{}, mount) {
+		t.Error("SEC001 should apply to mounts and SEC002 should not")
+	}
+	if rules.AppliesTo(&SEC005{}, socket(nil)) || rules.AppliesTo(&SEC001{}, &types.UnitFile{Name: "x.timer", Type: "timer"}) {
+		t.Error("SEC005 should only apply to services and SEC001 not to timers")
 	}
 }
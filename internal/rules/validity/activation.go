@@ -0,0 +1,205 @@
+package validity
+
+import (
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	activationRules := []*valRule{
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL007",
+				RuleName:        "Socket activates a missing service",
+				RuleDescription: "A socket starts the service named by Service=, or the service of its own name, when a connection arrives; if that service does not exist, every connection fails.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "socket-activation", "missing-unit"},
+				RuleSuggestion:  "Add the service, or name an existing one with Service=.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#Service="},
+			},
+			appliesTo:    []string{"socket"},
+			capabilities: rules.CapabilityCrossUnit,
+			find: func(ctx *rules.Context) []finding {
+				v := validation.ValidateSocket(ctx.Unit, ctx.AllUnits, nil)
+				if !v.MissingService || ctx.HasUnit(v.ServiceName) {
+					return nil
+				}
+				return []finding{missingTrigger(ctx.Unit, "Socket", "Service", v.ServiceName, "activates")}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL008",
+				RuleName:        "Socket has no Listen directive",
+				RuleDescription: "A socket unit needs at least one ListenStream=, ListenDatagram= or other Listen setting; systemd refuses to load one without.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "socket-activation"},
+				RuleSuggestion:  "Add the address the socket listens on, such as ListenStream=8080 or ListenStream=/run/app.sock.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#ListenStream="},
+			},
+			appliesTo: []string{"socket"},
+			find: func(ctx *rules.Context) []finding {
+				if !validation.ValidateSocket(ctx.Unit, ctx.AllUnits, nil).NoListen {
+					return nil
+				}
+				return []finding{{section: "Socket", description: ctx.Unit.Name + " has no Listen setting, so systemd refuses to load it."}}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL009",
+				RuleName:        "Invalid Listen address",
+				RuleDescription: "A Listen setting names a port out of range, an IP address without a port, a relative FIFO path or an unknown netlink family, which systemd cannot listen on.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "socket-activation"},
+				RuleSuggestion:  "Fix the address, such as ListenStream=127.0.0.1:8080 for an IP address.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.socket.html#ListenStream="},
+			},
+			appliesTo: []string{"socket"},
+			find: func(ctx *rules.Context) []finding {
+				var findings []finding
+				for _, l := range validation.ValidateSocket(ctx.Unit, ctx.AllUnits, nil).InvalidListen {
+					findings = append(findings, finding{section: "Socket", directive: l.Directive, value: l.Value, line: l.Line, description: l.Directive + "=" + l.Value + ": " + l.Reason + "."})
+				}
+				return findings
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL010",
+				RuleName:        "Timer triggers a missing unit",
+				RuleDescription: "A timer starts the unit named by Unit=, or the service of its own name, when it elapses; if that unit does not exist, the timer fails.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "timer", "missing-unit"},
+				RuleSuggestion:  "Add the service, or name an existing unit with Unit=.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.timer.html#Unit="},
+			},
+			appliesTo:    []string{"timer"},
+			capabilities: rules.CapabilityCrossUnit,
+			find: func(ctx *rules.Context) []finding {
+				v := validation.ValidateTimer(ctx.Unit, ctx.AllUnits)
+				if !v.MissingService || ctx.HasUnit(v.ServiceName) {
+					return nil
+				}
+				return []finding{missingTrigger(ctx.Unit, "Timer", "Unit", v.ServiceName, "triggers")}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL011",
+				RuleName:        "Timer has no trigger",
+				RuleDescription: "A timer unit needs OnCalendar=, OnBootSec= or another setting saying when it elapses; systemd refuses to load one without.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "timer"},
+				RuleSuggestion:  "Add when the timer elapses, such as OnCalendar=daily or OnUnitActiveSec=15min.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.timer.html#Options"},
+			},
+			appliesTo: []string{"timer"},
+			find: func(ctx *rules.Context) []finding {
+				if !validation.ValidateTimer(ctx.Unit, ctx.AllUnits).NoTrigger {
+					return nil
+				}
+				return []finding{{section: "Timer", description: ctx.Unit.Name + " has no OnCalendar= or other trigger, so systemd refuses to load it."}}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL012",
+				RuleName:        "Invalid timer time span",
+				RuleDescription: "OnBootSec=, OnActiveSec= and the other monotonic timer settings take a time span such as 5min or 1h 30min.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "timer"},
+				RuleSuggestion:  "Write the time span with units systemd knows, such as 90s, 15min or 1h30min.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.time.html#Parsing%20Time%20Spans"},
+			},
+			appliesTo: []string{"timer"},
+			find: func(ctx *rules.Context) []finding {
+				var findings []finding
+				for _, t := range validation.ValidateTimer(ctx.Unit, ctx.AllUnits).InvalidTimers {
+					findings = append(findings, finding{section: "Timer", directive: t.Directive, value: t.Value, line: t.Line, description: t.Directive + "=" + t.Value + " is not a valid time span."})
+				}
+				return findings
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL013",
+				RuleName:        "Path unit triggers a missing unit",
+				RuleDescription: "A path unit starts the unit named by Unit=, or the service of its own name, when its path changes; if that unit does not exist, the path unit fails.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "paths", "missing-unit"},
+				RuleSuggestion:  "Add the service, or name an existing unit with Unit=.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.path.html#Unit="},
+			},
+			appliesTo:    []string{"path"},
+			capabilities: rules.CapabilityCrossUnit,
+			find: func(ctx *rules.Context) []finding {
+				v := validation.ValidatePath(ctx.Unit, ctx.AllUnits)
+				if !v.MissingService || ctx.HasUnit(v.ServiceName) {
+					return nil
+				}
+				return []finding{missingTrigger(ctx.Unit, "Path", "Unit", v.ServiceName, "triggers")}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL014",
+				RuleName:        "Path unit watches nothing",
+				RuleDescription: "A path unit needs PathExists=, PathChanged= or another path to watch; systemd refuses to load one without.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "paths"},
+				RuleSuggestion:  "Add the path to watch, such as PathChanged=/etc/app/config.yaml.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.path.html#Options"},
+			},
+			appliesTo: []string{"path"},
+			find: func(ctx *rules.Context) []finding {
+				if !validation.ValidatePath(ctx.Unit, ctx.AllUnits).NoPathDirective {
+					return nil
+				}
+				return []finding{{section: "Path", description: ctx.Unit.Name + " has no path to watch, so systemd refuses to load it."}}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL015",
+				RuleName:        "Questionable watched path",
+				RuleDescription: "Paths a path unit watches must be absolute, and watching / or a path with .. in it rarely does what was meant.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityMedium,
+				RuleTags:        []string{"validation", "paths"},
+				RuleSuggestion:  "Watch the absolute path of the file or directory the service handles.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.path.html#PathExists="},
+			},
+			appliesTo: []string{"path"},
+			find: func(ctx *rules.Context) []finding {
+				var findings []finding
+				for _, p := range validation.ValidatePath(ctx.Unit, ctx.AllUnits).InvalidPaths {
+					findings = append(findings, finding{section: "Path", directive: p.Directive, value: p.Value, line: p.Line, description: p.Directive + "=" + p.Value + ": " + p.Reason + "."})
+				}
+				return findings
+			},
+		},
+	}
+	for _, r := range activationRules {
+		rules.Register(r)
+	}
+}
+
+// missingTrigger is the finding for a socket, timer or path unit whose unit
+// to start does not exist, at the directive naming it if there is one
+func missingTrigger(unit *types.UnitFile, section, key, name, verb string) finding {
+	f := finding{section: section, value: name, description: unit.Name + " " + verb + " " + name + ", which does not exist."}
+	if unit.HasDirective(section, key) {
+		f.directive = key
+	}
+	return f
+}
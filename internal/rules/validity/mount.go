@@ -0,0 +1,96 @@
+package validity
+
+import (
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	mountRules := []*valRule{
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL016",
+				RuleName:        "Mount unit has no What=",
+				RuleDescription: "A mount unit needs What=, the device or source to mount; systemd refuses to load one without.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "mount"},
+				RuleSuggestion:  "Add What=, such as What=/dev/disk/by-uuid/... or What=server:/export for NFS.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.mount.html#What="},
+			},
+			appliesTo: []string{"mount"},
+			find: func(ctx *rules.Context) []finding {
+				if !validation.ValidateMount(ctx.Unit, nil).WhatMissing {
+					return nil
+				}
+				return []finding{{section: "Mount", description: ctx.Unit.Name + " has no What=, so systemd refuses to load it."}}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL017",
+				RuleName:        "Mount unit name does not match Where=",
+				RuleDescription: "A mount unit must be named after its mount point, escaped as systemd-escape --path does; systemd refuses to load one whose name does not match Where=.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "mount"},
+				RuleSuggestion:  "Rename the unit to the name systemd-escape --path --suffix=mount prints for the mount point.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.mount.html#Where="},
+			},
+			appliesTo: []string{"mount"},
+			find: func(ctx *rules.Context) []finding {
+				v := validation.ValidateMount(ctx.Unit, nil)
+				if !v.NameMismatch {
+					return nil
+				}
+				return []finding{{section: "Mount", directive: "Where", value: v.WhereValue, description: "Where=" + v.WhereValue + " needs the unit to be named " + v.ExpectedName + ", so systemd refuses to load " + ctx.Unit.Name + "."}}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL018",
+				RuleName:        "Unknown filesystem type",
+				RuleDescription: "The Type= of a mount unit is not a filesystem sdaudit knows, which may be a typo the kernel rejects when mounting.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityLow,
+				RuleTags:        []string{"validation", "mount"},
+				RuleSuggestion:  "Check the type against /proc/filesystems, or leave Type= out to let mount detect it.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.mount.html#Type="},
+			},
+			appliesTo: []string{"mount"},
+			find: func(ctx *rules.Context) []finding {
+				v := validation.ValidateMount(ctx.Unit, nil)
+				if !v.InvalidFSType {
+					return nil
+				}
+				return []finding{{section: "Mount", directive: "Type", value: v.FSType, description: "Type=" + v.FSType + " is not a known filesystem type."}}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL019",
+				RuleName:        "Mount device not found",
+				RuleDescription: "The device in What= does not exist, so the mount waits for it until the device times out, unless it shows up later in boot.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityMedium,
+				RuleTags:        []string{"validation", "mount"},
+				RuleSuggestion:  "Refer to the device by a stable name, such as /dev/disk/by-uuid/..., and add nofail to Options= if the device is optional.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.mount.html#What="},
+			},
+			appliesTo: []string{"mount"},
+			// Devices only exist on the running system, not in an image
+			capabilities: rules.CapabilityFilesystem | rules.CapabilityRuntime,
+			find: func(ctx *rules.Context) []finding {
+				v := validation.ValidateMount(ctx.Unit, ctx.FileSystem)
+				if !v.DeviceNotFound {
+					return nil
+				}
+				return []finding{{section: "Mount", directive: "What", value: v.WhatValue, description: "What=" + v.WhatValue + " does not exist."}}
+			},
+		},
+	}
+	for _, r := range mountRules {
+		rules.Register(r)
+	}
+}
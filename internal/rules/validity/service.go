@@ -0,0 +1,155 @@
+package validity
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func init() {
+	serviceRules := []*valRule{
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL001",
+				RuleName:        "Service has no ExecStart=",
+				RuleDescription: "Only Type=oneshot services may leave out ExecStart=; systemd refuses to load any other service without one.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "exec"},
+				RuleSuggestion:  "Add the command that runs the service as ExecStart=, or set Type=oneshot for a service that only runs ExecStop= or ExecStartPre= commands.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#ExecStart="},
+			},
+			appliesTo: []string{"service"},
+			find: func(ctx *rules.Context) []finding {
+				if !validation.ValidateService(ctx.Unit, nil).ExecStartMissing {
+					return nil
+				}
+				return []finding{{section: "Service", directive: "ExecStart", description: ctx.Unit.Name + " has no ExecStart=, so systemd refuses to load it."}}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL002",
+				RuleName:        "Service Type= requirement not met",
+				RuleDescription: "Some service types need other settings: Type=dbus needs BusName=, and Type=forking works best with PIDFile=. An unknown Type= makes systemd ignore the setting.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityMedium,
+				RuleTags:        []string{"validation", "type"},
+				RuleSuggestion:  "Set the directives the service type needs, or pick the type that matches how the program starts.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.service.html#Type="},
+			},
+			appliesTo: []string{"service"},
+			find: func(ctx *rules.Context) []finding {
+				var findings []finding
+				for _, issue := range validation.ValidateService(ctx.Unit, nil).TypeIssues {
+					f := finding{section: "Service", directive: "Type", value: ctx.Unit.GetDirective("Service", "Type"), description: issue + "."}
+					if strings.HasPrefix(issue, "Unknown Type=") {
+						f.severity = "high"
+					}
+					findings = append(findings, f)
+				}
+				return findings
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL003",
+				RuleName:        "Sandboxing contradicts the service's commands",
+				RuleDescription: "A sandboxing setting takes away something the service's own commands or working directory need, such as PrivateNetwork=yes for a command that downloads a file.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityMedium,
+				RuleTags:        []string{"validation", "sandbox"},
+				RuleSuggestion:  "Relax the setting for the paths or access the service needs, such as with ReadWritePaths=, or move the command to a unit without the sandbox.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#Sandboxing"},
+			},
+			appliesTo: []string{"service"},
+			find: func(ctx *rules.Context) []finding {
+				var findings []finding
+				for _, c := range validation.ValidateService(ctx.Unit, nil).ContradictorySandbox {
+					// A read-only PID file directory is reported by REL033
+					if strings.HasPrefix(c.ConflictsWith, "PIDFile=") {
+						continue
+					}
+					findings = append(findings, finding{
+						section:     "Service",
+						directive:   settingKey(c.Setting),
+						value:       strings.TrimPrefix(c.Setting, settingKey(c.Setting)+"="),
+						description: c.Description + ".",
+						severity:    c.Severity,
+					})
+				}
+				return findings
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL004",
+				RuleName:        "EnvironmentFile= does not exist",
+				RuleDescription: "systemd fails to start a service when an EnvironmentFile= without the - prefix is missing.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "environment"},
+				RuleSuggestion:  "Create the file, fix the path, or prefix it with - if the file is optional.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#EnvironmentFile="},
+			},
+			appliesTo:    []string{"service"},
+			capabilities: rules.CapabilityFilesystem,
+			find: func(ctx *rules.Context) []finding {
+				var findings []finding
+				for _, m := range validation.ValidateDirectives(ctx.Unit, ctx.FileSystem).MissingEnvFiles {
+					if m.Optional {
+						continue
+					}
+					findings = append(findings, finding{section: "Service", directive: m.Directive, value: m.Path, line: m.Line, description: "EnvironmentFile=" + m.Path + " does not exist, so the service fails to start."})
+				}
+				return findings
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL005",
+				RuleName:        "WorkingDirectory= does not exist",
+				RuleDescription: "systemd fails to start a service whose WorkingDirectory= is missing, with status 200/CHDIR, unless the path is prefixed with -.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "paths"},
+				RuleSuggestion:  "Create the directory, have systemd create it with StateDirectory= or RuntimeDirectory=, or prefix the path with -.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#WorkingDirectory="},
+			},
+			appliesTo:    []string{"service"},
+			capabilities: rules.CapabilityFilesystem,
+			find: func(ctx *rules.Context) []finding {
+				dir := validation.ValidateDirectives(ctx.Unit, ctx.FileSystem).MissingWorkDir
+				if dir == "" {
+					return nil
+				}
+				return []finding{{section: "Service", directive: "WorkingDirectory", value: dir, description: "WorkingDirectory=" + dir + " does not exist, so the service fails to start."}}
+			},
+		},
+		{
+			BaseRule: rules.BaseRule{
+				RuleID:          "VAL006",
+				RuleName:        "Invalid directory name for systemd to create",
+				RuleDescription: "RuntimeDirectory=, StateDirectory=, CacheDirectory=, LogsDirectory= and ConfigurationDirectory= take names relative to their base directory, such as /run, and systemd ignores absolute paths and names with .. in them.",
+				RuleCategory:    types.CategoryReliability,
+				RuleSeverity:    types.SeverityHigh,
+				RuleTags:        []string{"validation", "paths"},
+				RuleSuggestion:  "Use a relative name such as myapp, which RuntimeDirectory= creates as /run/myapp.",
+				RuleReferences:  []string{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#RuntimeDirectory="},
+			},
+			appliesTo: []string{"service"},
+			find: func(ctx *rules.Context) []finding {
+				var findings []finding
+				for _, d := range validation.ValidateDirectives(ctx.Unit, nil).InvalidDirectories {
+					findings = append(findings, finding{section: "Service", directive: d.Directive, value: d.Name, line: d.Line, description: d.Directive + "=" + d.Name + " " + d.Reason + "."})
+				}
+				return findings
+			},
+		},
+	}
+	for _, r := range serviceRules {
+		rules.Register(r)
+	}
+}
@@ -0,0 +1,73 @@
+// Package validity holds the VAL rules, which report the findings of the
+// type-specific validators in package validation: units systemd refuses to
+// load, such as a timer without a trigger, and settings that make a unit fail
+// once started, such as a missing WorkingDirectory=. Validator findings that
+// other rules already report, such as missing programs (REL035) or users
+// (BP009), are left to them.
+package validity
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// finding is one finding of a validator, at the directive it is about
+type finding struct {
+	section   string
+	directive string
+	value     string
+	// line is the line of the directive, 0 to use the last one in the unit
+	line        int
+	description string
+	// severity rates the finding on its own, empty to keep the rule's
+	severity string
+}
+
+// valRule reports one kind of finding of a validator
+type valRule struct {
+	rules.BaseRule
+	appliesTo    []string
+	capabilities rules.Capability
+	find         func(ctx *rules.Context) []finding
+}
+
+func (r *valRule) AppliesTo() []string            { return r.appliesTo }
+func (r *valRule) Capabilities() rules.Capability { return r.capabilities }
+
+func (r *valRule) Check(ctx *rules.Context) []types.Issue {
+	unit := ctx.Unit
+	// A masked unit has no settings to validate
+	if unit == nil || unit.Masked {
+		return nil
+	}
+	if r.capabilities&rules.CapabilityFilesystem != 0 && ctx.FileSystem == nil {
+		return nil
+	}
+
+	var issues []types.Issue
+	for _, f := range r.find(ctx) {
+		line := f.line
+		if line == 0 && f.directive != "" {
+			line = rules.DirectiveLine(unit, f.section, f.directive)
+		}
+		var ref *int
+		if line > 0 {
+			ref = &line
+		}
+		issue := r.NewIssue(unit, f.description, ref)
+		if f.severity != "" {
+			issue.Severity = types.ParseSeverity(f.severity)
+		}
+		issue.Directive, issue.Value, issue.Section = f.directive, f.value, f.section
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// settingKey returns the directive of a setting such as "PrivateNetwork=yes"
+func settingKey(setting string) string {
+	key, _, _ := strings.Cut(setting, "=")
+	return key
+}
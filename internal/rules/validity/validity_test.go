@@ -0,0 +1,174 @@
+package validity
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/unitfile"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func parseUnits(t *testing.T, contents map[string]string) map[string]*types.UnitFile {
+	t.Helper()
+	units := make(map[string]*types.UnitFile)
+	for name, content := range contents {
+		unit, err := unitfile.ParseContent(filepath.Join("/etc/systemd/system", name), content)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", name, err)
+		}
+		units[name] = unit
+	}
+	return units
+}
+
+func TestRules(t *testing.T) {
+	units := parseUnits(t, map[string]string{
+		"noexec.service":   "[Service]\nUser=app\n",
+		"dbus.service":     "[Service]\nType=dbus\nExecStart=/usr/bin/app\n",
+		"weird.service":    "[Service]\nType=sometimes\nExecStart=/usr/bin/app\n",
+		"offline.service":  "[Service]\nExecStart=/usr/bin/curl -o /tmp/x https://example.com\nPrivateNetwork=yes\n",
+		"env.service":      "[Service]\nExecStart=/usr/bin/app\nEnvironmentFile=/etc/app/app.env\nEnvironmentFile=-/etc/app/local.env\n",
+		"workdir.service":  "[Service]\nExecStart=/usr/bin/app\nWorkingDirectory=/opt/app\n",
+		"dirs.service":     "[Service]\nExecStart=/usr/bin/app\nRuntimeDirectory=/run/app\n",
+		"orphan.socket":    "[Socket]\nListenStream=8080\n",
+		"quiet.socket":     "[Socket]\nAccept=no\nService=app.service\n",
+		"badport.socket":   "[Socket]\nListenStream=70000\nService=app.service\n",
+		"app.service":      "[Service]\nExecStart=/usr/bin/app\n",
+		"orphan.timer":     "[Timer]\nOnCalendar=daily\nUnit=gone.service\n",
+		"idle.timer":       "[Timer]\nUnit=app.service\n",
+		"span.timer":       "[Timer]\nOnBootSec=soon\nUnit=app.service\n",
+		"orphan.path":      "[Path]\nPathChanged=/etc/app\n",
+		"blind.path":       "[Path]\nUnit=app.service\n",
+		"relative.path":    "[Path]\nPathExists=etc/app\nUnit=app.service\n",
+		"data.mount":       "[Mount]\nWhere=/data\nType=ext4\n",
+		"misnamed.mount":   "[Mount]\nWhat=/dev/sdb1\nWhere=/srv/data\n",
+		"srv.mount":        "[Mount]\nWhat=/dev/sdb1\nWhere=/srv\nType=ext5\n",
+		"mnt.mount":        "[Mount]\nWhat=/dev/sdc1\nWhere=/mnt\n",
+		"worker@.service":  "[Service]\nExecStart=/usr/bin/worker %i\n",
+		"worker.timer":     "[Timer]\nOnCalendar=hourly\nUnit=worker@queue.service\n",
+		"good.service":     "[Service]\nExecStart=/usr/bin/app\nWorkingDirectory=/var/lib/good\nStateDirectory=good\n",
+		"good.socket":      "[Socket]\nListenStream=\nListenStream=127.0.0.1:8080\nService=good.service\n",
+		"clock.timer":      "[Timer]\nOnClockChange=yes\nOnUnitActiveSec=1h30min\nUnit=good.service\n",
+		"-.mount":          "[Mount]\nWhat=/dev/sda1\nWhere=/\n",
+		"chroot.service":   "[Service]\nExecStart=/usr/bin/app\nRootDirectory=/srv/root\nWorkingDirectory=/app\n",
+		"template.service": "[Service]\nType=oneshot\nExecStop=/usr/bin/cleanup\n",
+	})
+	fs := validation.NewMockFileSystem()
+	fs.Files["/usr/bin/app"] = true
+	fs.Files["/dev/sda1"] = true
+	fs.Files["/dev/sdb1"] = true
+	fs.Directories["/var/lib"] = true
+
+	tests := []struct {
+		rule     string
+		unit     string
+		wantLine int
+		wantText string
+	}{
+		{"VAL001", "noexec.service", 0, "has no ExecStart="},
+		{"VAL002", "dbus.service", 2, "Type=dbus requires BusName="},
+		{"VAL002", "weird.service", 2, "Unknown Type=sometimes"},
+		{"VAL003", "offline.service", 3, "uses curl"},
+		{"VAL004", "env.service", 3, "EnvironmentFile=/etc/app/app.env does not exist"},
+		{"VAL005", "workdir.service", 3, "WorkingDirectory=/opt/app does not exist"},
+		{"VAL006", "dirs.service", 3, "RuntimeDirectory=/run/app must not be an absolute path"},
+		{"VAL007", "orphan.socket", 0, "activates orphan.service, which does not exist"},
+		{"VAL008", "quiet.socket", 0, "has no Listen setting"},
+		{"VAL009", "badport.socket", 2, "out of valid range"},
+		{"VAL010", "orphan.timer", 3, "triggers gone.service, which does not exist"},
+		{"VAL011", "idle.timer", 0, "has no OnCalendar="},
+		{"VAL012", "span.timer", 2, "OnBootSec=soon is not a valid time span"},
+		{"VAL013", "orphan.path", 0, "triggers orphan.service, which does not exist"},
+		{"VAL014", "blind.path", 0, "has no path to watch"},
+		{"VAL015", "relative.path", 2, "etc/app is not an absolute path"},
+		{"VAL016", "data.mount", 0, "has no What="},
+		{"VAL017", "misnamed.mount", 3, "needs the unit to be named srv-data.mount"},
+		{"VAL018", "srv.mount", 4, "Type=ext5"},
+		{"VAL019", "mnt.mount", 2, "What=/dev/sdc1 does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" "+tt.unit, func(t *testing.T) {
+			rule := rules.Get(tt.rule)
+			if rule == nil {
+				t.Fatalf("rule %s is not registered", tt.rule)
+			}
+
+			for name, unit := range units {
+				if !rules.AppliesTo(rule, unit) {
+					continue
+				}
+				ctx := rules.NewContextWithUnits(unit, units)
+				ctx.FileSystem = fs
+				issues := rule.Check(ctx)
+
+				var want bool
+				for _, other := range tests {
+					want = want || other.rule == tt.rule && other.unit == name
+				}
+				if !want {
+					if len(issues) != 0 {
+						t.Errorf("%s found %+v on %s", tt.rule, issues, name)
+					}
+					continue
+				}
+				if name != tt.unit {
+					continue
+				}
+				if len(issues) != 1 {
+					t.Fatalf("%s on %s: got %d issues, want 1: %+v", tt.rule, name, len(issues), issues)
+				}
+				issue := issues[0]
+				switch {
+				case tt.wantLine == 0 && issue.Line != nil:
+					t.Errorf("Line = %d, want none", *issue.Line)
+				case tt.wantLine != 0 && (issue.Line == nil || *issue.Line != tt.wantLine):
+					t.Errorf("Line = %v, want %d", issue.Line, tt.wantLine)
+				}
+				if !strings.Contains(issue.Description, tt.wantText) {
+					t.Errorf("description %q should contain %q", issue.Description, tt.wantText)
+				}
+			}
+		})
+	}
+}
+
+func TestSeverityOfFinding(t *testing.T) {
+	units := parseUnits(t, map[string]string{
+		"weird.service": "[Service]\nType=sometimes\nExecStart=/usr/bin/app\n",
+		"fork.service":  "[Service]\nType=forking\nExecStart=/usr/bin/app\n",
+	})
+	rule := rules.Get("VAL002")
+	for name, want := range map[string]types.Severity{"weird.service": types.SeverityHigh, "fork.service": types.SeverityMedium} {
+		issues := rule.Check(rules.NewContextWithUnits(units[name], units))
+		if len(issues) != 1 || issues[0].Severity != want {
+			t.Errorf("VAL002 on %s = %+v, want one %s issue", name, issues, want)
+		}
+	}
+}
+
+func TestWithoutFileSystem(t *testing.T) {
+	units := parseUnits(t, map[string]string{
+		"env.service": "[Service]\nExecStart=/usr/bin/app\nEnvironmentFile=/etc/app/app.env\nWorkingDirectory=/opt/app\n",
+		"mnt.mount":   "[Mount]\nWhat=/dev/sdc1\nWhere=/mnt\n",
+	})
+	for _, id := range []string{"VAL004", "VAL005", "VAL019"} {
+		for _, unit := range units {
+			if issues := rules.Get(id).Check(rules.NewContextWithUnits(unit, units)); len(issues) != 0 {
+				t.Errorf("%s without a filesystem found %+v", id, issues)
+			}
+		}
+	}
+}
+
+func TestMaskedUnit(t *testing.T) {
+	unit := &types.UnitFile{Name: "gone.timer", Path: "/etc/systemd/system/gone.timer", Type: "timer", Masked: true, Sections: map[string]*types.Section{}}
+	for _, id := range []string{"VAL010", "VAL011"} {
+		if issues := rules.Get(id).Check(rules.NewContext(unit)); len(issues) != 0 {
+			t.Errorf("%s found %+v on a masked unit", id, issues)
+		}
+	}
+}
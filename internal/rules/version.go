@@ -0,0 +1,165 @@
+package rules
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VersionedRule is implemented by rules that check a directive which only
+// exists from a specific systemd version onwards
+type VersionedRule interface {
+	MinSystemdVersion() int
+}
+
+// MinSystemdVersion returns the minimum systemd version a rule applies to, or 0 if it has none
+func MinSystemdVersion(rule Rule) int {
+	if v, ok := rule.(VersionedRule); ok {
+		return v.MinSystemdVersion()
+	}
+	return 0
+}
+
+// Supported reports whether a rule applies to the given systemd version.
+// An unknown version (0) supports every rule.
+func Supported(rule Rule, version int) bool {
+	if version <= 0 {
+		return true
+	}
+	return MinSystemdVersion(rule) <= version
+}
+
+// DirectiveVersions maps directives to the systemd version that introduced them
+var DirectiveVersions = map[string]int{
+	"AmbientCapabilities":             229,
+	"AssertPathIsEncrypted":           246,
+	"BindPaths":                       233,
+	"BindReadOnlyPaths":               233,
+	"CacheDirectory":                  235,
+	"ConditionCPUs":                   244,
+	"ConditionControlGroupController": 242,
+	"ConditionCredential":             252,
+	"ConditionFirmware":               249,
+	"ConditionMemory":                 244,
+	"ConditionOSRelease":              249,
+	"ConditionPathIsEncrypted":        246,
+	"ConfigurationDirectory":          235,
+	"CoredumpFilter":                  246,
+	"DelegateSubgroup":                254,
+	"DynamicUser":                     232,
+	"ExecCondition":                   243,
+	"ExecPaths":                       249,
+	"ExitType":                        250,
+	"ExtensionDirectories":            251,
+	"ExtensionImages":                 248,
+	"FailureActionExitStatus":         240,
+	"IPAddressAllow":                  235,
+	"IPAddressDeny":                   235,
+	"ImportCredential":                254,
+	"InaccessiblePaths":               231,
+	"JobRunningTimeoutSec":            209,
+	"KeyringMode":                     235,
+	"LoadCredential":                  247,
+	"LoadCredentialEncrypted":         250,
+	"LockPersonality":                 235,
+	"LogExtraFields":                  236,
+	"LogNamespace":                    245,
+	"LogRateLimitBurst":               240,
+	"LogRateLimitIntervalSec":         240,
+	"LogsDirectory":                   235,
+	"ManagedOOMMemoryPressure":        247,
+	"ManagedOOMSwap":                  247,
+	"MemoryDenyWriteExecute":          231,
+	"MemoryHigh":                      231,
+	"MemoryMax":                       231,
+	"MemoryPressureWatch":             254,
+	"MemoryZSwapMax":                  252,
+	"MountImages":                     247,
+	"NFTSet":                          254,
+	"NUMAPolicy":                      243,
+	"NoExecPaths":                     249,
+	"NoNewPrivileges":                 187,
+	"OOMPolicy":                       243,
+	"OnSuccess":                       249,
+	"OnSuccessJobMode":                249,
+	"OpenFile":                        253,
+	"PrivateDevices":                  209,
+	"PrivateIPC":                      248,
+	"PrivateMounts":                   239,
+	"PrivateTmp":                      183,
+	"PrivateUsers":                    232,
+	"ProcSubset":                      247,
+	"ProtectClock":                    245,
+	"ProtectControlGroups":            232,
+	"ProtectHome":                     214,
+	"ProtectHostname":                 242,
+	"ProtectKernelLogs":               244,
+	"ProtectKernelModules":            232,
+	"ProtectKernelTunables":           232,
+	"ProtectProc":                     247,
+	"ProtectSystem":                   214,
+	"ReadOnlyPaths":                   231,
+	"ReadWritePaths":                  231,
+	"ReloadSignal":                    253,
+	"RemoveIPC":                       232,
+	"RestartKillSignal":               244,
+	"RestartMaxDelaySec":              254,
+	"RestartMode":                     254,
+	"RestartSteps":                    254,
+	"RestrictAddressFamilies":         211,
+	"RestrictFileSystems":             249,
+	"RestrictNamespaces":              233,
+	"RestrictNetworkInterfaces":       250,
+	"RestrictRealtime":                231,
+	"RestrictSUIDSGID":                242,
+	"RootHash":                        246,
+	"RootImage":                       233,
+	"RootVerity":                      246,
+	"RuntimeMaxSec":                   229,
+	"SetCredential":                   247,
+	"SetLoginEnvironment":             255,
+	"SocketBindAllow":                 249,
+	"SocketBindDeny":                  249,
+	"StandardInputData":               236,
+	"StandardInputText":               236,
+	"StartLimitIntervalSec":           230,
+	"StartupMemoryMax":                252,
+	"StateDirectory":                  235,
+	"SuccessActionExitStatus":         240,
+	"SurviveFinalKillSignal":          255,
+	"SystemCallArchitectures":         209,
+	"SystemCallErrorNumber":           209,
+	"SystemCallFilter":                187,
+	"SystemCallLog":                   247,
+	"TasksMax":                        227,
+	"TemporaryFileSystem":             238,
+	"TimeoutAbortSec":                 243,
+	"TimeoutStartFailureMode":         246,
+	"TimeoutStopFailureMode":          246,
+	"UnsetEnvironment":                235,
+	"WatchdogSignal":                  240,
+}
+
+// ParseSystemdVersion extracts the major version from strings such as
+// "252", "252.22-1~deb12u1" or the first line of systemctl --version
+// ("systemd 252 (252.22-1~deb12u1)"). It returns 0 if no version is found.
+func ParseSystemdVersion(s string) int {
+	s = strings.TrimSpace(s)
+	if line, _, ok := strings.Cut(s, "\n"); ok {
+		s = line
+	}
+	s = strings.TrimPrefix(s, "systemd ")
+
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0
+	}
+
+	v, err := strconv.Atoi(s[:end])
+	if err != nil {
+		return 0
+	}
+	return v
+}
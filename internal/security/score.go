@@ -0,0 +1,382 @@
+// Package security estimates a service's exposure from its unit file, the
+// way "systemd-analyze security" does for loaded units. It is used where the
+// external tool is unavailable and for units that are not loaded, such as
+// offline images and individual files.
+package security
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Check is the assessment of one setting
+type Check struct {
+	Name        string
+	Description string
+	Weight      float64
+	// Badness ranges from 0 (fully protected) to Range (unprotected)
+	Badness int
+	Range   int
+}
+
+// Passed reports whether the setting is fully protective
+func (c Check) Passed() bool {
+	return c.Badness == 0
+}
+
+// Score is the estimated exposure of a service, from 0.0 (safe) to 10.0 (unsafe)
+type Score struct {
+	Unit     string
+	Exposure float64
+	Level    string
+	Checks   []Check
+}
+
+// Exposure levels, as named by systemd-analyze security
+var levels = []struct {
+	min  float64
+	name string
+}{
+	{9.0, "UNSAFE"},
+	{7.5, "EXPOSED"},
+	{5.0, "MEDIUM"},
+	{1.0, "OK"},
+	{0, "SAFE"},
+}
+
+// Level names an exposure score
+func Level(exposure float64) string {
+	for _, l := range levels {
+		if exposure >= l.min {
+			return l.name
+		}
+	}
+	return "SAFE"
+}
+
+// Assess estimates the exposure of a service unit from its settings. Each
+// setting contributes its weight scaled by how unprotected it is, and the
+// total is normalized to 0-10.
+func Assess(unit *types.UnitFile) Score {
+	s := settings{unit: unit}
+
+	var rating, weights float64
+	score := Score{Unit: unit.Name}
+	for _, a := range assessors {
+		badness, description := a.assess(s)
+		if a.rng == 0 {
+			a.rng = 1
+		}
+		badness = min(max(badness, 0), a.rng)
+		score.Checks = append(score.Checks, Check{
+			Name:        a.name,
+			Description: description,
+			Weight:      a.weight,
+			Badness:     badness,
+			Range:       a.rng,
+		})
+		rating += a.weight * float64(badness) / float64(a.rng)
+		weights += a.weight
+	}
+
+	score.Exposure = math.Round(rating/weights*100) / 10
+	score.Level = Level(score.Exposure)
+	return score
+}
+
+// settings reads a service's [Service] directives, applying the settings
+// implied by DynamicUser= and PrivateDevices=
+type settings struct {
+	unit *types.UnitFile
+}
+
+// value returns the last assignment of a setting, which is the one in effect
+func (s settings) value(key string) string {
+	directives := s.unit.GetDirectives("Service", key)
+	if len(directives) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(directives[len(directives)-1].Value)
+}
+
+func (s settings) set(key string) bool {
+	return s.unit.HasDirective("Service", key)
+}
+
+func (s settings) enabled(key string) bool {
+	return parseBool(s.value(key))
+}
+
+func (s settings) dynamicUser() bool {
+	return s.enabled("DynamicUser")
+}
+
+func parseBool(v string) bool {
+	switch strings.ToLower(v) {
+	case "1", "yes", "y", "true", "t", "on":
+		return true
+	}
+	return false
+}
+
+// assessor rates one aspect of a service. badness is between 0 and rng.
+type assessor struct {
+	name   string
+	weight float64
+	rng    int
+	assess func(s settings) (badness int, description string)
+}
+
+// boolean rates a setting that protects when enabled
+func boolean(key string, weight float64, good, bad string, impliedBy ...string) assessor {
+	return assessor{
+		name:   key + "=",
+		weight: weight,
+		assess: func(s settings) (int, string) {
+			if s.enabled(key) {
+				return 0, good
+			}
+			for _, other := range impliedBy {
+				if s.enabled(other) {
+					return 0, good
+				}
+			}
+			return 1, bad
+		},
+	}
+}
+
+// dangerousCapabilities are capabilities that amount to root or close to it
+var dangerousCapabilities = []string{
+	"CAP_SYS_ADMIN", "CAP_SYS_PTRACE", "CAP_SYS_MODULE", "CAP_SYS_RAWIO",
+	"CAP_NET_ADMIN", "CAP_DAC_OVERRIDE", "CAP_DAC_READ_SEARCH", "CAP_SETUID",
+	"CAP_SETGID", "CAP_CHOWN", "CAP_FOWNER", "CAP_BPF", "CAP_SYS_BOOT",
+}
+
+// assessors mirror the settings and weights of systemd-analyze security.
+// Where systemd rates related settings individually (such as each system call
+// group or capability), they are folded into one check here.
+var assessors = []assessor{
+	{
+		name: "User=/DynamicUser=", weight: 2000, rng: 10,
+		assess: func(s settings) (int, string) {
+			if s.dynamicUser() {
+				return 0, "Service runs under a transient non-root user identity"
+			}
+			switch s.value("User") {
+			case "", "root", "0":
+				return 10, "Service runs as root user"
+			}
+			return 0, "Service runs under a static non-root user identity"
+		},
+	},
+	boolean("NoNewPrivileges", 1000, "Service processes cannot acquire new privileges", "Service processes may acquire new privileges"),
+	boolean("PrivateTmp", 1000, "Service has no access to other software's temporary files", "Service has access to other software's temporary files", "DynamicUser"),
+	boolean("PrivateDevices", 1000, "Service has no access to hardware devices", "Service potentially has access to hardware devices"),
+	boolean("PrivateNetwork", 2500, "Service has no access to the host's network", "Service has access to the host's network"),
+	boolean("PrivateUsers", 1000, "Service does not have access to other users", "Service has access to other users"),
+	boolean("PrivateMounts", 1000, "Service cannot install system mounts", "Service may install system mounts"),
+	{
+		name: "ProtectSystem=", weight: 1000, rng: 10,
+		assess: func(s settings) (int, string) {
+			v := strings.ToLower(s.value("ProtectSystem"))
+			switch {
+			case v == "strict" || (v == "" && s.dynamicUser()):
+				return 0, "Service has strict read-only access to the OS file hierarchy"
+			case v == "full":
+				return 3, "Service has very limited write access to the OS file hierarchy"
+			case parseBool(v):
+				return 5, "Service has limited write access to the OS file hierarchy"
+			}
+			return 10, "Service has full access to the OS file hierarchy"
+		},
+	},
+	{
+		name: "ProtectHome=", weight: 1000, rng: 10,
+		assess: func(s settings) (int, string) {
+			v := strings.ToLower(s.value("ProtectHome"))
+			switch {
+			case parseBool(v):
+				return 0, "Service has no access to home directories"
+			case v == "tmpfs":
+				return 1, "Service has no access to home directories, which are replaced by an empty tmpfs"
+			case v == "read-only" || (v == "" && s.dynamicUser()):
+				return 5, "Service has read-only access to home directories"
+			}
+			return 10, "Service has full access to home directories"
+		},
+	},
+	boolean("ProtectKernelTunables", 1000, "Service cannot alter kernel tunables (/proc/sys, …)", "Service may alter kernel tunables"),
+	boolean("ProtectKernelModules", 1000, "Service cannot load or read kernel modules", "Service may load or read kernel modules"),
+	boolean("ProtectKernelLogs", 1000, "Service cannot read from or write to the kernel log ring buffer", "Service may read from or write to the kernel log ring buffer"),
+	boolean("ProtectControlGroups", 1000, "Service cannot modify the control group file system", "Service may modify the control group file system"),
+	boolean("ProtectClock", 1000, "Service cannot write to the hardware clock or system clock", "Service may write to the hardware clock or system clock"),
+	boolean("ProtectHostname", 50, "Service cannot change system host/domainname", "Service may change system host/domainname"),
+	{
+		name: "ProtectProc=", weight: 1000,
+		assess: func(s settings) (int, string) {
+			switch strings.ToLower(s.value("ProtectProc")) {
+			case "invisible", "noaccess", "ptraceable":
+				return 0, "Service has restricted access to process tree (/proc hidepid=)"
+			}
+			return 1, "Service has full access to process tree (/proc hidepid=)"
+		},
+	},
+	boolean("RestrictSUIDSGID", 1000, "SUID/SGID file creation by service is restricted", "Service may create SUID/SGID files", "DynamicUser"),
+	{
+		name: "RestrictNamespaces=", weight: 500,
+		assess: func(s settings) (int, string) {
+			v := strings.ToLower(s.value("RestrictNamespaces"))
+			if v == "" || v == "no" || v == "false" || strings.HasPrefix(v, "~") {
+				return 1, "Service may create namespaces"
+			}
+			return 0, "Service cannot create namespaces beyond those it lists"
+		},
+	},
+	boolean("RestrictRealtime", 500, "Service realtime scheduling access is restricted", "Service may acquire realtime scheduling"),
+	boolean("LockPersonality", 100, "Service cannot change ABI personality", "Service may change ABI personality"),
+	boolean("MemoryDenyWriteExecute", 100, "Service cannot create writable executable memory mappings", "Service may create writable executable memory mappings"),
+	boolean("RemoveIPC", 100, "Service user cannot leave SysV IPC objects around", "Service user may leave SysV IPC objects around", "DynamicUser"),
+	{
+		name: "SystemCallArchitectures=", weight: 1000,
+		assess: func(s settings) (int, string) {
+			if s.value("SystemCallArchitectures") == "native" {
+				return 0, "Service may execute system calls only with native ABI"
+			}
+			return 1, "Service may execute system calls with all ABIs"
+		},
+	},
+	{
+		name: "SystemCallFilter=", weight: 1000, rng: 10,
+		assess: func(s settings) (int, string) {
+			v := s.value("SystemCallFilter")
+			switch {
+			case v == "":
+				return 10, "Service does not filter system calls"
+			case strings.HasPrefix(v, "~"):
+				return 5, "Service denies some system calls but allows the rest"
+			}
+			return 0, "System call allow list defined for service"
+		},
+	},
+	{
+		name: "CapabilityBoundingSet=", weight: 1500, rng: 10,
+		assess: func(s settings) (int, string) {
+			if !s.set("CapabilityBoundingSet") {
+				return 10, "Service may hold any capability"
+			}
+			v := strings.ToUpper(s.value("CapabilityBoundingSet"))
+			if v == "" {
+				return 0, "Service holds no capabilities"
+			}
+			if strings.HasPrefix(v, "~") {
+				if hasCapability(v, "CAP_SYS_ADMIN") {
+					return 5, "Service drops some capabilities, including CAP_SYS_ADMIN"
+				}
+				return 8, "Service drops some capabilities but keeps CAP_SYS_ADMIN"
+			}
+			for _, c := range dangerousCapabilities {
+				if hasCapability(v, c) {
+					return 6, "Service keeps " + c + " among its capabilities"
+				}
+			}
+			return 2, "Service is limited to a list of capabilities"
+		},
+	},
+	{
+		name: "AmbientCapabilities=", weight: 500,
+		assess: func(s settings) (int, string) {
+			if s.value("AmbientCapabilities") == "" {
+				return 0, "Service process does not receive ambient capabilities"
+			}
+			return 1, "Service process receives ambient capabilities"
+		},
+	},
+	{
+		name: "RestrictAddressFamilies=", weight: 1500,
+		assess: func(s settings) (int, string) {
+			v := s.value("RestrictAddressFamilies")
+			if v == "" || strings.HasPrefix(v, "~") {
+				return 1, "Service may allocate sockets of any address family"
+			}
+			return 0, "Service is limited to the address families it lists"
+		},
+	},
+	{
+		name: "IPAddressDeny=", weight: 1000,
+		assess: func(s settings) (int, string) {
+			v := strings.ToLower(s.value("IPAddressDeny"))
+			if v == "any" || s.enabled("PrivateNetwork") {
+				return 0, "Service defines an IP address allow list"
+			}
+			return 1, "Service does not define an IP address allow list"
+		},
+	},
+	{
+		name: "DevicePolicy=", weight: 1000,
+		assess: func(s settings) (int, string) {
+			switch strings.ToLower(s.value("DevicePolicy")) {
+			case "closed", "strict":
+				return 0, "Service has a device access policy"
+			}
+			if s.enabled("PrivateDevices") {
+				return 0, "Service has a device access policy"
+			}
+			return 1, "Service has no device access policy"
+		},
+	},
+	{
+		name: "KeyringMode=", weight: 1000,
+		assess: func(s settings) (int, string) {
+			if strings.ToLower(s.value("KeyringMode")) == "shared" {
+				return 1, "Service shares key chain with other services"
+			}
+			return 0, "Service doesn't share key material with other services"
+		},
+	},
+	{
+		name: "NotifyAccess=", weight: 1000,
+		assess: func(s settings) (int, string) {
+			if strings.ToLower(s.value("NotifyAccess")) == "all" {
+				return 1, "Service child processes may alter service state"
+			}
+			return 0, "Service child processes cannot alter service state"
+		},
+	},
+	{
+		name: "UMask=", weight: 100,
+		assess: func(s settings) (int, string) {
+			v := s.value("UMask")
+			if v == "" {
+				v = "0022"
+			}
+			mask, err := strconv.ParseUint(v, 8, 32)
+			if err == nil && mask&0o007 == 0o007 {
+				return 0, "Files created by service are accessible only by service's own user by default"
+			}
+			return 1, "Files created by service are world accessible by default"
+		},
+	},
+	{
+		name: "Delegate=", weight: 100,
+		assess: func(s settings) (int, string) {
+			if v := s.value("Delegate"); v != "" && v != "no" && v != "false" {
+				return 1, "Service maintains its own delegated control group subtree"
+			}
+			return 0, "Service does not maintain its own delegated control group subtree"
+		},
+	},
+}
+
+// hasCapability reports whether a capability list names c
+func hasCapability(list, c string) bool {
+	for _, field := range strings.Fields(strings.TrimPrefix(list, "~")) {
+		if field == c {
+			return true
+		}
+	}
+	return false
+}
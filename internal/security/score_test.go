@@ -0,0 +1,169 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func makeTestUnit(directives map[string]string) *types.UnitFile {
+	unit := &types.UnitFile{
+		Name: "test.service",
+		Path: "/etc/systemd/system/test.service",
+		Type: "service",
+		Sections: map[string]*types.Section{
+			"Service": {
+				Name:       "Service",
+				Directives: make(map[string][]types.Directive),
+			},
+		},
+	}
+
+	for k, v := range directives {
+		unit.Sections["Service"].Directives[k] = []types.Directive{{Key: k, Value: v}}
+	}
+
+	return unit
+}
+
+var hardened = map[string]string{
+	"DynamicUser":             "yes",
+	"NoNewPrivileges":         "yes",
+	"PrivateDevices":          "yes",
+	"PrivateNetwork":          "yes",
+	"PrivateUsers":            "yes",
+	"PrivateMounts":           "yes",
+	"ProtectHome":             "yes",
+	"ProtectKernelTunables":   "yes",
+	"ProtectKernelModules":    "yes",
+	"ProtectKernelLogs":       "yes",
+	"ProtectControlGroups":    "yes",
+	"ProtectClock":            "yes",
+	"ProtectHostname":         "yes",
+	"ProtectProc":             "invisible",
+	"RestrictNamespaces":      "yes",
+	"RestrictRealtime":        "yes",
+	"LockPersonality":         "yes",
+	"MemoryDenyWriteExecute":  "yes",
+	"SystemCallArchitectures": "native",
+	"SystemCallFilter":        "@system-service",
+	"CapabilityBoundingSet":   "",
+	"RestrictAddressFamilies": "AF_UNIX",
+	"UMask":                   "0077",
+}
+
+func TestAssess(t *testing.T) {
+	tests := []struct {
+		name       string
+		directives map[string]string
+		wantLevel  string
+	}{
+		{"no hardening", nil, "UNSAFE"},
+		{"partial hardening", map[string]string{
+			"User":            "app",
+			"NoNewPrivileges": "yes",
+			"PrivateTmp":      "yes",
+			"ProtectSystem":   "strict",
+			"ProtectHome":     "yes",
+			"PrivateDevices":  "yes",
+		}, "MEDIUM"},
+		{"fully hardened", hardened, "SAFE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := Assess(makeTestUnit(tt.directives))
+			if score.Level != tt.wantLevel {
+				t.Errorf("Assess() level = %s (%.1f), want %s", score.Level, score.Exposure, tt.wantLevel)
+			}
+			if score.Unit != "test.service" {
+				t.Errorf("Assess() unit = %q, want test.service", score.Unit)
+			}
+			if len(score.Checks) != len(assessors) {
+				t.Errorf("Assess() returned %d checks, want %d", len(score.Checks), len(assessors))
+			}
+		})
+	}
+}
+
+func TestAssessChecks(t *testing.T) {
+	tests := []struct {
+		name        string
+		directives  map[string]string
+		check       string
+		wantBadness int
+	}{
+		{"root user", nil, "User=/DynamicUser=", 10},
+		{"explicit root", map[string]string{"User": "root"}, "User=/DynamicUser=", 10},
+		{"static user", map[string]string{"User": "app"}, "User=/DynamicUser=", 0},
+		{"dynamic user", map[string]string{"DynamicUser": "yes"}, "User=/DynamicUser=", 0},
+		{"dynamic user implies private tmp", map[string]string{"DynamicUser": "yes"}, "PrivateTmp=", 0},
+		{"dynamic user implies strict system", map[string]string{"DynamicUser": "yes"}, "ProtectSystem=", 0},
+		{"dynamic user implies read-only home", map[string]string{"DynamicUser": "yes"}, "ProtectHome=", 5},
+		{"protect system full", map[string]string{"ProtectSystem": "full"}, "ProtectSystem=", 3},
+		{"protect system yes", map[string]string{"ProtectSystem": "yes"}, "ProtectSystem=", 5},
+		{"protect system unset", nil, "ProtectSystem=", 10},
+		{"empty bounding set", map[string]string{"CapabilityBoundingSet": ""}, "CapabilityBoundingSet=", 0},
+		{"bounding set allow list", map[string]string{"CapabilityBoundingSet": "CAP_NET_BIND_SERVICE"}, "CapabilityBoundingSet=", 2},
+		{"bounding set keeps sys admin", map[string]string{"CapabilityBoundingSet": "CAP_SYS_ADMIN CAP_NET_BIND_SERVICE"}, "CapabilityBoundingSet=", 6},
+		{"bounding set drops sys admin", map[string]string{"CapabilityBoundingSet": "~CAP_SYS_ADMIN"}, "CapabilityBoundingSet=", 5},
+		{"syscall allow list", map[string]string{"SystemCallFilter": "@system-service"}, "SystemCallFilter=", 0},
+		{"syscall deny list", map[string]string{"SystemCallFilter": "~@mount"}, "SystemCallFilter=", 5},
+		{"address family deny list", map[string]string{"RestrictAddressFamilies": "~AF_PACKET"}, "RestrictAddressFamilies=", 1},
+		{"private devices implies device policy", map[string]string{"PrivateDevices": "yes"}, "DevicePolicy=", 0},
+		{"default umask", nil, "UMask=", 1},
+		{"private umask", map[string]string{"UMask": "0077"}, "UMask=", 0},
+		{"shared keyring", map[string]string{"KeyringMode": "shared"}, "KeyringMode=", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := Assess(makeTestUnit(tt.directives))
+			for _, c := range score.Checks {
+				if c.Name != tt.check {
+					continue
+				}
+				if c.Badness != tt.wantBadness {
+					t.Errorf("%s badness = %d, want %d (%s)", c.Name, c.Badness, tt.wantBadness, c.Description)
+				}
+				return
+			}
+			t.Fatalf("check %s not found", tt.check)
+		})
+	}
+}
+
+func TestAssessLastAssignmentWins(t *testing.T) {
+	unit := makeTestUnit(nil)
+	unit.Sections["Service"].Directives["NoNewPrivileges"] = []types.Directive{
+		{Key: "NoNewPrivileges", Value: "yes"},
+		{Key: "NoNewPrivileges", Value: "no"},
+	}
+
+	for _, c := range Assess(unit).Checks {
+		if c.Name == "NoNewPrivileges=" && c.Passed() {
+			t.Error("NoNewPrivileges= passed, but the last assignment disables it")
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		exposure float64
+		want     string
+	}{
+		{9.6, "UNSAFE"},
+		{9.0, "UNSAFE"},
+		{8.2, "EXPOSED"},
+		{5.0, "MEDIUM"},
+		{4.9, "OK"},
+		{1.0, "OK"},
+		{0.4, "SAFE"},
+	}
+
+	for _, tt := range tests {
+		if got := Level(tt.exposure); got != tt.want {
+			t.Errorf("Level(%.1f) = %s, want %s", tt.exposure, got, tt.want)
+		}
+	}
+}
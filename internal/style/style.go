@@ -0,0 +1,209 @@
+// Package style decides how terminal output is decorated. All text and TUI
+// rendering goes through a Provider so that color, Unicode glyphs and layout
+// follow what the user asked for: with ASCII mode, output is plain ASCII with
+// no tree or bar glyphs, and is laid out as headings read line by line, which
+// suits screen readers.
+package style
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// EnvASCII enables ASCII mode when set to a true value such as "1"
+const EnvASCII = "SDAUDIT_ASCII"
+
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorGreen  = "\033[32m"
+	colorCyan   = "\033[36m"
+	colorGray   = "\033[90m"
+)
+
+// asciiReplacer spells out the non-ASCII glyphs sdaudit and systemd tools print
+var asciiReplacer = strings.NewReplacer(
+	"§ ", "section ",
+	"§", "section ",
+	"→", "->",
+	"≥", ">=",
+	"←", "<-",
+	"↑", "up",
+	"↓", "down",
+	"…", "...",
+	"—", "--",
+	"–", "-",
+	"•", "*",
+	"●", "*",
+	"█", "#",
+	"░", "-",
+	"└─", "`-",
+	"├─", "|-",
+	"│", "|",
+	"─", "-",
+	"✓", "yes",
+	"✗", "no",
+)
+
+// Provider renders decorations according to the output's capabilities
+type Provider struct {
+	color bool
+	ascii bool
+}
+
+// New returns a provider. color enables ANSI colors; ascii restricts output
+// to plain ASCII with a heading-structured layout.
+func New(color, ascii bool) Provider {
+	return Provider{color: color, ascii: ascii}
+}
+
+// ASCIIRequested reports whether ASCII mode is on, either by flag or through
+// the SDAUDIT_ASCII environment variable
+func ASCIIRequested(flag bool) bool {
+	if flag {
+		return true
+	}
+	on, err := strconv.ParseBool(os.Getenv(EnvASCII))
+	return err == nil && on
+}
+
+// EnvNoColor turns colors off when set to any non-empty value, following
+// https://no-color.org
+const EnvNoColor = "NO_COLOR"
+
+// NoColorRequested reports whether colors are turned off, either by flag or
+// through the NO_COLOR environment variable
+func NoColorRequested(flag bool) bool {
+	return flag || os.Getenv(EnvNoColor) != ""
+}
+
+// ColorMode is when output is colored
+type ColorMode int
+
+const (
+	// ColorAuto colors output to a terminal unless NO_COLOR is set
+	ColorAuto ColorMode = iota
+	// ColorAlways colors output even to files and pipes
+	ColorAlways
+	// ColorNever never colors output
+	ColorNever
+)
+
+var colorModeNames = []string{"auto", "always", "never"}
+
+func (m ColorMode) String() string {
+	if m < 0 || int(m) >= len(colorModeNames) {
+		return "unknown"
+	}
+	return colorModeNames[m]
+}
+
+// ParseColorMode returns the color mode named s: auto, always or never
+func ParseColorMode(s string) (ColorMode, error) {
+	for i, name := range colorModeNames {
+		if s == name {
+			return ColorMode(i), nil
+		}
+	}
+	return ColorAuto, fmt.Errorf("unknown color mode %q, use auto, always or never", s)
+}
+
+// UseColor reports whether output written to f is colored in mode
+func UseColor(mode ColorMode, f *os.File) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	default:
+		return !NoColorRequested(false) && IsTerminal(f)
+	}
+}
+
+// IsTerminal reports whether f is a terminal rather than a file or pipe
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Color reports whether ANSI colors are used
+func (p Provider) Color() bool { return p.color }
+
+// ASCII reports whether output is restricted to plain ASCII
+func (p Provider) ASCII() bool { return p.ascii }
+
+// Text returns s, with Unicode glyphs spelled out in ASCII mode
+func (p Provider) Text(s string) string {
+	if !p.ascii {
+		return s
+	}
+	return asciiReplacer.Replace(s)
+}
+
+// Heading renders a section heading. In ASCII mode it is prefixed with one
+// '#' per level so that headings can be found when reading line by line.
+func (p Provider) Heading(level int, text string) string {
+	if p.ascii {
+		return strings.Repeat("#", level) + " " + text
+	}
+	return p.Bold(text)
+}
+
+// Bar renders a horizontal bar of width cells, the first filled of them full
+func (p Provider) Bar(width, filled int) string {
+	if width <= 0 {
+		return ""
+	}
+	full, empty := "█", "░"
+	if p.ascii {
+		full, empty = "#", "-"
+	}
+	filled = min(max(filled, 0), width)
+	return strings.Repeat(full, filled) + strings.Repeat(empty, width-filled)
+}
+
+// Bold renders s in bold
+func (p Provider) Bold(s string) string {
+	return p.paint(colorBold, s)
+}
+
+// Green renders s in green
+func (p Provider) Green(s string) string {
+	return p.paint(colorGreen, s)
+}
+
+// Red renders s in red
+func (p Provider) Red(s string) string {
+	return p.paint(colorRed, s)
+}
+
+// Severity renders a severity by name, colored when colors are on. The name
+// is always present so the severity never depends on color alone.
+func (p Provider) Severity(sev types.Severity) string {
+	name := strings.ToUpper(sev.String())
+	switch sev {
+	case types.SeverityCritical:
+		return p.paint(colorBold+colorRed, name)
+	case types.SeverityHigh:
+		return p.paint(colorRed, name)
+	case types.SeverityMedium:
+		return p.paint(colorYellow, name)
+	case types.SeverityLow:
+		return p.paint(colorCyan, name)
+	default:
+		return p.paint(colorGray, name)
+	}
+}
+
+func (p Provider) paint(code, s string) string {
+	if !p.color {
+		return s
+	}
+	return code + s + colorReset
+}
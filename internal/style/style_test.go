@@ -0,0 +1,150 @@
+package style
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > 127 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestProviderModes(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    Provider
+		wantHeading string
+		wantBar     string
+		wantText    string
+		wantColor   bool
+	}{
+		{"default", New(true, false), "\033[1mIssues\033[0m", "██░░", "systemd.exec(5) § Sandboxing", true},
+		{"no color", New(false, false), "Issues", "██░░", "systemd.exec(5) § Sandboxing", false},
+		{"ascii", New(false, true), "## Issues", "##--", "systemd.exec(5) section Sandboxing", false},
+		{"ascii with color", New(true, true), "## Issues", "##--", "systemd.exec(5) section Sandboxing", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.provider
+			if got := p.Heading(2, "Issues"); got != tt.wantHeading {
+				t.Errorf("Heading() = %q, want %q", got, tt.wantHeading)
+			}
+			if got := p.Bar(4, 2); got != tt.wantBar {
+				t.Errorf("Bar() = %q, want %q", got, tt.wantBar)
+			}
+			if got := p.Text("systemd.exec(5) § Sandboxing"); got != tt.wantText {
+				t.Errorf("Text() = %q, want %q", got, tt.wantText)
+			}
+			if p.ASCII() {
+				if got := p.Text("systemd.exec(5) §Sandboxing"); got != tt.wantText {
+					t.Errorf("Text() = %q, want %q", got, tt.wantText)
+				}
+			}
+			if p.ASCII() && !isASCII(p.Bar(10, 3)+p.Text("└─ a → b … ✓")) {
+				t.Error("ASCII mode rendered non-ASCII glyphs")
+			}
+
+			// Severity is always spelled out, with or without color
+			for _, sev := range []types.Severity{types.SeverityCritical, types.SeverityHigh, types.SeverityMedium, types.SeverityLow, types.SeverityInfo} {
+				got := p.Severity(sev)
+				if !strings.Contains(got, strings.ToUpper(sev.String())) {
+					t.Errorf("Severity(%v) = %q, missing its name", sev, got)
+				}
+				if strings.Contains(got, "\033[") != tt.wantColor {
+					t.Errorf("Severity(%v) = %q, color = %v", sev, got, tt.wantColor)
+				}
+			}
+		})
+	}
+}
+
+func TestBarClamps(t *testing.T) {
+	p := New(false, true)
+	if got := p.Bar(3, 5); got != "###" {
+		t.Errorf("Bar(3, 5) = %q", got)
+	}
+	if got := p.Bar(3, -1); got != "---" {
+		t.Errorf("Bar(3, -1) = %q", got)
+	}
+	if got := p.Bar(0, 1); got != "" {
+		t.Errorf("Bar(0, 1) = %q", got)
+	}
+}
+
+func TestASCIIRequested(t *testing.T) {
+	tests := []struct {
+		env  string
+		flag bool
+		want bool
+	}{
+		{"", false, false},
+		{"", true, true},
+		{"1", false, true},
+		{"true", false, true},
+		{"0", false, false},
+		{"yes please", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(EnvASCII, tt.env)
+		if got := ASCIIRequested(tt.flag); got != tt.want {
+			t.Errorf("ASCIIRequested(%v) with %s=%q = %v, want %v", tt.flag, EnvASCII, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestNoColorRequested(t *testing.T) {
+	t.Setenv(EnvNoColor, "")
+	if NoColorRequested(false) {
+		t.Error("colors should be on without the flag or NO_COLOR")
+	}
+	if !NoColorRequested(true) {
+		t.Error("the flag should turn colors off")
+	}
+	t.Setenv(EnvNoColor, "1")
+	if !NoColorRequested(false) {
+		t.Error("NO_COLOR should turn colors off")
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	t.Setenv(EnvNoColor, "")
+	if UseColor(ColorAuto, w) {
+		t.Error("auto should not color output to a pipe")
+	}
+	if !UseColor(ColorAlways, w) {
+		t.Error("always should color output to a pipe")
+	}
+	t.Setenv(EnvNoColor, "1")
+	if !UseColor(ColorAlways, w) {
+		t.Error("always should override NO_COLOR")
+	}
+	if UseColor(ColorNever, w) {
+		t.Error("never should not color")
+	}
+
+	for _, name := range []string{"auto", "always", "never"} {
+		if mode, err := ParseColorMode(name); err != nil || mode.String() != name {
+			t.Errorf("ParseColorMode(%q) = %v, %v", name, mode, err)
+		}
+	}
+	if _, err := ParseColorMode("yes"); err == nil {
+		t.Error("ParseColorMode should reject unknown modes")
+	}
+}
@@ -0,0 +1,699 @@
+package timing
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	// Time zones in calendar expressions can be checked on hosts without a
+	// time zone database
+	_ "time/tzdata"
+)
+
+// calendarShorthands maps OnCalendar= shorthands to their shortest period.
+// Months are taken at their shortest so the interval is never overestimated.
+var calendarShorthands = map[string]time.Duration{
+	"minutely":     time.Minute,
+	"hourly":       time.Hour,
+	"daily":        24 * time.Hour,
+	"weekly":       7 * 24 * time.Hour,
+	"monthly":      28 * 24 * time.Hour,
+	"quarterly":    89 * 24 * time.Hour,
+	"semiannually": 181 * 24 * time.Hour,
+	"yearly":       365 * 24 * time.Hour,
+	"annually":     365 * 24 * time.Hour,
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// CalendarInterval returns the shortest time between two consecutive triggers
+// of an OnCalendar= expression. It understands the shorthands and expressions
+// whose time of day uses "*", lists, ranges and repetitions (e.g. "*:0/15" or
+// "Mon..Fri *-*-* 08,12:00"). ok is false when the interval cannot be
+// determined, such as a single trigger per day restricted to certain dates.
+func CalendarInterval(expr string) (interval time.Duration, ok bool) {
+	expr = strings.TrimSpace(expr)
+	if d, found := calendarShorthands[strings.ToLower(expr)]; found {
+		return d, true
+	}
+
+	everyDate, weekdays, recognized := true, false, false
+	timePart := "00:00:00"
+	for _, field := range strings.Fields(expr) {
+		switch {
+		case strings.Contains(field, ":"):
+			timePart, recognized = field, true
+		case isWeekdayField(field):
+			weekdays, recognized = true, true
+		case strings.Contains(field, "-"):
+			everyDate, recognized = field == "*-*-*" || field == "*-*", true
+		case !recognized:
+			return 0, false
+		default:
+			// Time zone suffix such as UTC or Europe/Berlin
+		}
+	}
+	dailyDate := everyDate && !weekdays
+
+	triggers, ok := dayTriggers(timePart)
+	if !ok || len(triggers) == 0 {
+		return 0, false
+	}
+
+	if len(triggers) == 1 {
+		if dailyDate {
+			return 24 * time.Hour, true
+		}
+		return 0, false
+	}
+
+	minGap := secondsPerDay
+	for i := 1; i < len(triggers); i++ {
+		minGap = min(minGap, triggers[i]-triggers[i-1])
+	}
+	if dailyDate {
+		minGap = min(minGap, triggers[0]+secondsPerDay-triggers[len(triggers)-1])
+	}
+
+	return time.Duration(minGap) * time.Second, true
+}
+
+// dayTriggers returns the trigger times of a HH:MM[:SS] spec as seconds since midnight, sorted
+func dayTriggers(spec string) ([]int, bool) {
+	parts := strings.Split(spec, ":")
+	if len(parts) == 2 {
+		parts = append(parts, "00")
+	}
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	hours, ok := expandCalendarField(parts[0], 24)
+	if !ok {
+		return nil, false
+	}
+	minutes, ok := expandCalendarField(parts[1], 60)
+	if !ok {
+		return nil, false
+	}
+	// Fractional seconds do not change the interval
+	seconds, err := parseComponent("second", parts[2], 0, 59, microsecondsPerSecond)
+	if err != nil {
+		return nil, false
+	}
+
+	var triggers []int
+	for _, h := range hours {
+		for _, m := range minutes {
+			for _, s := range seconds {
+				triggers = append(triggers, h*3600+m*60+s)
+			}
+		}
+	}
+	sort.Ints(triggers)
+	return triggers, true
+}
+
+// expandCalendarField expands a calendar component such as "*", "5", "1,5",
+// "8..17" or "0/15" into its values below limit
+func expandCalendarField(field string, limit int) ([]int, bool) {
+	values, err := parseComponent("value", field, 0, limit-1, 1)
+	return values, err == nil
+}
+
+// CalendarError reports the component of an OnCalendar= expression that
+// systemd would reject, such as the hour in "Mon..Fri 25:00".
+type CalendarError struct {
+	Component string // "weekday", "year", "month", "day", "hour", "minute", "second", "time zone" or "expression"
+	Value     string // Text of the component
+	Reason    string
+}
+
+func (e *CalendarError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Component, e.Value, e.Reason)
+}
+
+// Bounds of the years systemd accepts in calendar expressions
+const (
+	minCalendarYear = 1970
+	maxCalendarYear = 2199
+)
+
+// microsecondsPerSecond is the scale of seconds, which may have a fraction
+const microsecondsPerSecond = 1000000
+
+// parseComponent expands a calendar component such as "*", "5", "1,5",
+// "8..17", "0/15" or "8..17/2" into its sorted values between lo and hi.
+// Values are read in units of 1/scale, so seconds can use a scale of 1e6 to
+// accept "0/1.5", and returned truncated to whole units. As systemd does, a
+// repetition must fire at least twice and "*" cannot be repeated.
+func parseComponent(name, field string, lo, hi, scale int) ([]int, error) {
+	fail := func(reason string, args ...any) error {
+		return &CalendarError{Component: name, Value: field, Reason: fmt.Sprintf(reason, args...)}
+	}
+	if field == "" {
+		return nil, fail("empty")
+	}
+
+	seen := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		if item == "" {
+			return nil, fail("empty list item")
+		}
+		spec, stepStr, hasStep := strings.Cut(item, "/")
+		step := scale
+		if hasStep {
+			n, ok := parseCalendarNumber(stepStr, scale)
+			if !ok {
+				return nil, fail("repetition %q is not a number", stepStr)
+			}
+			if n <= 0 {
+				return nil, fail("repetition must be positive")
+			}
+			step = n
+		}
+
+		start, end := lo*scale, hi*scale
+		switch {
+		case spec == "*":
+			if hasStep {
+				return nil, fail("\"*\" cannot be repeated, start from a value such as 0/%s", stepStr)
+			}
+		case strings.Contains(spec, ".."):
+			from, to, _ := strings.Cut(spec, "..")
+			var ok1, ok2 bool
+			start, ok1 = parseCalendarNumber(from, scale)
+			end, ok2 = parseCalendarNumber(to, scale)
+			if !ok1 || !ok2 {
+				return nil, fail("range %q is not numeric", spec)
+			}
+			if start > end {
+				return nil, fail("range %s is descending", spec)
+			}
+		default:
+			n, ok := parseCalendarNumber(spec, scale)
+			if !ok {
+				return nil, fail("%q is not a number", spec)
+			}
+			start = n
+			if !hasStep {
+				end = n
+			}
+		}
+
+		if start < lo*scale || end >= (hi+1)*scale {
+			return nil, fail("%s is out of range %d-%d", spec, lo, hi)
+		}
+		if hasStep && start+step > hi*scale {
+			return nil, fail("repetition /%s from %s never fires twice below %d", stepStr, spec, hi+1)
+		}
+		for v := start; v <= end; v += step {
+			seen[v/scale] = true
+		}
+	}
+
+	values := make([]int, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+// parseCalendarNumber parses a non-negative number in units of 1/scale. Only a
+// scale above 1 accepts a fraction, such as "1.5".
+func parseCalendarNumber(s string, scale int) (int, bool) {
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" || strings.Trim(whole, "0123456789") != "" || (hasFrac && (scale == 1 || frac == "")) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(whole)
+	if err != nil {
+		return 0, false
+	}
+	n *= scale
+	for unit := scale / 10; hasFrac && frac != "" && unit > 0; unit /= 10 {
+		if frac[0] < '0' || frac[0] > '9' {
+			return 0, false
+		}
+		n += int(frac[0]-'0') * unit
+		frac = frac[1:]
+	}
+	if strings.Trim(frac, "0123456789") != "" {
+		return 0, false
+	}
+	return n, true
+}
+
+// weekdayNames maps the short and long weekday names to their day
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+	"monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday, "thursday": time.Thursday,
+	"friday": time.Friday, "saturday": time.Saturday, "sunday": time.Sunday,
+}
+
+// isWeekdayField reports whether a field is a weekday spec like "Mon",
+// "Mon,Fri", "Mon..Fri" or "Monday-Friday"
+func isWeekdayField(field string) bool {
+	for _, item := range strings.Split(strings.ToLower(field), ",") {
+		for _, day := range splitWeekdayRange(item) {
+			if day == "" {
+				continue
+			}
+			if _, ok := weekdayNames[day]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// splitWeekdayRange splits a weekday range written with ".." or "-"
+func splitWeekdayRange(item string) []string {
+	if strings.Contains(item, "..") {
+		return strings.Split(item, "..")
+	}
+	return strings.Split(item, "-")
+}
+
+// calendarNormalForms expands the OnCalendar= shorthands as systemd does
+var calendarNormalForms = map[string]string{
+	"minutely":     "*-*-* *:*:00",
+	"hourly":       "*-*-* *:00:00",
+	"daily":        "*-*-* 00:00:00",
+	"weekly":       "Mon *-*-* 00:00:00",
+	"monthly":      "*-*-01 00:00:00",
+	"quarterly":    "*-01,04,07,10-01 00:00:00",
+	"semiannually": "*-01,07-01 00:00:00",
+	"yearly":       "*-01-01 00:00:00",
+	"annually":     "*-01-01 00:00:00",
+}
+
+// Calendar is a normalized OnCalendar= expression. Each component holds the
+// sorted values it matches; Weekdays and Years are nil when any matches.
+type Calendar struct {
+	Weekdays []time.Weekday
+	Years    []int
+	Months   []int
+	Days     []int
+	// FromEnd counts Days back from the end of the month, 1 being the last
+	// day, as written with "~" in "*-02~03"
+	FromEnd bool
+	Hours   []int
+	Minutes []int
+	Seconds []int
+	// Zone is the time zone the expression names, empty for local time
+	Zone string
+}
+
+// ParseCalendar parses an OnCalendar= expression of the form
+// "[weekdays] [[year-]month-day] [hour:minute[:second]] [zone]", one of its
+// shorthands such as "daily" or a point in time such as "@1700000000". It
+// accepts what systemd accepts, including lists, ranges, repetitions and days
+// counted from the end of the month, and returns a *CalendarError naming the
+// component that failed otherwise. Fractional seconds are dropped.
+func ParseCalendar(expr string) (Calendar, error) {
+	expr = strings.TrimSpace(expr)
+	if full, ok := calendarNormalForms[strings.ToLower(expr)]; ok {
+		expr = full
+	}
+	if strings.HasPrefix(expr, "@") {
+		return parseEpoch(expr)
+	}
+	fields := strings.Fields(expr)
+	if len(fields) == 0 {
+		return Calendar{}, &CalendarError{Component: "expression", Value: expr, Reason: "empty"}
+	}
+
+	var c Calendar
+	datePart, timePart := "", ""
+	for i, field := range fields {
+		switch {
+		case i == 0 && isWeekdayField(field):
+			weekdays, err := parseWeekdays(field)
+			if err != nil {
+				return Calendar{}, err
+			}
+			c.Weekdays = weekdays
+		case timePart == "" && strings.Contains(field, ":"):
+			timePart = field
+		case datePart == "" && timePart == "" && strings.ContainsAny(field, "-~"):
+			datePart = field
+		case i == len(fields)-1 && i > 0 && strings.IndexFunc(field, unicode.IsLetter) >= 0:
+			if _, err := time.LoadLocation(field); err != nil {
+				return Calendar{}, &CalendarError{Component: "time zone", Value: field, Reason: "unknown time zone"}
+			}
+			c.Zone = field
+		default:
+			return Calendar{}, &CalendarError{Component: "expression", Value: field, Reason: "not a weekday, date, time or time zone in its place"}
+		}
+	}
+	if datePart == "" {
+		datePart = "*-*-*"
+	}
+	if timePart == "" {
+		timePart = "00:00:00"
+	}
+
+	if err := c.parseDate(datePart); err != nil {
+		return Calendar{}, err
+	}
+	if err := c.parseTime(timePart); err != nil {
+		return Calendar{}, err
+	}
+	return c, nil
+}
+
+// parseEpoch parses a point in time given as seconds since the epoch
+func parseEpoch(expr string) (Calendar, error) {
+	secs, err := strconv.ParseInt(strings.TrimPrefix(expr, "@"), 10, 64)
+	if err != nil || secs < 0 {
+		return Calendar{}, &CalendarError{Component: "expression", Value: expr, Reason: "not a number of seconds since the epoch"}
+	}
+	t := time.Unix(secs, 0).UTC()
+	if t.Year() > maxCalendarYear {
+		return Calendar{}, &CalendarError{Component: "expression", Value: expr, Reason: fmt.Sprintf("after the year %d", maxCalendarYear)}
+	}
+	return Calendar{
+		Years:   []int{t.Year()},
+		Months:  []int{int(t.Month())},
+		Days:    []int{t.Day()},
+		Hours:   []int{t.Hour()},
+		Minutes: []int{t.Minute()},
+		Seconds: []int{t.Second()},
+		Zone:    "UTC",
+	}, nil
+}
+
+func parseWeekdays(field string) ([]time.Weekday, error) {
+	seen := make(map[time.Weekday]bool)
+	for _, item := range strings.Split(strings.ToLower(field), ",") {
+		days := splitWeekdayRange(item)
+		if len(days) > 2 {
+			return nil, &CalendarError{Component: "weekday", Value: item, Reason: "range has more than two ends"}
+		}
+		from, to := days[0], days[len(days)-1]
+		start, ok1 := weekdayNames[from]
+		end, ok2 := weekdayNames[to]
+		if !ok1 || !ok2 {
+			return nil, &CalendarError{Component: "weekday", Value: item, Reason: "unknown day"}
+		}
+		// Weeks start on Monday in calendar expressions and ranges do not wrap
+		first, last := (start+6)%7, (end+6)%7
+		if first > last {
+			return nil, &CalendarError{Component: "weekday", Value: item, Reason: "range wraps past Sunday, split it at the end of the week"}
+		}
+		for d := first; d <= last; d++ {
+			seen[(d+1)%7] = true
+		}
+	}
+	var weekdays []time.Weekday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if seen[d] {
+			weekdays = append(weekdays, d)
+		}
+	}
+	if len(weekdays) == 7 {
+		return nil, nil
+	}
+	return weekdays, nil
+}
+
+func (c *Calendar) parseDate(spec string) error {
+	monthPart, dayPart, fromEnd := strings.Cut(spec, "~")
+	var parts []string
+	if fromEnd {
+		parts = append(strings.Split(monthPart, "-"), dayPart)
+	} else {
+		parts = strings.Split(spec, "-")
+	}
+	switch len(parts) {
+	case 2:
+		parts = append([]string{"*"}, parts...)
+	case 3:
+	default:
+		return &CalendarError{Component: "date", Value: spec, Reason: "not of the form [year-]month-day or [year-]month~day"}
+	}
+	c.FromEnd = fromEnd
+
+	var err error
+	if parts[0] != "*" {
+		if c.Years, err = parseComponent("year", fullYears(parts[0]), minCalendarYear, maxCalendarYear, 1); err != nil {
+			return err
+		}
+	}
+	if c.Months, err = parseComponent("month", parts[1], 1, 12, 1); err != nil {
+		return err
+	}
+	if c.Days, err = parseComponent("day", parts[2], 1, 31, 1); err != nil {
+		return err
+	}
+	if fromEnd {
+		// Repetitions run towards the end of the month, so "~07/6" is the
+		// seventh-last and the last day
+		c.Days, err = parseDaysFromEnd(parts[2])
+	}
+	return err
+}
+
+// fullYears expands the two-digit years of a year component as systemd does,
+// 70 to 99 to the 1900s and 00 to 69 to the 2000s. Repetitions are kept.
+func fullYears(field string) string {
+	expand := func(year string) string {
+		n, err := strconv.Atoi(year)
+		if err != nil || len(year) > 2 {
+			return year
+		}
+		if n >= 70 {
+			return strconv.Itoa(1900 + n)
+		}
+		return strconv.Itoa(2000 + n)
+	}
+
+	var items []string
+	for _, item := range strings.Split(field, ",") {
+		spec, step, hasStep := strings.Cut(item, "/")
+		from, to, isRange := strings.Cut(spec, "..")
+		spec = expand(from)
+		if isRange {
+			spec += ".." + expand(to)
+		}
+		if hasStep {
+			spec += "/" + step
+		}
+		items = append(items, spec)
+	}
+	return strings.Join(items, ",")
+}
+
+// parseDaysFromEnd parses the days of a "~" date, in which a repetition counts
+// down towards the last day of the month
+func parseDaysFromEnd(field string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		spec, stepStr, hasStep := strings.Cut(item, "/")
+		if !hasStep || strings.Contains(spec, "..") || spec == "*" {
+			values, err := parseComponent("day", item, 1, 31, 1)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range values {
+				seen[v] = true
+			}
+			continue
+		}
+		start, err1 := strconv.Atoi(spec)
+		step, err2 := strconv.Atoi(stepStr)
+		if err1 != nil || err2 != nil || step <= 0 || start-step < 1 {
+			return nil, &CalendarError{Component: "day", Value: field, Reason: fmt.Sprintf("repetition %s never fires twice before the end of the month", item)}
+		}
+		for v := start; v >= 1; v -= step {
+			seen[v] = true
+		}
+	}
+
+	values := make([]int, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+func (c *Calendar) parseTime(spec string) error {
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 2:
+		parts = append(parts, "00")
+	case 3:
+	default:
+		return &CalendarError{Component: "time", Value: spec, Reason: "not of the form hour:minute[:second]"}
+	}
+
+	var err error
+	if c.Hours, err = parseComponent("hour", parts[0], 0, 23, 1); err != nil {
+		return err
+	}
+	if c.Minutes, err = parseComponent("minute", parts[1], 0, 59, 1); err != nil {
+		return err
+	}
+	c.Seconds, err = parseComponent("second", parts[2], 0, 59, microsecondsPerSecond)
+	return err
+}
+
+// String returns the normal form of the expression, in which equivalent
+// expressions are equal: "*-*-* 00:00:00" for "daily" and "00:00"
+func (c Calendar) String() string {
+	var b strings.Builder
+	if c.Weekdays != nil {
+		var days []int
+		for _, d := range c.Weekdays {
+			// Number Monday first so ranges do not wrap around Sunday
+			days = append(days, (int(d)+6)%7)
+		}
+		sort.Ints(days)
+		b.WriteString(formatValues(days, 0, 6, func(v int) string {
+			return time.Weekday((v + 1) % 7).String()[:3]
+		}))
+		b.WriteString(" ")
+	}
+	year := "*"
+	if c.Years != nil {
+		year = formatValues(c.Years, minCalendarYear, maxCalendarYear, strconv.Itoa)
+	}
+	daySep := "-"
+	if c.FromEnd {
+		daySep = "~"
+	}
+	twoDigits := func(v int) string { return fmt.Sprintf("%02d", v) }
+	fmt.Fprintf(&b, "%s-%s%s%s %s:%s:%s", year,
+		formatValues(c.Months, 1, 12, twoDigits), daySep, formatValues(c.Days, 1, 31, twoDigits),
+		formatValues(c.Hours, 0, 23, twoDigits), formatValues(c.Minutes, 0, 59, twoDigits),
+		formatValues(c.Seconds, 0, 59, twoDigits))
+	if c.Zone != "" {
+		b.WriteString(" " + c.Zone)
+	}
+	return b.String()
+}
+
+// formatValues writes sorted values as "*" when they cover lo to hi, and
+// otherwise as a list that joins runs of three or more into ranges
+func formatValues(values []int, lo, hi int, format func(int) string) string {
+	if len(values) == hi-lo+1 {
+		return "*"
+	}
+	var items []string
+	for i := 0; i < len(values); {
+		j := i
+		for j+1 < len(values) && values[j+1] == values[j]+1 {
+			j++
+		}
+		switch {
+		case j-i >= 2:
+			items = append(items, format(values[i])+".."+format(values[j]))
+		case j > i:
+			items = append(items, format(values[i]), format(values[j]))
+		default:
+			items = append(items, format(values[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(items, ",")
+}
+
+// matchesDate reports whether the expression fires on the date of t
+func (c Calendar) matchesDate(t time.Time) bool {
+	if c.Years != nil && !containsInt(c.Years, t.Year()) {
+		return false
+	}
+	day := t.Day()
+	if c.FromEnd {
+		lastDay := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		day = lastDay - day + 1
+	}
+	if !containsInt(c.Months, int(t.Month())) || !containsInt(c.Days, day) {
+		return false
+	}
+	if c.Weekdays == nil {
+		return true
+	}
+	for _, d := range c.Weekdays {
+		if d == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// location returns the location the expression is read in: its zone, or loc
+// for local time
+func (c Calendar) location(loc *time.Location) *time.Location {
+	if c.Zone == "" {
+		return loc
+	}
+	if zone, err := time.LoadLocation(c.Zone); err == nil {
+		return zone
+	}
+	return loc
+}
+
+// Next returns the first time after t at which the expression fires, to the
+// second and in the location of t. Expressions without a zone are read in the
+// location of t. ok is false when the expression never fires again, such as
+// a date in the past or February 30.
+func (c Calendar) Next(t time.Time) (next time.Time, ok bool) {
+	loc := c.location(t.Location())
+	local := t.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	for day.Year() <= maxCalendarYear {
+		if c.Years != nil && !containsInt(c.Years, day.Year()) {
+			day = time.Date(day.Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.matchesDate(day) {
+			for _, h := range c.Hours {
+				for _, m := range c.Minutes {
+					for _, s := range c.Seconds {
+						fire := time.Date(day.Year(), day.Month(), day.Day(), h, m, s, 0, loc)
+						if fire.After(t) {
+							return fire.In(t.Location()), true
+						}
+					}
+				}
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+	}
+	return time.Time{}, false
+}
+
+// FireMinutes returns the start of each minute between from and to in which
+// the expression fires, in order. Times are read as wall-clock times in the
+// location of from, whatever the expression's zone.
+func (c Calendar) FireMinutes(from, to time.Time) []time.Time {
+	var minutes []time.Time
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !c.matchesDate(day) {
+			continue
+		}
+		for _, h := range c.Hours {
+			for _, m := range c.Minutes {
+				t := day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+				if !t.Before(from) && t.Before(to) {
+					minutes = append(minutes, t)
+				}
+			}
+		}
+	}
+	return minutes
+}
+
+func containsInt(values []int, v int) bool {
+	i := sort.SearchInts(values, v)
+	return i < len(values) && values[i] == v
+}
@@ -0,0 +1,281 @@
+package timing
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarInterval(t *testing.T) {
+	tests := []struct {
+		expr   string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"hourly", time.Hour, true},
+		{"Daily", 24 * time.Hour, true},
+		{"monthly", 28 * 24 * time.Hour, true},
+		{"*:0/15", 15 * time.Minute, true},
+		{"*:*", time.Minute, true},
+		{"*-*-* *:00:00", time.Hour, true},
+		{"*-*-* 02:30:00", 24 * time.Hour, true},
+		{"03:00", 24 * time.Hour, true},
+		{"*-*-* 08,12,20:00", 4 * time.Hour, true},
+		{"*-*-* 00/6:00:00", 6 * time.Hour, true},
+		{"Mon..Fri *-*-* 09..17:00", time.Hour, true},
+		{"*-*-* 0/2:30:00 UTC", 2 * time.Hour, true},
+		{"*:0/30:00.5", 30 * time.Minute, true},
+		{"Mon *-*-* 03:00", 0, false},
+		{"*-*-01 04:00:00", 0, false},
+		{"*-*-* 25:00", 0, false},
+		{"garbage", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, ok := CalendarInterval(tt.expr)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("CalendarInterval(%q) = (%v, %v), want (%v, %v)", tt.expr, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseCalendar(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"daily", "*-*-* 00:00:00"},
+		{"00:00", "*-*-* 00:00:00"},
+		{"*-*-* 00:00:00", "*-*-* 00:00:00"},
+		{"Mon..Sun 00:00", "*-*-* 00:00:00"},
+		{"weekly", "Mon *-*-* 00:00:00"},
+		{"quarterly", "*-01,04,07,10-01 00:00:00"},
+		{"Mon..Fri 09:00", "Mon..Fri *-*-* 09:00:00"},
+		{"Sat,Sun *-*-* 10:00", "Sat,Sun *-*-* 10:00:00"},
+		{"Fri..Sun 22:30", "Fri..Sun *-*-* 22:30:00"},
+		{"*:0/15", "*-*-* *:00,15,30,45:00"},
+		{"*-*-* 8..17:00", "*-*-* 08..17:00:00"},
+		{"12-25", "*-12-25 00:00:00"},
+		{"2024-*-01 12:00 UTC", "2024-*-01 12:00:00 UTC"},
+		{"*-*-* 03:00:00.5", "*-*-* 03:00:00"},
+		{"Monday-Friday", "Mon..Fri *-*-* 00:00:00"},
+		{"Sat,Mon..Wed 10:00", "Mon..Wed,Sat *-*-* 10:00:00"},
+		{"8..17/2:00", "*-*-* 08,10,12,14,16:00:00"},
+		{"*-02~03", "*-02~03 00:00:00"},
+		{"Mon *-05~07/1", "Mon *-05~01..07 00:00:00"},
+		{"*-*~14/7", "*-*~07,14 00:00:00"},
+		{"24-01-01", "2024-01-01 00:00:00"},
+		{"99-01-01", "1999-01-01 00:00:00"},
+		{"1/5-01-01", "2001,2006,2011,2016,2021,2026,2031,2036,2041,2046,2051,2056,2061,2066,2071,2076,2081,2086,2091,2096,2101,2106,2111,2116,2121,2126,2131,2136,2141,2146,2151,2156,2161,2166,2171,2176,2181,2186,2191,2196-01-01 00:00:00"},
+		{"*:*:0/7.5", "*-*-* *:*:00,07,15,22,30,37,45,52"},
+		{"@1700000000", "2023-11-14 22:13:20 UTC"},
+		{"Mon UTC", "Mon *-*-* 00:00:00 UTC"},
+		{"12:00 Europe/Berlin", "*-*-* 12:00:00 Europe/Berlin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseCalendar(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCalendar(%q) failed: %v", tt.expr, err)
+			}
+			if got := c.String(); got != tt.want {
+				t.Errorf("ParseCalendar(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+}
+
+func TestParseCalendarErrors(t *testing.T) {
+	tests := []struct {
+		expr      string
+		component string
+		value     string
+	}{
+		{"", "expression", ""},
+		{"garbage", "expression", "garbage"},
+		{"today", "expression", "today"},
+		{"Mon..Fri 25:00", "hour", "25"},
+		{"2024-13-01", "month", "13"},
+		{"*-*-0", "day", "0"},
+		{"*-*-32", "day", "32"},
+		{"1969-01-01", "year", "1969"},
+		{"2200-01-01", "year", "2200"},
+		{"*:60", "minute", "60"},
+		{"10:00:60", "second", "60"},
+		{"*:*/15", "minute", "*/15"},
+		{"*:0/0", "minute", "0/0"},
+		{"0/24:00", "hour", "0/24"},
+		{"*-*-1/31", "day", "1/31"},
+		{"5..2:00", "hour", "5..2"},
+		{"1..3..5:00", "hour", "1..3..5"},
+		{"*-*~07/7", "day", "07/7"},
+		{"Fri..Mon 10:00", "weekday", "fri..mon"},
+		{"Mon,,Tue", "weekday", ""},
+		{"Mon Tue 10:00", "expression", "Tue"},
+		{"*-*-*-*", "date", "*-*-*-*"},
+		{"10:00:00:00", "time", "10:00:00:00"},
+		{"12:00 Nowhere/Zone", "time zone", "Nowhere/Zone"},
+		{"10:00 UTC UTC", "expression", "UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseCalendar(tt.expr)
+			if err == nil {
+				t.Fatalf("ParseCalendar(%q) = %q, want an error", tt.expr, c)
+			}
+			var calErr *CalendarError
+			if !errors.As(err, &calErr) {
+				t.Fatalf("ParseCalendar(%q) error %v is not a *CalendarError", tt.expr, err)
+			}
+			if calErr.Component != tt.component || calErr.Value != tt.value {
+				t.Errorf("ParseCalendar(%q) failed on %s %q, want %s %q: %v", tt.expr, calErr.Component, calErr.Value, tt.component, tt.value, err)
+			}
+		})
+	}
+}
+
+func TestCalendarNext(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"daily", []string{"2024-01-02 00:00:00", "2024-01-03 00:00:00"}},
+		{"*:0/15", []string{"2024-01-01 00:15:00", "2024-01-01 00:30:00"}},
+		{"Mon *-05~07/1", []string{"2024-05-27 00:00:00", "2025-05-26 00:00:00"}},
+		{"*-02~01", []string{"2024-02-29 00:00:00", "2025-02-28 00:00:00"}},
+		{"Sat,Sun 10:00", []string{"2024-01-06 10:00:00", "2024-01-07 10:00:00"}},
+		{"12:00 Europe/Berlin", []string{"2024-01-01 11:00:00", "2024-01-02 11:00:00"}},
+		{"*-02-29 12:00", []string{"2024-02-29 12:00:00", "2028-02-29 12:00:00"}},
+		{"2024-02-30", nil},
+		{"@1700000000", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseCalendar(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCalendar(%q) failed: %v", tt.expr, err)
+			}
+			var got []string
+			for from := base; len(got) < len(tt.want); {
+				next, ok := c.Next(from)
+				if !ok {
+					break
+				}
+				got = append(got, next.Format(time.DateTime))
+				from = next
+			}
+			if len(tt.want) == 0 {
+				if next, ok := c.Next(base); ok {
+					t.Errorf("Next = %v, want never", next)
+				}
+				return
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCalendarSystemd checks ParseCalendar and Next against
+// "systemd-analyze calendar" where it is installed
+func TestCalendarSystemd(t *testing.T) {
+	analyze, err := exec.LookPath("systemd-analyze")
+	if err != nil {
+		t.Skip("systemd-analyze not installed")
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	exprs := []string{
+		"daily", "weekly", "quarterly", "Mon..Fri 09:00", "Monday-Friday", "Sat,Mon..Wed 10:00",
+		"*:0/15", "8..17/2:00", "*-*-* 05/10:00", "*-*-01..05/2", "*-1,5,9-1",
+		"*-02~03", "Mon *-05~07/1", "*-*~14/7", "*-*~01..03", "24-01-01", "1/5-01-01",
+		"*-*-* 12:00:00 UTC", "Mon 10:00 Europe/Berlin", "@1700000000", "2024-02-30",
+		"*:*:0/10", "*-*-* 10:00:00.5/2",
+		"Mon..Fri 25:00", "2024-13-01", "*:*/15", "*:0/0", "0/24:00", "*-*-1/31", "*-*~07/7",
+		"Fri..Mon 10:00", "Mon Tue 10:00", "*-*-*-*", "12:00 Nowhere/Zone", "today", "1969-01-01",
+	}
+
+	for _, expr := range exprs {
+		t.Run(expr, func(t *testing.T) {
+			cmd := exec.Command(analyze, "calendar", "--iterations=3", "--base-time=@"+strconv.FormatInt(base.Unix(), 10), expr)
+			cmd.Env = append(os.Environ(), "TZ=UTC", "SYSTEMD_COLORS=0")
+			out, runErr := cmd.Output()
+
+			c, err := ParseCalendar(expr)
+			if (runErr == nil) != (err == nil) {
+				t.Fatalf("systemd-analyze error %v, ParseCalendar error %v", runErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			var want []string
+			for _, line := range strings.Split(string(out), "\n") {
+				label, value, ok := strings.Cut(strings.TrimSpace(line), ": ")
+				if !ok || (label != "Next elapse" && !strings.HasPrefix(label, "Iter. #")) || value == "never" {
+					continue
+				}
+				want = append(want, value)
+			}
+			var got []string
+			for from := base; len(got) < len(want); {
+				next, ok := c.Next(from)
+				if !ok {
+					break
+				}
+				got = append(got, next.Format("Mon 2006-01-02 15:04:05 MST"))
+				from = next
+			}
+			if strings.Join(got, ", ") != strings.Join(want, ", ") {
+				t.Errorf("Next = %v, systemd-analyze = %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCalendarFireMinutes(t *testing.T) {
+	monday := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr  string
+		to    time.Time
+		want  int
+		first time.Time
+	}{
+		{"weekly", monday.AddDate(0, 0, 14), 2, monday},
+		{"*:0/15", monday.Add(time.Hour), 4, monday},
+		{"*-*-* *:*:0/10", monday.Add(time.Hour), 60, monday},
+		{"Sat,Sun 10:00", monday.AddDate(0, 0, 7), 2, time.Date(2024, 1, 6, 10, 0, 0, 0, time.UTC)},
+		{"*-02-29 12:00", monday.AddDate(1, 0, 0), 1, time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"2025-*-* 00:00", monday.AddDate(1, 0, 0), 0, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ParseCalendar(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCalendar(%q) failed: %v", tt.expr, err)
+			}
+			minutes := c.FireMinutes(monday, tt.to)
+			if len(minutes) != tt.want {
+				t.Fatalf("FireMinutes = %d times, want %d: %v", len(minutes), tt.want, minutes)
+			}
+			if tt.want > 0 && !minutes[0].Equal(tt.first) {
+				t.Errorf("first fire = %v, want %v", minutes[0], tt.first)
+			}
+		})
+	}
+}
@@ -10,29 +10,39 @@ import (
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
+// Kinds of cascade risk found by DetectCascades.
+const (
+	RiskJobTimeout   = "job_timeout"   // Waiting for dependencies outlasts JobTimeoutSec=
+	RiskNetworkWait  = "network_wait"  // Short TimeoutStartSec= on a unit ordered after the network
+	RiskLongChain    = "long_chain"    // Long chain of After= dependencies
+	RiskRestartCycle = "restart_cycle" // RestartSec= plus dependency start time nears TimeoutStartSec=
+)
+
 // CascadeRisk represents a potential timeout cascade.
 type CascadeRisk struct {
-	Unit           string
-	CriticalPath   time.Duration // Time to reach this unit
-	OwnTimeout     time.Duration // Unit's TimeoutStartSec
-	Risk           string        // "critical", "high", "medium", "low"
-	Description    string
-	Recommendation string
-	File           string
-	Line           int
+	Unit           string        `json:"unit"`
+	Kind           string        `json:"kind"`
+	CriticalPath   time.Duration `json:"critical_path"` // Time to reach this unit
+	OwnTimeout     time.Duration `json:"own_timeout"`   // Unit's TimeoutStartSec
+	Risk           string        `json:"risk"`          // "critical", "high", "medium", "low"
+	Description    string        `json:"description"`
+	Recommendation string        `json:"recommendation"`
+	File           string        `json:"file,omitempty"`
+	Line           int           `json:"line,omitempty"`
 }
 
 // CascadeResult contains all detected cascade risks.
 type CascadeResult struct {
-	Risks         []CascadeRisk
-	TotalRisks    int
-	CriticalCount int
-	HighCount     int
-	MediumCount   int
-	LowCount      int
+	Risks         []CascadeRisk `json:"risks"`
+	TotalRisks    int           `json:"total_risks"`
+	CriticalCount int           `json:"critical_count"`
+	HighCount     int           `json:"high_count"`
+	MediumCount   int           `json:"medium_count"`
+	LowCount      int           `json:"low_count"`
 }
 
-// DetectCascades finds units where critical path may exceed timeout.
+// DetectCascades finds units where critical path may exceed timeout. The
+// paths are those of ComputeCriticalPaths, weighted by timeouts.
 // Also detects:
 // - network-online.target deps with tight timeouts
 // - Long chains of serial dependencies
@@ -52,9 +62,12 @@ func DetectCascades(g *graph.Graph, paths CriticalPathResult, timeouts map[strin
 	// Detect restart loop risks
 	risks = append(risks, detectRestartLoopRisks(g, paths, timeouts)...)
 
-	// Sort by risk level
-	sort.Slice(risks, func(i, j int) bool {
-		return riskOrder(risks[i].Risk) < riskOrder(risks[j].Risk)
+	// Sort by risk level, then unit
+	sort.SliceStable(risks, func(i, j int) bool {
+		if riskOrder(risks[i].Risk) != riskOrder(risks[j].Risk) {
+			return riskOrder(risks[i].Risk) < riskOrder(risks[j].Risk)
+		}
+		return risks[i].Unit < risks[j].Unit
 	})
 
 	// Count by severity
@@ -93,7 +106,10 @@ func riskOrder(risk string) int {
 	}
 }
 
-// detectPathTimeoutExceeded finds units where critical path time exceeds their timeout.
+// detectPathTimeoutExceeded finds units whose dependencies take longer to
+// start than their JobTimeoutSec=, or close to it. The risk is critical when
+// they take at least twice as long, high when they take longer and medium from
+// 80% of the job timeout.
 func detectPathTimeoutExceeded(g *graph.Graph, paths CriticalPathResult, timeouts map[string]TimeoutConfig) []CascadeRisk {
 	var risks []CascadeRisk
 
@@ -103,42 +119,58 @@ func detectPathTimeoutExceeded(g *graph.Graph, paths CriticalPathResult, timeout
 		}
 
 		tc, ok := timeouts[unitName]
-		if !ok {
+		if !ok || tc.JobTimeoutSec <= 0 {
 			continue
 		}
 
-		// Calculate time spent waiting for dependencies (exclude own timeout)
+		// Calculate time spent waiting for dependencies (exclude own timeout),
+		// which is none when the unit's own timeout is the whole path
 		depTime := path.TotalTime - tc.TimeoutStartSec
+		if depTime < 0 {
+			depTime = 0
+		}
 
-		// Check if job timeout would be exceeded
 		// Job timeout starts when the job is created, not when the unit starts activating
-		if tc.JobTimeoutSec > 0 && depTime > tc.JobTimeoutSec {
-			risk := "critical"
-			if depTime < tc.JobTimeoutSec*2 {
-				risk = "high"
-			}
-
-			unit := g.Unit(unitName)
-			file := ""
-			line := 0
-			if unit != nil {
-				file = unit.Path
-			}
+		var risk, description string
+		switch {
+		case depTime >= tc.JobTimeoutSec*2:
+			risk = "critical"
+		case depTime > tc.JobTimeoutSec:
+			risk = "high"
+		case depTime*5 >= tc.JobTimeoutSec*4:
+			risk = "medium"
+			description = fmt.Sprintf(
+				"Critical path to %s takes %s, %s of JobTimeoutSec (%s). "+
+					"The job may timeout waiting for dependencies on a slower boot.",
+				unitName, FormatDuration(depTime), formatPercent(depTime, tc.JobTimeoutSec), FormatDuration(tc.JobTimeoutSec))
+		default:
+			continue
+		}
+		if description == "" {
+			description = fmt.Sprintf(
+				"Critical path to %s takes %s, but JobTimeoutSec is %s. "+
+					"The job may timeout waiting for dependencies.",
+				unitName, FormatDuration(depTime), FormatDuration(tc.JobTimeoutSec))
+		}
 
-			risks = append(risks, CascadeRisk{
-				Unit:         unitName,
-				CriticalPath: depTime,
-				OwnTimeout:   tc.JobTimeoutSec,
-				Risk:         risk,
-				Description: fmt.Sprintf(
-					"Critical path to %s takes %s, but JobTimeoutSec is %s. "+
-						"The job may timeout waiting for dependencies.",
-					unitName, FormatDuration(depTime), FormatDuration(tc.JobTimeoutSec)),
-				Recommendation: "Increase JobTimeoutSec or reduce dependency chain length",
-				File:           file,
-				Line:           line,
-			})
+		unit := g.Unit(unitName)
+		file := ""
+		line := 0
+		if unit != nil {
+			file = unit.Path
 		}
+
+		risks = append(risks, CascadeRisk{
+			Unit:           unitName,
+			Kind:           RiskJobTimeout,
+			CriticalPath:   depTime,
+			OwnTimeout:     tc.JobTimeoutSec,
+			Risk:           risk,
+			Description:    description,
+			Recommendation: "Increase JobTimeoutSec or reduce dependency chain length",
+			File:           file,
+			Line:           line,
+		})
 	}
 
 	return risks
@@ -190,6 +222,7 @@ func detectNetworkDependencyRisks(g *graph.Graph, timeouts map[string]TimeoutCon
 
 			risks = append(risks, CascadeRisk{
 				Unit:         unit.Name,
+				Kind:         RiskNetworkWait,
 				CriticalPath: 0, // Unknown
 				OwnTimeout:   tc.TimeoutStartSec,
 				Risk:         risk,
@@ -229,6 +262,7 @@ func detectLongChains(paths CriticalPathResult, timeouts map[string]TimeoutConfi
 
 		risks = append(risks, CascadeRisk{
 			Unit:         unitName,
+			Kind:         RiskLongChain,
 			CriticalPath: path.TotalTime,
 			OwnTimeout:   tc.TimeoutStartSec,
 			Risk:         risk,
@@ -288,6 +322,7 @@ func detectRestartLoopRisks(g *graph.Graph, paths CriticalPathResult, timeouts m
 
 			risks = append(risks, CascadeRisk{
 				Unit:         unit.Name,
+				Kind:         RiskRestartCycle,
 				CriticalPath: depTime,
 				OwnTimeout:   tc.TimeoutStartSec,
 				Risk:         risk,
@@ -318,12 +353,12 @@ func formatPercent(a, b time.Duration) string {
 
 // AnalyzeUnitTiming provides detailed timing analysis for a specific unit.
 type UnitTimingAnalysis struct {
-	Unit           string
-	TimeoutConfig  TimeoutConfig
-	CriticalPath   CriticalPath
-	CascadeRisks   []CascadeRisk
-	Dependencies   []string
-	DependencyTime time.Duration
+	Unit           string        `json:"unit"`
+	TimeoutConfig  TimeoutConfig `json:"timeout_config"`
+	CriticalPath   CriticalPath  `json:"critical_path"`
+	CascadeRisks   []CascadeRisk `json:"cascade_risks"`
+	Dependencies   []string      `json:"dependencies"`
+	DependencyTime time.Duration `json:"dependency_time"`
 }
 
 // AnalyzeUnit provides comprehensive timing analysis for a single unit.
@@ -0,0 +1,88 @@
+package timing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/graph"
+)
+
+// chainPath returns a critical path to unit through one dependency that
+// takes depTime, with the unit itself at its own timeout
+func chainPath(unit string, depTime, own time.Duration) CriticalPath {
+	return CriticalPath{
+		Unit:      unit,
+		TotalTime: depTime + own,
+		Path: []PathNode{
+			{Unit: "dep.service", Timeout: depTime, Cumulative: depTime},
+			{Unit: unit, Timeout: own, Cumulative: depTime + own},
+		},
+	}
+}
+
+func TestDetectPathTimeoutExceeded(t *testing.T) {
+	job := 100 * time.Second
+	tests := []struct {
+		name    string
+		path    CriticalPath
+		timeout TimeoutConfig
+		want    string // Risk, empty for none
+	}{
+		{"far beyond", chainPath("a.service", 250*time.Second, 10*time.Second), TimeoutConfig{TimeoutStartSec: 10 * time.Second, JobTimeoutSec: job}, "critical"},
+		{"twice", chainPath("a.service", 200*time.Second, 10*time.Second), TimeoutConfig{TimeoutStartSec: 10 * time.Second, JobTimeoutSec: job}, "critical"},
+		{"beyond", chainPath("a.service", 150*time.Second, 10*time.Second), TimeoutConfig{TimeoutStartSec: 10 * time.Second, JobTimeoutSec: job}, "high"},
+		{"all of it", chainPath("a.service", 100*time.Second, 10*time.Second), TimeoutConfig{TimeoutStartSec: 10 * time.Second, JobTimeoutSec: job}, "medium"},
+		{"80%", chainPath("a.service", 80*time.Second, 10*time.Second), TimeoutConfig{TimeoutStartSec: 10 * time.Second, JobTimeoutSec: job}, "medium"},
+		{"below 80%", chainPath("a.service", 79*time.Second, 10*time.Second), TimeoutConfig{TimeoutStartSec: 10 * time.Second, JobTimeoutSec: job}, ""},
+		{"no job timeout", chainPath("a.service", 250*time.Second, 10*time.Second), TimeoutConfig{TimeoutStartSec: 10 * time.Second}, ""},
+		// The path is shorter than the unit's own timeout, as when the own
+		// timeout comes from another configuration than the path
+		{"own timeout dominates", chainPath("a.service", 0, 10*time.Second), TimeoutConfig{TimeoutStartSec: 5 * time.Minute, JobTimeoutSec: job}, ""},
+		{"no dependencies", CriticalPath{Unit: "a.service", TotalTime: 5 * time.Minute, Path: []PathNode{{Unit: "a.service"}}}, TimeoutConfig{JobTimeoutSec: time.Second}, ""},
+	}
+	for _, tt := range tests {
+		paths := CriticalPathResult{Paths: map[string]CriticalPath{tt.path.Unit: tt.path}}
+		risks := detectPathTimeoutExceeded(graph.New(), paths, map[string]TimeoutConfig{tt.path.Unit: tt.timeout})
+		got := ""
+		if len(risks) > 1 {
+			t.Errorf("%s: %d risks, want at most one", tt.name, len(risks))
+		}
+		if len(risks) > 0 {
+			got = risks[0].Risk
+			if risks[0].CriticalPath < 0 {
+				t.Errorf("%s: CriticalPath = %s, want no negative dependency time", tt.name, risks[0].CriticalPath)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: risk = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCascadesCountsJobTimeoutTiers(t *testing.T) {
+	paths := CriticalPathResult{Paths: map[string]CriticalPath{}}
+	timeouts := map[string]TimeoutConfig{}
+	for unit, depTime := range map[string]time.Duration{
+		"critical.service": 5 * time.Minute,
+		"high.service":     2 * time.Minute,
+		"medium.service":   90 * time.Second,
+		"fine.service":     time.Minute,
+	} {
+		paths.Paths[unit] = chainPath(unit, depTime, time.Second)
+		timeouts[unit] = TimeoutConfig{Unit: unit, TimeoutStartSec: time.Second, JobTimeoutSec: 100 * time.Second}
+	}
+
+	result := DetectCascades(graph.New(), paths, timeouts)
+	var order []string
+	for _, risk := range result.Risks {
+		order = append(order, risk.Unit)
+	}
+	want := []string{"critical.service", "high.service", "medium.service"}
+	if len(order) != len(want) || order[0] != want[0] || order[1] != want[1] || order[2] != want[2] {
+		t.Errorf("risks = %v, want %v", order, want)
+	}
+	if result.CriticalCount != 1 || result.HighCount != 1 || result.MediumCount != 1 || result.LowCount != 0 {
+		t.Errorf("counts = %d critical, %d high, %d medium, %d low, want one each of the first three",
+			result.CriticalCount, result.HighCount, result.MediumCount, result.LowCount)
+	}
+}
@@ -2,24 +2,42 @@ package timing
 
 import (
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/supabase/sdaudit/internal/graph"
 )
 
+// Weighting is what each unit on a critical path counts for.
+type Weighting int
+
+const (
+	// WeightTimeout counts each unit at its TimeoutStartSec=, the worst case.
+	WeightTimeout Weighting = iota
+	// WeightObserved counts each unit at the time it took to start at boot.
+	WeightObserved
+)
+
 // PathNode represents a unit in a critical path.
 type PathNode struct {
-	Unit       string
-	Timeout    time.Duration
-	Cumulative time.Duration // Running total at this point
+	Unit    string        `json:"unit"`
+	Timeout time.Duration `json:"timeout"`
+	// Observed is the time the unit took to start at boot. Unobserved is set
+	// when start times were given but the unit has none, as for units that
+	// did not start; they count as starting at once.
+	Observed   time.Duration `json:"observed,omitempty"`
+	Unobserved bool          `json:"unobserved,omitempty"`
+	Cumulative time.Duration `json:"cumulative"` // Running total at this point
 }
 
 // CriticalPath represents the longest startup chain to reach a unit.
 type CriticalPath struct {
-	Unit       string
-	TotalTime  time.Duration // Sum of timeouts along path
-	Path       []PathNode    // Units in order
-	Bottleneck string        // Unit contributing most time
+	Unit       string        `json:"unit"`
+	TotalTime  time.Duration `json:"total_time"`         // Sum of the weights along path
+	WorstCase  time.Duration `json:"worst_case"`         // Sum of timeouts along path
+	Observed   time.Duration `json:"observed,omitempty"` // Sum of observed start times along path
+	Path       []PathNode    `json:"path"`               // Units in order
+	Bottleneck string        `json:"bottleneck"`         // Unit contributing most time
 }
 
 // CriticalPathResult contains all computed critical paths.
@@ -30,8 +48,17 @@ type CriticalPathResult struct {
 }
 
 // ComputeCriticalPaths walks the After= graph backward from each unit.
+// Before= on the unit waited for counts as After=, as the graph records it.
 // Returns the worst-case startup time chain for each unit.
 func ComputeCriticalPaths(g *graph.Graph, timeouts map[string]TimeoutConfig) CriticalPathResult {
+	return ComputeCriticalPathsWith(g, timeouts, nil, WeightTimeout)
+}
+
+// ComputeCriticalPathsWith computes the critical paths as ComputeCriticalPaths
+// does, choosing the longest chain by the given weighting. observed holds the
+// start times measured at boot by unit name, such as those of
+// systemd-analyze blame; when it is not nil each path also sums them up.
+func ComputeCriticalPathsWith(g *graph.Graph, timeouts map[string]TimeoutConfig, observed map[string]time.Duration, weighting Weighting) CriticalPathResult {
 	result := CriticalPathResult{
 		Paths: make(map[string]CriticalPath),
 	}
@@ -64,10 +91,21 @@ func ComputeCriticalPaths(g *graph.Graph, timeouts map[string]TimeoutConfig) Cri
 		visited[unit] = true
 		defer delete(visited, unit)
 
-		// Get this unit's timeout
-		timeout := DefaultTimeoutStartSec
+		// Get this unit's timeout. Targets are reached as soon as the
+		// units they are ordered after are, so they add no time.
+		node := PathNode{Unit: unit, Timeout: DefaultTimeoutStartSec}
 		if tc, ok := timeouts[unit]; ok {
-			timeout = tc.TimeoutStartSec
+			node.Timeout = tc.TimeoutStartSec
+		}
+		if strings.HasSuffix(unit, ".target") {
+			node.Timeout = 0
+		} else if observed != nil {
+			d, ok := observed[unit]
+			node.Observed, node.Unobserved = d, !ok
+		}
+		weight := node.Timeout
+		if weighting == WeightObserved {
+			weight = node.Observed
 		}
 
 		// Find the longest path among all dependencies
@@ -82,24 +120,26 @@ func ComputeCriticalPaths(g *graph.Graph, timeouts map[string]TimeoutConfig) Cri
 		// Build this unit's path
 		path := CriticalPath{
 			Unit:      unit,
-			TotalTime: longestDep.TotalTime + timeout,
+			TotalTime: longestDep.TotalTime + weight,
+			WorstCase: longestDep.WorstCase + node.Timeout,
+			Observed:  longestDep.Observed + node.Observed,
 			Path:      make([]PathNode, len(longestDep.Path)+1),
 		}
 
 		// Copy dependency path and add this unit
 		copy(path.Path, longestDep.Path)
-		cumulative := longestDep.TotalTime + timeout
-		path.Path[len(path.Path)-1] = PathNode{
-			Unit:       unit,
-			Timeout:    timeout,
-			Cumulative: cumulative,
-		}
+		node.Cumulative = path.TotalTime
+		path.Path[len(path.Path)-1] = node
 
-		// Find bottleneck (unit with largest timeout in path)
-		var maxTimeout time.Duration
+		// Find bottleneck (unit with largest weight in path)
+		var maxWeight time.Duration
 		for _, node := range path.Path {
-			if node.Timeout > maxTimeout {
-				maxTimeout = node.Timeout
+			w := node.Timeout
+			if weighting == WeightObserved {
+				w = node.Observed
+			}
+			if w > maxWeight {
+				maxWeight = w
 				path.Bottleneck = node.Unit
 			}
 		}
@@ -119,10 +159,10 @@ func ComputeCriticalPaths(g *graph.Graph, timeouts map[string]TimeoutConfig) Cri
 		}
 	}
 
-	// Find bottleneck units (appear frequently as bottleneck)
+	// Find bottleneck units (appear frequently as bottleneck of a chain)
 	bottleneckCount := make(map[string]int)
 	for _, path := range result.Paths {
-		if path.Bottleneck != "" {
+		if path.Bottleneck != "" && len(path.Path) > 1 {
 			bottleneckCount[path.Bottleneck]++
 		}
 	}
@@ -137,7 +177,10 @@ func ComputeCriticalPaths(g *graph.Graph, timeouts map[string]TimeoutConfig) Cri
 		bottlenecks = append(bottlenecks, bottleneck{unit, count})
 	}
 	sort.Slice(bottlenecks, func(i, j int) bool {
-		return bottlenecks[i].count > bottlenecks[j].count
+		if bottlenecks[i].count != bottlenecks[j].count {
+			return bottlenecks[i].count > bottlenecks[j].count
+		}
+		return bottlenecks[i].unit < bottlenecks[j].unit
 	})
 
 	for _, b := range bottlenecks {
@@ -158,7 +201,10 @@ func (r CriticalPathResult) PathsExceedingThreshold(threshold time.Duration) []C
 
 	// Sort by total time descending
 	sort.Slice(paths, func(i, j int) bool {
-		return paths[i].TotalTime > paths[j].TotalTime
+		if paths[i].TotalTime != paths[j].TotalTime {
+			return paths[i].TotalTime > paths[j].TotalTime
+		}
+		return paths[i].Unit < paths[j].Unit
 	})
 
 	return paths
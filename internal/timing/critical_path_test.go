@@ -0,0 +1,123 @@
+package timing
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/unitfile"
+)
+
+func loadLinearChain(t *testing.T) (*graph.Graph, map[string]TimeoutConfig) {
+	t.Helper()
+	dir, err := filepath.Abs("../../testdata/graph/linear_chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, err := unitfile.LoadDirectory(dir)
+	if err != nil {
+		t.Fatalf("failed to load units: %v", err)
+	}
+	return graph.Build(units), ParseAllTimeouts(units, DefaultSystemConfig())
+}
+
+func TestComputeCriticalPaths(t *testing.T) {
+	g, timeouts := loadLinearChain(t)
+	timeouts["s1.service"] = TimeoutConfig{Unit: "s1.service", TimeoutStartSec: 5 * time.Minute}
+
+	result := ComputeCriticalPaths(g, timeouts)
+
+	if result.LongestPath.Unit != "s3.service" {
+		t.Fatalf("LongestPath = %s, want s3.service", result.LongestPath.Unit)
+	}
+	if want := 5*time.Minute + 2*DefaultTimeoutStartSec; result.LongestPath.TotalTime != want {
+		t.Errorf("TotalTime = %s, want %s", result.LongestPath.TotalTime, want)
+	}
+	if got := result.LongestPath.PathDescription(); got != "s1.service -> s2.service -> s3.service" {
+		t.Errorf("PathDescription() = %q", got)
+	}
+
+	// s1.service alone is not a chain, so it only counts for s2 and s3
+	if len(result.BottleneckUnits) != 1 || result.BottleneckUnits[0] != "s1.service" {
+		t.Errorf("BottleneckUnits = %v, want [s1.service]", result.BottleneckUnits)
+	}
+
+	paths := result.PathsExceedingThreshold(5 * time.Minute)
+	if len(paths) != 2 || paths[0].Unit != "s3.service" || paths[1].Unit != "s2.service" {
+		t.Errorf("PathsExceedingThreshold(5m) = %v, want s3.service and s2.service", paths)
+	}
+}
+
+func TestComputeCriticalPathsObserved(t *testing.T) {
+	g, timeouts := loadLinearChain(t)
+	timeouts["s1.service"] = TimeoutConfig{Unit: "s1.service", TimeoutStartSec: 5 * time.Minute}
+	// s2.service did not start at boot
+	observed := map[string]time.Duration{"s1.service": 2 * time.Second, "s3.service": 3 * time.Second}
+
+	path := ComputeCriticalPathsWith(g, timeouts, observed, WeightObserved).Paths["s3.service"]
+	if got := path.PathDescription(); got != "s1.service -> s2.service -> s3.service" {
+		t.Errorf("PathDescription() = %q", got)
+	}
+	if path.TotalTime != 5*time.Second || path.Observed != 5*time.Second {
+		t.Errorf("TotalTime = %s, Observed = %s, want 5s", path.TotalTime, path.Observed)
+	}
+	if want := 5*time.Minute + 2*DefaultTimeoutStartSec; path.WorstCase != want {
+		t.Errorf("WorstCase = %s, want %s", path.WorstCase, want)
+	}
+	if path.Bottleneck != "s3.service" {
+		t.Errorf("Bottleneck = %s, want s3.service, the slowest to start", path.Bottleneck)
+	}
+	for _, node := range path.Path {
+		if node.Unobserved != (node.Unit == "s2.service") {
+			t.Errorf("%s: Unobserved = %v", node.Unit, node.Unobserved)
+		}
+	}
+
+	// Weighted by timeouts, the observed times are summed along the same chain
+	path = ComputeCriticalPathsWith(g, timeouts, observed, WeightTimeout).Paths["s3.service"]
+	if path.TotalTime != path.WorstCase || path.Observed != 5*time.Second || path.Bottleneck != "s1.service" {
+		t.Errorf("TotalTime = %s, WorstCase = %s, Observed = %s, Bottleneck = %s", path.TotalTime, path.WorstCase, path.Observed, path.Bottleneck)
+	}
+	if path := ComputeCriticalPaths(g, timeouts).Paths["s3.service"]; path.Observed != 0 || path.Path[0].Unobserved {
+		t.Errorf("without observed times, Observed = %s and Unobserved = %v", path.Observed, path.Path[0].Unobserved)
+	}
+}
+
+func TestAnalyzeUnit(t *testing.T) {
+	g, timeouts := loadLinearChain(t)
+
+	analysis := AnalyzeUnit("s2.service", g, nil, timeouts)
+	if analysis == nil {
+		t.Fatal("AnalyzeUnit(s2.service) = nil")
+	}
+	if analysis.DependencyTime != DefaultTimeoutStartSec {
+		t.Errorf("DependencyTime = %s, want %s", analysis.DependencyTime, DefaultTimeoutStartSec)
+	}
+	// The targets of the default dependencies do not add to DependencyTime
+	if want := []string{"basic.target", "s1.service", "sysinit.target"}; strings.Join(analysis.Dependencies, " ") != strings.Join(want, " ") {
+		t.Errorf("Dependencies = %v, want %v", analysis.Dependencies, want)
+	}
+
+	if AnalyzeUnit("missing.service", g, nil, timeouts) != nil {
+		t.Error("expected nil for an unknown unit")
+	}
+}
+
+func TestComputeCriticalPathsBefore(t *testing.T) {
+	// db.service orders itself before app.service with Before= alone
+	units, err := unitfile.LoadDirectory("../../testdata/graph/before_only")
+	if err != nil {
+		t.Fatalf("failed to load units: %v", err)
+	}
+	result := ComputeCriticalPaths(graph.Build(units), ParseAllTimeouts(units, DefaultSystemConfig()))
+
+	path := result.Paths["app.service"]
+	if got := path.PathDescription(); got != "db.service -> app.service" {
+		t.Errorf("PathDescription() = %q, want db.service -> app.service", got)
+	}
+	if want := 2*time.Minute + DefaultTimeoutStartSec; path.TotalTime != want {
+		t.Errorf("TotalTime = %s, want %s", path.TotalTime, want)
+	}
+}
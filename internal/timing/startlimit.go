@@ -0,0 +1,54 @@
+package timing
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// StartLimit holds a unit's start rate limiting.
+// A zero Interval means rate limiting is disabled.
+type StartLimit struct {
+	Interval time.Duration
+	Burst    int
+	// Configured is set when the unit overrides the manager defaults
+	Configured bool
+}
+
+// ParseStartLimit extracts start rate limiting from a unit, falling back to
+// the manager defaults. The deprecated [Service] StartLimitInterval= is honored.
+func ParseStartLimit(unit *types.UnitFile, systemConf *SystemConfig) StartLimit {
+	if systemConf == nil {
+		systemConf = DefaultSystemConfig()
+	}
+
+	limit := StartLimit{
+		Interval: systemConf.DefaultStartLimitIntervalSec,
+		Burst:    systemConf.DefaultStartLimitBurst,
+	}
+
+	for _, key := range []struct{ section, name string }{
+		{"Service", "StartLimitInterval"},
+		{"Unit", "StartLimitInterval"},
+		{"Unit", "StartLimitIntervalSec"},
+	} {
+		if val := unit.GetDirective(key.section, key.name); val != "" {
+			if d, err := ParseDuration(val); err == nil {
+				limit.Interval = d
+				limit.Configured = true
+			}
+		}
+	}
+
+	for _, section := range []string{"Service", "Unit"} {
+		if val := unit.GetDirective(section, "StartLimitBurst"); val != "" {
+			if n, err := strconv.Atoi(val); err == nil {
+				limit.Burst = n
+				limit.Configured = true
+			}
+		}
+	}
+
+	return limit
+}
@@ -0,0 +1,168 @@
+package timing
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigSource records where a manager setting was defined.
+type ConfigSource struct {
+	File string
+	Line int
+}
+
+// managerConfDirs are the directories searched for manager configuration,
+// lowest precedence first, mirroring systemd-system.conf(5).
+var managerConfDirs = []string{
+	"/usr/lib/systemd",
+	"/usr/local/lib/systemd",
+	"/run/systemd",
+	"/etc/systemd",
+}
+
+// LoadSystemConfig reads system.conf and its system.conf.d drop-ins below root.
+// An empty root reads the live system. Missing files are not an error; the
+// returned config then holds systemd's built-in defaults.
+func LoadSystemConfig(root string) (*SystemConfig, error) {
+	return loadManagerConfig(root, "system.conf")
+}
+
+// loadManagerConfig reads a manager configuration file and its drop-ins
+// below root. The main file in /etc is read first, then drop-ins in lexical
+// order of their file names; a drop-in in a later directory replaces one with
+// the same name in an earlier directory.
+func loadManagerConfig(root, name string) (*SystemConfig, error) {
+	conf := DefaultSystemConfig()
+
+	mainFile := filepath.Join(root, "/etc/systemd", name)
+	if err := applyConfigFile(conf, mainFile); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	dropIns := make(map[string]string)
+	for _, dir := range managerConfDirs {
+		entries, err := os.ReadDir(filepath.Join(root, dir, name+".d"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".conf") {
+				continue
+			}
+			dropIns[entry.Name()] = filepath.Join(root, dir, name+".d", entry.Name())
+		}
+	}
+
+	names := make([]string, 0, len(dropIns))
+	for n := range dropIns {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		if err := applyConfigFile(conf, dropIns[n]); err != nil {
+			return nil, err
+		}
+	}
+
+	return conf, nil
+}
+
+func applyConfigFile(conf *SystemConfig, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	return parseManagerConfig(conf, path, f)
+}
+
+// ParseSystemConfig parses the contents of a system.conf-style file on top of
+// systemd's defaults. Only the [Manager] section is considered.
+func ParseSystemConfig(path, content string) (*SystemConfig, error) {
+	conf := DefaultSystemConfig()
+	if err := parseManagerConfig(conf, path, strings.NewReader(content)); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func parseManagerConfig(conf *SystemConfig, path string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	section := ""
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+
+		if section != "Manager" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if conf.set(key, value) {
+			if conf.Sources == nil {
+				conf.Sources = make(map[string]ConfigSource)
+			}
+			conf.Sources[key] = ConfigSource{File: path, Line: lineNum}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// set applies a single [Manager] setting and reports whether it was recognized.
+func (c *SystemConfig) set(key, value string) bool {
+	switch key {
+	case "DefaultTimeoutStartSec", "DefaultTimeoutStopSec", "DefaultRestartSec", "DefaultStartLimitIntervalSec":
+		d, err := ParseDuration(value)
+		if err != nil {
+			return false
+		}
+		switch key {
+		case "DefaultTimeoutStartSec":
+			c.DefaultTimeoutStartSec = d
+		case "DefaultTimeoutStopSec":
+			c.DefaultTimeoutStopSec = d
+		case "DefaultStartLimitIntervalSec":
+			c.DefaultStartLimitIntervalSec = d
+		default:
+			c.DefaultRestartSec = d
+		}
+	case "DefaultStartLimitBurst":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return false
+		}
+		c.DefaultStartLimitBurst = n
+	case "DefaultTasksMax":
+		c.DefaultTasksMax = value
+	case "DefaultLimitNOFILE":
+		c.DefaultLimitNOFILE = value
+	default:
+		return false
+	}
+	return true
+}
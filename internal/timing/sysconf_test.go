@@ -0,0 +1,137 @@
+package timing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestParseSystemConfig(t *testing.T) {
+	content := `# Defaults are commented out
+[Manager]
+#DefaultTimeoutStopSec=90s
+DefaultTimeoutStartSec=2min
+DefaultRestartSec=500ms
+DefaultTasksMax=4096
+DefaultLimitNOFILE=65536:524288
+LogLevel=info
+
+[Other]
+DefaultTimeoutStopSec=1s
+`
+	conf, err := ParseSystemConfig("system.conf", content)
+	if err != nil {
+		t.Fatalf("ParseSystemConfig failed: %v", err)
+	}
+
+	if conf.DefaultTimeoutStartSec != 2*time.Minute {
+		t.Errorf("DefaultTimeoutStartSec = %v, want 2m", conf.DefaultTimeoutStartSec)
+	}
+	if conf.DefaultTimeoutStopSec != DefaultTimeoutStopSec {
+		t.Errorf("DefaultTimeoutStopSec = %v, want default %v", conf.DefaultTimeoutStopSec, DefaultTimeoutStopSec)
+	}
+	if conf.DefaultRestartSec != 500*time.Millisecond {
+		t.Errorf("DefaultRestartSec = %v, want 500ms", conf.DefaultRestartSec)
+	}
+	if conf.DefaultTasksMax != "4096" {
+		t.Errorf("DefaultTasksMax = %q, want 4096", conf.DefaultTasksMax)
+	}
+	if conf.DefaultLimitNOFILE != "65536:524288" {
+		t.Errorf("DefaultLimitNOFILE = %q, want 65536:524288", conf.DefaultLimitNOFILE)
+	}
+
+	src, ok := conf.Sources["DefaultTimeoutStartSec"]
+	if !ok || src.File != "system.conf" || src.Line != 4 {
+		t.Errorf("Sources[DefaultTimeoutStartSec] = %+v, want system.conf:4", src)
+	}
+	if _, ok := conf.Sources["DefaultTimeoutStopSec"]; ok {
+		t.Error("Settings outside [Manager] should be ignored")
+	}
+}
+
+func TestLoadSystemConfig(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, filepath.Join(root, "etc/systemd/system.conf"), "[Manager]\nDefaultTimeoutStartSec=30s\nDefaultTimeoutStopSec=30s\n")
+	writeFile(t, filepath.Join(root, "usr/lib/systemd/system.conf.d/10-vendor.conf"), "[Manager]\nDefaultTimeoutStartSec=45s\nDefaultTasksMax=100\n")
+	writeFile(t, filepath.Join(root, "usr/lib/systemd/system.conf.d/20-override.conf"), "[Manager]\nDefaultTasksMax=200\n")
+	// Same name in /etc masks the vendor drop-in
+	writeFile(t, filepath.Join(root, "etc/systemd/system.conf.d/20-override.conf"), "[Manager]\nDefaultTasksMax=300\n")
+
+	conf, err := LoadSystemConfig(root)
+	if err != nil {
+		t.Fatalf("LoadSystemConfig failed: %v", err)
+	}
+
+	if conf.DefaultTimeoutStartSec != 45*time.Second {
+		t.Errorf("DefaultTimeoutStartSec = %v, want 45s (drop-in overrides main file)", conf.DefaultTimeoutStartSec)
+	}
+	if conf.DefaultTimeoutStopSec != 30*time.Second {
+		t.Errorf("DefaultTimeoutStopSec = %v, want 30s", conf.DefaultTimeoutStopSec)
+	}
+	if conf.DefaultTasksMax != "300" {
+		t.Errorf("DefaultTasksMax = %q, want 300 (/etc drop-in masks vendor one)", conf.DefaultTasksMax)
+	}
+}
+
+func TestLoadSystemConfigMissing(t *testing.T) {
+	conf, err := LoadSystemConfig(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadSystemConfig failed: %v", err)
+	}
+	if conf.DefaultTimeoutStartSec != DefaultTimeoutStartSec {
+		t.Errorf("DefaultTimeoutStartSec = %v, want default", conf.DefaultTimeoutStartSec)
+	}
+	if len(conf.Sources) != 0 {
+		t.Errorf("Sources = %v, want empty", conf.Sources)
+	}
+}
+
+func TestParseAllTimeoutsUsesSystemConfig(t *testing.T) {
+	conf, err := ParseSystemConfig("system.conf", "[Manager]\nDefaultTimeoutStartSec=10s\n")
+	if err != nil {
+		t.Fatalf("ParseSystemConfig failed: %v", err)
+	}
+
+	timeouts := ParseAllTimeouts(map[string]*types.UnitFile{
+		"a.service": {Name: "a.service", Type: "service", Sections: map[string]*types.Section{}},
+	}, conf)
+
+	if got := timeouts["a.service"].TimeoutStartSec; got != 10*time.Second {
+		t.Errorf("TimeoutStartSec = %v, want 10s from system.conf", got)
+	}
+}
+
+func TestParseStartLimit(t *testing.T) {
+	conf, err := ParseSystemConfig("system.conf", "[Manager]\nDefaultStartLimitIntervalSec=30s\nDefaultStartLimitBurst=3\n")
+	if err != nil {
+		t.Fatalf("ParseSystemConfig failed: %v", err)
+	}
+
+	unit := &types.UnitFile{Name: "app.service", Sections: map[string]*types.Section{}}
+	limit := ParseStartLimit(unit, conf)
+	if limit.Interval != 30*time.Second || limit.Burst != 3 || limit.Configured {
+		t.Errorf("ParseStartLimit() = %+v, want manager defaults 30s/3", limit)
+	}
+
+	unit.Sections["Unit"] = &types.Section{Name: "Unit", Directives: map[string][]types.Directive{
+		"StartLimitIntervalSec": {{Key: "StartLimitIntervalSec", Value: "0"}},
+	}}
+	limit = ParseStartLimit(unit, conf)
+	if limit.Interval != 0 || !limit.Configured {
+		t.Errorf("ParseStartLimit() = %+v, want disabled rate limiting", limit)
+	}
+}
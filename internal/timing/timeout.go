@@ -2,6 +2,7 @@
 package timing
 
 import (
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -19,6 +20,11 @@ const (
 	DefaultTimeoutStopSec  = 90 * time.Second
 	DefaultRestartSec      = 100 * time.Millisecond
 	DefaultJobTimeoutSec   = 0 // infinity
+	DefaultTasksMax        = "15%"
+	DefaultLimitNOFILE     = "1024:524288"
+
+	DefaultStartLimitIntervalSec = 10 * time.Second
+	DefaultStartLimitBurst       = 5
 )
 
 // TimeoutConfig holds parsed timeout values for a unit.
@@ -26,13 +32,13 @@ const (
 //
 //nolint:staticcheck // ST1011: names match systemd directives
 type TimeoutConfig struct {
-	Unit            string
-	TimeoutStartSec time.Duration
-	TimeoutStopSec  time.Duration
-	TimeoutAbortSec time.Duration // Defaults to TimeoutStopSec
-	JobTimeoutSec   time.Duration // 0 = infinity
-	RestartSec      time.Duration
-	Source          string // File where primary timeout is defined
+	Unit            string        `json:"unit"`
+	TimeoutStartSec time.Duration `json:"timeout_start_sec"`
+	TimeoutStopSec  time.Duration `json:"timeout_stop_sec"`
+	TimeoutAbortSec time.Duration `json:"timeout_abort_sec"` // Defaults to TimeoutStopSec
+	JobTimeoutSec   time.Duration `json:"job_timeout_sec"`   // 0 = infinity
+	RestartSec      time.Duration `json:"restart_sec"`
+	Source          string        `json:"source,omitempty"` // File where primary timeout is defined
 }
 
 // SystemConfig holds system-wide defaults from system.conf.
@@ -43,6 +49,13 @@ type SystemConfig struct {
 	DefaultTimeoutStartSec time.Duration
 	DefaultTimeoutStopSec  time.Duration
 	DefaultRestartSec      time.Duration
+	DefaultTasksMax        string
+	DefaultLimitNOFILE     string
+
+	DefaultStartLimitIntervalSec time.Duration
+	DefaultStartLimitBurst       int
+	// Sources records the file and line each overridden setting came from.
+	Sources map[string]ConfigSource
 }
 
 // DefaultSystemConfig returns systemd's default system configuration.
@@ -51,6 +64,11 @@ func DefaultSystemConfig() *SystemConfig {
 		DefaultTimeoutStartSec: DefaultTimeoutStartSec,
 		DefaultTimeoutStopSec:  DefaultTimeoutStopSec,
 		DefaultRestartSec:      DefaultRestartSec,
+		DefaultTasksMax:        DefaultTasksMax,
+		DefaultLimitNOFILE:     DefaultLimitNOFILE,
+
+		DefaultStartLimitIntervalSec: DefaultStartLimitIntervalSec,
+		DefaultStartLimitBurst:       DefaultStartLimitBurst,
 	}
 }
 
@@ -148,8 +166,9 @@ func getDirectiveValue(section *types.Section, key string) string {
 	return ""
 }
 
-// ParseDuration parses a systemd time span into a Go duration.
-// Supports formats like: 5, 5s, 5min, 5h, 1h30min, "infinity"
+// ParseDuration parses a systemd time span into a Go duration, as written
+// in unit files or printed by systemd-analyze.
+// Supports formats like: 5, 5s, 5min, 5h, 1h30min, "1min 1.234s", 500µs, "infinity"
 func ParseDuration(s string) (time.Duration, error) {
 	s = strings.TrimSpace(s)
 
@@ -160,13 +179,18 @@ func ParseDuration(s string) (time.Duration, error) {
 
 	// If it's just a number, treat as seconds
 	if num, err := strconv.ParseFloat(s, 64); err == nil {
-		return time.Duration(num * float64(time.Second)), nil
+		return time.Duration(math.Round(num * float64(time.Second))), nil
 	}
 
 	// Parse complex duration strings
 	return parseSystemdTimeSpan(s)
 }
 
+// timeSpanRe matches a number and its unit in a systemd time span. Longer
+// units come first, so that "5min" is not read as 5m and "in". Units are
+// case-sensitive: "m" is a minute and "M" a month.
+var timeSpanRe = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(nsec|ns|usec|us|µs|μs|msec|ms|seconds?|sec|s|minutes?|min|months?|m|hours?|hr|h|days?|d|weeks?|w|M|years?|y)?`)
+
 // parseSystemdTimeSpan parses systemd time span format.
 // Examples: 5s, 5min, 5h, 1h30min, 5us, 5ms
 func parseSystemdTimeSpan(s string) (time.Duration, error) {
@@ -177,10 +201,7 @@ func parseSystemdTimeSpan(s string) (time.Duration, error) {
 
 	var total time.Duration
 
-	// Pattern to match number followed by unit
-	re := regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(usec|us|msec|ms|seconds?|sec|s|minutes?|min|m|hours?|hr|h|days?|d|weeks?|w|months?|M|years?|y)?`)
-
-	matches := re.FindAllStringSubmatch(s, -1)
+	matches := timeSpanRe.FindAllStringSubmatch(s, -1)
 	if len(matches) == 0 {
 		// Try parsing as Go duration
 		return time.ParseDuration(s)
@@ -203,8 +224,10 @@ func parseSystemdTimeSpan(s string) (time.Duration, error) {
 		}
 
 		var multiplier time.Duration
-		switch strings.ToLower(unit) {
-		case "usec", "us":
+		switch unit {
+		case "nsec", "ns":
+			multiplier = time.Nanosecond
+		case "usec", "us", "µs", "μs":
 			multiplier = time.Microsecond
 		case "msec", "ms":
 			multiplier = time.Millisecond
@@ -218,7 +241,7 @@ func parseSystemdTimeSpan(s string) (time.Duration, error) {
 			multiplier = 24 * time.Hour
 		case "weeks", "week", "w":
 			multiplier = 7 * 24 * time.Hour
-		case "months", "month":
+		case "months", "month", "M":
 			// Approximate month as 30 days
 			multiplier = 30 * 24 * time.Hour
 		case "years", "year", "y":
@@ -228,7 +251,8 @@ func parseSystemdTimeSpan(s string) (time.Duration, error) {
 			multiplier = time.Second
 		}
 
-		total += time.Duration(num * float64(multiplier))
+		// Rounded, so that 45.234s is 45234ms rather than a nanosecond less
+		total += time.Duration(math.Round(num * float64(multiplier)))
 	}
 
 	return total, nil
@@ -29,6 +29,18 @@ func TestParseDuration(t *testing.T) {
 		{"infinity", 0, false},
 		{"0", 0, false},
 		{"", 0, false},
+		// As systemd-analyze prints them
+		{"45.234s", 45234 * time.Millisecond, false},
+		{"2.5s", 2500 * time.Millisecond, false},
+		{"123ms", 123 * time.Millisecond, false},
+		{"1min 2.345s", time.Minute + 2345*time.Millisecond, false},
+		{"1min 1.234s", time.Minute + 1234*time.Millisecond, false},
+		{"1h 2min 3.456s", time.Hour + 2*time.Minute + 3456*time.Millisecond, false},
+		{"500us", 500 * time.Microsecond, false},
+		{"500µs", 500 * time.Microsecond, false},
+		{"1month", 30 * 24 * time.Hour, false},
+		{"1M", 30 * 24 * time.Hour, false},
+		{"soon", 0, true},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,198 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/supabase/sdaudit/internal/baseline"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// ackState is the state of acknowledging issues: the baseline they go into,
+// the issues waiting for a reason, and the confirmation to write the
+// baseline on quit
+type ackState struct {
+	baseline *baseline.Baseline
+	// path is where the baseline is written, "" when issues cannot be
+	// acknowledged
+	path string
+	// dirty is set once the baseline differs from the file
+	dirty bool
+	// pending are the issues the reason prompt acknowledges
+	pending []types.Issue
+	reason  textinput.Model
+	// confirming is set while asking whether to write the baseline on quit
+	confirming bool
+	// exists is set when the write replaces a file
+	exists bool
+	// err says why the baseline could not be written
+	err string
+}
+
+func newAckState(b *baseline.Baseline, path string) ackState {
+	if b == nil {
+		b = baseline.New()
+	}
+	reason := textinput.New()
+	reason.Placeholder = "optional"
+	reason.CharLimit = 200
+	return ackState{baseline: b, path: path, reason: reason}
+}
+
+// prompting reports whether the reason prompt is open
+func (a ackState) prompting() bool {
+	return len(a.pending) > 0
+}
+
+// acknowledged counts the issues of the scan that are in the baseline
+func (m Model) acknowledged() int {
+	n := 0
+	for _, issue := range m.result.Issues {
+		if m.ack.baseline.Contains(issue) {
+			n++
+		}
+	}
+	return n
+}
+
+// startAck acknowledges the selected issue, or with all the issues of its
+// rule in its unit, after asking for a reason. An acknowledged issue is taken
+// back instead.
+func (m *Model) startAck(all bool) tea.Cmd {
+	item, ok := m.issueList.SelectedItem().(IssueItem)
+	if !ok {
+		return nil
+	}
+	if m.ack.path == "" {
+		return m.issueList.NewStatusMessage("Start with --baseline FILE to acknowledge issues")
+	}
+
+	if !all {
+		if item.acked {
+			m.ack.baseline.Remove(item.issue)
+			m.ack.dirty = true
+			return m.applyFilter()
+		}
+		m.ack.pending = []types.Issue{item.issue}
+	} else {
+		for _, issue := range m.result.Issues {
+			if issue.RuleID == item.issue.RuleID && issue.Unit == item.issue.Unit && !m.ack.baseline.Contains(issue) {
+				m.ack.pending = append(m.ack.pending, issue)
+			}
+		}
+		if len(m.ack.pending) == 0 {
+			return m.issueList.NewStatusMessage(fmt.Sprintf("All %s issues of %s are acknowledged", item.issue.RuleID, item.issue.Unit))
+		}
+	}
+
+	m.ack.reason.Reset()
+	m.ack.reason.Prompt = fmt.Sprintf("Reason for acknowledging %s: ", pluralIssues(len(m.ack.pending)))
+	return m.ack.reason.Focus()
+}
+
+// updateAckPrompt handles keys while the reason prompt is open: enter
+// acknowledges the pending issues, esc gives up, anything else is the reason
+func (m Model) updateAckPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		for _, issue := range m.ack.pending {
+			m.ack.baseline.Add(issue, m.ack.reason.Value())
+		}
+		m.ack.pending = nil
+		m.ack.dirty = true
+		m.ack.reason.Blur()
+		return m, m.applyFilter()
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.ack.pending = nil
+		m.ack.reason.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.ack.reason, cmd = m.ack.reason.Update(msg)
+	return m, cmd
+}
+
+// confirmQuit asks whether to write the baseline before quitting
+func (m *Model) confirmQuit() {
+	_, err := os.Stat(m.ack.path)
+	m.ack.exists = err == nil
+	m.ack.confirming = true
+	m.ack.err = ""
+}
+
+// updateConfirmQuit handles keys while asking whether to write the baseline:
+// y writes it and quits, n quits without writing, esc stays
+func (m Model) updateConfirmQuit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.ack.confirming = false
+		if err := m.ack.baseline.Save(m.ack.path); err != nil {
+			m.ack.err = err.Error()
+			return m, nil
+		}
+		m.ack.dirty = false
+		m.quitting = true
+		return m, tea.Quit
+	case "n", "N":
+		m.quitting = true
+		return m, tea.Quit
+	case "esc":
+		m.ack.confirming = false
+	}
+	return m, nil
+}
+
+// viewAck renders the reason prompt and the quit confirmation below the
+// current view
+func (m Model) viewAck() string {
+	switch {
+	case m.ack.confirming:
+		verb := "Write"
+		if m.ack.exists {
+			verb = "Overwrite"
+		}
+		question := fmt.Sprintf("%s %s with %s acknowledged?", verb, m.ack.path, pluralIssues(m.ack.baseline.Len()))
+		return "\n\n" + m.styles.Bold.Render(m.styles.Glyphs.Text(question)) + "  " + m.styles.HelpBar.UnsetMarginTop().Render("[y]es  [n]o, quit without saving  [esc] cancel")
+	case m.ack.prompting() && m.view == ViewIssues:
+		return "\n\n" + m.ack.reason.View()
+	case m.ack.err != "":
+		return "\n\n" + m.styles.SeverityHigh.Render(m.styles.Glyphs.Text(m.ack.err))
+	}
+	return ""
+}
+
+// pluralIssues returns "1 issue" or "n issues"
+func pluralIssues(n int) string {
+	if n == 1 {
+		return "1 issue"
+	}
+	return fmt.Sprintf("%d issues", n)
+}
+
+// issueDelegate draws acknowledged issues dimmed
+type issueDelegate struct {
+	list.DefaultDelegate
+	dimmed list.DefaultDelegate
+}
+
+func newIssueDelegate() issueDelegate {
+	d := issueDelegate{DefaultDelegate: list.NewDefaultDelegate(), dimmed: list.NewDefaultDelegate()}
+	s := &d.dimmed.Styles
+	s.NormalTitle, s.NormalDesc = s.DimmedTitle, s.DimmedDesc
+	s.SelectedTitle = s.SelectedTitle.Faint(true)
+	s.SelectedDesc = s.SelectedDesc.Faint(true)
+	return d
+}
+
+func (d issueDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if issue, ok := item.(IssueItem); ok && issue.acked {
+		d.dimmed.Render(w, m, index, item)
+		return
+	}
+	d.DefaultDelegate.Render(w, m, index, item)
+}
@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/supabase/sdaudit/internal/baseline"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func ackTestIssues() []types.Issue {
+	return []types.Issue{
+		{RuleID: "SEC001", RuleName: "NoNewPrivileges not set", Severity: types.SeverityHigh, Unit: "app.service", Description: "first", Directive: "NoNewPrivileges"},
+		{RuleID: "SEC001", RuleName: "NoNewPrivileges not set", Severity: types.SeverityHigh, Unit: "app.service", Description: "second", Directive: "User"},
+		{RuleID: "SEC001", RuleName: "NoNewPrivileges not set", Severity: types.SeverityHigh, Unit: "db.service", Description: "other unit"},
+	}
+}
+
+// ackModel opens the issues view of ackTestIssues with a baseline at path
+func ackModel(t *testing.T, acked *baseline.Baseline, path string) (*Model, func(tea.KeyMsg) tea.Cmd) {
+	t.Helper()
+	result := makeResult()
+	result.Issues = ackTestIssues()
+	return issuesView(t, Input{Result: result, Baseline: acked, BaselinePath: path})
+}
+
+func TestAckIssue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	m, press := ackModel(t, nil, path)
+
+	press(runes("a"))
+	if !m.ack.prompting() {
+		t.Fatal("a did not ask for a reason")
+	}
+	for _, r := range "test box" {
+		press(runes(string(r)))
+	}
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if item := m.issueList.Items()[0].(IssueItem); !item.acked || !strings.HasSuffix(item.Title(), "(acknowledged)") {
+		t.Errorf("first issue not shown acknowledged: %q", item.Title())
+	}
+	if item := m.issueList.Items()[1].(IssueItem); item.acked {
+		t.Error("a acknowledged another issue")
+	}
+
+	press(runes("q"))
+	if m.quitting || !m.ack.confirming {
+		t.Fatal("q quit without asking to write the baseline")
+	}
+	if out := m.View(); !strings.Contains(out, "Write "+path+" with 1 issue acknowledged?") {
+		t.Errorf("confirmation missing:\n%s", out)
+	}
+	if cmd := press(runes("y")); cmd == nil || !m.quitting {
+		t.Fatal("y did not quit")
+	}
+
+	saved, err := baseline.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := saved.Lookup(ackTestIssues()[0])
+	if saved.Len() != 1 || !ok || entry.Reason != "test box" {
+		t.Errorf("saved %+v", saved.Entries())
+	}
+}
+
+func TestAckAllOfRuleInUnit(t *testing.T) {
+	m, press := ackModel(t, nil, filepath.Join(t.TempDir(), "baseline.json"))
+
+	press(runes("A"))
+	if got := len(m.ack.pending); got != 2 {
+		t.Fatalf("A acknowledges %d issues, want the 2 of SEC001 in app.service", got)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.acknowledged() != 2 || m.ack.baseline.Contains(ackTestIssues()[2]) {
+		t.Errorf("acknowledged %+v", m.ack.baseline.Entries())
+	}
+}
+
+func TestAckLoadedBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	acked := baseline.New()
+	acked.Add(ackTestIssues()[0], "known")
+	if err := acked.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+
+	m, press := ackModel(t, acked, path)
+	if !m.issueList.Items()[0].(IssueItem).acked {
+		t.Error("issue in the loaded baseline is not shown acknowledged")
+	}
+	m.view = ViewDashboard
+	if out := m.View(); !strings.Contains(out, "Acknowledged:  1") {
+		t.Errorf("dashboard does not count the acknowledged issue:\n%s", out)
+	}
+	m.view = ViewIssues
+
+	// a on an acknowledged issue takes it back
+	press(runes("a"))
+	if m.ack.prompting() || m.acknowledged() != 0 {
+		t.Errorf("a did not take back the acknowledgement")
+	}
+
+	press(runes("q"))
+	if out := m.View(); !strings.Contains(out, "Overwrite "+path) {
+		t.Errorf("confirmation does not say the file is replaced:\n%s", out)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.quitting || m.ack.confirming {
+		t.Error("esc did not cancel quitting")
+	}
+	press(runes("q"))
+	press(runes("n"))
+	if !m.quitting {
+		t.Error("n did not quit")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Errorf("n changed the baseline:\n%s", after)
+	}
+}
+
+func TestAckWithoutBaselinePath(t *testing.T) {
+	m, press := ackModel(t, nil, "")
+
+	press(runes("a"))
+	if m.ack.prompting() || m.acknowledged() != 0 {
+		t.Error("acknowledged an issue without a baseline file")
+	}
+	if cmd := press(runes("q")); cmd == nil || !m.quitting {
+		t.Error("q asked to write a baseline that was not changed")
+	}
+}
@@ -2,13 +2,21 @@ package tui
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 
 	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/baseline"
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/propagation"
+	"github.com/supabase/sdaudit/pkg/audit"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -19,7 +27,11 @@ const (
 	ViewDashboard View = iota
 	ViewIssues
 	ViewUnitDetail
+	ViewImpact
 	ViewHelp
+	ViewDependencies
+	ViewBoot
+	ViewSecurity
 )
 
 // Model is the main application model
@@ -31,14 +43,49 @@ type Model struct {
 	height    int
 	issueList list.Model
 	quitting  bool
+	// graph is the dependency graph of the scanned units, nil when the scan
+	// ran without it
+	graph  *graph.Graph
+	impact *propagation.UnitImpact
+	// filter selects the issues listed in the issues view
+	filter IssueFilter
+	// order is the order the issues view lists them in
+	order types.IssueOrder
+	// detail is the state of the issue detail view
+	detail unitDetail
+	// deps scrolls the dependency panel of depsUnit
+	deps     viewport.Model
+	depsUnit string
+	// ack is the state of acknowledging issues into the baseline
+	ack ackState
+	// export is the state of exporting the issues list to a file
+	export exportState
+	// The boot and security tabs, loaded with loadBoot and loadSecurity
+	// when first opened
+	bootTab      lazyTab
+	boot         *audit.BootAnalysis
+	loadBoot     func() (*audit.BootAnalysis, error)
+	securityTab  lazyTab
+	scores       []audit.SecurityScore
+	loadSecurity func() ([]audit.SecurityScore, error)
+	spinner      spinner.Model
+	// recheck checks a unit file again after it is edited
+	recheck func(path string, units map[string]*types.UnitFile) (*audit.Recheck, error)
+	// status is the outcome of the last edit, shown until the next key
+	status string
 }
 
 // IssueItem represents an issue in the list
 type IssueItem struct {
 	issue types.Issue
+	// acked is set for issues in the baseline, which are drawn dimmed
+	acked bool
 }
 
 func (i IssueItem) Title() string {
+	if i.acked {
+		return fmt.Sprintf("[%s] %s (acknowledged)", i.issue.RuleID, i.issue.RuleName)
+	}
 	return fmt.Sprintf("[%s] %s", i.issue.RuleID, i.issue.RuleName)
 }
 
@@ -59,9 +106,27 @@ type KeyMap struct {
 	Dashboard key.Binding
 	Issues    key.Binding
 	Filter    key.Binding
+	Impact    key.Binding
+	Deps      key.Binding
+	Boot      key.Binding
+	Scores    key.Binding
 	Rescan    key.Binding
 	Help      key.Binding
 	Quit      key.Binding
+
+	// Filter toggles of the issues view
+	Severity     key.Binding
+	Security     key.Binding
+	Performance  key.Binding
+	Reliability  key.Binding
+	BestPractice key.Binding
+	// Acknowledging issues in the issues view
+	Ack    key.Binding
+	AckAll key.Binding
+	Export key.Binding
+	Sort   key.Binding
+	// Editing the unit file in the issue detail view
+	Edit key.Binding
 }
 
 var keys = KeyMap{
@@ -93,6 +158,22 @@ var keys = KeyMap{
 		key.WithKeys("/"),
 		key.WithHelp("/", "filter"),
 	),
+	Impact: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "failure impact"),
+	),
+	Deps: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "dependencies"),
+	),
+	Boot: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "boot"),
+	),
+	Scores: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "security scores"),
+	),
 	Rescan: key.NewBinding(
 		key.WithKeys("r"),
 		key.WithHelp("r", "rescan"),
@@ -105,29 +186,244 @@ var keys = KeyMap{
 		key.WithKeys("q", "ctrl+c"),
 		key.WithHelp("q", "quit"),
 	),
+	Severity: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "min severity"),
+	),
+	Security: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "security"),
+	),
+	Performance: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "performance"),
+	),
+	Reliability: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reliability"),
+	),
+	BestPractice: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "best practice"),
+	),
+	Ack: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "acknowledge"),
+	),
+	AckAll: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "acknowledge rule in unit"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "sort"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "edit unit file"),
+	),
 }
 
-// New creates a new TUI model with the given scan result
-func New(result *analyzer.ScanResult) Model {
-	styles := DefaultStyles()
+// categoryKeys maps the category toggles of the issues view to categories
+var categoryKeys = []struct {
+	binding  *key.Binding
+	category types.Category
+}{
+	{&keys.Security, types.CategorySecurity},
+	{&keys.Performance, types.CategoryPerformance},
+	{&keys.Reliability, types.CategoryReliability},
+	{&keys.BestPractice, types.CategoryBestPractice},
+}
 
-	// Create issue list
-	items := make([]list.Item, len(result.Issues))
-	for i, issue := range result.Issues {
-		items[i] = IssueItem{issue: issue}
+// Options configures the TUI
+type Options struct {
+	// ASCII selects the high-contrast styles, which are mono, and draws with
+	// ASCII only
+	ASCII bool
+	// Theme selects the colors; ThemeAuto follows the terminal's background
+	Theme Theme
+	// NoColor selects the mono theme whatever Theme says
+	NoColor bool
+}
+
+// Input is what the TUI shows
+type Input struct {
+	Result *analyzer.ScanResult
+	// Graph is the dependency graph of the scanned units. The failure impact
+	// and dependency views say it is unavailable when it is nil.
+	Graph *graph.Graph
+	// Baseline holds the acknowledged issues, which are shown dimmed. It is
+	// written to BaselinePath on quit when issues were acknowledged; without
+	// a path issues cannot be acknowledged.
+	Baseline     *baseline.Baseline
+	BaselinePath string
+	// Boot and Security load the boot and security tabs when they are first
+	// opened. The tabs say they are unavailable when these are nil.
+	Boot     func() (*audit.BootAnalysis, error)
+	Security func() ([]audit.SecurityScore, error)
+	// Recheck checks a unit file again after it is edited from the issue
+	// detail view, with the scanned units. Unit files cannot be edited when
+	// it is nil.
+	Recheck func(path string, units map[string]*types.UnitFile) (*audit.Recheck, error)
+}
+
+// ScanGraph builds Input.Graph from the scanned units. unitPaths, the unit
+// search path of a system scan, add the .wants/ symlinks and aliases found
+// in it; without them the graph has the units' directives only.
+func ScanGraph(units map[string]*types.UnitFile, unitPaths []string) *graph.Graph {
+	if len(unitPaths) == 0 {
+		return graph.Build(units)
 	}
+	return analyzer.New(analyzer.Options{UnitPaths: unitPaths}).BuildGraph(units)
+}
 
-	delegate := list.NewDefaultDelegate()
-	issueList := list.New(items, delegate, 0, 0)
-	issueList.Title = "Issues"
+// New creates a new TUI model for a scan
+func New(in Input, opts Options) Model {
+	result := in.Result
+	styles := StylesFor(opts.theme())
+	if opts.ASCII {
+		styles = HighContrastStyles()
+	}
+
+	// The default delegate draws in its own colors
+	var delegate list.ItemDelegate = newIssueDelegate()
+	if styles.Theme == ThemeMono {
+		delegate = plainDelegate{styles: styles}
+	}
+	issueList := list.New(nil, delegate, 0, 0)
 	issueList.SetShowStatusBar(true)
 	issueList.SetFilteringEnabled(true)
+	issueList.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.Severity, keys.Security, keys.Performance, keys.Reliability, keys.BestPractice, keys.Sort, keys.Ack, keys.AckAll, keys.Export}
+	}
+	if styles.Theme == ThemeMono {
+		issueList.Styles.Title = styles.Title
+	}
+	if opts.ASCII {
+		asciiList(&issueList, styles)
+	}
+
+	spin := spinner.New(spinner.WithSpinner(spinner.Dot))
+	if opts.ASCII {
+		spin.Spinner = spinner.Line
+	}
+
+	m := Model{
+		result:       result,
+		styles:       styles,
+		view:         ViewDashboard,
+		issueList:    issueList,
+		graph:        in.Graph,
+		ack:          newAckState(in.Baseline, in.BaselinePath),
+		export:       newExportState(),
+		bootTab:      newLazyTab(),
+		loadBoot:     in.Boot,
+		securityTab:  newLazyTab(),
+		loadSecurity: in.Security,
+		spinner:      spin,
+		recheck:      in.Recheck,
+	}
+	m.applyFilter()
+	return m
+}
+
+// applyFilter rebuilds the issue list from the issues that pass the filter,
+// in the chosen order, and names the active filters and the order in its
+// title and the total in its status bar. The selected issue stays selected
+// if it is still listed.
+func (m *Model) applyFilter() tea.Cmd {
+	selected, hadSelection := m.issueList.SelectedItem().(IssueItem)
+
+	issues := types.SortIssues(FilterIssues(m.result.Issues, m.filter), m.order)
+	items := make([]list.Item, len(issues))
+	index := -1
+	for i, issue := range issues {
+		items[i] = IssueItem{issue: issue, acked: m.ack.baseline.Contains(issue)}
+		if hadSelection && index < 0 && sameIssue(issue, selected.issue) {
+			index = i
+		}
+	}
+
+	m.issueList.Title = "Issues"
+	if label := m.filter.Label(); label != "" {
+		m.issueList.Title += " " + m.styles.Glyphs.Text(label)
+	}
+	if m.order != types.OrderScan {
+		m.issueList.Title += " by " + m.order.String()
+	}
+	switch {
+	case !m.filter.Active():
+		m.issueList.SetStatusBarItemName("issue", "issues")
+	case len(issues) == 0:
+		m.issueList.SetStatusBarItemName("issue", "issues match the filters")
+	default:
+		total := fmt.Sprintf("of %d issues", len(m.result.Issues))
+		m.issueList.SetStatusBarItemName(total, total)
+	}
+	cmd := m.issueList.SetItems(items)
+	// With a search the list indexes the matches, which it filters later
+	if index >= 0 && !m.issueList.IsFiltered() {
+		m.issueList.Select(index)
+	}
+	return cmd
+}
+
+// plainDelegate draws issue list items in the styles given, without color of
+// its own, Unicode borders or ellipses
+type plainDelegate struct {
+	styles Styles
+}
+
+func (d plainDelegate) Height() int                               { return 2 }
+func (d plainDelegate) Spacing() int                              { return 1 }
+func (d plainDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
 
-	return Model{
-		result:    result,
-		styles:    styles,
-		view:      ViewDashboard,
-		issueList: issueList,
+func (d plainDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	issue, ok := item.(IssueItem)
+	if !ok {
+		return
+	}
+
+	itemStyle, marker := d.styles.ListItem, "  "
+	if issue.acked {
+		itemStyle = d.styles.Muted
+	}
+	if index == m.Index() {
+		itemStyle, marker = d.styles.ListItemSelected, "> "
+	}
+	width := m.Width() - 4
+	title := truncateASCII(d.styles.Glyphs.Text(issue.Title()), width)
+	desc := truncateASCII(d.styles.Glyphs.Text(issue.Description()), width)
+	_, _ = fmt.Fprint(w, itemStyle.Render(marker+title)+"\n"+itemStyle.Render("  "+desc))
+}
+
+// truncateASCII shortens s to width characters, ending in "..." when cut
+func truncateASCII(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+// asciiList replaces the list's Unicode bullets and arrows with ASCII
+func asciiList(l *list.Model, styles Styles) {
+	l.Styles.ActivePaginationDot = styles.Bold.SetString("*")
+	l.Styles.InactivePaginationDot = styles.Muted.SetString(".")
+	l.Styles.DividerDot = styles.Muted.SetString(" - ")
+	l.Paginator.ActiveDot = l.Styles.ActivePaginationDot.String()
+	l.Paginator.InactiveDot = l.Styles.InactivePaginationDot.String()
+	l.Help.ShortSeparator = " - "
+	l.Help.FullSeparator = "   "
+	for _, b := range []*key.Binding{&l.KeyMap.CursorUp, &l.KeyMap.CursorDown, &l.KeyMap.PrevPage, &l.KeyMap.NextPage} {
+		b.SetHelp(styles.Glyphs.Text(b.Help().Key), b.Help().Desc)
 	}
 }
 
@@ -143,16 +439,102 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.issueList.SetSize(msg.Width-4, msg.Height-8)
+		m.layoutDetail()
+		m.layoutDeps()
+		m.layoutTabs()
 		return m, nil
 
+	case spinner.TickMsg:
+		// The spinner stops once nothing is loading
+		if !m.bootTab.loading && !m.securityTab.loading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case bootLoadedMsg:
+		m.boot, m.bootTab.err = msg.analysis, msg.err
+		m.bootTab.loading, m.bootTab.loaded = false, true
+		m.layoutTabs()
+		return m, nil
+
+	case securityLoadedMsg:
+		m.scores, m.securityTab.err = msg.scores, msg.err
+		m.securityTab.loading, m.securityTab.loaded = false, true
+		m.layoutTabs()
+		return m, nil
+
+	case editorClosedMsg:
+		return m, m.editorClosed(msg)
+
+	case recheckedMsg:
+		return m, m.rechecked(msg)
+
 	case tea.KeyMsg:
+		m.status = ""
+
+		// The quit confirmation and the reason prompt take every key
+		if m.ack.confirming {
+			return m.updateConfirmQuit(msg)
+		}
+		if m.ack.prompting() {
+			return m.updateAckPrompt(msg)
+		}
+		if m.export.prompting {
+			return m.updateExport(msg)
+		}
+
+		// Keys typed into the list's search box are text, not commands
+		if m.view == ViewIssues && m.issueList.FilterState() == list.Filtering {
+			var cmd tea.Cmd
+			m.issueList, cmd = m.issueList.Update(msg)
+			return m, cmd
+		}
+
+		if m.view == ViewIssues {
+			if key.Matches(msg, keys.Severity) {
+				m.filter = m.filter.CycleSeverity()
+				return m, m.applyFilter()
+			}
+			if key.Matches(msg, keys.Sort) {
+				m.order = m.order.Next()
+				return m, m.applyFilter()
+			}
+			for _, c := range categoryKeys {
+				if key.Matches(msg, *c.binding) {
+					m.filter = m.filter.ToggleCategory(c.category)
+					return m, m.applyFilter()
+				}
+			}
+			if key.Matches(msg, keys.Ack) || key.Matches(msg, keys.AckAll) {
+				return m, m.startAck(key.Matches(msg, keys.AckAll))
+			}
+			if key.Matches(msg, keys.Export) {
+				return m, m.startExport()
+			}
+		}
+
 		switch {
 		case key.Matches(msg, keys.Quit):
+			// Acknowledgements are written only once the user confirms
+			if m.ack.dirty {
+				m.confirmQuit()
+				return m, nil
+			}
 			m.quitting = true
 			return m, tea.Quit
 
 		case key.Matches(msg, keys.Back):
-			if m.view != ViewDashboard {
+			// Esc clears the filters before it leaves the issues view
+			if m.view == ViewIssues && (m.filter.Active() || m.issueList.IsFiltered()) {
+				m.filter = IssueFilter{}
+				m.issueList.ResetFilter()
+				return m, m.applyFilter()
+			}
+			if m.view == ViewImpact || m.view == ViewDependencies {
+				m.view = ViewUnitDetail
+			} else if m.view != ViewDashboard {
 				m.view = ViewDashboard
 			}
 			return m, nil
@@ -165,6 +547,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.view = ViewIssues
 			return m, nil
 
+		// b toggles best practice issues in the issues view, handled above
+		case key.Matches(msg, keys.Boot):
+			return m, m.openBoot()
+
+		case key.Matches(msg, keys.Scores):
+			return m, m.openSecurity()
+
 		case key.Matches(msg, keys.Help):
 			if m.view == ViewHelp {
 				m.view = ViewDashboard
@@ -175,7 +564,42 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, keys.Enter):
 			if m.view == ViewIssues {
-				m.view = ViewUnitDetail
+				if item, ok := m.issueList.SelectedItem().(IssueItem); ok {
+					m.openDetail(item.issue)
+					m.view = ViewUnitDetail
+				}
+			}
+			return m, nil
+
+		case m.view == ViewUnitDetail && key.Matches(msg, keys.Up):
+			m.selectDetail(-1)
+			return m, nil
+
+		case m.view == ViewUnitDetail && key.Matches(msg, keys.Down):
+			m.selectDetail(1)
+			return m, nil
+
+		case key.Matches(msg, keys.Impact):
+			if m.view == ViewUnitDetail {
+				if issue, ok := m.detail.issue(); ok {
+					m.impact = nil
+					m.view = ViewImpact
+					if m.graph == nil {
+						return m, nil
+					}
+					impact := propagation.AnalyzeUnit(m.graph, issue.Unit, propagation.ScenarioFail, propagation.ScenarioStop)
+					m.impact = &impact
+				}
+				return m, nil
+			}
+
+		case m.view == ViewUnitDetail && key.Matches(msg, keys.Edit):
+			return m, m.startEdit()
+
+		case m.view == ViewUnitDetail && key.Matches(msg, keys.Deps):
+			if issue, ok := m.detail.issue(); ok {
+				m.openDeps(issue.Unit)
+				m.view = ViewDependencies
 			}
 			return m, nil
 		}
@@ -188,6 +612,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	// Scroll the file in the issue detail view
+	if m.view == ViewUnitDetail {
+		var cmd tea.Cmd
+		m.detail.source, cmd = m.detail.source.Update(msg)
+		return m, cmd
+	}
+
+	// Scroll the dependency panel
+	if m.view == ViewDependencies {
+		var cmd tea.Cmd
+		m.deps, cmd = m.deps.Update(msg)
+		return m, cmd
+	}
+
+	// Scroll the boot and security tabs
+	if m.view == ViewBoot && m.bootTab.loaded {
+		var cmd tea.Cmd
+		m.bootTab.view, cmd = m.bootTab.view.Update(msg)
+		return m, cmd
+	}
+	if m.view == ViewSecurity && m.securityTab.loaded {
+		var cmd tea.Cmd
+		m.securityTab.view, cmd = m.securityTab.view.Update(msg)
+		return m, cmd
+	}
+
 	return m, nil
 }
 
@@ -205,9 +655,18 @@ func (m Model) View() string {
 		content = m.viewIssues()
 	case ViewUnitDetail:
 		content = m.viewUnitDetail()
+	case ViewImpact:
+		content = m.viewImpact()
 	case ViewHelp:
 		content = m.viewHelp()
+	case ViewDependencies:
+		content = m.viewDeps()
+	case ViewBoot:
+		content = m.viewTab("Boot Times", m.bootTab)
+	case ViewSecurity:
+		content = m.viewTab("Security Scores", m.securityTab)
 	}
+	content += m.viewAck() + m.viewExport() + m.viewStatus()
 
 	return m.styles.App.Render(content)
 }
@@ -224,7 +683,24 @@ func (m Model) viewDashboard() string {
 	b.WriteString(m.styles.Title.Render("Scan Summary") + "\n")
 	b.WriteString(fmt.Sprintf("  Units scanned: %d\n", summary.TotalUnits))
 	b.WriteString(fmt.Sprintf("  Rules checked: %d\n", summary.RulesChecked))
-	b.WriteString(fmt.Sprintf("  Issues found:  %d\n\n", summary.TotalIssues))
+	if summary.FailedUnits > 0 {
+		b.WriteString("  Failed units:  " + m.styles.SeverityCritical.Render(fmt.Sprintf("%d", summary.FailedUnits)) + "\n")
+	}
+	if summary.ParseErrors > 0 {
+		b.WriteString("  Parse errors:  " + m.styles.SeverityHigh.Render(fmt.Sprintf("%d", summary.ParseErrors)) + "\n")
+	}
+	b.WriteString(fmt.Sprintf("  Issues found:  %d\n", summary.TotalIssues))
+	if len(m.result.Scores) > 0 {
+		b.WriteString(fmt.Sprintf("  Audit score:   %d/%d", summary.Score, types.ScoreMax))
+		if lowest := m.result.Scores[0]; lowest.Score < types.ScoreMax {
+			b.WriteString(fmt.Sprintf(" (lowest: %s, %d)", lowest.Unit, lowest.Score))
+		}
+		b.WriteString("\n")
+	}
+	if n := m.acknowledged(); n > 0 {
+		b.WriteString(fmt.Sprintf("  Acknowledged:  %d\n", n))
+	}
+	b.WriteString("\n")
 
 	// Severity breakdown with bars
 	b.WriteString(m.styles.Title.Render("Issues by Severity") + "\n")
@@ -255,10 +731,10 @@ func (m Model) viewDashboard() string {
 		}
 
 		sevStyle := m.styles.SeverityStyle(sev.String())
-		label := sevStyle.Render(fmt.Sprintf("  %-10s", strings.ToUpper(sev.String())))
+		label := m.styles.RenderSeverity(sev.String(), fmt.Sprintf("%-10s", strings.ToUpper(sev.String())))
 		countStr := fmt.Sprintf("%3d ", count)
-		bar := RenderBar(barWidth, barWidth, sevStyle)
-		b.WriteString(label + countStr + bar + "\n")
+		bar := m.styles.RenderBar(barWidth, barWidth, sevStyle)
+		b.WriteString("  " + label + countStr + bar + "\n")
 	}
 	b.WriteString("\n")
 
@@ -275,69 +751,102 @@ func (m Model) viewDashboard() string {
 		b.WriteString(fmt.Sprintf("  %-15s %d\n", cat.String(), count))
 	}
 
+	// Noisiest rules and units, side by side
+	if len(summary.ByRule) > 0 {
+		b.WriteString("\n" + lipgloss.JoinHorizontal(lipgloss.Top,
+			m.topTable("Top Rules", summary.ByRule), "    ",
+			m.topTable("Top Units", summary.ByUnit)))
+	}
+
 	// Help bar
-	b.WriteString("\n" + m.styles.HelpBar.Render("[i]ssues  [d]ashboard  [r]escan  [?]help  [q]uit"))
+	b.WriteString("\n" + m.styles.HelpBar.Render("[i]ssues  [d]ashboard  [b]oot  securi[t]y  [r]escan  [?]help  [q]uit"))
 
 	return b.String()
 }
 
+// dashboardTopMax is how many rules and units the dashboard lists as the
+// noisiest
+const dashboardTopMax = 5
+
+// topTable renders the largest counts under a title
+func (m Model) topTable(title string, counts map[string]int) string {
+	top := types.TopCounts(counts, dashboardTopMax)
+	width := 0
+	for _, c := range top {
+		width = max(width, len(c.Name))
+	}
+	lines := []string{m.styles.Title.Render(title)}
+	for _, c := range top {
+		lines = append(lines, fmt.Sprintf("  %-*s %3d", width, c.Name, c.Count))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (m Model) viewIssues() string {
 	return m.issueList.View()
 }
 
-func (m Model) viewUnitDetail() string {
+func (m Model) viewImpact() string {
 	var b strings.Builder
 
-	// Get selected issue
-	selectedItem := m.issueList.SelectedItem()
-	if selectedItem == nil {
-		b.WriteString("No issue selected\n")
+	if m.graph == nil {
+		b.WriteString("Dependency graph unavailable: the scan ran with --no-graph.\n")
 		b.WriteString("\n" + m.styles.HelpBar.Render("[esc] back"))
 		return b.String()
 	}
-
-	item := selectedItem.(IssueItem)
-	issue := item.issue
-
-	// Header
-	b.WriteString(m.styles.Title.Render("Issue Detail") + "\n\n")
-
-	// Issue info
-	sevStyle := m.styles.SeverityStyle(issue.Severity.String())
-	b.WriteString(fmt.Sprintf("Rule:     %s\n", m.styles.Bold.Render(issue.RuleID)))
-	b.WriteString(fmt.Sprintf("Name:     %s\n", issue.RuleName))
-	b.WriteString(fmt.Sprintf("Severity: %s\n", sevStyle.Render(strings.ToUpper(issue.Severity.String()))))
-	b.WriteString(fmt.Sprintf("Category: %s\n", issue.Category.String()))
-	b.WriteString(fmt.Sprintf("Unit:     %s\n", m.styles.Bold.Render(issue.Unit)))
-	b.WriteString(fmt.Sprintf("File:     %s\n", issue.File))
-	if issue.Line != nil {
-		b.WriteString(fmt.Sprintf("Line:     %d\n", *issue.Line))
+	if m.impact == nil {
+		b.WriteString("No unit selected\n")
+		b.WriteString("\n" + m.styles.HelpBar.Render("[esc] back"))
+		return b.String()
+	}
+	impact := m.impact
+	arrow := " " + m.styles.Glyphs.Text("→") + " "
+
+	b.WriteString(m.styles.Title.Render("Failure Impact") + "\n\n")
+	b.WriteString(fmt.Sprintf("Unit:     %s\n", m.styles.Bold.Render(impact.FailedUnit)))
+	b.WriteString(fmt.Sprintf("Affected: %d\n\n", impact.TotalAffected))
+
+	if len(impact.AffectedUnits) > 0 {
+		b.WriteString(m.styles.Title.Render("Affected Units") + "\n")
+		for _, a := range impact.AffectedUnits {
+			var actions []string
+			for _, impact := range a.Impacts() {
+				if impact == propagation.ImpactStop {
+					actions = append(actions, "stops")
+				} else {
+					actions = append(actions, "fails to start")
+				}
+			}
+			b.WriteString(fmt.Sprintf("  %s %s %s (%s=)\n", m.styles.RenderSeverity(a.Severity, fmt.Sprintf("%-8s", strings.ToUpper(a.Severity))), a.Name, strings.Join(actions, ", "), a.EdgeType))
+			b.WriteString("           " + m.styles.Muted.Render(strings.Join(a.PropagationPath, arrow)) + "\n")
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString("  No other units are affected.\n\n")
 	}
-	b.WriteString("\n")
-
-	// Description
-	b.WriteString(m.styles.Title.Render("Description") + "\n")
-	b.WriteString("  " + issue.Description + "\n\n")
 
-	// Suggestion
-	b.WriteString(m.styles.Title.Render("Suggestion") + "\n")
-	b.WriteString("  " + issue.Suggestion + "\n\n")
+	if len(impact.CriticalChain) > 0 {
+		b.WriteString(m.styles.Title.Render("Critical Chain") + "\n")
+		b.WriteString("  " + strings.Join(impact.CriticalChain, arrow) + "\n\n")
+	}
 
-	// References
-	if len(issue.References) > 0 {
-		b.WriteString(m.styles.Title.Render("References") + "\n")
-		for _, ref := range issue.References {
-			b.WriteString("  " + m.styles.Muted.Render(ref) + "\n")
+	if len(impact.SilentFailures) > 0 {
+		b.WriteString(m.styles.Title.Render("Silent Failures") + "\n")
+		for _, f := range impact.SilentFailures {
+			b.WriteString("  " + m.styles.Glyphs.Text(f.Description) + "\n")
 		}
+		b.WriteString("\n")
 	}
 
-	// Tags
-	if len(issue.Tags) > 0 {
-		b.WriteString("\n" + m.styles.Title.Render("Tags") + "\n")
-		b.WriteString("  " + strings.Join(issue.Tags, ", ") + "\n")
+	if len(impact.StopOrderInversions) > 0 {
+		b.WriteString(m.styles.Title.Render("Stop Order Inversions") + "\n")
+		for _, inv := range impact.StopOrderInversions {
+			b.WriteString("  " + m.styles.Glyphs.Text(inv.Description) + "\n")
+		}
+		b.WriteString("\n")
 	}
 
-	b.WriteString("\n" + m.styles.HelpBar.Render("[esc] back  [q]uit"))
+	b.WriteString(m.styles.HelpBar.Render("[esc] back  [q]uit"))
 
 	return b.String()
 }
@@ -357,13 +866,24 @@ func (m Model) viewHelp() string {
 		{"d", "Dashboard view"},
 		{"i", "Issues list"},
 		{"/", "Filter/search"},
+		{"c", "Cycle the minimum severity (issues list)"},
+		{"s/p/r/b", "Toggle security, performance, reliability, best practice (issues list)"},
+		{"o", "Sort by severity, unit, rule, category or scan order (issues list)"},
+		{"a", "Acknowledge the issue, or take it back (issues list)"},
+		{"A", "Acknowledge the rule's issues in the unit (issues list)"},
+		{"e", "Export the issues shown to a file (issues list)"},
+		{"f", "Failure impact of the issue's unit"},
+		{"g", "Dependencies and dependents of the issue's unit"},
+		{"E", "Edit the issue's unit file in $EDITOR and check it again (issue detail)"},
+		{"b", "Boot times and critical chain (outside the issues list)"},
+		{"t", "Security scores of the services"},
 		{"r", "Rescan"},
 		{"?", "Toggle help"},
 		{"q", "Quit"},
 	}
 
 	for _, item := range helpItems {
-		b.WriteString(fmt.Sprintf("  %-12s  %s\n", m.styles.Bold.Render(item.key), item.desc))
+		b.WriteString(fmt.Sprintf("  %-12s  %s\n", m.styles.Bold.Render(m.styles.Glyphs.Text(item.key)), item.desc))
 	}
 
 	b.WriteString("\n" + m.styles.HelpBar.Render("[esc] back  [q]uit"))
@@ -371,9 +891,14 @@ func (m Model) viewHelp() string {
 	return b.String()
 }
 
-// Run starts the TUI application
-func Run(result *analyzer.ScanResult) error {
-	p := tea.NewProgram(New(result), tea.WithAltScreen())
+// Run starts the TUI application. The theme follows the terminal's background
+// unless opts name one.
+func Run(in Input, opts Options) error {
+	opts.Theme = detectTheme(opts.theme())
+	if opts.Theme == ThemeMono {
+		useMonoProfile()
+	}
+	p := tea.NewProgram(New(in, opts), tea.WithAltScreen())
 	_, err := p.Run()
 	return err
 }
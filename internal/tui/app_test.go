@@ -0,0 +1,153 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func makeResult() *analyzer.ScanResult {
+	return &analyzer.ScanResult{
+		Issues: []types.Issue{{
+			RuleID:      "SEC001",
+			RuleName:    "NoNewPrivileges not set",
+			Severity:    types.SeverityCritical,
+			Category:    types.CategorySecurity,
+			Unit:        "test.service",
+			Description: "Service may gain privileges",
+			Suggestion:  "Add NoNewPrivileges=yes",
+			Refs:        []types.Reference{types.ManPage("systemd.exec", "NoNewPrivileges=")},
+		}},
+		Summary: analyzer.Summary{
+			TotalUnits:  1,
+			TotalIssues: 1,
+			BySeverity:  map[types.Severity]int{types.SeverityCritical: 1},
+			ByCategory:  map[types.Category]int{types.CategorySecurity: 1},
+			ByRule:      map[string]int{"SEC001": 1},
+			ByUnit:      map[string]int{"test.service": 1},
+		},
+	}
+}
+
+func nonASCII(s string) []rune {
+	var found []rune
+	for _, r := range s {
+		if r > 127 {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// issuesView opens the issues view of in, and returns it with a function
+// that sends it a key
+func issuesView(t *testing.T, in Input) (*Model, func(tea.KeyMsg) tea.Cmd) {
+	t.Helper()
+	m := new(Model)
+	*m = New(in, Options{ASCII: true})
+	m.width, m.height = 100, 40
+	m.issueList.SetSize(96, 32)
+	m.view = ViewIssues
+
+	press := func(k tea.KeyMsg) tea.Cmd {
+		t.Helper()
+		updated, cmd := m.Update(k)
+		*m = updated.(Model)
+		return cmd
+	}
+	return m, press
+}
+
+func runes(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+func TestViewsASCII(t *testing.T) {
+	m := New(Input{Result: makeResult()}, Options{ASCII: true})
+	m.width, m.height = 100, 40
+	m.issueList.SetSize(96, 32)
+
+	for _, view := range []View{ViewDashboard, ViewIssues, ViewUnitDetail, ViewImpact, ViewHelp, ViewDependencies} {
+		m.view = view
+		out := m.View()
+		if found := nonASCII(out); len(found) > 0 {
+			t.Errorf("view %d contains non-ASCII %q:\n%s", view, string(found), out)
+		}
+	}
+
+	m.view = ViewDashboard
+	if out := m.View(); !strings.Contains(out, "CRITICAL") || !strings.Contains(out, "#") {
+		t.Errorf("dashboard should name the severity and draw an ASCII bar:\n%s", out)
+	}
+}
+
+func TestViewsDefault(t *testing.T) {
+	m := New(Input{Result: makeResult()}, Options{})
+	if out := m.View(); !strings.Contains(out, "█") {
+		t.Errorf("default dashboard should draw block bars:\n%s", out)
+	}
+}
+
+// reverseDepsInput returns a scan of the reverse_deps fixtures with its one
+// issue on unit
+func reverseDepsInput(t *testing.T, unit string) Input {
+	t.Helper()
+	units, err := analyzer.LoadUnitsFromDirectory("../../testdata/graph/reverse_deps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := makeResult()
+	result.Issues[0].Unit = unit
+	for _, u := range units {
+		result.Units = append(result.Units, u)
+	}
+	return Input{Result: result, Graph: graph.Build(units)}
+}
+
+func TestImpactView(t *testing.T) {
+	m := New(reverseDepsInput(t, "db.service"), Options{ASCII: true})
+	m.view = ViewIssues
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	m = updated.(Model)
+	if m.view != ViewImpact {
+		t.Fatalf("view = %d, want the impact view", m.view)
+	}
+
+	out := m.View()
+	for _, want := range []string{"app.service fails to start", "sidecar.service stops", "backup.service uses Wants=db.service"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("impact view missing %q:\n%s", want, out)
+		}
+	}
+	if found := nonASCII(out); len(found) > 0 {
+		t.Errorf("impact view contains non-ASCII %q", string(found))
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if view := updated.(Model).view; view != ViewUnitDetail {
+		t.Errorf("esc from the impact view went to %d, want the issue detail", view)
+	}
+}
+
+func TestTruncateASCII(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"a longer title", 8, "a lon..."},
+		{"abcdef", 2, "ab"},
+		{"anything", 0, "anything"},
+	}
+	for _, tt := range tests {
+		if got := truncateASCII(tt.in, tt.width); got != tt.want {
+			t.Errorf("truncateASCII(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
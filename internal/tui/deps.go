@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/propagation"
+)
+
+// depsEdgeTypes are the dependencies the dependency panel lists, in order
+var depsEdgeTypes = []graph.EdgeType{graph.EdgeRequires, graph.EdgeBindsTo, graph.EdgeWants, graph.EdgeAfter}
+
+// depsKeys scroll the dependency panel. Unlike the file of the detail view,
+// up and down scroll it as there is nothing to select.
+var depsKeys = viewport.KeyMap{
+	PageDown:     sourceKeys.PageDown,
+	PageUp:       sourceKeys.PageUp,
+	HalfPageDown: sourceKeys.HalfPageDown,
+	HalfPageUp:   sourceKeys.HalfPageUp,
+	Up:           key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:         key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Left:         key.NewBinding(key.WithDisabled()),
+	Right:        key.NewBinding(key.WithDisabled()),
+}
+
+// openDeps shows the dependency panel of a unit
+func (m *Model) openDeps(unit string) {
+	m.deps = viewport.New(0, minSourceHeight)
+	m.deps.KeyMap = depsKeys
+	m.depsUnit = unit
+	m.layoutDeps()
+}
+
+// layoutDeps gives the dependency panel the lines between its title and the
+// help bar and renders it at the view's width
+func (m *Model) layoutDeps() {
+	if m.depsUnit == "" {
+		return
+	}
+	if m.height > 0 {
+		// The app's padding, the title and the help bar
+		m.deps.Height = max(minSourceHeight, m.height-6)
+	}
+	m.deps.Width = max(m.width-4, 20)
+
+	lines := strings.Split(m.renderDeps(), "\n")
+	for i, line := range lines {
+		lines[i] = truncateASCII(line, m.deps.Width)
+	}
+	m.deps.SetContent(strings.Join(lines, "\n"))
+}
+
+// renderDeps lists the direct dependencies of the panel's unit, the units
+// that depend on it and what happens to them when it fails
+func (m Model) renderDeps() string {
+	if m.graph == nil {
+		return "Dependency graph unavailable: the scan ran with --no-graph."
+	}
+	unit := m.graph.Resolve(m.depsUnit)
+	arrow := " " + m.styles.Glyphs.Text("→") + " "
+
+	var b strings.Builder
+
+	b.WriteString(m.styles.Title.Render("Dependencies") + "\n")
+	b.WriteString(m.renderEdges(m.graph.EdgesFrom(unit), func(e graph.Edge) string { return e.To }))
+
+	b.WriteString("\n" + m.styles.Title.Render("Dependents") + "\n")
+	b.WriteString(m.renderEdges(m.graph.EdgesTo(unit), func(e graph.Edge) string { return e.From }))
+
+	impact := propagation.SimulateFailure(m.graph, unit)
+	b.WriteString("\n" + m.styles.Title.Render("Failure Impact") + "\n")
+	if impact.TotalAffected == 0 {
+		b.WriteString("  No other units are affected when " + unit + " fails.\n")
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+	// A unit may both fail to start and be stopped
+	failing, stopping := 0, 0
+	for _, a := range impact.AffectedUnits {
+		if _, ok := a.Paths[propagation.ImpactStop]; ok {
+			stopping++
+		}
+		if _, ok := a.Paths[propagation.ImpactFailToStart]; ok {
+			failing++
+		}
+	}
+	b.WriteString(fmt.Sprintf("  %d units affected when %s fails: %d fail to start, %d stop\n", impact.TotalAffected, unit, failing, stopping))
+	if len(impact.CriticalChain) > 0 {
+		b.WriteString("  Critical chain: " + strings.Join(impact.CriticalChain, arrow) + "\n")
+	}
+	b.WriteString(m.styles.Muted.Render("  [f] in the issue detail shows every affected unit") + "\n")
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// renderEdges lists the units at the other end of the edges of
+// depsEdgeTypes, one line per edge type
+func (m Model) renderEdges(edges []graph.Edge, other func(graph.Edge) string) string {
+	byType := make(map[graph.EdgeType][]string)
+	for _, e := range edges {
+		name := other(e)
+		if e.Implicit {
+			name += " (implicit)"
+		}
+		byType[e.Type] = append(byType[e.Type], name)
+	}
+
+	var b strings.Builder
+	for _, t := range depsEdgeTypes {
+		names := byType[t]
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+		names = dedupSorted(names)
+		b.WriteString(fmt.Sprintf("  %-10s %s\n", t.String()+"=", strings.Join(names, ", ")))
+	}
+	if b.Len() == 0 {
+		return "  None\n"
+	}
+	return b.String()
+}
+
+// dedupSorted drops repeated names from a sorted list, as a dependency may
+// be declared both in the unit and by a .wants/ symlink
+func dedupSorted(names []string) []string {
+	out := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func (m Model) viewDeps() string {
+	var b strings.Builder
+
+	if m.depsUnit == "" {
+		b.WriteString("No unit selected\n")
+		b.WriteString("\n" + m.styles.HelpBar.Render("[esc] back"))
+		return b.String()
+	}
+
+	b.WriteString(m.styles.Title.Render("Dependencies of "+m.depsUnit) + "\n\n")
+	b.WriteString(m.deps.View() + "\n")
+	b.WriteString(m.styles.HelpBar.Render("[" + m.styles.Glyphs.Text("↑/↓") + "/pgup/pgdn] scroll  [esc] back  [q]uit"))
+
+	return b.String()
+}
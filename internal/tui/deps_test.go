@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openDepsView opens the dependency panel from the detail of the first issue
+func openDepsView(t *testing.T, in Input, width, height int) Model {
+	t.Helper()
+	m := New(in, Options{ASCII: true})
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	m = updated.(Model)
+	m.view = ViewIssues
+	for _, msg := range []tea.KeyMsg{{Type: tea.KeyEnter}, {Type: tea.KeyRunes, Runes: []rune("g")}} {
+		updated, _ = m.Update(msg)
+		m = updated.(Model)
+	}
+	if m.view != ViewDependencies {
+		t.Fatalf("view = %d, want the dependency panel", m.view)
+	}
+	return m
+}
+
+func TestDepsView(t *testing.T) {
+	m := openDepsView(t, reverseDepsInput(t, "app.service"), 100, 60)
+
+	out := m.View()
+	for _, want := range []string{
+		"Dependencies of app.service",
+		"Requires=  db.service",
+		"After=     basic.target (implicit), db.service, sysinit.target (implicit)",
+		"BindsTo=   metrics.service, worker.service",
+		"2 units affected when app.service fails: 2 fail to start, 2 stop",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dependency panel missing %q:\n%s", want, out)
+		}
+	}
+	if found := nonASCII(out); len(found) > 0 {
+		t.Errorf("dependency panel contains non-ASCII %q", string(found))
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if view := updated.(Model).view; view != ViewUnitDetail {
+		t.Errorf("esc from the dependency panel went to %d, want the issue detail", view)
+	}
+}
+
+func TestDepsViewScrolls(t *testing.T) {
+	m := openDepsView(t, reverseDepsInput(t, "db.service"), 100, 12)
+	if m.deps.TotalLineCount() <= m.deps.Height {
+		t.Fatalf("panel of %d lines fits in %d, nothing to scroll", m.deps.TotalLineCount(), m.deps.Height)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+	if m.deps.YOffset != 1 {
+		t.Errorf("down scrolled to line %d, want 1", m.deps.YOffset)
+	}
+}
+
+func TestDepsViewNoGraph(t *testing.T) {
+	in := reverseDepsInput(t, "app.service")
+	in.Graph = nil
+	m := openDepsView(t, in, 100, 60)
+	if out := m.View(); !strings.Contains(out, "graph unavailable") {
+		t.Errorf("dependency panel without a graph:\n%s", out)
+	}
+
+	// The failure impact needs the graph as well
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if out := updated.View(); !strings.Contains(out, "graph unavailable") {
+		t.Errorf("impact view without a graph:\n%s", out)
+	}
+}
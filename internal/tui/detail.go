@@ -0,0 +1,304 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// minSourceHeight is the fewest lines of the file the detail view shows
+const minSourceHeight = 5
+
+// maxSiblings is the most other issues of the unit listed at once
+const maxSiblings = 7
+
+// sourceKeys scroll the file in the detail view. Up and down select another
+// issue of the unit instead, and the letters viewport uses by default are
+// taken by the view's own commands.
+var sourceKeys = viewport.KeyMap{
+	PageDown:     key.NewBinding(key.WithKeys("pgdown", " "), key.WithHelp("pgdn", "page down")),
+	PageUp:       key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up")),
+	HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "half page down")),
+	HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u"), key.WithHelp("ctrl+u", "half page up")),
+	Up:           key.NewBinding(key.WithDisabled()),
+	Down:         key.NewBinding(key.WithDisabled()),
+	Left:         key.NewBinding(key.WithDisabled()),
+	Right:        key.NewBinding(key.WithDisabled()),
+}
+
+// unitDetail is the state of the issue detail view: the issues of one unit,
+// the one shown, and the file it points at
+type unitDetail struct {
+	issues []types.Issue
+	index  int
+	// file is the path whose contents are loaded
+	file    string
+	content string
+	// sourceErr says why the file cannot be shown, "" if it can
+	sourceErr string
+	source    viewport.Model
+}
+
+// issue returns the issue shown, false when there is none
+func (d *unitDetail) issue() (types.Issue, bool) {
+	if d.index < 0 || d.index >= len(d.issues) {
+		return types.Issue{}, false
+	}
+	return d.issues[d.index], true
+}
+
+// openDetail shows an issue in the detail view, with the other issues of its
+// unit to step through
+func (m *Model) openDetail(issue types.Issue) {
+	d := unitDetail{index: -1, source: viewport.New(0, minSourceHeight)}
+	d.source.KeyMap = sourceKeys
+	for _, other := range m.result.Issues {
+		if other.Unit != issue.Unit {
+			continue
+		}
+		if d.index < 0 && sameIssue(other, issue) {
+			d.index = len(d.issues)
+		}
+		d.issues = append(d.issues, other)
+	}
+	if d.index < 0 {
+		d.issues = append([]types.Issue{issue}, d.issues...)
+		d.index = 0
+	}
+	m.detail = d
+	m.loadSource()
+}
+
+// selectDetail shows the issue of the unit delta places after the current one
+func (m *Model) selectDetail(delta int) {
+	next := m.detail.index + delta
+	if next < 0 || next >= len(m.detail.issues) {
+		return
+	}
+	m.detail.index = next
+	m.loadSource()
+}
+
+// loadSource reads the file of the issue shown, if it is not the one already
+// loaded, and scrolls to the issue's line. Unit files are taken from the
+// scan, so they show what was checked; other files, such as environment
+// files, are read from disk.
+func (m *Model) loadSource() {
+	d := &m.detail
+	issue, ok := d.issue()
+	if !ok {
+		return
+	}
+
+	if issue.File != d.file || d.file == "" {
+		d.file = issue.File
+		d.content, d.sourceErr = "", ""
+		content, err := m.fileContent(issue.File)
+		if err != nil {
+			d.sourceErr = err.Error()
+		} else {
+			d.content = content
+		}
+	}
+	m.layoutDetail()
+}
+
+// fileContent returns the contents of a file an issue points at
+func (m *Model) fileContent(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("the issue does not point at a file")
+	}
+	for _, u := range m.result.Units {
+		if u.Path == path && u.Raw != "" {
+			return u.Raw, nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// renderSource numbers the lines of the loaded file and marks the issue's
+// line with '>' as well as the highlight, so it does not rely on color. Long
+// lines are cut at width rather than wrapped, which would push the lines
+// below them out of view.
+func (m Model) renderSource(issue types.Issue, width int) string {
+	if m.detail.sourceErr != "" {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSuffix(m.detail.content, "\n"), "\n")
+	digits := len(fmt.Sprint(len(lines)))
+
+	var b strings.Builder
+	for i, line := range lines {
+		text := fmt.Sprintf("%*d  %s", digits, i+1, m.styles.Glyphs.Text(strings.ReplaceAll(line, "\t", "    ")))
+		text = truncateASCII(text, width-2)
+		if issue.Line != nil && *issue.Line == i+1 {
+			b.WriteString(m.styles.Highlight.Render("> " + text))
+		} else {
+			b.WriteString("  " + text)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// layoutDetail gives the file the lines left below the issue information,
+// renders it at the view's width and scrolls so the issue's line is in the
+// middle
+func (m *Model) layoutDetail() {
+	d := &m.detail
+	issue, ok := d.issue()
+	if !ok {
+		return
+	}
+	height := minSourceHeight
+	if m.height > 0 {
+		// The app's padding, the file's heading and the help bar
+		used := strings.Count(m.viewDetailInfo(), "\n") + 6
+		height = max(minSourceHeight, m.height-used)
+	}
+	d.source.Height = height
+	d.source.Width = max(m.width-4, 20)
+	d.source.SetContent(m.renderSource(issue, d.source.Width))
+
+	if issue.Line != nil {
+		d.source.SetYOffset(*issue.Line - 1 - height/2)
+	} else {
+		d.source.GotoTop()
+	}
+}
+
+// sameIssue reports whether two issues are the same finding
+func sameIssue(a, b types.Issue) bool {
+	if a.RuleID != b.RuleID || a.Unit != b.Unit || a.File != b.File || a.Description != b.Description {
+		return false
+	}
+	if a.Line == nil || b.Line == nil {
+		return a.Line == b.Line
+	}
+	return *a.Line == *b.Line
+}
+
+func (m Model) viewUnitDetail() string {
+	var b strings.Builder
+
+	issue, ok := m.detail.issue()
+	if !ok {
+		b.WriteString("No issue selected\n")
+		b.WriteString("\n" + m.styles.HelpBar.Render("[esc] back"))
+		return b.String()
+	}
+
+	b.WriteString(m.viewDetailInfo())
+
+	// Source
+	b.WriteString("\n" + m.styles.Title.Render(m.styles.Glyphs.Text("File "+issue.File)) + "\n")
+	if m.detail.sourceErr != "" {
+		b.WriteString("  " + m.styles.SeverityHigh.Render(m.detail.sourceErr) + "\n")
+	} else {
+		b.WriteString(m.detail.source.View() + "\n")
+	}
+
+	help := "[f]ailure impact  [g] dependencies  [E]dit  [pgup/pgdn] scroll  [esc] back  [q]uit"
+	if len(m.detail.issues) > 1 {
+		help = "[" + m.styles.Glyphs.Text("↑/↓") + "] other issues  " + help
+	}
+	b.WriteString(m.styles.HelpBar.Render(help))
+
+	return b.String()
+}
+
+// viewDetailInfo renders everything of the detail view above the file
+func (m Model) viewDetailInfo() string {
+	var b strings.Builder
+	issue, _ := m.detail.issue()
+
+	// Header
+	b.WriteString(m.styles.Title.Render("Issue Detail") + "\n\n")
+
+	// Issue info
+	b.WriteString(fmt.Sprintf("Rule:     %s\n", m.styles.Bold.Render(issue.RuleID)))
+	b.WriteString(fmt.Sprintf("Name:     %s\n", issue.RuleName))
+	b.WriteString(fmt.Sprintf("Severity: %s\n", m.styles.RenderSeverity(issue.Severity.String(), strings.ToUpper(issue.Severity.String()))))
+	b.WriteString(fmt.Sprintf("Category: %s\n", issue.Category.String()))
+	b.WriteString(fmt.Sprintf("Unit:     %s\n", m.styles.Bold.Render(issue.Unit)))
+	b.WriteString(fmt.Sprintf("File:     %s\n", issue.File))
+	if issue.Line != nil {
+		b.WriteString(fmt.Sprintf("Line:     %d\n", *issue.Line))
+	}
+	if entry, ok := m.ack.baseline.Lookup(issue); ok {
+		status := "acknowledged"
+		if entry.Reason != "" {
+			status += ": " + entry.Reason
+		}
+		b.WriteString("Status:   " + m.styles.Muted.Render(m.styles.Glyphs.Text(status)) + "\n")
+	}
+	b.WriteString("\n")
+
+	// Description
+	b.WriteString(m.styles.Title.Render("Description") + "\n")
+	b.WriteString("  " + m.styles.Glyphs.Text(issue.Description) + "\n\n")
+
+	// Suggestion
+	b.WriteString(m.styles.Title.Render("Suggestion") + "\n")
+	b.WriteString("  " + m.styles.Glyphs.Text(issue.Suggestion) + "\n")
+
+	// References
+	if len(issue.Refs) > 0 {
+		b.WriteString("\n" + m.styles.Title.Render("References") + "\n")
+		for _, ref := range issue.Refs {
+			line := "  " + m.styles.Glyphs.Text(ref.String())
+			if ref.Kind != types.ReferenceURL && ref.URL != "" {
+				line += "  " + m.styles.Muted.Render(ref.URL)
+			}
+			b.WriteString(line + "\n")
+		}
+	} else if len(issue.References) > 0 {
+		b.WriteString("\n" + m.styles.Title.Render("References") + "\n")
+		for _, ref := range issue.References {
+			b.WriteString("  " + m.styles.Muted.Render(ref) + "\n")
+		}
+	}
+
+	// Tags
+	if len(issue.Tags) > 0 {
+		b.WriteString("\n" + m.styles.Title.Render("Tags") + "\n")
+		b.WriteString("  " + strings.Join(issue.Tags, ", ") + "\n")
+	}
+
+	// Other issues of the unit
+	if len(m.detail.issues) > 1 {
+		b.WriteString("\n" + m.styles.Title.Render(fmt.Sprintf("Issues in %s (%d of %d)", issue.Unit, m.detail.index+1, len(m.detail.issues))) + "\n")
+		// A window of the list around the issue shown
+		first := min(max(0, m.detail.index-maxSiblings/2), max(0, len(m.detail.issues)-maxSiblings))
+		last := min(len(m.detail.issues), first+maxSiblings)
+		if first > 0 {
+			b.WriteString(m.styles.Muted.Render(fmt.Sprintf("    %d more above", first)) + "\n")
+		}
+		for i := first; i < last; i++ {
+			other := m.detail.issues[i]
+			line := fmt.Sprintf("%-8s [%s] %s", strings.ToUpper(other.Severity.String()), other.RuleID, other.RuleName)
+			if other.Line != nil {
+				line += fmt.Sprintf(" (line %d)", *other.Line)
+			}
+			if i == m.detail.index {
+				b.WriteString(m.styles.ListItemSelected.Render("> "+line) + "\n")
+			} else {
+				b.WriteString(m.styles.ListItem.Render("  "+line) + "\n")
+			}
+		}
+		if last < len(m.detail.issues) {
+			b.WriteString(m.styles.Muted.Render(fmt.Sprintf("    %d more below", len(m.detail.issues)-last)) + "\n")
+		}
+	}
+
+	return b.String()
+}
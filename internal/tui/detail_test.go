@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func intPtr(n int) *int { return &n }
+
+func detailResult(raw string) *types.ScanResult {
+	unit := &types.UnitFile{Name: "app.service", Path: "/etc/systemd/system/app.service", Type: "service", Raw: raw}
+	return &types.ScanResult{
+		Units: []*types.UnitFile{unit},
+		Issues: []types.Issue{
+			{RuleID: "SEC001", RuleName: "NoNewPrivileges not set", Severity: types.SeverityHigh, Unit: unit.Name, File: unit.Path, Line: intPtr(3)},
+			{RuleID: "other", RuleName: "Other unit", Severity: types.SeverityHigh, Unit: "db.service", File: "/etc/systemd/system/db.service"},
+			{RuleID: "REL001", RuleName: "Restart policy not configured", Severity: types.SeverityMedium, Unit: unit.Name, File: unit.Path, Line: intPtr(2)},
+		},
+		Summary: types.Summary{BySeverity: map[types.Severity]int{}, ByCategory: map[types.Category]int{}},
+	}
+}
+
+func openDetailView(t *testing.T, result *types.ScanResult, width, height int) Model {
+	t.Helper()
+	m := New(Input{Result: result}, Options{ASCII: true})
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	m = updated.(Model)
+	m.view = ViewIssues
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.view != ViewUnitDetail {
+		t.Fatalf("view = %d, want the issue detail", m.view)
+	}
+	return m
+}
+
+func TestDetailHighlightsLine(t *testing.T) {
+	m := openDetailView(t, detailResult("[Service]\nType=simple\nExecStart=/usr/bin/app\n"), 100, 60)
+
+	out := m.View()
+	for _, want := range []string{"> 3  ExecStart=/usr/bin/app", "  2  Type=simple", "Issues in app.service (1 of 2)", "[REL001] Restart policy not configured (line 2)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("detail view missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "db.service") {
+		t.Errorf("detail view lists an issue of another unit:\n%s", out)
+	}
+
+	// Down selects the unit's next issue and moves the highlight
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+	out = m.View()
+	if !strings.Contains(out, "Rule:     REL001") || !strings.Contains(out, "> 2  Type=simple") || !strings.Contains(out, "(2 of 2)") {
+		t.Errorf("down should show REL001 with line 2 highlighted:\n%s", out)
+	}
+
+	// There is no issue past the last one
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if got := updated.(Model).detail.index; got != 1 {
+		t.Errorf("index after moving past the end = %d, want 1", got)
+	}
+}
+
+func TestDetailScrollsLargeFile(t *testing.T) {
+	var raw strings.Builder
+	raw.WriteString("[Service]\n")
+	for i := 2; i <= 300; i++ {
+		fmt.Fprintf(&raw, "Environment=VAR%d=%d\n", i, i)
+	}
+	result := detailResult(raw.String())
+	result.Issues[0].Line = intPtr(250)
+
+	m := openDetailView(t, result, 100, 60)
+	out := m.View()
+	if !strings.Contains(out, "> 250  Environment=VAR250=250") {
+		t.Errorf("line 250 should be in view and highlighted:\n%s", out)
+	}
+	if strings.Contains(out, "  1  [Service]") {
+		t.Errorf("the start of the file should be scrolled out of view:\n%s", out)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	m = updated.(Model)
+	if !m.detail.source.AtBottom() && !strings.Contains(m.View(), "300  Environment=VAR300") {
+		t.Errorf("pgdown should scroll towards the end of the file")
+	}
+	if m.view != ViewUnitDetail {
+		t.Errorf("pgdown left the detail view")
+	}
+}
+
+func TestDetailUnreadableFile(t *testing.T) {
+	result := detailResult("")
+	missing := filepath.Join(t.TempDir(), "missing.conf")
+	result.Issues[0].File = missing
+
+	m := openDetailView(t, result, 100, 60)
+	out := m.View()
+	if !strings.Contains(out, "cannot read "+missing) {
+		t.Errorf("detail view should explain that the file cannot be read:\n%s", out)
+	}
+}
@@ -0,0 +1,242 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// defaultEditor is run when $EDITOR is not set
+const defaultEditor = "vi"
+
+// editorClosedMsg is sent when the editor started from the detail view exits
+type editorClosedMsg struct {
+	path string
+	err  error
+}
+
+// recheckedMsg carries the issues of a unit file checked again after it was
+// edited
+type recheckedMsg struct {
+	path    string
+	recheck *audit.Recheck
+	err     error
+}
+
+// editorCommand runs $EDITOR, or vi, on path. $EDITOR may carry arguments,
+// as in "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	args := strings.Fields(os.Getenv("EDITOR"))
+	if len(args) == 0 {
+		args = []string{defaultEditor}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// startEdit suspends the TUI and opens the unit file of the issue shown in
+// the editor. Files that cannot be written are not opened.
+func (m *Model) startEdit() tea.Cmd {
+	issue, ok := m.detail.issue()
+	if !ok {
+		return nil
+	}
+	if m.recheck == nil {
+		m.status = "Unit files cannot be edited from this scan"
+		return nil
+	}
+	unit := m.unitAt(issue.File)
+	if unit == nil {
+		m.status = fmt.Sprintf("%s is not a unit file of the scan", issue.File)
+		return nil
+	}
+	if err := checkWritable(issue.File); err != nil {
+		if errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS) {
+			m.status = fmt.Sprintf("%s is read-only; use systemctl edit %s to override it with a drop-in", issue.File, unit.Name)
+		} else {
+			m.status = fmt.Sprintf("Cannot edit %s: %v", issue.File, err)
+		}
+		return nil
+	}
+
+	path := issue.File
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		return editorClosedMsg{path: path, err: err}
+	})
+}
+
+// checkWritable returns why path cannot be opened for writing, nil if it can
+func checkWritable(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// unitAt returns the scanned unit loaded from path, nil if there is none
+func (m Model) unitAt(path string) *types.UnitFile {
+	if path == "" {
+		return nil
+	}
+	for _, u := range m.result.Units {
+		if u.Path == path {
+			return u
+		}
+	}
+	return nil
+}
+
+// editorClosed checks the file again once the editor exits cleanly. An
+// editor that fails leaves the issues as they were.
+func (m *Model) editorClosed(msg editorClosedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.status = fmt.Sprintf("The editor failed (%v); %s was not checked again", msg.err, msg.path)
+		return nil
+	}
+
+	recheck := m.recheck
+	units := make(map[string]*types.UnitFile, len(m.result.Units))
+	for _, u := range m.result.Units {
+		units[u.Name] = u
+	}
+	return func() tea.Msg {
+		r, err := recheck(msg.path, units)
+		return recheckedMsg{path: msg.path, recheck: r, err: err}
+	}
+}
+
+// rechecked replaces the issues of the rules that ran on the edited unit
+// with the ones they found now, and says which were resolved and which are
+// new. The detail view stays on the issue shown if it is still found, and
+// returns to the issues list when the unit has no issues left.
+func (m *Model) rechecked(msg recheckedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Cannot check %s again: %v", msg.path, msg.err)
+		return nil
+	}
+	r := msg.recheck
+	ran := make(map[string]bool, len(r.Rules))
+	for _, id := range r.Rules {
+		ran[id] = true
+	}
+
+	var before, kept []types.Issue
+	for _, issue := range m.result.Issues {
+		if issue.File == msg.path && ran[issue.RuleID] {
+			before = append(before, issue)
+		} else {
+			kept = append(kept, issue)
+		}
+	}
+	m.result.Issues = types.SortIssues(append(kept, r.Issues...), types.OrderSeverity)
+	for i, u := range m.result.Units {
+		if u.Path == msg.path {
+			m.result.Units[i] = r.Unit
+		}
+	}
+	m.recount()
+
+	resolved, added := diffIssues(before, r.Issues)
+	m.status = recheckStatus(r.Unit.Name, resolved, added)
+
+	cmd := m.applyFilter()
+	if m.view == ViewUnitDetail {
+		m.reopenDetail(r.Unit.Name)
+	}
+	return cmd
+}
+
+// reopenDetail shows the edited unit's issues in the detail view again,
+// keeping the issue shown if the unit still has it
+func (m *Model) reopenDetail(unit string) {
+	shown, _ := m.detail.issue()
+	var first *types.Issue
+	for i, issue := range m.result.Issues {
+		if issue.Unit != unit {
+			continue
+		}
+		if issue.RuleID == shown.RuleID && issue.Description == shown.Description {
+			m.openDetail(issue)
+			return
+		}
+		if first == nil {
+			first = &m.result.Issues[i]
+		}
+	}
+	if first == nil {
+		m.view = ViewIssues
+		return
+	}
+	m.openDetail(*first)
+}
+
+// recount counts the scan's issues again for the summary and the scores
+func (m *Model) recount() {
+	m.result.Recount()
+}
+
+// diffIssues returns the issues of before that after no longer has, and the
+// issues of after that before did not have. Issues are told apart by rule
+// and description, as an edit moves the lines they point at.
+func diffIssues(before, after []types.Issue) (resolved, added []types.Issue) {
+	return missing(before, after), missing(after, before)
+}
+
+// missing returns the issues of a that b does not have as often
+func missing(a, b []types.Issue) []types.Issue {
+	key := func(issue types.Issue) string { return issue.RuleID + "\x00" + issue.Description }
+	count := make(map[string]int)
+	for _, issue := range b {
+		count[key(issue)]++
+	}
+	var out []types.Issue
+	for _, issue := range a {
+		if count[key(issue)] > 0 {
+			count[key(issue)]--
+		} else {
+			out = append(out, issue)
+		}
+	}
+	return out
+}
+
+// recheckStatus says how checking a unit again changed its issues
+func recheckStatus(unit string, resolved, added []types.Issue) string {
+	if len(resolved) == 0 && len(added) == 0 {
+		return fmt.Sprintf("Checked %s again: no change", unit)
+	}
+	var parts []string
+	if len(resolved) > 0 {
+		parts = append(parts, fmt.Sprintf("%d resolved (%s)", len(resolved), ruleList(resolved)))
+	}
+	if len(added) > 0 {
+		parts = append(parts, fmt.Sprintf("%d new (%s)", len(added), ruleList(added)))
+	}
+	return fmt.Sprintf("Checked %s again: %s", unit, strings.Join(parts, ", "))
+}
+
+// ruleList joins the rule IDs of issues
+func ruleList(issues []types.Issue) string {
+	ids := make([]string, len(issues))
+	for i, issue := range issues {
+		ids[i] = issue.RuleID
+	}
+	return strings.Join(ids, ", ")
+}
+
+// viewStatus renders the outcome of the last edit below the current view
+func (m Model) viewStatus() string {
+	if m.status == "" {
+		return ""
+	}
+	return "\n\n" + m.styles.Muted.Render(m.styles.Glyphs.Text(m.status))
+}
@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// editableResult is detailResult with its unit file written to a temporary
+// directory
+func editableResult(t *testing.T) *types.ScanResult {
+	t.Helper()
+	raw := "[Service]\nType=simple\nExecStart=/usr/bin/app\n"
+	path := filepath.Join(t.TempDir(), "app.service")
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := detailResult(raw)
+	result.Units[0].Path = path
+	result.Issues[0].File = path
+	result.Issues[2].File = path
+	return result
+}
+
+func TestEditRechecksUnit(t *testing.T) {
+	result := editableResult(t)
+	path := result.Units[0].Path
+	var gotUnits map[string]*types.UnitFile
+	recheck := func(p string, units map[string]*types.UnitFile) (*audit.Recheck, error) {
+		gotUnits = units
+		unit := &types.UnitFile{Name: "app.service", Path: p, Raw: "[Service]\nNoNewPrivileges=yes\n"}
+		return &audit.Recheck{
+			Unit: unit,
+			Issues: []types.Issue{
+				{RuleID: "SEC002", RuleName: "ProtectSystem not set", Severity: types.SeverityMedium, Unit: unit.Name, File: p},
+			},
+			Rules: []string{"SEC001", "SEC002"},
+		}, nil
+	}
+
+	m := New(Input{Result: result, Recheck: recheck}, Options{ASCII: true})
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 60})
+	m = updated.(Model)
+	m.view = ViewIssues
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+
+	updated, cmd := m.Update(runes("E"))
+	m = updated.(Model)
+	if cmd == nil || m.status != "" {
+		t.Fatalf("E should start the editor, status %q", m.status)
+	}
+
+	// The editor exits cleanly, and the unit is checked again
+	updated, cmd = m.Update(editorClosedMsg{path: path})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("closing the editor should check the unit again")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+
+	if gotUnits["app.service"] == nil || gotUnits["app.service"].Path != path {
+		t.Errorf("recheck got units %v, want the scanned units", gotUnits)
+	}
+	if want := "Checked app.service again: 1 resolved (SEC001), 1 new (SEC002)"; m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+	if got := ruleIDs(m.result.Issues); got != "other,REL001,SEC002" {
+		t.Errorf("issues = %s, want SEC001 replaced by SEC002 and REL001 kept", got)
+	}
+	if m.result.Summary.TotalIssues != 3 || m.result.Summary.BySeverity[types.SeverityMedium] != 2 {
+		t.Errorf("summary not counted again: %+v", m.result.Summary)
+	}
+	if m.result.Units[0].Raw != "[Service]\nNoNewPrivileges=yes\n" {
+		t.Errorf("unit not replaced: %q", m.result.Units[0].Raw)
+	}
+
+	// The shown issue was resolved, so the detail view moves to the unit's
+	// first issue and shows the edited file
+	out := m.View()
+	if m.view != ViewUnitDetail || !strings.Contains(out, "Rule:     REL001") || !strings.Contains(out, "NoNewPrivileges=yes") {
+		t.Errorf("detail view should show the unit's issues after the edit:\n%s", out)
+	}
+	if !strings.Contains(out, "1 new (SEC002)") {
+		t.Errorf("view should show the status:\n%s", out)
+	}
+
+	// The status is cleared by the next key
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if updated.(Model).status != "" {
+		t.Error("status should be cleared by the next key")
+	}
+}
+
+func TestEditFailures(t *testing.T) {
+	result := editableResult(t)
+	path := result.Units[0].Path
+	calls := 0
+	recheck := func(p string, units map[string]*types.UnitFile) (*audit.Recheck, error) {
+		calls++
+		return nil, errors.New("failed to parse")
+	}
+
+	m := openDetailView(t, result, 100, 60)
+	updated, cmd := m.Update(runes("E"))
+	m = updated.(Model)
+	if cmd != nil || !strings.Contains(m.status, "cannot be edited") {
+		t.Errorf("E without a recheck should say so, status %q", m.status)
+	}
+
+	m.recheck = recheck
+	updated, cmd = m.Update(editorClosedMsg{path: path, err: errors.New("exit status 1")})
+	m = updated.(Model)
+	if cmd != nil || !strings.Contains(m.status, "exit status 1") || !strings.Contains(m.status, "not checked again") {
+		t.Errorf("a failed editor should leave the issues, status %q", m.status)
+	}
+
+	updated, cmd = m.Update(editorClosedMsg{path: path})
+	m = updated.(Model)
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	if calls != 1 || !strings.Contains(m.status, "failed to parse") || len(m.result.Issues) != 3 {
+		t.Errorf("a failed recheck should leave the issues, status %q", m.status)
+	}
+
+	// Files other than the scan's unit files are not opened
+	m.detail.issues[m.detail.index].File = filepath.Join(t.TempDir(), "app.env")
+	updated, cmd = m.Update(runes("E"))
+	m = updated.(Model)
+	if cmd != nil || !strings.Contains(m.status, "not a unit file") {
+		t.Errorf("E on another file should refuse, status %q", m.status)
+	}
+}
+
+func TestEditReadOnlyFile(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write read-only files")
+	}
+	result := editableResult(t)
+	if err := os.Chmod(result.Units[0].Path, 0o444); err != nil {
+		t.Fatal(err)
+	}
+	m := New(Input{Result: result, Recheck: func(string, map[string]*types.UnitFile) (*audit.Recheck, error) { return nil, nil }}, Options{ASCII: true})
+	m.view = ViewIssues
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, cmd := updated.(Model).Update(runes("E"))
+	m = updated.(Model)
+	if cmd != nil || !strings.Contains(m.status, "read-only") || !strings.Contains(m.status, "systemctl edit app.service") {
+		t.Errorf("E on a read-only file should suggest a drop-in, status %q", m.status)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("EDITOR", "code --wait")
+	if got := editorCommand("/x.service").Args; strings.Join(got, " ") != "code --wait /x.service" {
+		t.Errorf("args = %v", got)
+	}
+	t.Setenv("EDITOR", "")
+	if got := editorCommand("/x.service").Args; strings.Join(got, " ") != "vi /x.service" {
+		t.Errorf("args = %v", got)
+	}
+}
@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/reporter"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// exportState is the state of exporting the issues list: the path prompt and
+// the confirmation to overwrite an existing file
+type exportState struct {
+	path textinput.Model
+	// prompting is set while asking for the path
+	prompting bool
+	// overwrite is set while asking whether to replace the file at the path
+	overwrite bool
+}
+
+func newExportState() exportState {
+	path := textinput.New()
+	path.Prompt = "Export to (.json, .md, .sarif or .txt): "
+	path.CharLimit = 4096
+	return exportState{path: path}
+}
+
+// defaultExportPath names the export after the time it is made, so that
+// exports do not replace each other
+func defaultExportPath(now time.Time) string {
+	return "sdaudit-" + now.Format("20060102-150405") + ".json"
+}
+
+// startExport asks where to export the issues the list shows
+func (m *Model) startExport() tea.Cmd {
+	m.export.path.SetValue(defaultExportPath(time.Now()))
+	m.export.path.CursorEnd()
+	m.export.prompting = true
+	return m.export.path.Focus()
+}
+
+// updateExport handles keys while exporting. Enter writes the file, asking
+// first when it exists; esc gives up.
+func (m Model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.export.overwrite {
+		switch msg.String() {
+		case "y", "Y":
+			return m, m.finishExport()
+		case "n", "N", "esc":
+			// Back to the prompt to choose another path
+			m.export.overwrite = false
+		}
+		return m, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEnter:
+		path := strings.TrimSpace(m.export.path.Value())
+		if path == "" {
+			return m, nil
+		}
+		if _, err := os.Stat(path); err == nil {
+			m.export.overwrite = true
+			return m, nil
+		}
+		return m, m.finishExport()
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.export.prompting = false
+		m.export.path.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.export.path, cmd = m.export.path.Update(msg)
+	return m, cmd
+}
+
+// finishExport writes the export, closes the prompt and reports the outcome
+// in the list's status bar
+func (m *Model) finishExport() tea.Cmd {
+	path := strings.TrimSpace(m.export.path.Value())
+	m.export.prompting, m.export.overwrite = false, false
+	m.export.path.Blur()
+
+	issues := m.visibleIssues()
+	if err := writeExport(path, m.exportResult(issues)); err != nil {
+		return m.issueList.NewStatusMessage(m.styles.SeverityHigh.Render(m.styles.Glyphs.Text("Export failed: " + err.Error())))
+	}
+	return m.issueList.NewStatusMessage(fmt.Sprintf("Exported %s to %s", pluralIssues(len(issues)), path))
+}
+
+// visibleIssues returns the issues the list shows, after the severity and
+// category filters and the search
+func (m Model) visibleIssues() []types.Issue {
+	items := m.issueList.VisibleItems()
+	issues := make([]types.Issue, 0, len(items))
+	for _, item := range items {
+		if issue, ok := item.(IssueItem); ok {
+			issues = append(issues, issue.issue)
+		}
+	}
+	return issues
+}
+
+// exportResult wraps a subset of the scan's issues in a scan result with the
+// summary and scores counted for them, keeping the rest of the scan's summary
+func (m Model) exportResult(issues []types.Issue) *analyzer.ScanResult {
+	result := &analyzer.ScanResult{Units: m.result.Units, Issues: issues, Summary: m.result.Summary}
+	result.Recount()
+	return result
+}
+
+// writeExport writes a scan result to path with the reporter its extension
+// names
+func writeExport(path string, result *analyzer.ScanResult) error {
+	var buf bytes.Buffer
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = reporter.NewJSONReporter(&buf, true).Report(result)
+	case ".md", ".markdown":
+		err = reporter.NewMarkdownReporter(&buf).Report(result)
+	case ".sarif":
+		err = reporter.NewSARIFReporter(&buf, true).Report(result)
+	case ".txt":
+		err = reporter.NewTextReporter(&buf, false).Report(result)
+	default:
+		return fmt.Errorf("cannot tell the format of %s, use .json, .md, .sarif or .txt", path)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// viewExport renders the path prompt and the overwrite confirmation below
+// the issues list
+func (m Model) viewExport() string {
+	switch {
+	case m.export.overwrite:
+		question := fmt.Sprintf("%s exists. Overwrite it?", strings.TrimSpace(m.export.path.Value()))
+		return "\n\n" + m.styles.Bold.Render(m.styles.Glyphs.Text(question)) + "  " + m.styles.HelpBar.UnsetMarginTop().Render("[y]es  [n]o")
+	case m.export.prompting:
+		return "\n\n" + m.export.path.View()
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exportTo opens the export prompt of the issues view and replaces the
+// default path with path
+func exportTo(t *testing.T, m *Model, press func(tea.KeyMsg) tea.Cmd, path string) {
+	t.Helper()
+	press(runes("e"))
+	if !m.export.prompting {
+		t.Fatal("e did not ask for a path")
+	}
+	press(tea.KeyMsg{Type: tea.KeyCtrlU})
+	press(runes(path))
+}
+
+func TestExportFilteredIssues(t *testing.T) {
+	result := makeResult()
+	result.Issues = filterTestIssues()
+	m, press := issuesView(t, Input{Result: result})
+
+	// Security issues of high severity or worse
+	press(runes("c"))
+	press(runes("c"))
+	press(runes("s"))
+
+	press(runes("e"))
+	if value := m.export.path.Value(); !regexp.MustCompile(`^sdaudit-\d{8}-\d{6}\.json$`).MatchString(value) {
+		t.Errorf("default path = %q, want a timestamped JSON file", value)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+
+	path := filepath.Join(t.TempDir(), "export.json")
+	exportTo(t, m, press, path)
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.export.prompting {
+		t.Fatal("enter did not export")
+	}
+	if out := m.View(); !strings.Contains(out, "Exported 1 issue to") {
+		t.Errorf("status line missing:\n%s", out)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported struct {
+		Summary struct {
+			TotalIssues int `json:"total_issues"`
+		} `json:"summary"`
+		Issues []struct {
+			ID string `json:"id"`
+		} `json:"issues"`
+	}
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatal(err)
+	}
+	if exported.Summary.TotalIssues != 1 || len(exported.Issues) != 1 || exported.Issues[0].ID != "SEC001" {
+		t.Errorf("exported %s", data)
+	}
+}
+
+func TestExportOverwrite(t *testing.T) {
+	result := makeResult()
+	result.Issues = filterTestIssues()
+	m, press := issuesView(t, Input{Result: result})
+
+	path := filepath.Join(t.TempDir(), "export.md")
+	if err := os.WriteFile(path, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exportTo(t, m, press, path)
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if out := m.View(); !m.export.overwrite || !strings.Contains(out, "exists. Overwrite it?") {
+		t.Fatalf("existing file was not confirmed:\n%s", out)
+	}
+	press(runes("n"))
+	if data, _ := os.ReadFile(path); string(data) != "keep" || !m.export.prompting {
+		t.Fatalf("n replaced the file or closed the prompt: %q", data)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	press(runes("y"))
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# sdaudit scan results") || !strings.Contains(string(data), "PERF001") {
+		t.Errorf("markdown export:\n%s", data)
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	m, press := issuesView(t, Input{Result: makeResult()})
+
+	path := filepath.Join(t.TempDir(), "export.yaml")
+	exportTo(t, m, press, path)
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if out := m.View(); !strings.Contains(out, "Export failed: cannot tell the format") {
+		t.Errorf("error missing from the status line:\n%s", out)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("file written for an unknown format")
+	}
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// severityCycle is the order the severity toggle steps through; SeverityInfo
+// shows every issue
+var severityCycle = []types.Severity{
+	types.SeverityInfo,
+	types.SeverityCritical,
+	types.SeverityHigh,
+	types.SeverityMedium,
+	types.SeverityLow,
+}
+
+// filterCategories are the categories that can be toggled, in display order
+var filterCategories = []types.Category{
+	types.CategorySecurity,
+	types.CategoryPerformance,
+	types.CategoryReliability,
+	types.CategoryBestPractice,
+}
+
+// IssueFilter selects the issues shown in the issues view. The zero value
+// shows every issue.
+type IssueFilter struct {
+	// MinSeverity hides less severe issues; SeverityInfo hides none
+	MinSeverity types.Severity
+	// Categories shows only the issues of these categories; none shows all
+	Categories map[types.Category]bool
+}
+
+// Active reports whether the filter hides any issue
+func (f IssueFilter) Active() bool {
+	return f.MinSeverity > types.SeverityInfo || len(f.Categories) > 0
+}
+
+// Match reports whether an issue passes the filter
+func (f IssueFilter) Match(issue types.Issue) bool {
+	if issue.Severity < f.MinSeverity {
+		return false
+	}
+	return len(f.Categories) == 0 || f.Categories[issue.Category]
+}
+
+// CycleSeverity returns the filter with the next minimum severity: all,
+// then critical, high, medium and low, then all again
+func (f IssueFilter) CycleSeverity() IssueFilter {
+	for i, sev := range severityCycle {
+		if sev == f.MinSeverity {
+			f.MinSeverity = severityCycle[(i+1)%len(severityCycle)]
+			return f
+		}
+	}
+	f.MinSeverity = types.SeverityInfo
+	return f
+}
+
+// ToggleCategory returns the filter with a category added or removed
+func (f IssueFilter) ToggleCategory(c types.Category) IssueFilter {
+	categories := make(map[types.Category]bool, len(f.Categories)+1)
+	for k := range f.Categories {
+		categories[k] = true
+	}
+	if categories[c] {
+		delete(categories, c)
+	} else {
+		categories[c] = true
+	}
+	f.Categories = categories
+	if len(categories) == 0 {
+		f.Categories = nil
+	}
+	return f
+}
+
+// Label describes the active filter for the list title, such as
+// "[≥high] [security]", or "" when nothing is filtered
+func (f IssueFilter) Label() string {
+	var parts []string
+	if f.MinSeverity > types.SeverityInfo {
+		parts = append(parts, "[≥"+f.MinSeverity.String()+"]")
+	}
+	for _, c := range filterCategories {
+		if f.Categories[c] {
+			parts = append(parts, "["+c.String()+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// FilterIssues returns the issues that pass the filter, in their order
+func FilterIssues(issues []types.Issue, f IssueFilter) []types.Issue {
+	if !f.Active() {
+		return issues
+	}
+	var kept []types.Issue
+	for _, issue := range issues {
+		if f.Match(issue) {
+			kept = append(kept, issue)
+		}
+	}
+	return kept
+}
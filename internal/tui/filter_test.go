@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func filterTestIssues() []types.Issue {
+	return []types.Issue{
+		{RuleID: "SEC001", Severity: types.SeverityCritical, Category: types.CategorySecurity, Unit: "a.service"},
+		{RuleID: "REL001", Severity: types.SeverityHigh, Category: types.CategoryReliability, Unit: "a.service"},
+		{RuleID: "SEC002", Severity: types.SeverityMedium, Category: types.CategorySecurity, Unit: "b.service"},
+		{RuleID: "PERF001", Severity: types.SeverityLow, Category: types.CategoryPerformance, Unit: "b.service"},
+		{RuleID: "BP004", Severity: types.SeverityInfo, Category: types.CategoryBestPractice, Unit: "c.service"},
+	}
+}
+
+func ruleIDs(issues []types.Issue) string {
+	var ids []string
+	for _, issue := range issues {
+		ids = append(ids, issue.RuleID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestFilterIssues(t *testing.T) {
+	security := IssueFilter{}.ToggleCategory(types.CategorySecurity)
+	tests := []struct {
+		name      string
+		filter    IssueFilter
+		want      string
+		wantLabel string
+	}{
+		{"none", IssueFilter{}, "SEC001,REL001,SEC002,PERF001,BP004", ""},
+		{"high", IssueFilter{MinSeverity: types.SeverityHigh}, "SEC001,REL001", "[≥high]"},
+		{"security", security, "SEC001,SEC002", "[security]"},
+		{"security and performance", security.ToggleCategory(types.CategoryPerformance), "SEC001,SEC002,PERF001", "[security] [performance]"},
+		{"security toggled off", security.ToggleCategory(types.CategorySecurity), "SEC001,REL001,SEC002,PERF001,BP004", ""},
+		{"medium security", IssueFilter{MinSeverity: types.SeverityMedium, Categories: security.Categories}, "SEC001,SEC002", "[≥medium] [security]"},
+		{"nothing matches", IssueFilter{MinSeverity: types.SeverityCritical}.ToggleCategory(types.CategoryBestPractice), "", "[≥critical] [bestpractice]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleIDs(FilterIssues(filterTestIssues(), tt.filter)); got != tt.want {
+				t.Errorf("FilterIssues() = %s, want %s", got, tt.want)
+			}
+			if got := tt.filter.Label(); got != tt.wantLabel {
+				t.Errorf("Label() = %q, want %q", got, tt.wantLabel)
+			}
+			if got := tt.filter.Active(); got != (tt.wantLabel != "") {
+				t.Errorf("Active() = %v", got)
+			}
+		})
+	}
+}
+
+func TestCycleSeverity(t *testing.T) {
+	var f IssueFilter
+	var got []string
+	for i := 0; i < 6; i++ {
+		f = f.CycleSeverity()
+		got = append(got, f.MinSeverity.String())
+	}
+	if want := "critical,high,medium,low,info,critical"; strings.Join(got, ",") != want {
+		t.Errorf("cycle = %s, want %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestToggleCategoryCopies(t *testing.T) {
+	f := IssueFilter{}.ToggleCategory(types.CategorySecurity)
+	g := f.ToggleCategory(types.CategoryReliability)
+	if len(f.Categories) != 1 || len(g.Categories) != 2 {
+		t.Errorf("ToggleCategory changed the original filter: %v, %v", f.Categories, g.Categories)
+	}
+}
+
+func TestIssuesViewFilterKeys(t *testing.T) {
+	result := makeResult()
+	result.Issues = filterTestIssues()
+	m := New(Input{Result: result}, Options{ASCII: true})
+	m.width, m.height = 100, 40
+	m.issueList.SetSize(96, 32)
+	m.view = ViewIssues
+
+	press := func(k tea.KeyMsg) {
+		t.Helper()
+		updated, _ := m.Update(k)
+		m = updated.(Model)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	press(runes("c"))
+	press(runes("c"))
+	press(runes("s"))
+	if got := len(m.issueList.Items()); got != 1 {
+		t.Errorf("items = %d, want 1 (high or worse security issues)", got)
+	}
+	if m.issueList.Title != "Issues [>=high] [security]" {
+		t.Errorf("title = %q", m.issueList.Title)
+	}
+	if out := m.View(); !strings.Contains(out, "1 of 5 issues") {
+		t.Errorf("status bar should count the subset against the total:\n%s", out)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.view != ViewIssues || m.filter.Active() || len(m.issueList.Items()) != 5 || m.issueList.Title != "Issues" {
+		t.Errorf("first esc should clear the filters and stay: view %d, filter %+v, %d items", m.view, m.filter, len(m.issueList.Items()))
+	}
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.view != ViewDashboard {
+		t.Errorf("second esc went to %d, want the dashboard", m.view)
+	}
+}
+
+func TestIssuesViewSort(t *testing.T) {
+	result := makeResult()
+	result.Issues = filterTestIssues()
+	// Scan order differs from every sort order
+	result.Issues[0], result.Issues[4] = result.Issues[4], result.Issues[0]
+	m, press := issuesView(t, Input{Result: result})
+
+	listed := func() string {
+		var ids []string
+		for _, item := range m.issueList.Items() {
+			ids = append(ids, item.(IssueItem).issue.RuleID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	// Select PERF001, which each order moves
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+
+	tests := []struct{ title, order string }{
+		{"Issues by severity", "SEC001,REL001,SEC002,PERF001,BP004"},
+		{"Issues by unit", "SEC001,REL001,SEC002,PERF001,BP004"},
+		{"Issues by rule", "BP004,PERF001,REL001,SEC001,SEC002"},
+		{"Issues by category", "SEC001,SEC002,PERF001,REL001,BP004"},
+		{"Issues", "BP004,REL001,SEC002,PERF001,SEC001"},
+	}
+	for _, tt := range tests {
+		press(runes("o"))
+		if m.issueList.Title != tt.title || listed() != tt.order {
+			t.Errorf("%q lists %s, want %q with %s", m.issueList.Title, listed(), tt.title, tt.order)
+		}
+		if item := m.issueList.SelectedItem().(IssueItem); item.issue.RuleID != "PERF001" {
+			t.Errorf("%s: selection moved to %s", tt.title, item.issue.RuleID)
+		}
+	}
+
+	// The order stays when leaving the list and filtering it
+	press(runes("o"))
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	press(runes("i"))
+	press(runes("s"))
+	if m.issueList.Title != "Issues [security] by severity" || listed() != "SEC001,SEC002" {
+		t.Errorf("%q lists %s after switching views", m.issueList.Title, listed())
+	}
+}
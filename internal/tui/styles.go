@@ -1,10 +1,14 @@
 package tui
 
 import (
+	"fmt"
+
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/supabase/sdaudit/internal/style"
 )
 
-// Color palette
+// Color palette of the dark theme
 var (
 	ColorCritical = lipgloss.Color("#FF0000")
 	ColorHigh     = lipgloss.Color("#FF6600")
@@ -17,6 +21,37 @@ var (
 	ColorWhite    = lipgloss.Color("#FFFFFF")
 )
 
+// palette holds the colors of a theme
+type palette struct {
+	critical, high, medium, low, info lipgloss.Color
+	muted, accent                     lipgloss.Color
+	// onAccent is text drawn on the accent color
+	onAccent lipgloss.Color
+}
+
+var darkPalette = palette{
+	critical: ColorCritical,
+	high:     ColorHigh,
+	medium:   ColorMedium,
+	low:      ColorLow,
+	info:     ColorInfo,
+	muted:    ColorMuted,
+	accent:   ColorAccent,
+	onAccent: ColorWhite,
+}
+
+// lightPalette darkens the hues that wash out on a light background
+var lightPalette = palette{
+	critical: lipgloss.Color("#C00000"),
+	high:     lipgloss.Color("#B84A00"),
+	medium:   lipgloss.Color("#8A6A00"),
+	low:      lipgloss.Color("#006C9C"),
+	info:     lipgloss.Color("#595959"),
+	muted:    lipgloss.Color("#6E6E6E"),
+	accent:   lipgloss.Color("#5B3CC4"),
+	onAccent: ColorWhite,
+}
+
 // Styles holds all the application styles
 type Styles struct {
 	App              lipgloss.Style
@@ -39,44 +74,63 @@ type Styles struct {
 	Bar              lipgloss.Style
 	Muted            lipgloss.Style
 	Bold             lipgloss.Style
+	// Highlight marks the line of a file an issue points at
+	Highlight lipgloss.Style
+
+	// Glyphs decides which characters bars, keys and text are drawn with
+	Glyphs style.Provider
+	// Theme is the theme the styles draw
+	Theme Theme
+	// SeverityMarks prefixes severities with marks, from "!!!" for critical
+	// to "." for info, so that they are told apart without color
+	SeverityMarks bool
 }
 
-// DefaultStyles returns the default style configuration
+// DefaultStyles returns the default style configuration, for dark terminals
 func DefaultStyles() Styles {
+	return paletteStyles(darkPalette, ThemeDark)
+}
+
+// LightStyles returns the styles for terminals with a light background
+func LightStyles() Styles {
+	return paletteStyles(lightPalette, ThemeLight)
+}
+
+func paletteStyles(p palette, theme Theme) Styles {
 	return Styles{
 		App: lipgloss.NewStyle().
 			Padding(1, 2),
 
 		Header: lipgloss.NewStyle().
 			Bold(true).
-			Foreground(ColorWhite).
-			Background(ColorAccent).
+			Foreground(p.onAccent).
+			Background(p.accent).
 			Padding(0, 1).
 			MarginBottom(1),
 
 		Title: lipgloss.NewStyle().
 			Bold(true).
-			Foreground(ColorAccent),
+			Foreground(p.accent),
 
 		Subtitle: lipgloss.NewStyle().
-			Foreground(ColorMuted),
+			Foreground(p.muted),
 
 		StatusBar: lipgloss.NewStyle().
-			Foreground(ColorMuted).
+			Foreground(p.muted).
 			MarginTop(1),
 
 		HelpBar: lipgloss.NewStyle().
-			Foreground(ColorMuted).
+			Foreground(p.muted).
 			MarginTop(1),
 
 		Panel: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(ColorMuted).
+			BorderForeground(p.muted).
 			Padding(0, 1),
 
 		PanelTitle: lipgloss.NewStyle().
 			Bold(true).
-			Foreground(ColorAccent),
+			Foreground(p.accent),
 
 		List: lipgloss.NewStyle(),
 
@@ -85,40 +139,106 @@ func DefaultStyles() Styles {
 
 		ListItemSelected: lipgloss.NewStyle().
 			PaddingLeft(2).
-			Foreground(ColorWhite).
-			Background(ColorAccent),
+			Foreground(p.onAccent).
+			Background(p.accent),
 
 		SeverityCritical: lipgloss.NewStyle().
 			Bold(true).
-			Foreground(ColorCritical),
+			Foreground(p.critical),
 
 		SeverityHigh: lipgloss.NewStyle().
 			Bold(true).
-			Foreground(ColorHigh),
+			Foreground(p.high),
 
 		SeverityMedium: lipgloss.NewStyle().
-			Foreground(ColorMedium),
+			Foreground(p.medium),
 
 		SeverityLow: lipgloss.NewStyle().
-			Foreground(ColorLow),
+			Foreground(p.low),
 
 		SeverityInfo: lipgloss.NewStyle().
-			Foreground(ColorInfo),
+			Foreground(p.info),
 
 		Category: lipgloss.NewStyle().
-			Foreground(ColorAccent),
+			Foreground(p.accent),
 
 		Bar: lipgloss.NewStyle().
-			Foreground(ColorAccent),
+			Foreground(p.accent),
 
 		Muted: lipgloss.NewStyle().
-			Foreground(ColorMuted),
+			Foreground(p.muted),
 
 		Bold: lipgloss.NewStyle().
 			Bold(true),
+
+		Highlight: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(p.onAccent).
+			Background(p.accent),
+
+		Glyphs: style.New(true, false),
+		Theme:  theme,
 	}
 }
 
+// MonoStyles returns styles without color: bold, underline and reverse video
+// in the terminal's own colors, which read on dark and light backgrounds
+// alike. Severities carry marks besides their names and weights.
+func MonoStyles() Styles {
+	plain := lipgloss.NewStyle()
+	reverse := plain.Reverse(true).Bold(true)
+
+	return Styles{
+		App:              plain.Padding(1, 2),
+		Header:           reverse.Padding(0, 1).MarginBottom(1),
+		Title:            plain.Bold(true).Underline(true),
+		Subtitle:         plain,
+		StatusBar:        plain.MarginTop(1),
+		HelpBar:          plain.MarginTop(1),
+		Panel:            plain.Border(lipgloss.RoundedBorder()).Padding(0, 1),
+		PanelTitle:       plain.Bold(true).Underline(true),
+		List:             plain,
+		ListItem:         plain.PaddingLeft(2),
+		ListItemSelected: reverse.PaddingLeft(2),
+		SeverityCritical: reverse,
+		SeverityHigh:     plain.Bold(true).Underline(true),
+		SeverityMedium:   plain.Bold(true),
+		SeverityLow:      plain,
+		SeverityInfo:     plain,
+		Category:         plain.Underline(true),
+		Bar:              plain,
+		Muted:            plain.Faint(true),
+		Bold:             plain.Bold(true),
+		Highlight:        reverse,
+		Glyphs:           style.New(false, false),
+		Theme:            ThemeMono,
+		SeverityMarks:    true,
+	}
+}
+
+// asciiBorder draws panels with plain ASCII characters
+var asciiBorder = lipgloss.Border{
+	Top:         "-",
+	Bottom:      "-",
+	Left:        "|",
+	Right:       "|",
+	TopLeft:     "+",
+	TopRight:    "+",
+	BottomLeft:  "+",
+	BottomRight: "+",
+}
+
+// HighContrastStyles returns styles for ASCII mode: the mono styles with
+// ASCII borders and glyphs. Muted text is not dimmed, so that it keeps its
+// contrast.
+func HighContrastStyles() Styles {
+	s := MonoStyles()
+	s.Panel = s.Panel.Border(asciiBorder)
+	s.Muted = lipgloss.NewStyle()
+	s.Glyphs = style.New(false, true)
+	return s
+}
+
 // SeverityStyle returns the appropriate style for a severity level
 func (s Styles) SeverityStyle(severity string) lipgloss.Style {
 	switch severity {
@@ -135,18 +255,29 @@ func (s Styles) SeverityStyle(severity string) lipgloss.Style {
 	}
 }
 
+// severityMarks tell severities apart without color. They are ASCII, so
+// they suit ASCII mode too.
+var severityMarks = map[string]string{
+	"critical": "!!!",
+	"high":     "!!",
+	"medium":   "!",
+	"low":      "-",
+	"info":     ".",
+}
+
+// RenderSeverity renders text in the style of a severity, after the
+// severity's mark when the styles use marks
+func (s Styles) RenderSeverity(severity, text string) string {
+	if s.SeverityMarks {
+		text = fmt.Sprintf("%-3s %s", severityMarks[severity], text)
+	}
+	return s.SeverityStyle(severity).Render(text)
+}
+
 // RenderBar renders a horizontal bar for visualization
-func RenderBar(width int, filled int, style lipgloss.Style) string {
+func (s Styles) RenderBar(width int, filled int, style lipgloss.Style) string {
 	if width <= 0 {
 		return ""
 	}
-	bar := ""
-	for i := 0; i < width; i++ {
-		if i < filled {
-			bar += "█"
-		} else {
-			bar += "░"
-		}
-	}
-	return style.Render(bar)
+	return style.Render(s.Glyphs.Bar(width, filled))
 }
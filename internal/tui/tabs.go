@@ -0,0 +1,251 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/supabase/sdaudit/pkg/audit"
+)
+
+// bootBlameUnits is how many of the slowest units the boot tab charts
+const bootBlameUnits = 10
+
+// lazyTab is the state of a tab whose content is only loaded when the tab is
+// first opened, as loading it runs systemd-analyze
+type lazyTab struct {
+	loading bool
+	loaded  bool
+	// err says why the content could not be loaded; it is shown in the tab
+	err  error
+	view viewport.Model
+}
+
+func newLazyTab() lazyTab {
+	view := viewport.New(0, minSourceHeight)
+	view.KeyMap = depsKeys
+	return lazyTab{view: view}
+}
+
+// open starts loading the tab's content unless it is loaded or loading. It
+// returns the command that loads it and spins the spinner meanwhile.
+func (t *lazyTab) open(s spinner.Model, load tea.Cmd) tea.Cmd {
+	if t.loaded || t.loading {
+		return nil
+	}
+	t.loading = true
+	return tea.Batch(s.Tick, load)
+}
+
+// bootLoadedMsg carries the boot analysis the boot tab loaded
+type bootLoadedMsg struct {
+	analysis *audit.BootAnalysis
+	err      error
+}
+
+// securityLoadedMsg carries the security scores the security tab loaded
+type securityLoadedMsg struct {
+	scores []audit.SecurityScore
+	err    error
+}
+
+// openBoot shows the boot tab, loading the boot analysis the first time
+func (m *Model) openBoot() tea.Cmd {
+	m.view = ViewBoot
+	load := m.loadBoot
+	return m.bootTab.open(m.spinner, func() tea.Msg {
+		if load == nil {
+			return bootLoadedMsg{err: fmt.Errorf("boot times are not available for this scan")}
+		}
+		analysis, err := load()
+		return bootLoadedMsg{analysis: analysis, err: err}
+	})
+}
+
+// openSecurity shows the security tab, loading the scores the first time
+func (m *Model) openSecurity() tea.Cmd {
+	m.view = ViewSecurity
+	load := m.loadSecurity
+	return m.securityTab.open(m.spinner, func() tea.Msg {
+		if load == nil {
+			return securityLoadedMsg{err: fmt.Errorf("security scores are not available for this scan")}
+		}
+		scores, err := load()
+		return securityLoadedMsg{scores: scores, err: err}
+	})
+}
+
+// layoutTabs gives the loaded tabs the lines between their title and the
+// help bar and renders them at the view's width
+func (m *Model) layoutTabs() {
+	if m.bootTab.loaded {
+		m.bootTab.layout(m.width, m.height, m.renderBoot())
+	}
+	if m.securityTab.loaded {
+		m.securityTab.layout(m.width, m.height, m.renderSecurity())
+	}
+}
+
+func (t *lazyTab) layout(width, height int, content string) {
+	if height > 0 {
+		// The app's padding, the title and the help bar
+		t.view.Height = max(minSourceHeight, height-6)
+	}
+	t.view.Width = max(width-4, 20)
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = truncateASCII(line, t.view.Width)
+	}
+	t.view.SetContent(strings.Join(lines, "\n"))
+}
+
+// renderBoot lists the boot's totals, charts its slowest units and shows its
+// critical chain
+func (m Model) renderBoot() string {
+	if m.bootTab.err != nil {
+		return m.styles.SeverityHigh.Render(m.styles.Glyphs.Text("Boot analysis failed: " + m.bootTab.err.Error()))
+	}
+	analysis := m.boot
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Total:     %s\n", analysis.TotalTime))
+	b.WriteString(fmt.Sprintf("Kernel:    %s\n", analysis.KernelTime))
+	if analysis.InitrdTime > 0 {
+		b.WriteString(fmt.Sprintf("Initrd:    %s\n", analysis.InitrdTime))
+	}
+	b.WriteString(fmt.Sprintf("Userspace: %s\n", analysis.UserspaceTime))
+	if analysis.ReachedTarget != "" {
+		b.WriteString(fmt.Sprintf("%s reached after %s in userspace\n", analysis.ReachedTarget, analysis.TargetReachedTime))
+	}
+
+	if analysis.TimingSource == "journal" {
+		b.WriteString("\n" + m.styles.Title.Render(fmt.Sprintf("Slowest Units (median of %d boots)", analysis.TimingBoots)) + "\n")
+	} else {
+		b.WriteString("\n" + m.styles.Title.Render("Slowest Units (this boot)") + "\n")
+	}
+	units := append([]audit.UnitTiming(nil), analysis.Units...)
+	sort.SliceStable(units, func(i, j int) bool { return units[i].Time > units[j].Time })
+	if len(units) > bootBlameUnits {
+		units = units[:bootBlameUnits]
+	}
+	if len(units) == 0 {
+		b.WriteString("  No unit start times recorded\n")
+	}
+	const barWidth = 30
+	for _, unit := range units {
+		filled := barWidth
+		if units[0].Time > 0 {
+			filled = int(unit.Time * barWidth / units[0].Time)
+		}
+		if filled == 0 {
+			filled = 1
+		}
+		b.WriteString(fmt.Sprintf("  %10s %s %s\n", unit.Time.Round(time.Millisecond), m.styles.RenderBar(barWidth, filled, m.styles.SeverityMedium), unit.Name))
+	}
+
+	if len(analysis.CriticalChain) > 0 {
+		b.WriteString("\n" + m.styles.Title.Render("Critical Chain") + "\n")
+		for _, link := range analysis.CriticalChain {
+			indent := strings.Repeat("  ", link.Depth)
+			if m.styles.Glyphs.ASCII() {
+				// Spell out criticality instead of relying on color
+				critical := ""
+				if link.IsCritical {
+					critical = ", critical"
+				}
+				b.WriteString(fmt.Sprintf("  %s%s: active at %s, took %s%s\n", indent, link.Name, link.ActiveAt, link.Time, critical))
+				continue
+			}
+			line := fmt.Sprintf("  %10s %10s  %s%s", "@"+link.ActiveAt.String(), "+"+link.Time.String(), indent, link.Name)
+			if link.IsCritical {
+				line = m.styles.SeverityHigh.Render(line)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// renderSecurity lists the security scores, most exposed first
+func (m Model) renderSecurity() string {
+	if m.securityTab.err != nil {
+		return m.styles.SeverityHigh.Render(m.styles.Glyphs.Text("Security analysis failed: " + m.securityTab.err.Error()))
+	}
+	if len(m.scores) == 0 {
+		return "No services to score"
+	}
+	scores := append([]audit.SecurityScore(nil), m.scores...)
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Unit < scores[j].Unit
+	})
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%d services, scored from 0 (safe) to 10 (unsafe)\n", len(scores)))
+	if estimatedScores(scores) {
+		b.WriteString(m.styles.Muted.Render("Scores marked (estimated) were computed from unit files, not by systemd-analyze.") + "\n")
+	}
+	b.WriteString("\n")
+	for _, score := range scores {
+		exposure := m.exposureStyle(score.Exposure).Render(fmt.Sprintf("%-8s", score.Exposure))
+		line := fmt.Sprintf("  %4.1f  %s  %s", score.Score, exposure, score.Unit)
+		if score.Estimated {
+			line += " (estimated)"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// exposureStyle colors an exposure level of systemd-analyze security like
+// the severity it corresponds to
+func (m Model) exposureStyle(exposure string) lipgloss.Style {
+	switch exposure {
+	case "UNSAFE":
+		return m.styles.SeverityCritical
+	case "EXPOSED":
+		return m.styles.SeverityHigh
+	case "MEDIUM":
+		return m.styles.SeverityMedium
+	case "OK":
+		return m.styles.SeverityLow
+	default:
+		return m.styles.SeverityInfo
+	}
+}
+
+func estimatedScores(scores []audit.SecurityScore) bool {
+	for _, score := range scores {
+		if score.Estimated {
+			return true
+		}
+	}
+	return false
+}
+
+// viewTab renders a tab with its title, its content or a spinner while it
+// loads, and the help bar
+func (m Model) viewTab(title string, tab lazyTab) string {
+	var b strings.Builder
+
+	b.WriteString(m.styles.Title.Render(title) + "\n\n")
+	if tab.loaded {
+		b.WriteString(tab.view.View() + "\n")
+	} else {
+		b.WriteString(m.spinner.View() + " Analyzing...\n")
+	}
+	b.WriteString("\n" + m.styles.HelpBar.Render("["+m.styles.Glyphs.Text("↑/↓")+"/pgup/pgdn] scroll  [b]oot  securi[t]y  [d]ashboard  [i]ssues  [q]uit"))
+
+	return b.String()
+}
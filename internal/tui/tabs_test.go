@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/supabase/sdaudit/pkg/audit"
+)
+
+// loadTab runs the commands of opening a tab and sends the model what they
+// load, leaving out the spinner's ticks
+func loadTab(t *testing.T, m *Model, cmd tea.Cmd) {
+	t.Helper()
+	if cmd == nil {
+		t.Fatal("opening the tab did not load it")
+	}
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok {
+		t.Fatal("opening the tab did not spin the spinner")
+	}
+	for _, c := range batch {
+		msg := c()
+		if _, tick := msg.(spinner.TickMsg); tick {
+			continue
+		}
+		updated, _ := m.Update(msg)
+		*m = updated.(Model)
+	}
+}
+
+func TestBootTab(t *testing.T) {
+	loads := 0
+	in := Input{Result: makeResult(), Boot: func() (*audit.BootAnalysis, error) {
+		loads++
+		return &audit.BootAnalysis{
+			TotalTime:     12 * time.Second,
+			KernelTime:    2 * time.Second,
+			UserspaceTime: 10 * time.Second,
+			Units: []audit.UnitTiming{
+				{Name: "fast.service", Time: time.Second},
+				{Name: "slow.service", Time: 4 * time.Second},
+			},
+			CriticalChain: []audit.ChainLink{
+				{Name: "multi-user.target", ActiveAt: 10 * time.Second},
+				{Name: "slow.service", ActiveAt: 6 * time.Second, Time: 4 * time.Second, IsCritical: true, Depth: 1},
+			},
+		}, nil
+	}}
+	m, press := issuesView(t, in)
+	m.view = ViewDashboard
+
+	cmd := press(runes("b"))
+	if m.view != ViewBoot || !strings.Contains(m.View(), "Analyzing") {
+		t.Fatalf("b did not open the boot tab loading:\n%s", m.View())
+	}
+	loadTab(t, m, cmd)
+
+	out := m.View()
+	for _, want := range []string{
+		"Total:     12s",
+		"Userspace: 10s",
+		"slow.service: active at 6s, took 4s, critical",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("boot tab missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "slow.service") > strings.Index(out, "fast.service") {
+		t.Errorf("slowest unit is not charted first:\n%s", out)
+	}
+
+	press(runes("d"))
+	if cmd := press(runes("b")); cmd != nil || loads != 1 {
+		t.Errorf("reopening the boot tab loaded it again (%d loads)", loads)
+	}
+}
+
+func TestSecurityTab(t *testing.T) {
+	in := Input{Result: makeResult(), Security: func() ([]audit.SecurityScore, error) {
+		return []audit.SecurityScore{
+			{Unit: "safe.service", Score: 1.2, Exposure: "SAFE"},
+			{Unit: "open.service", Score: 9.6, Exposure: "UNSAFE", Estimated: true},
+			{Unit: "mid.service", Score: 5.1, Exposure: "MEDIUM"},
+		}, nil
+	}}
+	m, press := issuesView(t, in)
+
+	loadTab(t, m, press(runes("t")))
+	out := m.View()
+	if !strings.Contains(out, "9.6  UNSAFE    open.service (estimated)") {
+		t.Errorf("security tab missing the unsafe service:\n%s", out)
+	}
+	open, mid, safe := strings.Index(out, "open.service"), strings.Index(out, "mid.service"), strings.Index(out, "safe.service")
+	if open > mid || mid > safe {
+		t.Errorf("scores are not listed highest first:\n%s", out)
+	}
+}
+
+func TestTabErrors(t *testing.T) {
+	in := Input{Result: makeResult(), Security: func() ([]audit.SecurityScore, error) {
+		return nil, errors.New("systemd-analyze not found")
+	}}
+	m, press := issuesView(t, in)
+	m.view = ViewDashboard
+
+	loadTab(t, m, press(runes("t")))
+	if out := m.View(); !strings.Contains(out, "Security analysis failed: systemd-analyze not found") {
+		t.Errorf("security tab does not show the error:\n%s", out)
+	}
+
+	// Without a loader the boot tab says it is unavailable
+	loadTab(t, m, press(runes("b")))
+	if out := m.View(); !strings.Contains(out, "boot times are not available") {
+		t.Errorf("boot tab does not say it is unavailable:\n%s", out)
+	}
+}
+
+func TestIssuesViewBKeepsBestPracticeToggle(t *testing.T) {
+	m, press := issuesView(t, Input{Result: makeResult()})
+
+	press(runes("b"))
+	if m.view != ViewIssues || !strings.Contains(m.issueList.Title, "[bestpractice]") {
+		t.Errorf("b in the issues view did not toggle best practice issues: view %d, title %q", m.view, m.issueList.Title)
+	}
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme is a set of colors for the TUI
+type Theme int
+
+const (
+	// ThemeAuto picks the dark or light theme after the terminal's
+	// background
+	ThemeAuto Theme = iota
+	// ThemeDark suits terminals with a dark background
+	ThemeDark
+	// ThemeLight suits terminals with a light background
+	ThemeLight
+	// ThemeMono draws without color, for --no-color and NO_COLOR
+	ThemeMono
+)
+
+var themeNames = []string{"auto", "dark", "light", "mono"}
+
+func (t Theme) String() string {
+	if t < 0 || int(t) >= len(themeNames) {
+		return "unknown"
+	}
+	return themeNames[t]
+}
+
+// ParseTheme returns the theme named s: auto, dark, light or mono
+func ParseTheme(s string) (Theme, error) {
+	for i, name := range themeNames {
+		if s == name {
+			return Theme(i), nil
+		}
+	}
+	return ThemeAuto, fmt.Errorf("unknown theme %q, use auto, dark, light or mono", s)
+}
+
+// StylesFor returns the styles of a theme. ThemeAuto gets the dark styles;
+// Run resolves it from the terminal first.
+func StylesFor(theme Theme) Styles {
+	switch theme {
+	case ThemeLight:
+		return LightStyles()
+	case ThemeMono:
+		return MonoStyles()
+	default:
+		return DefaultStyles()
+	}
+}
+
+// theme returns the theme opts select: mono when colors are off or in ASCII
+// mode, else the theme asked for
+func (o Options) theme() Theme {
+	if o.NoColor || o.ASCII {
+		return ThemeMono
+	}
+	return o.Theme
+}
+
+// detectTheme resolves ThemeAuto from the terminal's background color, which
+// terminals that do not report it leave as dark
+func detectTheme(theme Theme) Theme {
+	if theme != ThemeAuto {
+		return theme
+	}
+	if lipgloss.HasDarkBackground() {
+		return ThemeDark
+	}
+	return ThemeLight
+}
+
+// useMonoProfile stops the components drawn with their own colors, such as
+// the list's status bar and help, from using color
+func useMonoProfile() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
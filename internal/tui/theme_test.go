@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestParseTheme(t *testing.T) {
+	for _, name := range []string{"auto", "dark", "light", "mono"} {
+		theme, err := ParseTheme(name)
+		if err != nil || theme.String() != name {
+			t.Errorf("ParseTheme(%q) = %v, %v", name, theme, err)
+		}
+	}
+	if _, err := ParseTheme("solarized"); err == nil {
+		t.Error("ParseTheme should reject unknown themes")
+	}
+}
+
+func TestOptionsTheme(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want Theme
+	}{
+		{Options{}, ThemeAuto},
+		{Options{Theme: ThemeLight}, ThemeLight},
+		{Options{Theme: ThemeLight, NoColor: true}, ThemeMono},
+		{Options{Theme: ThemeDark, ASCII: true}, ThemeMono},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.theme(); got != tt.want {
+			t.Errorf("%+v.theme() = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+	if got := StylesFor(ThemeLight).Theme; got != ThemeLight {
+		t.Errorf("StylesFor(ThemeLight).Theme = %v", got)
+	}
+}
+
+// trimLines drops the padding lipgloss adds at the end of each line
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestMonoDashboardSnapshot(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+
+	m := New(Input{Result: makeResult()}, Options{NoColor: true})
+	m.width, m.height = 100, 40
+
+	got := trimLines(m.View())
+	want := `
+    sdaudit - Systemd Auditing Tool
+
+
+  Scan Summary
+    Units scanned: 1
+    Rules checked: 0
+    Issues found:  1
+
+  Issues by Severity
+    !!! CRITICAL    1 ` + strings.Repeat("█", 40) + `
+    !!  HIGH        0
+    !   MEDIUM      0
+    -   LOW         0
+    .   INFO        0
+
+  Issues by Category
+    security        1
+    reliability     0
+    performance     0
+    bestpractice    0
+
+  Top Rules       Top Units
+    SEC001   1      test.service   1
+
+  [i]ssues  [d]ashboard  [b]oot  securi[t]y  [r]escan  [?]help  [q]uit
+`
+	if got != want {
+		t.Errorf("mono dashboard:\n%q\nwant:\n%q", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package unitfile
+
+import (
+	"sort"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Change is a directive that differs between two versions of a unit file
+type Change struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	// Kind is "added", "removed" or "changed"
+	Kind string `json:"kind"`
+	// Old are the directive's values in the first file and New those in the
+	// second, in file order; nil where the directive is not set
+	Old []string `json:"old,omitempty"`
+	New []string `json:"new,omitempty"`
+}
+
+// Diff returns the directives that differ between from and to, sorted by
+// section in the order systemd writes them, [Unit], the type's section and
+// [Install], then by key. Comments, blank lines and the order of directives do not
+// count; the order of a directive's values does.
+func Diff(from, to *types.UnitFile) []Change {
+	keys := make(map[[2]string]bool)
+	for _, u := range []*types.UnitFile{from, to} {
+		for name, section := range u.Sections {
+			for key := range section.Directives {
+				keys[[2]string{name, key}] = true
+			}
+		}
+	}
+
+	var changes []Change
+	for k := range keys {
+		before, after := values(from, k[0], k[1]), values(to, k[0], k[1])
+		if slicesEqual(before, after) {
+			continue
+		}
+		kind := "changed"
+		switch {
+		case before == nil:
+			kind = "added"
+		case after == nil:
+			kind = "removed"
+		}
+		changes = append(changes, Change{Section: k[0], Key: k[1], Kind: kind, Old: before, New: after})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if a, b := changes[i].Section, changes[j].Section; a != b {
+			if sectionRank(a) != sectionRank(b) {
+				return sectionRank(a) < sectionRank(b)
+			}
+			return a < b
+		}
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// sectionRank orders [Unit] first and [Install] last, with the type's
+// section, or any other, between them
+func sectionRank(section string) int {
+	switch section {
+	case "Unit":
+		return 0
+	case "Install":
+		return 2
+	}
+	return 1
+}
+
+func values(unit *types.UnitFile, section, key string) []string {
+	var out []string
+	for _, d := range unit.GetDirectives(section, key) {
+		out = append(out, d.Value)
+	}
+	return out
+}
+
+func slicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package unitfile
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// DropIns returns the drop-in files of the unit named name in the unit
+// search paths, the .conf files of name.d directories, in the order systemd
+// applies them: by file name, a drop-in in an earlier path shadowing those of
+// the same file name in later ones.
+func DropIns(paths []string, name string) []string {
+	byName := make(map[string]string)
+	var names []string
+	for _, dir := range paths {
+		entries, err := os.ReadDir(filepath.Join(dir, name+".d"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".conf") {
+				continue
+			}
+			if _, ok := byName[entry.Name()]; ok {
+				continue
+			}
+			byName[entry.Name()] = filepath.Join(dir, name+".d", entry.Name())
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	files := make([]string, len(names))
+	for i, n := range names {
+		files[i] = byName[n]
+	}
+	return files
+}
+
+// ApplyDropIns adds the directives of drop-in files to unit, in order, after
+// those of the unit file, as systemd does. An empty assignment stays in the
+// list, where it resets what comes before as in a single file. Drop-ins that
+// cannot be read or parsed add parse errors to the unit.
+func ApplyDropIns(unit *types.UnitFile, files []string) {
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			unit.ParseErrors = append(unit.ParseErrors, types.ParseError{Message: err.Error(), File: file})
+			continue
+		}
+		dropIn, _ := ParseContent(file, string(content))
+		for _, e := range dropIn.ParseErrors {
+			e.File = file
+			unit.ParseErrors = append(unit.ParseErrors, e)
+		}
+
+		names := make([]string, 0, len(dropIn.Sections))
+		for name := range dropIn.Sections {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			section := unit.Sections[name]
+			if section == nil {
+				section = &types.Section{Name: name, Directives: make(map[string][]types.Directive)}
+				unit.Sections[name] = section
+			}
+			for key, directives := range dropIn.Sections[name].Directives {
+				for _, d := range directives {
+					d.File = file
+					section.Directives[key] = append(section.Directives[key], d)
+				}
+			}
+		}
+		unit.DropIns = append(unit.DropIns, file)
+	}
+}
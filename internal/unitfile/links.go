@@ -0,0 +1,73 @@
+package unitfile
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Link is a symlink in a unit directory, as created by systemctl enable or
+// shipped by a package: a dependency in a unit's .wants/, .requires/ or
+// .upholds/ directory, or another name for a unit.
+type Link struct {
+	From string // Unit that wants, requires or upholds To, or the alias
+	To   string // Unit linked to
+	Kind string // "Wants", "Requires", "Upholds" or "Alias"
+	Path string // Path of the symlink
+}
+
+// linkDirs maps the suffix of a dependency directory to its kind
+var linkDirs = map[string]string{
+	".wants":    "Wants",
+	".requires": "Requires",
+	".upholds":  "Upholds",
+}
+
+// LoadLinks returns the dependency links and aliases in unit directories.
+// Directories that cannot be read are skipped.
+func LoadLinks(paths []string) []Link {
+	var links []Link
+	for _, dir := range paths {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			path := filepath.Join(dir, name)
+
+			if entry.IsDir() {
+				ext := filepath.Ext(name)
+				kind, ok := linkDirs[ext]
+				if !ok || !IsUnitFile(strings.TrimSuffix(name, ext)) {
+					continue
+				}
+				deps, err := os.ReadDir(path)
+				if err != nil {
+					continue
+				}
+				for _, dep := range deps {
+					if IsUnitFile(dep.Name()) {
+						links = append(links, Link{From: strings.TrimSuffix(name, ext), To: dep.Name(), Kind: kind, Path: filepath.Join(path, dep.Name())})
+					}
+				}
+				continue
+			}
+
+			// An alias is a link to a unit file of another name
+			target, err := os.Readlink(path)
+			if err != nil || !IsUnitFile(name) {
+				continue
+			}
+			if base := filepath.Base(target); base != name && IsUnitFile(base) {
+				links = append(links, Link{From: name, To: base, Kind: "Alias", Path: path})
+			}
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Path < links[j].Path
+	})
+	return links
+}
@@ -0,0 +1,239 @@
+package unitfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// maxLinks is how many symlinks Resolve follows before giving up, as the
+// kernel does
+const maxLinks = 40
+
+// Set collects units by name, parsing each unit file once however many
+// paths lead to it: a file reached through an enablement link, an alias or
+// a symlinked directory such as /lib on merged-/usr systems is one unit.
+// Of the files of the same name, the one in the earliest search path wins,
+// as in systemd, and the others are kept as the unit's shadowed paths.
+type Set struct {
+	// Root is the root directory of an offline system image, empty for the
+	// live system; absolute link targets are taken below it
+	Root  string
+	Units map[string]*types.UnitFile
+	// files holds the real paths of the files loaded
+	files map[string]bool
+	// dirs are the resolved search paths, in precedence order
+	dirs []string
+	// origins are the origins of the units in the resolved runtime
+	// directories that systemd writes units to itself
+	origins map[string]string
+	// candidates are the files loaded for each unit name, by precedence
+	candidates map[string][]candidate
+	// loaded counts the files outside the search paths, which rank after
+	// them in the order they are loaded
+	loaded int
+}
+
+type candidate struct {
+	rank int
+	unit *types.UnitFile
+}
+
+// NewSet returns an empty set for the system rooted at root, with the unit
+// search paths in precedence order
+func NewSet(root string, paths ...string) *Set {
+	s := &Set{
+		Root:       root,
+		Units:      make(map[string]*types.UnitFile),
+		files:      make(map[string]bool),
+		candidates: make(map[string][]candidate),
+		origins:    make(map[string]string),
+	}
+	for _, path := range paths {
+		origin := runtimeOrigins[targetPath(root, path)]
+		if dir, err := Resolve(root, path); err == nil {
+			path = dir
+		}
+		path = filepath.Clean(path)
+		s.dirs = append(s.dirs, path)
+		if origin != "" {
+			s.origins[path] = origin
+		}
+	}
+	return s
+}
+
+// targetPath returns path as the system rooted at root sees it
+func targetPath(root, path string) string {
+	if root == "" {
+		return filepath.Clean(path)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return filepath.Clean(path)
+	}
+	return filepath.Join("/", rel)
+}
+
+// Load parses the unit file at path and adds it to the set, following
+// symlinks to the real file, whose name and path the unit gets. A real file
+// already in the set is not parsed again. The file ranks by the search path
+// it is in, or else by the search path of the link that led to it. Masked
+// units keep the path of their link to /dev/null. Units in the directories
+// of generators and transient units get their Origin.
+func (s *Set) Load(path string) error {
+	// Masks are read by name, as images seldom have a /dev/null to resolve
+	if target, err := os.Readlink(path); err == nil && target == "/dev/null" {
+		unit, err := Parse(path)
+		if err != nil {
+			return err
+		}
+		unit.Origin = s.origin(path, path)
+		s.add(unit, s.rank(path, path))
+		return nil
+	}
+
+	real, err := Resolve(s.Root, path)
+	if err != nil {
+		return err
+	}
+	if s.files[real] {
+		return nil
+	}
+
+	unit, err := Parse(real)
+	if err != nil {
+		return err
+	}
+	s.files[real] = true
+	unit.Origin = s.origin(real, path)
+	s.add(unit, s.rank(real, path))
+	return nil
+}
+
+// origin returns the origin of the units in the search path a file or the
+// link to it is in, empty for unit files
+func (s *Set) origin(real, path string) string {
+	for _, p := range []string{real, path} {
+		if dir := filepath.Dir(p); slices.Contains(s.dirs, dir) {
+			return s.origins[dir]
+		}
+	}
+	return ""
+}
+
+// rank returns the precedence of a file: the index of the search path it or
+// the link to it is in, lower first
+func (s *Set) rank(real, path string) int {
+	for _, p := range []string{real, path} {
+		for i, dir := range s.dirs {
+			if filepath.Dir(p) == dir {
+				return i
+			}
+		}
+	}
+	s.loaded++
+	return len(s.dirs) + s.loaded
+}
+
+// add adds a unit under its name, where the file of the lowest rank wins
+func (s *Set) add(unit *types.UnitFile, rank int) {
+	cands := append(s.candidates[unit.Name], candidate{rank: rank, unit: unit})
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].rank < cands[j].rank })
+	s.candidates[unit.Name] = cands
+
+	winner := cands[0].unit
+	winner.ShadowedPaths = nil
+	for _, c := range cands[1:] {
+		winner.ShadowedPaths = append(winner.ShadowedPaths, c.unit.Path)
+	}
+	s.Units[unit.Name] = winner
+}
+
+// ApplyDropIns adds to each unit that is not masked the drop-ins of its .d
+// directories in the search paths and next to the unit file
+func (s *Set) ApplyDropIns() {
+	for _, unit := range s.Units {
+		if unit.Masked {
+			continue
+		}
+		dirs := s.dirs
+		if dir := filepath.Dir(unit.Path); !slices.Contains(dirs, dir) {
+			dirs = append(slices.Clone(dirs), dir)
+		}
+		ApplyDropIns(unit, DropIns(dirs, unit.Name))
+	}
+}
+
+// Resolve returns the path of the file path leads to, with every symlink
+// followed. Absolute link targets are taken below root, as the target system
+// sees them; relative paths stay relative when no link needs resolving.
+func Resolve(root, path string) (string, error) {
+	if root == "" {
+		return filepath.EvalSymlinks(path)
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return filepath.EvalSymlinks(path)
+	}
+
+	resolved := "/"
+	todo := strings.Split(rel, "/")
+	links := 0
+	for len(todo) > 0 {
+		part := todo[0]
+		todo = todo[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, part)
+		info, err := os.Lstat(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxLinks {
+			return "", &os.PathError{Op: "resolve", Path: path, Err: errors.New("too many levels of symbolic links")}
+		}
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			resolved = "/"
+		}
+		todo = append(strings.Split(target, "/"), todo...)
+	}
+	return filepath.Join(root, resolved), nil
+}
+
+// MarkEnabled records on units the links in .wants/, .requires/ and
+// .upholds/ directories that enable them. Links to units not in units are
+// ignored.
+func MarkEnabled(units map[string]*types.UnitFile, links []Link) {
+	for _, link := range links {
+		if link.Kind == "Alias" {
+			continue
+		}
+		if unit, ok := units[link.To]; ok {
+			unit.Enabled = true
+			unit.WantedBySymlinks = append(unit.WantedBySymlinks, link.Path)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+// Package unitfile parses systemd unit files.
+package unitfile
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/supabase/sdaudit/internal/unitname"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Parse parses a systemd unit file from the given path
+func Parse(path string) (*types.UnitFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := ParseContent(path, string(content))
+	if err != nil {
+		return nil, err
+	}
+	unit.Masked = isMasked(path, content)
+	return unit, nil
+}
+
+// isMasked reports whether a unit file is masked: linked to /dev/null or
+// empty. The link target is checked by name so that masks in an offline
+// image are recognized too.
+func isMasked(path string, content []byte) bool {
+	if target, err := os.Readlink(path); err == nil && target == "/dev/null" {
+		return true
+	}
+	return len(content) == 0
+}
+
+// ParseContent parses a systemd unit file from string content. Lines
+// systemd would reject, such as a directive before any section header, are
+// recorded as the unit's parse errors and otherwise skipped; an error is
+// returned only when the content cannot be read at all.
+func ParseContent(path, content string) (*types.UnitFile, error) {
+	// Names are kept as references to them are looked up, with their
+	// escapes spelled one way
+	name := unitname.Normalize(filepath.Base(path))
+	typ := UnitType(name)
+
+	unit := &types.UnitFile{
+		Name:     name,
+		Path:     path,
+		Type:     typ,
+		Sections: make(map[string]*types.Section),
+		Raw:      content,
+	}
+	fail := func(line int, format string, args ...any) {
+		unit.ParseErrors = append(unit.ParseErrors, types.ParseError{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var currentSection *types.Section
+	// badSection is set after an invalid section header, whose directives
+	// are skipped without reporting each one
+	badSection := false
+
+	// parseLine parses a section header or directive starting on line start
+	parseLine := func(start int, line string) {
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || len(line) == 2 {
+				fail(start, "invalid section header %q", line)
+				currentSection, badSection = nil, true
+				return
+			}
+			sectionName := line[1 : len(line)-1]
+			badSection = false
+			currentSection = &types.Section{
+				Name:       sectionName,
+				Directives: make(map[string][]types.Directive),
+			}
+			unit.Sections[sectionName] = currentSection
+			return
+		}
+
+		idx := strings.Index(line, "=")
+		switch {
+		case badSection:
+			return
+		case currentSection == nil:
+			fail(start, "directive outside of any section")
+			return
+		case idx < 0:
+			fail(start, "missing '=' in %q", line)
+			return
+		case idx == 0:
+			fail(start, "missing directive name before '='")
+			return
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+		if escapedDirective(key) {
+			if seq := invalidEscape(value); seq != "" {
+				fail(start, "invalid escape sequence %s in %s=", seq, key)
+			}
+		}
+
+		directive := types.Directive{
+			Key:   key,
+			Value: value,
+			Line:  start,
+		}
+
+		currentSection.Directives[key] = append(currentSection.Directives[key], directive)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	lineNum := 0
+	// continued holds a directive whose line ends with a backslash, joined
+	// with the lines that follow until one does not
+	var continued string
+	continuedLine := 0
+
+	for scanner.Scan() {
+		lineNum++
+		text := scanner.Text()
+		if !utf8.ValidString(text) {
+			fail(lineNum, "invalid UTF-8")
+			continue
+		}
+		line := strings.TrimSpace(text)
+
+		if continuedLine > 0 {
+			// Comments within a continued directive are dropped, as in systemd
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+				continue
+			}
+			line = continued + " " + line
+		} else {
+			if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+				continue
+			}
+			continuedLine = lineNum
+		}
+		if strings.HasSuffix(line, "\\") {
+			continued = strings.TrimSpace(strings.TrimSuffix(line, "\\"))
+			continue
+		}
+		parseLine(continuedLine, line)
+		continued, continuedLine = "", 0
+	}
+	if continuedLine > 0 {
+		parseLine(continuedLine, continued)
+	}
+	if err := scanner.Err(); err != nil {
+		fail(lineNum+1, "%v", err)
+	}
+
+	return unit, nil
+}
+
+// escapedDirective reports whether systemd unescapes the values of a
+// directive, so that an invalid escape sequence makes it reject the line
+func escapedDirective(key string) bool {
+	return strings.HasPrefix(key, "Exec") || key == "Environment"
+}
+
+// invalidEscape returns the first backslash escape in value that systemd
+// does not know, empty if there is none
+func invalidEscape(value string) string {
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			continue
+		}
+		if i+1 == len(value) {
+			return "\\"
+		}
+		i++
+		if !strings.ContainsRune(`abfnrtvsxuU01234567\"' ;`, rune(value[i])) {
+			return value[i-1 : i+1]
+		}
+	}
+	return ""
+}
+
+// UnitType returns the type suffix of a unit name, such as "service", or
+// "unknown" for a name without one
+func UnitType(name string) string {
+	ext := filepath.Ext(name)
+	if ext == "" {
+		return "unknown"
+	}
+	return ext[1:]
+}
+
+// LoadDirectory loads all unit files from a directory
+func LoadDirectory(dir string) (map[string]*types.UnitFile, error) {
+	files, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	set := NewSet("", dir)
+	for _, path := range files {
+		_ = set.Load(path)
+	}
+	set.ApplyDropIns()
+	MarkEnabled(set.Units, LoadLinks([]string{dir}))
+
+	return set.Units, nil
+}
+
+// Files returns the unit files in a directory, or path itself if it is a
+// file, without parsing them
+func Files(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !IsUnitFile(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(path, entry.Name()))
+	}
+	return files, nil
+}
+
+// Walk returns the unit files in dir and its subdirectories, without parsing
+// them. Hidden directories and the .d, .wants, .requires and .upholds
+// directories of units are not entered; drop-ins and links are read with the
+// units they belong to.
+func Walk(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && (strings.HasPrefix(entry.Name(), ".") || IsUnitFile(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsUnitFile(entry.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// LoadPaths loads unit files from multiple directories, given in precedence
+// order: of the files of the same name, the one in the earliest directory
+// wins
+func LoadPaths(paths []string) (map[string]*types.UnitFile, error) {
+	set := NewSet("", paths...)
+
+	for _, path := range paths {
+		files, err := Files(path)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			_ = set.Load(file)
+		}
+	}
+	set.ApplyDropIns()
+	MarkEnabled(set.Units, LoadLinks(paths))
+
+	return set.Units, nil
+}
+
+func IsUnitFile(name string) bool {
+	extensions := []string{".service", ".socket", ".timer", ".mount", ".automount", ".swap", ".target", ".path", ".slice", ".scope"}
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultPaths returns the default systemd unit file paths, in the order
+// systemd looks units up. /lib comes last, after /usr/lib, as on systems
+// where it is not a link to /usr/lib.
+func DefaultPaths() []string {
+	return []string{
+		"/etc/systemd/system",
+		"/run/systemd/system",
+		"/usr/lib/systemd/system",
+		"/lib/systemd/system",
+	}
+}
+
+// RuntimePaths returns the unit search paths with the directories of the
+// units systemd writes itself, generated at boot or created at runtime, in
+// precedence order
+func RuntimePaths() []string {
+	return []string{
+		"/run/systemd/transient",
+		"/run/systemd/generator.early",
+		"/etc/systemd/system",
+		"/run/systemd/system",
+		"/run/systemd/generator",
+		"/usr/lib/systemd/system",
+		"/lib/systemd/system",
+		"/run/systemd/generator.late",
+	}
+}
+
+// runtimeOrigins are the origins of the units in the runtime directories
+var runtimeOrigins = map[string]string{
+	"/run/systemd/transient":       types.UnitOriginTransient,
+	"/run/systemd/generator.early": types.UnitOriginGenerator,
+	"/run/systemd/generator":       types.UnitOriginGenerator,
+	"/run/systemd/generator.late":  types.UnitOriginGenerator,
+}
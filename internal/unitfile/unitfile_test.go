@@ -0,0 +1,657 @@
+package unitfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestParse(t *testing.T) {
+	// Create a temporary unit file
+	content := `[Unit]
+Description=Test Service
+After=network.target
+
+[Service]
+Type=simple
+ExecStart=/usr/bin/testapp
+User=testuser
+Restart=always
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+`
+	tmpDir := t.TempDir()
+	tmpFile := filepath.Join(tmpDir, "test.service")
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	unit, err := Parse(tmpFile)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Check basic properties
+	if unit.Name != "test.service" {
+		t.Errorf("Name = %q, want %q", unit.Name, "test.service")
+	}
+	if unit.Type != "service" {
+		t.Errorf("Type = %q, want %q", unit.Type, "service")
+	}
+	if unit.Path != tmpFile {
+		t.Errorf("Path = %q, want %q", unit.Path, tmpFile)
+	}
+
+	// Check sections exist
+	if _, ok := unit.Sections["Unit"]; !ok {
+		t.Error("Missing Unit section")
+	}
+	if _, ok := unit.Sections["Service"]; !ok {
+		t.Error("Missing Service section")
+	}
+	if _, ok := unit.Sections["Install"]; !ok {
+		t.Error("Missing Install section")
+	}
+
+	// Check directives
+	tests := []struct {
+		section string
+		key     string
+		want    string
+	}{
+		{"Unit", "Description", "Test Service"},
+		{"Unit", "After", "network.target"},
+		{"Service", "Type", "simple"},
+		{"Service", "ExecStart", "/usr/bin/testapp"},
+		{"Service", "User", "testuser"},
+		{"Service", "Restart", "always"},
+		{"Service", "RestartSec", "5"},
+		{"Install", "WantedBy", "multi-user.target"},
+	}
+
+	for _, tt := range tests {
+		got := unit.GetDirective(tt.section, tt.key)
+		if got != tt.want {
+			t.Errorf("GetDirective(%q, %q) = %q, want %q", tt.section, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestParseMultipleDirectives(t *testing.T) {
+	content := `[Service]
+ExecStartPre=/usr/bin/prep1
+ExecStartPre=/usr/bin/prep2
+ExecStartPre=/usr/bin/prep3
+ExecStart=/usr/bin/main
+`
+	tmpDir := t.TempDir()
+	tmpFile := filepath.Join(tmpDir, "multi.service")
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	unit, err := Parse(tmpFile)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	directives := unit.GetDirectives("Service", "ExecStartPre")
+	if len(directives) != 3 {
+		t.Errorf("Got %d ExecStartPre directives, want 3", len(directives))
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	content := `[Unit]
+# This is a comment
+Description=Test Service
+; This is also a comment
+After=network.target
+`
+	tmpDir := t.TempDir()
+	tmpFile := filepath.Join(tmpDir, "comments.service")
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	unit, err := Parse(tmpFile)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if unit.GetDirective("Unit", "Description") != "Test Service" {
+		t.Error("Failed to parse directive after comment")
+	}
+	if unit.GetDirective("Unit", "After") != "network.target" {
+		t.Error("Failed to parse directive after semicolon comment")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []types.ParseError
+	}{
+		{
+			name:    "valid",
+			content: "[Service]\nExecStart=/bin/echo a\\tb \\x41\n",
+		},
+		{
+			name:    "unterminated section header",
+			content: "[Service\nExecStart=/bin/true\nUser=app\n[Install]\nWantedBy=multi-user.target\n",
+			want:    []types.ParseError{{Line: 1, Message: `invalid section header "[Service"`}},
+		},
+		{
+			name:    "directive before any section",
+			content: "Description=App\n[Unit]\n",
+			want:    []types.ParseError{{Line: 1, Message: "directive outside of any section"}},
+		},
+		{
+			name:    "missing equals sign",
+			content: "[Unit]\nDescription\n",
+			want:    []types.ParseError{{Line: 2, Message: `missing '=' in "Description"`}},
+		},
+		{
+			name:    "bad escape",
+			content: "[Service]\nExecStart=/bin/echo \\q\n",
+			want:    []types.ParseError{{Line: 2, Message: `invalid escape sequence \q in ExecStart=`}},
+		},
+		{
+			name:    "invalid UTF-8",
+			content: "[Unit]\nDescription=\xff\n",
+			want:    []types.ParseError{{Line: 2, Message: "invalid UTF-8"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit, err := ParseContent("app.service", tt.content)
+			if err != nil {
+				t.Fatalf("ParseContent failed: %v", err)
+			}
+			if !reflect.DeepEqual(unit.ParseErrors, tt.want) {
+				t.Errorf("ParseErrors = %+v, want %+v", unit.ParseErrors, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContinuation(t *testing.T) {
+	content := "[Service]\nExecStart=/usr/bin/app \\\n  # a comment\n  --verbose \\\n  --port=80\nUser=app\n"
+	unit, err := ParseContent("app.service", content)
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if len(unit.ParseErrors) != 0 {
+		t.Errorf("ParseErrors = %+v, want none", unit.ParseErrors)
+	}
+	directives := unit.GetDirectives("Service", "ExecStart")
+	if len(directives) != 1 || directives[0].Value != "/usr/bin/app --verbose --port=80" || directives[0].Line != 2 {
+		t.Errorf("ExecStart = %+v, want the joined command on line 2", directives)
+	}
+	if unit.GetDirective("Service", "User") != "app" {
+		t.Error("Failed to parse the directive after a continued one")
+	}
+}
+
+func TestParseNotFound(t *testing.T) {
+	_, err := Parse("/nonexistent/path/test.service")
+	if err == nil {
+		t.Error("Expected error for non-existent file")
+	}
+}
+
+func TestParseMasked(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	linked := filepath.Join(tmpDir, "linked.service")
+	if err := os.Symlink("/dev/null", linked); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	empty := filepath.Join(tmpDir, "empty.service")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	regular := filepath.Join(tmpDir, "regular.service")
+	if err := os.WriteFile(regular, []byte("[Service]\nExecStart=/bin/true\n"), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{linked, true},
+		{empty, true},
+		{regular, false},
+	}
+
+	for _, tt := range tests {
+		unit, err := Parse(tt.path)
+		if err != nil {
+			t.Fatalf("Parse(%s) failed: %v", tt.path, err)
+		}
+		if unit.Masked != tt.want {
+			t.Errorf("Parse(%s).Masked = %v, want %v", filepath.Base(tt.path), unit.Masked, tt.want)
+		}
+	}
+}
+
+func TestUnitType(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"nginx.service", "service"},
+		{"nginx.socket", "socket"},
+		{"backup.timer", "timer"},
+		{"dev-sda.mount", "mount"},
+		{"tmp.mount", "mount"},
+		{"home.automount", "automount"},
+		{"swap.swap", "swap"},
+		{"emergency.target", "target"},
+		{"syslog.path", "path"},
+		{"session.slice", "slice"},
+		{"machine.scope", "scope"},
+		{"unknown.xyz", "xyz"},
+	}
+
+	for _, tt := range tests {
+		got := UnitType(tt.filename)
+		if got != tt.want {
+			t.Errorf("UnitType(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestLoadLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "app.service"), []byte("[Service]\nExecStart=/bin/true\n"), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	for _, dir := range []string{"multi-user.target.wants", "app.service.requires", "notes.d"} {
+		if err := os.Mkdir(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	for link, target := range map[string]string{
+		"multi-user.target.wants/app.service": "../app.service",
+		"app.service.requires/db.service":     "/usr/lib/systemd/system/db.service",
+		"notes.d/app.service":                 "../app.service",
+		"dbus-org.example.App.service":        "app.service",
+		"masked.service":                      "/dev/null",
+	} {
+		if err := os.Symlink(target, filepath.Join(tmpDir, link)); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+	}
+
+	got := LoadLinks([]string{tmpDir, filepath.Join(tmpDir, "missing")})
+	want := []Link{
+		{From: "app.service", To: "db.service", Kind: "Requires", Path: filepath.Join(tmpDir, "app.service.requires/db.service")},
+		{From: "dbus-org.example.App.service", To: "app.service", Kind: "Alias", Path: filepath.Join(tmpDir, "dbus-org.example.App.service")},
+		{From: "multi-user.target", To: "app.service", Kind: "Wants", Path: filepath.Join(tmpDir, "multi-user.target.wants/app.service")},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("LoadLinks() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// writeImage writes a system image with an enabled unit shipped in
+// /usr/lib, the merged-/usr /lib link, an alias, a mask and an enablement
+// link to it, all with absolute targets as systemctl creates them
+func writeImage(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	etc := filepath.Join(root, "etc/systemd/system")
+	lib := filepath.Join(root, "usr/lib/systemd/system")
+	for _, dir := range []string{filepath.Join(etc, "multi-user.target.wants"), lib} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range map[string]string{
+		"app.service":       "[Service]\nExecStart=/usr/bin/app\n",
+		"masked.service":    "[Service]\nExecStart=/usr/bin/masked\n",
+		"multi-user.target": "[Unit]\nDescription=Multi-User System\n",
+	} {
+		if err := os.WriteFile(filepath.Join(lib, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"lib": "usr/lib",
+		"etc/systemd/system/multi-user.target.wants/app.service": "/lib/systemd/system/app.service",
+		"etc/systemd/system/dbus-org.example.App.service":        "/usr/lib/systemd/system/app.service",
+		"etc/systemd/system/masked.service":                      "/dev/null",
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestSetFollowsLinksOnce(t *testing.T) {
+	root := writeImage(t)
+	set := NewSet(root)
+	paths := []string{"etc/systemd/system", "etc/systemd/system/multi-user.target.wants", "lib/systemd/system", "usr/lib/systemd/system"}
+	for i := range paths {
+		paths[i] = filepath.Join(root, paths[i])
+		files, err := Files(paths[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			if err := set.Load(file); err != nil {
+				t.Fatalf("Load(%s) failed: %v", file, err)
+			}
+		}
+	}
+	MarkEnabled(set.Units, LoadLinks(paths))
+
+	if len(set.Units) != 3 {
+		t.Fatalf("loaded %d units, want app.service, masked.service and multi-user.target: %v", len(set.Units), set.Units)
+	}
+	app := set.Units["app.service"]
+	if app == nil || app.Path != filepath.Join(root, "usr/lib/systemd/system/app.service") {
+		t.Fatalf("app.service = %+v, want it loaded from its real path", app)
+	}
+	want := filepath.Join(root, "etc/systemd/system/multi-user.target.wants/app.service")
+	if !app.Enabled || len(app.WantedBySymlinks) != 1 || app.WantedBySymlinks[0] != want {
+		t.Errorf("app.service Enabled = %v, WantedBySymlinks = %v, want [%s]", app.Enabled, app.WantedBySymlinks, want)
+	}
+
+	// The mask is loaded as the link itself
+	set = NewSet(root)
+	if err := set.Load(filepath.Join(root, "etc/systemd/system/masked.service")); err != nil {
+		t.Fatal(err)
+	}
+	if masked := set.Units["masked.service"]; masked == nil || !masked.Masked {
+		t.Errorf("masked.service = %+v, want it masked", masked)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root := writeImage(t)
+	for path, want := range map[string]string{
+		"etc/systemd/system/multi-user.target.wants/app.service": "usr/lib/systemd/system/app.service",
+		"lib/systemd/system/app.service":                         "usr/lib/systemd/system/app.service",
+	} {
+		got, err := Resolve(root, filepath.Join(root, path))
+		if err != nil || got != filepath.Join(root, want) {
+			t.Errorf("Resolve(%s) = %q, %v, want %q", path, got, err, filepath.Join(root, want))
+		}
+	}
+
+	loop := filepath.Join(root, "loop.service")
+	if err := os.Symlink("loop.service", loop); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Resolve(root, loop); err == nil {
+		t.Error("Resolve of a symlink loop should fail")
+	}
+}
+
+func TestLoadPathsPrecedence(t *testing.T) {
+	etc, run, usrLib, lib := "etc/systemd/system", "run/systemd/system", "usr/lib/systemd/system", "lib/systemd/system"
+	tests := []struct {
+		name string
+		// files are the unit files by directory below the root; "@target"
+		// makes a symlink
+		files map[string]string
+		// want is the directory of the file that wins, and shadowed those
+		// of the files it shadows
+		want     string
+		shadowed []string
+		masked   bool
+	}{
+		{"etc over usr/lib", map[string]string{etc: "etc", usrLib: "vendor"}, etc, []string{usrLib}, false},
+		{"etc over run", map[string]string{etc: "etc", run: "run"}, etc, []string{run}, false},
+		{"run over usr/lib", map[string]string{run: "run", usrLib: "vendor"}, run, []string{usrLib}, false},
+		{"usr/lib over lib", map[string]string{usrLib: "vendor", lib: "old"}, usrLib, []string{lib}, false},
+		{"all four", map[string]string{etc: "etc", run: "run", usrLib: "vendor", lib: "old"}, etc, []string{run, usrLib, lib}, false},
+		{"vendor only", map[string]string{usrLib: "vendor"}, usrLib, nil, false},
+		{"mask in etc", map[string]string{etc: "@/dev/null", usrLib: "vendor"}, etc, []string{usrLib}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for dir, content := range tt.files {
+				writeUnit(t, filepath.Join(root, dir, "app.service"), content)
+			}
+			unit := loadApp(t, root, etc, run, usrLib, lib)
+
+			if want := filepath.Join(root, tt.want, "app.service"); unit.Path != want || unit.Masked != tt.masked {
+				t.Errorf("app.service from %s (masked %v), want %s (masked %v)", unit.Path, unit.Masked, want, tt.masked)
+			}
+			var shadowed []string
+			for _, dir := range tt.shadowed {
+				shadowed = append(shadowed, filepath.Join(root, dir, "app.service"))
+			}
+			if strings.Join(unit.ShadowedPaths, ",") != strings.Join(shadowed, ",") {
+				t.Errorf("ShadowedPaths = %v, want %v", unit.ShadowedPaths, shadowed)
+			}
+		})
+	}
+}
+
+func TestLoadPathsPrecedenceThroughAlias(t *testing.T) {
+	// The alias sorts before app.service in /etc and leads to the vendor
+	// file, which must still lose to the override next to it
+	root := t.TempDir()
+	writeUnit(t, filepath.Join(root, "etc/systemd/system/app.service"), "etc")
+	writeUnit(t, filepath.Join(root, "etc/systemd/system/alias.service"), "@/usr/lib/systemd/system/app.service")
+	writeUnit(t, filepath.Join(root, "usr/lib/systemd/system/app.service"), "vendor")
+
+	unit := loadApp(t, root, "etc/systemd/system", "usr/lib/systemd/system")
+	if unit.Path != filepath.Join(root, "etc/systemd/system/app.service") || len(unit.ShadowedPaths) != 1 {
+		t.Errorf("app.service from %s shadowing %v, want the override shadowing the vendor file", unit.Path, unit.ShadowedPaths)
+	}
+}
+
+func TestSetOrigins(t *testing.T) {
+	root := t.TempDir()
+	writeUnit(t, filepath.Join(root, "etc/systemd/system/app.service"), "app")
+	writeUnit(t, filepath.Join(root, "run/systemd/generator/data.mount"), "fstab")
+	writeUnit(t, filepath.Join(root, "run/systemd/generator.late/legacy.service"), "sysv")
+	writeUnit(t, filepath.Join(root, "run/systemd/transient/run-u1.service"), "systemd-run")
+	// A unit file in /etc overrides the generated unit of the same name
+	writeUnit(t, filepath.Join(root, "etc/systemd/system/srv.mount"), "local")
+	writeUnit(t, filepath.Join(root, "run/systemd/generator/srv.mount"), "fstab")
+
+	var paths []string
+	for _, dir := range RuntimePaths() {
+		paths = append(paths, filepath.Join(root, dir))
+	}
+	set := NewSet(root, paths...)
+	for _, path := range paths {
+		files, _ := Files(path)
+		for _, file := range files {
+			if err := set.Load(file); err != nil {
+				t.Fatalf("Load(%s) failed: %v", file, err)
+			}
+		}
+	}
+
+	want := map[string]string{
+		"app.service":    "",
+		"data.mount":     types.UnitOriginGenerator,
+		"legacy.service": types.UnitOriginGenerator,
+		"run-u1.service": types.UnitOriginTransient,
+		"srv.mount":      "",
+	}
+	for name, origin := range want {
+		unit, ok := set.Units[name]
+		if !ok {
+			t.Errorf("%s not loaded", name)
+			continue
+		}
+		if unit.Origin != origin {
+			t.Errorf("%s Origin = %q, want %q", name, unit.Origin, origin)
+		}
+	}
+}
+
+// writeUnit writes a unit file with content as its description, or a
+// symlink to the target of an "@target" content
+func writeUnit(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	if target, ok := strings.CutPrefix(content, "@"); ok {
+		err = os.Symlink(target, path)
+	} else {
+		err = os.WriteFile(path, []byte("[Unit]\nDescription="+content+"\n"), 0644)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// loadApp loads the unit directories below root in order and returns
+// app.service, the only unit they should hold
+func loadApp(t *testing.T, root string, dirs ...string) *types.UnitFile {
+	t.Helper()
+	var paths []string
+	for _, dir := range dirs {
+		paths = append(paths, filepath.Join(root, dir))
+	}
+	set := NewSet(root, paths...)
+	for _, path := range paths {
+		files, _ := Files(path)
+		for _, file := range files {
+			if err := set.Load(file); err != nil {
+				t.Fatalf("Load(%s) failed: %v", file, err)
+			}
+		}
+	}
+	unit := set.Units["app.service"]
+	if len(set.Units) != 1 || unit == nil {
+		t.Fatalf("units = %v, want app.service alone", set.Units)
+	}
+	return unit
+}
+
+func TestDiff(t *testing.T) {
+	from, err := ParseContent("/usr/lib/systemd/system/app.service", "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\nUser=app\nEnvironment=A=1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := ParseContent("/etc/systemd/system/app.service", "[Service]\n# reordered\nEnvironment=A=1\nEnvironment=B=2\nExecStart=/usr/bin/app\nRestart=always\n\n[Install]\nWantedBy=multi-user.target\n\n[Unit]\nDescription=App\nAfter=network.target\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := Diff(from, to)
+	// Sections come in systemd's order, not alphabetically
+	want := []Change{
+		{Section: "Unit", Key: "After", Kind: "added", New: []string{"network.target"}},
+		{Section: "Service", Key: "Environment", Kind: "changed", Old: []string{"A=1"}, New: []string{"A=1", "B=2"}},
+		{Section: "Service", Key: "Restart", Kind: "added", New: []string{"always"}},
+		{Section: "Service", Key: "User", Kind: "removed", Old: []string{"app"}},
+		{Section: "Install", Key: "WantedBy", Kind: "added", New: []string{"multi-user.target"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if changes := Diff(from, from); len(changes) != 0 {
+		t.Errorf("Diff() of a file with itself = %+v", changes)
+	}
+}
+
+func TestDropIns(t *testing.T) {
+	root := t.TempDir()
+	etc, lib := filepath.Join(root, "etc"), filepath.Join(root, "lib")
+	writeUnit(t, filepath.Join(etc, "app.service.d", "20-limits.conf"), "etc")
+	writeUnit(t, filepath.Join(lib, "app.service.d", "20-limits.conf"), "lib")
+	writeUnit(t, filepath.Join(lib, "app.service.d", "10-env.conf"), "lib")
+	writeUnit(t, filepath.Join(lib, "app.service.d", "README"), "not a drop-in")
+
+	got := DropIns([]string{etc, lib}, "app.service")
+	want := []string{
+		filepath.Join(lib, "app.service.d", "10-env.conf"),
+		filepath.Join(etc, "app.service.d", "20-limits.conf"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DropIns() = %v, want %v", got, want)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"app.service",
+		"sub/db.service",
+		"sub/db.service.d/override.conf",
+		"sub/multi-user.target.wants/db.service",
+		".git/stale.service",
+		"README.md",
+	} {
+		writeUnit(t, filepath.Join(dir, name), name)
+	}
+
+	files, err := Walk(dir)
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "app.service"), filepath.Join(dir, "sub/db.service")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Walk() = %v, want %v", files, want)
+	}
+}
+
+func TestApplyDropIns(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.service")
+	if err := os.WriteFile(path, []byte("[Service]\nExecStart=/usr/bin/app\nRestart=no\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "app.service.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.service.d", "10-restart.conf"), []byte("[Service]\nRestart=always\n\n[Unit]\nAfter=db.service\nbogus\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	set := NewSet("", dir)
+	if err := set.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	set.ApplyDropIns()
+	unit := set.Units["app.service"]
+
+	dropIn := filepath.Join(dir, "app.service.d", "10-restart.conf")
+	if !reflect.DeepEqual(unit.DropIns, []string{dropIn}) {
+		t.Errorf("DropIns = %v, want %v", unit.DropIns, []string{dropIn})
+	}
+	if got := unit.GetDirective("Service", "Restart"); got != "always" {
+		t.Errorf("Restart = %q, want the drop-in's always", got)
+	}
+	if restarts := unit.GetDirectives("Service", "Restart"); len(restarts) != 2 || restarts[1].File != dropIn {
+		t.Errorf("Restart directives = %+v, want the unit's then the drop-in's", restarts)
+	}
+	if got := unit.GetDirective("Unit", "After"); got != "db.service" {
+		t.Errorf("After = %q, want db.service from a section the unit file lacks", got)
+	}
+	want := []types.ParseError{{Line: 6, Message: `missing '=' in "bogus"`, File: dropIn}}
+	if !reflect.DeepEqual(unit.ParseErrors, want) {
+		t.Errorf("ParseErrors = %+v, want %+v", unit.ParseErrors, want)
+	}
+}
@@ -0,0 +1,118 @@
+// Package unitname escapes strings and paths into unit names as
+// systemd-escape does, and brings unit names to one spelling so that
+// references to a unit match its file however its escapes are written.
+package unitname
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Escape escapes s for use in a unit name, as systemd-escape does: "/"
+// becomes "-", and every byte other than ASCII letters, digits, ":", "_"
+// and a "." that does not lead becomes \xNN. Non-ASCII characters are
+// escaped byte by byte in UTF-8.
+func Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '/':
+			b.WriteByte('-')
+		case plain(c, i == 0):
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+	}
+	return b.String()
+}
+
+// EscapePath escapes a path for use in a unit name, as systemd-escape
+// --path does: the path is simplified, its leading and trailing slashes are
+// dropped and the rest is escaped, with "-" for the root directory
+func EscapePath(p string) string {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		return "-"
+	}
+	return Escape(p)
+}
+
+// Unescape undoes Escape: "-" becomes "/" and \xNN the byte NN. Malformed
+// escapes are kept as they are.
+func Unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '-' {
+			b.WriteByte('/')
+			continue
+		}
+		if c, ok := escapedByte(s, i); ok {
+			b.WriteByte(c)
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// UnescapePath undoes EscapePath, returning an absolute path
+func UnescapePath(s string) string {
+	if s == "-" {
+		return "/"
+	}
+	return "/" + Unescape(s)
+}
+
+// Normalize returns the spelling of a unit name that Escape would produce
+// for its prefix and instance: escapes are written in lower case, and
+// characters escaped without need, such as \x61 for "a", are written out.
+// Other names are returned unchanged.
+func Normalize(name string) string {
+	if !strings.Contains(name, `\x`) {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c, ok := escapedByte(name, i)
+		if !ok {
+			b.WriteByte(name[i])
+			continue
+		}
+		i += 3
+		// A "." stays escaped at the start of the prefix and the instance
+		if plain(c, b.Len() == 0 || strings.HasSuffix(b.String(), "@")) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+	}
+	return b.String()
+}
+
+// plain reports whether Escape leaves c as it is
+func plain(c byte, leading bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == ':', c == '_':
+		return true
+	case c == '.':
+		return !leading
+	}
+	return false
+}
+
+// escapedByte returns the byte of a \xNN escape at s[i]
+func escapedByte(s string, i int) (byte, bool) {
+	if i+3 >= len(s) || s[i] != '\\' || s[i+1] != 'x' {
+		return 0, false
+	}
+	c, err := strconv.ParseUint(s[i+2:i+4], 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	return byte(c), true
+}
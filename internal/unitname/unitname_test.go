@@ -0,0 +1,89 @@
+package unitname
+
+import "testing"
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"tty1", "tty1"},
+		{"crypt-root", `crypt\x2droot`},
+		{"a/b", "a-b"},
+		{"Hallöchen, Meister", `Hall\xc3\xb6chen\x2c\x20Meister`},
+		{".hidden", `\x2ehidden`},
+		{"a.b:c_d", "a.b:c_d"},
+		{`back\slash`, `back\x5cslash`},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := Escape(tt.in)
+		if got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if back := Unescape(got); back != tt.in {
+			t.Errorf("Unescape(%q) = %q, want %q", got, back, tt.in)
+		}
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/", "-"},
+		{"", "-"},
+		{"/dev/mapper/crypt-root", `dev-mapper-crypt\x2droot`},
+		{"/home/user-1000", `home-user\x2d1000`},
+		{"/tmp//waldi/./foobar/", "tmp-waldi-foobar"},
+		{"/srv/.cache", `srv-.cache`},
+		{"/.snapshots", `\x2esnapshots`},
+	}
+	for _, tt := range tests {
+		if got := EscapePath(tt.in); got != tt.want {
+			t.Errorf("EscapePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for escaped, want := range map[string]string{
+		"-":                        "/",
+		`dev-mapper-crypt\x2droot`: "/dev/mapper/crypt-root",
+		`\x2esnapshots`:            "/.snapshots",
+	} {
+		if got := UnescapePath(escaped); got != want {
+			t.Errorf("UnescapePath(%q) = %q, want %q", escaped, got, want)
+		}
+	}
+}
+
+func TestUnescapeMalformed(t *testing.T) {
+	for in, want := range map[string]string{
+		`a\x2`:  `a\x2`,
+		`a\xzz`: `a\xzz`,
+		`a\y41`: `a\y41`,
+	} {
+		if got := Unescape(in); got != want {
+			t.Errorf("Unescape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"app.service", "app.service"},
+		{`dev-mapper-crypt\x2droot.device`, `dev-mapper-crypt\x2droot.device`},
+		{`dev-mapper-crypt\x2Droot.device`, `dev-mapper-crypt\x2droot.device`},
+		{`home-\x61lice.mount`, "home-alice.mount"},
+		{`\x2esnapshots.mount`, `\x2esnapshots.mount`},
+		{`getty@\x2etty1.service`, `getty@\x2etty1.service`},
+		{`app@a\x2eb.service`, "app@a.b.service"},
+		{`app@Hall\xC3\xB6chen.service`, `app@Hall\xc3\xb6chen.service`},
+		{`bad\xzz.service`, `bad\xzz.service`},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package validation
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysusersDirs are where systemd-sysusers reads the users and groups it
+// creates at boot, which an image's /etc/passwd may not have yet
+var sysusersDirs = []string{"/etc/sysusers.d", "/run/sysusers.d", "/usr/lib/sysusers.d"}
+
+// accounts are the users and groups of an offline image
+type accounts struct {
+	users map[string]bool
+	// ids are the UID and primary GID of the users whose IDs are known
+	ids    map[string]FileOwner
+	groups map[string]bool
+	uids   map[uint32]bool
+	gids   map[uint32]bool
+}
+
+// loadAccounts reads the users and groups of the image below fs.Root from
+// /etc/passwd, /etc/group and sysusers.d. It returns nil if the image has no
+// /etc/passwd, when nothing can be said about its users.
+func loadAccounts(fs *RealFileSystem) *accounts {
+	passwd, err := fs.ReadFile("/etc/passwd")
+	if err != nil {
+		return nil
+	}
+	a := &accounts{
+		users:  make(map[string]bool),
+		ids:    make(map[string]FileOwner),
+		groups: make(map[string]bool),
+		uids:   make(map[uint32]bool),
+		gids:   make(map[uint32]bool),
+	}
+
+	// name:password:UID:GID:GECOS:home:shell
+	for _, fields := range colonFields(passwd) {
+		a.users[fields[0]] = true
+		if len(fields) > 3 {
+			addNumber(a.uids, fields[2])
+			addNumber(a.gids, fields[3])
+			a.addIDs(fields[0], fields[2], fields[3])
+		}
+	}
+	// name:password:GID:members
+	if group, err := fs.ReadFile("/etc/group"); err == nil {
+		for _, fields := range colonFields(group) {
+			a.groups[fields[0]] = true
+			if len(fields) > 2 {
+				addNumber(a.gids, fields[2])
+			}
+		}
+	}
+
+	for _, dir := range sysusersDirs {
+		for _, file := range readDirNames(fs, dir) {
+			if !strings.HasSuffix(file, ".conf") {
+				continue
+			}
+			if data, err := fs.ReadFile(dir + "/" + file); err == nil {
+				a.addSysusers(data)
+			}
+		}
+	}
+	return a
+}
+
+// addSysusers adds the users and groups a sysusers.d file creates
+func (a *accounts) addSysusers(data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		name := fields[1]
+		id := "-"
+		if len(fields) > 2 {
+			id = fields[2]
+		}
+		switch strings.TrimSuffix(fields[0], "!") {
+		case "u":
+			// A user gets a group of its name, unless its ID names another
+			a.users[name] = true
+			uid, gid, hasGID := strings.Cut(id, ":")
+			addNumber(a.uids, uid)
+			if !hasGID {
+				a.groups[name] = true
+				addNumber(a.gids, uid)
+				a.addIDs(name, uid, uid)
+			} else if _, err := strconv.ParseUint(gid, 10, 32); err == nil {
+				a.groups[name] = true
+				addNumber(a.gids, gid)
+				a.addIDs(name, uid, gid)
+			} else {
+				a.groups[gid] = true
+			}
+		case "g":
+			a.groups[name] = true
+			addNumber(a.gids, id)
+		case "m":
+			a.users[name] = true
+			if len(fields) > 2 {
+				a.groups[fields[2]] = true
+			}
+		}
+	}
+}
+
+// addIDs records the UID and GID of a user when both are numbers. A user
+// listed twice keeps its first IDs, as /etc/passwd comes before sysusers.d.
+func (a *accounts) addIDs(name, uid, gid string) {
+	if _, ok := a.ids[name]; ok {
+		return
+	}
+	u, uerr := strconv.ParseUint(uid, 10, 32)
+	g, gerr := strconv.ParseUint(gid, 10, 32)
+	if uerr == nil && gerr == nil {
+		a.ids[name] = FileOwner{UID: uint32(u), GID: uint32(g)}
+	}
+}
+
+// addNumber adds a numeric ID; "-", paths and ranges are left out
+func addNumber(ids map[uint32]bool, s string) {
+	if id, err := strconv.ParseUint(s, 10, 32); err == nil {
+		ids[uint32(id)] = true
+	}
+}
+
+// colonFields splits the lines of a passwd or group file, skipping comments
+// and NIS lines
+func colonFields(data []byte) [][]string {
+	var lines [][]string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		lines = append(lines, strings.Split(line, ":"))
+	}
+	return lines
+}
+
+// readDirNames returns the sorted names in a directory below the root, none
+// if it cannot be read
+func readDirNames(fs *RealFileSystem, dir string) []string {
+	full, err := fs.resolvePath(dir)
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
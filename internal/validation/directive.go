@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"path"
 	"regexp"
 	"strings"
 
@@ -10,15 +11,24 @@ import (
 // DirectiveValidation contains results of common directive validation.
 type DirectiveValidation struct {
 	Unit               string
-	MissingExecutables []MissingExec // ExecStart, ExecStop, etc. not found
-	NotExecutable      []MissingExec // Paths not executable
-	MissingEnvFiles    []MissingFile // EnvironmentFile= not found
-	MissingWorkDir     string        // WorkingDirectory= not found
-	InvalidDirectories []string      // RuntimeDirectory= invalid names
+	MissingExecutables []MissingExec      // ExecStart, ExecStop, etc. not found
+	NotExecutable      []MissingExec      // Paths not executable
+	MissingEnvFiles    []MissingFile      // EnvironmentFile= not found
+	MissingWorkDir     string             // WorkingDirectory= not found
+	InvalidDirectories []InvalidDirectory // RuntimeDirectory= invalid names
 	Issues             []string
 	Valid              bool
 }
 
+// InvalidDirectory is a name systemd refuses in RuntimeDirectory= and the
+// other directories it creates for a service.
+type InvalidDirectory struct {
+	Directive string
+	Name      string
+	Reason    string
+	Line      int
+}
+
 // MissingFile represents a missing file reference.
 type MissingFile struct {
 	Directive string
@@ -28,6 +38,8 @@ type MissingFile struct {
 }
 
 // ValidateDirectives checks common directives across all unit types.
+// Programs, environment files and the working directory are only looked up
+// when fs is not nil.
 func ValidateDirectives(unit *types.UnitFile, fs FileSystem) DirectiveValidation {
 	result := DirectiveValidation{
 		Unit:  unit.Name,
@@ -42,9 +54,13 @@ func ValidateDirectives(unit *types.UnitFile, fs FileSystem) DirectiveValidation
 			"ExecCondition",
 		}
 
+		// Programs of a service with its own root are not on the host
 		for _, directive := range execDirectives {
+			if fs == nil || chrooted(serviceSection) {
+				break
+			}
 			if dirs, ok := serviceSection.Directives[directive]; ok {
-				for _, d := range dirs {
+				for _, d := range afterReset(dirs) {
 					missing, notExec := validateExecPath(d.Value, directive, d.Line, fs)
 					result.MissingExecutables = append(result.MissingExecutables, missing...)
 					result.NotExecutable = append(result.NotExecutable, notExec...)
@@ -53,16 +69,17 @@ func ValidateDirectives(unit *types.UnitFile, fs FileSystem) DirectiveValidation
 		}
 
 		// Validate EnvironmentFile=
-		if dirs, ok := serviceSection.Directives["EnvironmentFile"]; ok {
-			for _, d := range dirs {
+		if dirs, ok := serviceSection.Directives["EnvironmentFile"]; ok && fs != nil {
+			for _, d := range afterReset(dirs) {
 				if missing := validateEnvironmentFile(d.Value, d.Line, fs); missing != nil {
 					result.MissingEnvFiles = append(result.MissingEnvFiles, *missing)
 				}
 			}
 		}
 
-		// Validate WorkingDirectory=
-		if workDir := getDirectiveValue(serviceSection, "WorkingDirectory"); workDir != "" {
+		// Validate WorkingDirectory=, unless it is in the service's own root
+		// or one of the directories systemd creates before starting it
+		if workDir := getDirectiveValue(serviceSection, "WorkingDirectory"); workDir != "" && fs != nil && !chrooted(serviceSection) && !underAny(path.Clean(workDir), createdDirectories(serviceSection)) {
 			if !validateWorkingDirectory(workDir, fs) {
 				result.MissingWorkDir = workDir
 				result.Valid = false
@@ -81,8 +98,9 @@ func ValidateDirectives(unit *types.UnitFile, fs FileSystem) DirectiveValidation
 		for _, directive := range dirDirectives {
 			if dirs, ok := serviceSection.Directives[directive]; ok {
 				for _, d := range dirs {
-					if invalid := validateDirectoryNames(d.Value); len(invalid) > 0 {
-						result.InvalidDirectories = append(result.InvalidDirectories, invalid...)
+					for _, invalid := range validateDirectoryNames(d.Value) {
+						invalid.Directive, invalid.Line = directive, d.Line
+						result.InvalidDirectories = append(result.InvalidDirectories, invalid)
 					}
 				}
 			}
@@ -90,7 +108,7 @@ func ValidateDirectives(unit *types.UnitFile, fs FileSystem) DirectiveValidation
 	}
 
 	// Also check socket sections for exec directives
-	if socketSection, ok := unit.Sections["Socket"]; ok {
+	if socketSection, ok := unit.Sections["Socket"]; ok && fs != nil {
 		execDirectives := []string{
 			"ExecStartPre", "ExecStartPost",
 			"ExecStopPre", "ExecStopPost",
@@ -98,7 +116,7 @@ func ValidateDirectives(unit *types.UnitFile, fs FileSystem) DirectiveValidation
 
 		for _, directive := range execDirectives {
 			if dirs, ok := socketSection.Directives[directive]; ok {
-				for _, d := range dirs {
+				for _, d := range afterReset(dirs) {
 					missing, notExec := validateExecPath(d.Value, directive, d.Line, fs)
 					result.MissingExecutables = append(result.MissingExecutables, missing...)
 					result.NotExecutable = append(result.NotExecutable, notExec...)
@@ -183,40 +201,55 @@ func validateWorkingDirectory(value string, fs FileSystem) bool {
 	return fs.IsDirectory(value)
 }
 
+// afterReset returns the assignments of a list directive systemd uses: those
+// after the last empty one, which resets the list
+func afterReset(dirs []types.Directive) []types.Directive {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i].Value == "" {
+			return dirs[i+1:]
+		}
+	}
+	return dirs
+}
+
+// validDirectoryName matches one component of a RuntimeDirectory= name
+var validDirectoryName = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`)
+
 // validateDirectoryNames validates RuntimeDirectory= etc. names.
-// These are relative directory names, not paths.
-func validateDirectoryNames(value string) []string {
-	var invalid []string
+// These are relative directory names, not paths. The directive and line of
+// the results are left to the caller.
+func validateDirectoryNames(value string) []InvalidDirectory {
+	var invalid []InvalidDirectory
 
 	names := strings.Fields(value)
 	for _, name := range names {
-		// Remove mode suffix like :0755
+		// Remove the symlink suffix of name:symlink
 		if idx := strings.Index(name, ":"); idx > 0 {
 			name = name[:idx]
 		}
 
+		// Specifiers are expanded by systemd
+		if strings.Contains(name, "%") {
+			continue
+		}
+
 		// Names must not be absolute paths
 		if strings.HasPrefix(name, "/") {
-			invalid = append(invalid, name+" (must not be absolute path)")
+			invalid = append(invalid, InvalidDirectory{Name: name, Reason: "must not be an absolute path"})
 			continue
 		}
 
 		// Names must not contain ..
 		if strings.Contains(name, "..") {
-			invalid = append(invalid, name+" (must not contain ..)")
+			invalid = append(invalid, InvalidDirectory{Name: name, Reason: "must not contain .."})
 			continue
 		}
 
-		// Names should be valid directory names
-		validNameRegex := regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`)
-		if !validNameRegex.MatchString(name) && !strings.Contains(name, "/") {
-			// Allow subdirectories like "myapp/cache"
-			parts := strings.Split(name, "/")
-			for _, part := range parts {
-				if part != "" && !validNameRegex.MatchString(part) {
-					invalid = append(invalid, name+" (invalid characters)")
-					break
-				}
+		// Each component, as in "myapp/cache", should be a valid directory name
+		for _, part := range strings.Split(name, "/") {
+			if part != "" && !validDirectoryName.MatchString(part) {
+				invalid = append(invalid, InvalidDirectory{Name: name, Reason: "has invalid characters"})
+				break
 			}
 		}
 	}
@@ -224,6 +257,22 @@ func validateDirectoryNames(value string) []string {
 	return invalid
 }
 
+// createdDirectories returns the directories systemd creates for a service
+// with RuntimeDirectory= and the like
+func createdDirectories(serviceSection *types.Section) []string {
+	var dirs []string
+	for _, d := range []struct{ key, base string }{
+		{"RuntimeDirectory", "/run"},
+		{"StateDirectory", "/var/lib"},
+		{"CacheDirectory", "/var/cache"},
+		{"LogsDirectory", "/var/log"},
+		{"ConfigurationDirectory", "/etc"},
+	} {
+		dirs = append(dirs, sandboxDirectories(serviceSection, d.key, d.base)...)
+	}
+	return dirs
+}
+
 // ValidateAllDirectives validates common directives for all units.
 func ValidateAllDirectives(units map[string]*types.UnitFile, fs FileSystem) map[string]DirectiveValidation {
 	results := make(map[string]DirectiveValidation)
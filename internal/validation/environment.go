@@ -0,0 +1,363 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Environment problems found by ValidateEnvironmentFiles.
+const (
+	EnvFileEmpty         = "env_file_empty"          // EnvironmentFile= names a file without any content
+	EnvFileSyntax        = "env_file_syntax"         // A line systemd ignores or reads differently than a shell
+	EnvFileOverride      = "env_file_override"       // Environment= sets a variable an EnvironmentFile= sets differently
+	EnvFileWorldWritable = "env_file_world_writable" // Any user can change the service's environment
+)
+
+// EnvironmentValidation contains the results of reading the EnvironmentFile=
+// targets of a unit.
+type EnvironmentValidation struct {
+	Unit     string
+	Problems []EnvironmentProblem
+}
+
+// EnvironmentProblem is a problem with an environment file or a variable it
+// sets. File and Line point at the line to change: the EnvironmentFile= or
+// Environment= directive, or the line of the environment file.
+type EnvironmentProblem struct {
+	Kind   string
+	File   string
+	Line   int
+	Reason string
+}
+
+// EnvVar is a variable assignment in an environment file or Environment=.
+type EnvVar struct {
+	Name  string
+	Value string
+	Line  int
+}
+
+// EnvSyntaxError is a line of an environment file that systemd ignores, or
+// reads differently than a shell sourcing the same file.
+type EnvSyntaxError struct {
+	Line   int
+	Reason string
+}
+
+// ParseEnvironmentFile parses an environment file as systemd does for
+// EnvironmentFile=: one KEY=VALUE per line, comments starting with # or ;,
+// single quotes taking everything literally, double quotes with backslash
+// escapes for \", \\, \`, \$ and newlines, and backslash-newline continuing
+// a line. Variables are not expanded. Assignments systemd ignores are left
+// out and reported as syntax errors.
+func ParseEnvironmentFile(content string) ([]EnvVar, []EnvSyntaxError) {
+	const (
+		preKey = iota
+		key
+		preValue
+		value
+		valueEscape
+		singleQuote
+		doubleQuote
+		doubleQuoteEscape
+		comment
+		commentEscape
+	)
+
+	var vars []EnvVar
+	var syntaxErrors []EnvSyntaxError
+	var name, val strings.Builder
+	state, line, start := preKey, 1, 1
+	// pendingSpace holds unquoted whitespace that is kept only if more of the
+	// value follows; spaceInside records that it was
+	pendingSpace, spaceInside := "", false
+
+	push := func() {
+		n := strings.TrimSpace(name.String())
+		switch {
+		case !validEnvName(n):
+			syntaxErrors = append(syntaxErrors, EnvSyntaxError{Line: start, Reason: fmt.Sprintf("%q is not a valid variable name, systemd ignores the assignment", n)})
+		case spaceInside:
+			syntaxErrors = append(syntaxErrors, EnvSyntaxError{Line: start, Reason: fmt.Sprintf("unquoted value of %s contains spaces, which systemd keeps but a shell sourcing the file splits", n)})
+			fallthrough
+		default:
+			vars = append(vars, EnvVar{Name: n, Value: val.String(), Line: start})
+		}
+		name.Reset()
+		val.Reset()
+		pendingSpace, spaceInside = "", false
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch state {
+		case preKey:
+			switch {
+			case c == '#' || c == ';':
+				state = comment
+			case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			default:
+				state, start = key, line
+				name.WriteByte(c)
+			}
+		case key:
+			switch c {
+			case '=':
+				state = preValue
+			case '\n':
+				syntaxErrors = append(syntaxErrors, EnvSyntaxError{Line: start, Reason: fmt.Sprintf("%q has no '=', systemd ignores the line", strings.TrimSpace(name.String()))})
+				name.Reset()
+				state = preKey
+			default:
+				name.WriteByte(c)
+			}
+		case preValue:
+			switch c {
+			case '\n':
+				push()
+				state = preKey
+			case ' ', '\t', '\r':
+			case '\'':
+				state = singleQuote
+			case '"':
+				state = doubleQuote
+			case '\\':
+				state = valueEscape
+			default:
+				state = value
+				val.WriteByte(c)
+			}
+		case value:
+			switch c {
+			case '\n':
+				push()
+				state = preKey
+			case ' ', '\t', '\r':
+				pendingSpace += string(c)
+			case '\\':
+				state = valueEscape
+			default:
+				if pendingSpace != "" {
+					val.WriteString(pendingSpace)
+					pendingSpace, spaceInside = "", true
+				}
+				val.WriteByte(c)
+			}
+		case valueEscape:
+			state = value
+			if c == '\n' {
+				break
+			}
+			if pendingSpace != "" {
+				val.WriteString(pendingSpace)
+				pendingSpace, spaceInside = "", true
+			}
+			val.WriteByte(c)
+		case singleQuote:
+			if c == '\'' {
+				state = preValue
+			} else {
+				val.WriteByte(c)
+			}
+		case doubleQuote:
+			switch c {
+			case '"':
+				state = preValue
+			case '\\':
+				state = doubleQuoteEscape
+			default:
+				val.WriteByte(c)
+			}
+		case doubleQuoteEscape:
+			state = doubleQuote
+			switch c {
+			case '"', '\\', '`', '$':
+				val.WriteByte(c)
+			case '\n':
+			default:
+				val.WriteByte('\\')
+				val.WriteByte(c)
+			}
+		case comment:
+			switch c {
+			case '\\':
+				state = commentEscape
+			case '\n':
+				state = preKey
+			}
+		case commentEscape:
+			state = comment
+		}
+		if c == '\n' {
+			line++
+		}
+	}
+
+	switch state {
+	case key:
+		syntaxErrors = append(syntaxErrors, EnvSyntaxError{Line: start, Reason: fmt.Sprintf("%q has no '=', systemd ignores the line", strings.TrimSpace(name.String()))})
+	case singleQuote, doubleQuote, doubleQuoteEscape:
+		syntaxErrors = append(syntaxErrors, EnvSyntaxError{Line: start, Reason: fmt.Sprintf("quoted value of %s is never closed, so it runs to the end of the file", strings.TrimSpace(name.String()))})
+		push()
+	case preValue, value, valueEscape:
+		push()
+	}
+
+	return vars, syntaxErrors
+}
+
+// validEnvName reports whether name is a variable name systemd accepts
+func validEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseEnvironment parses the assignments of an Environment= value, which
+// are separated by spaces and may be quoted as a whole, such as
+// `A=1 "B=two words"`.
+func ParseEnvironment(value string, line int) []EnvVar {
+	var vars []EnvVar
+	var word strings.Builder
+	var quote byte
+	inWord := false
+
+	flush := func() {
+		if n, v, ok := strings.Cut(word.String(), "="); ok && validEnvName(n) {
+			vars = append(vars, EnvVar{Name: n, Value: v, Line: line})
+		}
+		word.Reset()
+		inWord = false
+	}
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0 && c == '\\' && i+1 < len(value):
+			i++
+			word.WriteByte(value[i])
+		case quote != 0:
+			word.WriteByte(c)
+		case c == '"' || c == '\'':
+			quote, inWord = c, true
+		case c == ' ' || c == '\t':
+			if inWord {
+				flush()
+			}
+		case c == '\\' && i+1 < len(value):
+			i++
+			word.WriteByte(value[i])
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		flush()
+	}
+	return vars
+}
+
+// ValidateEnvironmentFiles reads the EnvironmentFile= targets of a service
+// and reports files that are empty, world-writable or contain lines systemd
+// ignores, and Environment= variables an environment file sets to another
+// value. Files that cannot be read or are named with specifiers are skipped;
+// ValidateDirectives reports missing ones.
+func ValidateEnvironmentFiles(unit *types.UnitFile, fs FileSystem) EnvironmentValidation {
+	result := EnvironmentValidation{Unit: unit.Name}
+	if fs == nil || !unit.IsService() {
+		return result
+	}
+
+	// fileVars holds the value each variable ends up with from the files,
+	// later files overriding earlier ones
+	type fileVar struct {
+		EnvVar
+		path string
+	}
+	fileVars := make(map[string]fileVar)
+
+	for _, d := range unit.GetDirectives("Service", "EnvironmentFile") {
+		path := strings.TrimPrefix(d.Value, "-")
+		if path == "" || strings.Contains(path, "%") || !strings.HasPrefix(path, "/") {
+			continue
+		}
+		data, err := fs.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		if mode, ok := fs.Mode(path); ok && mode&0002 != 0 {
+			result.Problems = append(result.Problems, EnvironmentProblem{
+				Kind:   EnvFileWorldWritable,
+				File:   unit.Path,
+				Line:   d.Line,
+				Reason: fmt.Sprintf("%s is world-writable (mode %04o), so any local user can set variables such as LD_PRELOAD for %s.", path, mode.Perm(), unit.Name),
+			})
+		}
+
+		if strings.TrimSpace(string(data)) == "" {
+			result.Problems = append(result.Problems, EnvironmentProblem{
+				Kind:   EnvFileEmpty,
+				File:   unit.Path,
+				Line:   d.Line,
+				Reason: fmt.Sprintf("%s exists but is empty, so %s gets none of the variables it expects from it.", path, unit.Name),
+			})
+			continue
+		}
+
+		vars, syntaxErrors := ParseEnvironmentFile(string(data))
+		for _, e := range syntaxErrors {
+			result.Problems = append(result.Problems, EnvironmentProblem{
+				Kind:   EnvFileSyntax,
+				File:   path,
+				Line:   e.Line,
+				Reason: fmt.Sprintf("%s line %d, read by %s: %s.", path, e.Line, unit.Name, e.Reason),
+			})
+		}
+		for _, v := range vars {
+			fileVars[v.Name] = fileVar{EnvVar: v, path: path}
+		}
+	}
+
+	// Environment= applies first and EnvironmentFile= overrides it, whatever
+	// the order of the directives
+	inline := make(map[string]EnvVar)
+	for _, d := range unit.GetDirectives("Service", "Environment") {
+		for _, v := range ParseEnvironment(d.Value, d.Line) {
+			inline[v.Name] = v
+		}
+	}
+	var names []string
+	for name := range inline {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := inline[name]
+		f, ok := fileVars[name]
+		if !ok || f.Value == v.Value {
+			continue
+		}
+		result.Problems = append(result.Problems, EnvironmentProblem{
+			Kind:   EnvFileOverride,
+			File:   unit.Path,
+			Line:   v.Line,
+			Reason: fmt.Sprintf("Environment= sets %s=%q, but %s line %d sets it to %q, which wins because EnvironmentFile= overrides Environment=.", name, v.Value, f.path, f.Line, f.Value),
+		})
+	}
+
+	return result
+}
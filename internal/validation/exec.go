@@ -0,0 +1,324 @@
+package validation
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Exec command problems found by ValidateExecCommands.
+const (
+	ExecShellSyntax      = "exec_shell_syntax"      // Pipes, redirections or && passed as literal arguments
+	ExecPrivilegeWrapper = "exec_privilege_wrapper" // sudo, su or runuser instead of User= or the + prefix
+	ExecRelativeConfig   = "exec_relative_config"   // Config file argument relative to an unset WorkingDirectory=
+	ExecMultipleStart    = "exec_multiple_start"    // More than one ExecStart= command outside Type=oneshot
+)
+
+// ExecValidation contains the results of checking the command lines of a
+// service's Exec* directives.
+type ExecValidation struct {
+	Unit     string
+	Problems []ExecProblem
+}
+
+// ExecProblem is a problem with one command of an Exec* directive.
+type ExecProblem struct {
+	Kind      string
+	Directive string
+	Line      int
+	Reason    string
+}
+
+// ExecCommand is one command of an Exec* directive, split into words the way
+// systemd splits it.
+type ExecCommand struct {
+	Prefixes string     // Special prefixes such as "-" or "+"
+	Path     string     // The executable
+	Args     []ExecWord // Arguments after argv[0]
+}
+
+// ExecWord is an argument of an ExecCommand. Quoted is set when any part of
+// the word was quoted or escaped, so it cannot be a shell operator.
+type ExecWord struct {
+	Value  string
+	Quoted bool
+}
+
+// execDirectives are the [Service] directives whose values are command lines
+var execDirectives = []string{
+	"ExecCondition", "ExecStartPre", "ExecStart", "ExecStartPost",
+	"ExecReload", "ExecStop", "ExecStopPost",
+}
+
+// shells interpret their -c argument themselves, so operators in it are fine
+var shells = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true,
+	"ash": true, "busybox": true, "fish": true,
+}
+
+// privilegeWrappers change user from inside the service
+var privilegeWrappers = map[string]bool{"sudo": true, "su": true, "runuser": true}
+
+// shellOperators are words a shell treats as syntax but systemd passes on
+var shellOperators = map[string]bool{
+	"|": true, "||": true, "|&": true, "&&": true, "&": true,
+	">": true, ">>": true, "<": true, "<<": true, "2>&1": true, "&>": true,
+}
+
+// configExtensions are the file extensions of arguments taken as config files
+var configExtensions = map[string]bool{
+	".conf": true, ".cfg": true, ".cnf": true, ".ini": true, ".yaml": true,
+	".yml": true, ".json": true, ".toml": true, ".xml": true,
+	".properties": true, ".env": true,
+}
+
+// ParseExecCommands splits the value of an Exec* directive into commands as
+// systemd does: words are separated by whitespace, quotes and backslash
+// escapes are removed, and a lone ";" separates commands while "\;" is a
+// literal semicolon. Each command may start with the prefixes "-@:+!", and
+// with "@" the second word is argv[0] rather than an argument.
+func ParseExecCommands(value string) []ExecCommand {
+	var commands []ExecCommand
+	var words []ExecWord
+
+	flush := func() {
+		if len(words) == 0 {
+			return
+		}
+		first := words[0].Value
+		prefixes := first[:len(first)-len(strings.TrimLeft(first, "-@:+!|"))]
+		command := ExecCommand{Prefixes: prefixes, Path: first[len(prefixes):]}
+		args := words[1:]
+		if strings.Contains(prefixes, "@") && len(args) > 0 {
+			args = args[1:]
+		}
+		if len(args) > 0 {
+			command.Args = args
+		}
+		commands = append(commands, command)
+		words = nil
+	}
+
+	for _, w := range splitExecWords(value) {
+		if w.Value == ";" && !w.Quoted {
+			flush()
+			continue
+		}
+		words = append(words, w)
+	}
+	flush()
+	return commands
+}
+
+// splitExecWords splits a command line into words, removing quotes and
+// backslash escapes
+func splitExecWords(value string) []ExecWord {
+	var words []ExecWord
+	var word strings.Builder
+	var quote byte
+	inWord, quoted := false, false
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\' && i+1 < len(value):
+			i++
+			word.WriteString(unescapeExecChar(value[i]))
+			inWord, quoted = true, true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteByte(c)
+		case c == '"' || c == '\'':
+			quote, inWord, quoted = c, true, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, ExecWord{Value: word.String(), Quoted: quoted})
+				word.Reset()
+				inWord, quoted = false, false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, ExecWord{Value: word.String(), Quoted: quoted})
+	}
+	return words
+}
+
+// unescapeExecChar returns the character a backslash escape stands for
+func unescapeExecChar(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	case 'r':
+		return "\r"
+	}
+	return string(c)
+}
+
+// ValidateExecCommands checks the command lines of a service's Exec*
+// directives for mistakes systemd does not report: shell syntax without a
+// shell, sudo and friends instead of User=, config files relative to an
+// unset WorkingDirectory=, and several ExecStart= commands in a service that
+// is not Type=oneshot, which systemd refuses to load.
+func ValidateExecCommands(unit *types.UnitFile) ExecValidation {
+	result := ExecValidation{Unit: unit.Name}
+	if !unit.IsService() {
+		return result
+	}
+
+	workDir := unit.GetDirective("Service", "WorkingDirectory")
+	for _, directive := range execDirectives {
+		for _, d := range unit.GetDirectives("Service", directive) {
+			for _, command := range ParseExecCommands(d.Value) {
+				result.Problems = append(result.Problems, checkExecCommand(command, directive, d.Line, workDir)...)
+			}
+		}
+	}
+
+	if p := checkMultipleExecStart(unit); p != nil {
+		result.Problems = append(result.Problems, *p)
+	}
+	return result
+}
+
+// checkExecCommand reports the problems of a single command
+func checkExecCommand(command ExecCommand, directive string, line int, workDir string) []ExecProblem {
+	var problems []ExecProblem
+	program, args := unwrapEnv(command)
+
+	if privilegeWrappers[program] {
+		problems = append(problems, ExecProblem{
+			Kind:      ExecPrivilegeWrapper,
+			Directive: directive,
+			Line:      line,
+			Reason:    fmt.Sprintf("%s= runs its command through %s, which depends on PAM and sudoers rather than the unit and hides the real user from systemd.", directive, program),
+		})
+	}
+
+	// The | prefix runs the command line through the user's shell
+	if !shells[program] && !strings.Contains(command.Prefixes, "|") {
+		for _, arg := range args {
+			if !arg.Quoted && isShellSyntax(arg.Value) {
+				problems = append(problems, ExecProblem{
+					Kind:      ExecShellSyntax,
+					Directive: directive,
+					Line:      line,
+					Reason:    fmt.Sprintf("%s= passes %q to %s as a literal argument: systemd does not run commands through a shell, so pipes, redirections, && and $(...) are not interpreted.", directive, arg.Value, command.Path),
+				})
+				break
+			}
+		}
+	}
+
+	if workDir == "" {
+		for _, arg := range command.Args {
+			if p := relativeConfigPath(arg.Value); p != "" {
+				problems = append(problems, ExecProblem{
+					Kind:      ExecRelativeConfig,
+					Directive: directive,
+					Line:      line,
+					Reason:    fmt.Sprintf("%s= passes the relative path %s, which resolves against / for a system service because WorkingDirectory= is not set.", directive, p),
+				})
+			}
+		}
+	}
+
+	return problems
+}
+
+// unwrapEnv returns the base name of the program a command runs and its
+// arguments, looking through an env wrapper and its assignments
+func unwrapEnv(command ExecCommand) (string, []ExecWord) {
+	program, args := path.Base(command.Path), command.Args
+	if program != "env" {
+		return program, args
+	}
+	for i := 0; i < len(args); i++ {
+		switch value := args[i].Value; {
+		case value == "-u" || value == "-C" || value == "--unset" || value == "--chdir":
+			i++
+		case strings.HasPrefix(value, "-") || strings.Contains(value, "="):
+		default:
+			return path.Base(value), args[i+1:]
+		}
+	}
+	return program, nil
+}
+
+// isShellSyntax reports whether an unquoted word is something only a shell
+// would interpret
+func isShellSyntax(word string) bool {
+	switch {
+	case shellOperators[word]:
+		return true
+	case strings.HasPrefix(word, ">") || strings.HasPrefix(word, "<") || strings.HasPrefix(word, "2>") || strings.HasPrefix(word, "&>"):
+		return true
+	case strings.HasSuffix(word, ";") || strings.HasSuffix(word, "&&") || strings.HasSuffix(word, "|"):
+		return true
+	case strings.Contains(word, "$(") || strings.Contains(word, "`"):
+		return true
+	}
+	return false
+}
+
+// relativeConfigPath returns the relative config file path an argument
+// names, either on its own or as the value of --option=, or ""
+func relativeConfigPath(arg string) string {
+	if strings.HasPrefix(arg, "-") {
+		_, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return ""
+		}
+		arg = value
+	}
+	if arg == "" || strings.HasPrefix(arg, "/") || strings.ContainsAny(arg, "%$") || strings.Contains(arg, "://") {
+		return ""
+	}
+	if !configExtensions[path.Ext(arg)] {
+		return ""
+	}
+	return arg
+}
+
+// checkMultipleExecStart reports a second ExecStart= command in a service
+// that is not Type=oneshot. An empty ExecStart= resets the list, as drop-ins
+// do before replacing the command.
+func checkMultipleExecStart(unit *types.UnitFile) *ExecProblem {
+	if unit.GetDirective("Service", "Type") == "oneshot" {
+		return nil
+	}
+
+	var lines []int
+	for _, d := range unit.GetDirectives("Service", "ExecStart") {
+		commands := ParseExecCommands(d.Value)
+		if len(commands) == 0 {
+			lines = nil
+			continue
+		}
+		for range commands {
+			lines = append(lines, d.Line)
+		}
+	}
+	if len(lines) < 2 {
+		return nil
+	}
+
+	reason := fmt.Sprintf("ExecStart= is set %d times, but only Type=oneshot services may have more than one command, so systemd refuses to load %s.", len(lines), unit.Name)
+	if lines[0] == lines[1] {
+		reason = fmt.Sprintf("ExecStart= separates %d commands with ';', but only Type=oneshot services may have more than one command, so systemd refuses to load %s.", len(lines), unit.Name)
+	}
+	return &ExecProblem{
+		Kind:      ExecMultipleStart,
+		Directive: "ExecStart",
+		Line:      lines[1],
+		Reason:    reason,
+	}
+}
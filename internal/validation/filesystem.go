@@ -4,6 +4,12 @@ package validation
 import (
 	"os"
 	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 )
 
 // FileSystem abstracts filesystem operations for testability.
@@ -13,29 +19,81 @@ type FileSystem interface {
 	IsDirectory(path string) bool
 	UserExists(name string) bool
 	GroupExists(name string) bool
+	UserIDExists(uid uint32) bool
+	LookupUser(name string) (FileOwner, bool)
+	GroupIDExists(gid uint32) bool
+	ReadFile(path string) ([]byte, error)
+	Mode(path string) (os.FileMode, bool)
+	Owner(path string) (FileOwner, bool)
+}
+
+// FileOwner is the user and group owning a file.
+type FileOwner struct {
+	UID uint32
+	GID uint32
 }
 
 // RealFileSystem implements FileSystem using the actual filesystem.
+//
+// Below Root, paths resolve as in a chroot: an absolute symlink in the image
+// points into the image, not the host, and .. stops at Root. Users and groups
+// are those of the image's /etc/passwd, /etc/group and sysusers.d.
+//
+// A RealFileSystem from NewRealFileSystem remembers what it looked up, so a
+// program that hundreds of units run is only stat'ed once; the zero value
+// looks everything up each time. It is safe for concurrent use.
 type RealFileSystem struct {
-	Root string // Root path for offline analysis (empty = live system)
+	Root  string // Root path for offline analysis (empty = live system)
+	cache *fsCache
+}
+
+// fsCache holds the lookups of a RealFileSystem
+type fsCache struct {
+	mu       sync.Mutex
+	stats    map[string]statResult
+	users    map[string]bool
+	groups   map[string]bool
+	uids     map[uint32]bool
+	gids     map[uint32]bool
+	accounts *accounts
+	loaded   bool
+	ids      map[string]userIDs
+}
+
+type userIDs struct {
+	owner FileOwner
+	ok    bool
+}
+
+type statResult struct {
+	info os.FileInfo
+	err  error
 }
 
-// NewRealFileSystem creates a new RealFileSystem.
+// NewRealFileSystem creates a new RealFileSystem that caches its lookups.
 func NewRealFileSystem(root string) *RealFileSystem {
-	return &RealFileSystem{Root: root}
+	return &RealFileSystem{
+		Root: root,
+		cache: &fsCache{
+			stats:  make(map[string]statResult),
+			users:  make(map[string]bool),
+			groups: make(map[string]bool),
+			uids:   make(map[uint32]bool),
+			gids:   make(map[uint32]bool),
+			ids:    make(map[string]userIDs),
+		},
+	}
 }
 
 // Exists checks if a path exists.
 func (fs *RealFileSystem) Exists(path string) bool {
-	fullPath := fs.resolvePath(path)
-	_, err := os.Stat(fullPath)
+	_, err := fs.stat(path)
 	return err == nil
 }
 
 // IsExecutable checks if a path is executable.
 func (fs *RealFileSystem) IsExecutable(path string) bool {
-	fullPath := fs.resolvePath(path)
-	info, err := os.Stat(fullPath)
+	info, err := fs.stat(path)
 	if err != nil {
 		return false
 	}
@@ -45,8 +103,7 @@ func (fs *RealFileSystem) IsExecutable(path string) bool {
 
 // IsDirectory checks if a path is a directory.
 func (fs *RealFileSystem) IsDirectory(path string) bool {
-	fullPath := fs.resolvePath(path)
-	info, err := os.Stat(fullPath)
+	info, err := fs.stat(path)
 	if err != nil {
 		return false
 	}
@@ -55,39 +112,245 @@ func (fs *RealFileSystem) IsDirectory(path string) bool {
 
 // UserExists checks if a user exists.
 func (fs *RealFileSystem) UserExists(name string) bool {
-	// For offline analysis, we can't reliably check users
-	if fs.Root != "" {
-		return true // Assume exists in offline mode
-	}
-	_, err := user.Lookup(name)
-	return err == nil
+	return lookup(fs, func(c *fsCache) map[string]bool { return c.users }, name,
+		func(a *accounts) bool { return a.users[name] },
+		func() bool {
+			_, err := user.Lookup(name)
+			return err == nil
+		})
 }
 
 // GroupExists checks if a group exists.
 func (fs *RealFileSystem) GroupExists(name string) bool {
-	// For offline analysis, we can't reliably check groups
-	if fs.Root != "" {
-		return true // Assume exists in offline mode
+	return lookup(fs, func(c *fsCache) map[string]bool { return c.groups }, name,
+		func(a *accounts) bool { return a.groups[name] },
+		func() bool {
+			_, err := user.LookupGroup(name)
+			return err == nil
+		})
+}
+
+// UserIDExists checks if a user database entry has a UID.
+func (fs *RealFileSystem) UserIDExists(uid uint32) bool {
+	return lookup(fs, func(c *fsCache) map[uint32]bool { return c.uids }, uid,
+		func(a *accounts) bool { return a.uids[uid] },
+		func() bool {
+			_, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+			return err == nil
+		})
+}
+
+// GroupIDExists checks if a group database entry has a GID.
+func (fs *RealFileSystem) GroupIDExists(gid uint32) bool {
+	return lookup(fs, func(c *fsCache) map[uint32]bool { return c.gids }, gid,
+		func(a *accounts) bool { return a.gids[gid] },
+		func() bool {
+			_, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10))
+			return err == nil
+		})
+}
+
+// LookupUser returns the UID and primary GID of a user.
+func (fs *RealFileSystem) LookupUser(name string) (FileOwner, bool) {
+	check := func() (FileOwner, bool) {
+		if fs.Root != "" {
+			a := fs.accounts()
+			if a == nil {
+				return FileOwner{}, false
+			}
+			owner, ok := a.ids[name]
+			return owner, ok
+		}
+		u, err := user.Lookup(name)
+		if err != nil {
+			return FileOwner{}, false
+		}
+		uid, uerr := strconv.ParseUint(u.Uid, 10, 32)
+		gid, gerr := strconv.ParseUint(u.Gid, 10, 32)
+		if uerr != nil || gerr != nil {
+			return FileOwner{}, false
+		}
+		return FileOwner{UID: uint32(uid), GID: uint32(gid)}, true
 	}
-	_, err := user.LookupGroup(name)
-	return err == nil
+	if fs.cache == nil {
+		return check()
+	}
+
+	fs.cache.mu.Lock()
+	ids, ok := fs.cache.ids[name]
+	fs.cache.mu.Unlock()
+	if ok {
+		return ids.owner, ids.ok
+	}
+	ids.owner, ids.ok = check()
+	fs.cache.mu.Lock()
+	fs.cache.ids[name] = ids
+	fs.cache.mu.Unlock()
+	return ids.owner, ids.ok
+}
+
+// ReadFile reads the contents of a file. Contents are not cached.
+func (fs *RealFileSystem) ReadFile(path string) ([]byte, error) {
+	full, err := fs.resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(full)
+}
+
+// Mode returns the permission bits of a path, os.ModeSticky for a directory
+// only the owners of its files may remove them from, and os.ModeSocket for a
+// unix socket, following symlinks.
+func (fs *RealFileSystem) Mode(path string) (os.FileMode, bool) {
+	info, err := fs.stat(path)
+	if err != nil {
+		return 0, false
+	}
+	return info.Mode() & (os.ModePerm | os.ModeSticky | os.ModeSocket), true
+}
+
+// Owner returns the owner of a path, following symlinks.
+func (fs *RealFileSystem) Owner(path string) (FileOwner, bool) {
+	info, err := fs.stat(path)
+	if err != nil {
+		return FileOwner{}, false
+	}
+	return fileOwner(info)
 }
 
-// resolvePath prepends the root if set.
-func (fs *RealFileSystem) resolvePath(path string) string {
+// stat stats a path below the root, following symlinks
+func (fs *RealFileSystem) stat(path string) (os.FileInfo, error) {
+	if fs.cache == nil {
+		return fs.statUncached(path)
+	}
+	fs.cache.mu.Lock()
+	r, ok := fs.cache.stats[path]
+	fs.cache.mu.Unlock()
+	if ok {
+		return r.info, r.err
+	}
+
+	info, err := fs.statUncached(path)
+	fs.cache.mu.Lock()
+	fs.cache.stats[path] = statResult{info, err}
+	fs.cache.mu.Unlock()
+	return info, err
+}
+
+func (fs *RealFileSystem) statUncached(path string) (os.FileInfo, error) {
+	full, err := fs.resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(full)
+}
+
+// lookup checks a user or group, in the image's account files when there
+// is a root and the live user database otherwise, caching the answer in the
+// map cached picks
+func lookup[K comparable](fs *RealFileSystem, cached func(*fsCache) map[K]bool, key K, offline func(*accounts) bool, live func() bool) bool {
+	check := func() bool {
+		if fs.Root == "" {
+			return live()
+		}
+		a := fs.accounts()
+		// Without account files nothing can be said, so assume it exists
+		return a == nil || offline(a)
+	}
+	if fs.cache == nil {
+		return check()
+	}
+
+	fs.cache.mu.Lock()
+	exists, ok := cached(fs.cache)[key]
+	fs.cache.mu.Unlock()
+	if ok {
+		return exists
+	}
+	exists = check()
+	fs.cache.mu.Lock()
+	cached(fs.cache)[key] = exists
+	fs.cache.mu.Unlock()
+	return exists
+}
+
+// accounts returns the users and groups of the image, nil if it has no
+// /etc/passwd
+func (fs *RealFileSystem) accounts() *accounts {
+	if fs.cache == nil {
+		return loadAccounts(fs)
+	}
+	fs.cache.mu.Lock()
+	defer fs.cache.mu.Unlock()
+	if !fs.cache.loaded {
+		fs.cache.accounts = loadAccounts(fs)
+		fs.cache.loaded = true
+	}
+	return fs.cache.accounts
+}
+
+// maxSymlinks is the number of symlinks followed before giving up, as the
+// kernel does with ELOOP
+const maxSymlinks = 40
+
+// resolvePath returns the host path of a path below the root, resolving
+// symlinks as if the root were chrooted
+func (fs *RealFileSystem) resolvePath(name string) (string, error) {
 	if fs.Root == "" {
-		return path
+		return name, nil
+	}
+
+	pending := strings.Split(name, "/")
+	current := "/"
+	links := 0
+	for len(pending) > 0 {
+		component := pending[0]
+		pending = pending[1:]
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			current = path.Dir(current)
+			continue
+		}
+
+		next := path.Join(current, component)
+		info, err := os.Lstat(filepath.Join(fs.Root, next))
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			// Missing paths are left for the caller to fail on
+			current = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", &os.PathError{Op: "stat", Path: name, Err: syscall.ELOOP}
+		}
+		target, err := os.Readlink(filepath.Join(fs.Root, next))
+		if err != nil {
+			return "", err
+		}
+		if path.IsAbs(target) {
+			current = "/"
+		}
+		pending = append(strings.Split(target, "/"), pending...)
 	}
-	return fs.Root + path
+	return filepath.Join(fs.Root, current), nil
 }
 
 // MockFileSystem implements FileSystem for testing.
 type MockFileSystem struct {
-	Files       map[string]bool // path -> exists
-	Executables map[string]bool // path -> is executable
-	Directories map[string]bool // path -> is directory
-	Users       map[string]bool // username -> exists
-	Groups      map[string]bool // groupname -> exists
+	Files       map[string]bool        // path -> exists
+	Executables map[string]bool        // path -> is executable
+	Directories map[string]bool        // path -> is directory
+	Users       map[string]bool        // username -> exists
+	UserOwners  map[string]FileOwner   // username -> UID and primary GID
+	Groups      map[string]bool        // groupname -> exists
+	UserIDs     map[uint32]bool        // uid -> has a user entry
+	GroupIDs    map[uint32]bool        // gid -> has a group entry
+	Contents    map[string]string      // path -> file contents, the file exists
+	Modes       map[string]os.FileMode // path -> permission and type bits, 0644 if unset
+	Owners      map[string]FileOwner   // path -> owner, root if unset
 }
 
 // NewMockFileSystem creates a new MockFileSystem.
@@ -97,12 +360,19 @@ func NewMockFileSystem() *MockFileSystem {
 		Executables: make(map[string]bool),
 		Directories: make(map[string]bool),
 		Users:       make(map[string]bool),
+		UserOwners:  make(map[string]FileOwner),
 		Groups:      make(map[string]bool),
+		UserIDs:     make(map[uint32]bool),
+		GroupIDs:    make(map[uint32]bool),
+		Contents:    make(map[string]string),
+		Modes:       make(map[string]os.FileMode),
+		Owners:      make(map[string]FileOwner),
 	}
 }
 
 func (fs *MockFileSystem) Exists(path string) bool {
-	return fs.Files[path]
+	_, hasContents := fs.Contents[path]
+	return fs.Files[path] || hasContents
 }
 
 func (fs *MockFileSystem) IsExecutable(path string) bool {
@@ -120,3 +390,41 @@ func (fs *MockFileSystem) UserExists(name string) bool {
 func (fs *MockFileSystem) GroupExists(name string) bool {
 	return fs.Groups[name]
 }
+
+func (fs *MockFileSystem) UserIDExists(uid uint32) bool {
+	return fs.UserIDs[uid]
+}
+
+func (fs *MockFileSystem) GroupIDExists(gid uint32) bool {
+	return fs.GroupIDs[gid]
+}
+
+func (fs *MockFileSystem) ReadFile(path string) ([]byte, error) {
+	contents, ok := fs.Contents[path]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	return []byte(contents), nil
+}
+
+func (fs *MockFileSystem) Mode(path string) (os.FileMode, bool) {
+	if !fs.Exists(path) {
+		return 0, false
+	}
+	if mode, ok := fs.Modes[path]; ok {
+		return mode, true
+	}
+	return 0644, true
+}
+
+func (fs *MockFileSystem) Owner(path string) (FileOwner, bool) {
+	if !fs.Exists(path) {
+		return FileOwner{}, false
+	}
+	return fs.Owners[path], true
+}
+
+func (fs *MockFileSystem) LookupUser(name string) (FileOwner, bool) {
+	owner, ok := fs.UserOwners[name]
+	return owner, ok
+}
@@ -0,0 +1,392 @@
+package validation
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// ListenAddress is an IP address and port, or the path of an AF_UNIX socket,
+// that a socket listens on
+type ListenAddress struct {
+	Protocol string // "tcp", "udp", "sctp", "udplite" or "unix"
+	// Host is the IP address, empty for a bare port, which listens on every
+	// address
+	Host string
+	Port int
+	// Path is the path of a unix socket, or its abstract name starting with @
+	Path string
+}
+
+// Wildcard reports whether an IP address listens on every address, as a
+// bare port, 0.0.0.0 and [::] do
+func (a ListenAddress) Wildcard() bool {
+	if a.Protocol == "unix" {
+		return false
+	}
+	if a.Host == "" {
+		return true
+	}
+	ip := net.ParseIP(a.Host)
+	return ip != nil && ip.IsUnspecified()
+}
+
+// Abstract reports whether a unix socket has an abstract name, which is not
+// in the filesystem
+func (a ListenAddress) Abstract() bool {
+	return strings.HasPrefix(a.Path, "@")
+}
+
+// Conflicts reports whether two sockets cannot both listen on the addresses:
+// the same protocol and port, on the same address or on every address for
+// either of them, or the same unix socket path, whatever the socket type
+func (a ListenAddress) Conflicts(b ListenAddress) bool {
+	if a.Protocol == "unix" || b.Protocol == "unix" {
+		return a.Path != "" && a.Path == b.Path
+	}
+	if a.Protocol != b.Protocol || a.Port != b.Port {
+		return false
+	}
+	return a.Wildcard() || b.Wildcard() || a.Host == b.Host
+}
+
+func (a ListenAddress) String() string {
+	switch {
+	case a.Abstract():
+		return "abstract socket " + a.Path
+	case a.Protocol == "unix":
+		return "unix socket " + a.Path
+	}
+	host := "every address"
+	if !a.Wildcard() {
+		host = a.Host
+	}
+	return fmt.Sprintf("%s port %d on %s", a.Protocol, a.Port, host)
+}
+
+// ParseListenAddress parses the value of ListenStream=, ListenDatagram= or
+// ListenSequentialPacket= as an IP address and port, such as 8080,
+// 127.0.0.1:8080 or [::1]:8080, or a unix socket, such as /run/app.sock or
+// @app. The IP protocol is the one the directive uses, unless
+// socketProtocol, the socket's SocketProtocol=, names another. Paths are
+// cleaned, so /run/app.sock/ is /run/app.sock. VSOCK addresses and invalid
+// values return false.
+func ParseListenAddress(directive, value, socketProtocol string) (ListenAddress, bool) {
+	var a ListenAddress
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "/") || strings.HasPrefix(value, "@") {
+		if directive != "ListenStream" && directive != "ListenDatagram" && directive != "ListenSequentialPacket" {
+			return a, false
+		}
+		a.Protocol, a.Path = "unix", value
+		if !a.Abstract() {
+			a.Path = path.Clean(value)
+		}
+		return a, true
+	}
+
+	switch {
+	case directive == "ListenStream" && socketProtocol == "sctp":
+		a.Protocol = "sctp"
+	case directive == "ListenStream":
+		a.Protocol = "tcp"
+	case directive == "ListenDatagram" && socketProtocol == "udplite":
+		a.Protocol = "udplite"
+	case directive == "ListenDatagram":
+		a.Protocol = "udp"
+	default:
+		return a, false
+	}
+
+	portStr := value
+	if _, err := strconv.Atoi(value); err != nil {
+		host, p, err := net.SplitHostPort(value)
+		if err != nil {
+			return a, false
+		}
+		// An IPv6 link-local address may name its interface
+		host, _, _ = strings.Cut(host, "%")
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return a, false
+		}
+		a.Host, portStr = ip.String(), p
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return a, false
+	}
+	a.Port = port
+	return a, true
+}
+
+// SocketListen is an address a socket unit listens on, with the directive
+// declaring it
+type SocketListen struct {
+	Unit      string
+	File      string
+	Line      int
+	Directive string // "ListenStream", "ListenDatagram" or "ListenSequentialPacket"
+	Value     string
+	Address   ListenAddress
+}
+
+func (l SocketListen) String() string {
+	return l.Directive + "=" + l.Value
+}
+
+// PortConflict is a pair of sockets that cannot both listen, because they
+// use the same port and protocol on the same address or either listens on
+// every address, or the same unix socket. Only one of them starts.
+type PortConflict struct {
+	Socket SocketListen // The socket the conflict is reported on
+	Other  SocketListen // The socket it conflicts with
+}
+
+// overlap describes what the two addresses share
+func (c PortConflict) overlap() string {
+	a, b := c.Socket.Address, c.Other.Address
+	if a.Protocol == "unix" {
+		return "both listen on " + a.String()
+	}
+	if a.Wildcard() || b.Wildcard() {
+		return fmt.Sprintf("both use %s port %d, on every address for one of them", a.Protocol, a.Port)
+	}
+	return "both listen on " + a.String()
+}
+
+// SocketListens returns the IP addresses and unix sockets a socket unit
+// listens on. An empty assignment drops the addresses before it, and paths
+// with specifiers not known before the unit runs are left out.
+func SocketListens(unit *types.UnitFile) []SocketListen {
+	if unit.Type != "socket" || unit.Masked {
+		return nil
+	}
+	protocol := strings.ToLower(unit.GetDirective("Socket", "SocketProtocol"))
+
+	var listens []SocketListen
+	for _, directive := range []string{"ListenStream", "ListenDatagram", "ListenSequentialPacket"} {
+		var values []SocketListen
+		for _, d := range unit.GetDirectives("Socket", directive) {
+			if strings.TrimSpace(d.Value) == "" {
+				values = nil
+				continue
+			}
+			value, ok := ExpandSpecifiers(unit, d.Value)
+			if !ok {
+				continue
+			}
+			if address, ok := ParseListenAddress(directive, value, protocol); ok {
+				values = append(values, SocketListen{Unit: unit.Name, File: unit.Path, Line: d.Line, Directive: directive, Value: d.Value, Address: address})
+			}
+		}
+		listens = append(listens, values...)
+	}
+	return listens
+}
+
+// DetectPortConflicts finds pairs of sockets that cannot both listen. Each
+// pair is reported once, on the socket whose name sorts first.
+func DetectPortConflicts(units map[string]*types.UnitFile) []PortConflict {
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var conflicts []PortConflict
+	var seen []SocketListen
+	for _, name := range names {
+		listens := SocketListens(units[name])
+		for _, l := range listens {
+			for _, other := range seen {
+				if l.Address.Conflicts(other.Address) {
+					conflicts = append(conflicts, PortConflict{Socket: other, Other: l})
+				}
+			}
+		}
+		seen = append(seen, listens...)
+	}
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].Socket.Unit < conflicts[j].Socket.Unit
+	})
+	return conflicts
+}
+
+// portConflictsOf returns the conflicts of a socket with the other sockets
+// in units, on the socket
+func portConflictsOf(unit *types.UnitFile, units map[string]*types.UnitFile) []PortConflict {
+	listens := SocketListens(unit)
+	if len(listens) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var conflicts []PortConflict
+	for _, name := range names {
+		if name == unit.Name {
+			continue
+		}
+		for _, other := range SocketListens(units[name]) {
+			for _, l := range listens {
+				if l.Address.Conflicts(other.Address) {
+					conflicts = append(conflicts, PortConflict{Socket: l, Other: other})
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// procNetFiles are the IP sockets of the running system, by protocol, and
+// the state of those listening: TCP_LISTEN for TCP, and TCP_CLOSE, the state
+// of a bound socket that has no peer, for UDP
+var procNetFiles = []struct {
+	path, protocol, state string
+}{
+	{"/proc/net/tcp", "tcp", "0A"},
+	{"/proc/net/tcp6", "tcp", "0A"},
+	{"/proc/net/udp", "udp", "07"},
+	{"/proc/net/udp6", "udp", "07"},
+}
+
+// ListeningAddresses returns the TCP and UDP addresses and the unix sockets
+// that sockets of the running system listen on or are bound to, read from
+// /proc/net. Files that cannot be read, such as in an image, are skipped.
+func ListeningAddresses(fs FileSystem) []ListenAddress {
+	var addresses []ListenAddress
+	for _, f := range procNetFiles {
+		data, err := fs.ReadFile(f.path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			// sl local_address rem_address st ...
+			if len(fields) < 4 || fields[3] != f.state {
+				continue
+			}
+			if address, ok := parseProcNetAddress(fields[1]); ok {
+				address.Protocol = f.protocol
+				addresses = append(addresses, address)
+			}
+		}
+	}
+
+	// Num RefCount Protocol Flags Type St Inode Path, where Path is left out
+	// for unbound sockets
+	if data, err := fs.ReadFile("/proc/net/unix"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 8 || !strings.HasSuffix(fields[0], ":") {
+				continue
+			}
+			addresses = append(addresses, ListenAddress{Protocol: "unix", Path: fields[7]})
+		}
+	}
+	return addresses
+}
+
+// parseProcNetAddress parses an address of /proc/net/tcp and the like, such
+// as 0100007F:1F90 for 127.0.0.1:8080: the IP address is in 32-bit words in
+// the byte order of the host, little-endian on the platforms systemd runs on,
+// and the port is big-endian
+func parseProcNetAddress(s string) (ListenAddress, bool) {
+	hostHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return ListenAddress{}, false
+	}
+	raw, err := hex.DecodeString(hostHex)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return ListenAddress{}, false
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil || port == 0 {
+		return ListenAddress{}, false
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	address := ListenAddress{Port: int(port)}
+	if !ip.IsUnspecified() {
+		address.Host = ip.String()
+	}
+	return address, true
+}
+
+// addressesInUse returns the addresses of a socket that a process other
+// than systemd already listens on, and the unix socket files that no process
+// holds any more. Only sockets that should be listening but are not, because
+// they are enabled or failed, are checked: an active socket holds its own
+// addresses, and one never started leaves them to others.
+func addressesInUse(unit *types.UnitFile, fs FileSystem) []SocketProblem {
+	state := unit.Runtime
+	if state == nil || state.ActiveState == "active" || !(unit.Enabled || state.IsFailed()) {
+		return nil
+	}
+	listens := SocketListens(unit)
+	if len(listens) == 0 {
+		return nil
+	}
+
+	var problems []SocketProblem
+	listening := ListeningAddresses(fs)
+	for _, l := range listens {
+		problem := SocketProblem{Unit: unit.Name, File: l.File, Line: l.Line}
+		if held, ok := heldBy(l.Address, listening); ok {
+			problem.Kind = SocketPortInUse
+			problem.Reason = fmt.Sprintf("%s needs %s, but another process already listens on %s, so the socket cannot start.", l, l.Address, held)
+			if l.Address.Protocol == "unix" {
+				problem.Kind = SocketPathInUse
+				problem.Reason = fmt.Sprintf("%s needs %s, but another process already holds it.", l, l.Address)
+			}
+			problems = append(problems, problem)
+			continue
+		}
+		if l.Address.Protocol != "unix" || l.Address.Abstract() {
+			continue
+		}
+		mode, exists := fs.Mode(l.Address.Path)
+		if !exists {
+			continue
+		}
+		problem.Kind = SocketStalePath
+		problem.Reason = fmt.Sprintf("%s is a socket file that no process listens on, left by a daemon that did not remove it, so a service binding it itself fails with \"Address already in use\".", l.Address.Path)
+		if mode&os.ModeSocket == 0 {
+			problem.Reason = fmt.Sprintf("%s exists but is not a socket, so %s cannot be bound until it is removed.", l.Address.Path, l)
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// heldBy returns the listening address that takes an address, formatted as
+// in a Listen setting with * for every address
+func heldBy(a ListenAddress, listening []ListenAddress) (string, bool) {
+	for _, other := range listening {
+		if !a.Conflicts(other) {
+			continue
+		}
+		if other.Protocol == "unix" {
+			return other.Path, true
+		}
+		host := "*"
+		if !other.Wildcard() {
+			host = other.Host
+		}
+		return other.Protocol + " " + net.JoinHostPort(host, strconv.Itoa(other.Port)), true
+	}
+	return "", false
+}
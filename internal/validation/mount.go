@@ -1,10 +1,9 @@
 package validation
 
 import (
-	"fmt"
-	"path/filepath"
 	"strings"
 
+	"github.com/supabase/sdaudit/internal/unitname"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -24,7 +23,8 @@ type MountValidation struct {
 	Valid          bool
 }
 
-// ValidateMount checks mount unit configuration.
+// ValidateMount checks mount unit configuration. The device in What= is only
+// looked up when fs is not nil.
 func ValidateMount(unit *types.UnitFile, fs FileSystem) MountValidation {
 	result := MountValidation{
 		Unit:  unit.Name,
@@ -38,6 +38,7 @@ func ValidateMount(unit *types.UnitFile, fs FileSystem) MountValidation {
 	mountSection, hasMount := unit.Sections["Mount"]
 	if !hasMount {
 		result.Valid = false
+		result.WhatMissing = true
 		result.Issues = append(result.Issues, "Mount unit has no [Mount] section")
 		return result
 	}
@@ -73,9 +74,9 @@ func ValidateMount(unit *types.UnitFile, fs FileSystem) MountValidation {
 	}
 
 	// Check if device exists (for local filesystems)
-	if result.WhatValue != "" && !result.WhatMissing {
+	if result.WhatValue != "" && !result.WhatMissing && fs != nil {
 		// Skip network filesystems and special devices
-		if !isNetworkFS(result.FSType) && !isSpecialDevice(result.WhatValue) {
+		if !isNetworkFS(result.FSType) && !isSpecialDevice(result.WhatValue) && strings.HasPrefix(result.WhatValue, "/") {
 			if !fs.Exists(result.WhatValue) {
 				result.DeviceNotFound = true
 				// Not necessarily invalid - device might be created later
@@ -94,41 +95,13 @@ func ValidateMount(unit *types.UnitFile, fs FileSystem) MountValidation {
 	return result
 }
 
-// pathToMountUnitName converts a path to the systemd mount unit name.
+// pathToMountUnitName returns the name of the mount unit for a path, as
+// systemd-escape --path --suffix=mount does:
 // /home/user -> home-user.mount
+// /opt/my-app -> opt-my\x2dapp.mount
 // / -> -.mount
 func pathToMountUnitName(path string) string {
-	if path == "/" {
-		return "-.mount"
-	}
-
-	// Clean and trim the path
-	path = filepath.Clean(path)
-	path = strings.TrimPrefix(path, "/")
-	path = strings.TrimSuffix(path, "/")
-
-	// Replace / with -
-	name := strings.ReplaceAll(path, "/", "-")
-
-	// Escape special characters
-	name = escapeMountUnitName(name)
-
-	return name + ".mount"
-}
-
-// escapeMountUnitName escapes characters that need escaping in unit names.
-func escapeMountUnitName(s string) string {
-	var result strings.Builder
-	for _, c := range s {
-		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
-			(c >= '0' && c <= '9') || c == '-' || c == '_' {
-			result.WriteRune(c)
-		} else {
-			// Escape with \xHH format
-			fmt.Fprintf(&result, "\\x%02x", byte(c))
-		}
-	}
-	return result.String()
+	return unitname.EscapePath(path) + ".mount"
 }
 
 // isNetworkFS returns true if the filesystem type is network-based.
@@ -195,13 +168,15 @@ func isValidFSType(fsType string) bool {
 		// Linux native
 		"ext2": true, "ext3": true, "ext4": true,
 		"xfs": true, "btrfs": true, "f2fs": true,
-		"jfs": true, "reiserfs": true,
+		"jfs": true, "reiserfs": true, "zfs": true,
+		"bcachefs": true, "nilfs2": true, "erofs": true,
 		// FAT/NTFS
 		"vfat": true, "fat": true, "msdos": true,
 		"ntfs": true, "ntfs-3g": true, "exfat": true,
 		// Network
-		"nfs": true, "nfs4": true, "cifs": true, "smb": true,
+		"nfs": true, "nfs4": true, "cifs": true, "smb": true, "smb3": true,
 		"sshfs": true, "fuse.sshfs": true, "glusterfs": true,
+		"ceph": true, "lustre": true, "9p": true, "virtiofs": true,
 		// Pseudo
 		"tmpfs": true, "ramfs": true, "devtmpfs": true,
 		"proc": true, "sysfs": true, "devpts": true,
@@ -209,11 +184,12 @@ func isValidFSType(fsType string) bool {
 		"securityfs": true, "debugfs": true, "tracefs": true,
 		"hugetlbfs": true, "mqueue": true, "configfs": true,
 		"fusectl": true, "pstore": true, "efivarfs": true, "bpf": true,
+		"binfmt_misc": true, "rpc_pipefs": true,
 		// Other
 		"iso9660": true, "udf": true,
 		"squashfs": true, "overlay": true, "overlayfs": true,
 		"fuse": true, "fuseblk": true,
-		"autofs": true, "nfsd": true,
+		"autofs": true, "nfsd": true, "auto": true,
 		"swap": true,
 	}
 
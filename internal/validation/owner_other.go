@@ -0,0 +1,10 @@
+//go:build !unix
+
+package validation
+
+import "os"
+
+// fileOwner reports no owner where files have no UID and GID
+func fileOwner(info os.FileInfo) (FileOwner, bool) {
+	return FileOwner{}, false
+}
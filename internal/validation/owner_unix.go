@@ -0,0 +1,17 @@
+//go:build unix
+
+package validation
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwner returns the owner stat reported for a file
+func fileOwner(info os.FileInfo) (FileOwner, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return FileOwner{}, false
+	}
+	return FileOwner{UID: st.Uid, GID: st.Gid}, true
+}
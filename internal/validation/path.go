@@ -9,15 +9,23 @@ import (
 // PathValidation contains results of path unit validation.
 type PathValidation struct {
 	Unit            string
-	MissingService  bool     // No matching .service
-	ServiceName     string   // The expected service name
-	NoPathDirective bool     // No PathExists=/PathChanged= etc.
-	InvalidPaths    []string // Paths that look wrong
-	WatchedPaths    []string // Paths being watched
+	MissingService  bool          // No matching .service
+	ServiceName     string        // The expected service name
+	NoPathDirective bool          // No PathExists=/PathChanged= etc.
+	InvalidPaths    []InvalidPath // Paths that look wrong
+	WatchedPaths    []string      // Paths being watched
 	Issues          []string
 	Valid           bool
 }
 
+// InvalidPath represents a watched path that looks wrong.
+type InvalidPath struct {
+	Directive string
+	Value     string
+	Reason    string
+	Line      int
+}
+
 // ValidatePath checks path unit configuration.
 func ValidatePath(unit *types.UnitFile, allUnits map[string]*types.UnitFile) PathValidation {
 	result := PathValidation{
@@ -32,6 +40,7 @@ func ValidatePath(unit *types.UnitFile, allUnits map[string]*types.UnitFile) Pat
 	pathSection, hasPath := unit.Sections["Path"]
 	if !hasPath {
 		result.Valid = false
+		result.NoPathDirective = true
 		result.Issues = append(result.Issues, "Path unit has no [Path] section")
 		return result
 	}
@@ -62,7 +71,12 @@ func ValidatePath(unit *types.UnitFile, allUnits map[string]*types.UnitFile) Pat
 			for _, d := range dirs {
 				result.WatchedPaths = append(result.WatchedPaths, d.Value)
 				if invalid := validateWatchedPath(directive, d.Value); invalid != "" {
-					result.InvalidPaths = append(result.InvalidPaths, invalid)
+					result.InvalidPaths = append(result.InvalidPaths, InvalidPath{
+						Directive: directive,
+						Value:     d.Value,
+						Reason:    invalid,
+						Line:      d.Line,
+					})
 				}
 			}
 		}
@@ -93,10 +107,11 @@ func getPathServiceName(unit *types.UnitFile, pathSection *types.Section) string
 	return strings.TrimSuffix(unit.Name, ".path") + ".service"
 }
 
-// validateWatchedPath checks if a watched path looks valid.
+// validateWatchedPath checks if a watched path looks valid. An empty value
+// resets the paths to watch, and specifiers are expanded by systemd.
 func validateWatchedPath(directive, value string) string {
-	if value == "" {
-		return "Empty path for " + directive
+	if value == "" || strings.HasPrefix(value, "%") {
+		return ""
 	}
 
 	// PathExistsGlob can have glob patterns
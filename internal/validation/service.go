@@ -2,6 +2,7 @@ package validation
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/supabase/sdaudit/pkg/types"
@@ -17,6 +18,7 @@ type ServiceValidation struct {
 	GroupNotFound        string          // Group= doesn't exist
 	ContradictorySandbox []Contradiction // e.g., PrivateNetwork + curl
 	TypeIssues           []string        // Issues with Type= setting
+	PIDFileProblems      []PIDFileProblem
 	Valid                bool
 }
 
@@ -36,7 +38,8 @@ type Contradiction struct {
 	Description   string
 }
 
-// ValidateService performs service-specific checks.
+// ValidateService performs service-specific checks. Programs, users and
+// groups are only looked up when fs is not nil.
 func ValidateService(unit *types.UnitFile, fs FileSystem) ServiceValidation {
 	result := ServiceValidation{
 		Unit:  unit.Name,
@@ -69,9 +72,13 @@ func ValidateService(unit *types.UnitFile, fs FileSystem) ServiceValidation {
 		"ExecStop", "ExecStopPost", "ExecReload",
 	}
 
+	// Programs of a service with its own root are not on the host
 	for _, directive := range execDirectives {
+		if fs == nil || chrooted(serviceSection) {
+			break
+		}
 		if dirs, ok := serviceSection.Directives[directive]; ok {
-			for _, d := range dirs {
+			for _, d := range afterReset(dirs) {
 				missing, notExec := validateExecPath(d.Value, directive, d.Line, fs)
 				result.ExecStartNotFound = append(result.ExecStartNotFound, missing...)
 				result.ExecStartNotExec = append(result.ExecStartNotExec, notExec...)
@@ -80,14 +87,14 @@ func ValidateService(unit *types.UnitFile, fs FileSystem) ServiceValidation {
 	}
 
 	// Check User= and Group=
-	if userVal := getDirectiveValue(serviceSection, "User"); userVal != "" {
+	if userVal := getDirectiveValue(serviceSection, "User"); userVal != "" && fs != nil {
 		if !fs.UserExists(userVal) {
 			result.UserNotFound = userVal
 			result.Valid = false
 		}
 	}
 
-	if groupVal := getDirectiveValue(serviceSection, "Group"); groupVal != "" {
+	if groupVal := getDirectiveValue(serviceSection, "Group"); groupVal != "" && fs != nil {
 		if !fs.GroupExists(groupVal) {
 			result.GroupNotFound = groupVal
 			result.Valid = false
@@ -100,6 +107,8 @@ func ValidateService(unit *types.UnitFile, fs FileSystem) ServiceValidation {
 	// Check Type= specific issues
 	result.TypeIssues = validateServiceType(serviceSection, unit)
 
+	result.PIDFileProblems = ValidatePIDFile(unit)
+
 	if len(result.ExecStartNotFound) > 0 || len(result.ContradictorySandbox) > 0 || len(result.TypeIssues) > 0 {
 		result.Valid = false
 	}
@@ -158,6 +167,11 @@ parsePath:
 		return // Can't validate paths with specifiers
 	}
 
+	// systemd looks bare names up in its own search path
+	if !strings.HasPrefix(execPath, "/") {
+		return
+	}
+
 	// Check if path exists
 	if !fs.Exists(execPath) {
 		missing = append(missing, MissingExec{
@@ -189,8 +203,6 @@ func checkContradictorySandboxing(unit *types.UnitFile, serviceSection *types.Se
 	// Get sandboxing settings
 	privateNetwork := getDirectiveValue(serviceSection, "PrivateNetwork") == "yes" ||
 		getDirectiveValue(serviceSection, "PrivateNetwork") == "true"
-	privateUsers := getDirectiveValue(serviceSection, "PrivateUsers") == "yes" ||
-		getDirectiveValue(serviceSection, "PrivateUsers") == "true"
 	protectSystem := getDirectiveValue(serviceSection, "ProtectSystem")
 	readOnlyPaths := serviceSection.Directives["ReadOnlyPaths"]
 	inaccessiblePaths := serviceSection.Directives["InaccessiblePaths"]
@@ -222,22 +234,6 @@ func checkContradictorySandboxing(unit *types.UnitFile, serviceSection *types.Se
 		}
 	}
 
-	// Check PrivateUsers with User= that might need real UID
-	if privateUsers {
-		user := getDirectiveValue(serviceSection, "User")
-		if user != "" && user != "root" && user != "nobody" {
-			// Check if user is numeric (UID) vs name
-			if _, isNumeric := isNumericUser(user); !isNumeric {
-				contradictions = append(contradictions, Contradiction{
-					Setting:       "PrivateUsers=yes",
-					ConflictsWith: fmt.Sprintf("User=%s", user),
-					Severity:      "medium",
-					Description:   "PrivateUsers=yes may cause User= lookup to fail if user doesn't exist in private namespace",
-				})
-			}
-		}
-	}
-
 	// Check ProtectSystem with write operations
 	if protectSystem == "strict" || protectSystem == "full" {
 		// Check if any ExecStart writes to protected areas
@@ -254,6 +250,18 @@ func checkContradictorySandboxing(unit *types.UnitFile, serviceSection *types.Se
 		}
 	}
 
+	// Check sandboxing that makes the PID file's directory read-only
+	if pidFile, ok := pidFilePath(getDirectiveValue(serviceSection, "PIDFile")); ok {
+		if setting := readOnlySetting(serviceSection, path.Dir(pidFile)); setting != "" {
+			contradictions = append(contradictions, Contradiction{
+				Setting:       setting,
+				ConflictsWith: fmt.Sprintf("PIDFile=%s", getDirectiveValue(serviceSection, "PIDFile")),
+				Severity:      "high",
+				Description:   fmt.Sprintf("%s makes %s read-only, so the daemon cannot write its PID file", setting, path.Dir(pidFile)),
+			})
+		}
+	}
+
 	// Check ReadOnlyPaths/InaccessiblePaths with WorkingDirectory
 	workDir := getDirectiveValue(serviceSection, "WorkingDirectory")
 	if workDir != "" {
@@ -282,12 +290,6 @@ func checkContradictorySandboxing(unit *types.UnitFile, serviceSection *types.Se
 	return contradictions
 }
 
-func isNumericUser(user string) (int, bool) {
-	var uid int
-	_, err := fmt.Sscanf(user, "%d", &uid)
-	return uid, err == nil
-}
-
 // validateServiceType checks for issues with the Type= setting.
 func validateServiceType(serviceSection *types.Section, unit *types.UnitFile) []string {
 	var issues []string
@@ -297,22 +299,12 @@ func validateServiceType(serviceSection *types.Section, unit *types.UnitFile) []
 		serviceType = "simple" // Default
 	}
 
-	execStart := serviceSection.Directives["ExecStart"]
 	busName := getDirectiveValue(serviceSection, "BusName")
 	pidFile := getDirectiveValue(serviceSection, "PIDFile")
 
 	switch serviceType {
-	case "simple":
-		// Simple services should have ExecStart
-		if len(execStart) == 0 {
-			issues = append(issues, "Type=simple but no ExecStart= defined")
-		}
-
-	case "exec":
-		// Same as simple but waits for exec()
-		if len(execStart) == 0 {
-			issues = append(issues, "Type=exec but no ExecStart= defined")
-		}
+	case "simple", "exec":
+		// A missing ExecStart= is reported as ExecStartMissing
 
 	case "forking":
 		// Forking services should have PIDFile
@@ -331,7 +323,7 @@ func validateServiceType(serviceSection *types.Section, unit *types.UnitFile) []
 			issues = append(issues, "Type=dbus requires BusName= to be set")
 		}
 
-	case "notify":
+	case "notify", "notify-reload":
 		// Notify services should use sd_notify
 		// Default NotifyAccess is "main", which is usually fine
 
@@ -346,6 +338,190 @@ func validateServiceType(serviceSection *types.Section, unit *types.UnitFile) []
 	return issues
 }
 
+// PIDFile problems found by ValidatePIDFile.
+const (
+	PIDFileLegacyRun          = "pid_file_legacy_run"           // PIDFile= below /var/run rather than /run
+	PIDFileNotWritable        = "pid_file_not_writable"         // Sandboxing makes the PID file's directory read-only
+	PIDFileNoRuntimeDirectory = "pid_file_no_runtime_directory" // PIDFile= in a /run subdirectory nothing creates
+)
+
+// PIDFileProblem is a problem with the PIDFile= of a service.
+type PIDFileProblem struct {
+	Kind   string
+	Path   string // PIDFile= as written in the unit
+	Line   int
+	Reason string
+}
+
+// ValidatePIDFile checks that a service can write the PID file it names:
+// that it is not below the legacy /var/run, that a /run subdirectory holding
+// it is created by RuntimeDirectory=, and that ProtectSystem= or
+// ReadOnlyPaths= do not make its directory read-only.
+func ValidatePIDFile(unit *types.UnitFile) []PIDFileProblem {
+	serviceSection, ok := unit.Sections["Service"]
+	if !ok || !unit.IsService() {
+		return nil
+	}
+	directives := serviceSection.Directives["PIDFile"]
+	if len(directives) == 0 {
+		return nil
+	}
+	d := directives[len(directives)-1]
+	pidFile, ok := pidFilePath(d.Value)
+	if !ok {
+		return nil
+	}
+
+	var problems []PIDFileProblem
+	if strings.HasPrefix(d.Value, "/var/run/") {
+		problems = append(problems, PIDFileProblem{
+			Kind:   PIDFileLegacyRun,
+			Path:   d.Value,
+			Line:   d.Line,
+			Reason: fmt.Sprintf("PIDFile=%s is below the legacy /var/run, a symlink to /run that systemd rewrites with a warning on every load; use %s.", d.Value, pidFile),
+		})
+	}
+
+	dir := path.Dir(pidFile)
+	if setting := readOnlySetting(serviceSection, dir); setting != "" {
+		problems = append(problems, PIDFileProblem{
+			Kind:   PIDFileNotWritable,
+			Path:   d.Value,
+			Line:   d.Line,
+			Reason: fmt.Sprintf("PIDFile=%s is in %s, which %s makes read-only, so the daemon cannot write its PID file and systemd times out waiting for it.", d.Value, dir, setting),
+		})
+	} else if strings.HasPrefix(dir, "/run/") && !underAny(dir, sandboxDirectories(serviceSection, "RuntimeDirectory", "/run")) {
+		problems = append(problems, PIDFileProblem{
+			Kind:   PIDFileNoRuntimeDirectory,
+			Path:   d.Value,
+			Line:   d.Line,
+			Reason: fmt.Sprintf("PIDFile=%s is in %s, which is gone after a reboot because /run is a tmpfs, and no RuntimeDirectory= creates it.", d.Value, dir),
+		})
+	}
+	return problems
+}
+
+// pidFilePath returns the absolute path systemd uses for a PIDFile= value:
+// relative paths and %t are below /run, and /var/run is rewritten to /run.
+// Values with other specifiers are not resolved.
+func pidFilePath(value string) (string, bool) {
+	value = strings.ReplaceAll(value, "%t", "/run")
+	if value == "" || strings.Contains(value, "%") {
+		return "", false
+	}
+	if !strings.HasPrefix(value, "/") {
+		value = "/run/" + value
+	}
+	if strings.HasPrefix(value, "/var/run/") {
+		value = "/run/" + strings.TrimPrefix(value, "/var/run/")
+	}
+	return path.Clean(value), true
+}
+
+// readOnlySetting returns the sandboxing setting that makes dir read-only
+// for the service, or "" when the service can write to it. The most specific
+// of ReadWritePaths=, ReadOnlyPaths= and InaccessiblePaths= wins, and the
+// directories systemd creates for RuntimeDirectory= and friends stay
+// writable under ProtectSystem=strict.
+func readOnlySetting(serviceSection *types.Section, dir string) string {
+	writable := sandboxPaths(serviceSection, "ReadWritePaths")
+	writable = append(writable, sandboxDirectories(serviceSection, "RuntimeDirectory", "/run")...)
+	writable = append(writable, sandboxDirectories(serviceSection, "StateDirectory", "/var/lib")...)
+	writable = append(writable, sandboxDirectories(serviceSection, "CacheDirectory", "/var/cache")...)
+	writable = append(writable, sandboxDirectories(serviceSection, "LogsDirectory", "/var/log")...)
+
+	best, setting := -1, ""
+	for _, key := range []string{"ReadOnlyPaths", "InaccessiblePaths"} {
+		for _, p := range sandboxPaths(serviceSection, key) {
+			if under(dir, p) && len(p) > best {
+				best, setting = len(p), key+"="+p
+			}
+		}
+	}
+
+	switch getDirectiveValue(serviceSection, "ProtectSystem") {
+	case "strict":
+		// /run itself stays writable for services that create their own
+		// files in it, as root services do
+		if best < 0 && dir != "/run" {
+			best, setting = 0, "ProtectSystem=strict"
+		}
+	case "full":
+		for _, p := range []string{"/usr", "/boot", "/efi", "/etc"} {
+			if under(dir, p) && len(p) > best {
+				best, setting = len(p), "ProtectSystem=full"
+			}
+		}
+	case "yes", "true":
+		for _, p := range []string{"/usr", "/boot", "/efi"} {
+			if under(dir, p) && len(p) > best {
+				best, setting = len(p), "ProtectSystem=yes"
+			}
+		}
+	}
+
+	for _, p := range writable {
+		if under(dir, p) && len(p) >= best {
+			return ""
+		}
+	}
+	return setting
+}
+
+// sandboxPaths returns the paths of a directive such as ReadWritePaths=,
+// without the "-" and "+" prefixes
+func sandboxPaths(serviceSection *types.Section, key string) []string {
+	var paths []string
+	for _, d := range serviceSection.Directives[key] {
+		if d.Value == "" {
+			paths = nil
+			continue
+		}
+		for _, p := range strings.Fields(d.Value) {
+			paths = append(paths, path.Clean(strings.TrimLeft(p, "-+")))
+		}
+	}
+	return paths
+}
+
+// sandboxDirectories returns the directories systemd creates below base for
+// a directive such as RuntimeDirectory=
+func sandboxDirectories(serviceSection *types.Section, key, base string) []string {
+	var dirs []string
+	for _, d := range serviceSection.Directives[key] {
+		if d.Value == "" {
+			dirs = nil
+			continue
+		}
+		for _, name := range strings.Fields(d.Value) {
+			// RuntimeDirectory=name:symlink also creates a symlink
+			name, _, _ = strings.Cut(name, ":")
+			dirs = append(dirs, path.Join(base, name))
+		}
+	}
+	return dirs
+}
+
+// under reports whether dir is p or below it
+func under(dir, p string) bool {
+	return dir == p || p == "/" || strings.HasPrefix(dir, p+"/")
+}
+
+func underAny(dir string, paths []string) bool {
+	for _, p := range paths {
+		if under(dir, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// chrooted reports whether a service runs in RootDirectory= or RootImage=,
+// where its programs and working directory are looked up instead of the host
+func chrooted(serviceSection *types.Section) bool {
+	return getDirectiveValue(serviceSection, "RootDirectory") != "" || getDirectiveValue(serviceSection, "RootImage") != ""
+}
+
 // getDirectiveValue gets the first value of a directive.
 func getDirectiveValue(section *types.Section, key string) string {
 	if section == nil {
@@ -3,7 +3,6 @@ package validation
 import (
 	"fmt"
 	"net"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -16,11 +15,37 @@ type SocketValidation struct {
 	MissingService bool            // No matching .service unit
 	ServiceName    string          // The expected service name
 	InvalidListen  []InvalidListen // Malformed ListenStream/ListenDatagram
-	PortConflicts  []PortConflict  // Same port as another socket
+	NoListen       bool            // No Listen*= directives at all
+	PortConflicts  []PortConflict  // Same port as another socket, see DetectPortConflicts
+	Problems       []SocketProblem // Socket/service pairing and ownership problems
 	Issues         []string
 	Valid          bool
 }
 
+// Socket problems found by ValidateSocket.
+const (
+	SocketDoubleActivation  = "double_activation"    // Service is enabled on its own as well
+	SocketMissingDependency = "missing_dependency"   // Service of an Accept=no socket does not require it
+	SocketAcceptNonTemplate = "accept_non_template"  // Accept=yes paired with a non-template service
+	SocketTemplateService   = "template_service"     // Service= names a template without an instance
+	SocketMissingUser       = "missing_socket_user"  // SocketUser= does not exist
+	SocketMissingGroup      = "missing_socket_group" // SocketGroup= does not exist
+	SocketPortConflict      = "port_conflict"        // Another socket listens on the same port or path
+	SocketPortInUse         = "port_in_use"          // A process already listens on the port
+	SocketPathInUse         = "path_in_use"          // A process already holds the unix socket
+	SocketStalePath         = "stale_path"           // The unix socket file exists but nothing listens on it
+)
+
+// SocketProblem represents a problem with how a socket and the service it
+// activates fit together, or with the owner of the socket.
+type SocketProblem struct {
+	Kind   string // One of the Socket* problems
+	Unit   string // Unit declaring the directive, the socket or its service
+	File   string
+	Line   int
+	Reason string
+}
+
 // InvalidListen represents an invalid listen directive.
 type InvalidListen struct {
 	Directive string // "ListenStream", "ListenDatagram", etc.
@@ -29,14 +54,10 @@ type InvalidListen struct {
 	Line      int
 }
 
-// PortConflict represents a port conflict between sockets.
-type PortConflict struct {
-	Port        string
-	OtherSocket string
-}
-
-// ValidateSocket checks socket unit configuration.
-func ValidateSocket(unit *types.UnitFile, allUnits map[string]*types.UnitFile) SocketValidation {
+// ValidateSocket checks socket unit configuration and how it pairs with the
+// service it activates. SocketUser= and SocketGroup= are only looked up when
+// fs is not nil.
+func ValidateSocket(unit *types.UnitFile, allUnits map[string]*types.UnitFile, fs FileSystem) SocketValidation {
 	result := SocketValidation{
 		Unit:  unit.Name,
 		Valid: true,
@@ -49,6 +70,7 @@ func ValidateSocket(unit *types.UnitFile, allUnits map[string]*types.UnitFile) S
 	socketSection, hasSocket := unit.Sections["Socket"]
 	if !hasSocket {
 		result.Valid = false
+		result.NoListen = true
 		result.Issues = append(result.Issues, "Socket unit has no [Socket] section")
 		return result
 	}
@@ -58,9 +80,34 @@ func ValidateSocket(unit *types.UnitFile, allUnits map[string]*types.UnitFile) S
 	result.ServiceName = serviceName
 
 	// Check if service exists
-	if _, exists := allUnits[serviceName]; !exists {
-		result.MissingService = true
-		result.Valid = false
+	service, exists := allUnits[serviceName]
+	if !exists {
+		// An Accept=yes socket named after a plain service is reported below
+		base := strings.TrimSuffix(unit.Name, ".socket") + ".service"
+		if _, plain := allUnits[base]; !plain || !accepts(socketSection) || getDirectiveValue(socketSection, "Service") != "" {
+			result.MissingService = true
+			result.Valid = false
+		}
+	}
+
+	result.Problems = append(result.Problems, validatePairing(unit, socketSection, serviceName, service, allUnits)...)
+	if fs != nil {
+		result.Problems = append(result.Problems, validateSocketOwner(unit, socketSection, fs)...)
+		result.Problems = append(result.Problems, addressesInUse(unit, fs)...)
+	}
+	result.PortConflicts = portConflictsOf(unit, allUnits)
+	for _, c := range result.PortConflicts {
+		location := c.Other.File
+		if c.Other.Line > 0 {
+			location += ":" + strconv.Itoa(c.Other.Line)
+		}
+		result.Problems = append(result.Problems, SocketProblem{
+			Kind:   SocketPortConflict,
+			Unit:   unit.Name,
+			File:   c.Socket.File,
+			Line:   c.Socket.Line,
+			Reason: fmt.Sprintf("%s and %s of %s (%s) %s, so only the socket started first can listen.", c.Socket, c.Other, c.Other.Unit, location, c.overlap()),
+		})
 	}
 
 	// Validate listen directives
@@ -94,11 +141,12 @@ func ValidateSocket(unit *types.UnitFile, allUnits map[string]*types.UnitFile) S
 		}
 	}
 	if !hasListen {
+		result.NoListen = true
 		result.Issues = append(result.Issues, "Socket unit has no Listen* directives")
 		result.Valid = false
 	}
 
-	if len(result.InvalidListen) > 0 || len(result.Issues) > 0 {
+	if len(result.InvalidListen) > 0 || len(result.Issues) > 0 || len(result.Problems) > 0 {
 		result.Valid = false
 	}
 
@@ -112,19 +160,155 @@ func getExpectedServiceName(unit *types.UnitFile, socketSection *types.Section)
 		return service
 	}
 
-	// Default: same name with .service extension
-	return strings.TrimSuffix(unit.Name, ".socket") + ".service"
+	// Default: same name with .service extension, a template for Accept=yes
+	base := strings.TrimSuffix(unit.Name, ".socket")
+	if accepts(socketSection) {
+		return base + "@.service"
+	}
+	return base + ".service"
+}
+
+// accepts reports whether a socket spawns a service instance per connection
+func accepts(socketSection *types.Section) bool {
+	accept := strings.ToLower(getDirectiveValue(socketSection, "Accept"))
+	return accept == "yes" || accept == "true" || accept == "on" || accept == "1"
+}
+
+// validatePairing checks that the socket and its service agree on how the
+// service is activated.
+func validatePairing(unit *types.UnitFile, socketSection *types.Section, serviceName string, service *types.UnitFile, allUnits map[string]*types.UnitFile) []SocketProblem {
+	var problems []SocketProblem
+
+	explicit := socketSection.Directives["Service"]
+	if len(explicit) > 0 && strings.HasSuffix(serviceName, "@.service") && !accepts(socketSection) {
+		problems = append(problems, SocketProblem{
+			Kind: SocketTemplateService,
+			Unit: unit.Name,
+			File: unit.Path,
+			Line: explicit[0].Line,
+			Reason: fmt.Sprintf("Service=%s names a template without an instance, so systemd refuses to load %s.",
+				serviceName, unit.Name),
+		})
+	}
+
+	if accepts(socketSection) {
+		accept := socketSection.Directives["Accept"][0]
+		switch {
+		case len(explicit) > 0:
+			problems = append(problems, SocketProblem{
+				Kind: SocketAcceptNonTemplate,
+				Unit: unit.Name,
+				File: unit.Path,
+				Line: explicit[0].Line,
+				Reason: fmt.Sprintf("%s has Accept=yes and Service=%s, but sockets that accept connections must activate the template %s, so systemd refuses to load the socket.",
+					unit.Name, serviceName, strings.TrimSuffix(unit.Name, ".socket")+"@.service"),
+			})
+		case service == nil:
+			plain := strings.TrimSuffix(unit.Name, ".socket") + ".service"
+			if _, ok := allUnits[plain]; ok {
+				problems = append(problems, SocketProblem{
+					Kind: SocketAcceptNonTemplate,
+					Unit: unit.Name,
+					File: unit.Path,
+					Line: accept.Line,
+					Reason: fmt.Sprintf("%s has Accept=yes, which starts an instance of %s for each connection, but only %s exists, so every connection fails.",
+						unit.Name, serviceName, plain),
+				})
+			}
+		}
+		return problems
+	}
+
+	if service == nil || strings.Contains(service.Name, "@") {
+		return problems
+	}
+
+	// A service enabled on its own starts at boot whether or not anything
+	// connects to the socket
+	for _, key := range []string{"WantedBy", "RequiredBy", "UpheldBy"} {
+		if directives := service.GetDirectives("Install", key); len(directives) > 0 {
+			problems = append(problems, SocketProblem{
+				Kind: SocketDoubleActivation,
+				Unit: service.Name,
+				File: service.Path,
+				Line: directives[0].Line,
+				Reason: fmt.Sprintf("%s is activated by %s but also has %s=%s, so it starts at boot without waiting for a connection.",
+					service.Name, unit.Name, key, directives[0].Value),
+			})
+			break
+		}
+	}
+
+	// Started directly, the service runs without the sockets it expects to
+	// be passed unless it pulls in the socket itself
+	if !pullsInSocket(service, unit.Name) {
+		problems = append(problems, SocketProblem{
+			Kind: SocketMissingDependency,
+			Unit: service.Name,
+			File: service.Path,
+			Reason: fmt.Sprintf("%s is activated by %s but has no Requires= and After= on it, so starting %s directly runs it without the listening sockets.",
+				service.Name, unit.Name, service.Name),
+		})
+	}
+
+	return problems
+}
+
+// pullsInSocket reports whether a service requires or wants a socket, or
+// lists it in Sockets=, which orders the service after it
+func pullsInSocket(service *types.UnitFile, socket string) bool {
+	for _, key := range []string{"Requires", "BindsTo", "Wants"} {
+		for _, d := range service.GetDirectives("Unit", key) {
+			for _, name := range strings.Fields(d.Value) {
+				if name == socket {
+					return true
+				}
+			}
+		}
+	}
+	for _, d := range service.GetDirectives("Service", "Sockets") {
+		for _, name := range strings.Fields(d.Value) {
+			if name == socket {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateSocketOwner checks that SocketUser= and SocketGroup= exist
+func validateSocketOwner(unit *types.UnitFile, socketSection *types.Section, fs FileSystem) []SocketProblem {
+	var problems []SocketProblem
+	owners := []struct {
+		key, kind, what string
+		exists          func(string) bool
+	}{
+		{"SocketUser", SocketMissingUser, "user", fs.UserExists},
+		{"SocketGroup", SocketMissingGroup, "group", fs.GroupExists},
+	}
+	for _, owner := range owners {
+		for _, d := range socketSection.Directives[owner.key] {
+			name := strings.TrimSpace(d.Value)
+			if name == "" || name == "root" || strings.Contains(name, "%") || owner.exists(name) {
+				continue
+			}
+			problems = append(problems, SocketProblem{
+				Kind:   owner.kind,
+				Unit:   unit.Name,
+				File:   unit.Path,
+				Line:   d.Line,
+				Reason: fmt.Sprintf("%s=%s names a %s that does not exist, so %s fails to start.", owner.key, name, owner.what, unit.Name),
+			})
+		}
+	}
+	return problems
 }
 
-// validateListenValue validates a listen directive value.
+// validateListenValue validates a listen directive value. An empty value
+// resets the list of addresses.
 func validateListenValue(directive, value string, line int) *InvalidListen {
 	if value == "" {
-		return &InvalidListen{
-			Directive: directive,
-			Value:     value,
-			Reason:    "Empty value",
-			Line:      line,
-		}
+		return nil
 	}
 
 	switch directive {
@@ -279,54 +463,3 @@ func validateNetlinkListen(directive, value string, line int) *InvalidListen {
 
 	return nil
 }
-
-// DetectPortConflicts finds sockets listening on the same port.
-func DetectPortConflicts(units map[string]*types.UnitFile) []PortConflict {
-	var conflicts []PortConflict
-
-	// Map port -> socket unit name
-	portMap := make(map[string]string)
-
-	// Regex to extract port from various formats
-	portRegex := regexp.MustCompile(`(?::(\d+)$|^(\d+)$)`)
-
-	for name, unit := range units {
-		if unit.Type != "socket" {
-			continue
-		}
-
-		socketSection, ok := unit.Sections["Socket"]
-		if !ok {
-			continue
-		}
-
-		for _, directive := range []string{"ListenStream", "ListenDatagram"} {
-			if dirs, ok := socketSection.Directives[directive]; ok {
-				for _, d := range dirs {
-					// Extract port number
-					matches := portRegex.FindStringSubmatch(d.Value)
-					var port string
-					if len(matches) > 1 && matches[1] != "" {
-						port = matches[1]
-					} else if len(matches) > 2 && matches[2] != "" {
-						port = matches[2]
-					}
-
-					if port != "" {
-						key := directive + ":" + port
-						if existingUnit, exists := portMap[key]; exists && existingUnit != name {
-							conflicts = append(conflicts, PortConflict{
-								Port:        port,
-								OtherSocket: existingUnit,
-							})
-						} else {
-							portMap[key] = name
-						}
-					}
-				}
-			}
-		}
-	}
-
-	return conflicts
-}
@@ -0,0 +1,76 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/unitname"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// systemSpecifiers are the specifiers with a fixed value for system units
+var systemSpecifiers = map[byte]string{
+	'h': "/root",
+	't': "/run",
+	'S': "/var/lib",
+	'C': "/var/cache",
+	'L': "/var/log",
+	'E': "/etc",
+	'T': "/tmp",
+	'V': "/var/tmp",
+	'%': "%",
+}
+
+// ExpandSpecifiers replaces the specifiers of a system unit's directive that
+// are known without the running manager: those made from the unit name, such
+// as %n and %i, the fixed directories of system units, such as %S, and %%.
+// It returns false when a specifier is left, such as %i in a template or %H,
+// the host name.
+func ExpandSpecifiers(unit *types.UnitFile, value string) (string, bool) {
+	if !strings.Contains(value, "%") {
+		return value, true
+	}
+
+	base := strings.TrimSuffix(unit.Name, "."+unit.Type)
+	prefix, instance, _ := strings.Cut(base, "@")
+	last := prefix
+	if i := strings.LastIndex(prefix, "-"); i >= 0 {
+		last = prefix[i+1:]
+	}
+	file := instance
+	if file == "" {
+		file = prefix
+	}
+	names := map[byte]string{
+		'n': unit.Name,
+		'N': base,
+		'p': prefix,
+		'P': unitname.Unescape(prefix),
+		'j': last,
+		'J': unitname.Unescape(last),
+		'f': "/" + strings.TrimPrefix(unitname.Unescape(file), "/"),
+	}
+	if instance != "" {
+		names['i'] = instance
+		names['I'] = unitname.Unescape(instance)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '%' {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+1 == len(value) {
+			return "", false
+		}
+		i++
+		if s, ok := names[value[i]]; ok {
+			b.WriteString(s)
+		} else if s, ok := systemSpecifiers[value[i]]; ok {
+			b.WriteString(s)
+		} else {
+			return "", false
+		}
+	}
+	return b.String(), true
+}
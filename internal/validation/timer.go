@@ -1,11 +1,13 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/supabase/sdaudit/internal/timing"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -23,9 +25,10 @@ type TimerValidation struct {
 
 // InvalidCalendar represents an invalid OnCalendar= expression.
 type InvalidCalendar struct {
-	Value  string
-	Reason string
-	Line   int
+	Value     string
+	Component string // Component that failed, such as "hour", empty if unknown
+	Reason    string
+	Line      int
 }
 
 // InvalidTimer represents an invalid timer directive.
@@ -50,6 +53,7 @@ func ValidateTimer(unit *types.UnitFile, allUnits map[string]*types.UnitFile) Ti
 	timerSection, hasTimer := unit.Sections["Timer"]
 	if !hasTimer {
 		result.Valid = false
+		result.NoTrigger = true
 		result.Issues = append(result.Issues, "Timer unit has no [Timer] section")
 		return result
 	}
@@ -93,6 +97,13 @@ func ValidateTimer(unit *types.UnitFile, allUnits map[string]*types.UnitFile) Ti
 		}
 	}
 
+	// OnClockChange= and OnTimezoneChange= take a boolean rather than a time
+	for _, directive := range []string{"OnClockChange", "OnTimezoneChange"} {
+		if len(timerSection.Directives[directive]) > 0 {
+			hasTrigger = true
+		}
+	}
+
 	if !hasTrigger {
 		result.NoTrigger = true
 		result.Valid = false
@@ -117,8 +128,8 @@ func getTimerServiceName(unit *types.UnitFile, timerSection *types.Section) stri
 	return strings.TrimSuffix(unit.Name, ".timer") + ".service"
 }
 
-// validateCalendarExpression validates an OnCalendar= expression.
-// Full calendar spec: DOW YYYY-MM-DD HH:MM:SS
+// validateCalendarExpression validates an OnCalendar= expression with the
+// calendar parser, naming the component systemd would reject.
 func validateCalendarExpression(value string, line int) *InvalidCalendar {
 	value = strings.TrimSpace(value)
 	if value == "" {
@@ -129,62 +140,20 @@ func validateCalendarExpression(value string, line int) *InvalidCalendar {
 		}
 	}
 
-	// Common predefined expressions
-	predefined := map[string]bool{
-		"minutely":     true,
-		"hourly":       true,
-		"daily":        true,
-		"monthly":      true,
-		"weekly":       true,
-		"yearly":       true,
-		"annually":     true,
-		"quarterly":    true,
-		"semiannually": true,
-		"*-*-* *:*:*":  true,
-	}
-
-	if predefined[strings.ToLower(value)] {
+	_, err := timing.ParseCalendar(value)
+	if err == nil {
 		return nil
 	}
-
-	// Basic validation of calendar expression format
-	// This is a simplified check - full validation would be complex
-
-	// Check for obviously malformed expressions
-	parts := strings.Fields(value)
-	if len(parts) == 0 {
-		return &InvalidCalendar{
-			Value:  value,
-			Reason: "Empty calendar expression",
-			Line:   line,
-		}
+	invalid := &InvalidCalendar{
+		Value:  value,
+		Reason: err.Error(),
+		Line:   line,
 	}
-
-	// Valid day-of-week names
-	validDOW := map[string]bool{
-		"sun": true, "sunday": true,
-		"mon": true, "monday": true,
-		"tue": true, "tuesday": true,
-		"wed": true, "wednesday": true,
-		"thu": true, "thursday": true,
-		"fri": true, "friday": true,
-		"sat": true, "saturday": true,
+	var calErr *timing.CalendarError
+	if errors.As(err, &calErr) {
+		invalid.Component = calErr.Component
 	}
-
-	// Check if first part is a day-of-week
-	firstPart := strings.ToLower(strings.TrimSuffix(parts[0], ","))
-	if validDOW[firstPart] || strings.Contains(firstPart, "..") {
-		// Day of week component - skip to next
-		parts = parts[1:]
-	}
-
-	// Be lenient with validation - systemd calendar expressions have many valid formats:
-	// - Shorthands like "*-*-1" or "Mon *-*-* 10:00"
-	// - Ranges, commas, and wildcards in date/time parts
-	// We only do basic validation here, trusting systemd to report detailed errors
-	_ = parts
-
-	return nil
+	return invalid
 }
 
 // validateTimerExpression validates On*Sec= directives.
@@ -208,7 +177,7 @@ func validateTimerExpression(directive, value string, line int) *InvalidTimer {
 	}
 
 	// Check for time span format
-	timeSpanRegex := regexp.MustCompile(`^\d+(\.\d+)?\s*(usec|us|msec|ms|seconds?|sec|s|minutes?|min|m|hours?|hr|h|days?|d|weeks?|w|months?|M|years?|y)?(\s+\d+(\.\d+)?\s*(usec|us|msec|ms|seconds?|sec|s|minutes?|min|m|hours?|hr|h|days?|d|weeks?|w|months?|M|years?|y)?)*$`)
+	timeSpanRegex := regexp.MustCompile(`^\d+(\.\d+)?\s*(usec|us|msec|ms|seconds?|sec|s|minutes?|min|m|hours?|hr|h|days?|d|weeks?|w|months?|M|years?|y)?(\s*\d+(\.\d+)?\s*(usec|us|msec|ms|seconds?|sec|s|minutes?|min|m|hours?|hr|h|days?|d|weeks?|w|months?|M|years?|y)?)*$`)
 
 	if !timeSpanRegex.MatchString(value) {
 		return &InvalidTimer{
@@ -1,10 +1,14 @@
 package validation
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
-	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/unitfile"
 	"github.com/supabase/sdaudit/pkg/types"
 )
 
@@ -14,7 +18,7 @@ func loadTestUnits(t *testing.T, path string) map[string]*types.UnitFile {
 	if err != nil {
 		t.Fatalf("failed to get absolute path: %v", err)
 	}
-	units, err := analyzer.LoadUnitsFromDirectory(absPath)
+	units, err := unitfile.LoadDirectory(absPath)
 	if err != nil {
 		t.Fatalf("failed to load units from %s: %v", path, err)
 	}
@@ -93,6 +97,114 @@ func TestValidateService_UserNotFound(t *testing.T) {
 	}
 }
 
+func TestValidateDirectives_Skips(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"chrooted", "[Service]\nRootDirectory=/srv/root\nExecStart=/usr/bin/app\nWorkingDirectory=/app\n"},
+		{"created directory", "[Service]\nExecStart=/bin/true\nStateDirectory=app\nWorkingDirectory=/var/lib/app\n"},
+		{"reset command", "[Service]\nExecStart=/usr/bin/gone\nExecStart=\nExecStart=/bin/true\n"},
+		{"specifier", "[Service]\nExecStart=/bin/true\nRuntimeDirectory=%N\n"},
+	}
+
+	fs := NewMockFileSystem()
+	fs.Files["/bin/true"] = true
+	fs.Executables["/bin/true"] = true
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit, err := unitfile.ParseContent("/etc/systemd/system/app.service", tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := ValidateDirectives(unit, fs)
+			if !result.Valid {
+				t.Errorf("ValidateDirectives() = %+v, want valid", result)
+			}
+		})
+	}
+}
+
+func TestValidateTimer_Triggers(t *testing.T) {
+	unit, err := unitfile.ParseContent("/etc/systemd/system/app.timer", "[Timer]\nOnClockChange=yes\nOnUnitActiveSec=1h30min\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := ValidateTimer(unit, nil)
+	if result.NoTrigger || len(result.InvalidTimers) != 0 {
+		t.Errorf("ValidateTimer() = %+v, want a trigger and no invalid time spans", result)
+	}
+}
+
+func TestValidatePIDFile(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/validation/pid_file")
+
+	type problem struct {
+		kind string
+		line int
+	}
+	tests := []struct {
+		unit string
+		want []problem
+	}{
+		{"legacy.service", []problem{{PIDFileLegacyRun, 6}}},
+		{"norundir.service", []problem{{PIDFileNoRuntimeDirectory, 6}}},
+		{"rundir.service", nil},
+		{"strict.service", []problem{{PIDFileNotWritable, 7}}},
+		{"strict-rw.service", nil},
+		{"readonly.service", []problem{{PIDFileNotWritable, 7}}},
+		{"legacy-rundir.service", []problem{{PIDFileLegacyRun, 8}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			var got []problem
+			for _, p := range ValidatePIDFile(units[tt.unit]) {
+				got = append(got, problem{p.Kind, p.Line})
+				if p.Reason == "" {
+					t.Errorf("%s has no reason", p.Kind)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("problems = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// ValidateService reports the read-only directory as a sandboxing contradiction
+	result := ValidateService(units["strict.service"], NewMockFileSystem())
+	if len(result.ContradictorySandbox) != 1 || result.ContradictorySandbox[0].Setting != "ProtectSystem=strict" {
+		t.Errorf("ContradictorySandbox = %+v, want ProtectSystem=strict", result.ContradictorySandbox)
+	}
+	if len(result.PIDFileProblems) != 1 {
+		t.Errorf("PIDFileProblems = %+v", result.PIDFileProblems)
+	}
+	if !strings.Contains(ValidatePIDFile(units["readonly.service"])[0].Reason, "ReadOnlyPaths=/srv") {
+		t.Error("reason should name the ReadOnlyPaths= entry")
+	}
+}
+
+func TestPIDFilePath(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"/run/app.pid", "/run/app.pid", true},
+		{"/var/run/app/app.pid", "/run/app/app.pid", true},
+		{"app/app.pid", "/run/app/app.pid", true},
+		{"%t/app.pid", "/run/app.pid", true},
+		{"/run/%i.pid", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := pidFilePath(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("pidFilePath(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
 func TestValidateSocket_MissingService(t *testing.T) {
 	units := loadTestUnits(t, "../../testdata/validation/socket_no_service")
 	unit := units["orphan.socket"]
@@ -100,7 +212,7 @@ func TestValidateSocket_MissingService(t *testing.T) {
 		t.Fatal("orphan.socket not found")
 	}
 
-	result := ValidateSocket(unit, units)
+	result := ValidateSocket(unit, units, nil)
 
 	if result.Valid {
 		t.Error("expected invalid socket")
@@ -113,6 +225,56 @@ func TestValidateSocket_MissingService(t *testing.T) {
 	}
 }
 
+func TestValidateSocket_Pairing(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/validation/socket_pairing")
+
+	fs := NewMockFileSystem()
+	fs.Groups["api"] = true
+
+	type problem struct {
+		kind, unit string
+		line       int
+	}
+	tests := []struct {
+		socket string
+		want   []problem
+	}{
+		{"web.socket", []problem{{SocketDoubleActivation, "web.service", 8}, {SocketMissingDependency, "web.service", 0}}},
+		{"api.socket", []problem{{SocketMissingUser, "api.socket", 6}}},
+		{"echo.socket", []problem{{SocketAcceptNonTemplate, "echo.socket", 6}}},
+		{"ssh.socket", nil},
+		{"queue.socket", []problem{{SocketTemplateService, "queue.socket", 6}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.socket, func(t *testing.T) {
+			result := ValidateSocket(units[tt.socket], units, fs)
+
+			var got []problem
+			for _, p := range result.Problems {
+				got = append(got, problem{p.Kind, p.Unit, p.Line})
+				if p.Reason == "" {
+					t.Errorf("%s has no reason", p.Kind)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Problems = %+v, want %+v", got, tt.want)
+			}
+			if result.MissingService {
+				t.Errorf("MissingService is set for %s", result.ServiceName)
+			}
+			if result.Valid != (len(tt.want) == 0) {
+				t.Errorf("Valid = %v with %d problems", result.Valid, len(tt.want))
+			}
+		})
+	}
+
+	// Without a filesystem the owner is not looked up
+	if result := ValidateSocket(units["api.socket"], units, nil); len(result.Problems) != 0 {
+		t.Errorf("Problems without a filesystem = %+v", result.Problems)
+	}
+}
+
 func TestValidateSocket_ValidListen(t *testing.T) {
 	tests := []struct {
 		directive string
@@ -142,6 +304,200 @@ func TestValidateSocket_ValidListen(t *testing.T) {
 	}
 }
 
+func parseSockets(t *testing.T, contents map[string]string) map[string]*types.UnitFile {
+	t.Helper()
+	units := make(map[string]*types.UnitFile)
+	for name, content := range contents {
+		unit, err := unitfile.ParseContent("/etc/systemd/system/"+name, content)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", name, err)
+		}
+		units[name] = unit
+	}
+	return units
+}
+
+func TestParseListenAddress(t *testing.T) {
+	tests := []struct {
+		directive, value, protocol string
+		want                       ListenAddress
+		ok                         bool
+	}{
+		{"ListenStream", "8080", "", ListenAddress{Protocol: "tcp", Port: 8080}, true},
+		{"ListenStream", "127.0.0.1:8080", "", ListenAddress{Protocol: "tcp", Host: "127.0.0.1", Port: 8080}, true},
+		{"ListenStream", "[::1]:8080", "", ListenAddress{Protocol: "tcp", Host: "::1", Port: 8080}, true},
+		{"ListenStream", "[fe80::1%eth0]:80", "", ListenAddress{Protocol: "tcp", Host: "fe80::1", Port: 80}, true},
+		{"ListenStream", "0.0.0.0:80", "sctp", ListenAddress{Protocol: "sctp", Host: "0.0.0.0", Port: 80}, true},
+		{"ListenDatagram", "514", "", ListenAddress{Protocol: "udp", Port: 514}, true},
+		{"ListenStream", "/run/app.sock", "", ListenAddress{Protocol: "unix", Path: "/run/app.sock"}, true},
+		{"ListenDatagram", "/run//app.sock/", "", ListenAddress{Protocol: "unix", Path: "/run/app.sock"}, true},
+		{"ListenSequentialPacket", "@app", "", ListenAddress{Protocol: "unix", Path: "@app"}, true},
+		{"ListenStream", "vsock:2:1234", "", ListenAddress{}, false},
+		{"ListenStream", "localhost:80", "", ListenAddress{}, false},
+		{"ListenStream", "70000", "", ListenAddress{}, false},
+		{"ListenFIFO", "/run/app.fifo", "", ListenAddress{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseListenAddress(tt.directive, tt.value, tt.protocol)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("ParseListenAddress(%s, %q) = %+v, %v, want %+v, %v", tt.directive, tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDetectPortConflicts(t *testing.T) {
+	units := parseSockets(t, map[string]string{
+		"any.socket":       "[Socket]\nListenStream=9000\n",
+		"local.socket":     "[Socket]\nListenStream=127.0.0.1:9000\n",
+		"private.socket":   "[Socket]\nListenStream=10.0.0.5:8080\n",
+		"loopback.socket":  "[Socket]\nListenStream=127.0.0.1:8080\n",
+		"dns.socket":       "[Socket]\nListenDatagram=53\n",
+		"dns-tcp.socket":   "[Socket]\nListenStream=53\n",
+		"reset.socket":     "[Socket]\nListenStream=9000\nListenStream=\nListenStream=9001\n",
+		"v6.socket":        "[Socket]\nListenStream=[::]:7000\n",
+		"v4.socket":        "[Socket]\nListenStream=192.168.1.1:7000\n",
+		"api.socket":       "[Socket]\nListenStream=/run/api.sock\n",
+		"api-dgram.socket": "[Socket]\nListenDatagram=/run/api.sock/\n",
+		"abstract.socket":  "[Socket]\nListenStream=@bus\n",
+		"bus.socket":       "[Socket]\nListenSequentialPacket=@bus\n",
+		"other.socket":     "[Socket]\nListenStream=/run/other.sock\n",
+		"runtime.socket":   "[Socket]\nListenStream=%t/api.sock\n",
+	})
+
+	var got []string
+	for _, c := range DetectPortConflicts(units) {
+		got = append(got, fmt.Sprintf("%s:%d %s:%d", c.Socket.Unit, c.Socket.Line, c.Other.Unit, c.Other.Line))
+	}
+	want := []string{
+		"abstract.socket:2 bus.socket:2",
+		"any.socket:2 local.socket:2",
+		"api-dgram.socket:2 api.socket:2",
+		"api-dgram.socket:2 runtime.socket:2",
+		"api.socket:2 runtime.socket:2",
+		"v4.socket:2 v6.socket:2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectPortConflicts() = %v, want %v", got, want)
+	}
+
+	// Each socket of a pair reports it, naming the other
+	for socket, other := range map[string]string{"any.socket": "local.socket", "local.socket": "any.socket"} {
+		result := ValidateSocket(units[socket], units, nil)
+		if len(result.PortConflicts) != 1 || result.PortConflicts[0].Socket.Unit != socket || result.PortConflicts[0].Other.Unit != other {
+			t.Errorf("PortConflicts of %s = %+v, want one with %s", socket, result.PortConflicts, other)
+		}
+		var reasons []string
+		for _, p := range result.Problems {
+			if p.Kind == SocketPortConflict {
+				reasons = append(reasons, p.Reason)
+			}
+		}
+		if len(reasons) != 1 || !strings.Contains(reasons[0], other+" (/etc/systemd/system/"+other+":2)") {
+			t.Errorf("port conflict of %s = %q, want one naming %s and its line", socket, reasons, other)
+		}
+	}
+}
+
+func TestListeningAddresses(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.Contents["/proc/net/tcp"] = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1 1 0000000000000000 100 0 0 10 0
+   1: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:1F90 0100007F:C350 01 00000000:00000000 00:00000000 00000000     0        0 3 1 0000000000000000 20 4 30 10 -1
+`
+	fs.Contents["/proc/net/tcp6"] = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000001000000:0277 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4 1 0000000000000000 100 0 0 10 0
+`
+	fs.Contents["/proc/net/udp"] = `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  1: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 5 2 0000000000000000 0
+`
+	fs.Contents["/proc/net/unix"] = `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 6 /run/systemd/notify
+0000000000000000: 00000003 00000000 00000000 0001 03 7
+0000000000000000: 00000002 00000000 00010000 0001 01 8 @/org/bus
+`
+
+	want := []ListenAddress{
+		{Protocol: "tcp", Host: "127.0.0.1", Port: 8080},
+		{Protocol: "tcp", Port: 22},
+		{Protocol: "tcp", Host: "::1", Port: 631},
+		{Protocol: "udp", Host: "127.0.0.53", Port: 53},
+		{Protocol: "unix", Path: "/run/systemd/notify"},
+		{Protocol: "unix", Path: "@/org/bus"},
+	}
+	if got := ListeningAddresses(fs); !reflect.DeepEqual(got, want) {
+		t.Errorf("ListeningAddresses() = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidateSocket_PortInUse(t *testing.T) {
+	units := parseSockets(t, map[string]string{
+		"web.socket": "[Socket]\nListenStream=8080\nListenStream=9090\n",
+	})
+	web := units["web.socket"]
+	fs := NewMockFileSystem()
+	fs.Contents["/proc/net/tcp"] = "  sl  local_address rem_address   st\n   0: 0100007F:1F90 00000000:0000 0A\n"
+
+	tests := []struct {
+		name    string
+		state   *types.RuntimeState
+		enabled bool
+		want    int
+	}{
+		{"enabled and not listening", &types.RuntimeState{ActiveState: "inactive"}, true, 1},
+		{"failed", &types.RuntimeState{ActiveState: "failed"}, false, 1},
+		{"active holds its own port", &types.RuntimeState{ActiveState: "active"}, true, 0},
+		{"disabled", &types.RuntimeState{ActiveState: "inactive"}, false, 0},
+		{"offline", nil, true, 0},
+	}
+	for _, tt := range tests {
+		web.Runtime, web.Enabled = tt.state, tt.enabled
+		var got []SocketProblem
+		for _, p := range ValidateSocket(web, units, fs).Problems {
+			if p.Kind == SocketPortInUse {
+				got = append(got, p)
+			}
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: port in use problems = %+v, want %d", tt.name, got, tt.want)
+			continue
+		}
+		if tt.want > 0 && (got[0].Line != 2 || !strings.Contains(got[0].Reason, "127.0.0.1:8080")) {
+			t.Errorf("%s: problem = %+v, want ListenStream=8080 at line 2 held on 127.0.0.1:8080", tt.name, got[0])
+		}
+	}
+}
+
+func TestValidateSocket_StalePath(t *testing.T) {
+	units := parseSockets(t, map[string]string{
+		"app.socket": "[Socket]\nListenStream=/run/app.sock\nListenStream=/run/held.sock\nListenStream=/run/file\nListenStream=/run/new.sock\nListenStream=@abstract\n",
+	})
+	app := units["app.socket"]
+	app.Runtime, app.Enabled = &types.RuntimeState{ActiveState: "inactive"}, true
+	fs := NewMockFileSystem()
+	fs.Contents["/proc/net/unix"] = "Num RefCount Protocol Flags Type St Inode Path\n0000000000000000: 00000002 00000000 00010000 0001 01 8 /run/held.sock\n"
+	for _, p := range []string{"/run/app.sock", "/run/held.sock", "/run/file"} {
+		fs.Files[p] = true
+		fs.Modes[p] = os.ModeSocket | 0666
+	}
+	fs.Modes["/run/file"] = 0644
+
+	var got []string
+	for _, p := range ValidateSocket(app, units, fs).Problems {
+		got = append(got, fmt.Sprintf("%s:%d", p.Kind, p.Line))
+	}
+	want := []string{SocketStalePath + ":2", SocketPathInUse + ":3", SocketStalePath + ":4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %v, want %v", got, want)
+	}
+
+	// A socket that is listening holds its own file
+	app.Runtime.ActiveState = "active"
+	if problems := ValidateSocket(app, units, fs).Problems; len(problems) != 0 {
+		t.Errorf("problems of an active socket = %+v, want none", problems)
+	}
+}
+
 func TestValidateTimer_NoTrigger(t *testing.T) {
 	units := loadTestUnits(t, "../../testdata/validation/timer_no_trigger")
 	unit := units["empty.timer"]
@@ -169,7 +525,10 @@ func TestValidateTimer_ValidCalendar(t *testing.T) {
 		{"weekly", false},
 		{"*-*-* 00:00:00", false},
 		{"Mon *-*-* 10:00", false},
+		{"*-02~03 08:00", false},
 		{"", true},
+		{"Mon..Fri 25:00", true},
+		{"2024-13-01", true},
 	}
 
 	for _, tt := range tests {
@@ -182,6 +541,238 @@ func TestValidateTimer_ValidCalendar(t *testing.T) {
 			}
 		})
 	}
+
+	// The failing component is named
+	result := validateCalendarExpression("Mon..Fri 25:00", 3)
+	if result == nil || result.Component != "hour" || result.Line != 3 || !strings.Contains(result.Reason, "out of range 0-23") {
+		t.Errorf("validateCalendarExpression(\"Mon..Fri 25:00\") = %+v, want the hour out of range on line 3", result)
+	}
+}
+
+func TestParseEnvironmentFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		want       []EnvVar
+		wantErrors []int // Lines of syntax errors
+	}{
+		{
+			name:    "plain and quoted values",
+			content: "A=1\nB = two\nC='single $HOME'\nD=\"double \\\"q\\\" \\n\"\n",
+			want:    []EnvVar{{"A", "1", 1}, {"B", "two", 2}, {"C", "single $HOME", 3}, {"D", `double "q" \n`, 4}},
+		},
+		{
+			name:    "comments and blank lines",
+			content: "# comment\n\n; also a comment \\\ncontinued comment\nA=1\n",
+			want:    []EnvVar{{"A", "1", 5}},
+		},
+		{
+			name:    "line continuations",
+			content: "A=one\\\ntwo\nB=\"x\\\ny\"\nC='a\nb'\n",
+			want:    []EnvVar{{"A", "onetwo", 1}, {"B", "xy", 3}, {"C", "a\nb", 5}},
+		},
+		{
+			name:    "trailing whitespace and empty values",
+			content: "A=1   \nB=\nC=\"\"\n",
+			want:    []EnvVar{{"A", "1", 1}, {"B", "", 2}, {"C", "", 3}},
+		},
+		{
+			name:       "unquoted spaces",
+			content:    "OPTS=--verbose --port 80\n",
+			want:       []EnvVar{{"OPTS", "--verbose --port 80", 1}},
+			wantErrors: []int{1},
+		},
+		{
+			name:       "ignored lines",
+			content:    "1A=1\nNO_EQUALS\nB-C=2\nD=4\n",
+			want:       []EnvVar{{"D", "4", 4}},
+			wantErrors: []int{1, 2, 3},
+		},
+		{
+			name:       "unterminated quote",
+			content:    "A=\"open\nB=2\n",
+			want:       []EnvVar{{"A", "open\nB=2\n", 1}},
+			wantErrors: []int{1},
+		},
+		{
+			name:    "no final newline",
+			content: "A=1",
+			want:    []EnvVar{{"A", "1", 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, syntaxErrors := ParseEnvironmentFile(tt.content)
+			if !reflect.DeepEqual(vars, tt.want) {
+				t.Errorf("vars = %q, want %q", vars, tt.want)
+			}
+			var lines []int
+			for _, e := range syntaxErrors {
+				lines = append(lines, e.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.wantErrors) {
+				t.Errorf("syntax errors = %+v, want lines %v", syntaxErrors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestParseEnvironment(t *testing.T) {
+	got := ParseEnvironment(`A=1 "B=two words" 'C=it''s' D=a\ b bad`, 7)
+	want := []EnvVar{{"A", "1", 7}, {"B", "two words", 7}, {"C", "its", 7}, {"D", "a b", 7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseEnvironment = %q, want %q", got, want)
+	}
+}
+
+func TestValidateEnvironmentFiles(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/validation/environment_files")
+	root, err := filepath.Abs("../../testdata/validation/environment_files/root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := NewRealFileSystem(root)
+
+	type problem struct {
+		kind, file string
+		line       int
+	}
+	tests := []struct {
+		unit string
+		want []problem
+	}{
+		{"app.service", []problem{{EnvFileOverride, "app.service", 5}, {EnvFileOverride, "app.service", 6}}},
+		{"empty.service", []problem{{EnvFileEmpty, "empty.service", 5}}},
+		{"broken.service", []problem{{EnvFileSyntax, "broken.env", 2}, {EnvFileSyntax, "broken.env", 3}, {EnvFileSyntax, "broken.env", 4}}},
+		{"optional.service", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			result := ValidateEnvironmentFiles(units[tt.unit], fs)
+
+			var got []problem
+			for _, p := range result.Problems {
+				got = append(got, problem{p.Kind, filepath.Base(p.File), p.Line})
+				if p.Reason == "" {
+					t.Errorf("%s has no reason", p.Kind)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Problems = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// The file wins over Environment= and the reason says so
+	result := ValidateEnvironmentFiles(units["app.service"], fs)
+	if reason := result.Problems[0].Reason; !strings.Contains(reason, `LOG_LEVEL="debug"`) || !strings.Contains(reason, `/etc/app/app.env line 2 sets it to "info"`) {
+		t.Errorf("reason = %q", reason)
+	}
+
+	// Permissions are not kept in git, so world-writable files are mocked
+	mock := NewMockFileSystem()
+	mock.Contents["/etc/app/app.env"] = "LOG_LEVEL=info\n"
+	mock.Modes["/etc/app/app.env"] = 0666
+	result = ValidateEnvironmentFiles(units["app.service"], mock)
+	if len(result.Problems) == 0 || result.Problems[0].Kind != EnvFileWorldWritable || result.Problems[0].Line != 7 {
+		t.Errorf("Problems = %+v, want world-writable /etc/app/app.env on line 7 first", result.Problems)
+	}
+
+	// Without a filesystem nothing is read
+	if result := ValidateEnvironmentFiles(units["app.service"], nil); len(result.Problems) != 0 {
+		t.Errorf("Problems without a filesystem = %+v", result.Problems)
+	}
+}
+
+func TestParseExecCommands(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []ExecCommand
+	}{
+		{
+			value: `/usr/bin/app --name "two words" 'it''s'`,
+			want:  []ExecCommand{{Path: "/usr/bin/app", Args: []ExecWord{{"--name", false}, {"two words", true}, {"its", true}}}},
+		},
+		{
+			value: `-/usr/bin/find /tmp -exec rm {} \; ; +/usr/bin/true`,
+			want: []ExecCommand{
+				{Prefixes: "-", Path: "/usr/bin/find", Args: []ExecWord{{"/tmp", false}, {"-exec", false}, {"rm", false}, {"{}", false}, {";", true}}},
+				{Prefixes: "+", Path: "/usr/bin/true"},
+			},
+		},
+		{
+			value: `@/usr/sbin/daemon daemon-name --foreground`,
+			want:  []ExecCommand{{Prefixes: "@", Path: "/usr/sbin/daemon", Args: []ExecWord{{"--foreground", false}}}},
+		},
+		{value: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := ParseExecCommands(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseExecCommands = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateExecCommands(t *testing.T) {
+	units := loadTestUnits(t, "../../testdata/validation/exec_commands")
+
+	type problem struct {
+		kind, directive string
+		line            int
+	}
+	tests := []struct {
+		unit string
+		want []problem
+	}{
+		{"pipe.service", []problem{{ExecShellSyntax, "ExecStart", 5}}},
+		{"sudo.service", []problem{{ExecPrivilegeWrapper, "ExecStart", 5}, {ExecPrivilegeWrapper, "ExecStop", 6}}},
+		{"relative.service", []problem{{ExecRelativeConfig, "ExecStart", 5}}},
+		{"workdir.service", nil},
+		{"multi.service", []problem{{ExecMultipleStart, "ExecStart", 7}}},
+		{"oneshot.service", nil},
+		{"reset.service", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			var got []problem
+			for _, p := range ValidateExecCommands(units[tt.unit]).Problems {
+				got = append(got, problem{p.Kind, p.Directive, p.Line})
+				if p.Reason == "" {
+					t.Errorf("%s has no reason", p.Kind)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Problems = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	unit := &types.UnitFile{
+		Name: "inline.service",
+		Type: "service",
+		Sections: map[string]*types.Section{
+			"Service": {Name: "Service", Directives: map[string][]types.Directive{
+				"ExecStart": {{Key: "ExecStart", Value: "/usr/bin/app ; /usr/bin/other", Line: 3}},
+				"ExecStop":  {{Key: "ExecStop", Value: "/usr/bin/app stop 2>&1 >>/var/log/app.log", Line: 4}},
+			}},
+		},
+	}
+	problems := ValidateExecCommands(unit).Problems
+	if len(problems) != 2 {
+		t.Fatalf("Problems = %+v, want a shell syntax and a multiple ExecStart problem", problems)
+	}
+	if !strings.Contains(problems[0].Reason, `"2>&1"`) {
+		t.Errorf("shell syntax reason %q should name the first operator", problems[0].Reason)
+	}
+	if !strings.Contains(problems[1].Reason, "separates 2 commands with ';'") || problems[1].Line != 3 {
+		t.Errorf("multiple ExecStart = %+v", problems[1])
+	}
 }
 
 func TestValidateMount_NameMismatch(t *testing.T) {
@@ -226,6 +817,27 @@ func TestPathToMountUnitName(t *testing.T) {
 		{"/home", "home.mount"},
 		{"/home/user", "home-user.mount"},
 		{"/mnt/data", "mnt-data.mount"},
+		{"/opt/my-app", `opt-my\x2dapp.mount`},
+		{"/srv/data/", "srv-data.mount"},
+	}
+
+	// Names systemd-escape gave, for dashed, dotted and non-ASCII paths
+	data, err := os.ReadFile("../../testdata/validation/mount_names.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		path, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			t.Fatalf("bad fixture line %q", line)
+		}
+		tests = append(tests, struct {
+			path     string
+			expected string
+		}{path, name})
 	}
 
 	for _, tt := range tests {
@@ -238,6 +850,17 @@ func TestPathToMountUnitName(t *testing.T) {
 	}
 }
 
+func TestValidateMount_DashedPath(t *testing.T) {
+	unit, err := unitfile.ParseContent(`/etc/systemd/system/opt-my\x2dapp.mount`, "[Mount]\nWhat=/dev/sdb1\nWhere=/opt/my-app\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := ValidateMount(unit, nil)
+	if result.NameMismatch {
+		t.Errorf("NameMismatch for %s on /opt/my-app: %v", unit.Name, result.Issues)
+	}
+}
+
 func TestMockFileSystem(t *testing.T) {
 	fs := NewMockFileSystem()
 
@@ -269,3 +892,190 @@ func TestMockFileSystem(t *testing.T) {
 		t.Error("expected GroupExists to return true")
 	}
 }
+
+// makeImage writes files below a new root; a value starting with "->" makes
+// a symlink to the rest
+func makeImage(t testing.TB, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		full := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			err = os.Symlink(target, full)
+		} else {
+			err = os.WriteFile(full, []byte(content), 0755)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestRealFileSystem_Root(t *testing.T) {
+	root := makeImage(t, map[string]string{
+		"/usr/bin/app":         "#!/bin/sh\n",
+		"/bin":                 "->usr/bin",
+		"/usr/local/bin/tool":  "->/usr/bin/app",
+		"/usr/local/bin/up":    "->../../../../../usr/bin/app",
+		"/usr/local/bin/loop":  "->loop",
+		"/usr/local/bin/stale": "->/usr/bin/gone",
+	})
+	fs := NewRealFileSystem(root)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/usr/bin/app", true},
+		{"/bin/app", true},
+		{"/usr/local/bin/tool", true},
+		{"/usr/local/bin/up", true},
+		{"/usr/bin/../../../usr/bin/app", true},
+		{"/usr/local/bin/loop", false},
+		{"/usr/local/bin/stale", false},
+		{"/usr/bin/gone", false},
+	}
+	for _, tt := range tests {
+		if got := fs.IsExecutable(tt.path); got != tt.want {
+			t.Errorf("IsExecutable(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if !fs.IsDirectory("/bin") {
+		t.Error("IsDirectory(/bin) should follow the symlink to /usr/bin")
+	}
+	owner, ok := fs.Owner("/usr/local/bin/tool")
+	if !ok || owner.UID != uint32(os.Getuid()) {
+		t.Errorf("Owner(/usr/local/bin/tool) = %+v, %v, want UID %d", owner, ok, os.Getuid())
+	}
+}
+
+func TestRealFileSystem_Accounts(t *testing.T) {
+	fs := NewRealFileSystem(makeImage(t, map[string]string{"/usr/bin/app": ""}))
+	if !fs.UserExists("anyone") || !fs.GroupIDExists(4242) {
+		t.Error("an image without /etc/passwd should be assumed to have every user and group")
+	}
+
+	fs = NewRealFileSystem(makeImage(t, map[string]string{
+		"/etc/passwd":                 "root:x:0:0::/root:/bin/sh\n# comment\napp:x:998:997::/var/lib/app:/usr/sbin/nologin\n",
+		"/etc/group":                  "root:x:0:\nweb:x:33:app\n",
+		"/usr/lib/sysusers.d/db.conf": "# database\nu db 123 \"Database\"\ng backup -\nm app audio\nu! worker 140:web\n",
+	}))
+	users := map[string]bool{"root": true, "app": true, "db": true, "worker": true, "nobody": false}
+	for name, want := range users {
+		if got := fs.UserExists(name); got != want {
+			t.Errorf("UserExists(%q) = %v, want %v", name, got, want)
+		}
+	}
+	groups := map[string]bool{"root": true, "web": true, "db": true, "backup": true, "audio": true, "worker": false, "app": false}
+	for name, want := range groups {
+		if got := fs.GroupExists(name); got != want {
+			t.Errorf("GroupExists(%q) = %v, want %v", name, got, want)
+		}
+	}
+	ids := map[string]FileOwner{"app": {998, 997}, "db": {123, 123}, "worker": {}}
+	for name, want := range ids {
+		got, ok := fs.LookupUser(name)
+		if got != want || ok != (want != FileOwner{}) {
+			t.Errorf("LookupUser(%q) = %+v, %v, want %+v", name, got, ok, want)
+		}
+	}
+	uids := map[uint32]bool{0: true, 998: true, 123: true, 140: true, 33: false}
+	for uid, want := range uids {
+		if got := fs.UserIDExists(uid); got != want {
+			t.Errorf("UserIDExists(%d) = %v, want %v", uid, got, want)
+		}
+	}
+	gids := map[uint32]bool{0: true, 33: true, 997: true, 123: true, 140: false}
+	for gid, want := range gids {
+		if got := fs.GroupIDExists(gid); got != want {
+			t.Errorf("GroupIDExists(%d) = %v, want %v", gid, got, want)
+		}
+	}
+}
+
+func TestRealFileSystem_Cache(t *testing.T) {
+	root := makeImage(t, map[string]string{"/usr/bin/app": ""})
+	cached := NewRealFileSystem(root)
+	uncached := &RealFileSystem{Root: root}
+	if !cached.Exists("/usr/bin/app") || !uncached.Exists("/usr/bin/app") {
+		t.Fatal("expected /usr/bin/app to exist")
+	}
+
+	if err := os.Remove(filepath.Join(root, "usr/bin/app")); err != nil {
+		t.Fatal(err)
+	}
+	if !cached.Exists("/usr/bin/app") {
+		t.Error("the cached lookup should be reused")
+	}
+	if uncached.Exists("/usr/bin/app") {
+		t.Error("the zero value should not cache lookups")
+	}
+}
+
+// benchmarkDirectives validates a few hundred services running the same
+// handful of programs, as a scan does
+func benchmarkDirectives(b *testing.B, newFS func(root string) FileSystem) {
+	files := make(map[string]string)
+	for i := 0; i < 5; i++ {
+		files[fmt.Sprintf("/usr/bin/app%d", i)] = ""
+	}
+	root := makeImage(b, files)
+
+	var units []*types.UnitFile
+	for i := 0; i < 300; i++ {
+		content := fmt.Sprintf("[Service]\nExecStartPre=/usr/bin/app%d --check\nExecStart=/usr/bin/app%d\nExecReload=/bin/kill -HUP $MAINPID\nWorkingDirectory=/srv\n", i%5, (i+1)%5)
+		unit, err := unitfile.ParseContent(fmt.Sprintf("/etc/systemd/system/unit%d.service", i), content)
+		if err != nil {
+			b.Fatal(err)
+		}
+		units = append(units, unit)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fs := newFS(root)
+		for _, unit := range units {
+			ValidateDirectives(unit, fs)
+		}
+	}
+}
+
+func BenchmarkDirectivesCached(b *testing.B) {
+	benchmarkDirectives(b, func(root string) FileSystem { return NewRealFileSystem(root) })
+}
+
+func BenchmarkDirectivesUncached(b *testing.B) {
+	benchmarkDirectives(b, func(root string) FileSystem { return &RealFileSystem{Root: root} })
+}
+
+func TestExpandSpecifiers(t *testing.T) {
+	tests := []struct {
+		unit, value string
+		want        string
+		wantOK      bool
+	}{
+		{"app.service", "/usr/bin/app", "/usr/bin/app", true},
+		{"app.service", "/opt/%N/bin/%p", "/opt/app/bin/app", true},
+		{"app.service", "%S/%n 100%%", "/var/lib/app.service 100%", true},
+		{"worker@queue.service", "/srv/%p/%i", "/srv/worker/queue", true},
+		{"worker@.service", "/srv/%p/%i", "", false},
+		{"backup@srv-data.service", "%I %f", "srv/data /srv/data", true},
+		{"web-blue-green.service", "%j %J", "green green", true},
+		{"backup@x\\x2dy.service", "%I", "x-y", true},
+		{"app.service", "/run/%H", "", false},
+		{"app.service", "trailing %", "", false},
+	}
+	for _, tt := range tests {
+		unit := &types.UnitFile{Name: tt.unit, Type: "service"}
+		got, ok := ExpandSpecifiers(unit, tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ExpandSpecifiers(%s, %q) = %q, %v, want %q, %v", tt.unit, tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
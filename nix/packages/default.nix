@@ -53,7 +53,7 @@
           version = "0.1.0";
           src = ../..;
 
-          vendorHash = "sha256-PFRrh1XjBfbSPRCanPP4FkE8nuEbFbzjYp8IX2ckNUA=";
+          vendorHash = "sha256-Tk84BdGVrAH0gMfXxnrOSlJypSjzT7vddFyc/olhdkc=";
 
           ldflags = [
             "-s"
@@ -0,0 +1,192 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Stages reported to Options.Progress, in the order they run.
+const (
+	StageLoad  = analyzer.StageLoad  // Reading the unit directories and paths
+	StageParse = analyzer.StageParse // Parsing each unit file
+	StageCheck = analyzer.StageCheck // Running the rules on each unit
+)
+
+// Options configures which rules run and what they may inspect.
+type Options struct {
+	// Category, MinSeverity and Tags select the rules to run. Nil or empty
+	// values select every rule.
+	Category    *types.Category
+	MinSeverity *types.Severity
+	Tags        []string
+	// SystemdVersion is the major systemd version of the target. Rules for
+	// directives it does not support are skipped; 0 runs every rule.
+	SystemdVersion int
+	// Root is the root directory of an offline system image, empty for the
+	// live system. It locates system.conf and the files rules read, such as
+	// environment files.
+	Root string
+	// IncludeRuntime also loads the units under /run/systemd that generators
+	// write at boot, such as the mounts of /etc/fstab, and transient units.
+	// Scan uses them for the dependency graph and the rules that look across
+	// units, but does not check or score them.
+	IncludeRuntime bool
+	// Quick runs only rules that inspect a unit's own directives
+	Quick bool
+	// NoGraph skips the rules that analyze the dependency graph of all units
+	NoGraph bool
+	// Journal reads this boot's restart history from the journal. Only Scan
+	// of the live system uses it.
+	Journal bool
+	// Progress, if set, is called as units are loaded, parsed and checked,
+	// with the items done so far and the total for the stage
+	Progress func(stage string, done, total int)
+	// CacheDir, if set, keeps the issues of rules that only read a unit's own
+	// directives between runs, so that only changed unit files are checked
+	// again by them. Rules that look beyond the unit always run.
+	CacheDir string
+	// Version is the version of the program embedding sdaudit. It is part of
+	// the key of CacheDir, so that issues cached by another version, whose
+	// rules may differ, are not reused.
+	Version string
+	// Profile names a bundled profile, such as "container" or "paranoid", that
+	// selects the rules to run and raises some of their severities. Empty
+	// runs every rule.
+	Profile string
+	// ScoreWeights are the points an issue of each severity takes off its
+	// unit's score; nil uses types.DefaultScoreWeights
+	ScoreWeights types.ScoreWeights
+	// SynchronizationUnits are units, such as a site's own targets, that
+	// REL005 and the ordering issues of the dependency graph do not expect a
+	// requirement on with After=, besides the special units of systemd
+	SynchronizationUnits []string
+	// RuleParams sets rule parameters, such as PERF005's max_timeout, by
+	// rule ID and parameter name. Unknown rules and parameters, and values
+	// that do not fit a parameter, are errors.
+	RuleParams map[string]map[string]string
+	// Stdin is read by Check for the path "-", as the unit named StdinName,
+	// such as "app.service". The unit's File is "<stdin>", and rules do not
+	// look up its paths, users or groups.
+	Stdin     io.Reader
+	StdinName string
+}
+
+func (o Options) analyzer() (analyzer.Options, error) {
+	var config *rules.Config
+	if o.Profile != "" {
+		profile, ok := rules.LookupProfile(o.Profile)
+		if !ok {
+			return analyzer.Options{}, unknownProfile(o.Profile)
+		}
+		config = rules.DefaultConfig()
+		profile.Apply(config)
+	}
+	if len(o.SynchronizationUnits) > 0 {
+		if config == nil {
+			config = rules.DefaultConfig()
+		}
+		config.SynchronizationUnits = o.SynchronizationUnits
+	}
+	if len(o.RuleParams) > 0 {
+		if config == nil {
+			config = rules.DefaultConfig()
+		}
+		if err := config.SetParams(o.RuleParams); err != nil {
+			return analyzer.Options{}, err
+		}
+	}
+	return analyzer.Options{
+		Category:       o.Category,
+		MinSeverity:    o.MinSeverity,
+		Tags:           o.Tags,
+		Profile:        o.Profile,
+		ScoreWeights:   o.ScoreWeights,
+		SystemdVersion: o.SystemdVersion,
+		Root:           o.Root,
+		IncludeRuntime: o.IncludeRuntime,
+		Quick:          o.Quick,
+		Journal:        o.Journal,
+		NoGraph:        o.NoGraph,
+		Progress:       o.Progress,
+		CacheDir:       o.CacheDir,
+		Version:        o.Version,
+		Stdin:          o.Stdin,
+		StdinName:      o.StdinName,
+		Config:         config,
+	}, nil
+}
+
+// Scan audits every unit on systemd's unit search path, as 'sdaudit scan'
+// does. Without opts.Root it scans the running system and adds the live
+// state of its units, so rules about failed units and restart loops run.
+func Scan(ctx context.Context, opts Options) (*types.ScanResult, error) {
+	o, err := opts.analyzer()
+	if err != nil {
+		return nil, err
+	}
+	result, err := analyzer.New(o).Scan(ctx, o)
+	if err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+	return result, nil
+}
+
+// Check audits unit files and the units in directories, as 'sdaudit check'
+// does. Cross-unit rules and the dependency graph see only the units loaded
+// from paths.
+func Check(ctx context.Context, paths []string, opts Options) (*types.ScanResult, error) {
+	o, err := opts.analyzer()
+	if err != nil {
+		return nil, err
+	}
+	result, err := analyzer.New(o).CheckFiles(ctx, paths, o)
+	if err != nil {
+		return nil, fmt.Errorf("check failed: %w", err)
+	}
+	return result, nil
+}
+
+// RunRules runs the rules on units the caller has loaded or parsed. Issues
+// are sorted most severe first, then by unit and rule.
+func RunRules(ctx context.Context, units map[string]*types.UnitFile, opts Options) ([]types.Issue, types.Summary, error) {
+	o, err := opts.analyzer()
+	if err != nil {
+		return nil, types.Summary{}, err
+	}
+	result, err := analyzer.New(o).CheckUnits(ctx, units, o)
+	if err != nil {
+		return nil, types.Summary{}, err
+	}
+	return result.Issues, result.Summary, nil
+}
+
+// Recheck is the outcome of checking a unit file again after it was edited
+type Recheck struct {
+	Unit   *types.UnitFile
+	Issues []types.Issue
+	// Rules are the IDs of the rules that ran, sorted. Issues of other rules
+	// for the unit still stand as the scan found them.
+	Rules []string
+}
+
+// RecheckFile parses the unit file at path again and runs on it the rules
+// that read only the unit's own directives, as after the file was edited
+// following a scan of units. Rules that look beyond the unit are not run.
+// It returns ctx's error when ctx is already cancelled.
+func RecheckFile(ctx context.Context, path string, units map[string]*types.UnitFile, opts Options) (*Recheck, error) {
+	o, err := opts.analyzer()
+	if err != nil {
+		return nil, err
+	}
+	r, err := analyzer.New(o).RecheckFile(ctx, path, units, o)
+	if err != nil {
+		return nil, err
+	}
+	recheck := Recheck(*r)
+	return &recheck, nil
+}
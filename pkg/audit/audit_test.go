@@ -0,0 +1,590 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/progress"
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func TestRules(t *testing.T) {
+	all := Rules()
+	if len(all) == 0 {
+		t.Fatal("Rules() returned no rules")
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1].ID >= all[i].ID {
+			t.Errorf("Rules() not sorted: %s before %s", all[i-1].ID, all[i].ID)
+		}
+	}
+
+	rule, ok := LookupRule("SEC018")
+	if !ok || rule.Name == "" {
+		t.Errorf("LookupRule(SEC018) = %+v, %v", rule, ok)
+	}
+	if _, ok := LookupRule("XXX999"); ok {
+		t.Error("LookupRule(XXX999) found a rule")
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	high := types.SeverityHigh
+	security := types.CategorySecurity
+	selected, err := RulesFor(Options{Category: &security, MinSeverity: &high})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rule := range selected {
+		if rule.Category != security || rule.Severity < high {
+			t.Errorf("RulesFor returned %s (%s, %s)", rule.ID, rule.Category, rule.Severity)
+		}
+	}
+	all, err := RulesFor(Options{})
+	if err != nil || len(all) != len(Rules()) {
+		t.Errorf("RulesFor(Options{}) returned %d rules, %v, want %d", len(all), err, len(Rules()))
+	}
+}
+
+// TestRuleExamples checks each rule's example is reported by the rule and its
+// fixed version is not
+func TestRuleExamples(t *testing.T) {
+	check := func(id, content string) bool {
+		unit, err := ParseUnit("/etc/systemd/system/example.service", content)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		issues, _, err := RunRules(context.Background(), map[string]*types.UnitFile{unit.Name: unit}, Options{})
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		for _, issue := range issues {
+			if issue.RuleID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	examples := 0
+	for _, rule := range Rules() {
+		if rule.ExampleBad == "" {
+			continue
+		}
+		examples++
+		if rule.ExampleGood == "" {
+			t.Errorf("%s has a reported example but no fixed one", rule.ID)
+		}
+		if !check(rule.ID, rule.ExampleBad) {
+			t.Errorf("%s does not report its example:\n%s", rule.ID, rule.ExampleBad)
+		}
+		if check(rule.ID, rule.ExampleGood) {
+			t.Errorf("%s reports its fixed example:\n%s", rule.ID, rule.ExampleGood)
+		}
+	}
+	if examples == 0 {
+		t.Error("no rule has an example")
+	}
+}
+
+func TestEncodeRulesJSON(t *testing.T) {
+	rule, _ := LookupRule("SEC001")
+	var buf bytes.Buffer
+	if err := EncodeRulesJSON(&buf, []RuleInfo{rule}); err != nil {
+		t.Fatal(err)
+	}
+	var out []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"id", "name", "description", "category", "severity", "tags", "suggestion", "references", "min_systemd_version", "example_bad", "example_good", "profiles"} {
+		if _, ok := out[0][field]; !ok {
+			t.Errorf("rule JSON has no %q field: %s", field, buf.String())
+		}
+	}
+	if out[0]["severity"] != "high" || out[0]["category"] != "security" {
+		t.Errorf("severity and category should be names: %s", buf.String())
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	ids := make(map[string]map[string]bool)
+	for _, p := range Profiles() {
+		if len(p.Rules) == 0 {
+			t.Errorf("profile %s has no rules", p.Name)
+		}
+		ids[p.Name] = make(map[string]bool)
+		for _, id := range p.Rules {
+			ids[p.Name][id] = true
+		}
+	}
+	for _, name := range []string{"baseline", "server", "container", "paranoid", "ci"} {
+		if ids[name] == nil {
+			t.Fatalf("profile %s is missing", name)
+		}
+	}
+
+	// Containers get device, kernel and cgroup isolation from their runtime
+	for _, id := range []string{"SEC007", "SEC008", "SEC009", "SEC010"} {
+		if ids["container"][id] {
+			t.Errorf("container profile runs %s", id)
+		}
+	}
+	if !ids["container"]["SEC001"] {
+		t.Error("container profile should run SEC001")
+	}
+
+	// ci checks correctness only, none of the hardening or live-state rules
+	for _, rule := range Rules() {
+		if !ids["ci"][rule.ID] {
+			continue
+		}
+		if rule.Category == types.CategorySecurity || hasTag(rule, "hardening") || hasTag(rule, "runtime") {
+			t.Errorf("ci profile runs %s (%s, %v)", rule.ID, rule.Category, rule.Tags)
+		}
+	}
+
+	// baseline leaves out info rules; server and paranoid run everything
+	if ids["baseline"]["BP004"] || len(ids["server"]) != len(Rules()) || len(ids["paranoid"]) != len(Rules()) {
+		t.Error("baseline should leave out info rules and server and paranoid should run every rule")
+	}
+
+	paranoid, _ := LookupProfile("paranoid")
+	if paranoid.Severities["SEC001"] != types.SeverityCritical || paranoid.Severities["SEC002"] != types.SeverityHigh {
+		t.Errorf("paranoid should raise hardening rules: %v", paranoid.Severities)
+	}
+	if _, ok := paranoid.Severities["REL001"]; ok {
+		t.Error("paranoid should not raise REL001")
+	}
+}
+
+func TestProfileOption(t *testing.T) {
+	unit, err := ParseUnit("/etc/systemd/system/app.service", "[Service]\nExecStart=/usr/bin/app\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	units := map[string]*types.UnitFile{unit.Name: unit}
+
+	issues, _, err := RunRules(context.Background(), units, Options{Profile: "paranoid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, issue := range issues {
+		if issue.RuleID == "SEC002" {
+			found = true
+			if issue.Severity != types.SeverityHigh {
+				t.Errorf("SEC002 severity = %s under paranoid, want high", issue.Severity)
+			}
+		}
+	}
+	if !found {
+		t.Error("paranoid profile did not report SEC002")
+	}
+
+	issues, _, err = RunRules(context.Background(), units, Options{Profile: "container"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range issues {
+		if issue.RuleID == "SEC007" {
+			t.Error("container profile reported SEC007")
+		}
+	}
+
+	if _, _, err := RunRules(context.Background(), units, Options{Profile: "nope"}); err == nil {
+		t.Error("unknown profile should fail")
+	}
+}
+
+func hasTag(rule RuleInfo, tag string) bool {
+	for _, t := range rule.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCheckCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Check(ctx, []string{"../../testdata/validation/pid_file"}, Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Check() error = %v, want context.Canceled", err)
+	}
+}
+
+// TestProgressOutput checks that progress goes only to the bar's writer, so
+// JSON written to stdout stays parseable
+func TestProgressOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	bar := progress.New(&stderr, style.New(false, false))
+
+	var stages []string
+	opts := Options{Progress: func(stage string, done, total int) {
+		if len(stages) == 0 || stages[len(stages)-1] != stage {
+			stages = append(stages, stage)
+		}
+		bar.Update(stage, done, total)
+	}}
+	result, err := Check(context.Background(), []string{"../../testdata/validation/pid_file"}, opts)
+	bar.Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewJSONEncoder(&stdout).Encode(result); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{StageLoad, StageParse, StageCheck}; len(stages) != len(want) || stages[0] != want[0] || stages[1] != want[1] || stages[2] != want[2] {
+		t.Errorf("stages = %v, want %v", stages, want)
+	}
+	if !json.Valid(stdout.Bytes()) {
+		t.Errorf("JSON output is not valid:\n%s", stdout.String())
+	}
+	if stderr.Len() == 0 {
+		t.Error("progress bar wrote nothing")
+	}
+}
+
+func TestNewEncoder(t *testing.T) {
+	units, err := LoadUnits(context.Background(), "../../testdata/validation/pid_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Check(context.Background(), []string{"../../testdata/validation/pid_file"}, Options{Tags: []string{"pidfile"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.TotalUnits != len(units) {
+		t.Errorf("TotalUnits = %d, want %d", result.Summary.TotalUnits, len(units))
+	}
+
+	for _, format := range []string{FormatText, FormatJSON, FormatSARIF, FormatMarkdown, FormatGitHub, FormatCodeClimate} {
+		var buf bytes.Buffer
+		encoder, err := NewEncoder(&buf, format, TextOptions{})
+		if err != nil {
+			t.Fatalf("NewEncoder(%s) error = %v", format, err)
+		}
+		if err := encoder.Encode(result); err != nil {
+			t.Fatalf("Encode(%s) error = %v", format, err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("REL033")) {
+			t.Errorf("%s output does not mention REL033:\n%s", format, buf.String())
+		}
+	}
+
+	if _, err := NewEncoder(&bytes.Buffer{}, "yaml", TextOptions{}); err == nil {
+		t.Error("NewEncoder(yaml) succeeded")
+	}
+}
+
+func TestCodeClimateSeverityFilter(t *testing.T) {
+	high := types.SeverityHigh
+	result, err := Check(context.Background(), []string{"../../testdata/units/test.service"}, Options{MinSeverity: &high})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Issues) == 0 {
+		t.Fatal("no issues at or above high severity in test.service")
+	}
+
+	var buf bytes.Buffer
+	if err := NewCodeClimateEncoder(&buf, "../../").Encode(result); err != nil {
+		t.Fatal(err)
+	}
+	var entries []struct {
+		CheckName   string `json:"check_name"`
+		Fingerprint string `json:"fingerprint"`
+		Severity    string `json:"severity"`
+		Location    struct {
+			Path  string `json:"path"`
+			Lines struct {
+				Begin int `json:"begin"`
+			} `json:"lines"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid codeclimate output: %v\n%s", err, buf.String())
+	}
+	if len(entries) != len(result.Issues) {
+		t.Fatalf("%d entries, want one per issue (%d)", len(entries), len(result.Issues))
+	}
+	for i, e := range entries {
+		if e.Severity != "blocker" && e.Severity != "critical" {
+			t.Errorf("%s has severity %q below the --severity high filter", e.CheckName, e.Severity)
+		}
+		if e.Fingerprint != result.Issues[i].Fingerprint() {
+			t.Errorf("%s fingerprint = %q, want %q", e.CheckName, e.Fingerprint, result.Issues[i].Fingerprint())
+		}
+		if e.Location.Path != "testdata/units/test.service" || e.Location.Lines.Begin < 1 {
+			t.Errorf("%s location = %+v", e.CheckName, e.Location)
+		}
+	}
+}
+
+func TestGraphEncode(t *testing.T) {
+	units, err := LoadUnits(context.Background(), "../../testdata/graph/linear_chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := BuildGraph(units)
+	if !g.HasUnit("s2.service") {
+		t.Fatal("graph is missing s2.service")
+	}
+
+	for _, format := range []string{GraphDOT, GraphJSON, GraphGraphML, GraphMermaid} {
+		var buf bytes.Buffer
+		if err := g.Encode(&buf, format, ExportOptions{Edges: []string{"Requires"}}); err != nil {
+			t.Errorf("Encode(%s) error = %v", format, err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("s1.service")) {
+			t.Errorf("%s output does not mention s1.service", format)
+		}
+	}
+
+	if err := g.Encode(&bytes.Buffer{}, "png", ExportOptions{}); err == nil {
+		t.Error("Encode(png) succeeded")
+	}
+	if err := g.Encode(&bytes.Buffer{}, GraphDOT, ExportOptions{Edges: []string{"Likes"}}); err == nil {
+		t.Error("Encode with an unknown edge type succeeded")
+	}
+}
+
+func TestDeterministicJSON(t *testing.T) {
+	paths := []string{
+		"../../testdata/graph/cycle_path",
+		"../../testdata/graph/reachability",
+		"../../testdata/graph/linear_chain",
+		"../../testdata/graph/after_without_requires",
+		"../../testdata/units",
+		"../../testdata/validation/pid_file",
+	}
+	report := func() []byte {
+		result, err := Check(context.Background(), paths, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := NewJSONEncoder(&buf).Encode(result); err != nil {
+			t.Fatal(err)
+		}
+		// Everything but the time of the report comes from the units
+		var lines [][]byte
+		for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+			if !bytes.Contains(line, []byte(`"timestamp":`)) {
+				lines = append(lines, line)
+			}
+		}
+		return bytes.Join(lines, []byte("\n"))
+	}
+
+	first := report()
+	for i := 0; i < 10; i++ {
+		if next := report(); !bytes.Equal(first, next) {
+			t.Fatalf("run %d wrote different JSON:\n%s\n\nfirst run:\n%s", i+2, next, first)
+		}
+	}
+}
+
+func TestCheckSynchronizationUnits(t *testing.T) {
+	paths := []string{"../../testdata/graph/after_without_requires"}
+	count := func(opts Options) int {
+		result, err := Check(context.Background(), paths, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n int
+		for _, issue := range result.Issues {
+			if issue.RuleID == "REL005" && issue.Value == "database.service" {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(Options{}); n != 1 {
+		t.Fatalf("got %d REL005 issues on database.service, want 1", n)
+	}
+	if n := count(Options{SynchronizationUnits: []string{"database.service"}}); n != 0 {
+		t.Errorf("got %d REL005 issues with database.service configured, want 0", n)
+	}
+}
+
+func TestRuleParams(t *testing.T) {
+	unit, err := ParseUnit("/etc/systemd/system/app.service", "[Service]\nExecStart=/usr/bin/app\nTimeoutStartSec=8min\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := func(opts Options) int {
+		issues, _, err := RunRules(context.Background(), map[string]*types.UnitFile{unit.Name: unit}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n int
+		for _, issue := range issues {
+			if issue.RuleID == "PERF005" {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(Options{}); n != 1 {
+		t.Fatalf("got %d PERF005 issues with the default max_timeout, want 1", n)
+	}
+	params := map[string]map[string]string{"PERF005": {"max_timeout": "10min"}}
+	if n := count(Options{RuleParams: params}); n != 0 {
+		t.Errorf("got %d PERF005 issues with max_timeout=10min, want 0", n)
+	}
+
+	infos, err := RulesFor(Options{RuleParams: params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if info.ID == "PERF005" && (len(info.Params) != 1 || info.Params[0].Value != "10min" || info.Params[0].Default != "5min") {
+			t.Errorf("PERF005 params = %+v, want max_timeout 10min, default 5min", info.Params)
+		}
+	}
+
+	if _, _, err := RunRules(context.Background(), nil, Options{RuleParams: map[string]map[string]string{"PERF005": {"max_timeout": "soon"}}}); err == nil {
+		t.Error("RunRules accepted max_timeout=soon")
+	}
+}
+
+func TestCheckDeadlocks(t *testing.T) {
+	units, err := LoadUnits(context.Background(), "../../testdata/propagation/deadlock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := CheckDeadlocks(context.Background(), units, types.SeverityInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Summary.TotalUnits != len(units) || result.Summary.RulesChecked == 0 {
+		t.Errorf("summary = %+v, want %d units and the deadlock rules checked", result.Summary, len(units))
+	}
+	if len(result.Issues) != 1 || result.Issues[0].RuleID != "PROP004" {
+		t.Fatalf("issues = %+v, want one PROP004", result.Issues)
+	}
+}
+
+func TestAnalyzeImpact(t *testing.T) {
+	units, err := LoadUnits(context.Background(), "../../testdata/graph/linear_chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := BuildGraph(units)
+	impact, err := AnalyzeImpact(g, "s1.service", ScenarioFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if impact.TotalAffected != 2 {
+		t.Errorf("TotalAffected = %d, want 2", impact.TotalAffected)
+	}
+	if _, err := AnalyzeImpact(g, "missing.service", ScenarioFail); err == nil {
+		t.Error("AnalyzeImpact of a unit not in the graph succeeded")
+	}
+}
+
+func TestAnalyzeDependencies(t *testing.T) {
+	units, err := LoadUnits(context.Background(), "../../testdata/graph/cycle_simple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := AnalyzeDependencies(BuildGraph(units), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cycle []CycleEdge
+	for _, issue := range report.Issues {
+		if issue.Kind == "cycle" {
+			cycle = issue.Cycle
+		}
+	}
+	if len(cycle) != 3 {
+		t.Fatalf("cycle = %+v, want 3 edges", cycle)
+	}
+	if got := cycle[0].Directive(); got != "Requires" {
+		t.Errorf("Directive() = %q, want Requires", got)
+	}
+
+	reverse, err := FindReverseDependencies(BuildGraph(units), "a.service", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverse.Direct == 0 {
+		t.Error("found no units depending on a.service")
+	}
+}
+
+func TestTiming(t *testing.T) {
+	units, err := LoadUnits(context.Background(), "../../testdata/graph/linear_chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	timing, err := NewTiming(units, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := timing.CriticalPaths(nil)
+	path, ok := paths.Paths["s3.service"]
+	if !ok || len(path.Path) != 3 {
+		t.Fatalf("critical path of s3.service = %+v, want 3 units", path)
+	}
+	observed := timing.CriticalPaths(map[string]time.Duration{"s1.service": time.Second})
+	if got := observed.Paths["s3.service"].Observed; got != time.Second {
+		t.Errorf("observed time of s3.service = %s, want 1s", got)
+	}
+	if timing.Unit("s2.service") == nil || timing.Unit("missing.service") != nil {
+		t.Error("Unit() does not find exactly the loaded units")
+	}
+	if got := FormatDuration(90 * time.Second); got != "1m30s" {
+		t.Errorf("FormatDuration(90s) = %q, want 1m30s", got)
+	}
+}
+
+func TestDiffOverride(t *testing.T) {
+	root := t.TempDir()
+	for dir, content := range map[string]string{
+		"etc/systemd/system":     "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\nRestart=always\n",
+		"run/systemd/system":     "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\nRestart=always\n",
+		"usr/lib/systemd/system": "[Unit]\nDescription=App\n\n[Service]\nExecStart=/usr/bin/app\n\n[Install]\nWantedBy=multi-user.target\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "app.service"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diff, err := DiffOverride(context.Background(), root, "app.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The copy in /run is not the vendor file, so the diff is against /usr/lib
+	if want := filepath.Join(root, "usr/lib/systemd/system/app.service"); diff.Vendor != want {
+		t.Errorf("Vendor = %q, want %q", diff.Vendor, want)
+	}
+	if want := filepath.Join(root, "run/systemd/system/app.service"); diff.Runtime != want {
+		t.Errorf("Runtime = %q, want %q", diff.Runtime, want)
+	}
+	var got []string
+	for _, c := range diff.Changes {
+		got = append(got, c.Kind+" "+c.Section+"."+c.Key)
+	}
+	want := []string{"added Service.Restart", "removed Install.WantedBy"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Changes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,310 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// DefaultBootHistoryDir is where 'sdaudit boot --save' keeps boot snapshots.
+const DefaultBootHistoryDir = analyzer.DefaultBootHistoryDir
+
+// BootJSONVersion is the version of the JSON boot report, raised when fields
+// change meaning or are removed.
+const BootJSONVersion = analyzer.BootJSONVersion
+
+// BootOptions configures where AnalyzeBoot takes unit start times from, and
+// when it reports units and the boot as slow.
+type BootOptions struct {
+	// Journal measures start times over the last Boots boots from the journal
+	Journal bool
+	Boots   int
+	// Fallback uses systemd-analyze blame when the journal has no start times
+	Fallback bool
+	// Timestamps takes start times from the activation timestamps of the
+	// units in this boot instead of the journal or blame
+	Timestamps bool
+	// SlowUnit is the start time above which a unit is reported, 5s if zero
+	SlowUnit time.Duration
+	// SlowUserspace is the userspace boot time above which the boot is
+	// reported, 30s if zero
+	SlowUserspace time.Duration
+}
+
+// BootAnalysis is the boot time of the running system, the start times of
+// its units and its critical chain.
+type BootAnalysis struct {
+	TotalTime     time.Duration
+	KernelTime    time.Duration
+	InitrdTime    time.Duration
+	UserspaceTime time.Duration
+	// ReachedTarget is the default target and TargetReachedTime when
+	// userspace reached it
+	ReachedTarget     string
+	TargetReachedTime time.Duration
+	// TimingSource is where unit start times came from: "journal", "blame"
+	// or "timestamps"
+	TimingSource string
+	// TimingBoots is how many boots the journal timings cover
+	TimingBoots int
+	// Parallelism is how many units were activating at a time on average,
+	// with timestamp timings
+	Parallelism   float64
+	Units         []UnitTiming
+	CriticalChain []ChainLink
+	// PredictedPath is the critical path the unit files predict, set by
+	// Timing.PredictBootPath
+	PredictedPath *BootPath
+	// Report joins the timings, the critical chain and the findings of a
+	// scan per unit, set by Correlate
+	Report *BootReport
+	Issues []BootIssue
+}
+
+// ObservedTimes returns the start time of each unit: the journal median,
+// the blame time or the activation interval of this boot.
+func (a *BootAnalysis) ObservedTimes() map[string]time.Duration {
+	return a.internal().ObservedTimes()
+}
+
+// Correlate sets Report to the units that start slowly, are critical in the
+// critical chain, or are in it with findings about their start, joined with
+// those of issues. Critical units come first, then the slowest.
+func (a *BootAnalysis) Correlate(issues []types.Issue, opts BootOptions) {
+	in := a.internal()
+	in.Correlate(issues, analyzer.BootOptions(opts))
+	a.Report = bootReportFrom(in.Report)
+}
+
+// Snapshot captures the analysis as a snapshot of the boot bootID, taken at
+// at. Units record their start time in this boot, not a median over earlier
+// ones.
+func (a *BootAnalysis) Snapshot(bootID string, at time.Time) BootSnapshot {
+	return bootSnapshotFrom(a.internal().Snapshot(bootID, at))
+}
+
+func bootAnalysisFrom(a *analyzer.BootAnalysis) *BootAnalysis {
+	return &BootAnalysis{
+		TotalTime:         a.TotalTime,
+		KernelTime:        a.KernelTime,
+		InitrdTime:        a.InitrdTime,
+		UserspaceTime:     a.UserspaceTime,
+		ReachedTarget:     a.ReachedTarget,
+		TargetReachedTime: a.TargetReachedTime,
+		TimingSource:      a.TimingSource,
+		TimingBoots:       a.TimingBoots,
+		Parallelism:       a.Parallelism,
+		Units:             convertSlice(a.Units, func(u analyzer.UnitTiming) UnitTiming { return UnitTiming(u) }),
+		CriticalChain:     convertSlice(a.CriticalChain, func(l analyzer.ChainLink) ChainLink { return ChainLink(l) }),
+		PredictedPath:     bootPathFrom(a.PredictedPath),
+		Report:            bootReportFrom(a.Report),
+		Issues:            convertSlice(a.Issues, func(i analyzer.BootIssue) BootIssue { return BootIssue(i) }),
+	}
+}
+
+func (a *BootAnalysis) internal() *analyzer.BootAnalysis {
+	in := &analyzer.BootAnalysis{
+		TotalTime:         a.TotalTime,
+		KernelTime:        a.KernelTime,
+		InitrdTime:        a.InitrdTime,
+		UserspaceTime:     a.UserspaceTime,
+		ReachedTarget:     a.ReachedTarget,
+		TargetReachedTime: a.TargetReachedTime,
+		TimingSource:      a.TimingSource,
+		TimingBoots:       a.TimingBoots,
+		Parallelism:       a.Parallelism,
+		Units:             convertSlice(a.Units, func(u UnitTiming) analyzer.UnitTiming { return analyzer.UnitTiming(u) }),
+		CriticalChain:     convertSlice(a.CriticalChain, func(l ChainLink) analyzer.ChainLink { return analyzer.ChainLink(l) }),
+		Issues:            convertSlice(a.Issues, func(i BootIssue) analyzer.BootIssue { return analyzer.BootIssue(i) }),
+	}
+	if p := a.PredictedPath; p != nil {
+		in.PredictedPath = &analyzer.BootPath{Target: p.Target, Path: p.Path.internal(), InChain: p.InChain, Unobserved: p.Unobserved}
+	}
+	if r := a.Report; r != nil {
+		in.Report = &analyzer.BootReport{Units: convertSlice(r.Units, func(u BootUnitReport) analyzer.BootUnitReport { return analyzer.BootUnitReport(u) })}
+	}
+	return in
+}
+
+// BootReport joins the timings, the critical chain and the findings of a
+// scan per unit.
+type BootReport struct {
+	Units []BootUnitReport `json:"units"`
+}
+
+func bootReportFrom(r *analyzer.BootReport) *BootReport {
+	if r == nil {
+		return nil
+	}
+	return &BootReport{Units: convertSlice(r.Units, func(u analyzer.BootUnitReport) BootUnitReport { return BootUnitReport(u) })}
+}
+
+// BootUnitReport is a unit of the boot with its start time, its place in the
+// critical chain and the issues found in it.
+type BootUnitReport struct {
+	Unit string `json:"unit"`
+	// Time is the start time: the journal median, the blame time, the
+	// activation interval or, for a unit only in the critical chain, the
+	// time it took there. WallClock is its share of boot wall-clock time
+	// with timestamp timings, which Slow is judged by then.
+	Time      time.Duration `json:"time"`
+	WallClock time.Duration `json:"wall_clock,omitempty"`
+	Slow      bool          `json:"slow"`
+	// InChain is set when the unit is in systemd's critical chain, and
+	// Critical when it takes a significant share of userspace boot there
+	InChain  bool          `json:"in_critical_chain"`
+	ActiveAt time.Duration `json:"active_at,omitempty"`
+	Critical bool          `json:"critical"`
+	// OnPredictedPath is set when the unit is on the critical path the unit
+	// files predict
+	OnPredictedPath bool `json:"on_predicted_path"`
+	// Findings are the unit's issues from rules about starting, most severe
+	// first
+	Findings       []types.Issue `json:"findings"`
+	Recommendation string        `json:"recommendation"`
+}
+
+// BootIssue is a slow unit or a slow boot.
+type BootIssue struct {
+	Unit        string
+	Description string
+	Severity    string
+	Suggestion  string
+}
+
+// UnitTiming is the time a unit took to start.
+type UnitTiming struct {
+	Name     string
+	Time     time.Duration
+	Position int
+	// Current is the start time in the current boot, zero if the unit did
+	// not start
+	Current time.Duration
+	P95     time.Duration
+	Samples int
+	// WallClock is the unit's share of the boot's wall-clock time, where
+	// units activating at the same time share it equally, and Parallelism
+	// is how many units were activating at a time on average while it was.
+	// Both are set with timestamp timings only.
+	WallClock   time.Duration
+	Parallelism float64
+}
+
+// ChainLink is a unit of the critical chain of the boot.
+type ChainLink struct {
+	Name       string
+	Time       time.Duration
+	ActiveAt   time.Duration
+	IsCritical bool
+	Depth      int
+	Parent     string
+}
+
+// BootPath is the critical path to the boot target that the unit files
+// predict, compared with the observed critical chain.
+type BootPath struct {
+	Target string `json:"target"`
+	// Path is chosen by observed start times. Its WorstCase is the same
+	// chain counted at the units' start timeouts.
+	Path CriticalPath `json:"path"`
+	// InChain are the units of the path that are in systemd's critical chain
+	InChain []string `json:"in_chain"`
+	// Unobserved are the units of the path without a start time, which
+	// count as starting at once
+	Unobserved []string `json:"unobserved"`
+}
+
+func bootPathFrom(p *analyzer.BootPath) *BootPath {
+	if p == nil {
+		return nil
+	}
+	return &BootPath{Target: p.Target, Path: criticalPathFrom(p.Path), InChain: p.InChain, Unobserved: p.Unobserved}
+}
+
+// BootSnapshot is the timing of one boot, as kept in the boot history.
+type BootSnapshot struct {
+	BootID        string                   `json:"boot_id"`
+	Time          time.Time                `json:"time"`
+	TotalTime     time.Duration            `json:"total_time"`
+	UserspaceTime time.Duration            `json:"userspace_time"`
+	Units         map[string]time.Duration `json:"units"`
+}
+
+func bootSnapshotFrom(s analyzer.BootSnapshot) BootSnapshot { return BootSnapshot(s) }
+
+func (s BootSnapshot) internal() analyzer.BootSnapshot { return analyzer.BootSnapshot(s) }
+
+// BootHistory compares a boot with the boots before it.
+type BootHistory struct {
+	// Boots are ordered oldest first and end with the current boot
+	Boots []BootSnapshot
+	// Series holds each unit's start time per boot, aligned with Boots; nil
+	// where the unit did not start
+	Series      map[string][]*time.Duration
+	Regressions []BootRegression
+}
+
+// BootRegression is a unit whose start time grew compared to earlier boots.
+type BootRegression struct {
+	Unit     string        `json:"unit"`
+	Baseline time.Duration `json:"baseline"`
+	Current  time.Duration `json:"current"`
+	Delta    time.Duration `json:"delta"`
+	Percent  float64       `json:"percent"`
+}
+
+// RegressionThresholds are how much a unit's start time must grow to be
+// reported as a regression: by at least Absolute, or by at least Percent
+// over its median in earlier boots.
+type RegressionThresholds struct {
+	Percent  float64
+	Absolute time.Duration
+}
+
+// AnalyzeBoot measures the boot of the running system, as 'sdaudit boot'
+// does, taking unit start times from the journal, from the activation
+// timestamps of the units or from systemd-analyze blame, as opts selects.
+// The systemd-analyze, systemctl and journalctl processes it starts are
+// killed when ctx is cancelled.
+func AnalyzeBoot(ctx context.Context, opts BootOptions) (*BootAnalysis, error) {
+	analysis, err := analyzer.AnalyzeBootWith(ctx, analyzer.BootOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return bootAnalysisFrom(analysis), nil
+}
+
+// CurrentBootID returns the ID of the running boot.
+func CurrentBootID() (string, error) {
+	return analyzer.CurrentBootID()
+}
+
+// SaveBootSnapshot writes snap to the boot history in dir, creating dir if
+// needed, and replaces an earlier snapshot of the same boot.
+func SaveBootSnapshot(dir string, snap BootSnapshot) error {
+	return analyzer.SaveBootSnapshot(dir, snap.internal())
+}
+
+// LoadBootSnapshots reads the boot history in dir, oldest boot first. A
+// missing dir is an empty history.
+func LoadBootSnapshots(dir string) ([]BootSnapshot, error) {
+	snaps, err := analyzer.LoadBootSnapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+	return convertSlice(snaps, bootSnapshotFrom), nil
+}
+
+// CompareBoots compares current with the last limit boots of previous, or
+// all of them if limit is 0, and reports the units whose start time grew
+// beyond thresholds over their median in those boots.
+func CompareBoots(current BootSnapshot, previous []BootSnapshot, limit int, thresholds RegressionThresholds) *BootHistory {
+	h := analyzer.CompareBoots(current.internal(), convertSlice(previous, BootSnapshot.internal), limit, analyzer.RegressionThresholds(thresholds))
+	return &BootHistory{
+		Boots:       convertSlice(h.Boots, bootSnapshotFrom),
+		Series:      h.Series,
+		Regressions: convertSlice(h.Regressions, func(r analyzer.BootRegression) BootRegression { return BootRegression(r) }),
+	}
+}
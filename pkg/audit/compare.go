@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// IssueDiff lists the issues of a scan that are new, resolved and still
+// present since an earlier scan.
+type IssueDiff struct {
+	// New are the issues of the new scan the old one did not have
+	New []types.Issue
+	// Resolved are the issues of the old scan the new one no longer has
+	Resolved []types.Issue
+	// Persisting are the issues of the new scan the old one had too
+	Persisting []types.Issue
+}
+
+func issueDiffFrom(d *analyzer.IssueDiff) *IssueDiff {
+	if d == nil {
+		return nil
+	}
+	diff := IssueDiff(*d)
+	return &diff
+}
+
+// CompareIssues matches the issues of an old and a new scan by fingerprint,
+// the same one baselines use, so issues keep matching when lines move.
+func CompareIssues(old, new []types.Issue) *IssueDiff {
+	return issueDiffFrom(analyzer.DiffIssues(old, new))
+}
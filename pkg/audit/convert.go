@@ -0,0 +1,14 @@
+package audit
+
+// convertSlice converts each element of s with f. A nil slice stays nil, so
+// that it encodes to JSON as the internal one did.
+func convertSlice[E, T any](s []E, f func(E) T) []T {
+	if s == nil {
+		return nil
+	}
+	out := make([]T, len(s))
+	for i, e := range s {
+		out[i] = f(e)
+	}
+	return out
+}
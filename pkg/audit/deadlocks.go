@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"context"
+	"sort"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// CheckDeadlocks runs the restart deadlock, Requisite= wait and restart
+// storm rules over units, as 'sdaudit deadlocks' does, and keeps the issues
+// at or above minSeverity. The result counts those rules as checked. It stops
+// with ctx's error when ctx is cancelled before the rules run.
+func CheckDeadlocks(ctx context.Context, units map[string]*types.UnitFile, minSeverity types.Severity) (*types.ScanResult, error) {
+	found, err := analyzer.AnalyzeDeadlocks(ctx, units)
+	if err != nil {
+		return nil, err
+	}
+	var issues []types.Issue
+	for _, issue := range found {
+		if issue.Severity >= minSeverity {
+			issues = append(issues, issue)
+		}
+	}
+
+	unitList := make([]*types.UnitFile, 0, len(units))
+	for _, unit := range units {
+		unitList = append(unitList, unit)
+	}
+	sort.Slice(unitList, func(i, j int) bool { return unitList[i].Name < unitList[j].Name })
+	return analyzer.NewResult(unitList, issues, len(analyzer.DeadlockRules())), nil
+}
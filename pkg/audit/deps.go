@@ -0,0 +1,266 @@
+package audit
+
+import (
+	"github.com/supabase/sdaudit/internal/analyzer"
+)
+
+// Effects of a unit failing or stopping on a unit that depends on it, as
+// ReverseDependency.Effect names them.
+const (
+	EffectStop        = analyzer.EffectStop
+	EffectFailToStart = analyzer.EffectFailToStart
+	EffectOrdering    = analyzer.EffectOrdering
+	EffectNone        = analyzer.EffectNone
+)
+
+// DependencyReport is the dependency analysis of a graph, as 'sdaudit deps'
+// reports it.
+type DependencyReport struct {
+	// Unit is set when the report is limited to one unit and what it pulls in
+	Unit        string
+	UnitCount   int
+	EdgesByType map[string]int
+	// Counts are sorted by transitive dependencies, largest first
+	Counts []DependencyCount
+	Issues []DependencyIssue
+	Graph  *DependencyGraph
+}
+
+// DependencyIssue is a cycle, a dangling reference or another problem in
+// the dependencies between units.
+type DependencyIssue struct {
+	// Kind is "cycle", "dangling", "ordering", "binding" or "conflict"
+	Kind string `json:"kind"`
+	// Rule is the ID of the scan rule reporting this kind of finding, so
+	// that both commands name it alike
+	Rule        string   `json:"rule,omitempty"`
+	Units       []string `json:"units"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Suggestion  string   `json:"suggestion,omitempty"`
+	File        string   `json:"file,omitempty"`
+	Line        int      `json:"line,omitempty"`
+	// Cycle lists the edges around the shortest path of a cycle, in order
+	Cycle []CycleEdge `json:"cycle,omitempty"`
+}
+
+func dependencyIssueFrom(i analyzer.DependencyIssue) DependencyIssue {
+	return DependencyIssue{
+		Kind:        i.Kind,
+		Rule:        i.Rule,
+		Units:       i.Units,
+		Description: i.Description,
+		Severity:    i.Severity,
+		Suggestion:  i.Suggestion,
+		File:        i.File,
+		Line:        i.Line,
+		Cycle:       convertSlice(i.Cycle, func(e analyzer.CycleEdge) CycleEdge { return CycleEdge(e) }),
+	}
+}
+
+// CycleEdge is an edge on the path of a dependency cycle.
+type CycleEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Break marks the edges suggested for removal
+	Break bool `json:"break,omitempty"`
+}
+
+// Directive returns the name of the directive that declares the edge, such
+// as "Requires".
+func (e CycleEdge) Directive() string {
+	return analyzer.CycleEdge(e).Directive()
+}
+
+// DependencyCount is how many units a unit pulls in when it starts.
+type DependencyCount struct {
+	Unit       string `json:"unit"`
+	Direct     int    `json:"direct"`
+	Transitive int    `json:"transitive"`
+}
+
+// DependencyGraph is the part of a dependency graph that is saved as a
+// baseline and compared between runs.
+type DependencyGraph struct {
+	Units map[string]*DependencyNode
+	Edges []DependencyEdge
+}
+
+// DependencyNode is a unit of a DependencyGraph and its dependencies.
+type DependencyNode struct {
+	Name string
+	// Type is the unit type, such as "service" or "target"
+	Type     string
+	Requires []string
+	Wants    []string
+	After    []string
+	Before   []string
+}
+
+// DependencyEdge is a dependency of a DependencyGraph.
+type DependencyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Type is the dependency, such as "requires" or "after"
+	Type string `json:"type"`
+}
+
+func dependencyEdgeFrom(e analyzer.DependencyEdge) DependencyEdge { return DependencyEdge(e) }
+
+func dependencyGraphFrom(g *analyzer.DependencyGraph) *DependencyGraph {
+	if g == nil {
+		return nil
+	}
+	dg := &DependencyGraph{Edges: convertSlice(g.Edges, dependencyEdgeFrom)}
+	if g.Units != nil {
+		dg.Units = make(map[string]*DependencyNode, len(g.Units))
+		for name, node := range g.Units {
+			n := DependencyNode(*node)
+			dg.Units[name] = &n
+		}
+	}
+	return dg
+}
+
+func (g *DependencyGraph) internal() *analyzer.DependencyGraph {
+	if g == nil {
+		return nil
+	}
+	dg := &analyzer.DependencyGraph{
+		Edges: convertSlice(g.Edges, func(e DependencyEdge) analyzer.DependencyEdge { return analyzer.DependencyEdge(e) }),
+	}
+	if g.Units != nil {
+		dg.Units = make(map[string]*analyzer.DependencyNode, len(g.Units))
+		for name, node := range g.Units {
+			n := analyzer.DependencyNode(*node)
+			dg.Units[name] = &n
+		}
+	}
+	return dg
+}
+
+// DependencyDiff is how a dependency graph changed against a baseline.
+type DependencyDiff struct {
+	AddedUnits   []string         `json:"added_units"`
+	RemovedUnits []string         `json:"removed_units"`
+	AddedEdges   []DependencyEdge `json:"added_edges"`
+	RemovedEdges []DependencyEdge `json:"removed_edges"`
+	ChangedEdges []EdgeChange     `json:"changed_edges"`
+}
+
+// Empty reports whether the graphs were identical.
+func (d *DependencyDiff) Empty() bool {
+	return len(d.AddedUnits) == 0 && len(d.RemovedUnits) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 && len(d.ChangedEdges) == 0
+}
+
+// EdgeChange is a pair of units linked in both graphs by different
+// dependency types.
+type EdgeChange struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	OldTypes []string `json:"old_types"`
+	NewTypes []string `json:"new_types"`
+}
+
+// ReverseDependencies is the tree of units that depend on a unit.
+type ReverseDependencies struct {
+	Unit string `json:"unit"`
+	// Depth is the depth limit of the tree, 0 if unlimited
+	Depth      int `json:"depth,omitempty"`
+	Direct     int `json:"direct"`
+	Transitive int `json:"transitive"`
+	// ByType lists the direct dependents by dependency type
+	ByType     map[string][]string  `json:"by_type"`
+	Dependents []*ReverseDependency `json:"dependents"`
+}
+
+// ReverseDependency is a unit in a ReverseDependencies tree.
+type ReverseDependency struct {
+	Unit string `json:"unit"`
+	// EdgeTypes are the dependencies the unit has on its parent in the tree
+	EdgeTypes []string `json:"edge_types"`
+	// Effect is the strongest effect the queried unit failing or stopping
+	// has on this unit
+	Effect string `json:"effect"`
+	// Affected holds the simulated impacts on this unit
+	Affected   []AffectedUnit       `json:"affected,omitempty"`
+	Dependents []*ReverseDependency `json:"dependents,omitempty"`
+}
+
+func reverseDependencyFrom(d *analyzer.ReverseDependency) *ReverseDependency {
+	return &ReverseDependency{
+		Unit:       d.Unit,
+		EdgeTypes:  d.EdgeTypes,
+		Effect:     d.Effect,
+		Affected:   convertSlice(d.Affected, affectedUnitFrom),
+		Dependents: convertSlice(d.Dependents, reverseDependencyFrom),
+	}
+}
+
+// AnalyzeDependencies reports the dependency issues of g and how many units
+// each unit pulls in. If unit is not empty, only that unit, the units it
+// pulls in and the issues involving it are reported.
+func AnalyzeDependencies(g *Graph, unit string) (*DependencyReport, error) {
+	r, err := analyzer.AnalyzeDependencies(g.g, unit)
+	if err != nil {
+		return nil, err
+	}
+	return &DependencyReport{
+		Unit:        r.Unit,
+		UnitCount:   r.UnitCount,
+		EdgesByType: r.EdgesByType,
+		Counts:      convertSlice(r.Counts, func(c analyzer.DependencyCount) DependencyCount { return DependencyCount(c) }),
+		Issues:      convertSlice(r.Issues, dependencyIssueFrom),
+		Graph:       dependencyGraphFrom(r.Graph),
+	}, nil
+}
+
+// FindReverseDependencies returns the units of g that depend on unit as a
+// tree, down to depth levels, or all of them if depth is 0. Each unit
+// appears once, at its shallowest depth.
+func FindReverseDependencies(g *Graph, unit string, depth int) (*ReverseDependencies, error) {
+	r, err := analyzer.FindReverseDependencies(g.g, unit, depth)
+	if err != nil {
+		return nil, err
+	}
+	return &ReverseDependencies{
+		Unit:       r.Unit,
+		Depth:      r.Depth,
+		Direct:     r.Direct,
+		Transitive: r.Transitive,
+		ByType:     r.ByType,
+		Dependents: convertSlice(r.Dependents, reverseDependencyFrom),
+	}, nil
+}
+
+// SaveDependencyGraph writes dg to path as JSON, to compare later graphs
+// against.
+func SaveDependencyGraph(path string, dg *DependencyGraph) error {
+	return analyzer.SaveDependencyGraph(path, dg.internal())
+}
+
+// LoadDependencyGraph reads a graph written by SaveDependencyGraph.
+func LoadDependencyGraph(path string) (*DependencyGraph, error) {
+	dg, err := analyzer.LoadDependencyGraph(path)
+	if err != nil {
+		return nil, err
+	}
+	return dependencyGraphFrom(dg), nil
+}
+
+// DiffDependencyGraphs compares current against baseline. Edges between the
+// same pair of units whose types differ are reported as changed.
+func DiffDependencyGraphs(baseline, current *DependencyGraph) *DependencyDiff {
+	d := analyzer.DiffDependencyGraphs(baseline.internal(), current.internal())
+	return &DependencyDiff{
+		AddedUnits:   d.AddedUnits,
+		RemovedUnits: d.RemovedUnits,
+		AddedEdges:   convertSlice(d.AddedEdges, dependencyEdgeFrom),
+		RemovedEdges: convertSlice(d.RemovedEdges, dependencyEdgeFrom),
+		ChangedEdges: convertSlice(d.ChangedEdges, func(c analyzer.EdgeChange) EdgeChange { return EdgeChange(c) }),
+	}
+}
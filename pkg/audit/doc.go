@@ -0,0 +1,17 @@
+// Package audit is the public API for embedding sdaudit in other tools.
+//
+// It loads and parses unit files, runs the rules that 'sdaudit scan' and
+// 'sdaudit check' run, builds the dependency graph of a set of units, and
+// writes results in the formats of the command line tool. It also runs the
+// analyses of the other commands: boot times, start-up timing, dependencies,
+// failure impact, restart deadlocks and the exposure of services. Units,
+// issues and results are the types of pkg/types.
+//
+// The API of this package and of pkg/types follows semantic versioning.
+// Packages under internal/ implement it and may change at any time.
+//
+// Importing the package registers every built-in rule. Operations that read
+// many files, run every rule or start systemctl, systemd-analyze or
+// journalctl take a context.Context and stop with its error once it is
+// cancelled; the processes they started are killed.
+package audit
@@ -0,0 +1,181 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/supabase/sdaudit/internal/reporter"
+	"github.com/supabase/sdaudit/internal/style"
+	"github.com/supabase/sdaudit/internal/validation"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Result formats accepted by NewEncoder.
+const (
+	FormatText        = "text"
+	FormatJSON        = "json"
+	FormatSARIF       = "sarif"
+	FormatMarkdown    = "markdown"
+	FormatGitHub      = "github"
+	FormatCodeClimate = "codeclimate"
+	FormatPrometheus  = "prometheus"
+)
+
+// Encoder writes scan results in one format.
+type Encoder interface {
+	Encode(result *types.ScanResult) error
+}
+
+// TextOptions configures the text format, the file paths of the github and
+// codeclimate formats, and the run the json format records.
+type TextOptions struct {
+	// Color enables ANSI colors
+	Color bool
+	// ASCII lays the report out as plain ASCII for screen readers
+	ASCII bool
+	// SummaryOnly leaves the issues out of the report, keeping the counts
+	// and the units with the worst issues
+	SummaryOnly bool
+	// ShowSource prints the lines of the unit file around each issue that
+	// has a line
+	ShowSource bool
+	// PathPrefixStrip is cut from the start of file paths in formats that
+	// locate issues in a checkout, github and codeclimate
+	PathPrefixStrip string
+	// Version and Hostname are the sdaudit version and the host it runs on,
+	// recorded in json reports when set
+	Version  string
+	Hostname string
+}
+
+type encoderFunc func(result *types.ScanResult) error
+
+func (f encoderFunc) Encode(result *types.ScanResult) error { return f(result) }
+
+// JSONSchemaVersion is the version of the format NewJSONEncoder writes.
+const JSONSchemaVersion = reporter.JSONSchemaVersion
+
+// JSONSchema returns the JSON Schema document of the format NewJSONEncoder
+// writes.
+func JSONSchema() []byte {
+	return slices.Clone(reporter.JSONSchema)
+}
+
+// NewJSONEncoder returns an encoder writing indented JSON to w.
+func NewJSONEncoder(w io.Writer) Encoder {
+	return encoderFunc(reporter.NewJSONReporter(w, true).Report)
+}
+
+// NewSARIFEncoder returns an encoder writing SARIF 2.1.0 to w, for code
+// scanning services.
+func NewSARIFEncoder(w io.Writer) Encoder {
+	return encoderFunc(reporter.NewSARIFReporter(w, true).Report)
+}
+
+// NewMarkdownEncoder returns an encoder writing a Markdown document to w,
+// for pull request comments and wikis.
+func NewMarkdownEncoder(w io.Writer) Encoder {
+	return encoderFunc(reporter.NewMarkdownReporter(w).Report)
+}
+
+// NewGitHubEncoder returns an encoder writing GitHub Actions workflow
+// commands to w, which annotate the files of a pull request. stripPrefix is
+// cut from the start of file paths.
+func NewGitHubEncoder(w io.Writer, stripPrefix string) Encoder {
+	return encoderFunc(reporter.NewGitHubReporter(w, stripPrefix).Report)
+}
+
+// NewCodeClimateEncoder returns an encoder writing a CodeClimate JSON array
+// to w, the Code Quality report of GitLab. stripPrefix is cut from the start
+// of file paths, which are written as given otherwise.
+func NewCodeClimateEncoder(w io.Writer, stripPrefix string) Encoder {
+	return encoderFunc(reporter.NewCodeClimateReporter(w, true, stripPrefix).Report)
+}
+
+// NewPrometheusEncoder returns an encoder writing the audit scores and issue
+// counts to w as Prometheus metrics, for the node exporter's textfile
+// collector.
+func NewPrometheusEncoder(w io.Writer) Encoder {
+	return encoderFunc(reporter.NewPrometheusReporter(w).Report)
+}
+
+// NewTextEncoder returns an encoder writing the report 'sdaudit scan' prints.
+func NewTextEncoder(w io.Writer, opts TextOptions) Encoder {
+	p := style.New(opts.Color, opts.ASCII)
+	if opts.SummaryOnly {
+		return encoderFunc(reporter.NewSummaryTextReporter(w, p).Report)
+	}
+	r := reporter.NewStyledTextReporter(w, p)
+	if opts.ShowSource {
+		// Issue files are paths on this host, below the root of an offline
+		// image already
+		r.ShowSource(validation.NewRealFileSystem(""))
+	}
+	return encoderFunc(r.Report)
+}
+
+// NewEncoder returns the encoder for one of the result formats.
+func NewEncoder(w io.Writer, format string, opts TextOptions) (Encoder, error) {
+	switch format {
+	case FormatText:
+		return NewTextEncoder(w, opts), nil
+	case FormatJSON:
+		tool := reporter.JSONTool{Version: opts.Version, Hostname: opts.Hostname}
+		return encoderFunc(reporter.NewJSONReporterFor(w, true, tool).Report), nil
+	case FormatSARIF:
+		return NewSARIFEncoder(w), nil
+	case FormatMarkdown:
+		return NewMarkdownEncoder(w), nil
+	case FormatGitHub:
+		return NewGitHubEncoder(w, opts.PathPrefixStrip), nil
+	case FormatCodeClimate:
+		return NewCodeClimateEncoder(w, opts.PathPrefixStrip), nil
+	case FormatPrometheus:
+		return NewPrometheusEncoder(w), nil
+	}
+	return nil, fmt.Errorf("unknown format %q (use text, json, sarif, markdown, github, codeclimate or prometheus)", format)
+}
+
+// DecodeJSON reads a report written by NewJSONEncoder back into a scan
+// result, for comparing reports. The report does not list the units scanned,
+// so the result has none.
+func DecodeJSON(r io.Reader) (*types.ScanResult, error) {
+	return reporter.ReadJSON(r)
+}
+
+// JSONIssue is an issue as NewJSONEncoder writes it.
+type JSONIssue struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Severity    string            `json:"severity"`
+	Category    string            `json:"category"`
+	Tags        []string          `json:"tags"`
+	Unit        string            `json:"unit"`
+	File        string            `json:"file"`
+	Line        *int              `json:"line,omitempty"`
+	Column      *int              `json:"column,omitempty"`
+	Description string            `json:"description"`
+	Suggestion  string            `json:"suggestion"`
+	References  []string          `json:"references"`
+	Refs        []types.Reference `json:"refs,omitempty"`
+	Origin      string            `json:"origin,omitempty"`
+	// Fingerprint identifies the issue across scans, as in baselines
+	Fingerprint string `json:"fingerprint"`
+	Directive   string `json:"directive,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Section     string `json:"section,omitempty"`
+	Expected    string `json:"expected,omitempty"`
+}
+
+// NewJSONIssue converts an issue to the form NewJSONEncoder writes, for
+// embedding issues in other JSON documents.
+func NewJSONIssue(issue types.Issue) JSONIssue {
+	return JSONIssue(reporter.NewJSONIssue(issue))
+}
+
+// Issue converts the issue back, failing on an unknown severity or
+// category.
+func (j JSONIssue) Issue() (types.Issue, error) {
+	return reporter.JSONIssue(j).Issue()
+}
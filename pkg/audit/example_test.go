@@ -0,0 +1,95 @@
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/supabase/sdaudit/pkg/audit"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+func ExampleRunRules() {
+	unit, err := audit.ParseUnit("/etc/systemd/system/app.service", `[Unit]
+Description=App
+
+[Service]
+ExecStart=/usr/bin/sudo -u app /opt/app/bin/app --config=conf/app.yaml
+`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	units := map[string]*types.UnitFile{unit.Name: unit}
+	issues, summary, err := audit.RunRules(context.Background(), units, audit.Options{Tags: []string{"exec"}, Quick: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d unit, %d issues\n", summary.TotalUnits, summary.TotalIssues)
+	for _, issue := range issues {
+		fmt.Printf("%s line %d: %s\n", issue.RuleID, *issue.Line, issue.RuleName)
+	}
+	// Output:
+	// 1 unit, 2 issues
+	// SEC018 line 5: sudo, su or runuser in Exec command
+	// REL031 line 5: Relative config path without WorkingDirectory=
+}
+
+func ExampleCheck() {
+	result, err := audit.Check(context.Background(), []string{"../../testdata/validation/pid_file"}, audit.Options{Tags: []string{"pidfile"}})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, issue := range result.Issues {
+		fmt.Printf("[%s] %s %s\n", issue.Severity, issue.Unit, issue.RuleID)
+	}
+	// Output:
+	// [high] readonly.service REL033
+	// [high] strict.service REL033
+	// [medium] norundir.service REL034
+	// [low] legacy-rundir.service REL032
+	// [low] legacy.service REL032
+}
+
+func ExampleNewEncoder() {
+	result, err := audit.Scan(context.Background(), audit.Options{Root: "/srv/image", MinSeverity: ptr(types.SeverityHigh)})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	encoder, err := audit.NewEncoder(os.Stdout, audit.FormatSARIF, audit.TextOptions{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := encoder.Encode(result); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func ExampleBuildGraph() {
+	units, err := audit.LoadUnits(context.Background(), "../../testdata/graph/linear_chain")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := audit.BuildGraph(units)
+	var requires []string
+	for _, edge := range g.Edges() {
+		if edge.Type == "Requires" && !edge.Implicit {
+			requires = append(requires, edge.From+" requires "+edge.To)
+		}
+	}
+	sort.Strings(requires)
+	for _, r := range requires {
+		fmt.Println(r)
+	}
+	// Output:
+	// s2.service requires s1.service
+	// s3.service requires s2.service
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,176 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// Graph formats accepted by Graph.Encode.
+const (
+	GraphDOT     = "dot"
+	GraphJSON    = "json"
+	GraphGraphML = "graphml"
+	GraphMermaid = "mermaid"
+)
+
+// Graph is the dependency graph of a set of units. Units referenced but not
+// loaded are part of the graph as missing units.
+type Graph struct {
+	g *graph.Graph
+}
+
+// Edge is a dependency of one unit on another.
+type Edge struct {
+	From string
+	To   string
+	// Type is the directive that creates the dependency, such as "Requires"
+	Type string
+	File string
+	Line int
+	// Implicit is set for dependencies systemd adds on its own
+	Implicit bool
+}
+
+// UnreachableUnit is a unit that is never started from the default target.
+type UnreachableUnit struct {
+	Unit string `json:"unit"`
+	// Kind is "unreferenced" or "unreachable_target"
+	Kind string `json:"kind"`
+	// Target is a target that wants the unit but is not started either
+	Target string `json:"target,omitempty"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+}
+
+// ExportOptions configures Graph.Encode.
+type ExportOptions struct {
+	// Title defaults to "Systemd Unit Dependencies"
+	Title string
+	// Edges limits the dependency types exported, by directive name
+	Edges []string
+	// Highlight lists units to draw highlighted
+	Highlight []string
+	// HighlightCycle highlights the units in dependency cycles and draws
+	// the shortest path around each cycle in red; in JSON it marks the
+	// edges of those paths with "cycle"
+	HighlightCycle bool
+	// Clustered groups units by type
+	Clustered bool
+	// HideMissing leaves out references to units that are not loaded
+	HideMissing bool
+	// HideImplicit leaves out the dependencies systemd adds on its own, such
+	// as the default dependencies on sysinit.target and shutdown.target
+	HideImplicit bool
+}
+
+// BuildGraph builds the dependency graph of units from their directives.
+// When unitPaths are given, the .wants/ and .requires/ symlinks and aliases
+// found in them are added as well, as UnitPaths returns them for a system.
+func BuildGraph(units map[string]*types.UnitFile, unitPaths ...string) *Graph {
+	if len(unitPaths) == 0 {
+		return &Graph{g: graph.Build(units)}
+	}
+	return &Graph{g: analyzer.New(analyzer.Options{UnitPaths: unitPaths}).BuildGraph(units)}
+}
+
+// Units returns the names of the units in the graph, sorted.
+func (g *Graph) Units() []string {
+	return g.g.NodeNames()
+}
+
+// HasUnit reports whether a unit, or an alias of one, is loaded.
+func (g *Graph) HasUnit(name string) bool {
+	return g.g.HasUnit(name)
+}
+
+// Edges returns the dependencies in the graph.
+func (g *Graph) Edges() []Edge {
+	internal := g.g.Edges()
+	edges := make([]Edge, len(internal))
+	for i, e := range internal {
+		edges[i] = Edge{From: e.From, To: e.To, Type: e.Type.String(), File: e.File, Line: e.Line, Implicit: e.Implicit}
+	}
+	return edges
+}
+
+// Neighborhood returns the graph of the given units and their direct
+// dependencies and dependents.
+func (g *Graph) Neighborhood(units ...string) *Graph {
+	return &Graph{g: g.g.Neighborhood(units)}
+}
+
+// DefaultTarget returns the target the system boots into, default.target or
+// graphical.target, or "" if neither is loaded.
+func (g *Graph) DefaultTarget() string {
+	return g.g.DefaultTarget()
+}
+
+// AddSynchronizationUnits adds units, such as a site's own targets, that
+// AnalyzeDependencies does not expect a requirement on with After=, as
+// Options.SynchronizationUnits does for Scan.
+func (g *Graph) AddSynchronizationUnits(units ...string) {
+	g.g.AddSynchronizationUnits(units...)
+}
+
+// AddRuntimeDependencies adds the dependencies systemd has loaded for the
+// units in the graph that are not in their unit files, such as default
+// dependencies and those of generators. It needs a running systemd.
+func (g *Graph) AddRuntimeDependencies(ctx context.Context) error {
+	return analyzer.AddRuntimeDependencies(ctx, g.g)
+}
+
+// Unreachable returns the units that are never started from the default
+// target, leaving out units that are activated on demand.
+func (g *Graph) Unreachable() []UnreachableUnit {
+	var units []UnreachableUnit
+	for _, d := range g.g.FindDeadUnits() {
+		units = append(units, UnreachableUnit(d))
+	}
+	return units
+}
+
+// Encode writes the graph in one of the Graph formats.
+func (g *Graph) Encode(w io.Writer, format string, opts ExportOptions) error {
+	dotOpts := graph.DefaultDOTOptions()
+	if opts.Title != "" {
+		dotOpts.Title = opts.Title
+	}
+	dotOpts.HighlightUnits = opts.Highlight
+	dotOpts.HighlightCycle = opts.HighlightCycle
+	dotOpts.Clustered = opts.Clustered
+	dotOpts.ShowMissing = !opts.HideMissing
+	dotOpts.HideImplicit = opts.HideImplicit
+	for _, name := range opts.Edges {
+		et, ok := graph.ParseEdgeType(strings.TrimSpace(name))
+		if !ok {
+			return fmt.Errorf("unknown dependency type %q", name)
+		}
+		dotOpts.IncludeEdges = append(dotOpts.IncludeEdges, et)
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case GraphDOT:
+		data = []byte(g.g.ToDOT(dotOpts))
+	case GraphMermaid:
+		data = []byte(g.g.ToMermaid(dotOpts))
+	case GraphJSON:
+		data, err = g.g.ToJSON(dotOpts)
+	case GraphGraphML:
+		data, err = g.g.ToGraphML(dotOpts)
+	default:
+		return fmt.Errorf("unknown graph format %q (use dot, json, graphml or mermaid)", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
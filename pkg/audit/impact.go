@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/supabase/sdaudit/internal/propagation"
+)
+
+// Scenarios AnalyzeImpact simulates.
+const (
+	ScenarioFail = propagation.ScenarioFail // The unit fails to start
+	ScenarioStop = propagation.ScenarioStop // The unit stops
+)
+
+// Impacts on a unit, as AffectedUnit.Impact names them.
+const (
+	ImpactStop        = propagation.ImpactStop        // The unit is stopped with it
+	ImpactFailToStart = propagation.ImpactFailToStart // The unit fails to start
+)
+
+// UnitImpact is what happens to the units that depend on a unit when it
+// fails or stops, and the dependencies on it that hide or misorder that.
+type UnitImpact struct {
+	FailedUnit    string         `json:"failed_unit"`
+	Scenarios     []string       `json:"scenarios"`
+	AffectedUnits []AffectedUnit `json:"affected_units"`
+	TotalAffected int            `json:"total_affected"`
+	// CriticalChain is the most severe propagation chain
+	CriticalChain []string `json:"critical_chain"`
+	// SilentFailures are the units depending on it through Wants= only
+	SilentFailures []SilentFailure `json:"silent_failures"`
+	// StopOrderInversions are the units bound to it without After=
+	StopOrderInversions []StopOrderInversion `json:"stop_order_inversions"`
+}
+
+// AffectedUnit is a unit that stops or fails to start with the unit.
+type AffectedUnit struct {
+	Name string `json:"name"`
+	// Impact is the strongest impact on the unit, ImpactStop or
+	// ImpactFailToStart. PropagationPath, EdgeType and Severity are those of
+	// its path.
+	Impact          string   `json:"impact"`
+	PropagationPath []string `json:"propagation_path"`
+	EdgeType        string   `json:"edge_type"`
+	// Severity is "critical", "high", "medium" or "low"
+	Severity string `json:"severity"`
+	// Paths holds the shortest propagation path of each impact on the unit
+	Paths map[string][]string `json:"paths"`
+}
+
+// Impacts returns the impacts on the unit, the strongest first.
+func (a AffectedUnit) Impacts() []string {
+	return propagation.AffectedUnit{Paths: a.Paths}.Impacts()
+}
+
+func affectedUnitFrom(a propagation.AffectedUnit) AffectedUnit {
+	return AffectedUnit{
+		Name:            a.Name,
+		Impact:          a.Impact,
+		PropagationPath: a.PropagationPath,
+		EdgeType:        a.EdgeType.String(),
+		Severity:        a.Severity,
+		Paths:           a.Paths,
+	}
+}
+
+// SilentFailure is a unit that pulls in the unit with Wants= where its
+// failure should propagate.
+type SilentFailure struct {
+	// Unit is the unit depended on, DependedBy the unit that should require
+	// it and EdgeType the weaker dependency it has instead
+	Unit        string `json:"unit"`
+	DependedBy  string `json:"depended_by"`
+	EdgeType    string `json:"edge_type"`
+	Risk        string `json:"risk"`
+	Description string `json:"description"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+}
+
+// StopOrderInversion is a unit bound to the unit without being ordered
+// after it, so it may still run while the unit stops.
+type StopOrderInversion struct {
+	Unit        string `json:"unit"`
+	BoundTo     string `json:"bound_to"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+}
+
+// AnalyzeImpact simulates unit failing to start or stopping, as scenarios
+// select, and reports the units of g that stop or fail with it, as 'sdaudit
+// impact' does. It also finds the units that depend on it through Wants=
+// only, or through BindsTo= without After=. The unit must be loaded or
+// referenced by a loaded unit.
+func AnalyzeImpact(g *Graph, unit string, scenarios ...string) (UnitImpact, error) {
+	if !g.g.HasUnit(unit) && len(g.g.EdgesTo(unit)) == 0 {
+		return UnitImpact{}, fmt.Errorf("unit %s not found", unit)
+	}
+	impact := propagation.AnalyzeUnit(g.g, unit, scenarios...)
+	return UnitImpact{
+		FailedUnit:    impact.FailedUnit,
+		Scenarios:     impact.Scenarios,
+		AffectedUnits: convertSlice(impact.AffectedUnits, affectedUnitFrom),
+		TotalAffected: impact.TotalAffected,
+		CriticalChain: impact.CriticalChain,
+		SilentFailures: convertSlice(impact.SilentFailures, func(f propagation.SilentFailure) SilentFailure {
+			return SilentFailure{
+				Unit:        f.Unit,
+				DependedBy:  f.DependedBy,
+				EdgeType:    f.EdgeType.String(),
+				Risk:        f.Risk,
+				Description: f.Description,
+				File:        f.File,
+				Line:        f.Line,
+			}
+		}),
+		StopOrderInversions: convertSlice(impact.StopOrderInversions, func(s propagation.StopOrderInversion) StopOrderInversion {
+			return StopOrderInversion(s)
+		}),
+	}, nil
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/supabase/sdaudit/internal/unitfile"
+)
+
+// DirectiveChange is a directive that differs between a vendor unit file
+// and its override.
+type DirectiveChange struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	// Kind is "added", "removed" or "changed"
+	Kind string `json:"kind"`
+	// Old are the directive's values in the vendor file and New those in
+	// the override, in file order; nil where the directive is not set
+	Old []string `json:"old,omitempty"`
+	New []string `json:"new,omitempty"`
+}
+
+// OverrideDiff compares the unit file in effect with the vendor file it
+// shadows, directive by directive.
+type OverrideDiff struct {
+	Unit string `json:"unit"`
+	// Override is the unit file in effect
+	Override string `json:"override"`
+	// Vendor is the vendor file it shadows in /usr/lib or /lib, empty when
+	// there is none
+	Vendor string `json:"vendor,omitempty"`
+	// Runtime is a copy in /run it shadows, between the override and the
+	// vendor file. Changes are against it when there is no vendor file.
+	Runtime string `json:"runtime,omitempty"`
+	// Masked is set when the override masks the unit
+	Masked bool `json:"masked,omitempty"`
+	// Changes are the directives the override adds, removes or changes,
+	// by section in systemd's order, then by key; empty for a verbatim copy
+	Changes []DirectiveChange `json:"changes"`
+	// DropIns are the unit's drop-in files, applied on top of the override
+	DropIns []string `json:"drop_ins"`
+}
+
+// DiffOverride compares the unit named name with the vendor unit file it
+// overrides, or with the copy in /run it shadows when there is no vendor
+// file, and lists its drop-ins. An empty root diffs the running
+// system's units; otherwise those of the offline image rooted at root.
+func DiffOverride(ctx context.Context, root, name string) (*OverrideDiff, error) {
+	units, err := LoadSystemUnits(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	unit, ok := units[name]
+	if !ok {
+		return nil, fmt.Errorf("unit %q not found", name)
+	}
+
+	diff := &OverrideDiff{
+		Unit:     unit.Name,
+		Override: unit.Path,
+		Masked:   unit.Masked,
+		Changes:  []DirectiveChange{},
+		DropIns:  unit.DropIns,
+	}
+	if diff.DropIns == nil {
+		diff.DropIns = []string{}
+	}
+	if len(unit.ShadowedPaths) == 0 {
+		return diff, nil
+	}
+
+	for _, path := range unit.ShadowedPaths {
+		switch {
+		case isVendorPath(path):
+			if diff.Vendor == "" {
+				diff.Vendor = path
+			}
+		case diff.Runtime == "":
+			diff.Runtime = path
+		}
+	}
+	if unit.Masked {
+		return diff, nil
+	}
+	base := diff.Vendor
+	if base == "" {
+		base = diff.Runtime
+	}
+	vendor, err := unitfile.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", base, err)
+	}
+	override := unit
+	if len(unit.DropIns) > 0 {
+		// The drop-ins are listed apart; compare the override file itself
+		if override, err = unitfile.Parse(unit.Path); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", unit.Path, err)
+		}
+	}
+	if changes := unitfile.Diff(vendor, override); changes != nil {
+		diff.Changes = convertSlice(changes, func(c unitfile.Change) DirectiveChange { return DirectiveChange(c) })
+	}
+	return diff, nil
+}
+
+// isVendorPath reports whether path is in a vendor unit directory, under
+// /usr/lib or /lib. Of several vendor files, the one in /usr/lib comes first
+// in ShadowedPaths and is the one systemd would load.
+func isVendorPath(path string) bool {
+	return strings.HasSuffix(filepath.Dir(path), "/lib/systemd/system")
+}
@@ -0,0 +1,345 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/internal/rules"
+	"github.com/supabase/sdaudit/internal/rules/custom"
+	"github.com/supabase/sdaudit/internal/rules/plugin"
+	"github.com/supabase/sdaudit/pkg/types"
+
+	// Register the built-in rules
+	_ "github.com/supabase/sdaudit/internal/rules/bestpractice"
+	_ "github.com/supabase/sdaudit/internal/rules/crossunit"
+	_ "github.com/supabase/sdaudit/internal/rules/performance"
+	_ "github.com/supabase/sdaudit/internal/rules/reliability"
+	_ "github.com/supabase/sdaudit/internal/rules/security"
+	_ "github.com/supabase/sdaudit/internal/rules/validity"
+)
+
+// RuleInfo describes a registered rule.
+type RuleInfo struct {
+	ID          string
+	Name        string
+	Description string
+	Category    types.Category
+	Severity    types.Severity
+	Tags        []string
+	Suggestion  string
+	References  []types.Reference
+	// MinSystemdVersion is the first systemd version with the directives the
+	// rule checks, 0 if it applies to every version
+	MinSystemdVersion int
+	// ExampleBad is a unit file snippet the rule reports and ExampleGood the
+	// same snippet fixed, both empty when the rule has no example
+	ExampleBad  string
+	ExampleGood string
+	// Profiles are the names of the bundled profiles that run the rule
+	Profiles []string
+	// UnitTypes are the unit types the rule checks, such as "service"; nil
+	// for every type
+	UnitTypes []string
+	// Origin is where the rule comes from, such as "plugin cmdb" or the rule
+	// file of a custom rule; empty for built-in rules
+	Origin string
+	// Params are the thresholds of the rule that the configuration file can
+	// change, nil when it has none
+	Params []ParamInfo
+}
+
+// ParamInfo describes a rule parameter and the value a scan uses.
+type ParamInfo struct {
+	Name        string
+	Kind        string // "integer" or "duration"
+	Description string
+	Default     string
+	// Value is the value set with Options.RuleParams, or else Default
+	Value string
+}
+
+// WithParams returns the rule with the parameter values that params, by
+// rule ID and parameter name, set for it.
+func (r RuleInfo) WithParams(params map[string]map[string]string) RuleInfo {
+	if len(r.Params) == 0 {
+		return r
+	}
+	r.Params = slices.Clone(r.Params)
+	for i, p := range r.Params {
+		if value, ok := params[r.ID][p.Name]; ok {
+			r.Params[i].Value = value
+		}
+	}
+	return r
+}
+
+// ProfileInfo describes a bundled profile.
+type ProfileInfo struct {
+	Name        string
+	Description string
+	// Rules are the IDs of the rules the profile runs, sorted
+	Rules []string
+	// Severities are the severities the profile gives some of its rules in
+	// place of their own
+	Severities map[string]types.Severity
+}
+
+// Profiles returns the bundled profiles.
+func Profiles() []ProfileInfo {
+	all := rules.Profiles()
+	infos := make([]ProfileInfo, len(all))
+	for i, p := range all {
+		infos[i] = profileInfo(p)
+	}
+	return infos
+}
+
+// ProfileNames returns the names of the bundled profiles.
+func ProfileNames() []string {
+	return rules.ProfileNames()
+}
+
+// LookupProfile returns the bundled profile with the given name.
+func LookupProfile(name string) (ProfileInfo, bool) {
+	p, ok := rules.LookupProfile(name)
+	if !ok {
+		return ProfileInfo{}, false
+	}
+	return profileInfo(p), true
+}
+
+func profileInfo(p rules.Profile) ProfileInfo {
+	info := ProfileInfo{
+		Name:        p.Name,
+		Description: p.Description,
+		Rules:       p.Resolve(),
+		Severities:  make(map[string]types.Severity),
+	}
+	for _, rule := range rules.All() {
+		if !p.Includes(rule) {
+			continue
+		}
+		if severity, changed := p.Severity(rule); changed {
+			info.Severities[rule.ID()] = severity
+		}
+	}
+	return info
+}
+
+func unknownProfile(name string) error {
+	return fmt.Errorf("unknown profile %q, use %s", name, strings.Join(rules.ProfileNames(), ", "))
+}
+
+// Rules returns the registered rules, sorted by ID.
+func Rules() []RuleInfo {
+	all := rules.All()
+	infos := make([]RuleInfo, len(all))
+	for i, rule := range all {
+		infos[i] = ruleInfo(rule)
+	}
+	return infos
+}
+
+// RulesFor returns the registered rules that opts' Category, MinSeverity,
+// Tags and Profile select, sorted by ID. With a profile, each rule has the
+// severity the profile gives it, and each has the parameter values of
+// opts.RuleParams.
+func RulesFor(opts Options) ([]RuleInfo, error) {
+	var profile *rules.Profile
+	if opts.Profile != "" {
+		p, ok := rules.LookupProfile(opts.Profile)
+		if !ok {
+			return nil, unknownProfile(opts.Profile)
+		}
+		profile = &p
+	}
+	if err := rules.DefaultConfig().SetParams(opts.RuleParams); err != nil {
+		return nil, err
+	}
+	var infos []RuleInfo
+	for _, rule := range rules.All() {
+		if profile != nil && !profile.Includes(rule) {
+			continue
+		}
+		if !rules.Matches(rule, opts.Category, opts.MinSeverity, opts.Tags) {
+			continue
+		}
+		info := ruleInfo(rule).WithParams(opts.RuleParams)
+		if profile != nil {
+			info.Severity, _ = profile.Severity(rule)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// LoadRules loads the rules declared in the YAML and JSON rule files of dir
+// and registers them alongside the built-in rules, so every function of the
+// package uses them. Nothing is registered when any file is invalid; errors
+// name the file and line at fault.
+func LoadRules(dir string) ([]RuleInfo, error) {
+	loaded, err := custom.LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]RuleInfo, len(loaded))
+	for i, rule := range loaded {
+		rules.Register(rule)
+		infos[i] = ruleInfo(rule)
+	}
+	return infos, nil
+}
+
+// PluginOptions configures how plugin programs are run.
+type PluginOptions struct {
+	// Timeout bounds each call of the plugin, 10 seconds if zero
+	Timeout time.Duration
+	// OnError is called when the plugin fails to check a unit, which is then
+	// left without the plugin's issues. Nil ignores failures.
+	OnError func(error)
+}
+
+// LoadPlugin starts the plugin program at path, or found in PATH, to
+// describe its rules, and registers them alongside the built-in rules. The
+// program is then run once per unit checked; see the README for the JSON
+// protocol it speaks.
+func LoadPlugin(path string, opts PluginOptions) ([]RuleInfo, error) {
+	p, err := plugin.Load(path, plugin.Options{Timeout: opts.Timeout, OnError: opts.OnError})
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]RuleInfo, len(p.Rules))
+	for i, rule := range p.Rules {
+		rules.Register(rule)
+		infos[i] = ruleInfo(rule)
+	}
+	return infos, nil
+}
+
+// LookupRule returns the rule with the given ID.
+func LookupRule(id string) (RuleInfo, bool) {
+	rule := rules.Get(id)
+	if rule == nil {
+		return RuleInfo{}, false
+	}
+	return ruleInfo(rule), true
+}
+
+func ruleInfo(rule rules.Rule) RuleInfo {
+	info := RuleInfo{
+		ID:                rule.ID(),
+		Name:              rule.Name(),
+		Description:       rule.Description(),
+		Category:          rule.Category(),
+		Severity:          rule.Severity(),
+		Tags:              rule.Tags(),
+		Suggestion:        rule.Suggestion(),
+		References:        rules.TypedReferences(rule),
+		MinSystemdVersion: rules.MinSystemdVersion(rule),
+		Origin:            rules.Origin(rule),
+	}
+	info.ExampleBad, info.ExampleGood = rules.Examples(rule)
+	info.Profiles = rules.ProfilesOf(rule)
+	if r, ok := rule.(rules.UnitTypeRule); ok {
+		info.UnitTypes = r.AppliesTo()
+	}
+	for _, p := range rules.Params(rule) {
+		info.Params = append(info.Params, ParamInfo{Name: p.Name, Kind: p.Kind.String(), Description: p.Description, Default: p.Default, Value: p.Default})
+	}
+	return info
+}
+
+// RuleJSON is a rule as EncodeRulesJSON writes it. The field names are
+// stable for scripts and documentation generators.
+type RuleJSON struct {
+	ID                string            `json:"id"`
+	Name              string            `json:"name"`
+	Description       string            `json:"description"`
+	Category          string            `json:"category"`
+	Severity          string            `json:"severity"`
+	Tags              []string          `json:"tags"`
+	Suggestion        string            `json:"suggestion"`
+	References        []types.Reference `json:"references"`
+	MinSystemdVersion int               `json:"min_systemd_version,omitempty"`
+	ExampleBad        string            `json:"example_bad,omitempty"`
+	ExampleGood       string            `json:"example_good,omitempty"`
+	Profiles          []string          `json:"profiles"`
+	UnitTypes         []string          `json:"unit_types,omitempty"`
+	Origin            string            `json:"origin,omitempty"`
+	Params            []ParamJSON       `json:"params,omitempty"`
+}
+
+// ParamJSON is a rule parameter as EncodeRulesJSON writes it.
+type ParamJSON struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	Default     string `json:"default"`
+	Value       string `json:"value"`
+}
+
+// JSON returns the rule as EncodeRulesJSON writes it.
+func (r RuleInfo) JSON() RuleJSON {
+	tags := r.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	profiles := r.Profiles
+	if profiles == nil {
+		profiles = []string{}
+	}
+	refs := r.References
+	if refs == nil {
+		refs = []types.Reference{}
+	}
+	var params []ParamJSON
+	for _, p := range r.Params {
+		params = append(params, ParamJSON(p))
+	}
+	return RuleJSON{
+		ID:                r.ID,
+		Name:              r.Name,
+		Description:       r.Description,
+		Category:          r.Category.String(),
+		Severity:          r.Severity.String(),
+		Tags:              tags,
+		Suggestion:        r.Suggestion,
+		References:        refs,
+		MinSystemdVersion: r.MinSystemdVersion,
+		ExampleBad:        r.ExampleBad,
+		ExampleGood:       r.ExampleGood,
+		Profiles:          profiles,
+		UnitTypes:         r.UnitTypes,
+		Origin:            r.Origin,
+		Params:            params,
+	}
+}
+
+// EncodeRulesJSON writes rules to w as an indented JSON array.
+func EncodeRulesJSON(w io.Writer, infos []RuleInfo) error {
+	out := make([]RuleJSON, len(infos))
+	for i, r := range infos {
+		out[i] = r.JSON()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// DetectSystemdVersion returns the major version of the running systemd, or
+// 0 if it cannot be determined.
+func DetectSystemdVersion() int {
+	return analyzer.DetectSystemdVersion()
+}
+
+// ParseSystemdVersion returns the major version in strings such as "252",
+// "252.22-1~deb12u1" or "systemd 252 (252.22-1~deb12u1)", or 0 if there is
+// none.
+func ParseSystemdVersion(s string) int {
+	return rules.ParseSystemdVersion(s)
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"context"
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// SecurityScore is the exposure score of a service, from 0 for a well
+// sandboxed service to 10, as systemd-analyze security rates it.
+type SecurityScore struct {
+	Unit  string
+	Score float64
+	// Exposure is "SAFE", "OK", "MEDIUM", "EXPOSED" or "UNSAFE"
+	Exposure string
+	Checks   []SecurityCheck
+	// Estimated is set when the score was computed from the unit file
+	// rather than by systemd-analyze
+	Estimated bool
+}
+
+// SecurityCheck is one of the settings a SecurityScore is made of.
+type SecurityCheck struct {
+	Name        string
+	Description string
+	// Result is "OK", "NA", "MEDIUM", "EXPOSED" or "UNSAFE"
+	Result string
+	Weight float64
+}
+
+func securityScoreFrom(s analyzer.SecurityScore) SecurityScore {
+	return SecurityScore{
+		Unit:      s.Unit,
+		Score:     s.Score,
+		Exposure:  s.Exposure,
+		Checks:    convertSlice(s.Checks, func(c analyzer.SecurityCheck) SecurityCheck { return SecurityCheck(c) }),
+		Estimated: s.Estimated,
+	}
+}
+
+// AnalyzeSecurity scores the services of the running system with
+// systemd-analyze security, or only unit when it is not empty, as 'sdaudit
+// security' does. When systemd-analyze is unavailable or fails, the scores
+// are estimated from the unit files instead, unless ctx was cancelled, which
+// kills systemd-analyze and returns ctx's error.
+func AnalyzeSecurity(ctx context.Context, unit string) ([]SecurityScore, error) {
+	scores, err := analyzer.AnalyzeSecurity(ctx, unit)
+	if err != nil {
+		return nil, err
+	}
+	return convertSlice(scores, securityScoreFrom), nil
+}
+
+// EstimateSecurity scores the services in units from their directives,
+// without systemd-analyze, or only unit when it is not empty. Templates are
+// skipped since their settings depend on the instance. Scores are sorted by
+// unit name.
+func EstimateSecurity(units map[string]*types.UnitFile, unit string) ([]SecurityScore, error) {
+	scores, err := analyzer.EstimateSecurity(units, unit)
+	if err != nil {
+		return nil, err
+	}
+	return convertSlice(scores, securityScoreFrom), nil
+}
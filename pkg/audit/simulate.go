@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+)
+
+// DirectiveEdit is a change to one directive of a unit, written as
+// Section.Key=value, or Section.Key=- to remove the directive.
+type DirectiveEdit struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	// Value is assigned after the unit's own assignments, as a drop-in
+	// would: it overrides a directive that takes one value and adds to a
+	// list. An empty value resets a list.
+	Value string `json:"value"`
+	// Remove drops every assignment of the directive, written as Key=-
+	Remove bool `json:"remove,omitempty"`
+}
+
+// String returns the edit as ParseDirectiveEdit reads it.
+func (e DirectiveEdit) String() string {
+	return analyzer.DirectiveEdit(e).String()
+}
+
+// UnitChange is a proposed change to a unit: a drop-in file applied to it,
+// then directive edits.
+type UnitChange struct {
+	Unit string `json:"unit"`
+	// Patch is the path of a drop-in file applied to the unit, empty for none
+	Patch string          `json:"patch,omitempty"`
+	Edits []DirectiveEdit `json:"edits"`
+}
+
+func unitChangeFrom(c analyzer.UnitChange) UnitChange {
+	return UnitChange{
+		Unit:  c.Unit,
+		Patch: c.Patch,
+		Edits: convertSlice(c.Edits, func(e analyzer.DirectiveEdit) DirectiveEdit { return DirectiveEdit(e) }),
+	}
+}
+
+func (c UnitChange) internal() analyzer.UnitChange {
+	return analyzer.UnitChange{
+		Unit:  c.Unit,
+		Patch: c.Patch,
+		Edits: convertSlice(c.Edits, func(e DirectiveEdit) analyzer.DirectiveEdit { return analyzer.DirectiveEdit(e) }),
+	}
+}
+
+// Simulation is how the issues, critical paths and timeout cascade risks of
+// a system differ once a change is made to one of its units.
+type Simulation struct {
+	Change UnitChange
+	// Issues holds the issues the change introduces and resolves, matched by
+	// fingerprint as compare does
+	Issues *IssueDiff
+	// CriticalPaths are the units whose critical path changed, the largest
+	// change first
+	CriticalPaths []CriticalPathChange
+	// NewCascades and ResolvedCascades are the timeout cascade risks, matched
+	// by unit and kind, that the change introduces and resolves
+	NewCascades      []CascadeRisk
+	ResolvedCascades []CascadeRisk
+}
+
+// CriticalPathChange is a unit whose critical path a change makes longer or
+// shorter.
+type CriticalPathChange struct {
+	Unit   string       `json:"unit"`
+	Before CriticalPath `json:"before"`
+	After  CriticalPath `json:"after"`
+}
+
+// Delta returns how much longer the path takes after the change, negative
+// when it got shorter.
+func (c CriticalPathChange) Delta() time.Duration {
+	return c.After.TotalTime - c.Before.TotalTime
+}
+
+// ParseDirectiveEdit parses an edit written as Section.Key=value, such as
+// Service.Restart=always, or as Section.Key=- to remove the directive.
+func ParseDirectiveEdit(s string) (DirectiveEdit, error) {
+	e, err := analyzer.ParseDirectiveEdit(s)
+	return DirectiveEdit(e), err
+}
+
+// Simulate scans the system as Scan does, then again with change made to a
+// copy of its unit, and reports the difference, as 'sdaudit simulate' does.
+// The unit files are not modified, and the changed unit is not cached.
+func Simulate(ctx context.Context, change UnitChange, opts Options) (*Simulation, error) {
+	o, err := opts.analyzer()
+	if err != nil {
+		return nil, err
+	}
+	sim, err := analyzer.New(o).Simulate(ctx, change.internal(), o)
+	if err != nil {
+		return nil, fmt.Errorf("simulation failed: %w", err)
+	}
+	return &Simulation{
+		Change: unitChangeFrom(sim.Change),
+		Issues: issueDiffFrom(sim.Issues),
+		CriticalPaths: convertSlice(sim.CriticalPaths, func(c analyzer.CriticalPathChange) CriticalPathChange {
+			return CriticalPathChange{Unit: c.Unit, Before: criticalPathFrom(c.Before), After: criticalPathFrom(c.After)}
+		}),
+		NewCascades:      convertSlice(sim.NewCascades, cascadeRiskFrom),
+		ResolvedCascades: convertSlice(sim.ResolvedCascades, cascadeRiskFrom),
+	}, nil
+}
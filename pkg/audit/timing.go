@@ -0,0 +1,260 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/supabase/sdaudit/internal/graph"
+	"github.com/supabase/sdaudit/internal/timing"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// PathNode is a unit on a critical path.
+type PathNode struct {
+	Unit    string        `json:"unit"`
+	Timeout time.Duration `json:"timeout"`
+	// Observed is the time the unit took to start at boot. Unobserved is set
+	// when start times were given but the unit has none, as for units that
+	// did not start; they count as starting at once.
+	Observed   time.Duration `json:"observed,omitempty"`
+	Unobserved bool          `json:"unobserved,omitempty"`
+	// Cumulative is the time along the path up to and including the unit
+	Cumulative time.Duration `json:"cumulative"`
+}
+
+// CriticalPath is the longest chain of units started before a unit.
+type CriticalPath struct {
+	Unit string `json:"unit"`
+	// TotalTime is the sum of the weights along the path, WorstCase that of
+	// the start timeouts and Observed that of the observed start times
+	TotalTime time.Duration `json:"total_time"`
+	WorstCase time.Duration `json:"worst_case"`
+	Observed  time.Duration `json:"observed,omitempty"`
+	// Path lists the units in start order
+	Path []PathNode `json:"path"`
+	// Bottleneck is the unit taking the most time on the path
+	Bottleneck string `json:"bottleneck"`
+}
+
+// PathDescription returns the units of the path joined by " -> ".
+func (p CriticalPath) PathDescription() string {
+	return p.internal().PathDescription()
+}
+
+func criticalPathFrom(p timing.CriticalPath) CriticalPath {
+	return CriticalPath{
+		Unit:       p.Unit,
+		TotalTime:  p.TotalTime,
+		WorstCase:  p.WorstCase,
+		Observed:   p.Observed,
+		Path:       convertSlice(p.Path, func(n timing.PathNode) PathNode { return PathNode(n) }),
+		Bottleneck: p.Bottleneck,
+	}
+}
+
+func (p CriticalPath) internal() timing.CriticalPath {
+	return timing.CriticalPath{
+		Unit:       p.Unit,
+		TotalTime:  p.TotalTime,
+		WorstCase:  p.WorstCase,
+		Observed:   p.Observed,
+		Path:       convertSlice(p.Path, func(n PathNode) timing.PathNode { return timing.PathNode(n) }),
+		Bottleneck: p.Bottleneck,
+	}
+}
+
+// CriticalPaths holds the critical path of each unit and the units that are
+// most often the slowest on them.
+type CriticalPaths struct {
+	Paths       map[string]CriticalPath
+	LongestPath CriticalPath
+	// BottleneckUnits are the units most often the bottleneck of a path
+	BottleneckUnits []string
+}
+
+// PathsExceedingThreshold returns the paths taking longer than threshold,
+// the longest first.
+func (r CriticalPaths) PathsExceedingThreshold(threshold time.Duration) []CriticalPath {
+	return convertSlice(r.internal().PathsExceedingThreshold(threshold), criticalPathFrom)
+}
+
+// PathForUnit returns the critical path of unit.
+func (r CriticalPaths) PathForUnit(unit string) (CriticalPath, bool) {
+	path, ok := r.Paths[unit]
+	return path, ok
+}
+
+func criticalPathsFrom(r timing.CriticalPathResult) CriticalPaths {
+	paths := CriticalPaths{
+		LongestPath:     criticalPathFrom(r.LongestPath),
+		BottleneckUnits: r.BottleneckUnits,
+	}
+	if r.Paths != nil {
+		paths.Paths = make(map[string]CriticalPath, len(r.Paths))
+		for unit, path := range r.Paths {
+			paths.Paths[unit] = criticalPathFrom(path)
+		}
+	}
+	return paths
+}
+
+func (r CriticalPaths) internal() timing.CriticalPathResult {
+	result := timing.CriticalPathResult{
+		LongestPath:     r.LongestPath.internal(),
+		BottleneckUnits: r.BottleneckUnits,
+	}
+	if r.Paths != nil {
+		result.Paths = make(map[string]timing.CriticalPath, len(r.Paths))
+		for unit, path := range r.Paths {
+			result.Paths[unit] = path.internal()
+		}
+	}
+	return result
+}
+
+// CascadeRisk is a unit whose dependencies may take longer to start than
+// the unit waits, so that its start times out.
+type CascadeRisk struct {
+	Unit string `json:"unit"`
+	Kind string `json:"kind"`
+	// CriticalPath is the time to reach the unit and OwnTimeout its
+	// TimeoutStartSec=
+	CriticalPath time.Duration `json:"critical_path"`
+	OwnTimeout   time.Duration `json:"own_timeout"`
+	// Risk is "critical", "high", "medium" or "low"
+	Risk           string `json:"risk"`
+	Description    string `json:"description"`
+	Recommendation string `json:"recommendation"`
+	File           string `json:"file,omitempty"`
+	Line           int    `json:"line,omitempty"`
+}
+
+func cascadeRiskFrom(r timing.CascadeRisk) CascadeRisk { return CascadeRisk(r) }
+
+// CascadeResult lists the cascade risks of a system, with counts by risk.
+type CascadeResult struct {
+	Risks         []CascadeRisk `json:"risks"`
+	TotalRisks    int           `json:"total_risks"`
+	CriticalCount int           `json:"critical_count"`
+	HighCount     int           `json:"high_count"`
+	MediumCount   int           `json:"medium_count"`
+	LowCount      int           `json:"low_count"`
+}
+
+// TimeoutConfig is the start, stop and restart timeouts of a unit, from its
+// directives and the defaults of system.conf.
+type TimeoutConfig struct {
+	Unit            string        `json:"unit"`
+	TimeoutStartSec time.Duration `json:"timeout_start_sec"`
+	TimeoutStopSec  time.Duration `json:"timeout_stop_sec"`
+	// TimeoutAbortSec defaults to TimeoutStopSec
+	TimeoutAbortSec time.Duration `json:"timeout_abort_sec"`
+	// JobTimeoutSec is 0 for infinity
+	JobTimeoutSec time.Duration `json:"job_timeout_sec"`
+	RestartSec    time.Duration `json:"restart_sec"`
+	// Source is the file setting the start timeout
+	Source string `json:"source,omitempty"`
+}
+
+// UnitTimingAnalysis is the timeouts, critical path and cascade risks of one
+// unit.
+type UnitTimingAnalysis struct {
+	Unit           string        `json:"unit"`
+	TimeoutConfig  TimeoutConfig `json:"timeout_config"`
+	CriticalPath   CriticalPath  `json:"critical_path"`
+	CascadeRisks   []CascadeRisk `json:"cascade_risks"`
+	Dependencies   []string      `json:"dependencies"`
+	DependencyTime time.Duration `json:"dependency_time"`
+}
+
+// Summary returns the analysis as the text 'sdaudit timing --unit' prints.
+func (a *UnitTimingAnalysis) Summary() string {
+	return (&timing.UnitTimingAnalysis{
+		Unit:           a.Unit,
+		TimeoutConfig:  timing.TimeoutConfig(a.TimeoutConfig),
+		CriticalPath:   a.CriticalPath.internal(),
+		CascadeRisks:   convertSlice(a.CascadeRisks, func(r CascadeRisk) timing.CascadeRisk { return timing.CascadeRisk(r) }),
+		Dependencies:   a.Dependencies,
+		DependencyTime: a.DependencyTime,
+	}).Summary()
+}
+
+// Timing is the start-up timing of a set of units: their dependency graph
+// and their timeouts, from their directives and system.conf.
+type Timing struct {
+	g        *graph.Graph
+	units    map[string]*types.UnitFile
+	timeouts map[string]timing.TimeoutConfig
+}
+
+// NewTiming reads the default timeouts from the system.conf under root, or
+// of the running system when root is empty, and the timeouts units set
+// themselves.
+func NewTiming(units map[string]*types.UnitFile, root string) (*Timing, error) {
+	systemConf, err := timing.LoadSystemConfig(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load system.conf: %w", err)
+	}
+	return &Timing{
+		g:        graph.Build(units),
+		units:    units,
+		timeouts: timing.ParseAllTimeouts(units, systemConf),
+	}, nil
+}
+
+// CriticalPaths computes the critical path of every unit, counting each
+// unit at its start timeout, the worst case. With observed start times, as
+// BootAnalysis.ObservedTimes returns them, units count at the time they took
+// to start instead.
+func (t *Timing) CriticalPaths(observed map[string]time.Duration) CriticalPaths {
+	if observed == nil {
+		return criticalPathsFrom(timing.ComputeCriticalPaths(t.g, t.timeouts))
+	}
+	return criticalPathsFrom(timing.ComputeCriticalPathsWith(t.g, t.timeouts, observed, timing.WeightObserved))
+}
+
+// Cascades finds the units whose start may time out waiting for their
+// dependencies. paths are the worst-case critical paths, as CriticalPaths
+// returns them without observed start times.
+func (t *Timing) Cascades(paths CriticalPaths) CascadeResult {
+	r := timing.DetectCascades(t.g, paths.internal(), t.timeouts)
+	return CascadeResult{
+		Risks:         convertSlice(r.Risks, cascadeRiskFrom),
+		TotalRisks:    r.TotalRisks,
+		CriticalCount: r.CriticalCount,
+		HighCount:     r.HighCount,
+		MediumCount:   r.MediumCount,
+		LowCount:      r.LowCount,
+	}
+}
+
+// Unit analyzes the timing of one unit, or returns nil if it is not loaded.
+func (t *Timing) Unit(name string) *UnitTimingAnalysis {
+	a := timing.AnalyzeUnit(name, t.g, t.units, t.timeouts)
+	if a == nil {
+		return nil
+	}
+	return &UnitTimingAnalysis{
+		Unit:           a.Unit,
+		TimeoutConfig:  TimeoutConfig(a.TimeoutConfig),
+		CriticalPath:   criticalPathFrom(a.CriticalPath),
+		CascadeRisks:   convertSlice(a.CascadeRisks, cascadeRiskFrom),
+		Dependencies:   a.Dependencies,
+		DependencyTime: a.DependencyTime,
+	}
+}
+
+// PredictBootPath sets analysis.PredictedPath to the critical path to the
+// boot target that the units predict, counting each unit at the time it
+// took to start in that boot.
+func (t *Timing) PredictBootPath(analysis *BootAnalysis) {
+	a := analysis.internal()
+	a.CorrelateCriticalPath(t.g, t.timeouts)
+	analysis.PredictedPath = bootPathFrom(a.PredictedPath)
+}
+
+// FormatDuration formats d as sdaudit prints durations, rounded to seconds
+// from one second up, and 0 as "infinity", the timeout that never expires.
+func FormatDuration(d time.Duration) string {
+	return timing.FormatDuration(d)
+}
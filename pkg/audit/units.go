@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/supabase/sdaudit/internal/analyzer"
+	"github.com/supabase/sdaudit/pkg/types"
+)
+
+// ParseUnitFile parses the unit file at path.
+func ParseUnitFile(path string) (*types.UnitFile, error) {
+	return analyzer.ParseUnitFile(path)
+}
+
+// ParseUnit parses the content of a unit file. The unit's name and type are
+// taken from path, which does not need to exist.
+func ParseUnit(path, content string) (*types.UnitFile, error) {
+	return analyzer.ParseUnitFileContent(path, content)
+}
+
+// LoadUnits loads unit files and the units in directories, keyed by unit
+// name. A unit found in more than one path is taken from the last.
+func LoadUnits(ctx context.Context, paths ...string) (map[string]*types.UnitFile, error) {
+	a := analyzer.New(analyzer.Options{})
+	units := make(map[string]*types.UnitFile)
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		loaded, err := a.LoadFiles([]string{path})
+		if err != nil {
+			return nil, err
+		}
+		for name, unit := range loaded {
+			units[name] = unit
+		}
+	}
+	return units, nil
+}
+
+// LoadSystemUnits loads the units of a system from systemd's unit search
+// path, with /etc taking precedence over /run and /usr/lib as in systemd.
+// An empty root loads the units of the running system; otherwise the search
+// path is taken relative to root, the root directory of an offline image.
+func LoadSystemUnits(ctx context.Context, root string) (map[string]*types.UnitFile, error) {
+	units, err := analyzer.New(analyzer.Options{Root: root}).LoadUnits(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to load units: %w", err)
+	}
+	return units, nil
+}
+
+// UnitPaths returns systemd's unit search path, relative to root when it is
+// not empty.
+func UnitPaths(root string) []string {
+	paths := analyzer.DefaultUnitPaths()
+	if root != "" {
+		for i, p := range paths {
+			paths[i] = filepath.Join(root, p)
+		}
+	}
+	return paths
+}
+
+// RuntimeUnitPaths returns systemd's unit search path with the directories
+// of generated and transient units, relative to root when it is not empty.
+func RuntimeUnitPaths(root string) []string {
+	paths := analyzer.RuntimeUnitPaths()
+	if root != "" {
+		for i, p := range paths {
+			paths[i] = filepath.Join(root, p)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,159 @@
+package types
+
+import "sort"
+
+// ScanResult contains the results of a scan
+type ScanResult struct {
+	Units   []*UnitFile
+	Issues  []Issue
+	Summary Summary
+	// Scores are the audit scores of the units, lowest first
+	Scores []UnitScore
+}
+
+// Summary provides aggregate statistics
+type Summary struct {
+	TotalUnits   int
+	TotalIssues  int
+	BySeverity   map[Severity]int
+	ByCategory   map[Category]int
+	RulesChecked int
+	// RulesSkipped counts rules skipped because the target systemd is too old
+	RulesSkipped   int
+	SystemdVersion int
+	// Quick is set for quick scans
+	Quick bool
+	// SkippedAnalyses lists analysis classes that could not run in this scan.
+	// Runtime is only listed for quick scans and live scans that could not
+	// reach systemd, as other scans never read live state.
+	SkippedAnalyses []string
+	// FailedUnits counts scanned units in the failed state, when runtime state is available
+	FailedUnits int
+	// ParseErrors counts the parse errors in the scanned unit files, each
+	// also reported as a PARSE issue
+	ParseErrors int
+	// RulesRun lists the IDs of the rules that ran, sorted, whether or not
+	// they found anything
+	RulesRun []string
+	// Options are the filters the scan ran with
+	Options ScanOptions
+	// WorstUnits are the units with the most weighted issues, worst first
+	WorstUnits []UnitWeight
+	// ByRule and ByUnit count the issues of each rule ID and unit
+	ByRule map[string]int
+	ByUnit map[string]int
+	// ByCategorySeverity counts the issues of each category by severity
+	ByCategorySeverity map[Category]map[Severity]int
+	// Score is the mean of the units' scores, the host's audit score
+	Score int
+	// ScoreWeights are the weights the scores were computed with
+	ScoreWeights ScoreWeights
+}
+
+// Count is the number of issues of one rule or unit
+type Count struct {
+	Name  string
+	Count int
+}
+
+// TopCounts returns the n largest counts, largest first. Equal counts are
+// ordered by name, so the same counts give the same list.
+func TopCounts(counts map[string]int, n int) []Count {
+	top := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		top = append(top, Count{Name: name, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Name < top[j].Name
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// UnitWeight is the issues of a unit weighted by severity, which ranks the
+// units most in need of attention
+type UnitWeight struct {
+	Unit string
+	// Weight sums the Weight of the severities of the unit's issues
+	Weight int
+	Issues int
+}
+
+// ScanOptions records the filters a scan ran with, for reports
+type ScanOptions struct {
+	// MinSeverity and Category are nil and Tags empty when they select
+	// every rule
+	MinSeverity *Severity
+	Category    *Category
+	Tags        []string
+	// Profile names the bundled profile that selected the rules, if any
+	Profile string
+}
+
+// WorstUnitsMax is how many units Summary.WorstUnits lists
+const WorstUnitsMax = 5
+
+// CountIssues sets the issue counts of the summary, and its worst units, to
+// those of issues
+func (s *Summary) CountIssues(issues []Issue) {
+	s.TotalIssues = len(issues)
+	s.BySeverity = make(map[Severity]int)
+	s.ByCategory = make(map[Category]int)
+	s.ByRule = make(map[string]int)
+	s.ByUnit = make(map[string]int)
+	s.ByCategorySeverity = make(map[Category]map[Severity]int)
+	for _, issue := range issues {
+		s.BySeverity[issue.Severity]++
+		s.ByCategory[issue.Category]++
+		s.ByRule[issue.RuleID]++
+		s.ByUnit[issue.Unit]++
+		if s.ByCategorySeverity[issue.Category] == nil {
+			s.ByCategorySeverity[issue.Category] = make(map[Severity]int)
+		}
+		s.ByCategorySeverity[issue.Category][issue.Severity]++
+	}
+	s.WorstUnits = worstUnits(issues, WorstUnitsMax)
+}
+
+// worstUnits ranks the units of issues by their weighted issues and returns
+// the n worst. Units whose issues all weigh nothing, such as info findings,
+// are left out. Ties go to the unit with more issues, then by name, so the
+// ranking is the same for the same issues.
+func worstUnits(issues []Issue, n int) []UnitWeight {
+	byUnit := make(map[string]*UnitWeight)
+	for _, issue := range issues {
+		w := byUnit[issue.Unit]
+		if w == nil {
+			w = &UnitWeight{Unit: issue.Unit}
+			byUnit[issue.Unit] = w
+		}
+		w.Weight += issue.Severity.Weight()
+		w.Issues++
+	}
+
+	ranked := make([]UnitWeight, 0, len(byUnit))
+	for _, w := range byUnit {
+		if w.Weight > 0 {
+			ranked = append(ranked, *w)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+		if a.Issues != b.Issues {
+			return a.Issues > b.Issues
+		}
+		return a.Unit < b.Unit
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"math"
+	"sort"
+)
+
+// ScoreMax is the score of a unit without issues
+const ScoreMax = 100
+
+// ScoreWeights are the points an issue of each severity takes off the score
+// of its unit
+type ScoreWeights map[Severity]int
+
+// DefaultScoreWeights returns the weights used unless the configuration file
+// sets others: an issue takes 40 points when critical, 20 when high, 8 when
+// medium, 3 when low and none when info
+func DefaultScoreWeights() ScoreWeights {
+	return ScoreWeights{
+		SeverityCritical: 40,
+		SeverityHigh:     20,
+		SeverityMedium:   8,
+		SeverityLow:      3,
+		SeverityInfo:     0,
+	}
+}
+
+// UnitScore is the audit score of a unit, from 0 to ScoreMax
+type UnitScore struct {
+	Unit  string
+	Score int
+}
+
+// Score returns the score of a unit with issues: ScoreMax less the weight of
+// each issue, down to 0. Severities the weights leave out count nothing.
+func Score(issues []Issue, weights ScoreWeights) int {
+	score := ScoreMax
+	for _, issue := range issues {
+		score -= weights[issue.Severity]
+	}
+	return max(score, 0)
+}
+
+// Recount sets the summary's issue counts and the scores to those of the
+// result's issues, as after issues are left out
+func (r *ScanResult) Recount() {
+	r.Summary.CountIssues(r.Issues)
+	r.Rescore()
+}
+
+// Rescore scores each unit of the result, and the units its issues name,
+// with the summary's weights, or the default weights when it has none. The
+// scores are sorted lowest first, then by unit; the summary's score is their
+// mean, rounded, and ScoreMax for a result without units.
+func (r *ScanResult) Rescore() {
+	if r.Summary.ScoreWeights == nil {
+		r.Summary.ScoreWeights = DefaultScoreWeights()
+	}
+
+	byUnit := make(map[string][]Issue)
+	for _, unit := range r.Units {
+		byUnit[unit.Name] = nil
+	}
+	for _, issue := range r.Issues {
+		if issue.Unit != "" {
+			byUnit[issue.Unit] = append(byUnit[issue.Unit], issue)
+		}
+	}
+
+	r.Scores = make([]UnitScore, 0, len(byUnit))
+	total := 0
+	for unit, issues := range byUnit {
+		score := Score(issues, r.Summary.ScoreWeights)
+		r.Scores = append(r.Scores, UnitScore{Unit: unit, Score: score})
+		total += score
+	}
+	sort.Slice(r.Scores, func(i, j int) bool {
+		if r.Scores[i].Score != r.Scores[j].Score {
+			return r.Scores[i].Score < r.Scores[j].Score
+		}
+		return r.Scores[i].Unit < r.Scores[j].Unit
+	})
+
+	r.Summary.Score = ScoreMax
+	if len(r.Scores) > 0 {
+		r.Summary.Score = int(math.Round(float64(total) / float64(len(r.Scores))))
+	}
+}
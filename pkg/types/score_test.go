@@ -0,0 +1,89 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	weights := DefaultScoreWeights()
+	tests := []struct {
+		name       string
+		severities []Severity
+		want       int
+	}{
+		{"no issues", nil, 100},
+		{"one high", []Severity{SeverityHigh}, 80},
+		{"mixed", []Severity{SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}, 69},
+		{"floored at zero", []Severity{SeverityCritical, SeverityCritical, SeverityCritical}, 0},
+	}
+	for _, tt := range tests {
+		var issues []Issue
+		for _, sev := range tt.severities {
+			issues = append(issues, Issue{Severity: sev})
+		}
+		if got := Score(issues, weights); got != tt.want {
+			t.Errorf("%s: Score() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRescore(t *testing.T) {
+	result := &ScanResult{
+		Units: []*UnitFile{{Name: "clean.service"}, {Name: "b.service"}},
+		Issues: []Issue{
+			{RuleID: "SEC001", Severity: SeverityHigh, Unit: "b.service"},
+			{RuleID: "SEC002", Severity: SeverityMedium, Unit: "b.service"},
+			{RuleID: "SEC001", Severity: SeverityHigh, Unit: "a.service"},
+			{RuleID: "REL001", Severity: SeverityLow, Unit: "a.service"},
+			{RuleID: "PERF001", Severity: SeverityLow, Unit: "c.service"},
+		},
+	}
+	result.Rescore()
+
+	// Lowest first, units without issues included
+	want := []UnitScore{
+		{Unit: "b.service", Score: 72},
+		{Unit: "a.service", Score: 77},
+		{Unit: "c.service", Score: 97},
+		{Unit: "clean.service", Score: 100},
+	}
+	if !reflect.DeepEqual(result.Scores, want) {
+		t.Errorf("Scores = %v, want %v", result.Scores, want)
+	}
+	// (72 + 77 + 97 + 100) / 4 = 86.5
+	if result.Summary.Score != 87 {
+		t.Errorf("Summary.Score = %d, want 87", result.Summary.Score)
+	}
+
+	// Identical inputs score the same, whatever the order of the issues
+	again := &ScanResult{Units: result.Units, Issues: append([]Issue(nil), result.Issues...)}
+	again.Issues[0], again.Issues[4] = again.Issues[4], again.Issues[0]
+	again.Rescore()
+	if !reflect.DeepEqual(again.Scores, result.Scores) || again.Summary.Score != result.Summary.Score {
+		t.Errorf("rescoring reordered issues gave %v (%d), want %v (%d)",
+			again.Scores, again.Summary.Score, result.Scores, result.Summary.Score)
+	}
+}
+
+func TestRescoreWeights(t *testing.T) {
+	result := &ScanResult{
+		Issues:  []Issue{{Severity: SeverityHigh, Unit: "a.service"}, {Severity: SeverityInfo, Unit: "a.service"}},
+		Summary: Summary{ScoreWeights: ScoreWeights{SeverityHigh: 50, SeverityInfo: 5}},
+	}
+	result.Rescore()
+	if got := result.Scores[0].Score; got != 45 {
+		t.Errorf("score with overridden weights = %d, want 45", got)
+	}
+}
+
+func TestRescoreEmpty(t *testing.T) {
+	var result ScanResult
+	result.Rescore()
+	if result.Summary.Score != ScoreMax || len(result.Scores) != 0 {
+		t.Errorf("empty result scored %d with %v, want %d and no units", result.Summary.Score, result.Scores, ScoreMax)
+	}
+	if !reflect.DeepEqual(result.Summary.ScoreWeights, DefaultScoreWeights()) {
+		t.Errorf("ScoreWeights = %v, want the defaults", result.Summary.ScoreWeights)
+	}
+}
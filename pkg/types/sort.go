@@ -0,0 +1,115 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// IssueOrder is an order to list issues in
+type IssueOrder int
+
+const (
+	// OrderScan keeps the order the scan found the issues in
+	OrderScan IssueOrder = iota
+	// OrderSeverity lists the most severe issues first
+	OrderSeverity
+	// OrderUnit groups the issues of each unit, by unit name
+	OrderUnit
+	// OrderRule groups the issues of each rule, by rule ID
+	OrderRule
+	// OrderCategory groups the issues of each category
+	OrderCategory
+)
+
+// issueOrders are the orders in the sequence Next cycles through
+var issueOrders = []IssueOrder{OrderScan, OrderSeverity, OrderUnit, OrderRule, OrderCategory}
+
+func (o IssueOrder) String() string {
+	switch o {
+	case OrderScan:
+		return "scan"
+	case OrderSeverity:
+		return "severity"
+	case OrderUnit:
+		return "unit"
+	case OrderRule:
+		return "rule"
+	case OrderCategory:
+		return "category"
+	default:
+		return "unknown"
+	}
+}
+
+// Next returns the order after o: scan, severity, unit, rule, category and
+// scan again
+func (o IssueOrder) Next() IssueOrder {
+	for i, order := range issueOrders {
+		if order == o {
+			return issueOrders[(i+1)%len(issueOrders)]
+		}
+	}
+	return OrderScan
+}
+
+// SortIssues returns the issues in the given order, leaving the slice passed
+// in as it is. Issues that tie on the order's key are ordered by severity,
+// unit and rule, whichever the key is not, then by file, line and
+// description, so that the same issues always come out in the same order.
+// Issues that tie on all of them keep their scan order.
+func SortIssues(issues []Issue, order IssueOrder) []Issue {
+	sorted := make([]Issue, len(issues))
+	copy(sorted, issues)
+	if order == OrderScan {
+		return sorted
+	}
+
+	var keys []func(a, b *Issue) int
+	switch order {
+	case OrderSeverity:
+		keys = []func(a, b *Issue) int{bySeverity, byUnit, byRule}
+	case OrderUnit:
+		keys = []func(a, b *Issue) int{byUnit, bySeverity, byRule}
+	case OrderRule:
+		keys = []func(a, b *Issue) int{byRule, bySeverity, byUnit}
+	case OrderCategory:
+		keys = []func(a, b *Issue) int{byCategory, bySeverity, byUnit, byRule}
+	}
+	keys = append(keys, byFile, byLine, byDescription)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, key := range keys {
+			if c := key(&sorted[i], &sorted[j]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return sorted
+}
+
+// bySeverity puts the more severe issue first
+func bySeverity(a, b *Issue) int { return int(b.Severity) - int(a.Severity) }
+
+func byUnit(a, b *Issue) int { return strings.Compare(a.Unit, b.Unit) }
+
+func byRule(a, b *Issue) int { return strings.Compare(a.RuleID, b.RuleID) }
+
+func byFile(a, b *Issue) int { return strings.Compare(a.File, b.File) }
+
+func byDescription(a, b *Issue) int { return strings.Compare(a.Description, b.Description) }
+
+func byCategory(a, b *Issue) int { return int(a.Category) - int(b.Category) }
+
+// byLine puts issues without a line first, then by line
+func byLine(a, b *Issue) int {
+	switch {
+	case a.Line == nil && b.Line == nil:
+		return 0
+	case a.Line == nil:
+		return -1
+	case b.Line == nil:
+		return 1
+	}
+	return *a.Line - *b.Line
+}
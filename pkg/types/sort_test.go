@@ -0,0 +1,83 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func sortTestIssues() []Issue {
+	line := func(n int) *int { return &n }
+	return []Issue{
+		{RuleID: "REL001", Severity: SeverityMedium, Category: CategoryReliability, Unit: "b.service"},
+		{RuleID: "SEC002", Severity: SeverityHigh, Category: CategorySecurity, Unit: "a.service", Line: line(9)},
+		{RuleID: "BP001", Severity: SeverityLow, Category: CategoryBestPractice, Unit: "a.service"},
+		{RuleID: "SEC002", Severity: SeverityHigh, Category: CategorySecurity, Unit: "a.service", Line: line(3)},
+		{RuleID: "PERF001", Severity: SeverityCritical, Category: CategoryPerformance, Unit: "c.service"},
+	}
+}
+
+// describe lists issues as rule@unit:line
+func describe(issues []Issue) string {
+	var parts []string
+	for _, issue := range issues {
+		s := issue.RuleID + "@" + issue.Unit
+		if issue.Line != nil {
+			s += fmt.Sprintf(":%d", *issue.Line)
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestSortIssues(t *testing.T) {
+	tests := []struct {
+		order IssueOrder
+		want  string
+	}{
+		{OrderScan, "REL001@b.service SEC002@a.service:9 BP001@a.service SEC002@a.service:3 PERF001@c.service"},
+		{OrderSeverity, "PERF001@c.service SEC002@a.service:3 SEC002@a.service:9 REL001@b.service BP001@a.service"},
+		{OrderUnit, "SEC002@a.service:3 SEC002@a.service:9 BP001@a.service REL001@b.service PERF001@c.service"},
+		{OrderRule, "BP001@a.service PERF001@c.service REL001@b.service SEC002@a.service:3 SEC002@a.service:9"},
+		{OrderCategory, "SEC002@a.service:3 SEC002@a.service:9 PERF001@c.service REL001@b.service BP001@a.service"},
+	}
+
+	for _, tt := range tests {
+		issues := sortTestIssues()
+		if got := describe(SortIssues(issues, tt.order)); got != tt.want {
+			t.Errorf("SortIssues(%s) =\n  %s\nwant\n  %s", tt.order, got, tt.want)
+		}
+		if describe(issues) != describe(sortTestIssues()) {
+			t.Errorf("SortIssues(%s) reordered its argument", tt.order)
+		}
+	}
+}
+
+func TestSortIssuesTies(t *testing.T) {
+	line := func(n int) *int { return &n }
+	issues := []Issue{
+		{RuleID: "SEC019", Unit: "a.service", File: "/etc/systemd/system/a.service.d/b.conf", Description: "second drop-in"},
+		{RuleID: "SEC019", Unit: "a.service", File: "/etc/systemd/system/a.service", Line: line(4), Description: "z"},
+		{RuleID: "SEC019", Unit: "a.service", File: "/etc/systemd/system/a.service", Line: line(4), Description: "a"},
+		{RuleID: "SEC019", Unit: "a.service", File: "/etc/systemd/system/a.service.d/a.conf", Description: "first drop-in"},
+	}
+	var got []string
+	for _, issue := range SortIssues(issues, OrderSeverity) {
+		got = append(got, issue.Description)
+	}
+	if want := "a z first drop-in second drop-in"; strings.Join(got, " ") != want {
+		t.Errorf("SortIssues() = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestIssueOrderNext(t *testing.T) {
+	var got []string
+	order := OrderScan
+	for i := 0; i < 6; i++ {
+		order = order.Next()
+		got = append(got, order.String())
+	}
+	if want := "severity,unit,rule,category,scan,severity"; strings.Join(got, ",") != want {
+		t.Errorf("cycle = %s, want %s", strings.Join(got, ","), want)
+	}
+}
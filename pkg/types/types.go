@@ -1,5 +1,13 @@
 package types
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Severity represents the severity level of an issue
 type Severity int
 
@@ -28,6 +36,24 @@ func (s Severity) String() string {
 	}
 }
 
+// Weight is how much an issue of the severity counts when ranking units by
+// their issues: a critical issue counts as two high ones, and info issues
+// not at all
+func (s Severity) Weight() int {
+	switch s {
+	case SeverityCritical:
+		return 10
+	case SeverityHigh:
+		return 5
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
 // ParseSeverity converts a string to a Severity level
 func ParseSeverity(s string) Severity {
 	switch s {
@@ -100,6 +126,34 @@ type Issue struct {
 	Description string   `json:"description"`
 	Suggestion  string   `json:"suggestion"`
 	References  []string `json:"references"`
+	// Refs are the typed form of References, filled in by the rule runner
+	Refs []Reference `json:"refs,omitempty"`
+	// Origin names where the rule comes from when it is not built in, such
+	// as "plugin cmdb", filled in by the rule runner
+	Origin string `json:"origin,omitempty"`
+	// Column is the 1-based column on Line the issue starts at, nil when it
+	// covers the whole line
+	Column *int `json:"column,omitempty"`
+	// Directive and Section name the directive the issue is about, and Value
+	// the value the unit gives it, or the entry of a list such as After=,
+	// empty when unset. Expected is a value that resolves the issue, empty
+	// when there is no single one. Rules fill in what applies, for tools that
+	// act on issues; Description stays the account for people.
+	Directive string `json:"directive,omitempty"`
+	Value     string `json:"value,omitempty"`
+	Section   string `json:"section,omitempty"`
+	Expected  string `json:"expected,omitempty"`
+}
+
+// Fingerprint identifies an issue across scans. It covers the rule, the unit
+// and the section, directive and value the issue is about, but not the file,
+// line or description, so the issue keeps its fingerprint when lines above it
+// change, the tree is scanned under another --root or a count or duration in
+// its description changes.
+func (i Issue) Fingerprint() string {
+	key := strings.Join([]string{i.RuleID, i.Unit, i.Section, i.Directive, i.Value}, "\x00")
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:8])
 }
 
 // UnitFile represents a parsed systemd unit file
@@ -109,6 +163,68 @@ type UnitFile struct {
 	Type     string              // e.g., "service", "socket", "timer"
 	Sections map[string]*Section // e.g., "Unit", "Service", "Install"
 	Raw      string              // Raw file contents
+	Runtime  *RuntimeState       // Live state from the service manager, nil if not collected
+	// Masked is set for units linked to /dev/null or left empty, which systemd refuses to load
+	Masked bool
+	// Enabled is set for units linked from another unit's .wants/, .requires/
+	// or .upholds/ directory, as systemctl enable does
+	Enabled bool
+	// WantedBySymlinks are the paths of those links
+	WantedBySymlinks []string
+	// ShadowedPaths are the files of the same name in later unit search
+	// paths, such as the vendor file in /usr/lib under an override in /etc,
+	// which systemd ignores. The first is the one the unit overrides.
+	ShadowedPaths []string
+	// ParseErrors are the lines systemd would reject or misread, such as a
+	// directive outside any section; the rest of the file is still parsed
+	ParseErrors []ParseError
+	// DropIns are the .conf files of the unit's .d directories whose
+	// directives were added to the unit, in the order applied
+	DropIns []string
+	// Origin is UnitOriginGenerator or UnitOriginTransient for units systemd
+	// writes itself under /run/systemd, empty for unit files
+	Origin string
+}
+
+// Origins of units that are not unit files: units written by generators at
+// boot, such as the mounts of /etc/fstab, and transient units created at
+// runtime, such as with systemd-run. Changes to their files do not last.
+const (
+	UnitOriginGenerator = "generator"
+	UnitOriginTransient = "transient"
+)
+
+// StdinPath is the path of a unit read from standard input, which has no
+// file on the target
+const StdinPath = "<stdin>"
+
+// ParseRuleID is the rule ID of the issues reporting parse errors
+const ParseRuleID = "PARSE"
+
+// ParseError is a problem with one line of a unit file
+type ParseError struct {
+	Line    int
+	Message string
+	// File is the drop-in the line is in, empty for the unit file itself
+	File string
+}
+
+// RuntimeState holds the live state of a loaded unit as reported by systemctl
+type RuntimeState struct {
+	ActiveState    string // e.g., "active", "failed"
+	SubState       string // e.g., "running", "auto-restart"
+	Result         string // e.g., "success", "exit-code"
+	NRestarts      int    // Automatic restarts since the unit was last started manually
+	ExecMainStatus int    // Exit status of the last main process
+	// StartHistory holds start attempts this boot read from the journal, nil if not collected
+	StartHistory []time.Time
+	// RunDurations holds how long each finished run this boot lasted, read from the journal
+	RunDurations []time.Duration
+}
+
+// IsFailed reports whether the unit is in the failed state
+func (r *RuntimeState) IsFailed() bool {
+	return r != nil && r.ActiveState == "failed"
 }
 
 // Section represents a section in a unit file (e.g., [Service])
@@ -122,13 +238,17 @@ type Directive struct {
 	Key   string
 	Value string
 	Line  int
+	// File is the drop-in the directive was read from, empty for the unit
+	// file itself
+	File string
 }
 
-// GetDirective returns the first value for a directive, or empty string if not found
+// GetDirective returns the value in effect for a directive, the last one
+// assigned as in systemd, or empty string if not found
 func (u *UnitFile) GetDirective(section, key string) string {
 	if s, ok := u.Sections[section]; ok {
 		if directives, ok := s.Directives[key]; ok && len(directives) > 0 {
-			return directives[0].Value
+			return directives[len(directives)-1].Value
 		}
 	}
 	return ""
@@ -167,3 +287,116 @@ func (u *UnitFile) IsSocket() bool {
 func (u *UnitFile) IsTimer() bool {
 	return u.Type == "timer"
 }
+
+// TypeSection returns the section named after the unit's type, such as
+// "Service" or "Socket", which holds the type's own directives and, for
+// services, sockets, mounts and swaps, their execution environment
+func (u *UnitFile) TypeSection() string {
+	if u.Type == "" {
+		return ""
+	}
+	return strings.ToUpper(u.Type[:1]) + u.Type[1:]
+}
+
+// ReferenceKind describes what a reference points to
+type ReferenceKind string
+
+const (
+	ReferenceManPage  ReferenceKind = "manpage"
+	ReferenceURL      ReferenceKind = "url"
+	ReferenceAdvisory ReferenceKind = "advisory"
+	ReferenceRule     ReferenceKind = "rule"
+)
+
+// Reference is a typed pointer to documentation for an issue
+type Reference struct {
+	Kind    ReferenceKind `json:"kind"`
+	Title   string        `json:"title"`             // e.g., "systemd.exec(5)", "CVE-2021-33910" or "SEC001"
+	Locator string        `json:"locator,omitempty"` // e.g., "Sandboxing" or "PrivateTmp="
+	URL     string        `json:"url,omitempty"`
+}
+
+// manBaseURL is where the upstream systemd man pages are published
+const manBaseURL = "https://www.freedesktop.org/software/systemd/man/"
+
+// manSections maps systemd man pages to their manual section
+var manSections = map[string]int{
+	"systemctl":                1,
+	"systemd-analyze":          1,
+	"journalctl":               1,
+	"systemd.unit":             5,
+	"systemd.service":          5,
+	"systemd.socket":           5,
+	"systemd.timer":            5,
+	"systemd.mount":            5,
+	"systemd.path":             5,
+	"systemd.exec":             5,
+	"systemd.kill":             5,
+	"systemd.resource-control": 5,
+	"systemd-system.conf":      5,
+	"org.freedesktop.systemd1": 5,
+	"systemd.directives":       7,
+	"systemd.time":             7,
+	"systemd.special":          7,
+}
+
+// ManPage returns a reference to a systemd man page, optionally to a section
+// or directive within it
+func ManPage(page, locator string) Reference {
+	title := page
+	if section, ok := manSections[page]; ok {
+		title += "(" + strconv.Itoa(section) + ")"
+	}
+	url := manBaseURL + page + ".html"
+	if locator != "" {
+		url += "#" + locator
+	}
+	return Reference{Kind: ReferenceManPage, Title: title, Locator: locator, URL: url}
+}
+
+// RuleReference returns a reference to another sdaudit rule, such as the
+// findings a summary rule is made of
+func RuleReference(id string) Reference {
+	return Reference{Kind: ReferenceRule, Title: id}
+}
+
+// ParseReference converts a plain reference URL into a typed Reference.
+// Links to the upstream man pages become man page references; anything else
+// is kept as a URL.
+func ParseReference(url string) Reference {
+	if rest, ok := strings.CutPrefix(url, manBaseURL); ok {
+		page, anchor, _ := strings.Cut(rest, "#")
+		if page, ok := strings.CutSuffix(page, ".html"); ok && page != "" {
+			ref := ManPage(page, anchor)
+			ref.URL = url
+			return ref
+		}
+	}
+	return Reference{Kind: ReferenceURL, Title: url, URL: url}
+}
+
+// String returns the short form, e.g. "systemd.exec(5) §Sandboxing"
+func (r Reference) String() string {
+	switch r.Kind {
+	case ReferenceManPage, ReferenceAdvisory:
+		if r.Locator != "" {
+			return r.Title + " §" + r.Locator
+		}
+		return r.Title
+	case ReferenceRule:
+		return "sdaudit explain " + r.Title
+	default:
+		if r.URL != "" {
+			return r.URL
+		}
+		return r.Title
+	}
+}
+
+// Markdown renders the reference as a Markdown link
+func (r Reference) Markdown() string {
+	if r.URL == "" {
+		return r.String()
+	}
+	return "[" + r.String() + "](" + r.URL + ")"
+}
@@ -1,6 +1,9 @@
 package types
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestSeverityString(t *testing.T) {
 	tests := []struct {
@@ -87,6 +90,7 @@ func TestUnitFileGetDirective(t *testing.T) {
 				Directives: map[string][]Directive{
 					"ExecStart": {{Key: "ExecStart", Value: "/usr/bin/myapp", Line: 5}},
 					"User":      {{Key: "User", Value: "nobody", Line: 6}},
+					"Restart":   {{Key: "Restart", Value: "no", Line: 7}, {Key: "Restart", Value: "always", Line: 2, File: "override.conf"}},
 				},
 			},
 		},
@@ -99,6 +103,7 @@ func TestUnitFileGetDirective(t *testing.T) {
 	}{
 		{"Service", "ExecStart", "/usr/bin/myapp"},
 		{"Service", "User", "nobody"},
+		{"Service", "Restart", "always"},
 		{"Service", "NotFound", ""},
 		{"Unit", "Description", ""},
 	}
@@ -155,3 +160,168 @@ func TestUnitFileIsService(t *testing.T) {
 		t.Error("IsTimer should return true for timer type")
 	}
 }
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		url       string
+		wantKind  ReferenceKind
+		wantShort string
+	}{
+		{"https://www.freedesktop.org/software/systemd/man/systemd.exec.html#PrivateTmp=", ReferenceManPage, "systemd.exec(5) §PrivateTmp="},
+		{"https://www.freedesktop.org/software/systemd/man/systemctl.html", ReferenceManPage, "systemctl(1)"},
+		{"https://www.freedesktop.org/software/systemd/man/unknown-page.html", ReferenceManPage, "unknown-page"},
+		{"https://example.com/docs", ReferenceURL, "https://example.com/docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			ref := ParseReference(tt.url)
+			if ref.Kind != tt.wantKind {
+				t.Errorf("Kind = %q, want %q", ref.Kind, tt.wantKind)
+			}
+			if got := ref.String(); got != tt.wantShort {
+				t.Errorf("String() = %q, want %q", got, tt.wantShort)
+			}
+			if ref.URL != tt.url {
+				t.Errorf("URL = %q, want original URL kept", ref.URL)
+			}
+		})
+	}
+}
+
+func TestManPageReference(t *testing.T) {
+	ref := ManPage("systemd.exec", "Sandboxing")
+	if ref.URL != "https://www.freedesktop.org/software/systemd/man/systemd.exec.html#Sandboxing" {
+		t.Errorf("URL = %q", ref.URL)
+	}
+	if got := ref.Markdown(); got != "[systemd.exec(5) §Sandboxing]("+ref.URL+")" {
+		t.Errorf("Markdown() = %q", got)
+	}
+
+	advisory := Reference{Kind: ReferenceAdvisory, Title: "CVE-2021-33910"}
+	if got := advisory.Markdown(); got != "CVE-2021-33910" {
+		t.Errorf("Markdown() without URL = %q, want plain title", got)
+	}
+}
+
+func TestIssueFingerprint(t *testing.T) {
+	line := 3
+	issue := Issue{RuleID: "SEC001", Unit: "app.service", File: "/etc/systemd/system/app.service", Line: &line, Description: "NoNewPrivileges= is not set"}
+
+	moved := issue
+	other := 7
+	moved.Line, moved.File = &other, "/srv/root/etc/systemd/system/app.service"
+	if issue.Fingerprint() != moved.Fingerprint() {
+		t.Error("fingerprint changed with the file and line")
+	}
+
+	for _, changed := range []Issue{
+		{RuleID: "SEC002", Unit: issue.Unit, Description: issue.Description},
+		{RuleID: issue.RuleID, Unit: "db.service", Description: issue.Description},
+		{RuleID: issue.RuleID, Unit: issue.Unit, Description: issue.Description, Directive: "NoNewPrivileges"},
+	} {
+		if changed.Fingerprint() == issue.Fingerprint() {
+			t.Errorf("%+v has the fingerprint of %+v", changed, issue)
+		}
+	}
+	restarts := Issue{RuleID: "REL012", Unit: "app.service", Section: "Service", Directive: "Restart", Value: "always", Description: "Unit has restarted 6 times since boot."}
+	more := restarts
+	more.Description = "Unit has restarted 9 times since boot."
+	if restarts.Fingerprint() != more.Fingerprint() {
+		t.Error("fingerprint changed with the count in the description")
+	}
+	for _, changed := range []Issue{
+		{RuleID: restarts.RuleID, Unit: restarts.Unit, Section: "Unit", Directive: restarts.Directive, Value: restarts.Value},
+		{RuleID: restarts.RuleID, Unit: restarts.Unit, Section: restarts.Section, Directive: "RestartSec", Value: restarts.Value},
+		{RuleID: restarts.RuleID, Unit: restarts.Unit, Section: restarts.Section, Directive: restarts.Directive, Value: "on-failure"},
+	} {
+		if changed.Fingerprint() == restarts.Fingerprint() {
+			t.Errorf("%+v has the fingerprint of %+v", changed, restarts)
+		}
+	}
+
+	if got := len(issue.Fingerprint()); got != 16 {
+		t.Errorf("fingerprint has %d characters, want 16", got)
+	}
+}
+
+func TestSummaryCountIssues(t *testing.T) {
+	var issues []Issue
+	add := func(unit string, severities ...Severity) {
+		for _, sev := range severities {
+			issues = append(issues, Issue{Unit: unit, Severity: sev, Category: CategorySecurity})
+		}
+	}
+	add("a.service", SeverityCritical)                          // 10
+	add("b.service", SeverityHigh, SeverityHigh)                // 10, more issues
+	add("c.service", SeverityMedium, SeverityLow, SeverityInfo) // 3
+	add("d.service", SeverityInfo, SeverityInfo)                // 0, left out
+	add("e.service", SeverityLow, SeverityLow, SeverityLow)     // 3, tie with c
+	add("f.service", SeverityLow)                               // 1
+	add("g.service", SeverityLow)                               // 1, sixth
+
+	var s Summary
+	s.CountIssues(issues)
+	if s.TotalIssues != len(issues) || s.BySeverity[SeverityLow] != 6 || s.ByCategory[CategorySecurity] != len(issues) {
+		t.Errorf("counts = %d total, %v, %v", s.TotalIssues, s.BySeverity, s.ByCategory)
+	}
+
+	want := []UnitWeight{
+		{Unit: "b.service", Weight: 10, Issues: 2},
+		{Unit: "a.service", Weight: 10, Issues: 1},
+		{Unit: "c.service", Weight: 3, Issues: 3},
+		{Unit: "e.service", Weight: 3, Issues: 3},
+		{Unit: "f.service", Weight: 1, Issues: 1},
+	}
+	if len(s.WorstUnits) != len(want) {
+		t.Fatalf("WorstUnits = %+v, want %+v", s.WorstUnits, want)
+	}
+	for i := range want {
+		if s.WorstUnits[i] != want[i] {
+			t.Errorf("WorstUnits[%d] = %+v, want %+v", i, s.WorstUnits[i], want[i])
+		}
+	}
+}
+
+func TestSummaryCountIssuesByRuleAndUnit(t *testing.T) {
+	issues := []Issue{
+		{RuleID: "BP004", Unit: "a.service", Severity: SeverityLow, Category: CategoryBestPractice},
+		{RuleID: "BP004", Unit: "b.service", Severity: SeverityLow, Category: CategoryBestPractice},
+		{RuleID: "BP004", Unit: "c.service", Severity: SeverityLow, Category: CategoryBestPractice},
+		{RuleID: "SEC001", Unit: "a.service", Severity: SeverityHigh, Category: CategorySecurity},
+		{RuleID: "SEC002", Unit: "a.service", Severity: SeverityMedium, Category: CategorySecurity},
+	}
+	var s Summary
+	s.CountIssues(issues)
+
+	if want := map[string]int{"BP004": 3, "SEC001": 1, "SEC002": 1}; !reflect.DeepEqual(s.ByRule, want) {
+		t.Errorf("ByRule = %v, want %v", s.ByRule, want)
+	}
+	if want := map[string]int{"a.service": 3, "b.service": 1, "c.service": 1}; !reflect.DeepEqual(s.ByUnit, want) {
+		t.Errorf("ByUnit = %v, want %v", s.ByUnit, want)
+	}
+	wantMatrix := map[Category]map[Severity]int{
+		CategoryBestPractice: {SeverityLow: 3},
+		CategorySecurity:     {SeverityHigh: 1, SeverityMedium: 1},
+	}
+	if !reflect.DeepEqual(s.ByCategorySeverity, wantMatrix) {
+		t.Errorf("ByCategorySeverity = %v, want %v", s.ByCategorySeverity, wantMatrix)
+	}
+}
+
+func TestTopCounts(t *testing.T) {
+	counts := map[string]int{"SEC002": 1, "BP004": 3, "SEC001": 1, "REL001": 2, "PERF001": 1}
+	want := []Count{{"BP004", 3}, {"REL001", 2}, {"PERF001", 1}, {"SEC001", 1}}
+	// Map order varies from run to run; ties must not
+	for range 20 {
+		if got := TopCounts(counts, 4); !reflect.DeepEqual(got, want) {
+			t.Fatalf("TopCounts() = %v, want %v", got, want)
+		}
+	}
+	if got := TopCounts(counts, 10); len(got) != len(counts) {
+		t.Errorf("TopCounts(10) returned %d counts, want %d", len(got), len(counts))
+	}
+	if got := TopCounts(nil, 10); len(got) != 0 {
+		t.Errorf("TopCounts(nil) = %v", got)
+	}
+}